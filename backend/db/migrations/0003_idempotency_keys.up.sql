@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  id_key CHAR(64) PRIMARY KEY,
+  request_hash CHAR(64) NOT NULL,
+  status_code INT NOT NULL DEFAULT 0,
+  response_body MEDIUMBLOB NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_idempotency_created_at (created_at)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS backlog_items;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS backlog_items (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  title VARCHAR(255) NOT NULL,
+  priority VARCHAR(16) NOT NULL DEFAULT 'normal',
+  opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  due_at TIMESTAMP NOT NULL,
+  resolved_at TIMESTAMP NULL,
+  INDEX idx_backlog_open (resolved_at, opened_at)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS insight_metrics (
+  insight_id BIGINT NOT NULL,
+  metric_key VARCHAR(32) NOT NULL,
+  window_start TIMESTAMP NULL,
+  window_end TIMESTAMP NULL,
+  PRIMARY KEY (insight_id, metric_key),
+  INDEX idx_insight_metrics_key (metric_key),
+  CONSTRAINT fk_insight_metrics_insight FOREIGN KEY (insight_id) REFERENCES insights (id) ON DELETE CASCADE
+);
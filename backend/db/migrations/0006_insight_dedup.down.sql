@@ -0,0 +1,5 @@
+ALTER TABLE insights
+  DROP INDEX idx_insights_fingerprint,
+  DROP COLUMN last_seen_at,
+  DROP COLUMN repeat_count,
+  DROP COLUMN fingerprint;
@@ -0,0 +1,5 @@
+ALTER TABLE insights
+  ADD COLUMN fingerprint CHAR(64) NULL,
+  ADD COLUMN repeat_count INT NOT NULL DEFAULT 1,
+  ADD COLUMN last_seen_at TIMESTAMP NULL,
+  ADD INDEX idx_insights_fingerprint (fingerprint, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE insights
+  DROP INDEX idx_insights_locale_created_at,
+  DROP COLUMN locale;
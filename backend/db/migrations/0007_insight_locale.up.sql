@@ -0,0 +1,3 @@
+ALTER TABLE insights
+  ADD COLUMN locale VARCHAR(16) NOT NULL DEFAULT 'zh-CN',
+  ADD INDEX idx_insights_locale_created_at (locale, created_at);
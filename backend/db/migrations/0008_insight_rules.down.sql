@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS insight_rules;
+
+ALTER TABLE insights
+  DROP COLUMN severity;
@@ -0,0 +1,16 @@
+ALTER TABLE insights
+  ADD COLUMN severity VARCHAR(16) NOT NULL DEFAULT 'info';
+
+CREATE TABLE IF NOT EXISTS insight_rules (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(128) NOT NULL,
+  rule_condition VARCHAR(1024) NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  template TEXT NOT NULL,
+  severity VARCHAR(16) NOT NULL DEFAULT 'info',
+  locale VARCHAR(16) NOT NULL DEFAULT 'zh-CN',
+  enabled TINYINT(1) NOT NULL DEFAULT 1,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_insight_rules_name (name)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+  name VARCHAR(64) PRIMARY KEY,
+  schedule VARCHAR(128) NOT NULL,
+  enabled TINYINT(1) NOT NULL DEFAULT 1,
+  last_run_at TIMESTAMP NULL,
+  last_status VARCHAR(16) NOT NULL DEFAULT '',
+  last_error VARCHAR(1024) NOT NULL DEFAULT '',
+  last_duration_ms BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS jobs (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  kind VARCHAR(64) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'queued',
+  payload JSON NOT NULL,
+  result JSON NULL,
+  error TEXT NULL,
+  progress INT NOT NULL DEFAULT 0,
+  attempts INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  started_at TIMESTAMP NULL,
+  finished_at TIMESTAMP NULL,
+  INDEX idx_jobs_status_created (status, created_at)
+);
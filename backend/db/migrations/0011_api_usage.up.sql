@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_usage (
+  bucket_start TIMESTAMP NOT NULL,
+  tenant VARCHAR(64) NOT NULL,
+  api_key VARCHAR(64) NOT NULL,
+  requests BIGINT NOT NULL DEFAULT 0,
+  errors BIGINT NOT NULL DEFAULT 0,
+  bytes_in BIGINT NOT NULL DEFAULT 0,
+  bytes_out BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (bucket_start, tenant, api_key),
+  INDEX idx_api_usage_tenant (tenant, bucket_start)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+  DROP COLUMN totp_last_step,
+  DROP COLUMN totp_enabled,
+  DROP COLUMN totp_secret;
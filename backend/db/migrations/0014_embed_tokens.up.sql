@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS embed_tokens (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(128) NOT NULL,
+  token_hash CHAR(64) NOT NULL,
+  metrics VARCHAR(255) NOT NULL,
+  created_by BIGINT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP NULL,
+  UNIQUE KEY uk_embed_tokens_hash (token_hash)
+);
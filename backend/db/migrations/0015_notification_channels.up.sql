@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS notification_channels (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(128) NOT NULL,
+  kind VARCHAR(16) NOT NULL,
+  webhook_url VARCHAR(1024) NOT NULL,
+  secret VARCHAR(255) NOT NULL DEFAULT '',
+  event_types VARCHAR(255) NOT NULL DEFAULT 'insight.created',
+  min_severity VARCHAR(16) NOT NULL DEFAULT 'critical',
+  enabled TINYINT(1) NOT NULL DEFAULT 1,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_notification_channels_name (name)
+);
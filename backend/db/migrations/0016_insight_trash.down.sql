@@ -0,0 +1,3 @@
+ALTER TABLE insights
+  DROP INDEX idx_insights_deleted_at,
+  DROP COLUMN deleted_at;
@@ -0,0 +1,3 @@
+ALTER TABLE insights
+  ADD COLUMN deleted_at TIMESTAMP NULL,
+  ADD INDEX idx_insights_deleted_at (deleted_at);
@@ -0,0 +1,10 @@
+ALTER TABLE insights
+  DROP INDEX ft_insights_text;
+
+ALTER TABLE insights
+  ADD INDEX idx_insights_locale_created_at (locale, created_at),
+  DROP INDEX idx_insights_feed;
+
+ALTER TABLE metrics_snapshot
+  ADD INDEX idx_metrics_created_at (created_at),
+  DROP INDEX idx_metrics_created_desc;
@@ -0,0 +1,10 @@
+ALTER TABLE metrics_snapshot
+  ADD INDEX idx_metrics_created_desc (created_at DESC, revenue, growth, sentiment, backlog),
+  DROP INDEX idx_metrics_created_at;
+
+ALTER TABLE insights
+  ADD INDEX idx_insights_feed (locale, deleted_at, created_at DESC),
+  DROP INDEX idx_insights_locale_created_at;
+
+ALTER TABLE insights
+  ADD FULLTEXT INDEX ft_insights_text (title, message) WITH PARSER ngram;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS sync_cursors (
+  node VARCHAR(64) NOT NULL,
+  segment VARCHAR(32) NOT NULL,
+  acked INT NOT NULL DEFAULT 0,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (node, segment)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE notification_outbox DROP COLUMN silenced_by;
+DROP TABLE IF EXISTS alert_silences;
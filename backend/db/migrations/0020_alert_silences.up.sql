@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS alert_silences (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  kind VARCHAR(16) NOT NULL DEFAULT 'silence',
+  matchers JSON NOT NULL,
+  starts_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  ends_at TIMESTAMP NULL,
+  schedule VARCHAR(128) NOT NULL DEFAULT '',
+  duration VARCHAR(32) NOT NULL DEFAULT '',
+  comment VARCHAR(512) NOT NULL DEFAULT '',
+  created_by VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_alert_silences_ends (ends_at)
+);
+
+ALTER TABLE notification_outbox ADD COLUMN silenced_by BIGINT NULL;
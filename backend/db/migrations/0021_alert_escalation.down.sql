@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS alerts;
+ALTER TABLE insight_rules DROP COLUMN escalation;
@@ -0,0 +1,20 @@
+ALTER TABLE insight_rules ADD COLUMN escalation JSON NULL;
+
+CREATE TABLE IF NOT EXISTS alerts (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  rule_id BIGINT NOT NULL,
+  insight_id BIGINT NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  message TEXT NOT NULL,
+  severity VARCHAR(16) NOT NULL,
+  locale VARCHAR(16) NOT NULL,
+  step INT NOT NULL DEFAULT 0,
+  next_step_at TIMESTAMP NULL,
+  ack_token VARCHAR(64) NOT NULL,
+  acknowledged_at TIMESTAMP NULL,
+  acknowledged_by VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_alerts_ack_token (ack_token),
+  INDEX idx_alerts_rule_open (rule_id, acknowledged_at),
+  INDEX idx_alerts_next_step (next_step_at)
+);
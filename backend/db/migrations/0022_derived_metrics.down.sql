@@ -0,0 +1,2 @@
+ALTER TABLE metrics_snapshot DROP COLUMN derived;
+DROP TABLE IF EXISTS derived_metrics;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS derived_metrics (
+  name VARCHAR(64) PRIMARY KEY,
+  expression VARCHAR(512) NOT NULL,
+  mode VARCHAR(16) NOT NULL DEFAULT 'query',
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE metrics_snapshot ADD COLUMN derived JSON NULL;
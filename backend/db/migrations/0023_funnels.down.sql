@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS funnel_events;
+DROP TABLE IF EXISTS funnels;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS funnels (
+  name VARCHAR(64) PRIMARY KEY,
+  stages JSON NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS funnel_events (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  funnel VARCHAR(64) NOT NULL,
+  stage VARCHAR(64) NOT NULL,
+  subject VARCHAR(128) NOT NULL,
+  occurred_at TIMESTAMP(3) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_funnel_events_time (funnel, occurred_at),
+  INDEX idx_funnel_events_subject (funnel, subject)
+);
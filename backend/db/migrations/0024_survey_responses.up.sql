@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS survey_responses (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  score TINYINT UNSIGNED NOT NULL,
+  segment VARCHAR(64) NOT NULL DEFAULT '',
+  responded_at TIMESTAMP(3) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_survey_responses_time (responded_at)
+);
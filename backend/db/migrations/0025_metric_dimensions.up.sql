@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS metric_dimensions (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  metric VARCHAR(32) NOT NULL,
+  dimension VARCHAR(64) NOT NULL,
+  member VARCHAR(128) NOT NULL,
+  value DOUBLE NOT NULL,
+  recorded_at TIMESTAMP(3) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_metric_dimensions_lookup (metric, dimension, recorded_at)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS metric_targets (
+  metric VARCHAR(32) NOT NULL,
+  quarter CHAR(7) NOT NULL,
+  value DOUBLE NOT NULL,
+  direction VARCHAR(8) NOT NULL DEFAULT 'above',
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (metric, quarter)
+);
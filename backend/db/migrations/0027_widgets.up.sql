@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS widgets (
+  name VARCHAR(64) PRIMARY KEY,
+  definition JSON NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
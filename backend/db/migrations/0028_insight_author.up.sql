@@ -0,0 +1,2 @@
+ALTER TABLE insights
+  ADD COLUMN author VARCHAR(64) NULL;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS insight_tags (
+  insight_id BIGINT NOT NULL,
+  tag VARCHAR(32) NOT NULL,
+  PRIMARY KEY (insight_id, tag),
+  INDEX idx_insight_tags_tag (tag, insight_id),
+  CONSTRAINT fk_insight_tags_insight FOREIGN KEY (insight_id) REFERENCES insights (id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS insight_assignments (
+  insight_id BIGINT PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'open',
+  due_at TIMESTAMP NULL,
+  assigned_by VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  done_at TIMESTAMP NULL,
+  INDEX idx_insight_assignments_user (user_id, status, due_at),
+  CONSTRAINT fk_insight_assignments_insight FOREIGN KEY (insight_id) REFERENCES insights (id) ON DELETE CASCADE,
+  CONSTRAINT fk_insight_assignments_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE metrics_snapshot DROP COLUMN valid_until;
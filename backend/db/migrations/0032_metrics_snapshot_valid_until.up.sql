@@ -0,0 +1 @@
+ALTER TABLE metrics_snapshot ADD COLUMN valid_until TIMESTAMP NULL;
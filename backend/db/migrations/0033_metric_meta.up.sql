@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS metric_meta (
+  tenant VARCHAR(64) NOT NULL,
+  metric_key VARCHAR(64) NOT NULL,
+  meta JSON NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (tenant, metric_key)
+);
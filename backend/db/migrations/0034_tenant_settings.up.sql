@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS tenant_settings (
+  tenant VARCHAR(64) PRIMARY KEY,
+  settings JSON NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
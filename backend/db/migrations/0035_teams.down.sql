@@ -0,0 +1,6 @@
+ALTER TABLE insight_assignments DROP FOREIGN KEY fk_insight_assignments_team, DROP INDEX idx_insight_assignments_team, DROP COLUMN team_id;
+ALTER TABLE insight_rules DROP FOREIGN KEY fk_insight_rules_team, DROP COLUMN team_id;
+ALTER TABLE widgets DROP FOREIGN KEY fk_widgets_team, DROP COLUMN team_id;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS orgs;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS orgs (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_orgs_name (name)
+);
+
+CREATE TABLE IF NOT EXISTS teams (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  org_id BIGINT NOT NULL,
+  parent_id BIGINT NULL,
+  name VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_teams_org_name (org_id, name),
+  INDEX idx_teams_parent (parent_id),
+  CONSTRAINT fk_teams_org FOREIGN KEY (org_id) REFERENCES orgs (id)
+);
+
+CREATE TABLE IF NOT EXISTS team_members (
+  team_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  role VARCHAR(16) NOT NULL DEFAULT 'member',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (team_id, user_id),
+  INDEX idx_team_members_user (user_id),
+  CONSTRAINT fk_team_members_team FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE,
+  CONSTRAINT fk_team_members_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+ALTER TABLE widgets
+  ADD COLUMN team_id BIGINT NULL,
+  ADD CONSTRAINT fk_widgets_team FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE SET NULL;
+
+ALTER TABLE insight_rules
+  ADD COLUMN team_id BIGINT NULL,
+  ADD CONSTRAINT fk_insight_rules_team FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE SET NULL;
+
+ALTER TABLE insight_assignments
+  ADD COLUMN team_id BIGINT NULL,
+  ADD INDEX idx_insight_assignments_team (team_id, status, due_at),
+  ADD CONSTRAINT fk_insight_assignments_team FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE SET NULL;
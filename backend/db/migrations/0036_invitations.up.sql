@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS invitations (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  email VARCHAR(255) NOT NULL,
+  token_hash CHAR(64) NOT NULL,
+  role VARCHAR(16) NOT NULL DEFAULT 'viewer',
+  team_id BIGINT NULL,
+  team_role VARCHAR(16) NOT NULL DEFAULT 'member',
+  invited_by VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at TIMESTAMP NOT NULL,
+  accepted_at TIMESTAMP NULL,
+  user_id BIGINT NULL,
+  revoked_at TIMESTAMP NULL,
+  UNIQUE KEY uk_invitations_token (token_hash),
+  INDEX idx_invitations_email (email),
+  CONSTRAINT fk_invitations_team FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE SET NULL,
+  CONSTRAINT fk_invitations_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
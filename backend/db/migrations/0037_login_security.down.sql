@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS audit_log;
+ALTER TABLE users
+  DROP COLUMN locked_until,
+  DROP COLUMN failed_logins;
@@ -0,0 +1,16 @@
+ALTER TABLE users
+  ADD COLUMN failed_logins INT NOT NULL DEFAULT 0,
+  ADD COLUMN locked_until TIMESTAMP NULL;
+
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  action VARCHAR(64) NOT NULL,
+  actor VARCHAR(64) NOT NULL DEFAULT '',
+  user_id BIGINT NULL,
+  ip VARCHAR(64) NOT NULL DEFAULT '',
+  detail VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_audit_log_created (created_at),
+  INDEX idx_audit_log_action (action, created_at),
+  INDEX idx_audit_log_user (user_id, created_at)
+);
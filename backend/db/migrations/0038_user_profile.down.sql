@@ -0,0 +1,4 @@
+ALTER TABLE users
+  DROP COLUMN avatar_url,
+  DROP COLUMN timezone,
+  DROP COLUMN locale;
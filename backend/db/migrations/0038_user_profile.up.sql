@@ -0,0 +1,4 @@
+ALTER TABLE users
+  ADD COLUMN locale VARCHAR(16) NOT NULL DEFAULT '',
+  ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN avatar_url VARCHAR(512) NOT NULL DEFAULT '';
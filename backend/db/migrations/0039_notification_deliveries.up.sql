@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  event_id BIGINT NOT NULL,
+  target VARCHAR(512) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT NULL,
+  next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  delivered_at TIMESTAMP NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_deliveries_event_target (event_id, target),
+  INDEX idx_deliveries_status_next (status, next_attempt_at),
+  CONSTRAINT fk_deliveries_event FOREIGN KEY (event_id) REFERENCES notification_outbox (id) ON DELETE CASCADE
+);
@@ -2,62 +2,412 @@
 
 import (
   "context"
+  "crypto/rand"
+  "crypto/tls"
+  "crypto/x509"
   "database/sql"
+  "encoding/json"
+  "flag"
+  "fmt"
   "log"
+  "net"
   "net/http"
+  "net/netip"
   "os"
   "os/signal"
   "path/filepath"
   "strconv"
+  "strings"
   "syscall"
   "time"
+  _ "time/tzdata"
 
   "github.com/joho/godotenv"
-  _ "github.com/go-sql-driver/mysql"
+  "github.com/go-sql-driver/mysql"
 
   "mydashboard-backend/internal/ai"
+  "mydashboard-backend/internal/archive"
   "mydashboard-backend/internal/api"
+  "mydashboard-backend/internal/auth"
+  "mydashboard-backend/internal/collector"
+  "mydashboard-backend/internal/loadgen"
+  "mydashboard-backend/internal/models"
+  "mydashboard-backend/internal/notify"
+  "mydashboard-backend/internal/plugin"
+  "mydashboard-backend/internal/scheduler"
   "mydashboard-backend/internal/service"
   "mydashboard-backend/internal/store"
 )
 
 func main() {
+  if len(os.Args) > 1 && os.Args[1] == "loadgen" {
+    // Load generation only talks HTTP to the target, so it needs no store.
+    runLoadgen(os.Args[2:])
+    return
+  }
   loadEnv()
   cfg := loadConfig()
 //读取环境变量
+  repoStore, failover := openStore(cfg)
+
+  deepseekClient := ai.NewDeepSeekClient(cfg.deepseekBaseURL, cfg.deepseekAPIKey, cfg.deepseekModel).
+    WithLogger(log.New(os.Stdout, "deepseek ", log.LstdFlags))
+
+  var archiver *service.ArchiveService
+  if cfg.archiveBucket != "" {
+    bucket, err := archive.NewS3Client(archive.S3Config{
+      Endpoint:  cfg.archiveEndpoint,
+      Region:    cfg.archiveRegion,
+      Bucket:    cfg.archiveBucket,
+      AccessKey: cfg.archiveAccessKey,
+      SecretKey: cfg.archiveSecretKey,
+    })
+    if err != nil {
+      log.Fatalf("ARCHIVE_S3: %v", err)
+    }
+    archiver = service.NewArchiveService(repoStore, bucket, cfg.archivePrefix)
+  }
+  backups := service.NewBackupService(repoStore)
+  if len(os.Args) > 1 {
+    runCommand(os.Args[1:], archiver, backups)
+    return
+  }
+
+  var bot ai.AIChatBot = deepseekClient
   if cfg.deepseekAPIKey == "" {
-    log.Fatal("DEEPSEEK_API_KEY is required")
+    if cfg.storeBackend == "mysql" {
+      log.Fatal("DEEPSEEK_API_KEY is required")
+    }
+    log.Printf("warning: DEEPSEEK_API_KEY is not set, insights will not be generated")
+    bot = nil
   }
-  db, err := sql.Open("mysql", cfg.dsn)
+  if missing, err := repoStore.MissingIndexes(context.Background()); err != nil {
+    log.Printf("index check failed: %v", err)
+  } else {
+    for _, name := range missing {
+      log.Printf("warning: index %s is missing, apply backend/db/migrations", name)
+    }
+  }
+  metricBounds, err := service.ParseMetricBounds(cfg.metricBounds)
   if err != nil {
-    log.Fatalf("db open failed: %v", err)
+    log.Fatalf("METRIC_BOUNDS: %v", err)
+  }
+  insightIntervals, err := service.ParseInsightIntervals(cfg.insightMinIntervals)
+  if err != nil {
+    log.Fatalf("INSIGHT_MIN_INTERVAL: %v", err)
+  }
+  derivedMetrics := service.NewDerivedMetricService(repoStore)
+  simulatedSeries, err := service.ParseSimulatedSeries(cfg.simSeries)
+  if err != nil {
+    log.Fatalf("SIM_SERIES: %v", err)
+  }
+  simulation := service.NewSimulation()
+  if err := simulation.SetSeries(simulatedSeries); err != nil {
+    log.Fatalf("SIM_SERIES: %v", err)
+  }
+  metricsService := service.NewMetricsService(repoStore, simulation).
+    WithBatching(cfg.simBatchSize).
+    WithBackpressure(cfg.simBackpressure).
+    WithDedup(cfg.snapshotDedupWindow, cfg.snapshotDedupTolerance).
+    WithValidation(service.NewMetricValidator(metricBounds, cfg.metricBoundsFlag)).
+    WithDerived(derivedMetrics)
+  surveys := service.NewSurveyService(repoStore).WithSentimentWindow(cfg.npsWindow, cfg.npsMinResponses)
+  if cfg.npsSentiment {
+    metricsService.WithSurveySentiment(surveys)
+  }
+  escalations := service.NewEscalationService(repoStore, cfg.publicURL)
+  insightsService := service.NewInsightsService(repoStore, bot).
+    WithEscalation(escalations).
+    WithDedupWindow(cfg.insightDedupWindow).
+    WithTrashRetention(cfg.insightTrashRetention).
+    WithLocales(cfg.insightLocales).
+    WithMinIntervals(insightIntervals)
+  if cfg.insightActiveHours != "" {
+    hours, err := scheduler.Parse(cfg.insightActiveHours)
+    if err != nil {
+      log.Fatalf("INSIGHT_ACTIVE_HOURS: %v", err)
+    }
+    insightsService.WithActiveHours(hours, cfg.timezone)
+  }
+  notifications := service.NewNotificationService(repoStore).WithDashboardURL(cfg.dashboardURL)
+  preferences := service.NewNotificationPreferenceService(repoStore)
+  invitations := service.NewInvitationService(repoStore).WithTTL(cfg.invitationTTL).WithDashboardURL(cfg.dashboardURL)
+  if cfg.slackBotToken != "" {
+    preferences.WithSender(models.DeliverySlack, notify.NewSlackDMSender(cfg.slackBotToken))
+  }
+  notifiers := []notify.Notifier{notifications, preferences}
+  if cfg.emailFrom != "" && (cfg.emailSMTPHost != "" || cfg.emailDryRun) {
+    email, err := notify.NewEmailNotifier(notify.EmailConfig{
+      Host:         cfg.emailSMTPHost,
+      Port:         cfg.emailSMTPPort,
+      Username:     cfg.emailSMTPUsername,
+      Password:     cfg.emailSMTPPassword,
+      From:         cfg.emailFrom,
+      ReplyTo:      cfg.emailReplyTo,
+      Environment:  cfg.emailEnvironment,
+      DashboardURL: cfg.dashboardURL,
+      DryRun:       cfg.emailDryRun,
+      Recipients:   cfg.emailTo,
+      Events:       cfg.emailEvents,
+    })
+    if err != nil {
+      log.Fatalf("email: %v", err)
+    }
+    preferences.WithSender(models.DeliveryEmail, email)
+    invitations.WithSender(email)
+    if len(cfg.emailTo) > 0 {
+      notifiers = append(notifiers, email)
+    }
   }
-  db.SetConnMaxLifetime(5 * time.Minute)
-  db.SetMaxOpenConns(10)
-  db.SetMaxIdleConns(5)
+  for _, url := range cfg.webhookURLs {
+    notifiers = append(notifiers, notify.NewWebhookNotifier(url))
+  }
+  var plugins plugin.Plugins
+  if cfg.pluginsFile != "" {
+    if plugins, err = plugin.Load(cfg.pluginsFile); err != nil {
+      log.Fatalf("PLUGINS_FILE: %v", err)
+    }
+    notifiers = append(notifiers, plugins.Notifiers...)
+  }
+  silences := service.NewSilenceService(repoStore).WithLocation(cfg.timezone)
+  dispatcher := service.NewOutboxDispatcher(repoStore, notifiers...).WithSilences(silences)
 
-  if err := db.Ping(); err != nil {
-    log.Fatalf("db ping failed: %v", err)
+  jobQueue := service.NewJobQueue(repoStore, cfg.jobTimeout).
+    Handle(models.JobKindMetricsImport, metricsService.ImportJob).
+    Handle(models.JobKindInsightsGenerate, insightsService.GenerateRangeJob).
+    Handle(models.JobKindMetricsRecompute, metricsService.RecomputeJob)
+
+  usageService := service.NewUsageService(repoStore)
+  authSecret := []byte(cfg.authSecret)
+  if len(authSecret) == 0 {
+    log.Printf("AUTH_SECRET not set: using a random signing key, sessions will not survive a restart")
+    authSecret = make([]byte, 32)
+    if _, err := rand.Read(authSecret); err != nil {
+      log.Fatalf("auth secret: %v", err)
+    }
   }
+  passwordPolicy := service.NewPasswordPolicy(cfg.passwordMinLength, cfg.passwordMinClasses)
+  if cfg.passwordBreachList != "" {
+    n, err := passwordPolicy.LoadBreachList(cfg.passwordBreachList)
+    if err != nil {
+      log.Fatalf("PASSWORD_BREACH_LIST: %v", err)
+    }
+    log.Printf("loaded %d breached passwords from %s", n, cfg.passwordBreachList)
+  }
+  auditService := service.NewAuditService(repoStore)
+  authService := service.NewAuthService(repoStore, auth.NewSigner(authSecret), cfg.accessTokenTTL, cfg.refreshTokenTTL).
+    WithTOTPPolicy(cfg.totpIssuer, cfg.totpRequiredRoles).
+    WithPasswordPolicy(passwordPolicy).
+    WithAudit(auditService).
+    WithLockout(cfg.loginMaxFailures, cfg.loginLockout).
+    WithClientThrottle(cfg.loginClientMaxFailures, cfg.loginClientWindow)
+  invitations.WithPasswordPolicy(passwordPolicy).WithAudit(auditService)
 
-  deepseekClient := ai.NewDeepSeekClient(cfg.deepseekBaseURL, cfg.deepseekAPIKey, cfg.deepseekModel).
-    WithLogger(log.New(os.Stdout, "deepseek ", log.LstdFlags))
+  redactionRules, err := service.ParseRedaction(cfg.metricRedaction)
+  if err != nil {
+    log.Fatalf("METRIC_REDACTION: %v", err)
+  }
 
-  repoStore := store.New(db)
-  metricsService := service.NewMetricsService(repoStore, service.NewSimulation())
-  insightsService := service.NewInsightsService(repoStore, deepseekClient)
-  apiServer := api.NewServer(metricsService, insightsService)
+  ipFilter := api.IPFilterConfig{
+    Allow: mustParsePrefixes("IP_ALLOWLIST", cfg.ipAllow),
+    Deny:  mustParsePrefixes("IP_DENYLIST", cfg.ipDeny),
+  }
+  slack := api.SlackConfig{
+    SigningSecret: cfg.slackSigningSecret,
+    PublicURL:     cfg.publicURL,
+    Locale:        cfg.slackLocale,
+  }
+
+  metricUnits := service.DefaultMetricDefinitions()
+  if err := service.ParseMetricUnits(cfg.metricUnits, metricUnits); err != nil {
+    log.Fatalf("METRIC_UNITS: %v", err)
+  }
+  defaultPrecision, err := service.ParseMetricPrecision(cfg.metricPrecision, metricUnits)
+  if err != nil {
+    log.Fatalf("METRIC_PRECISION: %v", err)
+  }
+  fxRates, err := service.ParseFXRates(cfg.fxRates)
+  if err != nil {
+    log.Fatalf("FX_RATES: %v", err)
+  }
+  fx := service.NewFXRates(cfg.fxBase, fxRates).WithSource(cfg.fxRatesURL)
+
+  health := service.NewHealthService().
+    Register("database", repoStore.Ping)
+
+  collectors := collector.NewManager(metricsService.Record)
+  if cfg.collectFile != "" {
+    mustCollect(collectors, collector.NewFileCollector("file", cfg.collectFile, cfg.collectFileEvery))
+  }
+  for _, c := range plugins.Collectors {
+    mustCollect(collectors, c)
+  }
+  for _, status := range collectors.Statuses() {
+    health.Register("collector:"+status.Name, collectors.Check(status.Name))
+  }
+
+  overview := service.NewOverviewService(repoStore, metricsService, insightsService, cfg.overviewTTL)
+
+  jobs := scheduler.New(repoStore).WithLocation(cfg.timezone)
+  mustRegister(jobs, "refresh-overview", every(cfg.overviewRefreshEvery), overview.Refresh)
+  mustRegister(jobs, "health-check", every(cfg.healthCheckEvery), health.Run)
+  if cfg.fxRatesURL != "" {
+    mustRegister(jobs, "refresh-fx", every(cfg.fxRefreshEvery), fx.Refresh)
+  }
+  if cfg.npsSentiment {
+    mustRegister(jobs, "refresh-nps", every(cfg.npsRefreshEvery), surveys.Refresh)
+  }
+  mustRegister(jobs, "flush-usage", every(cfg.usageFlushEvery), usageService.Flush)
+  mustRegister(jobs, "dispatch-outbox", every(cfg.outboxEvery), dispatcher.DispatchPending)
+  mustRegister(jobs, "escalate-alerts", every(cfg.escalationEvery), escalations.Run)
+  if cfg.summarySchedule != "" {
+    mustRegister(jobs, "daily-summary", cfg.summarySchedule, metricsService.PublishDailySummary)
+  }
+  if cfg.insightDigestSchedule != "" {
+    mustRegister(jobs, "generate-digest", cfg.insightDigestSchedule, insightsService.GenerateDigest)
+  }
+  mustRegister(jobs, "purge-insights", cfg.insightPurgeSchedule, insightsService.PurgeTrash)
+  mustRegister(jobs, "process-jobs", every(cfg.jobPollEvery), jobQueue.Work)
+  if cfg.storeBackend == "file" || (cfg.storeBackend == "memory" && cfg.memorySnapshotFile != "") {
+    mustRegister(jobs, "memory-snapshot", every(cfg.memorySnapshotEvery), func(context.Context) error {
+      return repoStore.SaveSnapshot()
+    })
+  }
+  if cfg.storeBackend == "file" && cfg.edgeSyncURL != "" {
+    edgeSync := service.NewEdgeSync(repoStore, cfg.edgeSyncURL, cfg.edgeSyncToken, cfg.edgeSyncNode)
+    mustRegister(jobs, "edge-sync", every(cfg.edgeSyncEvery), edgeSync.Run)
+  }
+  if cfg.enableSimulation {
+    mustRegister(jobs, "simulate-metrics", every(cfg.metricsEvery), metricsService.SimulateTick)
+    if bot != nil && !cfg.insightDigestOnly {
+      mustRegister(jobs, "generate-insights", every(cfg.insightsEvery), insightsService.GenerateLatest)
+    }
+    if cfg.simBatchSize > 1 {
+      mustRegister(jobs, "flush-metrics", every(cfg.simFlushEvery), metricsService.FlushPending)
+    }
+  }
+  if cfg.metricsRetention > 0 {
+    prune := metricsService.PruneBefore
+    if archiver != nil {
+      prune = archiver.PruneBefore
+    }
+    mustRegister(jobs, "prune-metrics", cfg.pruneSchedule, func(ctx context.Context) error {
+      deleted, err := prune(ctx, time.Now().Add(-cfg.metricsRetention))
+      if deleted > 0 {
+        log.Printf("pruned %d metric snapshots", deleted)
+      }
+      return err
+    })
+  }
+
+  catalog := service.NewMetricCatalog(metricUnits, fx).WithDefaultPrecision(defaultPrecision)
+  apiServer := api.NewServer(metricsService, insightsService).
+    WithIdempotency(service.NewIdempotencyService(repoStore, cfg.idempotencyTTL)).
+    WithBacklog(service.NewBacklogService(repoStore, cfg.backlogSLA)).
+    WithSync(service.NewSyncService(repoStore), cfg.syncToken).
+    WithAdminToken(cfg.adminToken).
+    WithScheduler(jobs).
+    WithJobs(jobQueue).
+    WithDBStats(repoStore.Stats).
+    WithUsage(usageService).
+    WithAuth(authService).
+    WithRedaction(service.NewRedactor(redactionRules, cfg.redactionExempt)).
+    WithIPFilter(ipFilter).
+    WithTrustedProxies(mustParsePrefixes("TRUSTED_PROXIES", cfg.trustedProxies)).
+    WithHealth(health).
+    WithCatalog(catalog).
+    WithLocation(cfg.timezone).
+    WithEmbeds(service.NewEmbedService(repoStore)).
+    WithAuthRequired(cfg.authRequired).
+    WithSlack(slack).
+    WithNotifications(notifications).
+    WithNotificationPreferences(preferences).
+    WithSilences(silences).
+    WithDerivedMetrics(derivedMetrics).
+    WithFunnels(service.NewFunnelService(repoStore).WithLocation(cfg.timezone)).
+    WithSurveys(surveys).
+    WithDimensions(service.NewDimensionService(repoStore)).
+    WithTargets(service.NewTargetService(repoStore).WithLocation(cfg.timezone)).
+    WithWidgets(service.NewWidgetService(repoStore, metricsService)).
+    WithTeams(service.NewTeamService(repoStore)).
+    WithInvitations(invitations).
+    WithAudit(auditService).
+    WithMetricMeta(service.NewMetricMetaService(repoStore, metricsService).WithCatalog(catalog)).
+    WithTenantSettings(service.NewTenantSettingsService(repoStore).WithLocation(cfg.timezone).WithCatalog(catalog)).
+    WithOverview(overview).
+    WithAdHocQueries(service.NewAdHocQueryService(repoStore, cfg.adHocMaxRows, cfg.adHocTimeout), cfg.adHocRoles).
+    WithSimulation(simulation).
+    WithEscalations(escalations).
+    WithDashboardURL(cfg.dashboardURL).
+    WithCalendarJobs(cfg.calendarJobs).
+    WithBackups(backups).
+    WithCollectors(collectors)
+  if cfg.recordSample > 0 || cfg.recordErrors {
+    apiServer.WithRequestRecorder(api.NewRequestRecorder(cfg.recordSize, cfg.recordSample, cfg.recordErrors, cfg.recordBodyLimit))
+  }
+  if cfg.cacheControl {
+    cacheRules, err := api.ParseCacheRules(cfg.cacheRules)
+    if err != nil {
+      log.Fatalf("CACHE_CONTROL_RULES: %v", err)
+    }
+    apiServer.WithCachePolicy(api.CachePolicy{
+      Rules:           cacheRules,
+      Historical:      cfg.cacheHistorical,
+      HistoricalAfter: cfg.cacheHistoricalAfter,
+    })
+  }
+  if cfg.chaosAllowed {
+    apiServer.WithChaos(api.NewChaos())
+  }
+  if cfg.contractSpec != "" {
+    contract, err := api.LoadContract(cfg.contractSpec)
+    if err != nil {
+      log.Fatalf("CONTRACT_SPEC: %v", err)
+    }
+    log.Printf("warning: validating responses against %s, do not enable in production", cfg.contractSpec)
+    apiServer.WithContract(contract)
+  }
+  cors := api.CORSConfig{
+    AllowedOrigins:   cfg.allowedOrigins,
+    AllowedMethods:   cfg.corsMethods,
+    AllowedHeaders:   cfg.corsHeaders,
+    AllowCredentials: cfg.corsCredentials,
+    MaxAge:           cfg.corsMaxAge,
+  }
+  if err := cors.Validate(); err != nil {
+    log.Fatalf("CORS_ALLOW_CREDENTIALS: %v; set ALLOWED_ORIGINS", err)
+  }
   httpServer := &http.Server{
     Addr:              cfg.addr,
-    Handler:           apiServer.Routes(cfg.allowedOrigins),
+    Handler:           apiServer.Routes(cors),
     ReadHeaderTimeout: 5 * time.Second,
   }
 
   ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
   defer stop()//不知道怎么停下来的
 
-  if cfg.enableSimulation {
-    go apiServer.StartSimulation(ctx, cfg.metricsEvery, cfg.insightsEvery)
+  go jobs.Start(ctx)
+  collectorsDone := make(chan struct{})
+  go func() {
+    collectors.Run(ctx)
+    close(collectorsDone)
+  }()
+  if failover != nil && len(cfg.dsns) > 1 {
+    go failover.Monitor(ctx, cfg.dbFailoverCheck)
+  }
+  if cfg.fxRatesURL != "" {
+    go func() {
+      if err := fx.Refresh(ctx); err != nil {
+        log.Printf("initial exchange rate refresh failed: %v", err)
+      }
+    }()
+  }
+  if cfg.npsSentiment {
+    go func() {
+      if err := surveys.Refresh(ctx); err != nil {
+        log.Printf("initial NPS refresh failed: %v", err)
+      }
+    }()
   }
 
   go func() {
@@ -73,18 +423,340 @@ func main() {
   if err := httpServer.Shutdown(shutdownCtx); err != nil {
     log.Printf("shutdown error: %v", err)
   }
+  select {
+  case <-collectorsDone:
+  case <-shutdownCtx.Done():
+  }
+  if err := metricsService.FlushPending(shutdownCtx); err != nil {
+    log.Printf("final flush failed: %v", err)
+  }
+  if err := usageService.Flush(shutdownCtx); err != nil {
+    log.Printf("usage flush failed: %v", err)
+  }
+  if err := repoStore.SaveSnapshot(); err != nil {
+    log.Printf("memory snapshot failed: %v", err)
+  }
+  _ = repoStore.Close()
+}
+
+// runCommand runs a one-off maintenance command instead of the API server:
+//
+//	server archive list [prefix]
+//	server archive restore <prefix>
+//	server backup [file|-]
+//	server restore <file>
+func runCommand(args []string, archiver *service.ArchiveService, backups *service.BackupService) {
+  ctx := context.Background()
+  switch {
+  case args[0] == "backup":
+    name := "mydashboard-backup-" + time.Now().UTC().Format("20060102T150405Z") + ".jsonl.gz"
+    if len(args) > 1 {
+      name = args[1]
+    }
+    out := os.Stdout
+    if name != "-" {
+      file, err := os.Create(name)
+      if err != nil {
+        log.Fatalf("backup: %v", err)
+      }
+      defer file.Close()
+      out = file
+    }
+    report, err := backups.Write(ctx, out)
+    if err != nil {
+      log.Fatalf("backup: %v", err)
+    }
+    log.Printf("backup written to %s: %v", name, report.Rows)
+  case args[0] == "restore" && len(args) == 2:
+    file, err := os.Open(args[1])
+    if err != nil {
+      log.Fatalf("restore: %v", err)
+    }
+    defer file.Close()
+    report, err := backups.Restore(ctx, file)
+    if err != nil {
+      log.Fatalf("restore: %v", err)
+    }
+    log.Printf("restored backup from %s: %v", report.CreatedAt.Format(time.RFC3339), report.Rows)
+    if len(report.Skipped) > 0 {
+      log.Printf("skipped rows naming users missing here: %v", report.Skipped)
+    }
+  case len(args) >= 2 && args[0] == "archive" && (args[1] == "list" || args[1] == "restore"):
+    if archiver == nil {
+      log.Fatal("archive: set ARCHIVE_S3_BUCKET, ARCHIVE_S3_ACCESS_KEY and ARCHIVE_S3_SECRET_KEY")
+    }
+    prefix := ""
+    if len(args) > 2 {
+      prefix = args[2]
+    }
+    if args[1] == "list" {
+      objects, err := archiver.List(ctx, prefix)
+      if err != nil {
+        log.Fatalf("archive list: %v", err)
+      }
+      for _, object := range objects {
+        fmt.Printf("%s\t%d\t%s\n", object.Key, object.Size, object.LastModified.Format(time.RFC3339))
+      }
+      return
+    }
+    if len(args) < 3 {
+      log.Fatal("archive restore needs a prefix such as 2024/03, or / for everything")
+    }
+    result, err := archiver.Restore(ctx, prefix)
+    log.Printf("restored %d snapshots from %d objects, %d already present", result.Restored, result.Objects, result.Skipped)
+    if err != nil {
+      log.Fatalf("archive restore: %v", err)
+    }
+  default:
+    log.Fatalf("unknown command %q; usage: server backup [file|-] | server restore <file> | server archive list [prefix] | server archive restore <prefix> | server loadgen [flags]", strings.Join(args, " "))
+  }
+}
+
+// runLoadgen runs "server loadgen [flags]" against a running instance and
+// prints latency percentiles per request.
+func runLoadgen(args []string) {
+  flags := flag.NewFlagSet("loadgen", flag.ExitOnError)
+  target := flags.String("target", "http://localhost:8080", "base URL of the instance")
+  token := flags.String("token", "", "bearer token sent with every request")
+  duration := flags.Duration("duration", 30*time.Second, "how long to run")
+  concurrency := flags.Int("concurrency", 16, "parallel workers")
+  rate := flags.Int("rate", 0, "requests per second across workers, 0 for unthrottled")
+  writeRatio := flags.Float64("write-ratio", 0.1, "share of requests that ingest a snapshot")
+  reads := flags.String("reads", strings.Join(loadgen.DefaultReads, ","), "comma-separated read paths")
+  timeout := flags.Duration("timeout", 10*time.Second, "per-request timeout")
+  asJSON := flags.Bool("json", false, "print the report as JSON")
+  flags.Parse(args)
+
+  ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+  defer stop()
+  report, err := loadgen.Run(ctx, loadgen.Config{
+    Target:      *target,
+    Token:       *token,
+    Duration:    *duration,
+    Concurrency: *concurrency,
+    Rate:        *rate,
+    WriteRatio:  *writeRatio,
+    Reads:       splitList(*reads),
+    Timeout:     *timeout,
+  })
+  if err != nil {
+    log.Fatal(err)
+  }
+  if *asJSON {
+    json.NewEncoder(os.Stdout).Encode(report)
+    return
+  }
+  fmt.Printf("%d requests in %s (%.1f/s), %d errors\n", report.Requests, report.Duration.Round(time.Millisecond), report.Throughput, report.Errors)
+  fmt.Printf("%-44s %8s %7s %9s %9s %9s %9s  %s\n", "request", "count", "errors", "p50 ms", "p90 ms", "p99 ms", "max ms", "statuses")
+  for _, result := range report.Results {
+    fmt.Printf("%-44s %8d %7d %9.2f %9.2f %9.2f %9.2f  %v\n", result.Path, result.Requests, result.Errors, result.P50, result.P90, result.P99, result.Max, result.Statuses)
+  }
+}
+
+func mustRegister(jobs *scheduler.Scheduler, name, spec string, fn scheduler.Func) {
+  if err := jobs.Register(name, spec, fn); err != nil {
+    log.Fatalf("scheduler: %v", err)
+  }
+}
+
+// mustCollect registers a collector. Teams add their own sources here.
+func mustCollect(collectors *collector.Manager, c collector.Collector) {
+  if err := collectors.Register(c); err != nil {
+    log.Fatalf("collectors: %v", err)
+  }
+}
+
+func mustParsePrefixes(name string, values []string) []netip.Prefix {
+  prefixes, err := api.ParsePrefixes(values)
+  if err != nil {
+    log.Fatalf("%s: %v", name, err)
+  }
+  return prefixes
+}
+
+// openStore connects to MySQL, or with STORE=memory or STORE=file returns a
+// local store and no failover.
+func openStore(cfg config) (*store.Store, *store.Failover) {
+  if cfg.storeBackend == "file" {
+    repoStore, err := store.NewFile(cfg.fileStoreDir)
+    if err != nil {
+      log.Fatalf("file store: %v", err)
+    }
+    log.Printf("using the file store in %s", cfg.fileStoreDir)
+    return repoStore.WithSlowQueryLog(cfg.slowQuery), nil
+  }
+  if cfg.storeBackend == "memory" {
+    repoStore, err := store.NewMemory(cfg.memorySnapshotFile)
+    if err != nil {
+      log.Fatalf("memory store: %v", err)
+    }
+    log.Printf("using the in-memory store; data is lost on exit unless MEMORY_SNAPSHOT_FILE is set")
+    return repoStore.WithSlowQueryLog(cfg.slowQuery), nil
+  }
+  failover, err := store.NewFailover(cfg.dsns)
+  if err != nil {
+    log.Fatalf("db open failed: %v", err)
+  }
+  db := sql.OpenDB(failover)
+  db.SetConnMaxLifetime(cfg.dbConnMaxLifetime)
+  db.SetConnMaxIdleTime(cfg.dbConnMaxIdleTime)
+  db.SetMaxOpenConns(cfg.dbMaxOpenConns)
+  db.SetMaxIdleConns(cfg.dbMaxIdleConns)
+
+  if err := db.Ping(); err != nil {
+    log.Fatalf("db ping failed: %v", err)
+  }
+  repoStore := store.New(db).
+    WithQueryTimeout(cfg.queryTimeout).
+    WithBreaker(store.NewBreaker(cfg.breakerThreshold, cfg.breakerCooldown)).
+    WithSlowQueryLog(cfg.slowQuery).
+    WithFailover(failover)
+  return repoStore, failover
+}
+
+func every(interval time.Duration) string {
+  return "@every " + interval.String()
 }
 
 type config struct {
-  addr             string
-  dsn              string
-  allowedOrigins   string
-  enableSimulation bool
-  metricsEvery     time.Duration
-  insightsEvery    time.Duration
-  deepseekAPIKey   string
-  deepseekBaseURL  string
-  deepseekModel    string
+  addr                   string
+  storeBackend           string
+  memorySnapshotFile     string
+  memorySnapshotEvery    time.Duration
+  fileStoreDir           string
+  edgeSyncURL            string
+  edgeSyncToken          string
+  syncToken              string
+  edgeSyncNode           string
+  edgeSyncEvery          time.Duration
+  dsns                   []string
+  queryTimeout           time.Duration
+  breakerThreshold       int
+  breakerCooldown        time.Duration
+  slowQuery              time.Duration
+  dbMaxOpenConns         int
+  dbMaxIdleConns         int
+  dbConnMaxLifetime      time.Duration
+  dbConnMaxIdleTime      time.Duration
+  dbFailoverCheck        time.Duration
+  allowedOrigins         []string
+  corsMethods            []string
+  corsHeaders            []string
+  corsCredentials        bool
+  corsMaxAge             time.Duration
+  enableSimulation       bool
+  metricsEvery           time.Duration
+  insightsEvery          time.Duration
+  simBatchSize           int
+  simSeries              []string
+  simFlushEvery          time.Duration
+  simBackpressure        time.Duration
+  snapshotDedupWindow    time.Duration
+  snapshotDedupTolerance float64
+  deepseekAPIKey         string
+  deepseekBaseURL        string
+  deepseekModel          string
+  webhookURLs            []string
+  outboxEvery            time.Duration
+  escalationEvery        time.Duration
+  idempotencyTTL         time.Duration
+  backlogSLA             time.Duration
+  insightDedupWindow     time.Duration
+  insightTrashRetention  time.Duration
+  insightPurgeSchedule   string
+  insightLocales         []string
+  adminToken             string
+  metricsRetention       time.Duration
+  pruneSchedule          string
+  archiveEndpoint        string
+  archiveRegion          string
+  archiveBucket          string
+  archiveAccessKey       string
+  archiveSecretKey       string
+  archivePrefix          string
+  jobPollEvery           time.Duration
+  jobTimeout             time.Duration
+  usageFlushEvery        time.Duration
+  authSecret             string
+  accessTokenTTL         time.Duration
+  refreshTokenTTL        time.Duration
+  totpIssuer             string
+  totpRequiredRoles      []string
+  passwordMinLength      int
+  passwordMinClasses     int
+  passwordBreachList     string
+  loginMaxFailures       int
+  loginLockout           time.Duration
+  loginClientMaxFailures int
+  loginClientWindow      time.Duration
+  metricRedaction        string
+  redactionExempt        []string
+  ipAllow                []string
+  ipDeny                 []string
+  trustedProxies         []string
+  recordSample           float64
+  recordErrors           bool
+  recordSize             int
+  recordBodyLimit        int
+  chaosAllowed           bool
+  contractSpec           string
+  collectFile            string
+  collectFileEvery       time.Duration
+  pluginsFile            string
+  adHocRoles             []string
+  adHocMaxRows           int
+  adHocTimeout           time.Duration
+  overviewTTL            time.Duration
+  overviewRefreshEvery   time.Duration
+  cacheControl           bool
+  cacheRules             string
+  cacheHistorical        string
+  cacheHistoricalAfter   time.Duration
+  healthCheckEvery       time.Duration
+  metricBounds           string
+  metricBoundsFlag       bool
+  metricUnits            string
+  metricPrecision        string
+  fxBase                 string
+  fxRates                []string
+  fxRatesURL             string
+  fxRefreshEvery         time.Duration
+  npsSentiment           bool
+  npsWindow              time.Duration
+  npsMinResponses        int
+  npsRefreshEvery        time.Duration
+  timezone               *time.Location
+  authRequired           bool
+  slackSigningSecret     string
+  slackBotToken          string
+  emailSMTPHost          string
+  emailSMTPPort          int
+  emailSMTPUsername      string
+  emailSMTPPassword      string
+  emailFrom              string
+  emailReplyTo           string
+  emailEnvironment       string
+  emailDryRun            bool
+  emailTo                []string
+  emailEvents            []string
+  publicURL              string
+  slackLocale            string
+  dashboardURL           string
+  invitationTTL          time.Duration
+  summarySchedule        string
+  insightDigestSchedule  string
+  insightDigestOnly      bool
+  insightActiveHours     string
+  insightMinIntervals    []string
+  calendarJobs           []string
+}
+
+// poolPresets size the connection pool by deployment. Polling dashboards
+// hold a connection per request, so medium suits a few dozen open clients.
+var poolPresets = map[string]struct{ maxOpen, maxIdle int }{
+  "small":  {maxOpen: 10, maxIdle: 5},
+  "medium": {maxOpen: 30, maxIdle: 15},
+  "large":  {maxOpen: 100, maxIdle: 50},
 }
 
 func loadEnv() {
@@ -112,6 +784,10 @@ func loadEnv() {
       return
     }
   }
+  // The local stores need no database configuration, so they run without one.
+  if store := os.Getenv("STORE"); store == "memory" || store == "file" {
+    return
+  }
   log.Fatal(".env file not found (searched upward from current directory)")
 }
 
@@ -119,34 +795,403 @@ func loadConfig() config {
   port := getEnv("APP_PORT", "8080")
   addr := ":" + port
 
-  host := getEnv("DB_HOST", "127.0.0.1")
-  dbPort := getEnv("DB_PORT", "3306")
-  user := getEnv("DB_USER", "root")
-  pass := getEnv("DB_PASS", "123456")
-  name := getEnv("DB_NAME", "dashboard")
-  dsn := user + ":" + pass + "@tcp(" + host + ":" + dbPort + ")/" + name + "?parseTime=true&charset=utf8mb4&loc=Local"
+  storeBackend := getEnv("STORE", "mysql")
+  if storeBackend != "mysql" && storeBackend != "memory" && storeBackend != "file" {
+    log.Fatalf("STORE must be mysql, memory or file, got %q", storeBackend)
+  }
+  memorySnapshotFile := getEnv("MEMORY_SNAPSHOT_FILE", "")
+  memorySnapshotEvery := parseDurationEnv("MEMORY_SNAPSHOT_EVERY", time.Minute)
+  fileStoreDir := getEnv("FILE_STORE_DIR", "data")
+  hostname, _ := os.Hostname()
+  edgeSyncURL := getEnv("EDGE_SYNC_URL", "")
+  edgeSyncToken := getEnv("EDGE_SYNC_TOKEN", "")
+  syncToken := getEnv("SYNC_TOKEN", "")
+  edgeSyncNode := getEnv("EDGE_SYNC_NODE", hostname)
+  edgeSyncEvery := parseDurationEnv("EDGE_SYNC_EVERY", 5*time.Minute)
+  dsns, err := buildDSNs()
+  if err != nil {
+    log.Fatalf("database config: %v", err)
+  }
+  queryTimeout := parseDurationEnv("DB_QUERY_TIMEOUT", 3*time.Second)
+  breakerThreshold := parseIntEnv("DB_BREAKER_THRESHOLD", 5)
+  breakerCooldown := parseDurationEnv("DB_BREAKER_COOLDOWN", 10*time.Second)
+  slowQuery := parseDurationEnv("DB_SLOW_QUERY", 200*time.Millisecond)
+  poolSize := getEnv("DB_POOL_SIZE", "medium")
+  pool, ok := poolPresets[poolSize]
+  if !ok {
+    log.Fatalf("DB_POOL_SIZE must be small, medium or large, got %q", poolSize)
+  }
+  dbMaxOpenConns := parseIntEnv("DB_MAX_OPEN_CONNS", pool.maxOpen)
+  dbMaxIdleConns := parseIntEnv("DB_MAX_IDLE_CONNS", min(pool.maxIdle, dbMaxOpenConns))
+  dbConnMaxLifetime := parseDurationEnv("DB_CONN_MAX_LIFETIME", 5*time.Minute)
+  dbConnMaxIdleTime := parseDurationEnv("DB_CONN_MAX_IDLE_TIME", 2*time.Minute)
+  dbFailoverCheck := parseDurationEnv("DB_FAILOVER_CHECK", 5*time.Second)
 
   enableSimulation := getEnv("ENABLE_SIMULATION", "true") == "true"
   metricsEvery := parseDurationEnv("SIM_METRICS_EVERY", 1*time.Second)
   insightsEvery := parseDurationEnv("SIM_INSIGHTS_EVERY", 5*time.Second)
-  allowedOrigins := getEnv("ALLOWED_ORIGINS", "*")
+  simBatchSize := parseIntEnv("SIM_BATCH_SIZE", 1)
+  simSeries := splitList(getEnv("SIM_SERIES", ""))
+  simFlushEvery := parseDurationEnv("SIM_FLUSH_EVERY", 5*time.Second)
+  simBackpressure := parseDurationEnv("SIM_BACKPRESSURE_THRESHOLD", 500*time.Millisecond)
+  snapshotDedupWindow := parseDurationEnv("SNAPSHOT_DEDUP_WINDOW", 0)
+  snapshotDedupTolerance := parseFloatEnv("SNAPSHOT_DEDUP_TOLERANCE", 0)
+  allowedOrigins := splitList(getEnv("ALLOWED_ORIGINS", "*"))
+  corsMethods := splitList(getEnv("CORS_ALLOWED_METHODS", ""))
+  corsHeaders := splitList(getEnv("CORS_ALLOWED_HEADERS", ""))
+  corsCredentials := getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true"
+  corsMaxAge := parseDurationEnv("CORS_MAX_AGE", 10*time.Minute)
   deepseekAPIKey := getEnv("DEEPSEEK_API_KEY", "")
   deepseekBaseURL := getEnv("DEEPSEEK_BASE_URL", "https://api.deepseek.com")
   deepseekModel := getEnv("DEEPSEEK_MODEL", "deepseek-chat")
+  webhookURLs := splitList(getEnv("NOTIFY_WEBHOOK_URLS", ""))
+  outboxEvery := parseDurationEnv("OUTBOX_POLL_EVERY", 2*time.Second)
+  escalationEvery := parseDurationEnv("ALERT_ESCALATION_EVERY", time.Minute)
+  idempotencyTTL := parseDurationEnv("IDEMPOTENCY_TTL", 24*time.Hour)
+  backlogSLA := parseDurationEnv("BACKLOG_SLA", 72*time.Hour)
+  insightDedupWindow := parseDurationEnv("INSIGHT_DEDUP_WINDOW", 10*time.Minute)
+  insightTrashRetention := parseDurationEnv("INSIGHT_TRASH_RETENTION", 30*24*time.Hour)
+  insightPurgeSchedule := getEnv("INSIGHT_PURGE_SCHEDULE", "30 3 * * *")
+  insightLocales := splitList(getEnv("INSIGHT_LOCALES", "zh-CN"))
+  adminToken := getEnv("ADMIN_TOKEN", "")
+  authRequired := getEnv("AUTH_REQUIRED", "false") == "true"
+  slackSigningSecret := getEnv("SLACK_SIGNING_SECRET", "")
+  slackBotToken := getEnv("SLACK_BOT_TOKEN", "")
+  emailSMTPHost := getEnv("EMAIL_SMTP_HOST", "")
+  emailSMTPPort := parseIntEnv("EMAIL_SMTP_PORT", 587)
+  emailSMTPUsername := getEnv("EMAIL_SMTP_USERNAME", "")
+  emailSMTPPassword := getEnv("EMAIL_SMTP_PASSWORD", "")
+  emailFrom := getEnv("EMAIL_FROM", "")
+  emailReplyTo := getEnv("EMAIL_REPLY_TO", "")
+  emailEnvironment := getEnv("EMAIL_ENVIRONMENT", "production")
+  emailDryRun := getEnv("EMAIL_DRY_RUN", "false") == "true"
+  emailTo := splitList(getEnv("EMAIL_TO", ""))
+  emailEvents := splitList(getEnv("EMAIL_EVENTS", models.EventAlertEscalated+","+models.EventDailySummary))
+  publicURL := getEnv("PUBLIC_URL", "")
+  slackLocale := getEnv("SLACK_LOCALE", "zh-CN")
+  dashboardURL := getEnv("DASHBOARD_URL", "")
+  invitationTTL := parseDurationEnv("INVITATION_TTL", 72*time.Hour)
+  summarySchedule := getEnv("DAILY_SUMMARY_SCHEDULE", "0 9 * * *")
+  insightDigestSchedule := getEnv("INSIGHT_DIGEST_SCHEDULE", "0 9 * * *")
+  insightDigestOnly := getEnv("INSIGHT_DIGEST_ONLY", "false") == "true"
+  insightActiveHours := getEnv("INSIGHT_ACTIVE_HOURS", "")
+  insightMinIntervals := splitList(getEnv("INSIGHT_MIN_INTERVAL", ""))
+  calendarJobs := splitList(getEnv("CALENDAR_JOBS", "daily-summary"))
+  metricsRetention := parseDurationEnv("METRICS_RETENTION", 0)
+  pruneSchedule := getEnv("METRICS_PRUNE_SCHEDULE", "0 3 * * *")
+  archiveEndpoint := getEnv("ARCHIVE_S3_ENDPOINT", "")
+  archiveRegion := getEnv("ARCHIVE_S3_REGION", "us-east-1")
+  archiveBucket := getEnv("ARCHIVE_S3_BUCKET", "")
+  archiveAccessKey := getEnv("ARCHIVE_S3_ACCESS_KEY", "")
+  archiveSecretKey := getEnv("ARCHIVE_S3_SECRET_KEY", "")
+  archivePrefix := getEnv("ARCHIVE_S3_PREFIX", "mydashboard/")
+  jobPollEvery := parseDurationEnv("JOB_POLL_EVERY", 2*time.Second)
+  jobTimeout := parseDurationEnv("JOB_TIMEOUT", 10*time.Minute)
+  usageFlushEvery := parseDurationEnv("USAGE_FLUSH_EVERY", 30*time.Second)
+  authSecret := getEnv("AUTH_SECRET", "")
+  accessTokenTTL := parseDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+  refreshTokenTTL := parseDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+  totpIssuer := getEnv("TOTP_ISSUER", "MyDashboard")
+  totpRequiredRoles := splitList(getEnv("TOTP_REQUIRED_ROLES", ""))
+  passwordMinLength := parseIntEnv("PASSWORD_MIN_LENGTH", 8)
+  passwordMinClasses := parseIntEnv("PASSWORD_MIN_CLASSES", 0)
+  passwordBreachList := getEnv("PASSWORD_BREACH_LIST", "")
+  loginMaxFailures := parseIntEnv("LOGIN_MAX_FAILURES", 5)
+  loginLockout := parseDurationEnv("LOGIN_LOCKOUT", 15*time.Minute)
+  loginClientMaxFailures := parseIntEnv("LOGIN_CLIENT_MAX_FAILURES", 20)
+  loginClientWindow := parseDurationEnv("LOGIN_CLIENT_WINDOW", 15*time.Minute)
+  metricRedaction := getEnv("METRIC_REDACTION", "")
+  redactionExempt := splitList(getEnv("REDACTION_EXEMPT_ROLES", "admin,analyst"))
+  ipAllow := splitList(getEnv("IP_ALLOWLIST", ""))
+  ipDeny := splitList(getEnv("IP_DENYLIST", ""))
+  trustedProxies := splitList(getEnv("TRUSTED_PROXIES", ""))
+  recordSample := parseFloatEnv("DEBUG_RECORD_SAMPLE", 0)
+  recordErrors := getEnv("DEBUG_RECORD_ERRORS", "false") == "true"
+  recordSize := parseIntEnv("DEBUG_RECORD_SIZE", 200)
+  recordBodyLimit := parseIntEnv("DEBUG_RECORD_BODY_LIMIT", 4096)
+  chaosAllowed := getEnv("CHAOS_ALLOWED", "false") == "true"
+  contractSpec := getEnv("CONTRACT_SPEC", "")
+  collectFile := getEnv("COLLECT_FILE", "")
+  collectFileEvery := parseDurationEnv("COLLECT_FILE_EVERY", time.Minute)
+  pluginsFile := getEnv("PLUGINS_FILE", "")
+  adHocRoles := splitList(getEnv("ADHOC_QUERY_ROLES", "admin,analyst"))
+  adHocMaxRows := parseIntEnv("ADHOC_QUERY_MAX_ROWS", 1000)
+  adHocTimeout := parseDurationEnv("ADHOC_QUERY_TIMEOUT", 5*time.Second)
+  overviewTTL := parseDurationEnv("OVERVIEW_TTL", time.Minute)
+  overviewRefreshEvery := parseDurationEnv("OVERVIEW_REFRESH_EVERY", 30*time.Second)
+  cacheControl := getEnv("CACHE_CONTROL_ENABLED", "true") == "true"
+  cacheRules := getEnv("CACHE_CONTROL_RULES", "")
+  cacheHistorical := getEnv("CACHE_CONTROL_HISTORICAL", "public, max-age=3600")
+  cacheHistoricalAfter := parseDurationEnv("CACHE_CONTROL_HISTORICAL_AFTER", 5*time.Minute)
+  healthCheckEvery := parseDurationEnv("HEALTH_CHECK_EVERY", 30*time.Second)
+  metricBounds := getEnv("METRIC_BOUNDS", "")
+  metricBoundsFlag := getEnv("METRIC_BOUNDS_MODE", "reject") == "flag"
+  metricUnits := getEnv("METRIC_UNITS", "")
+  metricPrecision := getEnv("METRIC_PRECISION", "")
+  fxBase := getEnv("FX_BASE", "USD")
+  fxRates := splitList(getEnv("FX_RATES", ""))
+  fxRatesURL := getEnv("FX_RATES_URL", "")
+  fxRefreshEvery := parseDurationEnv("FX_REFRESH_EVERY", time.Hour)
+  npsSentiment := getEnv("NPS_SENTIMENT", "false") == "true"
+  npsWindow := parseDurationEnv("NPS_WINDOW", 30*24*time.Hour)
+  npsMinResponses := parseIntEnv("NPS_MIN_RESPONSES", 30)
+  npsRefreshEvery := parseDurationEnv("NPS_REFRESH_EVERY", time.Minute)
+  timezone := time.Local
+  if name := getEnv("APP_TIMEZONE", ""); name != "" {
+    loc, err := time.LoadLocation(name)
+    if err != nil {
+      log.Fatalf("APP_TIMEZONE: %v", err)
+    }
+    timezone = loc
+  }
 
   return config{
-    addr:             addr,
-    dsn:              dsn,
-    allowedOrigins:   allowedOrigins,
-    enableSimulation: enableSimulation,
-    metricsEvery:     metricsEvery,
-    insightsEvery:    insightsEvery,
-    deepseekAPIKey:   deepseekAPIKey,
-    deepseekBaseURL:  deepseekBaseURL,
-    deepseekModel:    deepseekModel,
+    addr:                   addr,
+    storeBackend:           storeBackend,
+    memorySnapshotFile:     memorySnapshotFile,
+    memorySnapshotEvery:    memorySnapshotEvery,
+    fileStoreDir:           fileStoreDir,
+    edgeSyncURL:            edgeSyncURL,
+    edgeSyncToken:          edgeSyncToken,
+    syncToken:              syncToken,
+    edgeSyncNode:           edgeSyncNode,
+    edgeSyncEvery:          edgeSyncEvery,
+    dsns:                   dsns,
+    queryTimeout:           queryTimeout,
+    breakerThreshold:       breakerThreshold,
+    breakerCooldown:        breakerCooldown,
+    slowQuery:              slowQuery,
+    dbMaxOpenConns:         dbMaxOpenConns,
+    dbMaxIdleConns:         dbMaxIdleConns,
+    dbConnMaxLifetime:      dbConnMaxLifetime,
+    dbConnMaxIdleTime:      dbConnMaxIdleTime,
+    dbFailoverCheck:        dbFailoverCheck,
+    allowedOrigins:         allowedOrigins,
+    corsMethods:            corsMethods,
+    corsHeaders:            corsHeaders,
+    corsCredentials:        corsCredentials,
+    corsMaxAge:             corsMaxAge,
+    enableSimulation:       enableSimulation,
+    metricsEvery:           metricsEvery,
+    insightsEvery:          insightsEvery,
+    simBatchSize:           simBatchSize,
+    simSeries:              simSeries,
+    simFlushEvery:          simFlushEvery,
+    simBackpressure:        simBackpressure,
+    snapshotDedupWindow:    snapshotDedupWindow,
+    snapshotDedupTolerance: snapshotDedupTolerance,
+    deepseekAPIKey:         deepseekAPIKey,
+    deepseekBaseURL:        deepseekBaseURL,
+    deepseekModel:          deepseekModel,
+    webhookURLs:            webhookURLs,
+    outboxEvery:            outboxEvery,
+    escalationEvery:        escalationEvery,
+    idempotencyTTL:         idempotencyTTL,
+    backlogSLA:             backlogSLA,
+    insightDedupWindow:     insightDedupWindow,
+    insightTrashRetention:  insightTrashRetention,
+    insightPurgeSchedule:   insightPurgeSchedule,
+    insightLocales:         insightLocales,
+    adminToken:             adminToken,
+    metricsRetention:       metricsRetention,
+    pruneSchedule:          pruneSchedule,
+    archiveEndpoint:        archiveEndpoint,
+    archiveRegion:          archiveRegion,
+    archiveBucket:          archiveBucket,
+    archiveAccessKey:       archiveAccessKey,
+    archiveSecretKey:       archiveSecretKey,
+    archivePrefix:          archivePrefix,
+    jobPollEvery:           jobPollEvery,
+    jobTimeout:             jobTimeout,
+    usageFlushEvery:        usageFlushEvery,
+    authSecret:             authSecret,
+    accessTokenTTL:         accessTokenTTL,
+    refreshTokenTTL:        refreshTokenTTL,
+    totpIssuer:             totpIssuer,
+    totpRequiredRoles:      totpRequiredRoles,
+    passwordMinLength:      passwordMinLength,
+    passwordMinClasses:     passwordMinClasses,
+    passwordBreachList:     passwordBreachList,
+    loginMaxFailures:       loginMaxFailures,
+    loginLockout:           loginLockout,
+    loginClientMaxFailures: loginClientMaxFailures,
+    loginClientWindow:      loginClientWindow,
+    metricRedaction:        metricRedaction,
+    redactionExempt:        redactionExempt,
+    ipAllow:                ipAllow,
+    ipDeny:                 ipDeny,
+    trustedProxies:         trustedProxies,
+    recordSample:           recordSample,
+    recordErrors:           recordErrors,
+    recordSize:             recordSize,
+    recordBodyLimit:        recordBodyLimit,
+    chaosAllowed:           chaosAllowed,
+    contractSpec:           contractSpec,
+    collectFile:            collectFile,
+    collectFileEvery:       collectFileEvery,
+    pluginsFile:            pluginsFile,
+    adHocRoles:             adHocRoles,
+    adHocMaxRows:           adHocMaxRows,
+    adHocTimeout:           adHocTimeout,
+    overviewTTL:            overviewTTL,
+    overviewRefreshEvery:   overviewRefreshEvery,
+    cacheControl:           cacheControl,
+    cacheRules:             cacheRules,
+    cacheHistorical:        cacheHistorical,
+    cacheHistoricalAfter:   cacheHistoricalAfter,
+    healthCheckEvery:       healthCheckEvery,
+    metricBounds:           metricBounds,
+    metricBoundsFlag:       metricBoundsFlag,
+    metricUnits:            metricUnits,
+    metricPrecision:        metricPrecision,
+    fxBase:                 fxBase,
+    fxRates:                fxRates,
+    fxRatesURL:             fxRatesURL,
+    fxRefreshEvery:         fxRefreshEvery,
+    npsSentiment:           npsSentiment,
+    npsWindow:              npsWindow,
+    npsMinResponses:        npsMinResponses,
+    npsRefreshEvery:        npsRefreshEvery,
+    timezone:               timezone,
+    authRequired:           authRequired,
+    slackSigningSecret:     slackSigningSecret,
+    slackBotToken:          slackBotToken,
+    emailSMTPHost:          emailSMTPHost,
+    emailSMTPPort:          emailSMTPPort,
+    emailSMTPUsername:      emailSMTPUsername,
+    emailSMTPPassword:      emailSMTPPassword,
+    emailFrom:              emailFrom,
+    emailReplyTo:           emailReplyTo,
+    emailEnvironment:       emailEnvironment,
+    emailDryRun:            emailDryRun,
+    emailTo:                emailTo,
+    emailEvents:            emailEvents,
+    publicURL:              publicURL,
+    slackLocale:            slackLocale,
+    dashboardURL:           dashboardURL,
+    invitationTTL:          invitationTTL,
+    summarySchedule:        summarySchedule,
+    insightDigestSchedule:  insightDigestSchedule,
+    insightDigestOnly:      insightDigestOnly,
+    insightActiveHours:     insightActiveHours,
+    insightMinIntervals:    insightMinIntervals,
+    calendarJobs:           calendarJobs,
   }
 }
 
+// buildDSNs uses DB_DSN as given when set, for managed databases that need
+// their own parameters, and otherwise assembles one DSN per DB_HOST entry
+// from the DB_* variables. parseTime is always on because the store scans
+// timestamps.
+func buildDSNs() ([]string, error) {
+  tlsMode, err := registerDBTLS()
+  if err != nil {
+    return nil, err
+  }
+  if dsn := getEnv("DB_DSN", ""); dsn != "" {
+    cfg, err := mysql.ParseDSN(dsn)
+    if err != nil {
+      return nil, fmt.Errorf("DB_DSN: %w", err)
+    }
+    cfg.ParseTime = true
+    if tlsMode != "" {
+      cfg.TLSConfig = tlsMode
+    }
+    return []string{cfg.FormatDSN()}, nil
+  }
+  loc, err := time.LoadLocation(getEnv("DB_TIMEZONE", "Local"))
+  if err != nil {
+    return nil, fmt.Errorf("DB_TIMEZONE: %w", err)
+  }
+  defaultPort := getEnv("DB_PORT", "3306")
+  var dsns []string
+  for _, host := range splitList(getEnv("DB_HOST", "127.0.0.1")) {
+    addr := host
+    if _, _, err := net.SplitHostPort(host); err != nil {
+      addr = net.JoinHostPort(host, defaultPort)
+    }
+    cfg := mysql.NewConfig()
+    cfg.User = getEnv("DB_USER", "root")
+    cfg.Passwd = getEnv("DB_PASS", "123456")
+    cfg.Net = "tcp"
+    cfg.Addr = addr
+    cfg.DBName = getEnv("DB_NAME", "dashboard")
+    cfg.ParseTime = true
+    cfg.Loc = loc
+    cfg.Params = map[string]string{"charset": "utf8mb4"}
+    cfg.TLSConfig = tlsMode
+    setUTCSession(cfg)
+    dsns = append(dsns, cfg.FormatDSN())
+  }
+  if len(dsns) == 0 {
+    return nil, fmt.Errorf("DB_HOST is empty")
+  }
+  return dsns, nil
+}
+
+// setUTCSession makes NOW() and CURRENT_TIMESTAMP defaults agree with the
+// UTC times the driver writes once DB_TIMEZONE=UTC is chosen. Any other
+// zone, including the Local default, keeps the server's session zone.
+func setUTCSession(cfg *mysql.Config) {
+  if cfg.Loc != time.UTC {
+    return
+  }
+  if cfg.Params == nil {
+    cfg.Params = map[string]string{}
+  }
+  if _, ok := cfg.Params["time_zone"]; !ok {
+    cfg.Params["time_zone"] = "'+00:00'"
+  }
+}
+
+// registerDBTLS returns the driver's tls setting for DB_TLS. A CA bundle,
+// client certificate or server name registers them as the "custom" config.
+func registerDBTLS() (string, error) {
+  mode := getEnv("DB_TLS", "")
+  ca := getEnv("DB_TLS_CA", "")
+  cert := getEnv("DB_TLS_CERT", "")
+  key := getEnv("DB_TLS_KEY", "")
+  serverName := getEnv("DB_TLS_SERVER_NAME", "")
+  switch mode {
+  case "", "false", "true", "skip-verify", "preferred":
+  default:
+    return "", fmt.Errorf("DB_TLS must be true, false, skip-verify or preferred, got %q", mode)
+  }
+  if ca == "" && cert == "" && serverName == "" {
+    return mode, nil
+  }
+  if mode == "false" {
+    return "", fmt.Errorf("DB_TLS=false conflicts with DB_TLS_CA, DB_TLS_CERT and DB_TLS_SERVER_NAME")
+  }
+  tlsConfig := &tls.Config{
+    ServerName:         serverName,
+    InsecureSkipVerify: mode == "skip-verify",
+    MinVersion:         tls.VersionTLS12,
+  }
+  if ca != "" {
+    pem, err := os.ReadFile(ca)
+    if err != nil {
+      return "", fmt.Errorf("DB_TLS_CA: %w", err)
+    }
+    pool := x509.NewCertPool()
+    if !pool.AppendCertsFromPEM(pem) {
+      return "", fmt.Errorf("DB_TLS_CA: no certificates in %s", ca)
+    }
+    tlsConfig.RootCAs = pool
+  }
+  if cert != "" || key != "" {
+    pair, err := tls.LoadX509KeyPair(cert, key)
+    if err != nil {
+      return "", fmt.Errorf("DB_TLS_CERT: %w", err)
+    }
+    tlsConfig.Certificates = []tls.Certificate{pair}
+  }
+  if err := mysql.RegisterTLSConfig("custom", tlsConfig); err != nil {
+    return "", err
+  }
+  return "custom", nil
+}
+
 func getEnv(key, fallback string) string {
   if value, ok := os.LookupEnv(key); ok {
     return value
@@ -154,6 +1199,40 @@ func getEnv(key, fallback string) string {
   return fallback
 }
 
+func splitList(value string) []string {
+  var items []string
+  for _, item := range strings.Split(value, ",") {
+    if item = strings.TrimSpace(item); item != "" {
+      items = append(items, item)
+    }
+  }
+  return items
+}
+
+func parseIntEnv(key string, fallback int) int {
+  value := getEnv(key, "")
+  if value == "" {
+    return fallback
+  }
+  parsed, err := strconv.Atoi(value)
+  if err != nil {
+    return fallback
+  }
+  return parsed
+}
+
+func parseFloatEnv(key string, fallback float64) float64 {
+  value := getEnv(key, "")
+  if value == "" {
+    return fallback
+  }
+  parsed, err := strconv.ParseFloat(value, 64)
+  if err != nil {
+    return fallback
+  }
+  return parsed
+}
+
 func parseDurationEnv(key string, fallback time.Duration) time.Duration {
   value := getEnv(key, "")
   if value == "" {
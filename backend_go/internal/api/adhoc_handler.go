@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+const maxAdHocBody = 64 << 10
+
+var errAdHocRole = errors.New("ad-hoc queries are not enabled for your role")
+
+// WithAdHocQueries enables POST /api/query for callers with one of roles.
+func (s *Server) WithAdHocQueries(queries *service.AdHocQueryService, roles []string) *Server {
+	s.adHoc = queries
+	s.adHocRoles = roles
+	return s
+}
+
+func (s *Server) adHocAllowed(w http.ResponseWriter, r *http.Request) bool {
+	if s.adHoc == nil {
+		writeError(w, http.StatusNotFound, errors.New("ad-hoc queries are disabled"))
+		return false
+	}
+	if _, scoped := embedFrom(r.Context()); scoped || !slices.Contains(s.adHocRoles, s.callerRole(r)) {
+		writeError(w, http.StatusForbidden, errAdHocRole)
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleAdHocSchema(w http.ResponseWriter, r *http.Request) {
+	if !s.adHocAllowed(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.adHoc.Schema()})
+}
+
+// handleAdHocQuery runs a structured read-only query, see models.AdHocQuery.
+func (s *Server) handleAdHocQuery(w http.ResponseWriter, r *http.Request) {
+	if !s.adHocAllowed(w, r) {
+		return
+	}
+	var q models.AdHocQuery
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdHocBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&q); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	role := s.callerRole(r)
+	var hidden []string
+	for _, key := range models.MetricKeys {
+		if s.redactor.Restricted(role, key) {
+			hidden = append(hidden, key)
+		}
+	}
+	result, err := s.adHoc.Run(r.Context(), q, hidden)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidAdHocQuery):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrMetricRestricted):
+			status = http.StatusForbidden
+		case errors.Is(err, store.ErrUnsupported):
+			status = http.StatusNotImplemented
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": result})
+}
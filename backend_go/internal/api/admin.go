@@ -0,0 +1,50 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"mydashboard-backend/internal/models"
+)
+
+// requireAdmin accepts a signed-in admin user or the static ADMIN_TOKEN.
+// The token path is disabled entirely when no token is configured.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal, ok := principalFrom(r.Context()); ok && principal.Role == models.RoleAdmin && !principal.EnrollmentRequired {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.adminToken == "" {
+			writeError(w, http.StatusForbidden, errors.New("admin API disabled: ADMIN_TOKEN not configured"))
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("admin credentials required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAnalyst guards configuration that analysts maintain alongside
+// admins, such as alert rules and targets. Signed-in viewers are refused;
+// callers without a session need what requireAdmin accepts.
+func (s *Server) requireAnalyst(next http.Handler) http.Handler {
+	admin := s.requireAdmin(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := principalFrom(r.Context())
+		if !ok || principal.Role == models.RoleAdmin {
+			admin.ServeHTTP(w, r)
+			return
+		}
+		if principal.Role != models.RoleAnalyst || principal.EnrollmentRequired {
+			writeError(w, http.StatusForbidden, errors.New("analyst or admin role required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+type AdminGenerateRequest struct {
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+}
+
+func (s *Server) handleAdminGenerateInsights(w http.ResponseWriter, r *http.Request) {
+	var payload AdminGenerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if payload.From == nil && payload.To == nil {
+		metrics, _, err := s.metrics.Latest(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		items, err := s.insights.GenerateAuto(r.Context(), metrics)
+		if err != nil && len(items) == 0 {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, InsightsResponse{Data: items})
+		return
+	}
+
+	to := time.Now()
+	if payload.To != nil {
+		to = *payload.To
+	}
+	from := to.Add(-time.Hour)
+	if payload.From != nil {
+		from = *payload.From
+	}
+	if !from.Before(to) {
+		writeError(w, http.StatusBadRequest, errors.New("from must be before to"))
+		return
+	}
+	if wantsAsync(r) {
+		s.enqueueJob(w, r, models.JobKindInsightsGenerate, service.InsightRangeJob{From: from, To: to})
+		return
+	}
+	items, err := s.insights.GenerateRange(r.Context(), from, to)
+	if errors.Is(err, service.ErrNoData) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil && len(items) == 0 {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, InsightsResponse{Data: items})
+}
+
+// handleRecompute queues a job that recomputes the stored derived data of
+// the snapshots in ?from=&to=, see MetricsService.RecomputeJob. Poll
+// /api/jobs/{id} for progress.
+func (s *Server) handleRecompute(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.enqueueJob(w, r, models.JobKindMetricsRecompute, service.RecomputeRange{From: from, To: to})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+func (s *Server) WithEscalations(escalations *service.EscalationService) *Server {
+	s.escalations = escalations
+	return s
+}
+
+// handleListAlerts lists escalated alerts, newest first; ?open=true keeps the
+// unacknowledged ones.
+func (s *Server) handleListAlerts(w http.ResponseWriter, r *http.Request) {
+	limit := min(max(parseQueryInt(r, "limit", 50), 1), 500)
+	items, err := s.escalations.List(r.Context(), r.URL.Query().Get("open") == "true", limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+func (s *Server) handleAcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid alert id"))
+		return
+	}
+	by := "api"
+	if principal, ok := principalFrom(r.Context()); ok {
+		by = principal.Username
+	}
+	alert, err := s.escalations.Acknowledge(r.Context(), id, by)
+	if err != nil {
+		writeError(w, alertErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": alert})
+}
+
+// handleAcknowledgeAlertLink serves the link in escalation notifications. It
+// is public; the token only acknowledges its own alert.
+func (s *Server) handleAcknowledgeAlertLink(w http.ResponseWriter, r *http.Request) {
+	alert, err := s.escalations.AcknowledgeToken(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		writeError(w, alertErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": alert})
+}
+
+func alertErrorStatus(err error) int {
+	if errors.Is(err, store.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
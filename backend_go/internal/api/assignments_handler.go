@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type AssignInsightRequest struct {
+	UserID   int64      `json:"user_id"`
+	Username string     `json:"username"`
+	DueAt    *time.Time `json:"due_at"`
+	Status   string     `json:"status"`
+	TeamID   *int64     `json:"team_id"`
+}
+
+type AssignmentStatusRequest struct {
+	Status string `json:"status"`
+}
+
+func assignmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidAssignment):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrNotAssignee):
+		return http.StatusForbidden
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *Server) handleGetAssignment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	assignment, err := s.insights.Assignment(r.Context(), id)
+	if err != nil {
+		writeError(w, assignmentErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": assignment})
+}
+
+// handleAssignInsight assigns an insight to a user, replacing any earlier
+// assignment.
+func (s *Server) handleAssignInsight(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	var payload AssignInsightRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	by := "api"
+	if principal, ok := principalFrom(r.Context()); ok {
+		by = principal.Username
+	}
+	assignment, err := s.insights.Assign(r.Context(), id, service.Assignment{
+		UserID:   payload.UserID,
+		Username: payload.Username,
+		DueAt:    payload.DueAt,
+		Status:   payload.Status,
+		TeamID:   payload.TeamID,
+	}, by)
+	if err != nil {
+		writeError(w, assignmentErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": assignment})
+}
+
+func (s *Server) handleSetAssignmentStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	var payload AssignmentStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	principal, _ := principalFrom(r.Context())
+	principal.Role = s.callerRole(r)
+	assignment, err := s.insights.SetAssignmentStatus(r.Context(), id, payload.Status, principal)
+	if err != nil {
+		writeError(w, assignmentErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": assignment})
+}
+
+func (s *Server) handleUnassignInsight(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	if err := s.insights.Unassign(r.Context(), id); err != nil {
+		writeError(w, assignmentErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleMyAssignments lists the caller's assignments, soonest due first.
+// ?status=open,in-progress,done filters them; by default done ones are left
+// out.
+func (s *Server) handleMyAssignments(w http.ResponseWriter, r *http.Request) {
+	principal, _ := principalFrom(r.Context())
+	var statuses []string
+	if value := r.URL.Query().Get("status"); value != "" {
+		statuses = strings.Split(value, ",")
+	}
+	items, err := s.insights.AssignmentsFor(r.Context(), principal.UserID, statuses)
+	if err != nil {
+		writeError(w, assignmentErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
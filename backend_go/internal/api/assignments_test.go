@@ -0,0 +1,27 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"mydashboard-backend/internal/apitest"
+	"mydashboard-backend/internal/models"
+)
+
+func TestViewerCannotChangeAssignments(t *testing.T) {
+	h := apitest.New(t)
+	insight := h.SeedInsight(models.Insight{Title: "Revenue dip", Message: "Revenue fell 8%", Source: "rule", Severity: "warning"})
+	viewer := h.UserToken("vera", models.RoleViewer)
+	analyst := h.UserToken("anil", models.RoleAnalyst)
+	path := fmt.Sprintf("/api/insights/%d/assignment", insight.ID)
+	assign := map[string]any{"username": "anil"}
+
+	h.Do(apitest.Request{Method: http.MethodPut, Path: path, Body: assign, Token: viewer}).Status(http.StatusForbidden)
+	h.Do(apitest.Request{Method: http.MethodPut, Path: path, Body: assign}).Status(http.StatusUnauthorized)
+	h.Do(apitest.Request{Method: http.MethodPut, Path: path, Body: assign, Token: analyst}).Status(http.StatusOK)
+
+	h.Do(apitest.Request{Method: http.MethodDelete, Path: path, Token: viewer}).Status(http.StatusForbidden)
+	h.Do(apitest.Request{Method: http.MethodDelete, Path: path}).Status(http.StatusUnauthorized)
+	h.Do(apitest.Request{Method: http.MethodDelete, Path: path, Token: analyst}).Status(http.StatusNoContent)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+func (s *Server) WithAudit(audit *service.AuditService) *Server {
+	s.audit = audit
+	return s
+}
+
+// handleListAudit returns the newest audit events, filtered by ?action=,
+// ?user_id= and ?since=, with times in ?tz or the caller's profile
+// timezone.
+func (s *Server) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	loc, err := requestLocation(r, s.location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	q := models.AuditQuery{
+		Action: r.URL.Query().Get("action"),
+		Limit:  parseQueryInt(r, "limit", 0),
+	}
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid user id"))
+			return
+		}
+		q.UserID = id
+	}
+	since, err := parseQueryTime(r, "since", time.Time{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	q.Since = since
+	items, err := s.audit.List(r.Context(), q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range items {
+		items[i].CreatedAt = items[i].CreatedAt.In(loc)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
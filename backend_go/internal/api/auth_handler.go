@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type principalKey struct{}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	OTP      string `json:"otp"`
+}
+
+type TOTPVerifyRequest struct {
+	Code string `json:"code"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type CreateUserRequest struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	Role        string `json:"role"`
+	DisplayName string `json:"display_name"`
+}
+
+func principalFrom(ctx context.Context) (models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(models.Principal)
+	return principal, ok
+}
+
+func bearerToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token
+}
+
+// authenticate attaches the caller when a valid access token is presented.
+// It never rejects: public routes stay public and requireUser decides.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if s.auth == nil || token == "" || token == s.adminToken {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, err := s.auth.Authenticate(r.Context(), token)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		setUsageCaller(r.Context(), "user:"+strconv.FormatInt(principal.UserID, 10))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// requireSession admits any signed-in caller, including one who still has to
+// enroll in two-factor authentication; requireUser does not.
+func (s *Server) requireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := principalFrom(r.Context()); !ok {
+			writeError(w, http.StatusUnauthorized, service.ErrUnauthenticated)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) requireUser(next http.Handler) http.Handler {
+	return s.requireSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal, _ := principalFrom(r.Context()); principal.EnrollmentRequired {
+			writeError(w, http.StatusForbidden, errors.New("two-factor enrollment required: POST /api/me/2fa/setup"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	}))
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var payload LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	pair, err := s.auth.Login(r.Context(), payload.Username, payload.Password, payload.OTP, r.UserAgent(), r.RemoteAddr)
+	var lockout *service.LockoutError
+	if errors.As(err, &lockout) {
+		w.Header().Set("Retry-After", strconv.Itoa(max(int(time.Until(lockout.Until).Seconds())+1, 1)))
+		writeError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidCredentials) || errors.Is(err, service.ErrOTPRequired) || errors.Is(err, service.ErrInvalidOTP) {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": pair})
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	pair, err := s.auth.Refresh(r.Context(), payload.RefreshToken)
+	if errors.Is(err, service.ErrUnauthenticated) {
+		writeError(w, http.StatusUnauthorized, errors.New("refresh token is invalid, expired or revoked"))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": pair})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	principal, _ := principalFrom(r.Context())
+	if err := s.auth.RevokeSession(r.Context(), principal, principal.SessionID); err != nil && !errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	principal, _ := principalFrom(r.Context())
+	sessions, err := s.auth.Sessions(r.Context(), principal)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": sessions})
+}
+
+func (s *Server) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid session id"))
+		return
+	}
+	principal, _ := principalFrom(r.Context())
+	err = s.auth.RevokeSession(r.Context(), principal, id)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleTOTPSetup(w http.ResponseWriter, r *http.Request) {
+	principal, _ := principalFrom(r.Context())
+	enrollment, err := s.auth.SetupTOTP(r.Context(), principal)
+	if errors.Is(err, service.ErrTOTPEnabled) {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": enrollment})
+}
+
+func (s *Server) handleTOTPVerify(w http.ResponseWriter, r *http.Request) {
+	var payload TOTPVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	principal, _ := principalFrom(r.Context())
+	err := s.auth.VerifyTOTP(r.Context(), principal, payload.Code)
+	switch {
+	case errors.Is(err, service.ErrTOTPNotSetUp):
+		writeError(w, http.StatusConflict, err)
+		return
+	case errors.Is(err, service.ErrInvalidOTP):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]bool{"totp_enabled": true}})
+}
+
+func (s *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.auth.Users(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": users})
+}
+
+func (s *Server) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var payload CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	user, err := s.auth.CreateUser(r.Context(), models.User{
+		Username:    payload.Username,
+		Role:        payload.Role,
+		DisplayName: payload.DisplayName,
+	}, payload.Password)
+	switch {
+	case errors.Is(err, service.ErrInvalidUser):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, store.ErrConflict):
+		writeError(w, http.StatusConflict, errors.New("username already exists"))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": user})
+}
+
+// handleEraseUserData answers a data-subject erasure request for one user
+// and returns what was removed, for the requester's records.
+func (s *Server) handleEraseUserData(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid user id"))
+		return
+	}
+	report, err := s.auth.EraseUserData(r.Context(), id)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("user not found"))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": report})
+}
+
+// handleUnlockUser lifts a login lockout before it runs out.
+func (s *Server) handleUnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid user id"))
+		return
+	}
+	by := "api"
+	if principal, ok := principalFrom(r.Context()); ok {
+		by = principal.Username
+	}
+	user, err := s.auth.Unlock(r.Context(), id, by)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("user not found"))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": user})
+}
+
+// handlePasswordPolicy lets sign-up and invitation forms show the password
+// requirements before submitting.
+func (s *Server) handlePasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.auth.PasswordPolicy()})
+}
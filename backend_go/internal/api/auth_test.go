@@ -0,0 +1,13 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"mydashboard-backend/internal/apitest"
+)
+
+func TestPasswordPolicyWithAuthRequired(t *testing.T) {
+	h := apitest.New(t, apitest.WithAuthRequired())
+	h.Get("/api/auth/password-policy").Status(http.StatusOK).Golden("password_policy", "server_time")
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"mydashboard-backend/internal/service"
+)
+
+const maxRestoreBody = 1 << 30
+
+func (s *Server) WithBackups(backups *service.BackupService) *Server {
+	s.backups = backups
+	return s
+}
+
+// handleBackup streams the archive as it is written, so a failure midway
+// can only be logged; the client sees a truncated gzip stream.
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	name := fmt.Sprintf("mydashboard-backup-%s.jsonl.gz", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	report, err := s.backups.Write(r.Context(), w)
+	if err != nil {
+		log.Printf("backup failed: %v", err)
+		return
+	}
+	log.Printf("backup written: %v", report.Rows)
+}
+
+// handleRestore replaces metrics, insights and configuration with the
+// uploaded archive. Scheduled job changes take effect after a restart.
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	report, err := s.backups.Restore(r.Context(), http.MaxBytesReader(w, r.Body, maxRestoreBody))
+	if err != nil {
+		status := http.StatusInternalServerError
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrInvalidBackup):
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": report})
+}
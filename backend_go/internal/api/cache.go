@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheRule sets Cache-Control on successful GET and HEAD responses whose
+// path starts with Prefix. The longest matching prefix wins.
+type CacheRule struct {
+	Prefix string
+	Value  string
+}
+
+// CachePolicy decides the Cache-Control header per route. Requests whose
+// "to" lies more than HistoricalAfter in the past read data that no longer
+// changes and get Historical instead of their route's value.
+type CachePolicy struct {
+	Rules           []CacheRule
+	Historical      string
+	HistoricalAfter time.Duration
+}
+
+// DefaultCacheRules keep credentials and admin output out of caches and
+// let shared caches absorb polling of the latest values and wallboards.
+func DefaultCacheRules() []CacheRule {
+	return []CacheRule{
+		{Prefix: "/api/admin/", Value: "no-store"},
+		{Prefix: "/api/auth/", Value: "no-store"},
+		{Prefix: "/api/query", Value: "no-store"},
+		{Prefix: "/api/metrics/latest", Value: "public, max-age=5"},
+		{Prefix: "/api/metrics/", Value: "public, max-age=30"},
+		{Prefix: "/api/wallboard", Value: "public, max-age=15, stale-while-revalidate=30"},
+		{Prefix: "/api/overview", Value: "public, max-age=15, stale-while-revalidate=30"},
+	}
+}
+
+// ParseCacheRules reads rules such as
+// "/api/metrics/latest=public, max-age=10;/api/insights/=no-cache". They
+// are added to the defaults, replacing a default with the same prefix.
+func ParseCacheRules(spec string) ([]CacheRule, error) {
+	rules := DefaultCacheRules()
+	for _, item := range strings.Split(spec, ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		prefix, value, ok := strings.Cut(item, "=")
+		prefix, value = strings.TrimSpace(prefix), strings.TrimSpace(value)
+		if !ok || !strings.HasPrefix(prefix, "/") || value == "" {
+			return nil, fmt.Errorf("invalid cache rule %q (want /path/prefix=directives)", item)
+		}
+		replaced := false
+		for i := range rules {
+			if rules[i].Prefix == prefix {
+				rules[i].Value, replaced = value, true
+			}
+		}
+		if !replaced {
+			rules = append(rules, CacheRule{Prefix: prefix, Value: value})
+		}
+	}
+	return rules, nil
+}
+
+func (s *Server) WithCachePolicy(policy CachePolicy) *Server {
+	sort.SliceStable(policy.Rules, func(i, j int) bool {
+		return len(policy.Rules[i].Prefix) > len(policy.Rules[j].Prefix)
+	})
+	s.cachePolicy = policy
+	return s
+}
+
+func (p CachePolicy) value(r *http.Request) string {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return "no-store"
+	}
+	value := ""
+	for _, rule := range p.Rules {
+		if strings.HasPrefix(r.URL.Path, rule.Prefix) {
+			value = rule.Value
+			break
+		}
+	}
+	if value == "" || value == "no-store" {
+		return value
+	}
+	if p.Historical != "" {
+		if to, err := parseQueryTime(r, "to", time.Time{}); err == nil && !to.IsZero() && time.Since(to) > p.HistoricalAfter {
+			value = p.Historical
+		}
+	}
+	// Responses to credentialed requests are redacted per caller and must
+	// not be served to anyone else from a shared cache.
+	if r.Header.Get("Authorization") != "" || r.URL.Query().Has(embedTokenParam) {
+		value = privateCacheControl(value)
+	}
+	return value
+}
+
+func privateCacheControl(value string) string {
+	directives := strings.Split(value, ",")
+	out := directives[:0]
+	for _, directive := range directives {
+		directive = strings.TrimSpace(directive)
+		switch {
+		case directive == "public":
+			directive = "private"
+		case strings.HasPrefix(directive, "s-maxage"):
+			continue
+		}
+		out = append(out, directive)
+	}
+	return strings.Join(out, ", ")
+}
+
+// cacheControl sets the route's Cache-Control before the handler runs, so
+// handlers can still override it, and replaces it with no-store on error
+// responses.
+func (s *Server) cacheControl(next http.Handler) http.Handler {
+	if len(s.cachePolicy.Rules) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := s.cachePolicy.value(r)
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", value)
+		if strings.HasPrefix(value, "public") {
+			w.Header().Add("Vary", "Authorization, Accept-Language")
+		}
+		next.ServeHTTP(&cacheWriter{ResponseWriter: w}, r)
+	})
+}
+
+type cacheWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *cacheWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status != http.StatusOK && status != http.StatusNotModified {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/models"
+)
+
+const (
+	calendarEventLength = 15 * time.Minute
+	calendarMaxRuns     = 100
+	calendarMaxAlerts   = 500
+	icalTime            = "20060102T150405Z"
+)
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// WithCalendarJobs sets which scheduled jobs appear in the calendar feed,
+// typically the ones that deliver reports.
+func (s *Server) WithCalendarJobs(jobs []string) *Server {
+	s.calendarJobs = jobs
+	return s
+}
+
+// icalWriter builds an iCalendar body, folding lines at 75 octets as
+// RFC 5545 requires.
+type icalWriter struct {
+	b strings.Builder
+}
+
+func (w *icalWriter) line(name, value string) {
+	line := name + ":" + value
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with the folding space.
+		limit = 74
+	}
+	w.b.WriteString(line + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func (w *icalWriter) event(uid string, start time.Time, summary, description string, categories []string, url string) {
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", uid)
+	w.line("DTSTAMP", time.Now().UTC().Format(icalTime))
+	w.line("DTSTART", start.UTC().Format(icalTime))
+	w.line("DTEND", start.Add(calendarEventLength).UTC().Format(icalTime))
+	w.line("SUMMARY", icalEscaper.Replace(summary))
+	if description != "" {
+		w.line("DESCRIPTION", icalEscaper.Replace(description))
+	}
+	if len(categories) > 0 {
+		escaped := make([]string, len(categories))
+		for i, category := range categories {
+			escaped[i] = icalEscaper.Replace(category)
+		}
+		w.line("CATEGORIES", strings.Join(escaped, ","))
+	}
+	if url != "" {
+		w.line("URL", url)
+	}
+	w.line("END", "VEVENT")
+}
+
+// handleCalendar publishes upcoming report runs and recent major alerts as
+// an iCalendar feed for Outlook and similar clients. ?days sets how far
+// ahead and back it looks; ?severity which insights count as alerts.
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	days := parseQueryInt(r, "days", 30)
+	if days < 1 || days > 90 {
+		writeError(w, http.StatusBadRequest, errors.New("days must be between 1 and 90"))
+		return
+	}
+	severities := []string{models.SeverityCritical}
+	if value := r.URL.Query().Get("severity"); value != "" {
+		severities = strings.Split(value, ",")
+		for _, severity := range severities {
+			if _, ok := severityRank[severity]; !ok {
+				writeError(w, http.StatusBadRequest, errors.New("severity must list critical, warning or info"))
+				return
+			}
+		}
+	}
+	locale := requestLocale(r)
+	loc, err := requestLocation(r, s.location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	now := time.Now()
+	horizon := time.Duration(days) * 24 * time.Hour
+
+	var cal icalWriter
+	cal.line("BEGIN", "VCALENDAR")
+	cal.line("VERSION", "2.0")
+	cal.line("PRODID", "-//MyDashboard//Calendar//EN")
+	cal.line("CALSCALE", "GREGORIAN")
+	cal.line("METHOD", "PUBLISH")
+	cal.line("X-WR-CALNAME", icalEscaper.Replace(i18n.T(locale, "calendar.name")))
+	if name := loc.String(); name != "Local" {
+		cal.line("X-WR-TIMEZONE", name)
+	}
+
+	if s.scheduler != nil {
+		for _, name := range s.calendarJobs {
+			runs, err := s.scheduler.Upcoming(name, now.Add(horizon), calendarMaxRuns)
+			if err != nil {
+				log.Printf("calendar: %s: %v", name, err)
+				continue
+			}
+			for _, run := range runs {
+				cal.event(fmt.Sprintf("%s-%d@mydashboard", name, run.Unix()), run, i18n.T(locale, "calendar.job", name), "", []string{"report"}, s.dashboardURL)
+			}
+		}
+	}
+
+	items, err := s.insights.Between(r.Context(), locale, now.Add(-horizon), now, severities, calendarMaxAlerts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, hidden := s.visibleMetrics(r, models.Metrics{})
+	for _, item := range items {
+		if !insightVisible(item, hidden) {
+			continue
+		}
+		link := ""
+		if s.dashboardURL != "" {
+			link = fmt.Sprintf("%s/?insight=%d", s.dashboardURL, item.ID)
+		}
+		categories := []string{item.Severity}
+		for _, metric := range item.Metrics {
+			categories = append(categories, metric.MetricKey)
+		}
+		cal.event(fmt.Sprintf("insight-%d@mydashboard", item.ID), item.CreatedAt, item.Title, item.Message, categories, link)
+	}
+	cal.line("END", "VCALENDAR")
+
+	w.Header().Set("Content-Disposition", `inline; filename="mydashboard.ics"`)
+	writeWithETag(w, r, "text/calendar; charset=utf-8", []byte(cal.b.String()))
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxChaosLatency = time.Minute
+
+var errChaos = errors.New("injected by chaos mode")
+
+// ChaosSettings is the JSON form of the chaos configuration. Routes are
+// path prefixes, optionally preceded by a method as in "GET /api/metrics";
+// none means every route. Latencies are Go durations.
+type ChaosSettings struct {
+	Enabled     bool     `json:"enabled"`
+	Routes      []string `json:"routes"`
+	LatencyRate float64  `json:"latency_rate"`
+	LatencyMin  string   `json:"latency_min"`
+	LatencyMax  string   `json:"latency_max"`
+	ErrorRate   float64  `json:"error_rate"`
+	ErrorStatus int      `json:"error_status"`
+}
+
+type chaosRoute struct {
+	method string
+	prefix string
+}
+
+// Chaos delays requests and fails them at the configured rates so clients
+// can exercise their loading and error states. Admin routes are never
+// touched, so chaos mode can always be turned off again.
+type Chaos struct {
+	mu         sync.RWMutex
+	settings   ChaosSettings
+	routes     []chaosRoute
+	latencyMin time.Duration
+	latencyMax time.Duration
+}
+
+func NewChaos() *Chaos {
+	return &Chaos{settings: ChaosSettings{Routes: []string{}, LatencyMin: "0s", LatencyMax: "0s", ErrorStatus: http.StatusServiceUnavailable}}
+}
+
+func (c *Chaos) Settings() ChaosSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.settings
+}
+
+func (c *Chaos) Update(settings ChaosSettings) error {
+	if settings.LatencyRate < 0 || settings.LatencyRate > 1 || settings.ErrorRate < 0 || settings.ErrorRate > 1 {
+		return errors.New("latency_rate and error_rate must be between 0 and 1")
+	}
+	if settings.ErrorStatus == 0 {
+		settings.ErrorStatus = http.StatusServiceUnavailable
+	}
+	if settings.ErrorStatus < 400 || settings.ErrorStatus > 599 {
+		return errors.New("error_status must be a 4xx or 5xx status")
+	}
+	latencies := [2]time.Duration{}
+	for i, value := range []string{settings.LatencyMin, settings.LatencyMax} {
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 || parsed > maxChaosLatency {
+			return fmt.Errorf("invalid latency %q (want a duration up to %s)", value, maxChaosLatency)
+		}
+		latencies[i] = parsed
+	}
+	if latencies[1] < latencies[0] {
+		latencies[1] = latencies[0]
+	}
+	routes := make([]chaosRoute, 0, len(settings.Routes))
+	for _, route := range settings.Routes {
+		method, prefix, ok := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok {
+			method, prefix = "", method
+		}
+		prefix = strings.TrimSpace(prefix)
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("invalid route %q (want [METHOD] /path/prefix)", route)
+		}
+		routes = append(routes, chaosRoute{method: strings.ToUpper(method), prefix: prefix})
+	}
+	if settings.Routes == nil {
+		settings.Routes = []string{}
+	}
+	settings.LatencyMin, settings.LatencyMax = latencies[0].String(), latencies[1].String()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settings = settings
+	c.routes = routes
+	c.latencyMin, c.latencyMax = latencies[0], latencies[1]
+	return nil
+}
+
+// pick decides what happens to r: a delay to add and whether to fail it.
+func (c *Chaos) pick(r *http.Request) (time.Duration, int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.settings.Enabled || strings.HasPrefix(r.URL.Path, "/api/admin/") || !c.matches(r) {
+		return 0, 0
+	}
+	var delay time.Duration
+	if rand.Float64() < c.settings.LatencyRate {
+		delay = c.latencyMin + time.Duration(rand.Int63n(int64(c.latencyMax-c.latencyMin)+1))
+	}
+	status := 0
+	if rand.Float64() < c.settings.ErrorRate {
+		status = c.settings.ErrorStatus
+	}
+	return delay, status
+}
+
+func (c *Chaos) matches(r *http.Request) bool {
+	if len(c.routes) == 0 {
+		return true
+	}
+	for _, route := range c.routes {
+		if (route.method == "" || route.method == r.Method) && strings.HasPrefix(r.URL.Path, route.prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) WithChaos(chaos *Chaos) *Server {
+	s.chaos = chaos
+	return s
+}
+
+func (s *Server) injectChaos(next http.Handler) http.Handler {
+	chaos := s.chaos
+	if chaos == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay, status := chaos.pick(r)
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+		if status != 0 {
+			w.Header().Set("X-Chaos", "injected")
+			writeError(w, status, errChaos)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleGetChaos(w http.ResponseWriter, r *http.Request) {
+	if s.chaos == nil {
+		writeError(w, http.StatusNotFound, errors.New("chaos mode unavailable: set CHAOS_ALLOWED=true"))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.chaos.Settings()})
+}
+
+func (s *Server) handleUpdateChaos(w http.ResponseWriter, r *http.Request) {
+	if s.chaos == nil {
+		writeError(w, http.StatusNotFound, errors.New("chaos mode unavailable: set CHAOS_ALLOWED=true"))
+		return
+	}
+	var payload ChaosSettings
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.chaos.Update(payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.chaos.Settings()})
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+
+	"mydashboard-backend/internal/collector"
+	"mydashboard-backend/internal/models"
+)
+
+func (s *Server) WithCollectors(collectors *collector.Manager) *Server {
+	s.collectors = collectors
+	return s
+}
+
+func (s *Server) handleCollectors(w http.ResponseWriter, r *http.Request) {
+	statuses := []models.CollectorStatus{}
+	if s.collectors != nil {
+		statuses = s.collectors.Statuses()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": statuses})
+}
@@ -0,0 +1,447 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxContractViolations = 10
+
+// Contract checks responses against an OpenAPI 3 document in JSON form. It
+// supports the parts of JSON Schema that describe response bodies: types,
+// nullable, properties, required, additionalProperties, items, enum, allOf,
+// anyOf, oneOf and local $refs into components.
+type Contract struct {
+	basePath   string
+	operations []contractOperation
+	schemas    map[string]*contractSchema
+}
+
+type contractOperation struct {
+	method    string
+	segments  []string
+	responses map[string]*contractSchema
+	// json is false when no response is documented as JSON, e.g. for event
+	// streams and downloads, which are then passed through untouched.
+	json bool
+}
+
+type contractSchema struct {
+	Ref                  string                     `json:"$ref"`
+	Type                 schemaTypes                `json:"type"`
+	Nullable             bool                       `json:"nullable"`
+	Properties           map[string]*contractSchema `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	Items                *contractSchema            `json:"items"`
+	Enum                 []any                      `json:"enum"`
+	AllOf                []*contractSchema          `json:"allOf"`
+	AnyOf                []*contractSchema          `json:"anyOf"`
+	OneOf                []*contractSchema          `json:"oneOf"`
+}
+
+// schemaTypes accepts both the OpenAPI 3.0 form, "type": "string", and the
+// 3.1 form, "type": ["string", "null"].
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(raw []byte) error {
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// LoadContract reads an OpenAPI document. Paths are matched below the path
+// of the first server URL, so a document with "servers": [{"url": "/api"}]
+// may list "/metrics/latest".
+func LoadContract(path string) (*Contract, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+		// Components.Schemas are resolved by $ref.
+		Components struct {
+			Schemas map[string]*contractSchema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%s: not an OpenAPI 3 document", path)
+	}
+	c := &Contract{schemas: doc.Components.Schemas}
+	if len(doc.Servers) > 0 {
+		if u, err := url.Parse(doc.Servers[0].URL); err == nil {
+			c.basePath = strings.TrimRight(u.Path, "/")
+		}
+	}
+	for route, item := range doc.Paths {
+		for method, rawOp := range item {
+			method = strings.ToUpper(method)
+			if !isHTTPMethod(method) {
+				continue
+			}
+			var op struct {
+				Responses map[string]struct {
+					Content map[string]struct {
+						Schema *contractSchema `json:"schema"`
+					} `json:"content"`
+				} `json:"responses"`
+			}
+			if err := json.Unmarshal(rawOp, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, route, err)
+			}
+			operation := contractOperation{
+				method:    method,
+				segments:  strings.Split(strings.Trim(route, "/"), "/"),
+				responses: map[string]*contractSchema{},
+			}
+			for status, response := range op.Responses {
+				var schema *contractSchema
+				for mediaType, content := range response.Content {
+					if isJSONMediaType(mediaType) {
+						schema = content.Schema
+						operation.json = true
+					}
+				}
+				if schema == nil {
+					schema = &contractSchema{}
+				}
+				operation.responses[strings.ToUpper(status)] = schema
+			}
+			c.operations = append(c.operations, operation)
+		}
+	}
+	// Literal segments win over parameters, so /insights/tags is not taken
+	// for /insights/{id}.
+	sort.SliceStable(c.operations, func(i, j int) bool {
+		return literalSegments(c.operations[i].segments) > literalSegments(c.operations[j].segments)
+	})
+	return c, nil
+}
+
+func isHTTPMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isJSONMediaType(value string) bool {
+	mediaType, _, err := mime.ParseMediaType(value)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+func literalSegments(segments []string) int {
+	n := 0
+	for _, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *Contract) operation(method, path string) (contractOperation, bool) {
+	if c.basePath != "" {
+		rest, ok := strings.CutPrefix(path, c.basePath)
+		if !ok {
+			return contractOperation{}, false
+		}
+		path = rest
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, op := range c.operations {
+		if op.method != method || len(op.segments) != len(segments) {
+			continue
+		}
+		matched := true
+		for i, segment := range op.segments {
+			if segment != segments[i] && !(strings.HasPrefix(segment, "{") && segments[i] != "") {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return op, true
+		}
+	}
+	return contractOperation{}, false
+}
+
+// Check validates one response and returns what does not match, or nil.
+func (c *Contract) Check(method, path string, status int, contentType string, body []byte) []string {
+	op, ok := c.operation(method, path)
+	if !ok {
+		return nil
+	}
+	code := strconv.Itoa(status)
+	schema, ok := op.responses[code]
+	if !ok {
+		schema, ok = op.responses[code[:1]+"XX"]
+	}
+	if !ok {
+		schema, ok = op.responses["DEFAULT"]
+	}
+	if !ok {
+		return []string{fmt.Sprintf("status %d is not documented", status)}
+	}
+	if !isJSONMediaType(contentType) || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return []string{"body is not valid JSON: " + err.Error()}
+	}
+	var violations []string
+	c.validate(schema, value, "", &violations)
+	return violations
+}
+
+func (c *Contract) resolve(schema *contractSchema) *contractSchema {
+	for i := 0; schema != nil && schema.Ref != "" && i < 32; i++ {
+		name, ok := strings.CutPrefix(schema.Ref, "#/components/schemas/")
+		if !ok {
+			return &contractSchema{}
+		}
+		schema = c.schemas[name]
+	}
+	if schema == nil {
+		return &contractSchema{}
+	}
+	return schema
+}
+
+func (c *Contract) validate(schema *contractSchema, value any, at string, violations *[]string) {
+	if len(*violations) >= maxContractViolations {
+		return
+	}
+	schema = c.resolve(schema)
+	fail := func(format string, args ...any) {
+		where := at
+		if where == "" {
+			where = "/"
+		}
+		*violations = append(*violations, where+": "+fmt.Sprintf(format, args...))
+	}
+
+	if value == nil {
+		if schema.Nullable || len(schema.Type) == 0 || slices.Contains(schema.Type, "null") {
+			return
+		}
+		fail("null is not allowed")
+		return
+	}
+	if len(schema.Type) > 0 && !matchesType(schema.Type, value) {
+		fail("expected %s, got %s", strings.Join(schema.Type, " or "), jsonType(value))
+		return
+	}
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		fail("%v is not one of the documented values", value)
+	}
+	for _, sub := range schema.AllOf {
+		c.validate(sub, value, at, violations)
+	}
+	if len(schema.AnyOf) > 0 && c.matching(schema.AnyOf, value, at) == 0 {
+		fail("matches none of anyOf")
+	}
+	if len(schema.OneOf) > 0 {
+		if n := c.matching(schema.OneOf, value, at); n != 1 {
+			fail("matches %d of oneOf, want exactly 1", n)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		var additional *contractSchema
+		closed := false
+		if len(schema.AdditionalProperties) > 0 {
+			var allowed bool
+			if err := json.Unmarshal(schema.AdditionalProperties, &allowed); err == nil {
+				closed = !allowed
+			} else if err := json.Unmarshal(schema.AdditionalProperties, &additional); err != nil {
+				additional = nil
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := schema.Properties[name]; ok {
+				c.validate(property, v[name], at+"/"+name, violations)
+			} else if closed {
+				fail("property %q is not documented", name)
+			} else if additional != nil {
+				c.validate(additional, v[name], at+"/"+name, violations)
+			}
+		}
+	case []any:
+		if schema.Items != nil {
+			for i, item := range v {
+				c.validate(schema.Items, item, at+"/"+strconv.Itoa(i), violations)
+			}
+		}
+	}
+}
+
+func (c *Contract) matching(schemas []*contractSchema, value any, at string) int {
+	n := 0
+	for _, schema := range schemas {
+		var violations []string
+		c.validate(schema, value, at, &violations)
+		if len(violations) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func matchesType(types schemaTypes, value any) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) && !strings.ContainsAny(v.String(), ".eE") {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func inEnum(enum []any, value any) bool {
+	if n, ok := value.(json.Number); ok {
+		f, _ := n.Float64()
+		value = f
+	}
+	for _, option := range enum {
+		if reflect.DeepEqual(option, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithContract makes the server refuse responses that do not match the
+// contract. It is meant for development and tests: every documented JSON
+// response is buffered and validated, and a mismatch is replaced by a 500
+// listing what differs.
+func (s *Server) WithContract(contract *Contract) *Server {
+	s.contract = contract
+	return s
+}
+
+func (s *Server) checkContract(next http.Handler) http.Handler {
+	contract := s.contract
+	if contract == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, ok := contract.operation(r.Method, r.URL.Path)
+		if !ok || !op.json {
+			next.ServeHTTP(w, r)
+			return
+		}
+		buffered := &contractWriter{ResponseWriter: w, header: http.Header{}}
+		next.ServeHTTP(buffered, r)
+		status := buffered.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		violations := contract.Check(r.Method, r.URL.Path, status, buffered.header.Get("Content-Type"), buffered.body.Bytes())
+		if len(violations) > 0 {
+			log.Printf("contract: %s %s %d: %s", r.Method, r.URL.Path, status, strings.Join(violations, "; "))
+			writeError(w, http.StatusInternalServerError, errors.New("response does not match the API contract: "+strings.Join(violations, "; ")))
+			return
+		}
+		for key, values := range buffered.header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(buffered.body.Bytes())
+	})
+}
+
+// contractWriter holds a response back until it has been validated.
+type contractWriter struct {
+	http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *contractWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *contractWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *contractWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+func (w *contractWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var publishOnce sync.Once
+
+// debugRoutes mounts pprof and expvar. pprof.Index only resolves named
+// profiles under /debug/pprof/, so they are routed to pprof.Handler here.
+func (s *Server) debugRoutes(r chi.Router) {
+	publishOnce.Do(s.publishVars)
+
+	r.Get("/vars", expvar.Handler().ServeHTTP)
+	r.Get("/requests", s.handleRecordedRequests)
+	r.Get("/pprof/", pprof.Index)
+	r.Get("/pprof/cmdline", pprof.Cmdline)
+	r.Get("/pprof/profile", pprof.Profile)
+	r.Post("/pprof/symbol", pprof.Symbol)
+	r.Get("/pprof/symbol", pprof.Symbol)
+	r.Get("/pprof/trace", pprof.Trace)
+	r.Get("/pprof/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) publishVars() {
+	expvar.Publish("goroutines", expvar.Func(func() any {
+		return runtime.NumGoroutine()
+	}))
+	expvar.Publish("simulation", expvar.Func(func() any {
+		return s.metrics.SimulationStats()
+	}))
+	expvar.Publish("db", expvar.Func(func() any {
+		if s.dbStats == nil {
+			return nil
+		}
+		return s.dbStats()
+	}))
+	expvar.Publish("scheduler", expvar.Func(func() any {
+		if s.scheduler == nil {
+			return nil
+		}
+		return s.scheduler.Jobs()
+	}))
+}
+
+func (s *Server) handleDBStats(w http.ResponseWriter, r *http.Request) {
+	if s.dbStats == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("db stats not configured"))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.dbStats()})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type DerivedMetricRequest struct {
+	Expression  string `json:"expression"`
+	Mode        string `json:"mode"`
+	Description string `json:"description"`
+}
+
+func (s *Server) WithDerivedMetrics(derived *service.DerivedMetricService) *Server {
+	s.derived = derived
+	return s
+}
+
+func (s *Server) handleListDerivedMetrics(w http.ResponseWriter, r *http.Request) {
+	items, err := s.derived.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+// handleSaveDerivedMetric creates the derived metric named in the path or
+// replaces its definition.
+func (s *Server) handleSaveDerivedMetric(w http.ResponseWriter, r *http.Request) {
+	var payload DerivedMetricRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	item := models.DerivedMetric{
+		Name:        chi.URLParam(r, "name"),
+		Expression:  payload.Expression,
+		Mode:        payload.Mode,
+		Description: payload.Description,
+	}
+	if err := s.derived.Save(r.Context(), item); err != nil {
+		writeError(w, derivedErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteDerivedMetric(w http.ResponseWriter, r *http.Request) {
+	if err := s.derived.Delete(r.Context(), chi.URLParam(r, "name")); err != nil {
+		writeError(w, derivedErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func derivedErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidDerivedMetric):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
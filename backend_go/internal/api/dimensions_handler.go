@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+type DimensionValuesRequest struct {
+	Values []models.DimensionValue `json:"values"`
+}
+
+func (s *Server) WithDimensions(dimensions *service.DimensionService) *Server {
+	s.dimensions = dimensions
+	return s
+}
+
+func (s *Server) handleIngestDimensionValues(w http.ResponseWriter, r *http.Request) {
+	var payload DimensionValuesRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	count, err := s.dimensions.Ingest(r.Context(), payload.Values)
+	if err != nil {
+		writeError(w, dimensionErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"count": count})
+}
+
+// handleTopContributors ranks the members of ?by= by their total of ?metric=
+// over from/to, the last 30 days by default, returning ?limit= (10) of them.
+func (s *Server) handleTopContributors(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 30*24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	query := r.URL.Query()
+	metric := query.Get("metric")
+	if s.redactor.Restricted(s.callerRole(r), metric) {
+		writeError(w, http.StatusForbidden, service.ErrMetricRestricted)
+		return
+	}
+	board, err := s.dimensions.Top(r.Context(), metric, query.Get("by"), from, to, parseQueryInt(r, "limit", 10))
+	if err != nil {
+		writeError(w, dimensionErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": board})
+}
+
+func dimensionErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidDimension) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
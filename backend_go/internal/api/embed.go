@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	embedTokenParam = "embed_token"
+	defaultEmbedTTL = 30 * 24 * time.Hour
+)
+
+type embedKey struct{}
+
+type CreateEmbedTokenRequest struct {
+	Name    string   `json:"name"`
+	Metrics []string `json:"metrics"`
+	TTL     string   `json:"ttl"`
+}
+
+// publicPaths stay reachable without credentials when AUTH_REQUIRED is set.
+var publicPaths = map[string]bool{
+	"/api/auth/login":     true,
+	"/api/auth/refresh":   true,
+	"/api/status/history": true,
+	"/api/alerts/ack":     true,
+	// Sign-up and invitation forms show the rules before there is a session.
+	"/api/auth/password-policy": true,
+
+	// Invitees have no account until they accept.
+	"/api/invitations/accept": true,
+	// Edge instances authenticate with SYNC_TOKEN, checked by the handler.
+	"/api/sync/push": true,
+
+	"/api/integrations/slack/command":       true,
+	"/api/integrations/slack/sparkline.png": true,
+}
+
+func (s *Server) WithEmbeds(embeds *service.EmbedService) *Server {
+	s.embeds = embeds
+	return s
+}
+
+// WithAuthRequired rejects anonymous callers on every /api route except
+// publicPaths. Embed tokens still work for the routes they cover.
+func (s *Server) WithAuthRequired(required bool) *Server {
+	s.authRequired = required
+	return s
+}
+
+func embedFrom(ctx context.Context) (models.EmbedToken, bool) {
+	token, ok := ctx.Value(embedKey{}).(models.EmbedToken)
+	return token, ok
+}
+
+// embedAllows lists what an embed token may read: the latest snapshot, the
+// wallboard, the insights and calendar feeds, the Grafana datasource (limited to its
+// metrics by the handlers) and trends of its metrics.
+func embedAllows(r *http.Request, token models.EmbedToken) bool {
+	path := strings.TrimPrefix(r.URL.Path, "/api")
+	if path == "/grafana" || strings.HasPrefix(path, "/grafana/") {
+		return true
+	}
+	if r.Method != http.MethodGet {
+		return false
+	}
+	switch path {
+	case "/metrics/latest", "/wallboard", "/insights/feed.atom", "/calendar.ics":
+		return true
+	case "/metrics/trend":
+		return slices.Contains(token.Metrics, "revenue")
+	}
+	if key, ok := strings.CutSuffix(strings.TrimPrefix(path, "/metrics/"), "/trend"); ok && !strings.Contains(key, "/") {
+		return slices.Contains(token.Metrics, key)
+	}
+	return false
+}
+
+// authorize runs after authenticate. It resolves embed tokens, confines them
+// to embedAllows, and enforces AUTH_REQUIRED for everyone else.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := principalFrom(r.Context()); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token := bearerToken(r)
+		if token == "" {
+			token = r.URL.Query().Get(embedTokenParam)
+		}
+		if s.embeds != nil && strings.HasPrefix(token, service.EmbedTokenPrefix) {
+			embed, err := s.embeds.Resolve(r.Context(), token)
+			if errors.Is(err, service.ErrUnauthenticated) {
+				writeError(w, http.StatusUnauthorized, errors.New("invalid or expired embed token"))
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			setUsageCaller(r.Context(), "embed:"+strconv.FormatInt(embed.ID, 10))
+			if !embedAllows(r, embed) {
+				writeError(w, http.StatusForbidden, errors.New("embed token does not cover this endpoint"))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), embedKey{}, embed)))
+			return
+		}
+		if s.authRequired && !publicPaths[r.URL.Path] && s.callerRole(r) != models.RoleAdmin {
+			writeError(w, http.StatusUnauthorized, service.ErrUnauthenticated)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleListEmbedTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := s.embeds.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": tokens})
+}
+
+func (s *Server) handleCreateEmbedToken(w http.ResponseWriter, r *http.Request) {
+	var payload CreateEmbedTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	ttl := defaultEmbedTTL
+	if payload.TTL != "" {
+		parsed, err := time.ParseDuration(payload.TTL)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("ttl must be a duration such as 720h"))
+			return
+		}
+		ttl = parsed
+	}
+	var createdBy *int64
+	if principal, ok := principalFrom(r.Context()); ok {
+		createdBy = &principal.UserID
+	}
+	token, err := s.embeds.Create(r.Context(), payload.Name, payload.Metrics, ttl, createdBy)
+	if errors.Is(err, service.ErrInvalidEmbedToken) || errors.Is(err, service.ErrUnknownMetric) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": token})
+}
+
+func (s *Server) handleRevokeEmbedToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid embed token id"))
+		return
+	}
+	err = s.embeds.Revoke(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("embed token not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
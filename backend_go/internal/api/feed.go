@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/models"
+)
+
+const (
+	atomNamespace   = "http://www.w3.org/2005/Atom"
+	atomContentType = "application/atom+xml; charset=utf-8"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Lang    string      `xml:"xml:lang,attr,omitempty"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Content    atomText       `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+	Links      []atomLink     `xml:"link"`
+}
+
+// handleInsightsFeed publishes the advisory feed as Atom. Feed readers cannot
+// send headers, so embed tokens are accepted as ?embed_token here as well.
+func (s *Server) handleInsightsFeed(w http.ResponseWriter, r *http.Request) {
+	limit := parseQueryInt(r, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	locale := requestLocale(r)
+	items, _, err := s.insights.Latest(r.Context(), locale, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, hidden := s.visibleMetrics(r, models.Metrics{})
+
+	feed := atomFeed{
+		Xmlns:   atomNamespace,
+		Lang:    locale,
+		ID:      "urn:mydashboard:insights:" + locale,
+		Title:   i18n.T(locale, "insight.title"),
+		Author:  atomPerson{Name: "MyDashboard"},
+		Entries: []atomEntry{},
+	}
+	if s.dashboardURL != "" {
+		feed.Links = append(feed.Links, atomLink{Rel: "alternate", Href: s.dashboardURL + "/"})
+	}
+	updated := time.Time{}
+	for _, item := range items {
+		if !insightVisible(item, hidden) {
+			continue
+		}
+		entry := atomEntry{
+			ID:         "urn:mydashboard:insight:" + strconv.FormatInt(item.ID, 10),
+			Title:      item.Title,
+			Updated:    item.CreatedAt.UTC().Format(time.RFC3339),
+			Published:  item.CreatedAt.UTC().Format(time.RFC3339),
+			Content:    atomText{Type: "text", Body: item.Message},
+			Categories: []atomCategory{{Term: item.Severity}},
+		}
+		for _, link := range item.Metrics {
+			entry.Categories = append(entry.Categories, atomCategory{Term: link.MetricKey})
+		}
+		if s.dashboardURL != "" {
+			entry.Links = []atomLink{{Rel: "alternate", Href: s.dashboardURL + "/?insight=" + strconv.FormatInt(item.ID, 10)}}
+		}
+		if item.CreatedAt.After(updated) {
+			updated = item.CreatedAt
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeWithETag(w, r, atomContentType, append([]byte(xml.Header), append(body, '\n')...))
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type FunnelRequest struct {
+	Stages []string `json:"stages"`
+}
+
+type FunnelEventsRequest struct {
+	Events []models.FunnelEvent `json:"events"`
+}
+
+func (s *Server) WithFunnels(funnels *service.FunnelService) *Server {
+	s.funnels = funnels
+	return s
+}
+
+func (s *Server) handleListFunnels(w http.ResponseWriter, r *http.Request) {
+	items, err := s.funnels.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+// handleSaveFunnel creates the funnel named in the path or replaces its
+// stages.
+func (s *Server) handleSaveFunnel(w http.ResponseWriter, r *http.Request) {
+	var payload FunnelRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	funnel := models.Funnel{Name: chi.URLParam(r, "name"), Stages: payload.Stages}
+	if err := s.funnels.Save(r.Context(), funnel); err != nil {
+		writeError(w, funnelErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteFunnel(w http.ResponseWriter, r *http.Request) {
+	if err := s.funnels.Delete(r.Context(), chi.URLParam(r, "name")); err != nil {
+		writeError(w, funnelErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleIngestFunnelEvents(w http.ResponseWriter, r *http.Request) {
+	var payload FunnelEventsRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	count, err := s.funnels.Ingest(r.Context(), chi.URLParam(r, "name"), payload.Events)
+	if err != nil {
+		writeError(w, funnelErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"count": count})
+}
+
+// handleFunnelReport reports stage conversion over from/to, the last 30 days
+// by default.
+func (s *Server) handleFunnelReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 30*24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	report, err := s.funnels.Report(r.Context(), chi.URLParam(r, "name"), from, to)
+	if err != nil {
+		writeError(w, funnelErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": report})
+}
+
+// handleFunnelCohorts returns the retention matrix over from/to, the last 90
+// days by default, in periods of ?period= (week by default).
+func (s *Server) handleFunnelCohorts(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 90*24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	query := r.URL.Query()
+	period := query.Get("period")
+	if period == "" {
+		period = service.PeriodWeek
+	}
+	matrix, err := s.funnels.Retention(r.Context(), chi.URLParam(r, "name"), from, to,
+		period, query.Get("cohort_stage"), query.Get("active_stage"), parseQueryInt(r, "periods", 12))
+	if err != nil {
+		writeError(w, funnelErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": matrix})
+}
+
+func funnelErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidFunnel):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+)
+
+const grafanaMaxAnnotations = 500
+
+// The request and response shapes follow Grafana's SimpleJSON / JSON
+// datasource contract.
+
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type GrafanaQueryRequest struct {
+	Range         grafanaRange `json:"range"`
+	MaxDataPoints int          `json:"maxDataPoints"`
+	Targets       []struct {
+		Target string `json:"target"`
+		RefID  string `json:"refId"`
+		Type   string `json:"type"`
+	} `json:"targets"`
+}
+
+type GrafanaAnnotationRequest struct {
+	Range      grafanaRange   `json:"range"`
+	Annotation map[string]any `json:"annotation"`
+}
+
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+type grafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []grafanaColumn `json:"columns"`
+	Rows    [][]float64     `json:"rows"`
+}
+
+type grafanaAnnotation struct {
+	Annotation map[string]any `json:"annotation"`
+	Time       int64          `json:"time"`
+	Title      string         `json:"title"`
+	Text       string         `json:"text"`
+	Tags       []string       `json:"tags"`
+}
+
+func (s *Server) grafanaRoutes(r chi.Router) {
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	r.Post("/search", s.handleGrafanaSearch)
+	r.Post("/query", s.handleGrafanaQuery)
+	r.Post("/annotations", s.handleGrafanaAnnotations)
+}
+
+// grafanaMetrics lists the metrics the caller may chart: everything except
+// masked metrics and, for embed tokens, anything outside the token's scope.
+func (s *Server) grafanaMetrics(r *http.Request) []string {
+	embed, scoped := embedFrom(r.Context())
+	role := s.callerRole(r)
+	var keys []string
+	for _, key := range s.metrics.Keys(r.Context()) {
+		if scoped && !slices.Contains(embed.Metrics, key) {
+			continue
+		}
+		if _, _, err := s.redactor.Points(role, key, nil); err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *Server) handleGrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.grafanaMetrics(r))
+}
+
+func (s *Server) handleGrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	var payload GrafanaQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.Range.From.IsZero() || !payload.Range.To.After(payload.Range.From) {
+		writeError(w, http.StatusBadRequest, errors.New("range.from must be before range.to"))
+		return
+	}
+	_, factors, err := s.units(r)
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	allowed := s.grafanaMetrics(r)
+	role := s.callerRole(r)
+
+	out := make([]any, 0, len(payload.Targets))
+	for _, target := range payload.Targets {
+		key := strings.ToLower(strings.TrimSpace(target.Target))
+		if key == "" {
+			continue
+		}
+		var points []models.MetricPoint
+		factor := 1.0
+		if slices.Contains(allowed, key) {
+			points, err = s.metrics.Series(r.Context(), key, payload.Range.From, payload.Range.To, payload.MaxDataPoints)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			points, _, err = s.redactor.Points(role, key, points)
+			if err != nil {
+				writeError(w, http.StatusForbidden, err)
+				return
+			}
+			if f := factors[key]; f != 0 {
+				factor = f
+			}
+		} else {
+			// Anything else is an expression, evaluated in base units.
+			q, ok := s.compileQuery(w, r, target.Target)
+			if !ok {
+				return
+			}
+			points, err = s.metrics.RunQuery(r.Context(), q, payload.Range.From, payload.Range.To, payload.MaxDataPoints)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		if target.Type == "table" {
+			table := grafanaTable{
+				Type:    "table",
+				Columns: []grafanaColumn{{Text: "Time", Type: "time"}, {Text: key, Type: "number"}},
+				Rows:    make([][]float64, len(points)),
+			}
+			for i, point := range points {
+				table.Rows[i] = []float64{float64(point.Timestamp.UnixMilli()), point.Value * factor}
+			}
+			out = append(out, table)
+			continue
+		}
+		series := grafanaSeries{Target: key, Datapoints: make([][2]float64, len(points))}
+		for i, point := range points {
+			series.Datapoints[i] = [2]float64{point.Value * factor, float64(point.Timestamp.UnixMilli())}
+		}
+		out = append(out, series)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleGrafanaAnnotations turns insights into annotations tagged with their
+// severity and metrics. The annotation query, if set, keeps only insights
+// carrying that tag, e.g. "critical" or "revenue".
+func (s *Server) handleGrafanaAnnotations(w http.ResponseWriter, r *http.Request) {
+	var payload GrafanaAnnotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.Range.From.IsZero() || !payload.Range.To.After(payload.Range.From) {
+		writeError(w, http.StatusBadRequest, errors.New("range.from must be before range.to"))
+		return
+	}
+	items, err := s.insights.Between(r.Context(), requestLocale(r), payload.Range.From, payload.Range.To, nil, grafanaMaxAnnotations)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	filter, _ := payload.Annotation["query"].(string)
+	filter = strings.TrimSpace(filter)
+	_, hidden := s.visibleMetrics(r, models.Metrics{})
+
+	out := make([]grafanaAnnotation, 0, len(items))
+	for _, item := range items {
+		if !insightVisible(item, hidden) {
+			continue
+		}
+		tags := []string{item.Severity}
+		for _, link := range item.Metrics {
+			tags = append(tags, link.MetricKey)
+		}
+		if filter != "" && !slices.Contains(tags, filter) {
+			continue
+		}
+		out = append(out, grafanaAnnotation{
+			Annotation: payload.Annotation,
+			Time:       item.CreatedAt.UnixMilli(),
+			Title:      item.Title,
+			Text:       item.Message,
+			Tags:       tags,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
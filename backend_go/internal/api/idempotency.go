@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+const idempotencyHeader = "Idempotency-Key"
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if s.idempotency == nil || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBody))
+		if err != nil {
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeError(w, status, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		id, replay, err := s.idempotency.Begin(r.Context(), s.idempotencyScope(r), key, body)
+		switch {
+		case errors.Is(err, service.ErrIdempotencyInFlight):
+			writeError(w, http.StatusConflict, err)
+			return
+		case errors.Is(err, service.ErrIdempotencyMismatch):
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if replay != nil {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(replay.StatusCode)
+			_, _ = w.Write(replay.Body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		// Server errors are not remembered so the client can retry them.
+		ctx := context.WithoutCancel(r.Context())
+		if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+			if err := s.idempotency.Release(ctx, id); err != nil {
+				log.Printf("release idempotency key failed: %v", err)
+			}
+			return
+		}
+		if err := s.idempotency.Complete(ctx, id, recorder.status, recorder.body.Bytes()); err != nil {
+			log.Printf("store idempotent response failed: %v", err)
+		}
+	})
+}
+
+// idempotencyScope keeps keys of different callers apart, so two clients
+// that happen to pick the same key never see each other's responses. The
+// scope is hashed together with the key before it is stored.
+func (s *Server) idempotencyScope(r *http.Request) string {
+	caller := "anonymous"
+	switch principal, ok := principalFrom(r.Context()); {
+	case ok:
+		caller = "user:" + strconv.FormatInt(principal.UserID, 10)
+	case s.callerRole(r) == models.RoleAdmin:
+		caller = "admin"
+	case r.Header.Get(apiKeyHeader) != "":
+		caller = "key:" + r.Header.Get(apiKeyHeader)
+	}
+	return r.Method + " " + r.URL.Path + "\n" + r.Header.Get(tenantHeader) + "\n" + caller
+}
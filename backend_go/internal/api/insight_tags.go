@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type InsightTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type RenameInsightTagRequest struct {
+	Name string `json:"name"`
+}
+
+func insightTagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidInsight):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *Server) handleListInsightTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.insights.Tags(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": tags})
+}
+
+// handleSetInsightTags replaces the tags of an insight with those sent.
+func (s *Server) handleSetInsightTags(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	var payload InsightTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	insight, err := s.insights.SetTags(r.Context(), id, payload.Tags)
+	if err != nil {
+		writeError(w, insightTagErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": insight})
+}
+
+func (s *Server) handleRemoveInsightTag(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	insight, err := s.insights.RemoveTag(r.Context(), id, chi.URLParam(r, "tag"))
+	if err != nil {
+		writeError(w, insightTagErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": insight})
+}
+
+// handleRenameInsightTag renames a tag on every insight, merging it into an
+// existing tag of the new name.
+func (s *Server) handleRenameInsightTag(w http.ResponseWriter, r *http.Request) {
+	var payload RenameInsightTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	renamed, err := s.insights.RenameTag(r.Context(), chi.URLParam(r, "tag"), payload.Name)
+	if err != nil {
+		writeError(w, insightTagErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"tag": strings.ToLower(strings.TrimSpace(payload.Name)), "insights": renamed}})
+}
+
+func (s *Server) handleDeleteInsightTag(w http.ResponseWriter, r *http.Request) {
+	deleted, err := s.insights.DeleteTag(r.Context(), chi.URLParam(r, "tag"))
+	if err != nil {
+		writeError(w, insightTagErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"insights": deleted}})
+}
@@ -0,0 +1,26 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"mydashboard-backend/internal/apitest"
+	"mydashboard-backend/internal/models"
+)
+
+func TestViewerCannotChangeTags(t *testing.T) {
+	h := apitest.New(t)
+	insight := h.SeedInsight(models.Insight{Title: "Churn up", Message: "Churn rose in EMEA", Source: "rule", Severity: "warning"})
+	viewer := h.UserToken("vera", models.RoleViewer)
+	analyst := h.UserToken("anil", models.RoleAnalyst)
+	tags := fmt.Sprintf("/api/insights/%d/tags", insight.ID)
+	body := map[string]any{"tags": []string{"emea", "churn"}}
+
+	h.Do(apitest.Request{Method: http.MethodPut, Path: tags, Body: body, Token: viewer}).Status(http.StatusForbidden)
+	h.Do(apitest.Request{Method: http.MethodPut, Path: tags, Body: body, Token: analyst}).Status(http.StatusOK)
+	h.Do(apitest.Request{Method: http.MethodDelete, Path: tags + "/emea", Token: viewer}).Status(http.StatusForbidden)
+	if code := h.Do(apitest.Request{Method: http.MethodDelete, Path: tags + "/emea", Token: analyst}).Code(); code >= http.StatusBadRequest {
+		t.Errorf("analyst DELETE: status %d", code)
+	}
+}
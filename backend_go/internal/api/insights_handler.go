@@ -2,7 +2,19 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
 )
 
 func (s *Server) handleLatestInsights(w http.ResponseWriter, r *http.Request) {
@@ -10,12 +22,21 @@ func (s *Server) handleLatestInsights(w http.ResponseWriter, r *http.Request) {
 	if limit < 1 {
 		limit = 6
 	}
-	items, err := s.insights.Latest(r.Context(), limit)
+	if value := r.URL.Query().Get("tags"); value != "" {
+		items, err := s.insights.Tagged(r.Context(), requestLocale(r), strings.Split(value, ","), limit)
+		if err != nil {
+			writeError(w, insightTagErrorStatus(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, InsightsResponse{Data: items})
+		return
+	}
+	items, degraded, err := s.insights.Latest(r.Context(), requestLocale(r), limit)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, InsightsResponse{Data: items})
+	writeJSON(w, http.StatusOK, InsightsResponse{Data: items, Degraded: degraded})
 }
 
 func (s *Server) handleCreateInsight(w http.ResponseWriter, r *http.Request) {
@@ -25,7 +46,11 @@ func (s *Server) handleCreateInsight(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	insight, err := s.insights.Create(r.Context(), payload.MetricKey)
+	if payload.Title != "" || payload.Message != "" {
+		s.handlePostInsight(w, r, payload)
+		return
+	}
+	insight, err := s.insights.Create(r.Context(), payload.MetricKey, requestLocale(r))
 	if err != nil {
 		writeError(w, http.StatusBadGateway, err)
 		return
@@ -33,3 +58,267 @@ func (s *Server) handleCreateInsight(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, map[string]any{"data": insight})
 }
+
+// handlePostInsight stores human-written commentary, attributed to the
+// signed-in user.
+func (s *Server) handlePostInsight(w http.ResponseWriter, r *http.Request, payload InsightRequest) {
+	author := "api"
+	if principal, ok := principalFrom(r.Context()); ok {
+		author = principal.Username
+	}
+	insight, err := s.insights.Post(r.Context(), service.InsightPost{
+		Title:     payload.Title,
+		Message:   payload.Message,
+		Source:    payload.Source,
+		Severity:  payload.Severity,
+		MetricKey: payload.MetricKey,
+		Tags:      payload.Tags,
+		Author:    author,
+		Locale:    requestLocale(r),
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidInsight), errors.Is(err, service.ErrUnknownMetric):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("ETag", insightETag(insight))
+	writeJSON(w, http.StatusCreated, map[string]any{"data": insight})
+}
+
+func (s *Server) handleInsightContext(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	result, err := s.insights.Context(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("ETag", insightETag(result.Insight))
+	writeJSON(w, http.StatusOK, map[string]any{"data": result})
+}
+
+// UpdateInsightRequest edits an insight. The version being edited comes
+// from If-Match (the ETag of an earlier response) or, failing that, Version.
+type UpdateInsightRequest struct {
+	Title    *string `json:"title"`
+	Message  *string `json:"message"`
+	Severity *string `json:"severity"`
+	Version  *int    `json:"version"`
+}
+
+func insightETag(insight models.Insight) string {
+	return `"` + strconv.Itoa(insight.Version) + `"`
+}
+
+func (s *Server) handleUpdateInsight(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	var payload UpdateInsightRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	version := payload.Version
+	if match := r.Header.Get("If-Match"); match != "" {
+		parsed, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(match, "W/"), `"`))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("If-Match must be the insight's ETag"))
+			return
+		}
+		version = &parsed
+	}
+	if version == nil {
+		writeError(w, http.StatusPreconditionRequired, errors.New("send If-Match or version with the version being edited"))
+		return
+	}
+
+	insight, err := s.insights.Update(r.Context(), id, *version, service.InsightEdit{
+		Title:    payload.Title,
+		Message:  payload.Message,
+		Severity: payload.Severity,
+	})
+	switch {
+	case errors.Is(err, store.ErrConflict):
+		w.Header().Set("ETag", insightETag(insight))
+		writeError(w, http.StatusConflict, fmt.Errorf("%w: insight is now at version %d, reload it and reapply your changes", err, insight.Version))
+		return
+	case errors.Is(err, service.ErrInvalidInsight):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("ETag", insightETag(insight))
+	writeJSON(w, http.StatusOK, map[string]any{"data": insight})
+}
+
+// handleDeleteInsight moves an insight to the trash, from where it can be
+// restored until the purge-insights job removes it.
+func (s *Server) handleDeleteInsight(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	err = s.insights.Delete(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRestoreInsight(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid insight id"))
+		return
+	}
+	insight, err := s.insights.Restore(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("insight is not in the trash"))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": insight})
+}
+
+func (s *Server) handleInsightTrash(w http.ResponseWriter, r *http.Request) {
+	limit := min(max(parseQueryInt(r, "limit", 50), 1), 500)
+	items, err := s.insights.Trash(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, hidden := s.visibleMetrics(r, models.Metrics{})
+	visible := make([]models.Insight, 0, len(items))
+	for _, item := range items {
+		if insightVisible(item, hidden) {
+			visible = append(visible, item)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": visible})
+}
+
+// handleListInsightRules lists the rules, with ?team= those of a team and
+// the teams below it.
+func (s *Server) handleListInsightRules(w http.ResponseWriter, r *http.Request) {
+	inTeam, err := s.teamScope(r)
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	items, err := s.insights.ListRules(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if inTeam != nil {
+		items = slices.DeleteFunc(items, func(rule models.InsightRule) bool { return !inTeam(rule.TeamID) })
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+func (s *Server) handleCreateInsightRule(w http.ResponseWriter, r *http.Request) {
+	rule := models.InsightRule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	created, err := s.insights.CreateRule(r.Context(), rule)
+	if err != nil {
+		writeError(w, ruleErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": created})
+}
+
+// handlePreviewInsightRule reports when a proposed rule would have fired over
+// the from/to range, 7 days by default, without saving it.
+func (s *Server) handlePreviewInsightRule(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 7*24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var rule models.InsightRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	preview, err := s.insights.PreviewRule(r.Context(), rule, from, to)
+	if err != nil {
+		writeError(w, ruleErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": preview})
+}
+
+func (s *Server) handleUpdateInsightRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid rule id"))
+		return
+	}
+	var rule models.InsightRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	rule.ID = id
+	updated, err := s.insights.UpdateRule(r.Context(), rule)
+	if err != nil {
+		writeError(w, ruleErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": updated})
+}
+
+func (s *Server) handleDeleteInsightRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid rule id"))
+		return
+	}
+	if err := s.insights.DeleteRule(r.Context(), id); err != nil {
+		writeError(w, ruleErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func ruleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidRule):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrConflict):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
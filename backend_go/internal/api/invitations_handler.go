@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type CreateInvitationRequest struct {
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	TeamID   *int64 `json:"team_id"`
+	TeamRole string `json:"team_role"`
+	TTL      string `json:"ttl"`
+}
+
+type AcceptInvitationRequest struct {
+	Token       string `json:"token"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	DisplayName string `json:"display_name"`
+}
+
+func (s *Server) WithInvitations(invitations *service.InvitationService) *Server {
+	s.invitations = invitations
+	return s
+}
+
+func invitationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidInvitation), errors.Is(err, service.ErrInvalidUser):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrInvitationClosed):
+		return http.StatusGone
+	case errors.Is(err, store.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// handleCreateInvitation invites someone by email. The response carries the
+// token and link once; emailed tells whether the mail went out or the link
+// has to be passed on by hand.
+func (s *Server) handleCreateInvitation(w http.ResponseWriter, r *http.Request) {
+	var payload CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var ttl time.Duration
+	if payload.TTL != "" {
+		parsed, err := time.ParseDuration(payload.TTL)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("ttl must be a duration such as 72h"))
+			return
+		}
+		ttl = parsed
+	}
+	by := "api"
+	if principal, ok := principalFrom(r.Context()); ok {
+		by = principal.Username
+	}
+	inv, err := s.invitations.Create(r.Context(), models.Invitation{
+		Email:    payload.Email,
+		Role:     payload.Role,
+		TeamID:   payload.TeamID,
+		TeamRole: payload.TeamRole,
+	}, ttl, by)
+	if err != nil {
+		writeError(w, invitationErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": inv})
+}
+
+func (s *Server) handleListInvitations(w http.ResponseWriter, r *http.Request) {
+	items, err := s.invitations.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+func (s *Server) handleRevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid invitation id"))
+		return
+	}
+	if err := s.invitations.Revoke(r.Context(), id); err != nil {
+		writeError(w, invitationErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAcceptInvitation creates the account an invitation was for. The
+// token travels in the body rather than the path to keep it out of access
+// logs.
+func (s *Server) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var payload AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if payload.Token == "" {
+		writeError(w, http.StatusBadRequest, errors.New("token is required"))
+		return
+	}
+	user, err := s.invitations.Accept(r.Context(), payload.Token, models.User{
+		Username:    payload.Username,
+		DisplayName: payload.DisplayName,
+	}, payload.Password)
+	if errors.Is(err, store.ErrConflict) {
+		writeError(w, http.StatusConflict, errors.New("username already exists"))
+		return
+	}
+	if err != nil {
+		writeError(w, invitationErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": user})
+}
@@ -0,0 +1,34 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"mydashboard-backend/internal/apitest"
+	"mydashboard-backend/internal/models"
+)
+
+func TestAcceptInvitationWithAuthRequired(t *testing.T) {
+	h := apitest.New(t, apitest.WithAuthRequired())
+	var created struct {
+		Data models.Invitation `json:"data"`
+	}
+	h.Admin(http.MethodPost, "/api/admin/invitations", map[string]any{"email": "new@example.com", "role": models.RoleViewer}).
+		Status(http.StatusCreated).
+		Decode(&created)
+
+	var accepted struct {
+		Data models.User `json:"data"`
+	}
+	h.Do(apitest.Request{
+		Method: http.MethodPost,
+		Path:   "/api/invitations/accept",
+		Body:   map[string]any{"token": created.Data.Token, "username": "newcomer", "password": "apitest-Passw0rd!"},
+	}).Status(http.StatusCreated).Decode(&accepted)
+	if accepted.Data.Username != "newcomer" || accepted.Data.Role != models.RoleViewer {
+		t.Errorf("accepted %+v", accepted.Data)
+	}
+
+	// Other routes still need credentials.
+	h.Get("/api/metrics/latest").Status(http.StatusUnauthorized)
+}
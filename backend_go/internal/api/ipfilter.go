@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// IPFilterConfig restricts which clients may reach the API. Deny wins over
+// Allow; an empty Allow list admits everyone not denied.
+type IPFilterConfig struct {
+	Allow []netip.Prefix
+	Deny  []netip.Prefix
+}
+
+// ParsePrefixes accepts CIDRs and bare addresses, which become /32 or /128.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func (s *Server) WithIPFilter(cfg IPFilterConfig) *Server {
+	s.ipFilter = cfg
+	return s
+}
+
+// WithTrustedProxies enables forwarding headers for requests arriving from
+// the given proxies. With none configured the headers are ignored entirely.
+func (s *Server) WithTrustedProxies(proxies []netip.Prefix) *Server {
+	s.trustedProxies = proxies
+	return s
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr walks X-Forwarded-For from the right, skipping hops added by
+// trusted proxies, so a client cannot choose its address by sending the
+// header itself.
+func clientAddr(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+	if !containsAddr(trusted, addr) {
+		return addr, true
+	}
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	if len(hops) == 0 {
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			hops = []string{realIP}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return addr, true
+		}
+		addr = hop.Unmap()
+		if !containsAddr(trusted, addr) {
+			break
+		}
+	}
+	return addr, true
+}
+
+// realIP replaces RemoteAddr with the resolved client address so handlers,
+// logs, rate limits and the IP filter all see the same value.
+func (s *Server) realIP(next http.Handler) http.Handler {
+	if len(s.trustedProxies) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if addr, ok := clientAddr(r, s.trustedProxies); ok {
+			r.RemoteAddr = addr.String()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) filterIPs(next http.Handler) http.Handler {
+	cfg := s.ipFilter
+	if len(cfg.Allow) == 0 && len(cfg.Deny) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		addr, ok := clientAddr(r, nil)
+		if !ok || containsAddr(cfg.Deny, addr) || (len(cfg.Allow) > 0 && !containsAddr(cfg.Allow, addr)) {
+			log.Printf("rejected request from %s to %s", r.RemoteAddr, r.URL.Path)
+			writeError(w, http.StatusForbidden, errors.New("client address not allowed"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/scheduler"
+	"mydashboard-backend/internal/store"
+)
+
+type JobUpdateRequest struct {
+	Schedule *string `json:"schedule"`
+	Enabled  *bool   `json:"enabled"`
+}
+
+// handleListJobs shows run times in ?tz, the caller's profile timezone or
+// the server's, so report schedules read in local time.
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	loc, err := requestLocation(r, s.location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	jobs := s.scheduler.Jobs()
+	for i := range jobs {
+		jobs[i] = jobIn(jobs[i], loc)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": jobs})
+}
+
+func jobIn(job models.ScheduledJob, loc *time.Location) models.ScheduledJob {
+	if job.NextRunAt != nil {
+		next := job.NextRunAt.In(loc)
+		job.NextRunAt = &next
+	}
+	if job.LastRunAt != nil {
+		last := job.LastRunAt.In(loc)
+		job.LastRunAt = &last
+	}
+	return job
+}
+
+func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request) {
+	loc, err := requestLocation(r, s.location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var payload JobUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	job, err := s.scheduler.Update(r.Context(), chi.URLParam(r, "name"), payload.Schedule, payload.Enabled)
+	if err != nil {
+		writeError(w, jobErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": jobIn(job, loc)})
+}
+
+func (s *Server) handleRunJob(w http.ResponseWriter, r *http.Request) {
+	job, err := s.scheduler.Trigger(chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, jobErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"data": job})
+}
+
+func jobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, scheduler.ErrUnknownJob):
+		return http.StatusNotFound
+	case errors.Is(err, scheduler.ErrInvalidSchedule):
+		return http.StatusBadRequest
+	case errors.Is(err, scheduler.ErrJobRunning):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid job id"))
+		return
+	}
+	job, err := s.jobs.Get(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": job})
+}
+
+func wantsAsync(r *http.Request) bool {
+	async, _ := strconv.ParseBool(r.URL.Query().Get("async"))
+	return async
+}
+
+func (s *Server) enqueueJob(w http.ResponseWriter, r *http.Request, kind string, payload any) {
+	job, err := s.jobs.Enqueue(r.Context(), kind, payload)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Location", "/api/jobs/"+strconv.FormatInt(job.ID, 10))
+	writeJSON(w, http.StatusAccepted, map[string]any{"data": job})
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type MetricMetaRequest struct {
+	Labels       map[string]string        `json:"labels"`
+	Descriptions map[string]string        `json:"descriptions"`
+	Unit         string                   `json:"unit"`
+	Color        string                   `json:"color"`
+	Thresholds   []models.MetricThreshold `json:"thresholds"`
+}
+
+func (s *Server) WithMetricMeta(meta *service.MetricMetaService) *Server {
+	s.metricMeta = meta
+	return s
+}
+
+// metaTenant is the tenant of ?tenant=, else of the X-Tenant-ID header.
+func metaTenant(r *http.Request) string {
+	if tenant := r.URL.Query().Get("tenant"); tenant != "" {
+		return tenant
+	}
+	return r.Header.Get(tenantHeader)
+}
+
+// handleMetricMeta serves the labels, units, colors and thresholds the
+// frontend displays metrics with, for the caller's tenant and locale.
+func (s *Server) handleMetricMeta(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", tenantHeader)
+	locale := requestLocale(r)
+	if s.metricMeta == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"data": []models.MetricMeta{}, "locale": locale})
+		return
+	}
+	items, err := s.metricMeta.List(r.Context(), metaTenant(r), locale)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items, "locale": locale})
+}
+
+func (s *Server) handleListMetricMeta(w http.ResponseWriter, r *http.Request) {
+	items, err := s.metricMeta.Overrides(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+// handleSaveMetricMeta replaces the override of a metric for ?tenant=, or
+// for every tenant without it.
+func (s *Server) handleSaveMetricMeta(w http.ResponseWriter, r *http.Request) {
+	var payload MetricMetaRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	item, err := s.metricMeta.Save(r.Context(), models.MetricMetaOverride{
+		Tenant:       r.URL.Query().Get("tenant"),
+		Key:          chi.URLParam(r, "key"),
+		Labels:       payload.Labels,
+		Descriptions: payload.Descriptions,
+		Unit:         payload.Unit,
+		Color:        payload.Color,
+		Thresholds:   payload.Thresholds,
+	})
+	if err != nil {
+		writeError(w, metricMetaErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": item})
+}
+
+func (s *Server) handleDeleteMetricMeta(w http.ResponseWriter, r *http.Request) {
+	if err := s.metricMeta.Delete(r.Context(), r.URL.Query().Get("tenant"), chi.URLParam(r, "key")); err != nil {
+		writeError(w, metricMetaErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func metricMetaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidMetricMeta), errors.Is(err, service.ErrUnknownMetric):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -1,38 +1,433 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	defaultHistoryPageSize = 1000
+	maxHistoryPageSize     = 10000
 )
 
 func (s *Server) handleLatestMetrics(w http.ResponseWriter, r *http.Request) {
-	metrics, err := s.metrics.Latest(r.Context())
+	units, factors, err := s.units(r)
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	metrics, degraded, err := s.metrics.Latest(r.Context())
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	resp := MetricsResponse{Data: metrics, Timestamp: time.Now()}
+	metrics, redacted := s.visibleMetrics(r, metrics)
+	metrics = s.convert(metrics, factors)
+	resp := MetricsResponse{Data: metrics, Timestamp: time.Now(), Degraded: degraded, Redacted: redacted, Units: units}
 	writeJSON(w, http.StatusOK, resp)
 }
 
+// handleTrend serves the last window snapshots with a checkpoint. Passing it
+// back as ?checkpoint= returns only the snapshots added since, to append to
+// the chart; with reset set the client should redraw instead.
 func (s *Server) handleTrend(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("expr") {
+		s.handleTrendExpression(w, r)
+		return
+	}
+	fill, step, err := parseFill(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if query := r.URL.Query(); query.Has("from") || query.Has("to") {
+		s.streamTrend(w, r, fill, step)
+		return
+	}
 	window := parseQueryInt(r, "window", 12)
 	if window < 3 {
 		window = 3
 	}
-	points, err := s.metrics.Trend(r.Context(), window)
+	units, factors, err := s.units(r)
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	var points []models.Metrics
+	var checkpoint string
+	var reset bool
+	if query := r.URL.Query(); query.Get("checkpoint") != "" {
+		points, checkpoint, reset, err = s.metrics.TrendSince(r.Context(), query.Get("checkpoint"), window)
+	} else {
+		points, checkpoint, err = s.metrics.TrendCheckpoint(r.Context(), window)
+	}
+	if errors.Is(err, service.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, errors.New("invalid checkpoint"))
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
+	// Embed tokens lose everything outside their scope, derived metrics
+	// included, on top of the role's redaction.
+	_, redacted := s.visibleMetrics(r, models.Metrics{})
+	visible := make([]models.Metrics, len(points))
+	for i := range points {
+		visible[i], _ = s.visibleMetrics(r, points[i])
+	}
+	points = visible
 	trend := make([]TrendPoint, 0, len(points))
+	filler := service.NewGapFiller(fill, step, func(point models.Metrics, filled string) error {
+		trend = append(trend, s.trendPoint(point, factors, filled))
+		return nil
+	})
 	for _, point := range points {
-		trend = append(trend, TrendPoint{
-			Timestamp: point.CreatedAt,
-			Revenue:   point.Revenue,
-		})
+		_ = filler.Add(point)
+	}
+	_ = filler.Close()
+	resp := TrendResponse{Data: trend, Redacted: redacted, Checkpoint: checkpoint, Reset: reset}
+	if unit, ok := units["revenue"]; ok {
+		resp.Unit = &unit
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// trendPoint converts a snapshot, or a point added by the gap filler, for
+// the response.
+func (s *Server) trendPoint(point models.Metrics, factors map[string]float64, filled string) TrendPoint {
+	point = s.convert(point, factors)
+	return TrendPoint{Timestamp: point.CreatedAt, Revenue: point.Revenue, Derived: point.Derived, Filled: filled}
+}
+
+// parseFill reads ?fill= and ?step=, the expected interval between
+// snapshots; without a step the gap filler infers it.
+func parseFill(r *http.Request) (string, time.Duration, error) {
+	fill := r.URL.Query().Get("fill")
+	if err := service.CheckFill(fill); err != nil {
+		return "", 0, err
+	}
+	step, err := parseQueryDuration(r, "step", 0)
+	if err != nil {
+		return "", 0, err
+	}
+	if step < 0 {
+		return "", 0, errors.New("step must be positive")
+	}
+	return fill, step, nil
+}
+
+// streamTrend writes the revenue trend over a from/to range while it is read
+// from the store. With ?fill= and no step, the first points are held back
+// until the step is inferred.
+func (s *Server) streamTrend(w http.ResponseWriter, r *http.Request, fill string, step time.Duration) {
+	from, to, err := parseRange(r, 24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	units, factors, err := s.units(r)
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	meta := map[string]any{}
+	if _, redacted := s.visibleMetrics(r, models.Metrics{}); len(redacted) > 0 {
+		meta["redacted"] = redacted
+	}
+	if unit, ok := units["revenue"]; ok {
+		meta["unit"] = unit
+	}
+	stream := newArrayStream(w, r, meta)
+	filler := service.NewGapFiller(fill, step, func(point models.Metrics, filled string) error {
+		return stream.Write(s.trendPoint(point, factors, filled))
+	})
+	err = s.metrics.TrendRange(r.Context(), from, to, func(point models.Metrics) error {
+		point, _ = s.visibleMetrics(r, point)
+		return filler.Add(point)
+	})
+	if err == nil {
+		err = filler.Close()
+	}
+	stream.Close(err)
+}
+
+// handleTrendExpression evaluates ?expr= over the last window snapshots or,
+// with from or to, over that range. See MetricsService.CompileQuery for the
+// language. Values are in base units and every metric referenced must be
+// readable in full by the caller.
+func (s *Server) handleTrendExpression(w http.ResponseWriter, r *http.Request) {
+	q, ok := s.compileQuery(w, r, r.URL.Query().Get("expr"))
+	if !ok {
+		return
+	}
+	var points []models.MetricPoint
+	var err error
+	if query := r.URL.Query(); query.Has("from") || query.Has("to") {
+		from, to, rangeErr := parseRange(r, 24*time.Hour)
+		if rangeErr != nil {
+			writeError(w, http.StatusBadRequest, rangeErr)
+			return
+		}
+		points, err = s.metrics.RunQuery(r.Context(), q, from, to, parseQueryInt(r, "max_points", 0))
+	} else {
+		points, err = s.metrics.QueryTrend(r.Context(), q, max(parseQueryInt(r, "window", 12), 3))
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ExpressionResponse{Expr: q.Expr, Metrics: q.Metrics, Data: points})
+}
+
+// compileQuery compiles expr and checks the caller may read its metrics,
+// writing the error response when not.
+func (s *Server) compileQuery(w http.ResponseWriter, r *http.Request, expr string) (*service.MetricQuery, bool) {
+	q, err := s.metrics.CompileQuery(r.Context(), expr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	if !s.canReadQuery(r, q) {
+		writeError(w, http.StatusForbidden, service.ErrMetricRestricted)
+		return nil, false
+	}
+	return q, true
+}
+
+// canReadQuery reports whether the caller may read every metric q uses.
+func (s *Server) canReadQuery(r *http.Request, q *service.MetricQuery) bool {
+	if embed, scoped := embedFrom(r.Context()); scoped {
+		for _, key := range q.Metrics {
+			if !slices.Contains(embed.Metrics, key) {
+				return false
+			}
+		}
+	}
+	return !s.redactor.Restricted(s.callerRole(r), q.Metrics...)
+}
+
+// handleMetricsHistory pages through the stored snapshots with all fields
+// and no unit conversion or downsampling. Pass next_cursor back as cursor,
+// with the same from and to, to get the following page.
+func (s *Server) handleMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	pageSize := min(max(parseQueryInt(r, "page_size", defaultHistoryPageSize), 1), maxHistoryPageSize)
+	rows, next, err := s.metrics.History(r.Context(), from, to, r.URL.Query().Get("cursor"), pageSize)
+	if errors.Is(err, service.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	_, redacted := s.visibleMetrics(r, models.Metrics{})
+	for i := range rows {
+		rows[i].Metrics, _ = s.visibleMetrics(r, rows[i].Metrics)
+	}
+	if rows == nil {
+		rows = []models.MetricSnapshot{}
+	}
+	writeJSON(w, http.StatusOK, MetricHistoryResponse{Data: rows, NextCursor: next, Redacted: redacted})
+}
+
+func (s *Server) handleMetricTrend(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	window := parseQueryInt(r, "window", 12)
+	if window < 3 {
+		window = 3
+	}
+	span := parseQueryInt(r, "span", 5)
+	method := r.URL.Query().Get("smooth")
+	units, factors, err := s.units(r)
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	points, err := s.metrics.MetricTrend(r.Context(), key, window, method, span)
+	if errors.Is(err, service.ErrUnknownMetric) || errors.Is(err, service.ErrUnknownSmoothing) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	points, redacted, err := s.redactor.Points(s.callerRole(r), key, points)
+	if err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	if factor := factors[key]; factor != 0 && factor != 1 {
+		for i := range points {
+			points[i].Value *= factor
+		}
+	}
+	for i := range points {
+		points[i].Value = s.catalog.RoundValue(key, points[i].Value)
+	}
+	if method == "" {
+		method = service.SmoothNone
+	}
+	resp := MetricTrendResponse{Metric: key, Smooth: method, Redacted: redacted, Data: points}
+	if unit, ok := units[key]; ok {
+		resp.Unit = &unit
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleMetricDistribution(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	buckets := parseQueryInt(r, "buckets", 20)
+	if buckets < 1 || buckets > 200 {
+		buckets = 20
 	}
-	writeJSON(w, http.StatusOK, TrendResponse{Data: trend})
+	key := chi.URLParam(r, "key")
+	if s.redactor.Restricted(s.callerRole(r), key) {
+		writeError(w, http.StatusForbidden, service.ErrMetricRestricted)
+		return
+	}
+	dist, err := s.metrics.Distribution(r.Context(), key, from, to, buckets)
+	if errors.Is(err, service.ErrUnknownMetric) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": dist})
+}
+
+func (s *Server) handleCorrelate(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	query := r.URL.Query()
+	x, y := query.Get("x"), query.Get("y")
+	if x == "" || y == "" {
+		writeError(w, http.StatusBadRequest, errors.New("x and y metric keys are required"))
+		return
+	}
+	if s.redactor.Restricted(s.callerRole(r), x, y) {
+		writeError(w, http.StatusForbidden, service.ErrMetricRestricted)
+		return
+	}
+	maxLag := parseQueryInt(r, "max_lag", 10)
+	if maxLag < 0 || maxLag > 500 {
+		maxLag = 10
+	}
+	result, err := s.metrics.Correlate(r.Context(), x, y, from, to, maxLag)
+	if errors.Is(err, service.ErrUnknownMetric) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": result})
+}
+
+func (s *Server) handleMetricHeatmap(w http.ResponseWriter, r *http.Request) {
+	weeks := parseQueryInt(r, "weeks", 4)
+	if weeks < 1 || weeks > 52 {
+		weeks = 4
+	}
+	from, to, err := parseRange(r, time.Duration(weeks)*7*24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	loc, err := requestLocation(r, s.location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	key := chi.URLParam(r, "key")
+	if s.redactor.Restricted(s.callerRole(r), key) {
+		writeError(w, http.StatusForbidden, service.ErrMetricRestricted)
+		return
+	}
+	cells, err := s.metrics.Heatmap(r.Context(), key, from, to, loc)
+	if errors.Is(err, service.ErrUnknownMetric) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp := HeatmapResponse{Metric: key, Timezone: loc.String(), From: from, To: to, Data: cells}
+	for i := range cells {
+		if resp.Peak == nil || cells[i].Average > resp.Peak.Average {
+			resp.Peak = &cells[i]
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleMetricsDiff(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("at1") == "" {
+		writeError(w, http.StatusBadRequest, errors.New("at1 is required"))
+		return
+	}
+	at1, err := parseQueryTime(r, "at1", time.Time{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	at2, err := parseQueryTime(r, "at2", time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	units, factors, err := s.units(r)
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	from, to, err := s.metrics.SnapshotsAt(r.Context(), at1, at2)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("no snapshot recorded at or before the requested time"))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	role := s.callerRole(r)
+	from, redacted := s.redactor.Metrics(role, from)
+	to, _ = s.redactor.Metrics(role, to)
+	diff := service.Diff(at1, at2, s.convert(from, factors), s.convert(to, factors), redacted)
+	resp := map[string]any{"data": diff}
+	if len(redacted) > 0 {
+		resp["redacted"] = redacted
+	}
+	if units != nil {
+		resp["units"] = units
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) handleSimulateMetrics(w http.ResponseWriter, r *http.Request) {
@@ -43,3 +438,71 @@ func (s *Server) handleSimulateMetrics(w http.ResponseWriter, r *http.Request) {
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"data": next})
 }
+
+func (s *Server) handleIngestMetrics(w http.ResponseWriter, r *http.Request) {
+	var payload models.Metrics
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	saved, flagged, err := s.metrics.Ingest(r.Context(), []models.Metrics{payload})
+	if err != nil {
+		writeError(w, ingestErrorStatus(err), err)
+		return
+	}
+	// A snapshot folded into the previous one by dedup is accepted but not
+	// stored, so there is no row to return.
+	resp := map[string]any{"data": nil, "folded": true}
+	if len(saved) > 0 {
+		resp = map[string]any{"data": saved[0]}
+	}
+	if len(flagged) > 0 {
+		resp["flagged"] = flagged
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// maxImportBody bounds bulk writes; the idempotency middleware buffers
+// bodies up to the same size.
+const maxImportBody = 32 << 20
+
+func (s *Server) handleImportMetrics(w http.ResponseWriter, r *http.Request) {
+	var payload MetricsImportRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportBody)).Decode(&payload); err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, err)
+		return
+	}
+	if len(payload.Data) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("data must contain at least one snapshot"))
+		return
+	}
+	if wantsAsync(r) {
+		s.enqueueJob(w, r, models.JobKindMetricsImport, payload.Data)
+		return
+	}
+	saved, flagged, err := s.metrics.Ingest(r.Context(), payload.Data)
+	if err != nil {
+		writeError(w, ingestErrorStatus(err), err)
+		return
+	}
+	resp := map[string]any{"data": saved, "count": len(saved)}
+	if folded := len(payload.Data) - len(saved); folded > 0 {
+		resp["folded"] = folded
+	}
+	if len(flagged) > 0 {
+		resp["flagged"] = flagged
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func ingestErrorStatus(err error) int {
+	if errors.Is(err, service.ErrImplausibleMetrics) {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+// NotificationChannelRequest creates or replaces a channel. Secret is write
+// only; leaving it out of an update keeps the stored one.
+type NotificationChannelRequest struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	WebhookURL  string   `json:"webhook_url"`
+	Secret      *string  `json:"secret"`
+	EventTypes  []string `json:"event_types"`
+	MinSeverity string   `json:"min_severity"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+func (req NotificationChannelRequest) channel() models.NotificationChannel {
+	channel := models.NotificationChannel{
+		Name:        req.Name,
+		Kind:        req.Kind,
+		WebhookURL:  req.WebhookURL,
+		EventTypes:  req.EventTypes,
+		MinSeverity: req.MinSeverity,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	if req.Secret != nil {
+		channel.Secret = *req.Secret
+	}
+	return channel
+}
+
+func (s *Server) WithNotifications(notifications *service.NotificationService) *Server {
+	s.notifications = notifications
+	return s
+}
+
+func (s *Server) handleListChannels(w http.ResponseWriter, r *http.Request) {
+	items, err := s.notifications.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+func (s *Server) handleCreateChannel(w http.ResponseWriter, r *http.Request) {
+	var payload NotificationChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	created, err := s.notifications.Create(r.Context(), payload.channel())
+	if err != nil {
+		writeError(w, channelErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": created})
+}
+
+func (s *Server) handleUpdateChannel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid channel id"))
+		return
+	}
+	var payload NotificationChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	channel := payload.channel()
+	channel.ID = id
+	updated, err := s.notifications.Update(r.Context(), channel, payload.Secret)
+	if err != nil {
+		writeError(w, channelErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": updated})
+}
+
+func (s *Server) handleDeleteChannel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid channel id"))
+		return
+	}
+	if err := s.notifications.Delete(r.Context(), id); err != nil {
+		writeError(w, channelErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleTestChannel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid channel id"))
+		return
+	}
+	err = s.notifications.Test(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "sent"})
+}
+
+func channelErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidChannel):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrConflict):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
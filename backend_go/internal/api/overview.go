@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+// OverviewResponse is what the landing page needs on first load. Deltas
+// compare with the snapshot in effect 24 hours ago and sparklines cover the
+// same span.
+type OverviewResponse struct {
+	Metrics     models.Metrics                     `json:"metrics"`
+	Deltas      map[string]models.MetricDelta      `json:"deltas"`
+	Sparklines  map[string][]float64               `json:"sparklines"`
+	OpenAlerts  int                                `json:"open_alerts"`
+	TopInsight  *models.Insight                    `json:"top_insight"`
+	Units       map[string]models.MetricDefinition `json:"units,omitempty"`
+	Redacted    []string                           `json:"redacted,omitempty"`
+	Degraded    bool                               `json:"degraded,omitempty"`
+	GeneratedAt time.Time                          `json:"generated_at"`
+}
+
+func (s *Server) WithOverview(overview *service.OverviewService) *Server {
+	s.overview = overview
+	return s
+}
+
+// handleOverview serves the materialized overview, redacted and converted
+// for the caller. The cached snapshots are shared, so they are copied first.
+func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
+	units, factors, err := s.units(r)
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	overview, err := s.overview.Get(r.Context(), requestLocale(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	latest, redacted := s.visibleMetrics(r, overview.Latest)
+	latest = s.convert(latest, factors)
+	baseline := s.visibleSeries(r, []models.Metrics{overview.Baseline}, factors)[0]
+	trend := s.visibleSeries(r, slices.Clone(overview.Trend), factors)
+
+	resp := OverviewResponse{
+		Metrics:     latest,
+		Deltas:      service.Diff(baseline.CreatedAt, latest.CreatedAt, baseline, latest, redacted).Deltas,
+		OpenAlerts:  overview.OpenAlerts,
+		Units:       units,
+		Redacted:    redacted,
+		Degraded:    overview.Degraded,
+		GeneratedAt: overview.BuiltAt,
+	}
+	resp.Sparklines = sparklines(trend, resp.Deltas)
+	if top := topInsights(overview.Insights, redacted, 1); len(top) > 0 {
+		resp.TopInsight = &top[0]
+	}
+	writeJSONWithETag(w, r, resp)
+}
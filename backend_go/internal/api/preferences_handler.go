@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+// PreferencesResponse adds the deliveries this server can send, so clients
+// can warn about rules that will not be delivered.
+type PreferencesResponse struct {
+	Data       models.NotificationPreferences `json:"data"`
+	Deliveries []string                       `json:"deliveries"`
+}
+
+func (s *Server) WithNotificationPreferences(preferences *service.NotificationPreferenceService) *Server {
+	s.preferences = preferences
+	return s
+}
+
+func (s *Server) handleGetPreferences(w http.ResponseWriter, r *http.Request) {
+	if s.preferences == nil {
+		writeError(w, http.StatusNotFound, errors.New("notification preferences are not enabled"))
+		return
+	}
+	principal, _ := principalFrom(r.Context())
+	prefs, err := s.preferences.Get(r.Context(), principal.UserID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PreferencesResponse{Data: prefs, Deliveries: s.preferences.Deliveries()})
+}
+
+// handleSavePreferences replaces the caller's settings with those sent.
+func (s *Server) handleSavePreferences(w http.ResponseWriter, r *http.Request) {
+	if s.preferences == nil {
+		writeError(w, http.StatusNotFound, errors.New("notification preferences are not enabled"))
+		return
+	}
+	var prefs models.NotificationPreferences
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	principal, _ := principalFrom(r.Context())
+	prefs.UserID = principal.UserID
+	saved, err := s.preferences.Save(r.Context(), prefs)
+	if errors.Is(err, service.ErrInvalidPreferences) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PreferencesResponse{Data: saved, Deliveries: s.preferences.Deliveries()})
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:mydashboard:problem:"
+)
+
+// Problem is an RFC 7807 error body.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// problemTypes maps known error classes to stable type names. Anything else
+// falls back to a name derived from the status code.
+var problemTypes = []struct {
+	err  error
+	name string
+}{
+	{store.ErrCircuitOpen, "circuit-open"},
+	{store.ErrNotFound, "not-found"},
+	{store.ErrConflict, "conflict"},
+	{service.ErrUnknownMetric, "unknown-metric"},
+	{service.ErrUnknownSmoothing, "unknown-smoothing"},
+	{service.ErrInvalidQuery, "invalid-expression"},
+	{service.ErrMetricRestricted, "metric-restricted"},
+	{service.ErrImplausibleMetrics, "implausible-metrics"},
+	{service.ErrIdempotencyInFlight, "idempotency-in-flight"},
+	{service.ErrIdempotencyMismatch, "idempotency-mismatch"},
+	{service.ErrInvalidCredentials, "invalid-credentials"},
+	{service.ErrUnauthenticated, "unauthenticated"},
+	{service.ErrOTPRequired, "otp-required"},
+	{service.ErrInvalidOTP, "invalid-otp"},
+}
+
+var statusProblemTypes = map[int]string{
+	http.StatusBadRequest:           "bad-request",
+	http.StatusUnauthorized:         "unauthenticated",
+	http.StatusForbidden:            "forbidden",
+	http.StatusNotFound:             "not-found",
+	http.StatusConflict:             "conflict",
+	http.StatusPreconditionRequired: "precondition-required",
+	http.StatusUnprocessableEntity:  "unprocessable",
+	http.StatusServiceUnavailable:   "unavailable",
+	http.StatusGatewayTimeout:       "timeout",
+	http.StatusInternalServerError:  "internal",
+}
+
+func problemType(status int, err error) string {
+	var timeoutErr *store.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return problemTypePrefix + "timeout"
+	}
+	for _, known := range problemTypes {
+		if errors.Is(err, known.err) {
+			return problemTypePrefix + known.name
+		}
+	}
+	if name, ok := statusProblemTypes[status]; ok {
+		return problemTypePrefix + name
+	}
+	if status >= http.StatusInternalServerError {
+		return problemTypePrefix + "internal"
+	}
+	return problemTypePrefix + "bad-request"
+}
+
+// problemWriter marks a response whose client asked for problem+json.
+// writeError finds it through the Unwrap chain of any later wrappers.
+type problemWriter struct {
+	http.ResponseWriter
+	instance  string
+	requestID string
+}
+
+func (w *problemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func negotiateProblems(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accepts(r, problemContentType) {
+			w = &problemWriter{ResponseWriter: w, instance: r.URL.Path, requestID: middleware.GetReqID(r.Context())}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func findProblemWriter(w http.ResponseWriter) (*problemWriter, bool) {
+	for {
+		switch current := w.(type) {
+		case *problemWriter:
+			return current, true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = current.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+func writeProblem(w http.ResponseWriter, pw *problemWriter, status int, err error) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(Problem{
+		Type:      problemType(status, err),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    err.Error(),
+		Instance:  pw.instance,
+		RequestID: pw.requestID,
+	})
+}
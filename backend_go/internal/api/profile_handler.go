@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type ProfileRequest struct {
+	DisplayName string `json:"display_name"`
+	Locale      string `json:"locale"`
+	Timezone    string `json:"timezone"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+func (s *Server) handleGetProfile(w http.ResponseWriter, r *http.Request) {
+	principal, _ := principalFrom(r.Context())
+	user, err := s.auth.Profile(r.Context(), principal)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("user not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": user})
+}
+
+// handleUpdateProfile replaces the caller's profile; fields left out are
+// cleared. An empty locale or timezone falls back to Accept-Language and the
+// server timezone.
+func (s *Server) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var payload ProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	principal, _ := principalFrom(r.Context())
+	user, err := s.auth.UpdateProfile(r.Context(), principal, models.User{
+		DisplayName: payload.DisplayName,
+		Locale:      payload.Locale,
+		Timezone:    payload.Timezone,
+		AvatarURL:   payload.AvatarURL,
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidProfile):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("user not found"))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": user})
+}
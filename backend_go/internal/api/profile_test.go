@@ -0,0 +1,39 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"mydashboard-backend/internal/apitest"
+	"mydashboard-backend/internal/models"
+)
+
+func TestProfile(t *testing.T) {
+	h := apitest.New(t)
+	token := h.UserToken("ana", models.RoleViewer)
+	me := func(method string, body any) *apitest.Response {
+		return h.Do(apitest.Request{Method: method, Path: "/api/me/", Body: body, Token: token})
+	}
+
+	me(http.MethodPut, map[string]any{
+		"display_name": "Ana Lima",
+		"locale":       "zh",
+		"timezone":     "Asia/Shanghai",
+		"avatar_url":   "https://example.com/ana.png",
+	}).Status(http.StatusOK)
+	me(http.MethodGet, nil).Status(http.StatusOK).Golden("profile", "id", "created_at", "updated_at", "server_time")
+
+	me(http.MethodPut, map[string]any{"timezone": "Local"}).Status(http.StatusBadRequest)
+	me(http.MethodPut, map[string]any{"avatar_url": "ftp://example.com/ana.png"}).Status(http.StatusBadRequest)
+
+	// Fields left out are cleared.
+	var cleared struct {
+		Data models.User `json:"data"`
+	}
+	me(http.MethodPut, map[string]any{"display_name": "Ana"}).Status(http.StatusOK).Decode(&cleared)
+	if cleared.Data.Locale != "" || cleared.Data.Timezone != "" || cleared.Data.AvatarURL != "" {
+		t.Errorf("got %+v, want locale, timezone and avatar cleared", cleared.Data)
+	}
+
+	h.Get("/api/me/").Status(http.StatusUnauthorized)
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const redactedValue = "[redacted]"
+
+var (
+	secretHeaders = map[string]bool{
+		"Authorization": true,
+		"Cookie":        true,
+		"Set-Cookie":    true,
+		apiKeyHeader:    true,
+	}
+	secretField = regexp.MustCompile(`(?i)("[^"]*(?:password|secret|token|otp|api_?key)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+	secretParam = regexp.MustCompile(`(?i)(?:password|secret|token|otp|api_?key)`)
+)
+
+// RecordedExchange is one captured request/response pair. Bodies are cut at
+// the recorder's limit and secrets are replaced before they are stored.
+type RecordedExchange struct {
+	ID                int64             `json:"id"`
+	Time              time.Time         `json:"time"`
+	Method            string            `json:"method"`
+	Path              string            `json:"path"`
+	Query             string            `json:"query,omitempty"`
+	Status            int               `json:"status"`
+	DurationMs        float64           `json:"duration_ms"`
+	RemoteAddr        string            `json:"remote_addr"`
+	RequestID         string            `json:"request_id,omitempty"`
+	RequestHeaders    map[string]string `json:"request_headers"`
+	RequestBody       string            `json:"request_body,omitempty"`
+	RequestTruncated  bool              `json:"request_truncated,omitempty"`
+	ResponseBody      string            `json:"response_body,omitempty"`
+	ResponseTruncated bool              `json:"response_truncated,omitempty"`
+}
+
+// RequestRecorder keeps the most recent exchanges in a fixed-size ring. A
+// sample of all traffic is kept, plus every error response if recordErrors
+// is set, since those are usually the ones worth looking at.
+type RequestRecorder struct {
+	sampleRate   float64
+	recordErrors bool
+	maxBody      int
+
+	mu     sync.Mutex
+	ring   []RecordedExchange
+	next   int
+	lastID int64
+}
+
+func NewRequestRecorder(capacity int, sampleRate float64, recordErrors bool, maxBody int) *RequestRecorder {
+	return &RequestRecorder{
+		sampleRate:   sampleRate,
+		recordErrors: recordErrors,
+		maxBody:      maxBody,
+		ring:         make([]RecordedExchange, 0, max(capacity, 1)),
+	}
+}
+
+func (rec *RequestRecorder) add(exchange RecordedExchange) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.lastID++
+	exchange.ID = rec.lastID
+	if len(rec.ring) < cap(rec.ring) {
+		rec.ring = append(rec.ring, exchange)
+		return
+	}
+	rec.ring[rec.next] = exchange
+	rec.next = (rec.next + 1) % len(rec.ring)
+}
+
+// Recent returns up to limit exchanges, newest first.
+func (rec *RequestRecorder) Recent(limit int, errorsOnly bool) []RecordedExchange {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	out := make([]RecordedExchange, 0, min(limit, len(rec.ring)))
+	for i := 0; i < len(rec.ring) && len(out) < limit; i++ {
+		exchange := rec.ring[(rec.next+len(rec.ring)-1-i)%len(rec.ring)]
+		if errorsOnly && exchange.Status < http.StatusBadRequest {
+			continue
+		}
+		out = append(out, exchange)
+	}
+	return out
+}
+
+// capture keeps the first limit bytes that pass through it.
+type capture struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	room := c.limit - c.buf.Len()
+	if len(p) > room {
+		c.truncated = true
+		p = p[:max(room, 0)]
+	}
+	c.buf.Write(p)
+	return len(p), nil
+}
+
+type captureBody struct {
+	io.ReadCloser
+	capture *capture
+}
+
+func (b captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	_, _ = b.capture.Write(p[:n])
+	return n, err
+}
+
+func redactBody(body []byte) string {
+	return secretField.ReplaceAllString(string(body), `$1"`+redactedValue+`"`)
+}
+
+func redactQuery(r *http.Request) string {
+	query := r.URL.Query()
+	for key := range query {
+		if secretParam.MatchString(key) {
+			query[key] = []string{redactedValue}
+		}
+	}
+	return query.Encode()
+}
+
+func redactHeaders(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for key, values := range header {
+		if secretHeaders[key] {
+			out[key] = redactedValue
+			continue
+		}
+		out[key] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+func (s *Server) WithRequestRecorder(recorder *RequestRecorder) *Server {
+	s.recorder = recorder
+	return s
+}
+
+func (s *Server) recordRequests(next http.Handler) http.Handler {
+	rec := s.recorder
+	if rec == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sampled := rec.sampleRate > 0 && rand.Float64() < rec.sampleRate
+		if (!sampled && !rec.recordErrors) || strings.HasPrefix(r.URL.Path, "/api/admin/debug/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		started := time.Now()
+		reqBody := &capture{limit: rec.maxBody}
+		if r.Body != nil {
+			r.Body = captureBody{ReadCloser: r.Body, capture: reqBody}
+		}
+		respBody := &capture{limit: rec.maxBody}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(respBody)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if !sampled && status < http.StatusBadRequest {
+			return
+		}
+		rec.add(RecordedExchange{
+			Time:              started,
+			Method:            r.Method,
+			Path:              r.URL.Path,
+			Query:             redactQuery(r),
+			Status:            status,
+			DurationMs:        float64(time.Since(started).Microseconds()) / 1000,
+			RemoteAddr:        r.RemoteAddr,
+			RequestID:         middleware.GetReqID(r.Context()),
+			RequestHeaders:    redactHeaders(r.Header),
+			RequestBody:       redactBody(reqBody.buf.Bytes()),
+			RequestTruncated:  reqBody.truncated,
+			ResponseBody:      redactBody(respBody.buf.Bytes()),
+			ResponseTruncated: respBody.truncated,
+		})
+	})
+}
+
+func (s *Server) handleRecordedRequests(w http.ResponseWriter, r *http.Request) {
+	if s.recorder == nil {
+		writeError(w, http.StatusNotFound, errors.New("request recording disabled: set DEBUG_RECORD_SAMPLE or DEBUG_RECORD_ERRORS"))
+		return
+	}
+	limit := parseQueryInt(r, "limit", 50)
+	if limit < 1 {
+		limit = 50
+	}
+	errorsOnly := r.URL.Query().Get("errors") == "true"
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.recorder.Recent(limit, errorsOnly)})
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"slices"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+func (s *Server) WithRedaction(redactor *service.Redactor) *Server {
+	s.redactor = redactor
+	return s
+}
+
+// callerRole is the role redaction is decided on. Anonymous callers and users
+// still pending two-factor enrollment get the empty role.
+func (s *Server) callerRole(r *http.Request) string {
+	if principal, ok := principalFrom(r.Context()); ok && !principal.EnrollmentRequired {
+		return principal.Role
+	}
+	if token := bearerToken(r); s.adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) == 1 {
+		return models.RoleAdmin
+	}
+	return ""
+}
+
+// visibleMetrics applies the caller's redaction and, for embed tokens, zeroes
+// the metrics outside the token's scope.
+func (s *Server) visibleMetrics(r *http.Request, metrics models.Metrics) (models.Metrics, []string) {
+	metrics, redacted := s.redactor.Metrics(s.callerRole(r), metrics)
+	embed, ok := embedFrom(r.Context())
+	if !ok {
+		return metrics, redacted
+	}
+	metrics, dropped := service.Keep(metrics, embed.Metrics)
+	for _, key := range dropped {
+		if !slices.Contains(redacted, key) {
+			redacted = append(redacted, key)
+		}
+	}
+	return metrics, redacted
+}
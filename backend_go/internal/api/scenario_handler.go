@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+const (
+	defaultScenarioHistory = 30 * 24 * time.Hour
+	defaultScenarioStep    = 24 * time.Hour
+	defaultScenarioHorizon = 30
+)
+
+// ScenarioRequest takes history and step as Go durations, e.g. "720h" and
+// "24h"; horizon counts steps.
+type ScenarioRequest struct {
+	Changes []models.ScenarioChange `json:"changes"`
+	History string                  `json:"history"`
+	Step    string                  `json:"step"`
+	Horizon int                     `json:"horizon"`
+}
+
+// handleSimulateScenario compares the projected metrics with and without
+// hypothetical changes for the planning view. Metrics the caller may not
+// read precisely are left out.
+func (s *Server) handleSimulateScenario(w http.ResponseWriter, r *http.Request) {
+	var payload ScenarioRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	scenario := models.Scenario{
+		Changes: payload.Changes,
+		History: defaultScenarioHistory,
+		Step:    defaultScenarioStep,
+		Horizon: payload.Horizon,
+	}
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{{"history", payload.History, &scenario.History}, {"step", payload.Step, &scenario.Step}} {
+		if field.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(field.value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %w", field.name, err))
+			return
+		}
+		*field.dest = d
+	}
+	if scenario.Changes == nil {
+		scenario.Changes = []models.ScenarioChange{}
+	}
+	if scenario.Horizon == 0 {
+		scenario.Horizon = defaultScenarioHorizon
+	}
+	projection, err := s.metrics.SimulateScenario(r.Context(), scenario)
+	switch {
+	case errors.Is(err, service.ErrInvalidScenario):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, service.ErrNoData):
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	role := s.callerRole(r)
+	var redacted []string
+	visible := projection.Series[:0]
+	for _, series := range projection.Series {
+		if s.redactor.Restricted(role, series.Metric) {
+			redacted = append(redacted, series.Metric)
+			continue
+		}
+		visible = append(visible, series)
+	}
+	projection.Series = visible
+	resp := map[string]any{"data": projection}
+	if len(redacted) > 0 {
+		resp["redacted"] = redacted
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -1,43 +1,158 @@
 package api
 
 import (
-	"context"
-	"log"
+	"encoding/json"
 	"net/http"
+	"net/netip"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"mydashboard-backend/internal/collector"
 	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/scheduler"
 	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
 )
 
 type Server struct {
-	metrics  *service.MetricsService
-	insights *service.InsightsService
+	metrics        *service.MetricsService
+	insights       *service.InsightsService
+	idempotency    *service.IdempotencyService
+	backlog        *service.BacklogService
+	adminToken     string
+	scheduler      *scheduler.Scheduler
+	jobs           *service.JobQueue
+	dbStats        func() store.DBStats
+	usage          *service.UsageService
+	auth           *service.AuthService
+	redactor       *service.Redactor
+	ipFilter       IPFilterConfig
+	trustedProxies []netip.Prefix
+	recorder       *RequestRecorder
+	chaos          *Chaos
+	contract       *Contract
+	collectors     *collector.Manager
+	health         *service.HealthService
+	catalog        *service.MetricCatalog
+	location       *time.Location
+	embeds         *service.EmbedService
+	authRequired   bool
+	slack          SlackConfig
+	notifications  *service.NotificationService
+	preferences    *service.NotificationPreferenceService
+	silences       *service.SilenceService
+	derived        *service.DerivedMetricService
+	funnels        *service.FunnelService
+	surveys        *service.SurveyService
+	dimensions     *service.DimensionService
+	targets        *service.TargetService
+	widgets        *service.WidgetService
+	metricMeta     *service.MetricMetaService
+	tenantSettings *service.TenantSettingsService
+	teams          *service.TeamService
+	invitations    *service.InvitationService
+	audit          *service.AuditService
+	adHoc          *service.AdHocQueryService
+	overview       *service.OverviewService
+	cachePolicy    CachePolicy
+	adHocRoles     []string
+	simulation     *service.Simulation
+	escalations    *service.EscalationService
+	dashboardURL   string
+	calendarJobs   []string
+	backups        *service.BackupService
+	sync           *service.SyncService
+	syncToken      string
 }
 
 type MetricsResponse struct {
-	Data      models.Metrics `json:"data"`
-	Timestamp time.Time      `json:"timestamp"`
+	Data      models.Metrics                     `json:"data"`
+	Timestamp time.Time                          `json:"timestamp"`
+	Degraded  bool                               `json:"degraded,omitempty"`
+	Redacted  []string                           `json:"redacted,omitempty"`
+	Units     map[string]models.MetricDefinition `json:"units,omitempty"`
 }
 
 type TrendPoint struct {
-	Timestamp time.Time `json:"timestamp"`
-	Revenue   float64   `json:"revenue"`
+	Timestamp time.Time          `json:"timestamp"`
+	Revenue   float64            `json:"revenue"`
+	Derived   map[string]float64 `json:"derived,omitempty"`
+	// Filled is the ?fill= method of a point added for a gap, or "held"
+	// for a repeat of a snapshot within its valid_until.
+	Filled string `json:"filled,omitempty"`
+}
+
+// MarshalJSON writes the revenue of a fill=null point as null.
+func (p TrendPoint) MarshalJSON() ([]byte, error) {
+	type point TrendPoint
+	if p.Filled != service.FillNull {
+		return json.Marshal(point(p))
+	}
+	return json.Marshal(struct {
+		Timestamp time.Time `json:"timestamp"`
+		Revenue   *float64  `json:"revenue"`
+		Filled    string    `json:"filled"`
+	}{p.Timestamp, nil, p.Filled})
 }
 
 type TrendResponse struct {
-	Data []TrendPoint `json:"data"`
+	Data       []TrendPoint             `json:"data"`
+	Redacted   []string                 `json:"redacted,omitempty"`
+	Unit       *models.MetricDefinition `json:"unit,omitempty"`
+	Checkpoint string                   `json:"checkpoint,omitempty"`
+	Reset      bool                     `json:"reset,omitempty"`
+}
+
+type MetricTrendResponse struct {
+	Metric   string                   `json:"metric"`
+	Smooth   string                   `json:"smooth"`
+	Redacted bool                     `json:"redacted,omitempty"`
+	Unit     *models.MetricDefinition `json:"unit,omitempty"`
+	Data     []models.MetricPoint     `json:"data"`
+}
+
+type ExpressionResponse struct {
+	Expr    string               `json:"expr"`
+	Metrics []string             `json:"metrics"`
+	Data    []models.MetricPoint `json:"data"`
+}
+
+type MetricHistoryResponse struct {
+	Data       []models.MetricSnapshot `json:"data"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+	Redacted   []string                `json:"redacted,omitempty"`
+}
+
+type HeatmapResponse struct {
+	Metric   string               `json:"metric"`
+	Timezone string               `json:"timezone"`
+	From     time.Time            `json:"from"`
+	To       time.Time            `json:"to"`
+	Peak     *models.HeatmapCell  `json:"peak,omitempty"`
+	Data     []models.HeatmapCell `json:"data"`
 }
 
 type InsightsResponse struct {
-	Data []models.Insight `json:"data"`
+	Data     []models.Insight `json:"data"`
+	Degraded bool             `json:"degraded,omitempty"`
+}
+
+type MetricsImportRequest struct {
+	Data []models.Metrics `json:"data"`
 }
 
+// InsightRequest asks for a generated insight about MetricKey, or, when
+// Title or Message is set, posts that text as written.
 type InsightRequest struct {
-	MetricKey string `json:"metricKey"`
+	MetricKey string   `json:"metricKey"`
+	Title     string   `json:"title"`
+	Message   string   `json:"message"`
+	Source    string   `json:"source"`
+	Severity  string   `json:"severity"`
+	Tags      []string `json:"tags"`
 }
 
 func NewServer(metrics *service.MetricsService, insights *service.InsightsService) *Server {
@@ -47,21 +162,227 @@ func NewServer(metrics *service.MetricsService, insights *service.InsightsServic
 	}
 }
 
-func (s *Server) Routes(allowedOrigins string) http.Handler {
+func (s *Server) WithIdempotency(idempotency *service.IdempotencyService) *Server {
+	s.idempotency = idempotency
+	return s
+}
+
+func (s *Server) WithBacklog(backlog *service.BacklogService) *Server {
+	s.backlog = backlog
+	return s
+}
+
+// WithLocation sets the timezone rollups use when a request has no ?tz.
+func (s *Server) WithLocation(loc *time.Location) *Server {
+	s.location = loc
+	return s
+}
+
+// WithDashboardURL sets the frontend address feeds and links point to.
+func (s *Server) WithDashboardURL(url string) *Server {
+	s.dashboardURL = strings.TrimRight(url, "/")
+	return s
+}
+
+func (s *Server) WithAdminToken(token string) *Server {
+	s.adminToken = token
+	return s
+}
+
+func (s *Server) WithScheduler(scheduler *scheduler.Scheduler) *Server {
+	s.scheduler = scheduler
+	return s
+}
+
+func (s *Server) WithJobs(jobs *service.JobQueue) *Server {
+	s.jobs = jobs
+	return s
+}
+
+func (s *Server) WithDBStats(stats func() store.DBStats) *Server {
+	s.dbStats = stats
+	return s
+}
+
+func (s *Server) WithUsage(usage *service.UsageService) *Server {
+	s.usage = usage
+	return s
+}
+
+func (s *Server) WithAuth(auth *service.AuthService) *Server {
+	s.auth = auth
+	return s
+}
+
+func (s *Server) Routes(cors CORSConfig) http.Handler {
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
+	router.Use(negotiateProblems)
+	router.Use(s.realIP)
+	router.Use(s.filterIPs)
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.Logger)
-	router.Use(corsMiddleware(allowedOrigins))
+	router.Use(corsMiddleware(cors))
 
 	router.Get("/healthz", s.handleHealth)
 	router.Route("/api", func(r chi.Router) {
+		r.Use(s.trackUsage)
+		r.Use(s.cacheControl)
+		r.Use(s.authenticate)
+		r.Use(s.authorize)
+		r.Use(s.recordRequests)
+		r.Use(s.injectChaos)
+		r.Use(s.checkContract)
 		r.Get("/metrics/latest", s.handleLatestMetrics)
 		r.Get("/metrics/trend", s.handleTrend)
+		r.Get("/metrics/history", s.handleMetricsHistory)
+		r.Get("/metrics/definitions", s.handleMetricDefinitions)
+		r.Get("/metrics/meta", s.handleMetricMeta)
+		r.Get("/tenant/settings", s.handleTenantSettings)
+		r.With(s.requireAdmin).Put("/tenant/settings", s.handleSaveTenantSettings)
+		r.With(s.requireAdmin).Delete("/tenant/settings", s.handleDeleteTenantSettings)
+		r.Get("/metrics/derived", s.handleListDerivedMetrics)
+		r.With(s.requireAnalyst).Put("/metrics/derived/{name}", s.handleSaveDerivedMetric)
+		r.With(s.requireAnalyst).Delete("/metrics/derived/{name}", s.handleDeleteDerivedMetric)
+		r.Get("/metrics/diff", s.handleMetricsDiff)
+		r.Get("/metrics/correlate", s.handleCorrelate)
+		r.Get("/metrics/top", s.handleTopContributors)
+		r.With(s.idempotent).Post("/metrics/dimensions", s.handleIngestDimensionValues)
+		r.Get("/metrics/{key}/trend", s.handleMetricTrend)
+		r.Get("/metrics/{key}/distribution", s.handleMetricDistribution)
+		r.Get("/metrics/{key}/heatmap", s.handleMetricHeatmap)
+		r.Get("/query/schema", s.handleAdHocSchema)
+		r.Post("/query", s.handleAdHocQuery)
+		r.Get("/orgs", s.handleListOrgs)
+		r.Get("/orgs/{id}/teams", s.handleListTeams)
+		r.Get("/teams/{id}", s.handleGetTeam)
+		r.Get("/teams/{id}/assignments", s.handleTeamAssignments)
+		r.Get("/widgets", s.handleListWidgets)
+		r.Get("/widgets/{name}", s.handleGetWidget)
+		r.Get("/widgets/{name}/data", s.handleWidgetData)
+		r.With(middleware.Compress(5)).Get("/wallboard", s.handleWallboard)
+		r.Get("/overview", s.handleOverview)
 		r.Get("/insights/latest", s.handleLatestInsights)
-		r.Post("/insights", s.handleCreateInsight)
-		r.Post("/metrics/simulate", s.handleSimulateMetrics)
+		r.Get("/insights/feed.atom", s.handleInsightsFeed)
+		r.Get("/calendar.ics", s.handleCalendar)
+		r.Get("/insights/trash", s.handleInsightTrash)
+		r.Get("/insights/tags", s.handleListInsightTags)
+		r.Get("/insights/{id}/context", s.handleInsightContext)
+		r.With(s.requireAnalyst).Put("/insights/{id}", s.handleUpdateInsight)
+		r.With(s.requireAnalyst).Delete("/insights/{id}", s.handleDeleteInsight)
+		r.With(s.requireAnalyst).Post("/insights/{id}/restore", s.handleRestoreInsight)
+		r.With(s.requireAnalyst).Put("/insights/{id}/tags", s.handleSetInsightTags)
+		r.With(s.requireAnalyst).Delete("/insights/{id}/tags/{tag}", s.handleRemoveInsightTag)
+		r.Get("/insights/{id}/assignment", s.handleGetAssignment)
+		r.With(s.requireAnalyst).Put("/insights/{id}/assignment", s.handleAssignInsight)
+		r.With(s.requireAnalyst).Delete("/insights/{id}/assignment", s.handleUnassignInsight)
+		r.Put("/insights/{id}/assignment/status", s.handleSetAssignmentStatus)
+		r.Get("/insights/rules", s.handleListInsightRules)
+		r.With(s.requireAnalyst).Post("/insights/rules", s.handleCreateInsightRule)
+		r.With(s.requireAnalyst).Put("/insights/rules/{id}", s.handleUpdateInsightRule)
+		r.With(s.requireAnalyst).Delete("/insights/rules/{id}", s.handleDeleteInsightRule)
+		r.Post("/alerts/rules/preview", s.handlePreviewInsightRule)
+		r.Get("/alerts/silences", s.handleListSilences)
+		r.With(s.requireAnalyst).Post("/alerts/silences", s.handleCreateSilence)
+		r.With(s.requireAnalyst).Delete("/alerts/silences/{id}", s.handleDeleteSilence)
+		r.Get("/alerts", s.handleListAlerts)
+		r.Get("/alerts/ack", s.handleAcknowledgeAlertLink)
+		r.Post("/alerts/{id}/ack", s.handleAcknowledgeAlert)
+		r.With(s.idempotent).Post("/insights", s.handleCreateInsight)
+		r.With(s.requireAdmin, s.idempotent).Post("/metrics", s.handleIngestMetrics)
+		r.With(s.requireAdmin, s.idempotent).Post("/metrics/import", s.handleImportMetrics)
+		r.With(s.requireAnalyst).Post("/metrics/simulate", s.handleSimulateMetrics)
+		r.Get("/simulation/series", s.handleListSimulatedSeries)
+		r.With(s.requireAdmin).Put("/simulation/series", s.handleSetSimulatedSeries)
+		r.Post("/scenarios/simulate", s.handleSimulateScenario)
+		r.Get("/targets", s.handleListTargets)
+		r.Get("/targets/pacing", s.handleTargetPacing)
+		r.With(s.requireAnalyst).Put("/targets/{quarter}/{metric}", s.handleSaveTarget)
+		r.With(s.requireAnalyst).Delete("/targets/{quarter}/{metric}", s.handleDeleteTarget)
+		r.Get("/funnels", s.handleListFunnels)
+		r.With(s.requireAnalyst).Put("/funnels/{name}", s.handleSaveFunnel)
+		r.With(s.requireAnalyst).Delete("/funnels/{name}", s.handleDeleteFunnel)
+		r.With(s.idempotent).Post("/funnels/{name}/events", s.handleIngestFunnelEvents)
+		r.Get("/funnels/{name}/report", s.handleFunnelReport)
+		r.Get("/funnels/{name}/cohorts", s.handleFunnelCohorts)
+		r.With(s.idempotent).Post("/surveys/responses", s.handleIngestSurveyResponses)
+		r.Get("/surveys/nps", s.handleNPS)
+		r.Get("/surveys/nps/trend", s.handleNPSTrend)
+		r.With(s.requireAnalyst).Post("/backlog/items", s.handleCreateBacklogItem)
+		r.With(s.requireAnalyst).Post("/backlog/items/{id}/resolve", s.handleResolveBacklogItem)
+		r.Get("/backlog/aging", s.handleBacklogAging)
+		r.Get("/jobs/{id}", s.handleGetJob)
+		r.Get("/status/history", s.handleStatusHistory)
+		r.Route("/grafana", s.grafanaRoutes)
+		r.Post("/sync/push", s.handleSyncPush)
+		r.Post("/integrations/slack/command", s.handleSlackCommand)
+		r.Get("/integrations/slack/sparkline.png", s.handleSlackSparkline)
+		r.Post("/auth/login", s.handleLogin)
+		r.Post("/auth/refresh", s.handleRefresh)
+		r.Get("/auth/password-policy", s.handlePasswordPolicy)
+		r.Post("/invitations/accept", s.handleAcceptInvitation)
+		r.With(s.requireSession).Post("/auth/logout", s.handleLogout)
+
+		r.Route("/me", func(r chi.Router) {
+			r.With(s.requireSession).Get("/", s.handleGetProfile)
+			r.With(s.requireUser).Put("/", s.handleUpdateProfile)
+			r.With(s.requireSession).Post("/2fa/setup", s.handleTOTPSetup)
+			r.With(s.requireSession).Post("/2fa/verify", s.handleTOTPVerify)
+			r.With(s.requireUser).Get("/sessions", s.handleListSessions)
+			r.With(s.requireUser).Delete("/sessions/{id}", s.handleRevokeSession)
+			r.With(s.requireUser).Get("/assignments", s.handleMyAssignments)
+			r.With(s.requireUser).Get("/teams", s.handleMyTeams)
+			r.With(s.requireUser).Get("/notification-preferences", s.handleGetPreferences)
+			r.With(s.requireUser).Put("/notification-preferences", s.handleSavePreferences)
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(s.requireAdmin)
+			r.Post("/insights/generate", s.handleAdminGenerateInsights)
+			r.Post("/recompute", s.handleRecompute)
+			r.Put("/insights/tags/{tag}", s.handleRenameInsightTag)
+			r.Delete("/insights/tags/{tag}", s.handleDeleteInsightTag)
+			r.Get("/jobs", s.handleListJobs)
+			r.Put("/jobs/{name}", s.handleUpdateJob)
+			r.Post("/jobs/{name}/run", s.handleRunJob)
+			r.Get("/db/stats", s.handleDBStats)
+			r.Get("/usage", s.handleUsageReport)
+			r.Get("/users", s.handleListUsers)
+			r.Post("/users", s.handleCreateUser)
+			r.Post("/users/{id}/erase", s.handleEraseUserData)
+			r.Post("/users/{id}/unlock", s.handleUnlockUser)
+			r.Get("/audit", s.handleListAudit)
+			r.Get("/invitations", s.handleListInvitations)
+			r.Post("/invitations", s.handleCreateInvitation)
+			r.Delete("/invitations/{id}", s.handleRevokeInvitation)
+			r.Get("/embed-tokens", s.handleListEmbedTokens)
+			r.Post("/embed-tokens", s.handleCreateEmbedToken)
+			r.Delete("/embed-tokens/{id}", s.handleRevokeEmbedToken)
+			r.Get("/notifications/channels", s.handleListChannels)
+			r.Post("/notifications/channels", s.handleCreateChannel)
+			r.Put("/notifications/channels/{id}", s.handleUpdateChannel)
+			r.Delete("/notifications/channels/{id}", s.handleDeleteChannel)
+			r.Post("/notifications/channels/{id}/test", s.handleTestChannel)
+			r.Post("/orgs", s.handleCreateOrg)
+			r.Put("/orgs/{id}", s.handleUpdateOrg)
+			r.Delete("/orgs/{id}", s.handleDeleteOrg)
+			r.Post("/orgs/{id}/teams", s.handleCreateTeam)
+			r.Put("/teams/{id}", s.handleUpdateTeam)
+			r.Delete("/teams/{id}", s.handleDeleteTeam)
+			r.Put("/teams/{id}/members/{user_id}", s.handleSetTeamMember)
+			r.Delete("/teams/{id}/members/{user_id}", s.handleRemoveTeamMember)
+			r.Put("/widgets/{name}", s.handleSaveWidget)
+			r.Delete("/widgets/{name}", s.handleDeleteWidget)
+			r.Get("/metrics/meta", s.handleListMetricMeta)
+			r.Put("/metrics/meta/{key}", s.handleSaveMetricMeta)
+			r.Delete("/metrics/meta/{key}", s.handleDeleteMetricMeta)
+			r.Get("/collectors", s.handleCollectors)
+			r.Get("/chaos", s.handleGetChaos)
+			r.Put("/chaos", s.handleUpdateChaos)
+			r.Get("/backup", s.handleBackup)
+			r.Post("/restore", s.handleRestore)
+			r.Route("/debug", s.debugRoutes)
+		})
 	})
 
 	return router
@@ -70,11 +391,3 @@ func (s *Server) Routes(allowedOrigins string) http.Handler {
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
-
-func (s *Server) StartSimulation(ctx context.Context, metricEvery, insightEvery time.Duration) {
-	if s.metrics == nil || s.insights == nil {
-		log.Printf("simulation skipped: services not configured")
-		return
-	}
-	s.metrics.StartSimulation(ctx, metricEvery, insightEvery, s.insights)
-}
@@ -0,0 +1,164 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"mydashboard-backend/internal/apitest"
+	"mydashboard-backend/internal/models"
+)
+
+var seeded = []models.Metrics{
+	{Revenue: 1000, Growth: 2.5, Sentiment: 0.6, Backlog: 12, CreatedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)},
+	{Revenue: 1100, Growth: 3, Sentiment: 0.7, Backlog: 10, CreatedAt: time.Date(2026, 3, 1, 9, 1, 0, 0, time.UTC)},
+	{Revenue: 1200, Growth: 3.5, Sentiment: 0.8, Backlog: 9, CreatedAt: time.Date(2026, 3, 1, 9, 2, 0, 0, time.UTC)},
+}
+
+func TestLatestMetrics(t *testing.T) {
+	h := apitest.New(t)
+	h.SeedMetrics(seeded...)
+	h.Get("/api/metrics/latest").Status(http.StatusOK).Golden("latest_metrics", "server_time", "timestamp")
+}
+
+func TestTrend(t *testing.T) {
+	h := apitest.New(t)
+	h.SeedMetrics(seeded...)
+	h.Get("/api/metrics/trend?limit=3").Status(http.StatusOK).Golden("trend", "server_time")
+}
+
+func TestOverview(t *testing.T) {
+	h := apitest.New(t)
+	h.SeedMetrics(seeded...)
+	h.Get("/api/overview").Status(http.StatusOK).Golden("overview", "generated_at", "server_time")
+}
+
+func TestTenantSettings(t *testing.T) {
+	h := apitest.New(t)
+	h.Get("/api/tenant/settings").Status(http.StatusOK).Golden("tenant_settings", "server_time")
+}
+
+func TestConfigRoutesNeedAnalyst(t *testing.T) {
+	h := apitest.New(t)
+	viewer := h.UserToken("vera", models.RoleViewer)
+	analyst := h.UserToken("anil", models.RoleAnalyst)
+	target := map[string]any{"value": 5000, "direction": "up"}
+
+	h.Do(apitest.Request{Method: http.MethodPut, Path: "/api/targets/2026-Q1/revenue", Body: target, Token: viewer}).
+		Status(http.StatusForbidden)
+	if code := h.Do(apitest.Request{Method: http.MethodPut, Path: "/api/targets/2026-Q1/revenue", Body: target, Token: analyst}).Code(); code == http.StatusForbidden || code == http.StatusUnauthorized {
+		t.Errorf("analyst got %d", code)
+	}
+}
+
+// TestReadRoutesAreWired requests every read route the harness can reach
+// without fixtures. A route whose service was not wired panics, which the
+// recoverer turns into a 500.
+func TestReadRoutesAreWired(t *testing.T) {
+	h := apitest.New(t)
+	h.SeedMetrics(seeded...)
+	paths := []string{
+		"/api/metrics/latest",
+		"/api/metrics/trend",
+		"/api/metrics/history",
+		"/api/metrics/definitions",
+		"/api/metrics/meta",
+		"/api/metrics/derived",
+		"/api/metrics/revenue/trend",
+		"/api/tenant/settings",
+		"/api/query/schema",
+		"/api/orgs",
+		"/api/widgets",
+		"/api/wallboard",
+		"/api/overview",
+		"/api/insights/latest",
+		"/api/insights/trash",
+		"/api/insights/tags",
+		"/api/insights/rules",
+		"/api/alerts",
+		"/api/alerts/silences",
+		"/api/simulation/series",
+		"/api/targets",
+		"/api/targets/pacing",
+		"/api/funnels",
+		"/api/surveys/nps",
+		"/api/backlog/aging",
+		"/api/status/history",
+		"/api/auth/password-policy",
+	}
+	for _, path := range paths {
+		if code := h.Get(path).Code(); code >= http.StatusInternalServerError {
+			t.Errorf("GET %s: status %d", path, code)
+		}
+	}
+	admin := []string{
+		"/api/admin/jobs",
+		"/api/admin/db/stats",
+		"/api/admin/usage",
+		"/api/admin/users",
+		"/api/admin/audit",
+		"/api/admin/invitations",
+		"/api/admin/embed-tokens",
+		"/api/admin/notifications/channels",
+		"/api/admin/metrics/meta",
+		"/api/admin/collectors",
+		"/api/admin/backup",
+	}
+	for _, path := range admin {
+		if code := h.Admin(http.MethodGet, path, nil).Code(); code >= http.StatusInternalServerError {
+			t.Errorf("GET %s: status %d", path, code)
+		}
+	}
+}
+
+func TestTrendFillNullKeepsHeldValues(t *testing.T) {
+	h := apitest.New(t)
+	until := time.Date(2026, 3, 1, 9, 3, 0, 0, time.UTC)
+	h.SeedMetrics(
+		models.Metrics{Revenue: 1000, CreatedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), ValidUntil: &until},
+		models.Metrics{Revenue: 1200, CreatedAt: time.Date(2026, 3, 1, 9, 8, 0, 0, time.UTC)},
+	)
+	h.Get("/api/metrics/trend?fill=null&step=1m").Status(http.StatusOK).Golden("trend_fill_null", "server_time", "checkpoint")
+}
+
+func TestTrendFillNoneKeepsHeldValues(t *testing.T) {
+	h := apitest.New(t)
+	until := time.Date(2026, 3, 1, 9, 3, 0, 0, time.UTC)
+	h.SeedMetrics(
+		models.Metrics{Revenue: 1000, CreatedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), ValidUntil: &until},
+		models.Metrics{Revenue: 1200, CreatedAt: time.Date(2026, 3, 1, 9, 8, 0, 0, time.UTC)},
+	)
+	h.Get("/api/metrics/trend?fill=none&step=1m").Status(http.StatusOK).Golden("trend_fill_none", "server_time", "checkpoint")
+}
+
+func TestWriteRoutesNeedRoles(t *testing.T) {
+	h := apitest.New(t)
+	h.SeedMetrics(seeded...)
+	viewer := h.UserToken("vera", models.RoleViewer)
+	analyst := h.UserToken("anil", models.RoleAnalyst)
+	snapshot := models.Metrics{Revenue: 1300, Growth: 3.5, Sentiment: 0.8, Backlog: 9}
+	ingest := []apitest.Request{
+		{Method: http.MethodPost, Path: "/api/metrics", Body: snapshot},
+		{Method: http.MethodPost, Path: "/api/metrics/import", Body: map[string]any{"data": []models.Metrics{snapshot}}},
+	}
+	analystOnly := []apitest.Request{
+		{Method: http.MethodPost, Path: "/api/metrics/simulate"},
+		{Method: http.MethodPost, Path: "/api/backlog/items", Body: map[string]any{"title": "Refund request"}},
+		{Method: http.MethodPost, Path: "/api/backlog/items/1/resolve"},
+	}
+	denied := func(req apitest.Request, token string) {
+		t.Helper()
+		req.Token = token
+		if code := h.Do(req).Code(); code != http.StatusUnauthorized && code != http.StatusForbidden {
+			t.Errorf("%s %s: status %d, want it refused", req.Method, req.Path, code)
+		}
+	}
+	for _, req := range append(ingest, analystOnly...) {
+		denied(req, viewer)
+	}
+	for _, req := range ingest {
+		denied(req, analyst)
+	}
+	h.Do(apitest.Request{Method: http.MethodPost, Path: "/api/metrics/simulate", Token: analyst}).Status(http.StatusOK)
+	h.Admin(http.MethodPost, "/api/metrics", snapshot).Status(http.StatusOK)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type SilenceRequest struct {
+	Kind     string            `json:"kind"`
+	Matchers map[string]string `json:"matchers"`
+	StartsAt time.Time         `json:"starts_at"`
+	EndsAt   *time.Time        `json:"ends_at"`
+	Schedule string            `json:"schedule"`
+	Duration string            `json:"duration"`
+	Comment  string            `json:"comment"`
+}
+
+func (s *Server) WithSilences(silences *service.SilenceService) *Server {
+	s.silences = silences
+	return s
+}
+
+// handleListSilences lists the silences that have not ended, or all of them
+// with ?expired=true.
+func (s *Server) handleListSilences(w http.ResponseWriter, r *http.Request) {
+	items, err := s.silences.List(r.Context(), r.URL.Query().Get("expired") == "true")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+func (s *Server) handleCreateSilence(w http.ResponseWriter, r *http.Request) {
+	var payload SilenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	silence := models.Silence{
+		Kind:     payload.Kind,
+		Matchers: payload.Matchers,
+		StartsAt: payload.StartsAt,
+		EndsAt:   payload.EndsAt,
+		Schedule: payload.Schedule,
+		Duration: payload.Duration,
+		Comment:  payload.Comment,
+	}
+	if principal, ok := principalFrom(r.Context()); ok {
+		silence.CreatedBy = principal.Username
+	}
+	created, err := s.silences.Create(r.Context(), silence)
+	if err != nil {
+		writeError(w, silenceErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": created})
+}
+
+func (s *Server) handleDeleteSilence(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid silence id"))
+		return
+	}
+	if err := s.silences.Delete(r.Context(), id); err != nil {
+		writeError(w, silenceErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func silenceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidSilence):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
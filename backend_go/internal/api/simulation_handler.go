@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+type SimulatedSeriesRequest struct {
+	Series []models.SimulatedSeries `json:"series"`
+}
+
+func (s *Server) WithSimulation(simulation *service.Simulation) *Server {
+	s.simulation = simulation
+	return s
+}
+
+func (s *Server) handleListSimulatedSeries(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.simulation.Series()})
+}
+
+// handleSetSimulatedSeries replaces the series the simulator splits its
+// snapshots across. An empty list turns the split off.
+func (s *Server) handleSetSimulatedSeries(w http.ResponseWriter, r *http.Request) {
+	var payload SimulatedSeriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.simulation.SetSeries(payload.Series); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidSeries) {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": s.simulation.Series()})
+}
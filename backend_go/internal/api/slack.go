@@ -0,0 +1,230 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/chart"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+const (
+	slackMaxSkew      = 5 * time.Minute
+	slackMaxBody      = 64 << 10
+	slackImageTTL     = 24 * time.Hour
+	slackTrendPoints  = 24
+	slackInsightPool  = 20
+	slackSparklinePNG = "/api/integrations/slack/sparkline.png"
+)
+
+// SlackConfig enables the /dashboard slash command. PublicURL is where Slack
+// can reach this server; without it replies carry a text sparkline instead
+// of an image.
+type SlackConfig struct {
+	SigningSecret string
+	PublicURL     string
+	Locale        string
+}
+
+type slackBlock map[string]any
+
+func (s *Server) WithSlack(cfg SlackConfig) *Server {
+	s.slack = cfg
+	return s
+}
+
+// verifySlack checks the v0 request signature Slack sends with every
+// command and returns the raw form body it covers.
+func (s *Server) verifySlack(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, slackMaxBody))
+	if err != nil {
+		return nil, err
+	}
+	ts, err := strconv.ParseInt(r.Header.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return nil, errors.New("missing slack request timestamp")
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return nil, errors.New("slack request timestamp too old")
+	}
+	mac := hmac.New(sha256.New, []byte(s.slack.SigningSecret))
+	fmt.Fprintf(mac, "v0:%d:%s", ts, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(r.Header.Get("X-Slack-Signature")), []byte(expected)) {
+		return nil, errors.New("invalid slack signature")
+	}
+	return body, nil
+}
+
+// imageSignature authorizes Slack to fetch a sparkline without credentials.
+func (s *Server) imageSignature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.slack.SigningSecret))
+	fmt.Fprintf(mac, "sparkline:%s:%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Server) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	if s.slack.SigningSecret == "" {
+		writeError(w, http.StatusNotFound, errors.New("slack integration disabled: set SLACK_SIGNING_SECRET"))
+		return
+	}
+	body, err := s.verifySlack(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	key := strings.ToLower(strings.TrimSpace(form.Get("text")))
+	if keys := s.metrics.Keys(r.Context()); !slices.Contains(keys, key) {
+		writeSlack(w, "ephemeral", fmt.Sprintf("Usage: %s <metric>. Metrics: %s.", form.Get("command"), strings.Join(keys, ", ")), nil)
+		return
+	}
+	if s.redactor.Restricted("", key) {
+		writeSlack(w, "ephemeral", fmt.Sprintf("%s is restricted and cannot be shared in Slack.", key), nil)
+		return
+	}
+
+	units, factors, err := s.units(r)
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	latest, _, err := s.metrics.Latest(r.Context())
+	if err != nil {
+		writeSlack(w, "ephemeral", "The dashboard is unavailable right now, try again shortly.", nil)
+		return
+	}
+	latest = s.convert(latest, factors)
+	value, _ := latest.Value(key)
+	summary := fmt.Sprintf("*%s*: %s", key, formatSlackValue(value, units[key]))
+
+	points, err := s.metrics.MetricTrend(r.Context(), key, slackTrendPoints, service.SmoothNone, 1)
+	blocks := []slackBlock{}
+	if err == nil && len(points) > 1 {
+		factor := factors[key]
+		if factor == 0 {
+			factor = 1
+		}
+		values := make([]float64, len(points))
+		for i, point := range points {
+			values[i] = point.Value * factor
+		}
+		if first := values[0]; first != 0 {
+			summary += fmt.Sprintf(" (%+.1f%% over the last %d updates)", (values[len(values)-1]-first)/first*100, len(values))
+		}
+		if s.slack.PublicURL == "" {
+			summary += "\n`" + chart.SparklineText(values) + "`"
+		} else {
+			expires := time.Now().Add(slackImageTTL).Unix()
+			query := url.Values{
+				"metric": {key},
+				"exp":    {strconv.FormatInt(expires, 10)},
+				"sig":    {s.imageSignature(key, expires)},
+			}
+			blocks = append(blocks, slackBlock{
+				"type":      "image",
+				"image_url": strings.TrimRight(s.slack.PublicURL, "/") + slackSparklinePNG + "?" + query.Encode(),
+				"alt_text":  key + " trend",
+			})
+		}
+	}
+	blocks = append([]slackBlock{{"type": "section", "text": slackBlock{"type": "mrkdwn", "text": summary}}}, blocks...)
+
+	if items, _, err := s.insights.Latest(r.Context(), s.slack.Locale, slackInsightPool); err == nil {
+		if insight, ok := relevantInsight(items, key); ok {
+			blocks = append(blocks, slackBlock{
+				"type": "context",
+				"elements": []slackBlock{{
+					"type": "mrkdwn",
+					"text": fmt.Sprintf("*%s* %s", insight.Title, insight.Message),
+				}},
+			})
+		}
+	}
+	writeSlack(w, "in_channel", summary, blocks)
+}
+
+// handleSlackSparkline serves the image linked from a command reply. It is
+// public so Slack can fetch it, but only with a signature from that reply.
+func (s *Server) handleSlackSparkline(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := query.Get("metric")
+	expires, err := strconv.ParseInt(query.Get("exp"), 10, 64)
+	if s.slack.SigningSecret == "" || err != nil || time.Now().Unix() > expires ||
+		!hmac.Equal([]byte(query.Get("sig")), []byte(s.imageSignature(key, expires))) {
+		writeError(w, http.StatusForbidden, errors.New("invalid or expired image link"))
+		return
+	}
+	points, err := s.metrics.MetricTrend(r.Context(), key, slackTrendPoints, service.SmoothNone, 1)
+	if errors.Is(err, service.ErrUnknownMetric) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	points, _, err = s.redactor.Points("", key, points)
+	if err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	values := make([]float64, len(points))
+	for i, point := range points {
+		values[i] = point.Value
+	}
+	img, err := chart.SparklinePNG(values, 400, 80)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_, _ = w.Write(img)
+}
+
+func relevantInsight(items []models.Insight, key string) (models.Insight, bool) {
+	for _, item := range items {
+		for _, link := range item.Metrics {
+			if link.MetricKey == key {
+				return item, true
+			}
+		}
+	}
+	return models.Insight{}, false
+}
+
+func formatSlackValue(value float64, def models.MetricDefinition) string {
+	out := strconv.FormatFloat(value, 'f', 2, 64)
+	if def.Unit != "" {
+		out += " " + def.Unit
+	}
+	if def.Currency != "" && !strings.Contains(def.Unit, def.Currency) {
+		out += " " + def.Currency
+	}
+	return out
+}
+
+func writeSlack(w http.ResponseWriter, responseType, text string, blocks []slackBlock) {
+	payload := map[string]any{"response_type": responseType, "text": text}
+	if len(blocks) > 0 {
+		payload["blocks"] = blocks
+	}
+	writeJSON(w, http.StatusOK, payload)
+}
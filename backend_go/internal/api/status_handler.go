@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"mydashboard-backend/internal/service"
+)
+
+func (s *Server) WithHealth(health *service.HealthService) *Server {
+	s.health = health
+	return s
+}
+
+func (s *Server) handleStatusHistory(w http.ResponseWriter, r *http.Request) {
+	if s.health == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("health checks not configured"))
+		return
+	}
+	window, err := parseQueryDuration(r, "window", 24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	step, err := parseQueryDuration(r, "bucket", time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	history, err := s.health.History(window, step)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": history, "window": window.String(), "bucket": step.String()})
+}
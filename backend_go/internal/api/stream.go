@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// arrayStream writes a list response one element at a time instead of
+// encoding a finished slice. By default the body is {"data":[...]} preceded
+// by the metadata fields; clients that accept application/x-ndjson get one
+// element per line and no envelope.
+type arrayStream struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	meta    map[string]any
+	ndjson  bool
+	started bool
+}
+
+func newArrayStream(w http.ResponseWriter, r *http.Request, meta map[string]any) *arrayStream {
+	return &arrayStream{w: w, enc: json.NewEncoder(w), meta: meta, ndjson: accepts(r, ndjsonContentType)}
+}
+
+func (a *arrayStream) begin() error {
+	a.started = true
+	if a.ndjson {
+		a.w.Header().Set("Content-Type", ndjsonContentType)
+		a.w.WriteHeader(http.StatusOK)
+		return nil
+	}
+	a.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	a.w.WriteHeader(http.StatusOK)
+	var head strings.Builder
+	head.WriteString("{")
+	keys := make([]string, 0, len(a.meta))
+	for key := range a.meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		data, err := json.Marshal(a.meta[key])
+		if err != nil {
+			return err
+		}
+		head.WriteString(`"` + key + `":`)
+		head.Write(data)
+		head.WriteString(",")
+	}
+	if _, ok := a.meta["server_time"]; !ok {
+		head.WriteString(`"server_time":"` + serverTime() + `",`)
+	}
+	head.WriteString(`"data":[`)
+	_, err := io.WriteString(a.w, head.String())
+	return err
+}
+
+// Write encodes one element, sending the status and headers first.
+func (a *arrayStream) Write(v any) error {
+	if !a.started {
+		if err := a.begin(); err != nil {
+			return err
+		}
+	} else if !a.ndjson {
+		if _, err := io.WriteString(a.w, ","); err != nil {
+			return err
+		}
+	}
+	return a.enc.Encode(v)
+}
+
+// Close ends the response. With err set it reports the error if nothing has
+// been sent yet; otherwise the body is left truncated so clients see invalid
+// JSON rather than a short but well-formed list.
+func (a *arrayStream) Close(err error) {
+	if err != nil {
+		if !a.started {
+			writeError(a.w, http.StatusInternalServerError, err)
+			return
+		}
+		log.Printf("stream response: %v", err)
+		return
+	}
+	if !a.started {
+		if err := a.begin(); err != nil {
+			log.Printf("stream response: %v", err)
+			return
+		}
+	}
+	if !a.ndjson {
+		_, _ = io.WriteString(a.w, "]}\n")
+	}
+}
+
+func accepts(r *http.Request, contentType string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && mediaType == contentType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+type SurveyResponsesRequest struct {
+	Responses []models.SurveyResponse `json:"responses"`
+}
+
+func (s *Server) WithSurveys(surveys *service.SurveyService) *Server {
+	s.surveys = surveys
+	return s
+}
+
+func (s *Server) handleIngestSurveyResponses(w http.ResponseWriter, r *http.Request) {
+	var payload SurveyResponsesRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	count, err := s.surveys.Ingest(r.Context(), payload.Responses)
+	if err != nil {
+		writeError(w, surveyErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"count": count})
+}
+
+// handleNPS reports the NPS over from/to, the last 30 days by default,
+// overall and per segment.
+func (s *Server) handleNPS(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 30*24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	report, err := s.surveys.NPS(r.Context(), from, to)
+	if err != nil {
+		writeError(w, surveyErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": report})
+}
+
+// handleNPSTrend returns the rolling NPS over from/to, the last 90 days by
+// default, one point per ?step= (1 day) over a ?window= (30 days), for one
+// ?segment= or all responses.
+func (s *Server) handleNPSTrend(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 90*24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	window, err := parseQueryDuration(r, "window", 30*24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	step, err := parseQueryDuration(r, "step", 24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	points, err := s.surveys.Trend(r.Context(), from, to, window, step, r.URL.Query().Get("segment"))
+	if err != nil {
+		writeError(w, surveyErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": points})
+}
+
+func surveyErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidSurvey) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
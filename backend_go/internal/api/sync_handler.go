@@ -0,0 +1,48 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+// WithSync accepts pushes from edge instances presenting token as a bearer
+// token. Without a token the endpoint is disabled.
+func (s *Server) WithSync(sync *service.SyncService, token string) *Server {
+	s.sync = sync
+	s.syncToken = token
+	return s
+}
+
+// handleSyncPush answers 200 with the new cursor, or 409 with the current
+// one when the push starts past it.
+func (s *Server) handleSyncPush(w http.ResponseWriter, r *http.Request) {
+	if s.sync == nil || s.syncToken == "" {
+		writeError(w, http.StatusNotFound, errors.New("sync disabled: set SYNC_TOKEN"))
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(bearerToken(r)), []byte(s.syncToken)) != 1 {
+		writeError(w, http.StatusUnauthorized, errors.New("sync credentials required"))
+		return
+	}
+	var push models.SyncPush
+	if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := s.sync.Push(r.Context(), push)
+	switch {
+	case errors.Is(err, service.ErrSyncGap):
+		writeJSON(w, http.StatusConflict, result)
+	case errors.Is(err, service.ErrInvalidSyncPush):
+		writeError(w, http.StatusBadRequest, err)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
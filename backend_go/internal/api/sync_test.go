@@ -0,0 +1,28 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"mydashboard-backend/internal/apitest"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+func TestSyncPushWithAuthRequired(t *testing.T) {
+	const token = "apitest-sync"
+	h := apitest.New(t, apitest.WithAuthRequired(), func(h *apitest.Harness) {
+		h.Server.WithSync(service.NewSyncService(h.Store), token)
+	})
+	push := map[string]any{
+		"node":    "shop1",
+		"segment": "2026-03-01",
+		"offset":  0,
+		"metrics": []models.Metrics{{Revenue: 10, CreatedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)}},
+	}
+
+	h.Do(apitest.Request{Method: http.MethodPost, Path: "/api/sync/push", Body: push, Token: token}).Status(http.StatusOK)
+	h.Do(apitest.Request{Method: http.MethodPost, Path: "/api/sync/push", Body: push}).Status(http.StatusUnauthorized)
+	h.Do(apitest.Request{Method: http.MethodPost, Path: "/api/sync/push", Body: push, Token: "wrong"}).Status(http.StatusUnauthorized)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type TargetRequest struct {
+	Value     float64 `json:"value"`
+	Direction string  `json:"direction"`
+}
+
+func (s *Server) WithTargets(targets *service.TargetService) *Server {
+	s.targets = targets
+	return s
+}
+
+func (s *Server) handleListTargets(w http.ResponseWriter, r *http.Request) {
+	items, err := s.targets.List(r.Context(), r.URL.Query().Get("quarter"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+// handleSaveTarget sets the target of the metric and quarter in the path,
+// as in PUT /targets/2026-Q4/revenue.
+func (s *Server) handleSaveTarget(w http.ResponseWriter, r *http.Request) {
+	var payload TargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	target := models.Target{
+		Metric:    chi.URLParam(r, "metric"),
+		Quarter:   chi.URLParam(r, "quarter"),
+		Value:     payload.Value,
+		Direction: payload.Direction,
+	}
+	if err := s.targets.Save(r.Context(), target); err != nil {
+		writeError(w, targetErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteTarget(w http.ResponseWriter, r *http.Request) {
+	if err := s.targets.Delete(r.Context(), chi.URLParam(r, "metric"), chi.URLParam(r, "quarter")); err != nil {
+		writeError(w, targetErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTargetPacing reports the pacing of the targets of ?quarter=, the
+// current quarter by default. Targets of restricted metrics are left out.
+func (s *Server) handleTargetPacing(w http.ResponseWriter, r *http.Request) {
+	quarter := r.URL.Query().Get("quarter")
+	if quarter == "" {
+		quarter = s.targets.CurrentQuarter()
+	}
+	pacing, err := s.targets.Pacing(r.Context(), quarter)
+	if err != nil {
+		writeError(w, targetErrorStatus(err), err)
+		return
+	}
+	role := s.callerRole(r)
+	var redacted []string
+	visible := pacing[:0]
+	for _, item := range pacing {
+		if s.redactor.Restricted(role, item.Metric) {
+			redacted = append(redacted, item.Metric)
+			continue
+		}
+		visible = append(visible, item)
+	}
+	resp := map[string]any{"quarter": quarter, "data": visible}
+	if len(redacted) > 0 {
+		resp["redacted"] = redacted
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func targetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidTarget):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrNoData):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type OrgRequest struct {
+	Name string `json:"name"`
+}
+
+type TeamRequest struct {
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id"`
+}
+
+type TeamMemberRequest struct {
+	Role string `json:"role"`
+}
+
+func (s *Server) WithTeams(teams *service.TeamService) *Server {
+	s.teams = teams
+	return s
+}
+
+func teamErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidTeam):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrTeamNotEmpty), errors.Is(err, store.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// teamScope reads ?team=, the ID of a team whose widgets, rules or
+// assignments are wanted. The filter it returns keeps what is scoped to that
+// team or to a team below it; it is nil without ?team=.
+func (s *Server) teamScope(r *http.Request) (func(teamID *int64) bool, error) {
+	value := r.URL.Query().Get("team")
+	if value == "" || s.teams == nil {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, errors.New("team must be a team id")
+	}
+	ids, err := s.teams.Subtree(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	return func(teamID *int64) bool { return teamID != nil && slices.Contains(ids, *teamID) }, nil
+}
+
+func (s *Server) handleListOrgs(w http.ResponseWriter, r *http.Request) {
+	items, err := s.teams.Orgs(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+func (s *Server) handleCreateOrg(w http.ResponseWriter, r *http.Request) {
+	var payload OrgRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	org, err := s.teams.SaveOrg(r.Context(), models.Org{Name: payload.Name})
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": org})
+}
+
+func (s *Server) handleUpdateOrg(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid org id"))
+		return
+	}
+	var payload OrgRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	org, err := s.teams.SaveOrg(r.Context(), models.Org{ID: id, Name: payload.Name})
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": org})
+}
+
+func (s *Server) handleDeleteOrg(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid org id"))
+		return
+	}
+	if err := s.teams.DeleteOrg(r.Context(), id); err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListTeams(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid org id"))
+		return
+	}
+	items, err := s.teams.Teams(r.Context(), id)
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+// handleGetTeam returns a team with its members and the teams directly
+// below it.
+func (s *Server) handleGetTeam(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid team id"))
+		return
+	}
+	team, err := s.teams.Team(r.Context(), id)
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	members, err := s.teams.Members(r.Context(), id)
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	teams, err := s.teams.Teams(r.Context(), team.OrgID)
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	subteams := slices.DeleteFunc(teams, func(t models.Team) bool { return t.ParentID == nil || *t.ParentID != id })
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+		"team":     team,
+		"members":  members,
+		"subteams": subteams,
+	}})
+}
+
+func (s *Server) handleCreateTeam(w http.ResponseWriter, r *http.Request) {
+	orgID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid org id"))
+		return
+	}
+	var payload TeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := s.teams.Org(r.Context(), orgID); err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	team, err := s.teams.SaveTeam(r.Context(), models.Team{OrgID: orgID, ParentID: payload.ParentID, Name: payload.Name})
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"data": team})
+}
+
+// handleUpdateTeam renames a team or moves it under another parent in its
+// org; a null parent_id makes it a top-level team.
+func (s *Server) handleUpdateTeam(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid team id"))
+		return
+	}
+	var payload TeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	team, err := s.teams.SaveTeam(r.Context(), models.Team{ID: id, ParentID: payload.ParentID, Name: payload.Name})
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": team})
+}
+
+func (s *Server) handleDeleteTeam(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid team id"))
+		return
+	}
+	if err := s.teams.DeleteTeam(r.Context(), id); err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleSetTeamMember(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid team id"))
+		return
+	}
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid user id"))
+		return
+	}
+	var payload TeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	member, err := s.teams.SetMember(r.Context(), teamID, userID, payload.Role)
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": member})
+}
+
+func (s *Server) handleRemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid team id"))
+		return
+	}
+	userID, err := strconv.ParseInt(chi.URLParam(r, "user_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid user id"))
+		return
+	}
+	if err := s.teams.RemoveMember(r.Context(), teamID, userID); err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTeamAssignments lists the assignments scoped to a team or a team
+// below it, filtered by ?status= as /api/me/assignments is.
+func (s *Server) handleTeamAssignments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid team id"))
+		return
+	}
+	ids, err := s.teams.Subtree(r.Context(), id)
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	var statuses []string
+	if value := r.URL.Query().Get("status"); value != "" {
+		statuses = strings.Split(value, ",")
+	}
+	items, err := s.insights.TeamAssignments(r.Context(), ids, statuses)
+	if err != nil {
+		writeError(w, assignmentErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+func (s *Server) handleMyTeams(w http.ResponseWriter, r *http.Request) {
+	principal, _ := principalFrom(r.Context())
+	items, err := s.teams.UserTeams(r.Context(), principal.UserID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
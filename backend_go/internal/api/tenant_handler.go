@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type TenantSettingsRequest struct {
+	Name     string   `json:"name"`
+	LogoURL  string   `json:"logo_url"`
+	Palette  []string `json:"palette"`
+	Locale   string   `json:"locale"`
+	Timezone string   `json:"timezone"`
+	Currency string   `json:"currency"`
+}
+
+func (s *Server) WithTenantSettings(settings *service.TenantSettingsService) *Server {
+	s.tenantSettings = settings
+	return s
+}
+
+// handleTenantSettings serves the branding and defaults of the tenant in
+// ?tenant= or X-Tenant-ID, for the frontend to apply when it loads.
+func (s *Server) handleTenantSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", tenantHeader)
+	settings, err := s.tenantSettings.Get(r.Context(), metaTenant(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": settings})
+}
+
+// handleSaveTenantSettings replaces the settings of the tenant in ?tenant=
+// or X-Tenant-ID, or the defaults of every tenant without either.
+func (s *Server) handleSaveTenantSettings(w http.ResponseWriter, r *http.Request) {
+	var payload TenantSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	settings, err := s.tenantSettings.Save(r.Context(), models.TenantSettings{
+		Tenant:   metaTenant(r),
+		Name:     payload.Name,
+		LogoURL:  payload.LogoURL,
+		Palette:  payload.Palette,
+		Locale:   payload.Locale,
+		Timezone: payload.Timezone,
+		Currency: payload.Currency,
+	})
+	if err != nil {
+		writeError(w, tenantSettingsErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": settings})
+}
+
+func (s *Server) handleDeleteTenantSettings(w http.ResponseWriter, r *http.Request) {
+	if err := s.tenantSettings.Delete(r.Context(), metaTenant(r)); err != nil {
+		writeError(w, tenantSettingsErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func tenantSettingsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidTenantSettings):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+func (s *Server) WithCatalog(catalog *service.MetricCatalog) *Server {
+	s.catalog = catalog
+	return s
+}
+
+// units resolves the ?currency parameter. Without a catalog nothing is
+// described or converted.
+func (s *Server) units(r *http.Request) (map[string]models.MetricDefinition, map[string]float64, error) {
+	if s.catalog == nil {
+		return nil, nil, nil
+	}
+	return s.catalog.Definitions(r.URL.Query().Get("currency"))
+}
+
+func unitsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUnknownCurrency):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrFXUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *Server) handleMetricDefinitions(w http.ResponseWriter, r *http.Request) {
+	if s.catalog == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"data": []models.MetricDefinition{}})
+		return
+	}
+	defs, err := s.catalog.List(r.URL.Query().Get("currency"))
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": defs})
+}
+
+// convert prepares a snapshot for a response: currency conversion, then the
+// configured rounding.
+func (s *Server) convert(metrics models.Metrics, factors map[string]float64) models.Metrics {
+	return s.catalog.Round(service.Convert(metrics, factors))
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"mydashboard-backend/internal/models"
+)
+
+const (
+	tenantHeader = "X-Tenant-ID"
+	apiKeyHeader = "X-API-Key"
+)
+
+// usageCallerKey holds the *string that authenticate and authorize fill in
+// with the caller they verified. trackUsage runs before them so that the
+// requests they reject are counted too.
+type usageCallerKey struct{}
+
+func setUsageCaller(ctx context.Context, caller string) {
+	if holder, ok := ctx.Value(usageCallerKey{}).(*string); ok {
+		*holder = caller
+	}
+}
+
+// trackUsage attributes each request to the verified caller only; the
+// X-Tenant-ID and X-API-Key headers are not checked against anything, so
+// they would let any client bill its traffic to someone else.
+func (s *Server) trackUsage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.usage == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var caller string
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), usageCallerKey{}, &caller)))
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if caller == "" && s.callerRole(r) == models.RoleAdmin {
+			caller = "admin"
+		}
+		s.usage.Record(
+			caller,
+			max(r.ContentLength, 0),
+			int64(ww.BytesWritten()),
+			status >= http.StatusBadRequest,
+		)
+	})
+}
+
+func (s *Server) handleUsageReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r, 7*24*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var byKey bool
+	switch r.URL.Query().Get("group") {
+	case "", "tenant":
+	case "caller", "key":
+		byKey = true
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("group must be tenant or caller"))
+		return
+	}
+	report, err := s.usage.Report(r.Context(), from, to, byKey)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": report})
+}
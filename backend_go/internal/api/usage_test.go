@@ -0,0 +1,42 @@
+package api_test
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"mydashboard-backend/internal/apitest"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+func TestUsageIgnoresClaimedTenantAndKey(t *testing.T) {
+	h := apitest.New(t, apitest.WithAuthRequired())
+	viewer := h.UserToken("vera", models.RoleViewer)
+	spoofed := map[string]string{"X-Tenant-ID": "acme", "X-API-Key": "someone-elses-key"}
+
+	h.Do(apitest.Request{Method: http.MethodGet, Path: "/api/tenant/settings", Token: viewer, Headers: spoofed}).Status(http.StatusOK)
+	h.Do(apitest.Request{Method: http.MethodGet, Path: "/api/tenant/settings", Headers: spoofed}).Status(http.StatusUnauthorized)
+	h.Do(apitest.Request{Method: http.MethodGet, Path: "/api/tenant/settings", Token: apitest.AdminToken, Headers: spoofed}).Status(http.StatusOK)
+
+	var report struct {
+		Data models.UsageReport `json:"data"`
+	}
+	h.Admin(http.MethodGet, "/api/admin/usage?group=caller", nil).Status(http.StatusOK).Decode(&report)
+
+	var callers []string
+	for _, row := range report.Data.Rows {
+		if row.Tenant != service.DefaultTenant {
+			t.Errorf("row %+v, want it counted against %s", row, service.DefaultTenant)
+		}
+		if row.Requests != 1 {
+			t.Errorf("row %+v, want one request", row)
+		}
+		callers = append(callers, row.Caller)
+	}
+	slices.Sort(callers)
+	if len(callers) != 3 || callers[0] != "" || callers[1] != "admin" || !strings.HasPrefix(callers[2], "user:") {
+		t.Errorf("callers %q, want anonymous, admin and the viewer", callers)
+	}
+}
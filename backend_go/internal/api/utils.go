@@ -4,38 +4,164 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/store"
+)
+
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", "Idempotency-Key", "Accept-Language", "X-Tenant-ID", "X-API-Key", "If-Match", "If-None-Match"}
 )
 
-func corsMiddleware(allowedOrigins string) func(http.Handler) http.Handler {
-	origins := strings.FieldsFunc(allowedOrigins, func(r rune) bool { return r == ',' })
-	allowAll := allowedOrigins == "" || allowedOrigins == "*"
+// allowsAnyOrigin reports whether the configuration admits every origin,
+// either by listing "*" or by listing nothing.
+func (c CORSConfig) allowsAnyOrigin() bool {
+	for _, origin := range c.AllowedOrigins {
+		if strings.TrimSpace(origin) == "*" {
+			return true
+		}
+	}
+	return len(c.AllowedOrigins) == 0
+}
+
+// Validate rejects credentials combined with any origin, which would let
+// every site make authenticated requests on a visitor's behalf.
+func (c CORSConfig) Validate() error {
+	if c.AllowCredentials && c.allowsAnyOrigin() {
+		return errors.New("credentials need an explicit list of allowed origins, not *")
+	}
+	return nil
+}
+
+// corsMiddleware accepts exact origins, "*", and wildcard subdomains such as
+// "*.example.com" or "https://*.example.com". With credentials enabled the
+// request origin is echoed instead of "*", as browsers require, but only for
+// origins listed explicitly; "*" never admits credentialed requests.
+func corsMiddleware(cfg CORSConfig) func(http.Handler) http.Handler {
+	allowAll := cfg.allowsAnyOrigin()
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
-			if allowAll {
-				w.Header().Set("Access-Control-Allow-Origin", "*")
-			} else if origin != "" {
-				for _, allowed := range origins {
-					if strings.EqualFold(strings.TrimSpace(allowed), origin) {
-						w.Header().Set("Access-Control-Allow-Origin", origin)
-						break
-					}
+			header := w.Header()
+			if !allowAll || cfg.AllowCredentials {
+				header.Add("Vary", "Origin")
+			}
+			allowed := origin != "" && originAllowed(cfg.AllowedOrigins, origin)
+			switch {
+			case allowAll && !cfg.AllowCredentials:
+				header.Set("Access-Control-Allow-Origin", "*")
+			case allowed:
+				header.Set("Access-Control-Allow-Origin", origin)
+				if cfg.AllowCredentials {
+					header.Set("Access-Control-Allow-Credentials", "true")
 				}
 			}
-			w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			if header.Get("Access-Control-Allow-Origin") != "" {
+				header.Set("Access-Control-Expose-Headers", "ETag")
+			}
 
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusNoContent)
+			if r.Method != http.MethodOptions {
+				next.ServeHTTP(w, r)
 				return
 			}
-			next.ServeHTTP(w, r)
+			if r.Header.Get("Access-Control-Request-Method") != "" {
+				header.Add("Vary", "Access-Control-Request-Method")
+				header.Add("Vary", "Access-Control-Request-Headers")
+				header.Set("Access-Control-Allow-Methods", allowMethods)
+				header.Set("Access-Control-Allow-Headers", allowHeaders)
+				if cfg.MaxAge > 0 {
+					header.Set("Access-Control-Max-Age", maxAge)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
 		})
 	}
 }
 
+func originAllowed(patterns []string, origin string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if strings.EqualFold(pattern, origin) {
+			return true
+		}
+		scheme, host, hasScheme := strings.Cut(pattern, "://")
+		if !hasScheme {
+			scheme, host = "", pattern
+		}
+		suffix, ok := strings.CutPrefix(host, "*.")
+		if !ok || (scheme != "" && !strings.EqualFold(scheme, parsed.Scheme)) {
+			continue
+		}
+		originHost := parsed.Host
+		if !strings.Contains(suffix, ":") {
+			originHost = parsed.Hostname()
+		}
+		if strings.HasSuffix(strings.ToLower(originHost), "."+strings.ToLower(suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestLocale picks the locale from ?lang, then the caller's profile, then
+// Accept-Language.
+func requestLocale(r *http.Request) string {
+	lang := r.URL.Query().Get("lang")
+	if principal, ok := principalFrom(r.Context()); ok && lang == "" {
+		lang = principal.Locale
+	}
+	return i18n.Negotiate(lang, r.Header.Get("Accept-Language"))
+}
+
+// requestLocation reads ?tz as an IANA zone name, falling back to the
+// caller's profile timezone and then to fallback.
+func requestLocation(r *http.Request, fallback *time.Location) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if principal, ok := principalFrom(r.Context()); ok && name == "" {
+		name = principal.Timezone
+	}
+	if name == "" {
+		if fallback == nil {
+			return time.Local, nil
+		}
+		return fallback, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.New("tz must be an IANA timezone such as Asia/Shanghai")
+	}
+	return loc, nil
+}
+
 func parseQueryInt(r *http.Request, key string, fallback int) int {
 	value := r.URL.Query().Get(key)
 	if value == "" {
@@ -48,15 +174,108 @@ func parseQueryInt(r *http.Request, key string, fallback int) int {
 	return parsed
 }
 
+func parseQueryTime(r *http.Request, key string, fallback time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New(key + " must be an RFC3339 timestamp")
+	}
+	return parsed, nil
+}
+
+// parseQueryDuration accepts Go durations plus whole days such as "7d".
+func parseQueryDuration(r *http.Request, key string, fallback time.Duration) (time.Duration, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.New(key + " must be a duration such as 30m, 24h or 7d")
+	}
+	return parsed, nil
+}
+
+// parseRange reads from/to query parameters, defaulting to the trailing
+// span ending now.
+func parseRange(r *http.Request, span time.Duration) (time.Time, time.Time, error) {
+	to, err := parseQueryTime(r, "to", time.Now())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	from, err := parseQueryTime(r, "from", to.Add(-span))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+// writeJSON adds server_time to object payloads, so clients can place the
+// UTC timestamps they receive against the server's clock.
 func writeJSON(w http.ResponseWriter, status int, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		status = http.StatusInternalServerError
+		body, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(payload)
+	_, _ = w.Write(withServerTime(body))
+	_, _ = w.Write([]byte("\n"))
+}
+
+func serverTime() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+// withServerTime leaves the body alone when the payload sets server_time
+// itself. Only top-level keys count; a nested object that happens to carry
+// the same key does not.
+func withServerTime(body []byte) []byte {
+	if len(body) < 2 || body[0] != '{' {
+		return body
+	}
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(body, &top); err != nil {
+		return body
+	}
+	if _, ok := top["server_time"]; ok {
+		return body
+	}
+	field := `"server_time":"` + serverTime() + `"`
+	if len(body) == 2 {
+		return []byte("{" + field + "}")
+	}
+	out := make([]byte, 0, len(body)+len(field)+1)
+	out = append(out, body[:len(body)-1]...)
+	out = append(out, ","+field+"}"...)
+	return out
 }
 
 func writeError(w http.ResponseWriter, status int, err error) {
 	if err == nil {
 		err = errors.New("unknown error")
 	}
+	var timeoutErr *store.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		status = http.StatusGatewayTimeout
+	} else if errors.Is(err, store.ErrCircuitOpen) {
+		status = http.StatusServiceUnavailable
+	}
+	if pw, ok := findProblemWriter(w); ok {
+		writeProblem(w, pw, status, err)
+		return
+	}
 	writeJSON(w, status, map[string]string{"error": err.Error()})
 }
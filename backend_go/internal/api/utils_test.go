@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWithServerTimeChecksTopLevelKeysOnly(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body  string
+		added bool
+	}{
+		"object":          {`{"data":[1,2]}`, true},
+		"empty object":    {`{}`, true},
+		"nested key":      {`{"data":{"server_time":"2026-03-01T09:00:00Z"}}`, true},
+		"key in a string": {`{"note":"\"server_time\":"}`, true},
+		"top-level key":   {`{"data":1,"server_time":"2026-03-01T09:00:00Z"}`, false},
+		"array":           {`[{"a":1}]`, false},
+	} {
+		var out map[string]json.RawMessage
+		body := withServerTime([]byte(tc.body))
+		if !tc.added {
+			if string(body) != tc.body {
+				t.Errorf("%s: got %s, want the body unchanged", name, body)
+			}
+			continue
+		}
+		if err := json.Unmarshal(body, &out); err != nil {
+			t.Fatalf("%s: %v in %s", name, err, body)
+		}
+		if _, ok := out["server_time"]; !ok {
+			t.Errorf("%s: got %s, want server_time added", name, body)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+)
+
+const (
+	wallboardInsights    = 3
+	wallboardInsightPool = 20
+	wallboardRefresh     = 60
+)
+
+var severityRank = map[string]int{
+	models.SeverityCritical: 0,
+	models.SeverityWarning:  1,
+	models.SeverityInfo:     2,
+}
+
+// WallboardResponse is everything a TV display needs in one request.
+// RefreshAfter is a hint in seconds; displays should keep showing the last
+// payload when a refresh fails.
+type WallboardResponse struct {
+	Metrics      models.Metrics                     `json:"metrics"`
+	Deltas       map[string]models.MetricDelta      `json:"deltas"`
+	Sparklines   map[string][]float64               `json:"sparklines"`
+	Insights     []models.Insight                   `json:"insights"`
+	Units        map[string]models.MetricDefinition `json:"units,omitempty"`
+	Redacted     []string                           `json:"redacted,omitempty"`
+	Degraded     bool                               `json:"degraded,omitempty"`
+	RefreshAfter int                                `json:"refresh_after"`
+}
+
+// handleWallboard serves a compact snapshot with an ETag so displays polling
+// over poor connections mostly get an empty 304. A failing trend or insight
+// lookup degrades the payload instead of failing it.
+func (s *Server) handleWallboard(w http.ResponseWriter, r *http.Request) {
+	points := parseQueryInt(r, "points", 24)
+	points = min(max(points, 3), 200)
+	units, factors, err := s.units(r)
+	if err != nil {
+		writeError(w, unitsErrorStatus(err), err)
+		return
+	}
+	latest, degraded, err := s.metrics.Latest(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	latest, redacted := s.visibleMetrics(r, latest)
+	latest = s.convert(latest, factors)
+
+	resp := WallboardResponse{
+		Metrics:      latest,
+		Deltas:       map[string]models.MetricDelta{},
+		Sparklines:   map[string][]float64{},
+		Insights:     []models.Insight{},
+		Units:        units,
+		Redacted:     redacted,
+		Degraded:     degraded,
+		RefreshAfter: wallboardRefresh,
+	}
+
+	trend, err := s.metrics.Trend(r.Context(), points)
+	if err != nil {
+		log.Printf("wallboard trend failed: %v", err)
+		resp.Degraded = true
+	} else if len(trend) > 0 {
+		trend = s.visibleSeries(r, trend, factors)
+		first := trend[0]
+		resp.Deltas = service.Diff(first.CreatedAt, latest.CreatedAt, first, latest, redacted).Deltas
+		resp.Sparklines = sparklines(trend, resp.Deltas)
+	}
+
+	items, insightsDegraded, err := s.insights.Latest(r.Context(), requestLocale(r), wallboardInsightPool)
+	if err != nil {
+		log.Printf("wallboard insights failed: %v", err)
+		resp.Degraded = true
+	} else {
+		resp.Degraded = resp.Degraded || insightsDegraded
+		resp.Insights = topInsights(items, redacted, wallboardInsights)
+	}
+
+	writeJSONWithETag(w, r, resp)
+}
+
+// visibleSeries redacts and converts snapshots in place for the caller.
+func (s *Server) visibleSeries(r *http.Request, series []models.Metrics, factors map[string]float64) []models.Metrics {
+	for i := range series {
+		series[i], _ = s.visibleMetrics(r, series[i])
+		series[i] = s.convert(series[i], factors)
+	}
+	return series
+}
+
+// sparklines has one line per metric in deltas, so hidden metrics left out
+// of the deltas get no line either.
+func sparklines(trend []models.Metrics, deltas map[string]models.MetricDelta) map[string][]float64 {
+	lines := make(map[string][]float64, len(deltas))
+	for key := range deltas {
+		line := make([]float64, len(trend))
+		for i, point := range trend {
+			line[i], _ = point.Value(key)
+		}
+		lines[key] = line
+	}
+	return lines
+}
+
+// topInsights orders by severity, newest first within a severity, and skips
+// insights about metrics the caller cannot see.
+func topInsights(items []models.Insight, hidden []string, limit int) []models.Insight {
+	out := make([]models.Insight, 0, limit)
+	for _, item := range items {
+		if insightVisible(item, hidden) {
+			out = append(out, item)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b models.Insight) int {
+		return severityRank[a.Severity] - severityRank[b.Severity]
+	})
+	return out[:min(limit, len(out))]
+}
+
+// insightVisible hides insights about hidden metrics. Unlinked insights may
+// mention any metric, so they are hidden whenever anything is.
+func insightVisible(item models.Insight, hidden []string) bool {
+	if len(hidden) == 0 {
+		return true
+	}
+	if len(item.Metrics) == 0 {
+		return false
+	}
+	for _, link := range item.Metrics {
+		if slices.Contains(hidden, link.MetricKey) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeWithETag(w, r, "application/json; charset=utf-8", append(body, '\n'))
+}
+
+// writeWithETag answers 304 when the client already holds body. Without a
+// route cache rule, clients revalidate on every use.
+func writeWithETag(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
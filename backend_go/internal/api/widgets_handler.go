@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+type WidgetRequest struct {
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Kind        string                `json:"kind"`
+	Series      []models.WidgetSeries `json:"series"`
+	Params      []models.WidgetParam  `json:"params"`
+	Window      int                   `json:"window"`
+	TeamID      *int64                `json:"team_id"`
+}
+
+func (s *Server) WithWidgets(widgets *service.WidgetService) *Server {
+	s.widgets = widgets
+	return s
+}
+
+// handleListWidgets lists the widgets, with ?team= those of a team and the
+// teams below it.
+func (s *Server) handleListWidgets(w http.ResponseWriter, r *http.Request) {
+	inTeam, err := s.teamScope(r)
+	if err != nil {
+		writeError(w, teamErrorStatus(err), err)
+		return
+	}
+	items, err := s.widgets.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if inTeam != nil {
+		items = slices.DeleteFunc(items, func(widget models.Widget) bool { return !inTeam(widget.TeamID) })
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+func (s *Server) handleGetWidget(w http.ResponseWriter, r *http.Request) {
+	widget, err := s.widgets.Get(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, widgetErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": widget})
+}
+
+// handleWidgetData evaluates a widget. Parameters are taken from query
+// arguments of the same name; with from or to the series cover that range,
+// otherwise the widget's window of recent snapshots.
+func (s *Server) handleWidgetData(w http.ResponseWriter, r *http.Request) {
+	values := map[string]string{}
+	for key := range r.URL.Query() {
+		values[key] = r.URL.Query().Get(key)
+	}
+	prepared, err := s.widgets.Prepare(r.Context(), chi.URLParam(r, "name"), values)
+	if err != nil {
+		writeError(w, widgetErrorStatus(err), err)
+		return
+	}
+	for _, q := range prepared.Queries {
+		if !s.canReadQuery(r, q) {
+			writeError(w, http.StatusForbidden, service.ErrMetricRestricted)
+			return
+		}
+	}
+	var from, to time.Time
+	if query := r.URL.Query(); query.Has("from") || query.Has("to") {
+		if from, to, err = parseRange(r, 24*time.Hour); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	data, err := s.widgets.Data(r.Context(), prepared, from, to, parseQueryInt(r, "max_points", 0))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": data})
+}
+
+// handleSaveWidget creates the widget named in the path or replaces it.
+func (s *Server) handleSaveWidget(w http.ResponseWriter, r *http.Request) {
+	var payload WidgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	widget, err := s.widgets.Save(r.Context(), models.Widget{
+		Name:        chi.URLParam(r, "name"),
+		Title:       payload.Title,
+		Description: payload.Description,
+		Kind:        payload.Kind,
+		Series:      payload.Series,
+		Params:      payload.Params,
+		Window:      payload.Window,
+		TeamID:      payload.TeamID,
+	})
+	if err != nil {
+		writeError(w, widgetErrorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": widget})
+}
+
+func (s *Server) handleDeleteWidget(w http.ResponseWriter, r *http.Request) {
+	if err := s.widgets.Delete(r.Context(), chi.URLParam(r, "name")); err != nil {
+		writeError(w, widgetErrorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func widgetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidWidget):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
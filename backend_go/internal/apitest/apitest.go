@@ -0,0 +1,322 @@
+// Package apitest is a harness for endpoint tests. It wires the API server to
+// an in-memory store, sends requests through the full router without opening
+// a port, and compares JSON responses with golden files under testdata.
+//
+//	func TestLatestMetrics(t *testing.T) {
+//		h := apitest.New(t)
+//		h.SeedMetrics(models.Metrics{Revenue: 1200, Growth: 3.5})
+//		h.Get("/api/metrics/latest").Status(http.StatusOK).Golden("latest_metrics", "created_at", "timestamp")
+//	}
+//
+// Run the tests with UPDATE_GOLDEN=1 to write or refresh the golden files.
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mydashboard-backend/internal/api"
+	"mydashboard-backend/internal/auth"
+	"mydashboard-backend/internal/collector"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/scheduler"
+	"mydashboard-backend/internal/service"
+	"mydashboard-backend/internal/store"
+)
+
+// AdminToken is the bearer token the harness server accepts for admin routes.
+const AdminToken = "apitest-admin"
+
+const masked = "<masked>"
+
+// Harness is a server backed by a fresh in-memory store. The services are
+// exposed so tests can configure them or set up state the API has no route
+// for.
+type Harness struct {
+	T        testing.TB
+	Store    *store.Store
+	Metrics  *service.MetricsService
+	Insights *service.InsightsService
+	Auth     *service.AuthService
+	Server   *api.Server
+
+	handler http.Handler
+}
+
+// New builds a harness. Options run before the routes are built, so they can
+// attach further services with the server's With methods.
+func New(t testing.TB, options ...func(*Harness)) *Harness {
+	t.Helper()
+	st, err := store.NewMemory("")
+	if err != nil {
+		t.Fatalf("apitest: memory store: %v", err)
+	}
+	h := &Harness{T: t, Store: st}
+	simulation := service.NewSimulation()
+	derived := service.NewDerivedMetricService(st)
+	h.Metrics = service.NewMetricsService(st, simulation).WithDerived(derived)
+	escalations := service.NewEscalationService(st, "")
+	h.Insights = service.NewInsightsService(st, nil).WithEscalation(escalations)
+	audit := service.NewAuditService(st)
+	policy := service.NewPasswordPolicy(8, 0)
+	h.Auth = service.NewAuthService(st, auth.NewSigner([]byte("apitest-secret")), 15*time.Minute, time.Hour).
+		WithPasswordPolicy(policy).
+		WithAudit(audit)
+	catalog := service.NewMetricCatalog(service.DefaultMetricDefinitions(), service.NewFXRates("USD", nil))
+	// The server gets every service main wires, with the defaults of their
+	// environment variables, so no route is left with a nil service.
+	h.Server = api.NewServer(h.Metrics, h.Insights).
+		WithAdminToken(AdminToken).
+		WithAuth(h.Auth).
+		WithIdempotency(service.NewIdempotencyService(st, 24*time.Hour)).
+		WithBacklog(service.NewBacklogService(st, 72*time.Hour)).
+		WithSync(service.NewSyncService(st), "").
+		WithScheduler(scheduler.New(st)).
+		WithJobs(service.NewJobQueue(st, time.Minute)).
+		WithDBStats(st.Stats).
+		WithUsage(service.NewUsageService(st)).
+		WithRedaction(service.NewRedactor(nil, []string{models.RoleAdmin, models.RoleAnalyst})).
+		WithHealth(service.NewHealthService().Register("database", st.Ping)).
+		WithCatalog(catalog).
+		WithLocation(time.UTC).
+		WithEmbeds(service.NewEmbedService(st)).
+		WithNotifications(service.NewNotificationService(st)).
+		WithNotificationPreferences(service.NewNotificationPreferenceService(st)).
+		WithSilences(service.NewSilenceService(st).WithLocation(time.UTC)).
+		WithDerivedMetrics(derived).
+		WithFunnels(service.NewFunnelService(st).WithLocation(time.UTC)).
+		WithSurveys(service.NewSurveyService(st)).
+		WithDimensions(service.NewDimensionService(st)).
+		WithTargets(service.NewTargetService(st).WithLocation(time.UTC)).
+		WithWidgets(service.NewWidgetService(st, h.Metrics)).
+		WithTeams(service.NewTeamService(st)).
+		WithInvitations(service.NewInvitationService(st).WithPasswordPolicy(policy).WithAudit(audit)).
+		WithAudit(audit).
+		WithMetricMeta(service.NewMetricMetaService(st, h.Metrics).WithCatalog(catalog)).
+		WithTenantSettings(service.NewTenantSettingsService(st).WithLocation(time.UTC).WithCatalog(catalog)).
+		WithOverview(service.NewOverviewService(st, h.Metrics, h.Insights, time.Minute)).
+		WithAdHocQueries(service.NewAdHocQueryService(st, 1000, 5*time.Second), []string{models.RoleAdmin, models.RoleAnalyst}).
+		WithSimulation(simulation).
+		WithEscalations(escalations).
+		WithBackups(service.NewBackupService(st)).
+		WithCollectors(collector.NewManager(h.Metrics.Record))
+	for _, option := range options {
+		option(h)
+	}
+	h.handler = h.Server.Routes(api.CORSConfig{})
+	return h
+}
+
+// WithAuthRequired turns on AUTH_REQUIRED, so anonymous callers only reach
+// the public routes.
+func WithAuthRequired() func(*Harness) {
+	return func(h *Harness) {
+		h.Server.WithAuthRequired(true)
+	}
+}
+
+// WithContract validates every response against an OpenAPI document, see
+// api.LoadContract. A response that does not match comes back as a 500
+// listing the differences.
+func WithContract(path string) func(*Harness) {
+	return func(h *Harness) {
+		h.T.Helper()
+		contract, err := api.LoadContract(path)
+		if err != nil {
+			h.T.Fatalf("apitest: %v", err)
+		}
+		h.Server.WithContract(contract)
+	}
+}
+
+// SeedMetrics stores snapshots in order. Snapshots without CreatedAt are
+// spaced a minute apart, ending now.
+func (h *Harness) SeedMetrics(snapshots ...models.Metrics) {
+	h.T.Helper()
+	start := time.Now().UTC().Truncate(time.Second).Add(-time.Duration(len(snapshots)-1) * time.Minute)
+	for i, m := range snapshots {
+		if m.CreatedAt.IsZero() {
+			m.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		}
+		if err := h.Store.InsertMetrics(context.Background(), m); err != nil {
+			h.T.Fatalf("apitest: seed metrics: %v", err)
+		}
+	}
+}
+
+// SeedInsight stores an insight and returns it with its id.
+func (h *Harness) SeedInsight(insight models.Insight) models.Insight {
+	h.T.Helper()
+	if insight.CreatedAt.IsZero() {
+		insight.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	stored, err := h.Store.InsertInsight(context.Background(), insight)
+	if err != nil {
+		h.T.Fatalf("apitest: seed insight: %v", err)
+	}
+	return stored
+}
+
+// UserToken creates a user with the given role and returns an access token
+// for it.
+func (h *Harness) UserToken(username, role string) string {
+	h.T.Helper()
+	const password = "apitest-Passw0rd!"
+	ctx := context.Background()
+	if _, err := h.Auth.CreateUser(ctx, models.User{Username: username, Role: role}, password); err != nil {
+		h.T.Fatalf("apitest: create user %s: %v", username, err)
+	}
+	pair, err := h.Auth.Login(ctx, username, password, "", "apitest", "127.0.0.1")
+	if err != nil {
+		h.T.Fatalf("apitest: login %s: %v", username, err)
+	}
+	return pair.AccessToken
+}
+
+// Request is sent with Do. Body is encoded as JSON unless it is already a
+// string or []byte.
+type Request struct {
+	Method  string
+	Path    string
+	Body    any
+	Token   string
+	Headers map[string]string
+}
+
+func (h *Harness) Get(path string) *Response {
+	return h.Do(Request{Method: http.MethodGet, Path: path})
+}
+
+// Admin sends a request with the admin token.
+func (h *Harness) Admin(method, path string, body any) *Response {
+	return h.Do(Request{Method: method, Path: path, Body: body, Token: AdminToken})
+}
+
+func (h *Harness) Do(req Request) *Response {
+	h.T.Helper()
+	var body io.Reader
+	switch b := req.Body.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(b)
+	case []byte:
+		body = bytes.NewReader(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			h.T.Fatalf("apitest: encode body: %v", err)
+		}
+		body = bytes.NewReader(raw)
+	}
+	r := httptest.NewRequest(req.Method, req.Path, body)
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if req.Token != "" {
+		r.Header.Set("Authorization", "Bearer "+req.Token)
+	}
+	for key, value := range req.Headers {
+		r.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	h.handler.ServeHTTP(w, r)
+	return &Response{Recorder: w, t: h.T, req: req}
+}
+
+type Response struct {
+	Recorder *httptest.ResponseRecorder
+	t        testing.TB
+	req      Request
+}
+
+func (r *Response) Code() int {
+	return r.Recorder.Code
+}
+
+func (r *Response) Body() []byte {
+	return r.Recorder.Body.Bytes()
+}
+
+// Status fails the test unless the response has the given status code.
+func (r *Response) Status(want int) *Response {
+	r.t.Helper()
+	if r.Recorder.Code != want {
+		r.t.Fatalf("%s %s: status %d, want %d\n%s", r.req.Method, r.req.Path, r.Recorder.Code, want, r.Recorder.Body.String())
+	}
+	return r
+}
+
+// Decode unmarshals the response body into v.
+func (r *Response) Decode(v any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), v); err != nil {
+		r.t.Fatalf("%s %s: decode response: %v\n%s", r.req.Method, r.req.Path, err, r.Recorder.Body.String())
+	}
+	return r
+}
+
+// Golden compares the JSON body with testdata/<name>.golden.json. Values of
+// the masked keys, at any depth, are replaced before comparing, so ids and
+// timestamps that change between runs do not break the test.
+func (r *Response) Golden(name string, mask ...string) *Response {
+	r.t.Helper()
+	var body any
+	if err := json.Unmarshal(r.Recorder.Body.Bytes(), &body); err != nil {
+		r.t.Fatalf("%s %s: response is not JSON: %v\n%s", r.req.Method, r.req.Path, err, r.Recorder.Body.String())
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(maskKeys(body, mask)); err != nil {
+		r.t.Fatalf("apitest: %v", err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			r.t.Fatalf("apitest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			r.t.Fatalf("apitest: %v", err)
+		}
+		return r
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		r.t.Fatalf("apitest: %v (run with UPDATE_GOLDEN=1 to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		r.t.Fatalf("%s %s: response differs from %s\n--- got\n%s--- want\n%s", r.req.Method, r.req.Path, path, got, want)
+	}
+	return r
+}
+
+func maskKeys(v any, keys []string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if slices.Contains(keys, key) && value != nil {
+				v[key] = masked
+				continue
+			}
+			v[key] = maskKeys(value, keys)
+		}
+	case []any:
+		for i := range v {
+			v[i] = maskKeys(v[i], keys)
+		}
+	}
+	return v
+}
@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+var csvHeader = []string{"created_at", "revenue", "growth", "sentiment", "backlog"}
+
+// EncodeMetrics writes snapshots as gzip-compressed CSV with a header row
+// and RFC 3339 UTC timestamps.
+func EncodeMetrics(points []models.Metrics) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	w := csv.NewWriter(zw)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, point := range points {
+		if err := w.Write([]string{
+			point.CreatedAt.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(point.Revenue, 'f', -1, 64),
+			strconv.FormatFloat(point.Growth, 'f', -1, 64),
+			strconv.FormatFloat(point.Sentiment, 'f', -1, 64),
+			strconv.Itoa(point.Backlog),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeMetrics reads an object written by EncodeMetrics.
+func DecodeMetrics(data []byte) ([]models.Metrics, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(zr)
+	r.FieldsPerRecord = len(csvHeader)
+	if _, err := r.Read(); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	var points []models.Metrics
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return points, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		point, err := parseRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		points = append(points, point)
+	}
+}
+
+func parseRecord(record []string) (models.Metrics, error) {
+	var point models.Metrics
+	var err error
+	if point.CreatedAt, err = time.Parse(time.RFC3339Nano, record[0]); err != nil {
+		return point, err
+	}
+	if point.Revenue, err = strconv.ParseFloat(record[1], 64); err != nil {
+		return point, err
+	}
+	if point.Growth, err = strconv.ParseFloat(record[2], 64); err != nil {
+		return point, err
+	}
+	if point.Sentiment, err = strconv.ParseFloat(record[3], 64); err != nil {
+		return point, err
+	}
+	if point.Backlog, err = strconv.Atoi(record[4]); err != nil {
+		return point, err
+	}
+	return point, nil
+}
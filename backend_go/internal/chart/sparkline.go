@@ -0,0 +1,113 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+var (
+	background = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	lineColor  = color.RGBA{R: 0x25, G: 0x63, B: 0xeb, A: 0xff}
+	blocks     = []rune("▁▂▃▄▅▆▇█")
+)
+
+// SparklinePNG draws values as a line on a white background, scaled to fill
+// the image vertically. Fewer than two values give a blank image.
+func SparklinePNG(values []float64, width, height int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, background)
+		}
+	}
+	if len(values) >= 2 {
+		lo, hi := bounds(values)
+		const pad = 4
+		px := func(i int) int {
+			return pad + i*(width-1-2*pad)/(len(values)-1)
+		}
+		py := func(v float64) int {
+			if hi == lo {
+				return height / 2
+			}
+			return height - 1 - pad - int(math.Round((v-lo)/(hi-lo)*float64(height-1-2*pad)))
+		}
+		for i := 1; i < len(values); i++ {
+			line(img, px(i-1), py(values[i-1]), px(i), py(values[i]))
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SparklineText renders values with block characters, for places that cannot
+// show images.
+func SparklineText(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := bounds(values)
+	out := make([]rune, len(values))
+	for i, v := range values {
+		level := len(blocks) / 2
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(blocks)-1))
+		}
+		out[i] = blocks[level]
+	}
+	return string(out)
+}
+
+func bounds(values []float64) (float64, float64) {
+	lo, hi := values[0], values[0]
+	for _, v := range values[1:] {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	return lo, hi
+}
+
+// line draws a two pixel wide segment with Bresenham's algorithm.
+func line(img *image.RGBA, x0, y0, x1, y1 int) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	err := dx + dy
+	for {
+		img.Set(x0, y0, lineColor)
+		img.Set(x0, y0+1, lineColor)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,223 @@
+// Package collector runs pluggable metric sources. A Collector is registered
+// in main and polled on its own interval; the values it returns are handed
+// to a Sink, normally MetricsService.Record.
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const minInterval = time.Second
+
+var ErrUnknownCollector = errors.New("unknown collector")
+
+type Collector interface {
+	Name() string
+	Interval() time.Duration
+	Collect(ctx context.Context) ([]models.MetricValue, error)
+}
+
+// Starter is implemented by collectors that need to open connections or
+// files before the first Collect. A failed Start is retried on the next
+// interval.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by collectors holding resources to release on
+// shutdown.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+type Sink func(ctx context.Context, values []models.MetricValue) error
+
+type entry struct {
+	collector Collector
+	started   bool
+	status    models.CollectorStatus
+}
+
+// Manager owns the registered collectors. Each runs in its own goroutine,
+// bounded by its interval, and a panicking collector is recorded as a
+// failure instead of taking the process down.
+type Manager struct {
+	sink    Sink
+	mu      sync.Mutex
+	sinkMu  sync.Mutex
+	entries map[string]*entry
+	wg      sync.WaitGroup
+	running bool
+}
+
+func NewManager(sink Sink) *Manager {
+	return &Manager{sink: sink, entries: map[string]*entry{}}
+}
+
+// Register adds a collector. It must be called before Run.
+func (m *Manager) Register(c Collector) error {
+	name := c.Name()
+	if name == "" {
+		return errors.New("collector name is required")
+	}
+	if c.Interval() < minInterval {
+		return fmt.Errorf("collector %s: interval must be at least %s", name, minInterval)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		return fmt.Errorf("collector %s: manager already running", name)
+	}
+	if _, ok := m.entries[name]; ok {
+		return fmt.Errorf("collector %s already registered", name)
+	}
+	m.entries[name] = &entry{collector: c, status: models.CollectorStatus{
+		Name:     name,
+		Interval: c.Interval().String(),
+		State:    models.CollectorIdle,
+	}}
+	return nil
+}
+
+// Run polls every collector until ctx is done, then stops them and waits
+// for in-flight collections to finish.
+func (m *Manager) Run(ctx context.Context) {
+	m.mu.Lock()
+	m.running = true
+	entries := make([]*entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, e)
+	}
+	m.mu.Unlock()
+
+	for _, e := range entries {
+		m.wg.Add(1)
+		go m.loop(ctx, e)
+	}
+	m.wg.Wait()
+}
+
+func (m *Manager) loop(ctx context.Context, e *entry) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(e.collector.Interval())
+	defer ticker.Stop()
+	for {
+		m.runOnce(ctx, e)
+		select {
+		case <-ctx.Done():
+			m.stop(e)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) stop(e *entry) {
+	if stopper, ok := e.collector.(Stopper); ok && e.started {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := stopper.Stop(ctx); err != nil {
+			log.Printf("collector %s: stop failed: %v", e.collector.Name(), err)
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.status.State = models.CollectorStopped
+}
+
+func (m *Manager) runOnce(ctx context.Context, e *entry) {
+	ctx, cancel := context.WithTimeout(ctx, e.collector.Interval())
+	defer cancel()
+	started := time.Now()
+	count, err := m.collect(ctx, e)
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err != nil {
+		log.Printf("collector %s: %v", e.collector.Name(), err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := &e.status
+	status.Runs++
+	status.LastRun = &started
+	status.LastDurationMs = float64(time.Since(started).Microseconds()) / 1000
+	status.LastValues = count
+	if err != nil {
+		status.Failures++
+		status.ConsecutiveFailures++
+		status.LastError = err.Error()
+		status.State = models.CollectorFailing
+		return
+	}
+	status.ConsecutiveFailures = 0
+	status.LastError = ""
+	status.LastSuccess = &started
+	status.State = models.CollectorHealthy
+}
+
+func (m *Manager) collect(ctx context.Context, e *entry) (count int, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	if starter, ok := e.collector.(Starter); ok && !e.started {
+		if err := starter.Start(ctx); err != nil {
+			return 0, fmt.Errorf("start: %w", err)
+		}
+	}
+	e.started = true
+	values, err := e.collector.Collect(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 0 {
+		return 0, nil
+	}
+	// Collectors share one sink so their snapshots do not interleave.
+	m.sinkMu.Lock()
+	defer m.sinkMu.Unlock()
+	if err := m.sink(ctx, values); err != nil {
+		return 0, fmt.Errorf("record: %w", err)
+	}
+	return len(values), nil
+}
+
+// Statuses lists every collector by name.
+func (m *Manager) Statuses() []models.CollectorStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]models.CollectorStatus, 0, len(m.entries))
+	for _, e := range m.entries {
+		out = append(out, e.status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Check reports the collector's last outcome, for HealthService.Register.
+// A collector that has not run yet counts as healthy.
+func (m *Manager) Check(name string) func(context.Context) error {
+	return func(context.Context) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		e, ok := m.entries[name]
+		if !ok {
+			return ErrUnknownCollector
+		}
+		if e.status.State == models.CollectorFailing {
+			return errors.New(e.status.LastError)
+		}
+		return nil
+	}
+}
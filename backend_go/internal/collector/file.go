@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+// FileCollector reads a JSON file written by another process, either an
+// object of metric values such as {"revenue": 1200, "backlog": 7} or an
+// array of MetricValue. An unchanged file is not collected again.
+type FileCollector struct {
+	name     string
+	path     string
+	interval time.Duration
+	modTime  time.Time
+}
+
+func NewFileCollector(name, path string, interval time.Duration) *FileCollector {
+	return &FileCollector{name: name, path: path, interval: interval}
+}
+
+func (c *FileCollector) Name() string            { return c.name }
+func (c *FileCollector) Interval() time.Duration { return c.interval }
+
+func (c *FileCollector) Collect(ctx context.Context) ([]models.MetricValue, error) {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return nil, err
+	}
+	if info.ModTime().Equal(c.modTime) {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := ParseValues(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.path, err)
+	}
+	c.modTime = info.ModTime()
+	return values, nil
+}
+
+// ParseValues decodes an object of metric values or an array of MetricValue.
+func ParseValues(raw []byte) ([]models.MetricValue, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var values []models.MetricValue
+		err := json.Unmarshal(raw, &values)
+		return values, err
+	}
+	var object map[string]float64
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+	values := make([]models.MetricValue, 0, len(object))
+	for metric, value := range object {
+		values = append(values, models.MetricValue{Metric: metric, Value: value})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Metric < values[j].Metric })
+	return values, nil
+}
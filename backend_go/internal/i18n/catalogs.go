@@ -0,0 +1,63 @@
+package i18n
+
+var zhCN = map[string]string{
+	"insight.title":         "AI 战略顾问",
+	"insight.suggestions":   " 建议：",
+	"insight.separator":     "；",
+	"prompt.system":         "你是企业战略分析师。基于提供的数据做真实、克制的分析，不编造背景或外部事实。必须输出严格JSON：{\"analysis\":\"...\",\"suggestions\":[\"...\",\"...\"]}。analysis 为连续中文正文，不要标题、分段、列表、符号或Markdown。suggestions 为 2-4 条行动建议短句。总长度不超过300字。",
+	"prompt.trend":          "趋势起止：%s -> %s，营收 %s，增长 %s，情绪 %s，积压 %s",
+	"prompt.trend.missing":  "趋势数据不足",
+	"prompt.user":           "公司实时指标：营收 %sB，增长 %s%%，情绪 %s%%，积压 %dK。更新时间：%s。关注点：%s。%s。请给出真实分析与行动建议。",
+	"prompt.max_characters": "300",
+	"summary.title":         "每日指标摘要（%s）",
+	"calendar.name":         "MyDashboard 报告与告警",
+	"calendar.job":          "计划任务：%s",
+	"summary.message":       "与 24 小时前相比：营收 %s，增长 %s，情绪 %s，积压 %s。",
+	"alert.escalated":       "%s（第 %d 级升级）",
+	"alert.ack":             "确认告警：%s",
+	"digest.title":          "每日洞察摘要（%s）",
+	"digest.alerts":         "共触发 %d 条告警，其中 %d 条未确认：%s。",
+	"digest.alerts.none":    "没有触发告警。",
+	"digest.anomalies":      "最显著的异常波动：%s。",
+	"digest.joiner":         "",
+
+	"metric.revenue":               "全球营收",
+	"metric.revenue.description":   "全球业务的累计营收。",
+	"metric.growth":                "用户增长",
+	"metric.growth.description":    "活跃用户数的环比增长率。",
+	"metric.sentiment":             "市场情绪",
+	"metric.sentiment.description": "媒体与用户反馈的综合情绪指数，0–100。",
+	"metric.backlog":               "未交付订单",
+	"metric.backlog.description":   "已确认但尚未交付的订单数量。",
+}
+
+var enUS = map[string]string{
+	"insight.title":         "AI Strategy Advisor",
+	"insight.suggestions":   " Suggestions: ",
+	"insight.separator":     "; ",
+	"prompt.system":         "You are a corporate strategy analyst. Give a grounded, restrained analysis based only on the data provided; do not invent background or outside facts. Output strict JSON: {\"analysis\":\"...\",\"suggestions\":[\"...\",\"...\"]}. analysis is continuous English prose with no headings, paragraphs, lists, symbols or Markdown. suggestions holds 2-4 short action items. Keep the total under 120 words.",
+	"prompt.trend":          "Trend from %s to %s: revenue %s, growth %s, sentiment %s, backlog %s",
+	"prompt.trend.missing":  "Not enough trend data",
+	"prompt.user":           "Live company metrics: revenue %sB, growth %s%%, sentiment %s%%, backlog %dK. Updated at %s. Focus: %s. %s. Provide a grounded analysis with action items.",
+	"prompt.max_characters": "800",
+	"summary.title":         "Daily metrics summary (%s)",
+	"calendar.name":         "MyDashboard reports and alerts",
+	"calendar.job":          "Scheduled: %s",
+	"summary.message":       "Versus 24 hours ago: revenue %s, growth %s, sentiment %s, backlog %s.",
+	"alert.escalated":       "%s (escalation step %d)",
+	"alert.ack":             "Acknowledge: %s",
+	"digest.title":          "Daily insight digest (%s)",
+	"digest.alerts":         "%d alerts fired, %d still unacknowledged: %s.",
+	"digest.alerts.none":    "No alerts fired.",
+	"digest.anomalies":      "Most unusual moves: %s.",
+	"digest.joiner":         " ",
+
+	"metric.revenue":               "Global revenue",
+	"metric.revenue.description":   "Cumulative revenue across all regions.",
+	"metric.growth":                "User growth",
+	"metric.growth.description":    "Period-over-period growth of active users.",
+	"metric.sentiment":             "Market sentiment",
+	"metric.sentiment.description": "Combined sentiment of press and customer feedback, 0-100.",
+	"metric.backlog":               "Open orders",
+	"metric.backlog.description":   "Orders confirmed but not yet delivered.",
+}
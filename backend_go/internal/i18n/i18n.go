@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ZhCN = "zh-CN"
+	EnUS = "en-US"
+
+	Default = ZhCN
+)
+
+var catalogs = map[string]map[string]string{
+	ZhCN: zhCN,
+	EnUS: enUS,
+}
+
+// Supported reports whether locale has a message catalog.
+func Supported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Locales lists the supported locales in order.
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Lookup returns the message for key in locale's own catalog, without the
+// fallbacks T applies.
+func Lookup(locale, key string) (string, bool) {
+	message, ok := catalogs[locale][key]
+	return message, ok
+}
+
+// T looks up key in the locale's catalog, falling back to the default locale
+// and finally to the key itself. Args are applied with fmt.Sprintf.
+func T(locale, key string, args ...any) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		message, ok = catalogs[Default][key]
+	}
+	if !ok {
+		message = key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Match maps a language tag such as "en", "en-GB" or "zh-Hans-CN" onto a
+// supported locale.
+func Match(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", false
+	}
+	for locale := range catalogs {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+	}
+	primary := strings.ToLower(strings.SplitN(strings.ReplaceAll(tag, "_", "-"), "-", 2)[0])
+	switch primary {
+	case "zh":
+		return ZhCN, true
+	case "en":
+		return EnUS, true
+	}
+	return "", false
+}
+
+// Negotiate picks a locale from an explicit lang value first, then from an
+// Accept-Language header ordered by q-value.
+func Negotiate(lang, acceptLanguage string) string {
+	if locale, ok := Match(lang); ok {
+		return locale
+	}
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		c := candidate{tag: strings.TrimSpace(fields[0]), q: 1}
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					c.q = q
+				}
+			}
+		}
+		if c.tag != "" && c.q > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	for _, c := range candidates {
+		if locale, ok := Match(c.tag); ok {
+			return locale
+		}
+	}
+	return Default
+}
@@ -0,0 +1,225 @@
+// Package loadgen drives a running instance with a mix of read and write
+// requests and reports latency percentiles per request, for sizing the
+// database pool and caches ahead of a launch.
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultReads are the dashboard's hot read paths.
+var DefaultReads = []string{
+	"/api/metrics/latest",
+	"/api/metrics/trend?window=60",
+	"/api/insights/latest",
+}
+
+const writePath = "/api/metrics"
+
+type Config struct {
+	Target      string
+	Token       string
+	Duration    time.Duration
+	Concurrency int
+	// Rate caps requests per second across all workers; 0 runs unthrottled.
+	Rate int
+	// WriteRatio is the share of requests that ingest a snapshot.
+	WriteRatio float64
+	Reads      []string
+	Timeout    time.Duration
+}
+
+// Result summarises the requests to one path. Latencies are in
+// milliseconds.
+type Result struct {
+	Path     string         `json:"path"`
+	Requests int            `json:"requests"`
+	Errors   int            `json:"errors"`
+	Statuses map[string]int `json:"statuses"`
+	P50      float64        `json:"p50_ms"`
+	P90      float64        `json:"p90_ms"`
+	P99      float64        `json:"p99_ms"`
+	Max      float64        `json:"max_ms"`
+}
+
+type Report struct {
+	Target     string        `json:"target"`
+	Duration   time.Duration `json:"duration"`
+	Requests   int           `json:"requests"`
+	Errors     int           `json:"errors"`
+	Throughput float64       `json:"requests_per_second"`
+	Results    []Result      `json:"results"`
+}
+
+type sample struct {
+	path    string
+	latency time.Duration
+	status  string
+	failed  bool
+}
+
+// Run sends requests until cfg.Duration has passed or ctx is done.
+// Responses other than 2xx count as errors, as do transport failures,
+// which are reported under the status "error".
+func Run(ctx context.Context, cfg Config) (Report, error) {
+	if cfg.Target == "" || cfg.Duration <= 0 || cfg.Concurrency < 1 {
+		return Report{}, errors.New("loadgen: target, a positive duration and concurrency are required")
+	}
+	if cfg.WriteRatio < 0 || cfg.WriteRatio > 1 {
+		return Report{}, errors.New("loadgen: write ratio must be between 0 and 1")
+	}
+	if len(cfg.Reads) == 0 && cfg.WriteRatio < 1 {
+		return Report{}, errors.New("loadgen: no read paths")
+	}
+	target := strings.TrimRight(cfg.Target, "/")
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        cfg.Concurrency,
+			MaxIdleConnsPerHost: cfg.Concurrency,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+	var tokens <-chan time.Time
+	if cfg.Rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(cfg.Rate))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	samples := make(chan sample, cfg.Concurrency*4)
+	var wg sync.WaitGroup
+	started := time.Now()
+	for worker := 0; worker < cfg.Concurrency; worker++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for {
+				if tokens != nil {
+					select {
+					case <-tokens:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				var result sample
+				if rng.Float64() < cfg.WriteRatio {
+					result = send(ctx, client, cfg.Token, http.MethodPost, target, writePath, snapshot(rng))
+				} else {
+					result = send(ctx, client, cfg.Token, http.MethodGet, target, cfg.Reads[rng.Intn(len(cfg.Reads))], nil)
+				}
+				// A request cut short by the end of the run says nothing
+				// about the server.
+				if ctx.Err() != nil {
+					return
+				}
+				samples <- result
+			}
+		}(started.UnixNano() + int64(worker))
+	}
+	go func() {
+		wg.Wait()
+		close(samples)
+	}()
+
+	latencies := map[string][]float64{}
+	results := map[string]*Result{}
+	report := Report{Target: target}
+	for sample := range samples {
+		result := results[sample.path]
+		if result == nil {
+			result = &Result{Path: sample.path, Statuses: map[string]int{}}
+			results[sample.path] = result
+		}
+		result.Requests++
+		result.Statuses[sample.status]++
+		if sample.failed {
+			result.Errors++
+			report.Errors++
+		}
+		latencies[sample.path] = append(latencies[sample.path], float64(sample.latency)/float64(time.Millisecond))
+		report.Requests++
+	}
+	report.Duration = time.Since(started)
+	report.Throughput = float64(report.Requests) / report.Duration.Seconds()
+	for path, result := range results {
+		sorted := latencies[path]
+		sort.Float64s(sorted)
+		result.P50 = percentile(sorted, 50)
+		result.P90 = percentile(sorted, 90)
+		result.P99 = percentile(sorted, 99)
+		result.Max = sorted[len(sorted)-1]
+		report.Results = append(report.Results, *result)
+	}
+	sort.Slice(report.Results, func(i, j int) bool { return report.Results[i].Path < report.Results[j].Path })
+	return report, nil
+}
+
+func send(ctx context.Context, client *http.Client, token, method, target, path string, body []byte) sample {
+	result := sample{path: method + " " + path}
+	req, err := http.NewRequestWithContext(ctx, method, target+path, bytes.NewReader(body))
+	if err != nil {
+		result.status, result.failed = "error", true
+		return result
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.latency = time.Since(start)
+		result.status, result.failed = "error", true
+		return result
+	}
+	// Reading the body is part of the latency a dashboard sees.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	result.latency = time.Since(start)
+	result.status = fmt.Sprint(resp.StatusCode)
+	result.failed = resp.StatusCode < 200 || resp.StatusCode > 299
+	return result
+}
+
+// snapshot returns a plausible snapshot so writes pass metric validation.
+func snapshot(rng *rand.Rand) []byte {
+	body, _ := json.Marshal(map[string]any{
+		"revenue":   4 + rng.Float64()*2,
+		"growth":    10 + rng.Float64()*15,
+		"sentiment": 60 + rng.Float64()*30,
+		"backlog":   100 + rng.Intn(80),
+	})
+	return body
+}
+
+// percentile expects sorted input and interpolates between closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,55 @@
+package models
+
+// AdHocQuery is a structured, read-only query over one allowlisted table.
+// Filters are combined with AND. Filter values on *_at columns are RFC 3339
+// times; the "in" operator takes an array.
+type AdHocQuery struct {
+	Table      string           `json:"table"`
+	Select     []string         `json:"select"`
+	Aggregates []QueryAggregate `json:"aggregates"`
+	Where      []QueryFilter    `json:"where"`
+	GroupBy    []string         `json:"group_by"`
+	OrderBy    []QueryOrder     `json:"order_by"`
+	Limit      int              `json:"limit"`
+}
+
+// QueryAggregate is count, sum, avg, min or max over Column. Count accepts
+// "*" as the column. As defaults to func_column.
+type QueryAggregate struct {
+	Func   string `json:"func"`
+	Column string `json:"column"`
+	As     string `json:"as"`
+}
+
+type QueryFilter struct {
+	Column string `json:"column"`
+	Op     string `json:"op"`
+	Value  any    `json:"value"`
+}
+
+// QueryOrder sorts by a selected column or an aggregate alias.
+type QueryOrder struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc"`
+}
+
+// AdHocResult holds the rows of an ad-hoc query. Truncated is set when more
+// rows matched than the limit.
+type AdHocResult struct {
+	Columns   []string `json:"columns"`
+	Rows      [][]any  `json:"rows"`
+	Truncated bool     `json:"truncated"`
+	ElapsedMs float64  `json:"elapsed_ms"`
+}
+
+// Referenced lists the table columns the query uses, before validation.
+func (q AdHocQuery) Referenced() []string {
+	columns := append([]string(nil), q.Select...)
+	for _, agg := range q.Aggregates {
+		columns = append(columns, agg.Column)
+	}
+	for _, filter := range q.Where {
+		columns = append(columns, filter.Column)
+	}
+	return append(columns, q.GroupBy...)
+}
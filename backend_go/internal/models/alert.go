@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Alert tracks a rule firing through the rule's escalation steps until it is
+// acknowledged. Step counts the steps notified so far; NextStepAt is unset
+// once every step has been notified or the alert is acknowledged.
+type Alert struct {
+	ID             int64      `json:"id"`
+	RuleID         int64      `json:"rule_id"`
+	InsightID      int64      `json:"insight_id"`
+	Title          string     `json:"title"`
+	Message        string     `json:"message"`
+	Severity       string     `json:"severity"`
+	Locale         string     `json:"locale"`
+	Step           int        `json:"step"`
+	NextStepAt     *time.Time `json:"next_step_at,omitempty"`
+	AckToken       string     `json:"-"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// AlertEscalation is the payload of EventAlertEscalated. It is delivered to
+// ChannelID only, whatever the channel's event types and minimum severity.
+type AlertEscalation struct {
+	AlertID   int64  `json:"alert_id"`
+	RuleID    int64  `json:"rule_id"`
+	InsightID int64  `json:"insight_id"`
+	Step      int    `json:"step"`
+	ChannelID int64  `json:"channel_id"`
+	Title     string `json:"title"`
+	Message   string `json:"message"`
+	Severity  string `json:"severity"`
+	Source    string `json:"source"`
+	AckURL    string `json:"ack_url,omitempty"`
+}
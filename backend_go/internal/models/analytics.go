@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+type HistogramBucket struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int     `json:"count"`
+}
+
+type Distribution struct {
+	Metric      string             `json:"metric"`
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"`
+	Samples     int                `json:"samples"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Mean        float64            `json:"mean"`
+	Percentiles map[string]float64 `json:"percentiles"`
+	Current     float64            `json:"current"`
+	CurrentRank float64            `json:"current_percentile"`
+	Buckets     []HistogramBucket  `json:"buckets"`
+}
+
+type LagCorrelation struct {
+	Lag         int     `json:"lag"`
+	LagSeconds  float64 `json:"lag_seconds"`
+	Correlation float64 `json:"correlation"`
+	Samples     int     `json:"samples"`
+}
+
+type Correlation struct {
+	X       string           `json:"x"`
+	Y       string           `json:"y"`
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Samples int              `json:"samples"`
+	Pearson float64          `json:"pearson"`
+	BestLag *LagCorrelation  `json:"best_lag,omitempty"`
+	Lags    []LagCorrelation `json:"lags"`
+}
+
+// MetricBucket is the sum and count of a metric over one interval.
+type MetricBucket struct {
+	Start time.Time
+	Sum   float64
+	Count int
+}
+
+type HeatmapCell struct {
+	Weekday int     `json:"weekday"`
+	Hour    int     `json:"hour"`
+	Average float64 `json:"average"`
+	Samples int     `json:"samples"`
+}
+
+// MetricDelta is the change of one metric between two snapshots. Percent is
+// omitted when the earlier value is zero.
+type MetricDelta struct {
+	From    float64  `json:"from"`
+	To      float64  `json:"to"`
+	Change  float64  `json:"change"`
+	Percent *float64 `json:"percent,omitempty"`
+}
+
+// MetricsDiff compares the snapshots in effect at two requested times.
+type MetricsDiff struct {
+	At1    time.Time              `json:"at1"`
+	At2    time.Time              `json:"at2"`
+	From   Metrics                `json:"from"`
+	To     Metrics                `json:"to"`
+	Deltas map[string]MetricDelta `json:"deltas"`
+}
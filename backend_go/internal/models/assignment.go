@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	AssignmentOpen       = "open"
+	AssignmentInProgress = "in-progress"
+	AssignmentDone       = "done"
+)
+
+// InsightAssignment turns an insight into a task for one user. DoneAt is set
+// when the status becomes done and cleared if it is reopened.
+type InsightAssignment struct {
+	InsightID  int64      `json:"insight_id"`
+	UserID     int64      `json:"user_id"`
+	Username   string     `json:"username"`
+	Status     string     `json:"status"`
+	DueAt      *time.Time `json:"due_at,omitempty"`
+	AssignedBy string     `json:"assigned_by"`
+	TeamID     *int64     `json:"team_id,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DoneAt     *time.Time `json:"done_at,omitempty"`
+	Overdue    bool       `json:"overdue"`
+	Insight    *Insight   `json:"insight,omitempty"`
+}
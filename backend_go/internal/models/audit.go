@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	AuditLoginSucceeded   = "login.succeeded"
+	AuditLoginFailed      = "login.failed"
+	AuditLoginThrottled   = "login.throttled"
+	AuditAccountLocked    = "account.locked"
+	AuditAccountUnlocked  = "account.unlocked"
+	AuditPasswordRejected = "password.rejected"
+	AuditProfileUpdated   = "profile.updated"
+)
+
+// AuditEvent records a security-relevant action. Actor is who acted, or the
+// username tried for a login that matched no account; UserID is the account
+// the action concerned, when there is one.
+type AuditEvent struct {
+	ID        int64     `json:"id"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	UserID    *int64    `json:"user_id,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditQuery filters the audit log; zero fields do not filter.
+type AuditQuery struct {
+	Action string
+	UserID int64
+	Since  time.Time
+	Limit  int
+}
@@ -0,0 +1,103 @@
+package models
+
+import "time"
+
+const (
+	RoleAdmin   = "admin"
+	RoleAnalyst = "analyst"
+	RoleViewer  = "viewer"
+)
+
+type User struct {
+	ID           int64      `json:"id"`
+	Username     string     `json:"username"`
+	PasswordHash string     `json:"-"`
+	Role         string     `json:"role"`
+	DisplayName  string     `json:"display_name"`
+	Locale       string     `json:"locale"`
+	Timezone     string     `json:"timezone"`
+	AvatarURL    string     `json:"avatar_url"`
+	Disabled     bool       `json:"disabled"`
+	TOTPEnabled  bool       `json:"totp_enabled"`
+	TOTPSecret   string     `json:"-"`
+	TOTPLastStep int64      `json:"-"`
+	FailedLogins int        `json:"-"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type Session struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"user_id"`
+	RefreshHash string     `json:"-"`
+	UserAgent   string     `json:"user_agent"`
+	IP          string     `json:"ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  time.Time  `json:"last_used_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	Current     bool       `json:"current"`
+}
+
+type TokenPair struct {
+	AccessToken        string    `json:"access_token"`
+	RefreshToken       string    `json:"refresh_token"`
+	TokenType          string    `json:"token_type"`
+	ExpiresIn          int       `json:"expires_in"`
+	Session            Session   `json:"session"`
+	User               User      `json:"user"`
+	EnrollmentRequired bool      `json:"enrollment_required,omitempty"`
+	IssuedAt           time.Time `json:"issued_at"`
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"otpauth_url"`
+}
+
+// Principal is the authenticated caller attached to a request.
+// EnrollmentRequired is set when the role policy demands two-factor
+// authentication the user has not enabled yet.
+type Principal struct {
+	UserID             int64  `json:"user_id"`
+	Username           string `json:"username"`
+	Role               string `json:"role"`
+	SessionID          int64  `json:"session_id"`
+	TOTPEnabled        bool   `json:"totp_enabled"`
+	EnrollmentRequired bool   `json:"enrollment_required,omitempty"`
+	// Locale and Timezone come from the user's profile and are empty when
+	// unset.
+	Locale   string `json:"locale,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// EmbedToken grants read-only access to selected metrics. Token holds the
+// plaintext value and is only set in the response that creates it.
+type EmbedToken struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Token     string     `json:"token,omitempty"`
+	TokenHash string     `json:"-"`
+	Metrics   []string   `json:"metrics"`
+	CreatedBy *int64     `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ErasureReport records what an erasure request removed for one user.
+type ErasureReport struct {
+	UserID              int64  `json:"user_id"`
+	Username            string `json:"username"`
+	SessionsDeleted     int64  `json:"sessions_deleted"`
+	EmbedTokensUnlinked int64  `json:"embed_tokens_unlinked"`
+	InsightsDeleted     int64  `json:"insights_deleted"`
+	AssignmentsDeleted  int64  `json:"assignments_deleted"`
+	AuditEntriesDeleted int64  `json:"audit_entries_deleted"`
+	InvitationsDeleted  int64  `json:"invitations_deleted"`
+	SilencesUnlinked    int64  `json:"silences_unlinked"`
+	// RecordsUnlinked counts alert acknowledgements, assignments and
+	// invitations that named the user and now name nobody.
+	RecordsUnlinked int64     `json:"records_unlinked"`
+	ErasedAt        time.Time `json:"erased_at"`
+}
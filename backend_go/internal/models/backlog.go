@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type BacklogItem struct {
+	ID         int64      `json:"id"`
+	Title      string     `json:"title"`
+	Priority   string     `json:"priority"`
+	OpenedAt   time.Time  `json:"opened_at"`
+	DueAt      time.Time  `json:"due_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+type AgingBucket struct {
+	Label    string  `json:"label"`
+	MinHours float64 `json:"min_hours"`
+	MaxHours float64 `json:"max_hours,omitempty"`
+	Count    int     `json:"count"`
+}
+
+type BacklogAging struct {
+	Open           int           `json:"open"`
+	SLABreached    int           `json:"sla_breached"`
+	SLAAtRisk      int           `json:"sla_at_risk"`
+	OldestAgeHours float64       `json:"oldest_age_hours"`
+	Buckets        []AgingBucket `json:"buckets"`
+	GeneratedAt    time.Time     `json:"generated_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	CollectorIdle    = "idle"
+	CollectorHealthy = "healthy"
+	CollectorFailing = "failing"
+	CollectorStopped = "stopped"
+)
+
+// MetricValue is one reading produced by a collector. A zero At means the
+// time it is recorded.
+type MetricValue struct {
+	Metric string    `json:"metric"`
+	Value  float64   `json:"value"`
+	At     time.Time `json:"at,omitempty"`
+}
+
+// CollectorStatus reports the health of one registered collector.
+type CollectorStatus struct {
+	Name                string     `json:"name"`
+	Interval            string     `json:"interval"`
+	State               string     `json:"state"`
+	Runs                int        `json:"runs"`
+	Failures            int        `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastRun             *time.Time `json:"last_run,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastDurationMs      float64    `json:"last_duration_ms"`
+	LastValues          int        `json:"last_values"`
+	LastError           string     `json:"last_error,omitempty"`
+}
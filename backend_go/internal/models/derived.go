@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	DerivedAtQuery  = "query"
+	DerivedAtIngest = "ingest"
+)
+
+// DerivedMetric defines a metric computed from the stored ones, such as
+// revenue_per_backlog = revenue / backlog. Query-time metrics are evaluated
+// whenever snapshots are read, so a changed expression applies to all of
+// history. Ingest-time metrics are evaluated once when a snapshot is written
+// and stored with it; snapshots written before the definition have no value.
+type DerivedMetric struct {
+	Name        string    `json:"name"`
+	Expression  string    `json:"expression"`
+	Mode        string    `json:"mode"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
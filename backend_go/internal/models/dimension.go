@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// DimensionValue is the part of a metric one member of a dimension, such as
+// the "emea" member of "region", contributed at a point in time.
+type DimensionValue struct {
+	Metric     string    `json:"metric"`
+	Dimension  string    `json:"dimension"`
+	Member     string    `json:"member"`
+	Value      float64   `json:"value"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Contributor is a member's total over a range and its share of the total
+// of all members.
+type Contributor struct {
+	Rank   int     `json:"rank"`
+	Member string  `json:"member"`
+	Value  float64 `json:"value"`
+	Share  float64 `json:"share"`
+}
+
+// Leaderboard ranks the members of a dimension by their contribution to a
+// metric. Others sums the members below the cut.
+type Leaderboard struct {
+	Metric       string        `json:"metric"`
+	Dimension    string        `json:"dimension"`
+	From         time.Time     `json:"from"`
+	To           time.Time     `json:"to"`
+	Total        float64       `json:"total"`
+	Members      int           `json:"members"`
+	Contributors []Contributor `json:"contributors"`
+	Others       float64       `json:"others"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Funnel names the ordered stages subjects move through, such as visit,
+// signup and paid.
+type Funnel struct {
+	Name      string    `json:"name"`
+	Stages    []string  `json:"stages"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FunnelEvent records a subject reaching a stage. Subjects are opaque ids
+// chosen by the sender; the same subject may report a stage repeatedly,
+// which is what cohort retention counts.
+type FunnelEvent struct {
+	Stage      string    `json:"stage"`
+	Subject    string    `json:"subject"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// FunnelStage counts the subjects that reached a stage after all earlier
+// ones. Conversion is relative to the previous stage and Overall to the
+// first.
+type FunnelStage struct {
+	Stage      string  `json:"stage"`
+	Subjects   int     `json:"subjects"`
+	Conversion float64 `json:"conversion"`
+	Overall    float64 `json:"overall"`
+}
+
+type FunnelReport struct {
+	Funnel    string        `json:"funnel"`
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Stages    []FunnelStage `json:"stages"`
+	Truncated bool          `json:"truncated,omitempty"`
+}
+
+// Cohort is one row of a retention matrix: the subjects whose first cohort
+// stage event fell in the period starting at Start, and how many of them
+// were active in each period after it, Retained[0] being the first one.
+type Cohort struct {
+	Start    time.Time `json:"start"`
+	Size     int       `json:"size"`
+	Retained []int     `json:"retained"`
+	Rates    []float64 `json:"rates"`
+}
+
+type CohortMatrix struct {
+	Funnel      string   `json:"funnel"`
+	Period      string   `json:"period"`
+	CohortStage string   `json:"cohort_stage"`
+	ActiveStage string   `json:"active_stage,omitempty"`
+	Cohorts     []Cohort `json:"cohorts"`
+	Truncated   bool     `json:"truncated,omitempty"`
+}
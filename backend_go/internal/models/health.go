@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+// HealthBucket aggregates the checks run during one interval.
+type HealthBucket struct {
+	Start    time.Time `json:"start"`
+	Checks   int       `json:"checks"`
+	Failures int       `json:"failures"`
+}
+
+// DependencyHealth is the status history of one dependency. Uptime values are
+// percentages keyed by window ("1h", "24h", "7d"); windows without any check
+// are omitted.
+type DependencyHealth struct {
+	Name        string             `json:"name"`
+	Status      string             `json:"status"`
+	LastChecked time.Time          `json:"last_checked"`
+	LatencyMs   float64            `json:"latency_ms"`
+	Uptime      map[string]float64 `json:"uptime"`
+	History     []HealthBucket     `json:"history"`
+}
@@ -3,9 +3,37 @@ package models
 import "time"
 
 type Insight struct {
-	ID        int64     `json:"id"`
-	Title     string    `json:"title"`
-	Message   string    `json:"message"`
-	Source    string    `json:"source"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64               `json:"id"`
+	Title     string              `json:"title"`
+	Message   string              `json:"message"`
+	Source    string              `json:"source"`
+	Author    string              `json:"author,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	Metrics   []InsightMetricLink `json:"metrics,omitempty"`
+	Tags      []string            `json:"tags,omitempty"`
+
+	RepeatCount int        `json:"repeat_count"`
+	Locale      string     `json:"locale"`
+	Severity    string     `json:"severity"`
+	Fingerprint string     `json:"-"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Version     int        `json:"version"`
+}
+
+type InsightMetricLink struct {
+	MetricKey   string    `json:"metric_key"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+}
+
+// InsightTag is a tag with the number of insights, outside the trash,
+// carrying it.
+type InsightTag struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type InsightContext struct {
+	Insight   Insight   `json:"insight"`
+	Snapshots []Metrics `json:"snapshots"`
 }
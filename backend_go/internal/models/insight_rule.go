@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+type InsightRule struct {
+	ID         int64            `json:"id"`
+	Name       string           `json:"name"`
+	Condition  string           `json:"condition"`
+	Title      string           `json:"title"`
+	Template   string           `json:"template"`
+	Severity   string           `json:"severity"`
+	Locale     string           `json:"locale"`
+	Enabled    bool             `json:"enabled"`
+	Escalation []EscalationStep `json:"escalation,omitempty"`
+	TeamID     *int64           `json:"team_id,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// EscalationStep notifies a channel when an alert raised by the rule is still
+// unacknowledged AfterMinutes after it was raised.
+type EscalationStep struct {
+	ChannelID    int64 `json:"channel_id"`
+	AfterMinutes int   `json:"after_minutes"`
+}
+
+// RulePreview reports how a rule would have behaved over a past range.
+// Matched counts the snapshots that satisfied the condition; consecutive
+// matches form one firing.
+type RulePreview struct {
+	From      time.Time    `json:"from"`
+	To        time.Time    `json:"to"`
+	Evaluated int          `json:"evaluated"`
+	Matched   int          `json:"matched"`
+	Firings   []RuleFiring `json:"firings"`
+	Truncated bool         `json:"truncated,omitempty"`
+}
+
+type RuleFiring struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Snapshots int       `json:"snapshots"`
+	Message   string    `json:"message,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationExpired  = "expired"
+	InvitationRevoked  = "revoked"
+)
+
+// Invitation lets someone create their own account with the role, and
+// optionally the team, an admin chose. Token and URL are only set in the
+// response that creates it; Emailed reports whether the invitation mail
+// went out.
+type Invitation struct {
+	ID         int64      `json:"id"`
+	Email      string     `json:"email"`
+	TokenHash  string     `json:"-"`
+	Role       string     `json:"role"`
+	TeamID     *int64     `json:"team_id,omitempty"`
+	TeamRole   string     `json:"team_role,omitempty"`
+	InvitedBy  string     `json:"invited_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	UserID     *int64     `json:"user_id,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Status     string     `json:"status"`
+	Token      string     `json:"token,omitempty"`
+	URL        string     `json:"url,omitempty"`
+	Emailed    *bool      `json:"emailed,omitempty"`
+}
+
+// InvitationNotice is the payload of the invitation mail.
+type InvitationNotice struct {
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	InviteURL string    `json:"invite_url"`
+	InvitedBy string    `json:"invited_by"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// MetricMeta is how a metric is presented: its label and description per
+// locale, the unit shown next to values, a preferred chart color and value
+// thresholds. Label and Description are resolved for the request locale;
+// Labels and Descriptions hold every configured translation.
+type MetricMeta struct {
+	Key          string            `json:"key"`
+	Label        string            `json:"label"`
+	Description  string            `json:"description,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+	Unit         string            `json:"unit,omitempty"`
+	Color        string            `json:"color,omitempty"`
+	Thresholds   []MetricThreshold `json:"thresholds,omitempty"`
+	UpdatedAt    *time.Time        `json:"updated_at,omitempty"`
+}
+
+// MetricThreshold colors values at or above Value, for example a warning
+// band on a gauge. Thresholds are listed in ascending order.
+type MetricThreshold struct {
+	Value float64 `json:"value"`
+	Color string  `json:"color"`
+	Label string  `json:"label,omitempty"`
+}
+
+// MetricMetaOverride is the metadata an admin saved for one metric and
+// tenant. Empty fields fall back to the default tenant, then to the
+// built-in metadata.
+type MetricMetaOverride struct {
+	Tenant       string            `json:"tenant"`
+	Key          string            `json:"key"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+	Unit         string            `json:"unit,omitempty"`
+	Color        string            `json:"color,omitempty"`
+	Thresholds   []MetricThreshold `json:"thresholds,omitempty"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
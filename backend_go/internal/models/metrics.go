@@ -8,4 +8,60 @@ type Metrics struct {
 	Sentiment float64   `json:"sentiment"`
 	Backlog   int       `json:"backlog"`
 	CreatedAt time.Time `json:"created_at"`
+	// Derived holds the values of derived metrics, see DerivedMetric. A value
+	// that cannot be computed, e.g. after a division by zero, is left out.
+	Derived map[string]float64 `json:"derived,omitempty"`
+	// ValidUntil is set when later readings equal to this one were not
+	// stored; the values held until then.
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
+
+// MetricSnapshot is a stored snapshot together with its row id.
+type MetricSnapshot struct {
+	ID int64 `json:"id"`
+	Metrics
+}
+
+var MetricKeys = []string{"revenue", "growth", "sentiment", "backlog"}
+
+func (m Metrics) Value(key string) (float64, bool) {
+	switch key {
+	case "revenue":
+		return m.Revenue, true
+	case "growth":
+		return m.Growth, true
+	case "sentiment":
+		return m.Sentiment, true
+	case "backlog":
+		return float64(m.Backlog), true
+	}
+	value, ok := m.Derived[key]
+	return value, ok
+}
+
+type MetricPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// MetricViolation describes a value outside its configured bounds. Index is
+// the position of the snapshot in the submitted batch.
+type MetricViolation struct {
+	Index  int     `json:"index"`
+	Metric string  `json:"metric"`
+	Value  float64 `json:"value"`
+	Rule   string  `json:"rule"`
+	Limit  float64 `json:"limit"`
+}
+
+// MetricDefinition describes how a metric's values are expressed. Scale is
+// the number of base units per reported unit, e.g. 1e9 for billions.
+type MetricDefinition struct {
+	Key      string  `json:"key"`
+	Unit     string  `json:"unit"`
+	Currency string  `json:"currency,omitempty"`
+	Scale    float64 `json:"scale,omitempty"`
+	// Precision is the number of decimals values are rounded to in
+	// responses; nil leaves them as stored.
+	Precision *int `json:"precision,omitempty"`
 }
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+const (
+	ChannelWebhook   = "webhook"
+	ChannelDingTalk  = "dingtalk"
+	ChannelFeishu    = "feishu"
+	ChannelTeams     = "teams"
+	ChannelPagerDuty = "pagerduty"
+	ChannelOpsgenie  = "opsgenie"
+)
+
+// NotificationChannel is a destination for outbox events. Only events whose
+// type is listed and whose severity reaches MinSeverity are delivered.
+type NotificationChannel struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	WebhookURL  string    `json:"webhook_url"`
+	Secret      string    `json:"-"`
+	HasSecret   bool      `json:"has_secret"`
+	EventTypes  []string  `json:"event_types"`
+	MinSeverity string    `json:"min_severity"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DailySummary is the payload of EventDailySummary. Title and Message carry a
+// readable version for notifiers that only display text.
+type DailySummary struct {
+	Title    string                 `json:"title"`
+	Message  string                 `json:"message"`
+	From     time.Time              `json:"from"`
+	To       time.Time              `json:"to"`
+	Snapshot Metrics                `json:"snapshot"`
+	Deltas   map[string]MetricDelta `json:"deltas"`
+}
+
+const (
+	DeliveryEmail = "email"
+	DeliverySlack = "slack"
+	DeliveryNone  = "none"
+)
+
+// NotificationRule says how a user receives one category of events, such as
+// "alerts", and from which severity on.
+type NotificationRule struct {
+	Category    string `json:"category"`
+	Delivery    string `json:"delivery"`
+	MinSeverity string `json:"min_severity,omitempty"`
+}
+
+// NotificationPreferences are a user's personal notification settings.
+// Categories without a rule are not delivered.
+type NotificationPreferences struct {
+	UserID      int64              `json:"user_id"`
+	Email       string             `json:"email"`
+	SlackUserID string             `json:"slack_user_id"`
+	Rules       []NotificationRule `json:"rules"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// AssignmentNotice is the payload of EventInsightAssigned.
+type AssignmentNotice struct {
+	InsightID  int64      `json:"insight_id"`
+	UserID     int64      `json:"user_id"`
+	Username   string     `json:"username"`
+	AssignedBy string     `json:"assigned_by"`
+	DueAt      *time.Time `json:"due_at,omitempty"`
+	Title      string     `json:"title"`
+	Message    string     `json:"message"`
+	Severity   string     `json:"severity"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventInsightCreated  = "insight.created"
+	EventDailySummary    = "summary.daily"
+	EventAlertEscalated  = "alert.escalated"
+	EventInsightAssigned = "insight.assigned"
+	EventUserInvited     = "user.invited"
+)
+
+type OutboxEvent struct {
+	ID        int64           `json:"id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// OutboxDelivery is one event on its way to one target: a notifier, or a
+// single channel or user of a notifier that fans out. Each is retried on its
+// own, so a failing target never resends the event to the others.
+type OutboxDelivery struct {
+	ID       int64       `json:"id"`
+	Target   string      `json:"target"`
+	Attempts int         `json:"attempts"`
+	Event    OutboxEvent `json:"event"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// ScenarioChange is a hypothetical change to one stored metric. Percent
+// scales the projected values and Delta is then added to them, so
+// {"metric": "backlog", "delta": -20} projects 20 fewer items throughout.
+type ScenarioChange struct {
+	Metric  string  `json:"metric"`
+	Delta   float64 `json:"delta,omitempty"`
+	Percent float64 `json:"percent,omitempty"`
+}
+
+type Scenario struct {
+	Changes []ScenarioChange
+	History time.Duration
+	Step    time.Duration
+	Horizon int
+}
+
+// ScenarioSeries compares the projection of one metric with and without the
+// scenario's changes. History is the bucketed data the projection starts
+// from.
+type ScenarioSeries struct {
+	Metric   string        `json:"metric"`
+	History  []MetricPoint `json:"history"`
+	Baseline []MetricPoint `json:"baseline"`
+	Scenario []MetricPoint `json:"scenario"`
+}
+
+type ScenarioProjection struct {
+	From    time.Time        `json:"from"`
+	Step    string           `json:"step"`
+	Horizon int              `json:"horizon"`
+	Changes []ScenarioChange `json:"changes"`
+	Series  []ScenarioSeries `json:"series"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	JobStatusOK      = "ok"
+	JobStatusFailed  = "failed"
+	JobStatusSkipped = "skipped"
+)
+
+type ScheduledJob struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Enabled        bool       `json:"enabled"`
+	Running        bool       `json:"running"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	// SkippedTicks counts ticks that found the previous run still going,
+	// since start. It is not persisted.
+	SkippedTicks int64 `json:"skipped_ticks"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+	SilenceKindSilence     = "silence"
+	SilenceKindMaintenance = "maintenance"
+)
+
+// Silence suppresses the notifications of matching outbox events while it is
+// active; the events are still recorded. A silence is active from StartsAt to
+// EndsAt. A maintenance window is active for Duration after every activation
+// of Schedule, a cron expression, from StartsAt until the optional EndsAt.
+//
+// Matchers compare exactly against the event type, under "event_type", and
+// the string fields of the event payload such as severity, source or title.
+// A silence without matchers matches every event.
+type Silence struct {
+	ID        int64             `json:"id"`
+	Kind      string            `json:"kind"`
+	Matchers  map[string]string `json:"matchers"`
+	StartsAt  time.Time         `json:"starts_at"`
+	EndsAt    *time.Time        `json:"ends_at,omitempty"`
+	Schedule  string            `json:"schedule,omitempty"`
+	Duration  string            `json:"duration,omitempty"`
+	Comment   string            `json:"comment"`
+	CreatedBy string            `json:"created_by,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Active    bool              `json:"active"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// SimulatedSeries is a member of a dimension the simulator splits revenue
+// and backlog across. Weight is its starting share relative to the other
+// members of the dimension and Volatility how much that share drifts per
+// tick, as the standard deviation of its relative change.
+type SimulatedSeries struct {
+	Dimension  string  `json:"dimension"`
+	Member     string  `json:"member"`
+	Weight     float64 `json:"weight"`
+	Volatility float64 `json:"volatility"`
+}
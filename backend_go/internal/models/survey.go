@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// SurveyResponse is one answer to "how likely are you to recommend us", on
+// a scale of 0 to 10. Segment is an optional label such as a plan or region.
+type SurveyResponse struct {
+	Score       int       `json:"score"`
+	Segment     string    `json:"segment,omitempty"`
+	RespondedAt time.Time `json:"responded_at"`
+}
+
+// NPSScore counts promoters (9-10), passives (7-8) and detractors (0-6).
+// NPS is the share of promoters minus the share of detractors, from -100 to
+// 100.
+type NPSScore struct {
+	Segment    string  `json:"segment,omitempty"`
+	Responses  int     `json:"responses"`
+	Promoters  int     `json:"promoters"`
+	Passives   int     `json:"passives"`
+	Detractors int     `json:"detractors"`
+	NPS        float64 `json:"nps"`
+}
+
+type NPSReport struct {
+	From      time.Time  `json:"from"`
+	To        time.Time  `json:"to"`
+	Overall   NPSScore   `json:"overall"`
+	Segments  []NPSScore `json:"segments"`
+	Truncated bool       `json:"truncated,omitempty"`
+}
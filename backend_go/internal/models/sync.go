@@ -0,0 +1,20 @@
+package models
+
+// SyncPush carries rows of one edge segment starting at Offset, the position
+// of Metrics[0] in the segment as the edge numbers it.
+type SyncPush struct {
+	Node    string    `json:"node"`
+	Segment string    `json:"segment"`
+	Offset  int       `json:"offset"`
+	Metrics []Metrics `json:"metrics"`
+}
+
+// SyncResult reports how far the central instance has received a segment.
+// Duplicates were already stored with the same values; conflicts were stored
+// with different values and kept as they were.
+type SyncResult struct {
+	Acked      int       `json:"acked"`
+	Inserted   int       `json:"inserted"`
+	Duplicates int       `json:"duplicates"`
+	Conflicts  []Metrics `json:"conflicts,omitempty"`
+}
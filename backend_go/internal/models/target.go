@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	TargetAbove = "above"
+	TargetBelow = "below"
+)
+
+// Target is the value a metric should reach by the end of a quarter such as
+// "2026-Q4". Direction says whether ending above or below it is a hit; a
+// backlog target is usually a ceiling.
+type Target struct {
+	Metric    string    `json:"metric"`
+	Quarter   string    `json:"quarter"`
+	Value     float64   `json:"value"`
+	Direction string    `json:"direction"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TargetPacing compares where a metric is projected to end the quarter with
+// its target. Margin is projected minus target, signed so that a positive
+// margin is always good. RequiredPerDay is the daily change still needed to
+// land exactly on target and ProjectedPerDay the change the forecast has.
+type TargetPacing struct {
+	Target
+	Current         float64   `json:"current"`
+	Projected       float64   `json:"projected"`
+	Margin          float64   `json:"margin"`
+	MarginPercent   float64   `json:"margin_percent"`
+	OnTrack         bool      `json:"on_track"`
+	QuarterEnd      time.Time `json:"quarter_end"`
+	DaysLeft        float64   `json:"days_left"`
+	RequiredPerDay  float64   `json:"required_per_day"`
+	ProjectedPerDay float64   `json:"projected_per_day"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	TeamRoleMember = "member"
+	TeamRoleLead   = "lead"
+)
+
+// Org is the top of the hierarchy, one per business unit.
+type Org struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Team belongs to an org and, when ParentID is set, to a parent team of the
+// same org. Dashboards, insight rules and assignments scoped to a team are
+// also listed for the teams above it.
+type Team struct {
+	ID        int64     `json:"id"`
+	OrgID     int64     `json:"org_id"`
+	ParentID  *int64    `json:"parent_id,omitempty"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TeamMember struct {
+	TeamID      int64     `json:"team_id"`
+	UserID      int64     `json:"user_id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TeamMembership is a team as seen by one of its members.
+type TeamMembership struct {
+	Team
+	Role string `json:"role"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// TenantSettings is a tenant's branding and defaults, read by the frontend
+// when it loads. Palette holds hex colors in the order charts use them.
+type TenantSettings struct {
+	Tenant    string     `json:"tenant"`
+	Name      string     `json:"name"`
+	LogoURL   string     `json:"logo_url,omitempty"`
+	Palette   []string   `json:"palette,omitempty"`
+	Locale    string     `json:"locale"`
+	Timezone  string     `json:"timezone,omitempty"`
+	Currency  string     `json:"currency,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// UsageCounter is one hourly bucket for a tenant and caller. Caller is
+// stored in the api_key column.
+type UsageCounter struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Tenant      string    `json:"tenant"`
+	Caller      string    `json:"caller"`
+	Requests    int64     `json:"requests"`
+	Errors      int64     `json:"errors"`
+	BytesIn     int64     `json:"bytes_in"`
+	BytesOut    int64     `json:"bytes_out"`
+}
+
+type UsageRow struct {
+	Tenant   string `json:"tenant"`
+	Caller   string `json:"caller,omitempty"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`
+	BytesIn  int64  `json:"bytes_in"`
+	BytesOut int64  `json:"bytes_out"`
+}
+
+type UsageReport struct {
+	From    time.Time  `json:"from"`
+	To      time.Time  `json:"to"`
+	GroupBy string     `json:"group_by"`
+	Rows    []UsageRow `json:"rows"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+const (
+	WidgetSeriesKind = "series"
+	WidgetStatKind   = "stat"
+)
+
+// Widget is a named data source for a dashboard panel. Each series is a
+// metric expression (see MetricsService.CompileQuery) that may contain
+// {{param}} placeholders; callers fill them from the query string, limited
+// to the values listed in Allowed.
+type Widget struct {
+	Name        string         `json:"name"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Kind        string         `json:"kind"`
+	Series      []WidgetSeries `json:"series"`
+	Params      []WidgetParam  `json:"params"`
+	Window      int            `json:"window,omitempty"`
+	TeamID      *int64         `json:"team_id,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type WidgetSeries struct {
+	Label string `json:"label"`
+	Expr  string `json:"expr"`
+}
+
+type WidgetParam struct {
+	Name    string   `json:"name"`
+	Default string   `json:"default"`
+	Allowed []string `json:"allowed"`
+}
+
+// WidgetData is a widget evaluated for one set of parameters. Stat widgets
+// carry only the latest value of each series.
+type WidgetData struct {
+	Widget string             `json:"widget"`
+	Title  string             `json:"title"`
+	Kind   string             `json:"kind"`
+	Params map[string]string  `json:"params"`
+	Series []WidgetSeriesData `json:"series"`
+}
+
+type WidgetSeriesData struct {
+	Label   string        `json:"label"`
+	Expr    string        `json:"expr"`
+	Metrics []string      `json:"metrics"`
+	Points  []MetricPoint `json:"points,omitempty"`
+	Value   *float64      `json:"value,omitempty"`
+}
@@ -0,0 +1,452 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const emailTimeout = 30 * time.Second
+
+// EmailConfig configures EmailNotifier. Port 465 uses implicit TLS; other
+// ports upgrade with STARTTLS when the server offers it. Outside production,
+// subjects are prefixed with the environment so test mail stands out.
+// Recipients and Events only matter for Notify: every listed event goes to
+// every recipient.
+type EmailConfig struct {
+	Host         string
+	Port         int
+	Username     string
+	Password     string
+	From         string
+	ReplyTo      string
+	Environment  string
+	DashboardURL string
+	DryRun       bool
+	Recipients   []string
+	Events       []string
+}
+
+// EmailNotifier sends HTML mail with a plain text alternative over SMTP. In
+// dry-run mode it logs the rendered mail instead.
+type EmailNotifier struct {
+	cfg  EmailConfig
+	from *mail.Address
+}
+
+func NewEmailNotifier(cfg EmailConfig) (*EmailNotifier, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("from address: %w", err)
+	}
+	if cfg.ReplyTo != "" {
+		if _, err := mail.ParseAddress(cfg.ReplyTo); err != nil {
+			return nil, fmt.Errorf("reply-to address: %w", err)
+		}
+	}
+	if cfg.Host == "" && !cfg.DryRun {
+		return nil, errors.New("an SMTP host is required unless in dry-run mode")
+	}
+	for _, to := range cfg.Recipients {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("recipient %q: %w", to, err)
+		}
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	cfg.DashboardURL = strings.TrimRight(cfg.DashboardURL, "/")
+	return &EmailNotifier{cfg: cfg, from: from}, nil
+}
+
+func (n *EmailNotifier) Name() string {
+	return "email"
+}
+
+// Notify mails the event to the configured recipients if its type is one of
+// the configured events.
+func (n *EmailNotifier) Notify(ctx context.Context, event Event) error {
+	if !slices.Contains(n.cfg.Events, event.Type) {
+		return nil
+	}
+	var errs []error
+	for _, to := range n.cfg.Recipients {
+		if err := n.Send(ctx, to, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", to, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *EmailNotifier) Send(ctx context.Context, to string, event Event) error {
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return err
+	}
+	subject, text, html, err := renderEmail(event, n.cfg.Environment, n.cfg.DashboardURL)
+	if err != nil {
+		return err
+	}
+	if n.cfg.DryRun {
+		log.Printf("email dry run to %s: %s\n%s\n%s", rcpt.Address, subject, text, html)
+		return nil
+	}
+	msg, err := n.message(rcpt, subject, text, html, event)
+	if err != nil {
+		return err
+	}
+	return n.deliver(ctx, rcpt.Address, msg)
+}
+
+func (n *EmailNotifier) message(to *mail.Address, subject, text, html string, event Event) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ kind, content string }{{"text/plain", text}, {"text/html", html}} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.kind + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	header := func(key, value string) { msg.WriteString(key + ": " + value + "\r\n") }
+	header("From", n.from.String())
+	header("To", to.String())
+	if n.cfg.ReplyTo != "" {
+		header("Reply-To", n.cfg.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(n.from.Address))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	// Lets mail filters drop redeliveries of the same outbox event.
+	header("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	header("X-Event-Type", event.Type)
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if _, host, ok := strings.Cut(from, "@"); ok {
+		domain = host
+	}
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+func (n *EmailNotifier) deliver(ctx context.Context, to string, msg []byte) error {
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: n.cfg.Host}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if n.cfg.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(emailTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok && n.cfg.Port != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if n.cfg.Username != "" {
+		// PlainAuth refuses to send the password over an unencrypted
+		// connection to anything but localhost.
+		if err := client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// emailData is what the templates see. Fields a given event does not carry
+// are left empty.
+type emailData struct {
+	Title        string
+	Message      string
+	Severity     string
+	Environment  string
+	DashboardURL string
+	AckURL       string
+	Step         int
+	AssignedBy   string
+	DueAt        *time.Time
+	From, To     time.Time
+	InviteURL    string
+	InvitedBy    string
+	Role         string
+	ExpiresAt    time.Time
+	Metrics      []emailMetric
+}
+
+type emailMetric struct {
+	Key   string
+	Value float64
+	Delta *models.MetricDelta
+}
+
+// renderEmail picks the template for the event type and returns the subject
+// with the plain text and HTML bodies.
+func renderEmail(event Event, environment, dashboardURL string) (string, string, string, error) {
+	var payload struct {
+		Title      string                        `json:"title"`
+		Message    string                        `json:"message"`
+		Severity   string                        `json:"severity"`
+		AckURL     string                        `json:"ack_url"`
+		Step       int                           `json:"step"`
+		AssignedBy string                        `json:"assigned_by"`
+		DueAt      *time.Time                    `json:"due_at"`
+		From       time.Time                     `json:"from"`
+		To         time.Time                     `json:"to"`
+		Snapshot   *models.Metrics               `json:"snapshot"`
+		Deltas     map[string]models.MetricDelta `json:"deltas"`
+		InviteURL  string                        `json:"invite_url"`
+		InvitedBy  string                        `json:"invited_by"`
+		Role       string                        `json:"role"`
+		ExpiresAt  time.Time                     `json:"expires_at"`
+	}
+	_ = json.Unmarshal(event.Payload, &payload)
+	data := emailData{
+		Title:        payload.Title,
+		Message:      payload.Message,
+		Severity:     payload.Severity,
+		DashboardURL: dashboardURL,
+		AckURL:       payload.AckURL,
+		Step:         payload.Step,
+		AssignedBy:   payload.AssignedBy,
+		DueAt:        payload.DueAt,
+		From:         payload.From,
+		To:           payload.To,
+		InviteURL:    payload.InviteURL,
+		InvitedBy:    payload.InvitedBy,
+		Role:         payload.Role,
+		ExpiresAt:    payload.ExpiresAt,
+	}
+	if environment != "" && environment != "production" {
+		data.Environment = environment
+	}
+	if data.Title == "" {
+		data.Title = event.Type
+	}
+	if payload.Snapshot != nil {
+		var derived []string
+		for key := range payload.Snapshot.Derived {
+			derived = append(derived, key)
+		}
+		sort.Strings(derived)
+		for _, key := range append(slices.Clone(models.MetricKeys), derived...) {
+			value, ok := payload.Snapshot.Value(key)
+			if !ok {
+				continue
+			}
+			metric := emailMetric{Key: key, Value: value}
+			if delta, ok := payload.Deltas[key]; ok {
+				metric.Delta = &delta
+			}
+			data.Metrics = append(data.Metrics, metric)
+		}
+	}
+
+	tmpl, ok := emailTemplates[event.Type]
+	if !ok {
+		tmpl = emailTemplates[""]
+	}
+	var subject, text, html bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return "", "", "", err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return "", "", "", err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return "", "", "", err
+	}
+	prefix := ""
+	if data.Environment != "" {
+		prefix = "[" + data.Environment + "] "
+	}
+	return prefix + strings.TrimSpace(subject.String()), text.String(), html.String(), nil
+}
+
+type emailTemplate struct {
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+var emailFuncs = map[string]any{
+	"date": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 UTC") },
+	"day":  func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+	"num":  func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) },
+	"delta": func(d models.MetricDelta) string {
+		out := fmt.Sprintf("%+.2f", d.Change)
+		if d.Percent != nil {
+			out += fmt.Sprintf(" (%+.1f%%)", *d.Percent)
+		}
+		return out
+	},
+}
+
+const emailLayout = `{{define "layout"}}<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f4f5f7;font-family:Helvetica,Arial,sans-serif;color:#1f2328">
+<table role="presentation" width="100%" style="max-width:600px;margin:0 auto;background:#ffffff;border-radius:6px;border-collapse:collapse">
+{{if .Environment}}<tr><td style="padding:8px 24px;background:#fff4ce;font-size:12px">Sent from the {{.Environment}} environment</td></tr>{{end}}
+<tr><td style="padding:24px">
+{{template "content" .}}
+{{if .DashboardURL}}<p style="margin-top:24px"><a href="{{.DashboardURL}}" style="color:#0969da">Open the dashboard</a></p>{{end}}
+</td></tr>
+</table>
+</body>
+</html>
+{{end}}`
+
+// emailTemplates is keyed by event type; the empty key is the fallback for
+// insights and anything else.
+var emailTemplates = map[string]emailTemplate{
+	"": newEmailTemplate(
+		`{{.Title}}`,
+		`{{.Title}}
+{{if .Severity}}Severity: {{.Severity}}
+{{end}}
+{{.Message}}
+{{if .DashboardURL}}
+{{.DashboardURL}}
+{{end}}`,
+		`<h2 style="margin:0 0 8px">{{.Title}}</h2>
+{{if .Severity}}<p style="margin:0 0 16px;font-size:12px;text-transform:uppercase">{{.Severity}}</p>{{end}}
+<p>{{.Message}}</p>`),
+	"alert.escalated": newEmailTemplate(
+		`{{if .Severity}}[{{.Severity}}] {{end}}{{.Title}}`,
+		`Alert: {{.Title}}
+Severity: {{.Severity}}{{if gt .Step 0}}, escalation step {{.Step}}{{end}}
+
+{{.Message}}
+{{if .AckURL}}
+Acknowledge: {{.AckURL}}
+{{end}}`,
+		`<h2 style="margin:0 0 8px">{{.Title}}</h2>
+<p style="margin:0 0 16px;font-size:12px;text-transform:uppercase">{{.Severity}}{{if gt .Step 0}} &middot; escalation step {{.Step}}{{end}}</p>
+<p>{{.Message}}</p>
+{{if .AckURL}}<p><a href="{{.AckURL}}" style="display:inline-block;padding:8px 16px;background:#cf222e;color:#ffffff;border-radius:4px;text-decoration:none">Acknowledge</a></p>{{end}}`),
+	"summary.daily": newEmailTemplate(
+		`{{.Title}}{{if not .To.IsZero}} for {{day .To}}{{end}}`,
+		`{{.Title}}
+{{if not .From.IsZero}}{{date .From}} to {{date .To}}
+{{end}}
+{{.Message}}
+{{range .Metrics}}
+{{.Key}}: {{num .Value}}{{with .Delta}} {{delta .}}{{end}}{{end}}
+`,
+		`<h2 style="margin:0 0 8px">{{.Title}}</h2>
+{{if not .From.IsZero}}<p style="margin:0 0 16px;font-size:12px">{{date .From}} to {{date .To}}</p>{{end}}
+<p>{{.Message}}</p>
+{{if .Metrics}}<table role="presentation" width="100%" style="border-collapse:collapse">
+<tr><th align="left" style="padding:6px;border-bottom:1px solid #d0d7de">Metric</th><th align="right" style="padding:6px;border-bottom:1px solid #d0d7de">Value</th><th align="right" style="padding:6px;border-bottom:1px solid #d0d7de">Change</th></tr>
+{{range .Metrics}}<tr><td style="padding:6px">{{.Key}}</td><td align="right" style="padding:6px">{{num .Value}}</td><td align="right" style="padding:6px">{{with .Delta}}<span style="color:{{if lt .Change 0.0}}#cf222e{{else}}#1a7f37{{end}}">{{delta .}}</span>{{end}}</td></tr>
+{{end}}</table>{{end}}`),
+	"insight.assigned": newEmailTemplate(
+		`Assigned to you: {{.Title}}`,
+		`{{if .AssignedBy}}{{.AssignedBy}} assigned{{else}}You were assigned{{end}} an insight{{if .AssignedBy}} to you{{end}}.
+
+{{.Title}}
+{{.Message}}
+{{if .DueAt}}
+Due: {{date .DueAt}}
+{{end}}`,
+		`<p style="margin:0 0 16px">{{if .AssignedBy}}<strong>{{.AssignedBy}}</strong> assigned you an insight{{else}}You were assigned an insight{{end}}{{if .DueAt}}, due <strong>{{date .DueAt}}</strong>{{end}}.</p>
+<h2 style="margin:0 0 8px">{{.Title}}</h2>
+{{if .Severity}}<p style="margin:0 0 16px;font-size:12px;text-transform:uppercase">{{.Severity}}</p>{{end}}
+<p>{{.Message}}</p>`),
+	"user.invited": newEmailTemplate(
+		`{{.Title}}`,
+		`{{if .InvitedBy}}{{.InvitedBy}} invited you{{else}}You are invited{{end}} to the dashboard as {{.Role}}.
+
+Create your account: {{.InviteURL}}
+{{if not .ExpiresAt.IsZero}}
+The link expires {{date .ExpiresAt}}.
+{{end}}`,
+		`<h2 style="margin:0 0 8px">{{.Title}}</h2>
+<p>{{if .InvitedBy}}<strong>{{.InvitedBy}}</strong> invited you{{else}}You are invited{{end}} to the dashboard as <strong>{{.Role}}</strong>.</p>
+<p><a href="{{.InviteURL}}" style="display:inline-block;padding:8px 16px;background:#0969da;color:#ffffff;border-radius:4px;text-decoration:none">Create your account</a></p>
+{{if not .ExpiresAt.IsZero}}<p style="font-size:12px">The link expires {{date .ExpiresAt}}.</p>{{end}}`),
+}
+
+func newEmailTemplate(subject, text, html string) emailTemplate {
+	return emailTemplate{
+		subject: template.Must(template.New("subject").Funcs(emailFuncs).Parse(subject)),
+		text:    template.Must(template.New("text").Funcs(emailFuncs).Parse(text)),
+		html:    htmltemplate.Must(htmltemplate.Must(htmltemplate.New("layout").Funcs(emailFuncs).Parse(emailLayout)).New("content").Parse(html)),
+	}
+}
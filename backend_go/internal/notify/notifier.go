@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event Event) error
+}
+
+// DirectSender delivers an event to one person, addressed by an email
+// address, a chat user id or whatever else the sender understands.
+type DirectSender interface {
+	Name() string
+	Send(ctx context.Context, to string, event Event) error
+}
+
+// Fanout is implemented by notifiers that send one event to several
+// destinations, such as configured channels or individual users. The outbox
+// dispatcher tracks each target on its own so a retry only reaches the
+// targets that failed rather than all of them again.
+type Fanout interface {
+	Notifier
+	Targets(ctx context.Context, event Event) ([]string, error)
+	NotifyTarget(ctx context.Context, target string, event Event) error
+}
@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const (
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	OpsgenieAlertsURL  = "https://api.opsgenie.com/v2/alerts"
+)
+
+// pagerFields are the parts of an event payload paging services use.
+type pagerFields struct {
+	robotMessage
+	ID      int64  `json:"id"`
+	AlertID int64  `json:"alert_id"`
+	Source  string `json:"source"`
+	AckURL  string `json:"ack_url"`
+}
+
+// pagerEvent reads the payload and derives a deduplication key, so repeated
+// deliveries and later escalation steps of one alert update a single
+// incident instead of opening new ones.
+func pagerEvent(event Event) (pagerFields, string) {
+	var fields pagerFields
+	_ = json.Unmarshal(event.Payload, &fields)
+	fields.robotMessage = summarize(event)
+	switch {
+	case fields.AlertID > 0:
+		return fields, "mydashboard-alert-" + strconv.FormatInt(fields.AlertID, 10)
+	case event.Type == models.EventInsightCreated && fields.ID > 0:
+		return fields, "mydashboard-insight-" + strconv.FormatInt(fields.ID, 10)
+	}
+	return fields, "mydashboard-event-" + strconv.FormatInt(event.ID, 10)
+}
+
+func postPager(ctx context.Context, client *http.Client, target string, header http.Header, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// PagerDutyNotifier triggers incidents through the PagerDuty Events API v2
+// with the routing key of a service integration.
+type PagerDutyNotifier struct {
+	name       string
+	url        string
+	routingKey string
+	httpClient *http.Client
+}
+
+func NewPagerDutyNotifier(name, url, routingKey string) *PagerDutyNotifier {
+	if url == "" {
+		url = PagerDutyEventsURL
+	}
+	return &PagerDutyNotifier{
+		name:       name,
+		url:        url,
+		routingKey: routingKey,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (n *PagerDutyNotifier) Name() string {
+	return "pagerduty " + n.name
+}
+
+func (n *PagerDutyNotifier) Notify(ctx context.Context, event Event) error {
+	fields, dedupKey := pagerEvent(event)
+	severity := fields.Severity
+	if severity == "" {
+		severity = models.SeverityInfo
+	}
+	body := map[string]any{
+		"routing_key":  n.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    dedupKey,
+		"payload": map[string]any{
+			"summary":        truncate(fields.Title, 1024),
+			"source":         "mydashboard",
+			"severity":       severity,
+			"timestamp":      event.CreatedAt.Format(time.RFC3339),
+			"component":      fields.Source,
+			"class":          event.Type,
+			"custom_details": json.RawMessage(event.Payload),
+		},
+	}
+	if fields.AckURL != "" {
+		body["links"] = []map[string]string{{"href": fields.AckURL, "text": "Acknowledge in MyDashboard"}}
+	}
+	if err := postPager(ctx, n.httpClient, n.url, nil, body); err != nil {
+		return fmt.Errorf("pagerduty error: %w", err)
+	}
+	return nil
+}
+
+// OpsgenieNotifier creates alerts through the Opsgenie Alert API with the
+// key of an API integration. EU accounts set the api.eu.opsgenie.com URL.
+type OpsgenieNotifier struct {
+	name       string
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewOpsgenieNotifier(name, url, apiKey string) *OpsgenieNotifier {
+	if url == "" {
+		url = OpsgenieAlertsURL
+	}
+	return &OpsgenieNotifier{
+		name:   name,
+		url:    url,
+		apiKey: apiKey,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (n *OpsgenieNotifier) Name() string {
+	return "opsgenie " + n.name
+}
+
+var opsgeniePriority = map[string]string{
+	models.SeverityCritical: "P1",
+	models.SeverityWarning:  "P3",
+	models.SeverityInfo:     "P5",
+}
+
+func (n *OpsgenieNotifier) Notify(ctx context.Context, event Event) error {
+	fields, alias := pagerEvent(event)
+	priority, ok := opsgeniePriority[fields.Severity]
+	if !ok {
+		priority = "P5"
+	}
+	description := fields.Message
+	if fields.AckURL != "" {
+		description += "\n\n" + fields.AckURL
+	}
+	body := map[string]any{
+		"message":     truncate(fields.Title, 130),
+		"alias":       alias,
+		"description": truncate(description, 15000),
+		"source":      "mydashboard",
+		"priority":    priority,
+		"tags":        []string{event.Type},
+	}
+	header := http.Header{"Authorization": {"GenieKey " + n.apiKey}}
+	if err := postPager(ctx, n.httpClient, n.url, header, body); err != nil {
+		return fmt.Errorf("opsgenie error: %w", err)
+	}
+	return nil
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// robotMessage is the subset of an event payload group robots display.
+// Insight events carry all three fields; other events fall back to the type.
+type robotMessage struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+func summarize(event Event) robotMessage {
+	var msg robotMessage
+	_ = json.Unmarshal(event.Payload, &msg)
+	if msg.Title == "" {
+		msg.Title = event.Type
+	}
+	if msg.Severity != "" {
+		msg.Title = "[" + msg.Severity + "] " + msg.Title
+	}
+	return msg
+}
+
+func hmacBase64(key, data string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// postRobot sends body and decodes the JSON reply into result. Robots answer
+// HTTP 200 for most failures, so callers check the code in result as well.
+func postRobot(ctx context.Context, client *http.Client, target string, body any, result any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(result)
+}
+
+// DingTalkNotifier posts to a DingTalk group robot. With a secret, requests
+// are signed as the robot's "加签" security setting expects.
+type DingTalkNotifier struct {
+	name       string
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+func NewDingTalkNotifier(name, url, secret string) *DingTalkNotifier {
+	return &DingTalkNotifier{
+		name:   name,
+		url:    url,
+		secret: secret,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Name leaves out the URL, which carries the robot's access token.
+func (n *DingTalkNotifier) Name() string {
+	return "dingtalk " + n.name
+}
+
+func (n *DingTalkNotifier) Notify(ctx context.Context, event Event) error {
+	target, err := url.Parse(n.url)
+	if err != nil {
+		return err
+	}
+	if n.secret != "" {
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		query := target.Query()
+		query.Set("timestamp", ts)
+		query.Set("sign", hmacBase64(n.secret, ts+"\n"+n.secret))
+		target.RawQuery = query.Encode()
+	}
+	msg := summarize(event)
+	body := map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": msg.Title,
+			"text":  "### " + msg.Title + "\n\n" + msg.Message,
+		},
+	}
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := postRobot(ctx, n.httpClient, target.String(), body, &result); err != nil {
+		return err
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("dingtalk error %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// FeishuNotifier posts to a Feishu (Lark) custom bot, signing the request
+// when the bot has signature verification enabled.
+type FeishuNotifier struct {
+	name       string
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+func NewFeishuNotifier(name, url, secret string) *FeishuNotifier {
+	return &FeishuNotifier{
+		name:   name,
+		url:    url,
+		secret: secret,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (n *FeishuNotifier) Name() string {
+	return "feishu " + n.name
+}
+
+func (n *FeishuNotifier) Notify(ctx context.Context, event Event) error {
+	msg := summarize(event)
+	body := map[string]any{
+		"msg_type": "post",
+		"content": map[string]any{
+			"post": map[string]any{
+				"zh_cn": map[string]any{
+					"title":   msg.Title,
+					"content": [][]map[string]string{{{"tag": "text", "text": msg.Message}}},
+				},
+			},
+		},
+	}
+	if n.secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		// Feishu uses the timestamp and secret as the HMAC key over an empty message.
+		body["timestamp"] = ts
+		body["sign"] = hmacBase64(ts+"\n"+n.secret, "")
+	}
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := postRobot(ctx, n.httpClient, n.url, body, &result); err != nil {
+		return err
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("feishu error %d: %s", result.Code, result.Msg)
+	}
+	return nil
+}
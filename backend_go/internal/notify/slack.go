@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// SlackDMSender sends direct messages through a Slack app's bot token. The
+// address is a Slack user id such as U012AB3CD; the app needs the chat:write
+// scope.
+type SlackDMSender struct {
+	token      string
+	url        string
+	httpClient *http.Client
+}
+
+func NewSlackDMSender(token string) *SlackDMSender {
+	return &SlackDMSender{
+		token: token,
+		url:   slackPostMessageURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (s *SlackDMSender) Name() string {
+	return "slack dm"
+}
+
+func (s *SlackDMSender) Send(ctx context.Context, to string, event Event) error {
+	msg := summarize(event)
+	text := "*" + msg.Title + "*"
+	if msg.Message != "" {
+		text += "\n" + msg.Message
+	}
+	body, err := json.Marshal(map[string]any{"channel": to, "text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	// Slack answers 200 with ok=false for most failures.
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return err
+	}
+	if !result.OK {
+		return fmt.Errorf("slack: %s", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+// TeamsNotifier posts adaptive cards to a Microsoft Teams incoming webhook
+// or a Workflows "post to a channel when a webhook request is received" URL.
+// With a dashboard URL, cards link back to it.
+type TeamsNotifier struct {
+	name         string
+	url          string
+	dashboardURL string
+	httpClient   *http.Client
+}
+
+func NewTeamsNotifier(name, url, dashboardURL string) *TeamsNotifier {
+	return &TeamsNotifier{
+		name:         name,
+		url:          url,
+		dashboardURL: strings.TrimRight(dashboardURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (n *TeamsNotifier) Name() string {
+	return "teams " + n.name
+}
+
+func (n *TeamsNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     n.card(event),
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("teams error: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// card shows the event's title and message, a fact per metric when the
+// payload carries a snapshot, an acknowledgment link for escalated alerts and
+// a link to the dashboard.
+func (n *TeamsNotifier) card(event Event) map[string]any {
+	msg := summarize(event)
+	var payload struct {
+		ID       int64                         `json:"id"`
+		Snapshot *models.Metrics               `json:"snapshot"`
+		Deltas   map[string]models.MetricDelta `json:"deltas"`
+		AckURL   string                        `json:"ack_url"`
+	}
+	_ = json.Unmarshal(event.Payload, &payload)
+
+	title := map[string]any{"type": "TextBlock", "text": msg.Title, "weight": "Bolder", "size": "Medium", "wrap": true}
+	if strings.HasPrefix(msg.Title, "["+models.SeverityCritical+"]") {
+		title["color"] = "Attention"
+	}
+	body := []map[string]any{title}
+	if msg.Message != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": msg.Message, "wrap": true})
+	}
+	if snapshot := payload.Snapshot; snapshot != nil {
+		facts := make([]map[string]string, 0, len(models.MetricKeys))
+		for _, key := range models.MetricKeys {
+			value, _ := snapshot.Value(key)
+			text := strconv.FormatFloat(value, 'f', 2, 64)
+			if delta, ok := payload.Deltas[key]; ok && delta.Percent != nil {
+				text += fmt.Sprintf(" (%+.1f%%)", *delta.Percent)
+			}
+			facts = append(facts, map[string]string{"title": key, "value": text})
+		}
+		body = append(body, map[string]any{"type": "FactSet", "facts": facts})
+	}
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	var actions []map[string]string
+	if payload.AckURL != "" {
+		actions = append(actions, map[string]string{"type": "Action.OpenUrl", "title": "Acknowledge", "url": payload.AckURL})
+	}
+	if n.dashboardURL != "" {
+		link := n.dashboardURL + "/"
+		if event.Type == models.EventInsightCreated && payload.ID > 0 {
+			link += "?" + url.Values{"insight": {strconv.FormatInt(payload.ID, 10)}}.Encode()
+		}
+		actions = append(actions, map[string]string{"type": "Action.OpenUrl", "title": "Open dashboard", "url": link})
+	}
+	if actions != nil {
+		card["actions"] = actions
+	}
+	return card
+}
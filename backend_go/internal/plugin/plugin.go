@@ -0,0 +1,211 @@
+// Package plugin loads collectors and notifiers that run as external
+// processes, so they can be written in any language and shipped without
+// rebuilding the server. Plugins are listed in a JSON manifest:
+//
+//	{"plugins": [
+//	  {"name": "crm", "kind": "collector", "command": "/opt/plugins/crm", "args": ["--region", "eu"], "interval": "5m"},
+//	  {"name": "sms", "kind": "notifier", "command": "/opt/plugins/sms", "env": {"SMS_TOKEN": "..."}}
+//	]}
+//
+// A collector plugin is run once per interval and prints its readings to
+// stdout, in the format accepted by collector.ParseValues. A notifier plugin
+// is run once per event with the event JSON on stdin. Exiting non-zero is a
+// failure; the first lines of stderr are reported with it. Plugins do not
+// inherit the server's environment, only PATH, HOME and their own env.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/collector"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/notify"
+)
+
+const (
+	KindCollector = "collector"
+	KindNotifier  = "notifier"
+
+	defaultTimeout  = 30 * time.Second
+	defaultInterval = time.Minute
+	maxOutput       = 1 << 20
+	maxStderr       = 512
+)
+
+type Spec struct {
+	Name     string            `json:"name"`
+	Kind     string            `json:"kind"`
+	Command  string            `json:"command"`
+	Args     []string          `json:"args"`
+	Env      map[string]string `json:"env"`
+	Interval string            `json:"interval"`
+	Timeout  string            `json:"timeout"`
+}
+
+// Plugins is a loaded manifest, split by kind.
+type Plugins struct {
+	Collectors []collector.Collector
+	Notifiers  []notify.Notifier
+}
+
+// Load reads the manifest at path. Commands must exist and be executable,
+// so a typo fails at startup rather than on the first run.
+func Load(path string) (Plugins, error) {
+	var plugins Plugins
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return plugins, err
+	}
+	var manifest struct {
+		Plugins []Spec `json:"plugins"`
+	}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return plugins, fmt.Errorf("%s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for i, spec := range manifest.Plugins {
+		p, err := newProcess(spec)
+		if err != nil {
+			return plugins, fmt.Errorf("plugin %d: %w", i, err)
+		}
+		if seen[spec.Kind+"/"+spec.Name] {
+			return plugins, fmt.Errorf("plugin %d: duplicate %s %q", i, spec.Kind, spec.Name)
+		}
+		seen[spec.Kind+"/"+spec.Name] = true
+		switch spec.Kind {
+		case KindCollector:
+			interval := defaultInterval
+			if spec.Interval != "" {
+				if interval, err = time.ParseDuration(spec.Interval); err != nil {
+					return plugins, fmt.Errorf("plugin %d: invalid interval %q", i, spec.Interval)
+				}
+			}
+			plugins.Collectors = append(plugins.Collectors, &Collector{process: p, interval: interval})
+		case KindNotifier:
+			plugins.Notifiers = append(plugins.Notifiers, &Notifier{process: p})
+		default:
+			return plugins, fmt.Errorf("plugin %d: kind must be %s or %s", i, KindCollector, KindNotifier)
+		}
+	}
+	return plugins, nil
+}
+
+type process struct {
+	name    string
+	command string
+	args    []string
+	env     []string
+	timeout time.Duration
+}
+
+func newProcess(spec Spec) (process, error) {
+	if spec.Name == "" {
+		return process{}, errors.New("name is required")
+	}
+	command, err := exec.LookPath(spec.Command)
+	if err != nil {
+		return process{}, fmt.Errorf("%s: %w", spec.Name, err)
+	}
+	timeout := defaultTimeout
+	if spec.Timeout != "" {
+		if timeout, err = time.ParseDuration(spec.Timeout); err != nil || timeout <= 0 {
+			return process{}, fmt.Errorf("%s: invalid timeout %q", spec.Name, spec.Timeout)
+		}
+	}
+	env := []string{"PATH=" + os.Getenv("PATH"), "HOME=" + os.Getenv("HOME"), "PLUGIN_NAME=" + spec.Name}
+	for key, value := range spec.Env {
+		env = append(env, key+"="+value)
+	}
+	return process{name: spec.Name, command: command, args: spec.Args, env: env, timeout: timeout}, nil
+}
+
+// run executes the plugin with stdin and extra environment, returning its
+// stdout.
+func (p process) run(ctx context.Context, stdin []byte, env ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.command, p.args...)
+	cmd.Env = append(append([]string(nil), p.env...), env...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr limitedBuffer
+	stdout.limit, stderr.limit = maxOutput, maxStderr
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("plugin %s: %w", p.name, ctx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %s: %w: %s", p.name, err, msg)
+		}
+		return nil, fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("plugin %s: output exceeds %d bytes", p.name, maxOutput)
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+type Collector struct {
+	process
+	interval time.Duration
+}
+
+func (c *Collector) Name() string            { return c.name }
+func (c *Collector) Interval() time.Duration { return c.interval }
+
+func (c *Collector) Collect(ctx context.Context) ([]models.MetricValue, error) {
+	out, err := c.run(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	values, err := collector.ParseValues(out)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid output: %w", c.name, err)
+	}
+	return values, nil
+}
+
+type Notifier struct {
+	process
+}
+
+func (n *Notifier) Name() string {
+	return "plugin " + n.name
+}
+
+func (n *Notifier) Notify(ctx context.Context, event notify.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = n.run(ctx, body, "EVENT_ID="+strconv.FormatInt(event.ID, 10), "EVENT_TYPE="+event.Type)
+	return err
+}
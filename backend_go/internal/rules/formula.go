@@ -0,0 +1,251 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrUndefined = errors.New("result is undefined")
+
+// Formula is a parsed arithmetic expression such as "revenue / backlog".
+type Formula interface {
+	Eval(vars map[string]float64) (float64, error)
+	String() string
+}
+
+type binary struct {
+	left, right Formula
+	op          rune
+}
+
+// Eval returns ErrUndefined for a division by zero or any other result that
+// is not a finite number.
+func (b binary) Eval(vars map[string]float64) (float64, error) {
+	left, err := b.left.Eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	right, err := b.right.Eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	var value float64
+	switch b.op {
+	case '+':
+		value = left + right
+	case '-':
+		value = left - right
+	case '*':
+		value = left * right
+	case '/':
+		if right == 0 {
+			return 0, ErrUndefined
+		}
+		value = left / right
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, ErrUndefined
+	}
+	return value, nil
+}
+
+func (b binary) String() string {
+	return "(" + b.left.String() + " " + string(b.op) + " " + b.right.String() + ")"
+}
+
+type negated struct {
+	inner Formula
+}
+
+func (n negated) Eval(vars map[string]float64) (float64, error) {
+	value, err := n.inner.Eval(vars)
+	return -value, err
+}
+
+func (n negated) String() string {
+	return "-" + n.inner.String()
+}
+
+func (o operand) Eval(vars map[string]float64) (float64, error) {
+	return o.resolve(vars)
+}
+
+// FormulaVars lists the variable names referenced by formula, sorted.
+func FormulaVars(formula Formula) []string {
+	seen := map[string]bool{}
+	var walk func(Formula)
+	walk = func(f Formula) {
+		switch f := f.(type) {
+		case operand:
+			if f.name != "" {
+				seen[f.name] = true
+			}
+		case binary:
+			walk(f.left)
+			walk(f.right)
+		case negated:
+			walk(f.inner)
+		}
+	}
+	walk(formula)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFormula accepts identifiers and numbers combined with + - * /, unary
+// minus and parentheses, with the usual precedence.
+func ParseFormula(src string) (Formula, error) {
+	tokens, err := lexFormula(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &formulaParser{tokens: tokens}
+	formula, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].pos)
+	}
+	return formula, nil
+}
+
+func lexFormula(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case strings.ContainsRune("+-*/", r):
+			tokens = append(tokens, token{tokOp, string(r), i})
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i]), start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty expression")
+	}
+	return tokens, nil
+}
+
+type formulaParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *formulaParser) peekOp(ops string) (rune, bool) {
+	if p.pos >= len(p.tokens) {
+		return 0, false
+	}
+	tok := p.tokens[p.pos]
+	if tok.kind != tokOp || !strings.Contains(ops, tok.text) {
+		return 0, false
+	}
+	return rune(tok.text[0]), true
+}
+
+func (p *formulaParser) parseSum() (Formula, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp("+-")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{left: left, right: right, op: op}
+	}
+}
+
+func (p *formulaParser) parseProduct() (Formula, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp("*/")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{left: left, right: right, op: op}
+	}
+}
+
+func (p *formulaParser) parseFactor() (Formula, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("unexpected end of expression")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokOp:
+		if tok.text != "-" {
+			break
+		}
+		inner, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return negated{inner: inner}, nil
+	case tokLParen:
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokRParen {
+			return nil, fmt.Errorf("missing ) for ( at position %d", tok.pos)
+		}
+		p.pos++
+		return inner, nil
+	case tokIdent:
+		return operand{name: strings.ToLower(tok.text)}, nil
+	case tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return operand{value: value}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
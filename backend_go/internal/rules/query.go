@@ -0,0 +1,269 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// QueryNode is a parsed series expression such as "rate(revenue, 1h) * 100".
+// The parser only checks syntax; which functions exist and what arguments
+// they take is up to the evaluator.
+type QueryNode interface {
+	String() string
+}
+
+type (
+	QueryNumber   float64
+	QueryDuration time.Duration
+	QueryMetric   string
+)
+
+type QueryCall struct {
+	Func string
+	Args []QueryNode
+}
+
+type QueryBinary struct {
+	Op          rune
+	Left, Right QueryNode
+}
+
+type QueryNeg struct {
+	Inner QueryNode
+}
+
+func (n QueryNumber) String() string {
+	return strconv.FormatFloat(float64(n), 'f', -1, 64)
+}
+
+func (d QueryDuration) String() string {
+	return time.Duration(d).String()
+}
+
+func (m QueryMetric) String() string {
+	return string(m)
+}
+
+func (c QueryCall) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = arg.String()
+	}
+	return c.Func + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (b QueryBinary) String() string {
+	return "(" + b.Left.String() + " " + string(b.Op) + " " + b.Right.String() + ")"
+}
+
+func (n QueryNeg) String() string {
+	return "-" + n.Inner.String()
+}
+
+// ParseQuery accepts metric names, numbers, durations (30s, 15m, 1h, 7d),
+// function calls, + - * /, unary minus and parentheses.
+func ParseQuery(src string) (QueryNode, error) {
+	tokens, err := lexQuery(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	node, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].pos)
+	}
+	return node, nil
+}
+
+func lexQuery(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case strings.ContainsRune("+-*/", r):
+			tokens = append(tokens, token{tokOp, string(r), i})
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			kind := tokNumber
+			for i < len(runes) && unicode.IsLetter(runes[i]) {
+				kind = tokDuration
+				i++
+			}
+			tokens = append(tokens, token{kind, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i]), start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty expression")
+	}
+	return tokens, nil
+}
+
+// parseQueryDuration extends time.ParseDuration with days.
+func parseQueryDuration(text string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(text)
+}
+
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *queryParser) peek(kind tokenKind) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind
+}
+
+func (p *queryParser) peekOp(ops string) (rune, bool) {
+	if !p.peek(tokOp) || !strings.Contains(ops, p.tokens[p.pos].text) {
+		return 0, false
+	}
+	return rune(p.tokens[p.pos].text[0]), true
+}
+
+func (p *queryParser) parseSum() (QueryNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp("+-")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = QueryBinary{Op: op, Left: left, Right: right}
+	}
+}
+
+func (p *queryParser) parseProduct() (QueryNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp("*/")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = QueryBinary{Op: op, Left: left, Right: right}
+	}
+}
+
+func (p *queryParser) parseFactor() (QueryNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("unexpected end of expression")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokOp:
+		if tok.text != "-" {
+			break
+		}
+		inner, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return QueryNeg{Inner: inner}, nil
+	case tokLParen:
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(tokRParen) {
+			return nil, fmt.Errorf("missing ) for ( at position %d", tok.pos)
+		}
+		p.pos++
+		return inner, nil
+	case tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return QueryNumber(value), nil
+	case tokDuration:
+		d, err := parseQueryDuration(tok.text)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration %q at position %d", tok.text, tok.pos)
+		}
+		return QueryDuration(d), nil
+	case tokIdent:
+		name := strings.ToLower(tok.text)
+		if !p.peek(tokLParen) {
+			return QueryMetric(name), nil
+		}
+		p.pos++
+		return p.parseCall(name, tok.pos)
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+func (p *queryParser) parseCall(name string, pos int) (QueryNode, error) {
+	call := QueryCall{Func: name}
+	if p.peek(tokRParen) {
+		p.pos++
+		return call, nil
+	}
+	for {
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+		switch {
+		case p.peek(tokComma):
+			p.pos++
+		case p.peek(tokRParen):
+			p.pos++
+			return call, nil
+		default:
+			return nil, fmt.Errorf("missing ) for %s( at position %d", name, pos)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a parsed rule condition such as "backlog > 150 and growth < 12".
+type Expr interface {
+	Eval(vars map[string]float64) (bool, error)
+	String() string
+}
+
+type operand struct {
+	name  string
+	value float64
+}
+
+func (o operand) resolve(vars map[string]float64) (float64, error) {
+	if o.name == "" {
+		return o.value, nil
+	}
+	value, ok := vars[o.name]
+	if !ok {
+		return 0, fmt.Errorf("unknown variable %q", o.name)
+	}
+	return value, nil
+}
+
+func (o operand) String() string {
+	if o.name != "" {
+		return o.name
+	}
+	return strconv.FormatFloat(o.value, 'f', -1, 64)
+}
+
+type comparison struct {
+	left, right operand
+	op          string
+}
+
+func (c comparison) Eval(vars map[string]float64) (bool, error) {
+	left, err := c.left.resolve(vars)
+	if err != nil {
+		return false, err
+	}
+	right, err := c.right.resolve(vars)
+	if err != nil {
+		return false, err
+	}
+	switch c.op {
+	case ">":
+		return left > right, nil
+	case ">=":
+		return left >= right, nil
+	case "<":
+		return left < right, nil
+	case "<=":
+		return left <= right, nil
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", c.op)
+}
+
+func (c comparison) String() string {
+	return c.left.String() + " " + c.op + " " + c.right.String()
+}
+
+type logical struct {
+	left, right Expr
+	and         bool
+}
+
+func (l logical) Eval(vars map[string]float64) (bool, error) {
+	left, err := l.left.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	if l.and && !left {
+		return false, nil
+	}
+	if !l.and && left {
+		return true, nil
+	}
+	return l.right.Eval(vars)
+}
+
+func (l logical) String() string {
+	op := " or "
+	if l.and {
+		op = " and "
+	}
+	return "(" + l.left.String() + op + l.right.String() + ")"
+}
+
+type negation struct {
+	inner Expr
+}
+
+func (n negation) Eval(vars map[string]float64) (bool, error) {
+	value, err := n.inner.Eval(vars)
+	return !value, err
+}
+
+func (n negation) String() string {
+	return "not " + n.inner.String()
+}
+
+// Vars lists the variable names referenced by expr, sorted.
+func Vars(expr Expr) []string {
+	seen := map[string]bool{}
+	var walk func(Expr)
+	walk = func(e Expr) {
+		switch e := e.(type) {
+		case comparison:
+			for _, o := range []operand{e.left, e.right} {
+				if o.name != "" {
+					seen[o.name] = true
+				}
+			}
+		case logical:
+			walk(e.left)
+			walk(e.right)
+		case negation:
+			walk(e.inner)
+		}
+	}
+	walk(expr)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse accepts comparisons between identifiers and numbers combined with
+// and/or/not (or &&, ||, !) and parentheses.
+func Parse(src string) (Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].pos)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+	tokComma
+	tokDuration
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case strings.ContainsRune("<>=!&|", r):
+			start := i
+			i++
+			if i < len(runes) && strings.ContainsRune("=&|", runes[i]) {
+				i++
+			}
+			text := string(runes[start:i])
+			switch text {
+			case "&&":
+				tokens = append(tokens, token{tokAnd, text, start})
+			case "||":
+				tokens = append(tokens, token{tokOr, text, start})
+			case "!":
+				tokens = append(tokens, token{tokNot, text, start})
+			case ">", ">=", "<", "<=", "==", "!=":
+				tokens = append(tokens, token{tokOp, text, start})
+			case "=":
+				tokens = append(tokens, token{tokOp, "==", start})
+			default:
+				return nil, fmt.Errorf("unknown operator %q at position %d", text, start)
+			}
+		case unicode.IsDigit(r) || r == '.' || r == '-':
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			text := string(runes[start:i])
+			switch strings.ToLower(text) {
+			case "and":
+				tokens = append(tokens, token{tokAnd, text, start})
+			case "or":
+				tokens = append(tokens, token{tokOr, text, start})
+			case "not":
+				tokens = append(tokens, token{tokNot, text, start})
+			default:
+				tokens = append(tokens, token{tokIdent, text, start})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty condition")
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokOr {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokAnd {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{left: left, right: right, and: true}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, errors.New("unexpected end of condition")
+	}
+	switch tok.kind {
+	case tokNot:
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negation{inner: inner}, nil
+	case tokLParen:
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokRParen {
+			return nil, fmt.Errorf("missing ) for ( at position %d", tok.pos)
+		}
+		p.pos++
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	tok, ok := p.peek()
+	if !ok || tok.kind != tokOp {
+		return nil, fmt.Errorf("expected comparison after %s", left)
+	}
+	p.pos++
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return comparison{left: left, right: right, op: tok.text}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return operand{}, errors.New("unexpected end of condition")
+	}
+	p.pos++
+	switch tok.kind {
+	case tokIdent:
+		return operand{name: strings.ToLower(tok.text)}, nil
+	case tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return operand{}, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return operand{value: value}, nil
+	}
+	return operand{}, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
@@ -0,0 +1,21 @@
+package rules
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Render replaces {{name}} placeholders with the matching variable. Unknown
+// placeholders are left untouched.
+func Render(template string, vars map[string]float64) string {
+	return placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			return match
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	})
+}
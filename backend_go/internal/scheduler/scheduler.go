@@ -0,0 +1,334 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+// persistEvery bounds how often run status is written back per job, so
+// second-level jobs do not turn into a write per tick.
+const persistEvery = time.Minute
+
+var (
+	ErrUnknownJob      = errors.New("scheduler: unknown job")
+	ErrJobRunning      = errors.New("scheduler: job already running")
+	ErrInvalidSchedule = errors.New("scheduler: invalid schedule")
+)
+
+type Func func(ctx context.Context) error
+
+type job struct {
+	fn          Func
+	state       models.ScheduledJob
+	schedule    Schedule
+	reload      chan struct{}
+	persistedAt time.Time
+	overrun     int64
+}
+
+type Scheduler struct {
+	store *store.Store
+	loc   *time.Location
+
+	mu   sync.Mutex
+	ctx  context.Context
+	jobs map[string]*job
+}
+
+func New(store *store.Store) *Scheduler {
+	return &Scheduler{
+		store: store,
+		loc:   time.Local,
+		ctx:   context.Background(),
+		jobs:  map[string]*job{},
+	}
+}
+
+// WithLocation sets the timezone cron fields are evaluated in.
+func (s *Scheduler) WithLocation(loc *time.Location) *Scheduler {
+	s.loc = loc
+	return s
+}
+
+// Register adds a job with its default schedule. A schedule saved through the
+// admin API takes precedence once Start has loaded it.
+func (s *Scheduler) Register(name, spec string, fn Func) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidSchedule, name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("scheduler: job %q registered twice", name)
+	}
+	s.jobs[name] = &job{
+		fn:       fn,
+		state:    models.ScheduledJob{Name: name, Schedule: spec, Enabled: true},
+		schedule: schedule,
+		reload:   make(chan struct{}, 1),
+	}
+	return nil
+}
+
+// Start loads persisted definitions and runs every job until ctx is done.
+// Jobs keep their defaults when the store is unavailable.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+	s.load(ctx)
+
+	var wg sync.WaitGroup
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	s.mu.Unlock()
+	wg.Wait()
+}
+
+func (s *Scheduler) load(ctx context.Context) {
+	s.mu.Lock()
+	defaults := make(map[string]string, len(s.jobs))
+	for name, j := range s.jobs {
+		defaults[name] = j.state.Schedule
+	}
+	s.mu.Unlock()
+
+	for name, spec := range defaults {
+		if err := s.store.EnsureScheduledJob(ctx, name, spec); err != nil {
+			log.Printf("scheduler: register %s failed: %v", name, err)
+			return
+		}
+	}
+	saved, err := s.store.ListScheduledJobs(ctx)
+	if err != nil {
+		log.Printf("scheduler: load jobs failed: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, row := range saved {
+		j, ok := s.jobs[row.Name]
+		if !ok {
+			continue
+		}
+		schedule, err := Parse(row.Schedule)
+		if err != nil {
+			log.Printf("scheduler: saved schedule for %s ignored: %v", row.Name, err)
+		} else {
+			j.schedule = schedule
+			j.state.Schedule = row.Schedule
+		}
+		j.state.Enabled = row.Enabled
+		j.state.LastRunAt = row.LastRunAt
+		j.state.LastStatus = row.LastStatus
+		j.state.LastError = row.LastError
+		j.state.LastDurationMs = row.LastDurationMs
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		var fire <-chan time.Time
+		var timer *time.Timer
+		s.mu.Lock()
+		j.state.NextRunAt = nil
+		if j.state.Enabled {
+			if next := j.schedule.Next(time.Now().In(s.loc)); !next.IsZero() {
+				j.state.NextRunAt = &next
+				timer = time.NewTimer(time.Until(next))
+				fire = timer.C
+			}
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-j.reload:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-fire:
+			if s.claim(j) {
+				s.execute(ctx, j)
+			} else {
+				s.skip(j)
+			}
+		}
+	}
+}
+
+func (s *Scheduler) claim(j *job) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.state.Running {
+		return false
+	}
+	if j.overrun > 0 {
+		log.Printf("scheduler: %s finished, %d ticks skipped", j.state.Name, j.overrun)
+		j.overrun = 0
+	}
+	j.state.Running = true
+	return true
+}
+
+// skip counts a tick that found the job still running. It logs once per
+// overrun rather than per tick, which for a job running every second behind
+// a slow database adds up quickly.
+func (s *Scheduler) skip(j *job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.overrun == 0 {
+		log.Printf("scheduler: %s still running, skipping ticks", j.state.Name)
+	}
+	j.overrun++
+	j.state.SkippedTicks++
+}
+
+func (s *Scheduler) execute(ctx context.Context, j *job) error {
+	started := time.Now()
+	err := j.fn(ctx)
+	finished := time.Now()
+
+	s.mu.Lock()
+	previous := j.state.LastStatus
+	j.state.Running = false
+	j.state.LastRunAt = &finished
+	j.state.LastDurationMs = finished.Sub(started).Milliseconds()
+	j.state.LastStatus = models.JobStatusOK
+	j.state.LastError = ""
+	if err != nil {
+		j.state.LastStatus = models.JobStatusFailed
+		j.state.LastError = err.Error()
+	}
+	persist := j.state.LastStatus != previous || finished.Sub(j.persistedAt) >= persistEvery
+	if persist {
+		j.persistedAt = finished
+	}
+	snapshot := j.state
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("scheduler: %s failed: %v", snapshot.Name, err)
+	}
+	if persist {
+		if err := s.store.RecordJobRun(context.WithoutCancel(ctx), snapshot); err != nil {
+			log.Printf("scheduler: record %s run failed: %v", snapshot.Name, err)
+		}
+	}
+	return err
+}
+
+// Jobs returns the in-memory view, which is fresher than the persisted one.
+func (s *Scheduler) Jobs() []models.ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]models.ScheduledJob, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j.state)
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].Name < jobs[b].Name })
+	return jobs
+}
+
+// Upcoming lists the activations of a job between now and until, at most
+// limit of them. Disabled jobs have none.
+func (s *Scheduler) Upcoming(name string, until time.Time, limit int) ([]time.Time, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	if !ok {
+		s.mu.Unlock()
+		return nil, ErrUnknownJob
+	}
+	enabled, schedule := j.state.Enabled, j.schedule
+	s.mu.Unlock()
+
+	var runs []time.Time
+	if !enabled {
+		return runs, nil
+	}
+	for next := schedule.Next(time.Now().In(s.loc)); !next.IsZero() && !next.After(until) && len(runs) < limit; next = schedule.Next(next) {
+		runs = append(runs, next)
+	}
+	return runs, nil
+}
+
+// Update changes the schedule and/or enabled flag of a job; nil leaves the
+// current value. The change is persisted before it takes effect.
+func (s *Scheduler) Update(ctx context.Context, name string, spec *string, enabled *bool) (models.ScheduledJob, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	if !ok {
+		s.mu.Unlock()
+		return models.ScheduledJob{}, ErrUnknownJob
+	}
+	nextSpec, nextEnabled, schedule := j.state.Schedule, j.state.Enabled, j.schedule
+	s.mu.Unlock()
+
+	if spec != nil {
+		parsed, err := Parse(*spec)
+		if err != nil {
+			return models.ScheduledJob{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+		nextSpec, schedule = *spec, parsed
+	}
+	if enabled != nil {
+		nextEnabled = *enabled
+	}
+	if err := s.store.UpdateScheduledJob(ctx, name, nextSpec, nextEnabled); err != nil {
+		return models.ScheduledJob{}, err
+	}
+
+	s.mu.Lock()
+	j.state.Schedule = nextSpec
+	j.state.Enabled = nextEnabled
+	j.schedule = schedule
+	snapshot := j.state
+	s.mu.Unlock()
+
+	select {
+	case j.reload <- struct{}{}:
+	default:
+	}
+	return snapshot, nil
+}
+
+// Trigger starts a run outside the schedule and returns immediately. It
+// works for disabled jobs too.
+func (s *Scheduler) Trigger(name string) (models.ScheduledJob, error) {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	ctx := s.ctx
+	s.mu.Unlock()
+	if !ok {
+		return models.ScheduledJob{}, ErrUnknownJob
+	}
+	if !s.claim(j) {
+		return models.ScheduledJob{}, ErrJobRunning
+	}
+
+	s.mu.Lock()
+	snapshot := j.state
+	s.mu.Unlock()
+	go s.execute(ctx, j)
+	return snapshot, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	defaultAdHocLimit = 100
+	maxAdHocFilters   = 20
+	maxAdHocInValues  = 100
+)
+
+var ErrInvalidAdHocQuery = errors.New("invalid query")
+
+var adHocAlias = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// AdHocQueryService checks ad-hoc queries before the store runs them: only
+// allowlisted tables and columns, a row limit capped at maxRows and a
+// statement timeout.
+type AdHocQueryService struct {
+	store   *store.Store
+	maxRows int
+	timeout time.Duration
+}
+
+func NewAdHocQueryService(store *store.Store, maxRows int, timeout time.Duration) *AdHocQueryService {
+	return &AdHocQueryService{store: store, maxRows: max(maxRows, 1), timeout: timeout}
+}
+
+// Schema lists what can be queried, for clients building query editors.
+func (s *AdHocQueryService) Schema() map[string][]string {
+	return store.QueryableTables
+}
+
+// Run validates q and runs it. Metrics in hidden may not be read: selecting
+// one of their snapshot columns fails with ErrMetricRestricted, and rows of
+// tables keyed by metric are filtered to exclude them.
+func (s *AdHocQueryService) Run(ctx context.Context, q models.AdHocQuery, hidden []string) (models.AdHocResult, error) {
+	if err := s.validate(&q); err != nil {
+		return models.AdHocResult{}, err
+	}
+	columns := store.QueryableTables[q.Table]
+	if len(hidden) > 0 {
+		if q.Table == "metrics_snapshot" {
+			for _, column := range q.Referenced() {
+				if slices.Contains(hidden, column) {
+					return models.AdHocResult{}, ErrMetricRestricted
+				}
+			}
+		}
+		if slices.Contains(columns, "metric") {
+			for _, key := range hidden {
+				q.Where = append(q.Where, models.QueryFilter{Column: "metric", Op: "!=", Value: key})
+			}
+		}
+	}
+	return s.store.AdHocQuery(ctx, q, s.timeout)
+}
+
+func (s *AdHocQueryService) validate(q *models.AdHocQuery) error {
+	columns, ok := store.QueryableTables[q.Table]
+	if !ok {
+		return fmt.Errorf("%w: unknown table %q", ErrInvalidAdHocQuery, q.Table)
+	}
+	for _, column := range q.Referenced() {
+		if column != "" && column != "*" && !slices.Contains(columns, column) {
+			return fmt.Errorf("%w: unknown column %q in %s", ErrInvalidAdHocQuery, column, q.Table)
+		}
+	}
+	if len(q.Select) == 0 && len(q.Aggregates) == 0 {
+		return fmt.Errorf("%w: select or aggregates required", ErrInvalidAdHocQuery)
+	}
+	var aliases []string
+	for i := range q.Aggregates {
+		agg := &q.Aggregates[i]
+		agg.Func = strings.ToLower(agg.Func)
+		switch agg.Func {
+		case "count":
+			if agg.Column == "" {
+				agg.Column = "*"
+			}
+		case "sum", "avg", "min", "max":
+			if agg.Column == "*" || agg.Column == "" {
+				return fmt.Errorf("%w: %s needs a column", ErrInvalidAdHocQuery, agg.Func)
+			}
+		default:
+			return fmt.Errorf("%w: unknown aggregate %q", ErrInvalidAdHocQuery, agg.Func)
+		}
+		if agg.As == "" {
+			agg.As = agg.Func + "_" + strings.TrimPrefix(agg.Column, "*")
+			agg.As = strings.TrimSuffix(agg.As, "_")
+		}
+		if !adHocAlias.MatchString(agg.As) || slices.Contains(columns, agg.As) || slices.Contains(aliases, agg.As) {
+			return fmt.Errorf("%w: invalid or duplicate alias %q", ErrInvalidAdHocQuery, agg.As)
+		}
+		aliases = append(aliases, agg.As)
+	}
+	if len(q.Aggregates) > 0 || len(q.GroupBy) > 0 {
+		for _, column := range q.Select {
+			if !slices.Contains(q.GroupBy, column) {
+				return fmt.Errorf("%w: %s must be in group_by", ErrInvalidAdHocQuery, column)
+			}
+		}
+	}
+	if len(q.Where) > maxAdHocFilters {
+		return fmt.Errorf("%w: at most %d filters", ErrInvalidAdHocQuery, maxAdHocFilters)
+	}
+	for i := range q.Where {
+		if err := normalizeFilter(&q.Where[i]); err != nil {
+			return fmt.Errorf("%w: filter %d: %v", ErrInvalidAdHocQuery, i, err)
+		}
+	}
+	for _, order := range q.OrderBy {
+		if !slices.Contains(q.Select, order.Column) && !slices.Contains(aliases, order.Column) {
+			return fmt.Errorf("%w: order_by %q must be selected or an aggregate alias", ErrInvalidAdHocQuery, order.Column)
+		}
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidAdHocQuery)
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultAdHocLimit
+	}
+	q.Limit = min(q.Limit, s.maxRows)
+	return nil
+}
+
+// normalizeFilter checks the operator and value types, turning values on
+// time columns into time.Time.
+func normalizeFilter(filter *models.QueryFilter) error {
+	switch filter.Op {
+	case "=", "!=", "<", "<=", ">", ">=":
+		value, err := filterValue(filter.Column, filter.Value)
+		if err != nil {
+			return err
+		}
+		filter.Value = value
+	case "in":
+		list, ok := filter.Value.([]any)
+		if !ok || len(list) > maxAdHocInValues {
+			return fmt.Errorf("in takes an array of at most %d values", maxAdHocInValues)
+		}
+		for i, item := range list {
+			value, err := filterValue(filter.Column, item)
+			if err != nil {
+				return err
+			}
+			list[i] = value
+		}
+	default:
+		return fmt.Errorf("unknown operator %q", filter.Op)
+	}
+	return nil
+}
+
+func filterValue(column string, value any) (any, error) {
+	switch value := value.(type) {
+	case string:
+		if strings.HasSuffix(column, "_at") {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s takes RFC 3339 times", column)
+			}
+			return at.UTC(), nil
+		}
+		return value, nil
+	case float64, bool:
+		return value, nil
+	}
+	return nil, fmt.Errorf("%s: value must be a string, number or boolean", column)
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const maxRangeRows = 200000
+
+var ErrNoData = errors.New("no metrics in the requested range")
+
+// errRangeFull stops a TrendRange walk once maxRangeRows have been read.
+var errRangeFull = errors.New("range row limit reached")
+
+func (s *MetricsService) series(ctx context.Context, key string, from, to time.Time) ([]models.Metrics, []float64, error) {
+	if !s.known(ctx, key) {
+		return nil, nil, ErrUnknownMetric
+	}
+	points, err := s.store.MetricsBetween(ctx, from, to, maxRangeRows)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.derived.Apply(ctx, points)
+	// Snapshots a derived metric could not be computed for are skipped.
+	kept := points[:0]
+	values := make([]float64, 0, len(points))
+	for _, point := range points {
+		if value, ok := point.Value(key); ok {
+			kept = append(kept, point)
+			values = append(values, value)
+		}
+	}
+	return kept, values, nil
+}
+
+// Series returns one metric over [from, to]. When there are more than
+// maxPoints snapshots, consecutive ones are averaged into maxPoints buckets
+// of equal duration. Snapshots are streamed, so only the output is held in
+// memory.
+func (s *MetricsService) Series(ctx context.Context, key string, from, to time.Time, maxPoints int) ([]models.MetricPoint, error) {
+	if !s.known(ctx, key) {
+		return nil, ErrUnknownMetric
+	}
+	step := time.Nanosecond
+	if maxPoints > 0 && to.Sub(from) >= time.Duration(maxPoints) {
+		step = to.Sub(from) / time.Duration(maxPoints)
+	}
+	raw := []models.MetricPoint{}
+	var out []models.MetricPoint
+	var sum float64
+	var count int
+	var bucket time.Time
+	add := func(point models.MetricPoint) {
+		start := from.Add(point.Timestamp.Sub(from) / step * step)
+		if count > 0 && !start.Equal(bucket) {
+			out = append(out, models.MetricPoint{Timestamp: bucket, Value: sum / float64(count)})
+			sum, count = 0, 0
+		}
+		bucket = start
+		sum += point.Value
+		count++
+	}
+	err := s.TrendRange(ctx, from, to, func(metrics models.Metrics) error {
+		value, ok := metrics.Value(key)
+		if !ok {
+			return nil
+		}
+		point := models.MetricPoint{Timestamp: metrics.CreatedAt, Value: value}
+		if out != nil {
+			add(point)
+			return nil
+		}
+		raw = append(raw, point)
+		switch {
+		case maxPoints > 0 && len(raw) > maxPoints:
+			out = make([]models.MetricPoint, 0, maxPoints)
+			for _, point := range raw {
+				add(point)
+			}
+			raw = nil
+		case maxPoints <= 0 && len(raw) >= maxRangeRows:
+			return errRangeFull
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRangeFull) {
+		return nil, err
+	}
+	if out == nil {
+		return raw, nil
+	}
+	if count > 0 {
+		out = append(out, models.MetricPoint{Timestamp: bucket, Value: sum / float64(count)})
+	}
+	return out, nil
+}
+
+func (s *MetricsService) Distribution(ctx context.Context, key string, from, to time.Time, buckets int) (models.Distribution, error) {
+	_, values, err := s.series(ctx, key, from, to)
+	if err != nil {
+		return models.Distribution{}, err
+	}
+	latest, _, err := s.Latest(ctx)
+	if err != nil {
+		return models.Distribution{}, err
+	}
+	current, _ := latest.Value(key)
+
+	dist := models.Distribution{
+		Metric:      key,
+		From:        from,
+		To:          to,
+		Samples:     len(values),
+		Current:     current,
+		Percentiles: map[string]float64{},
+		Buckets:     []models.HistogramBucket{},
+	}
+	if len(values) == 0 {
+		return dist, nil
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	dist.Min = sorted[0]
+	dist.Max = sorted[len(sorted)-1]
+	dist.Mean = mean(sorted)
+	for _, p := range []float64{50, 90, 95, 99} {
+		dist.Percentiles["p"+formatFloat(p, 0)] = percentile(sorted, p)
+	}
+	dist.CurrentRank = float64(sort.SearchFloat64s(sorted, math.Nextafter(current, math.Inf(1)))) / float64(len(sorted)) * 100
+	dist.Buckets = histogram(sorted, buckets)
+	return dist, nil
+}
+
+func histogram(sorted []float64, buckets int) []models.HistogramBucket {
+	low, high := sorted[0], sorted[len(sorted)-1]
+	if high == low {
+		return []models.HistogramBucket{{Lower: low, Upper: high, Count: len(sorted)}}
+	}
+	width := (high - low) / float64(buckets)
+	out := make([]models.HistogramBucket, buckets)
+	for i := range out {
+		out[i].Lower = low + width*float64(i)
+		out[i].Upper = low + width*float64(i+1)
+	}
+	for _, value := range sorted {
+		idx := int((value - low) / width)
+		if idx >= buckets {
+			idx = buckets - 1
+		}
+		out[idx].Count++
+	}
+	return out
+}
+
+// percentile expects sorted input and interpolates between closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	frac := rank - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*frac
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// Correlate computes Pearson r between x and y, plus r for y shifted
+// 1..maxLag snapshots behind x, so a positive best lag means x leads y.
+func (s *MetricsService) Correlate(ctx context.Context, xKey, yKey string, from, to time.Time, maxLag int) (models.Correlation, error) {
+	if !s.known(ctx, yKey) {
+		return models.Correlation{}, ErrUnknownMetric
+	}
+	points, xs, err := s.series(ctx, xKey, from, to)
+	if err != nil {
+		return models.Correlation{}, err
+	}
+	ys := make([]float64, len(points))
+	for i, point := range points {
+		ys[i], _ = point.Value(yKey)
+	}
+
+	result := models.Correlation{
+		X:       xKey,
+		Y:       yKey,
+		From:    from,
+		To:      to,
+		Samples: len(points),
+		Pearson: pearson(xs, ys),
+		Lags:    []models.LagCorrelation{},
+	}
+	step := medianInterval(points)
+	for lag := 0; lag <= maxLag && lag < len(points)-2; lag++ {
+		entry := models.LagCorrelation{
+			Lag:         lag,
+			LagSeconds:  step.Seconds() * float64(lag),
+			Correlation: pearson(xs[:len(xs)-lag], ys[lag:]),
+			Samples:     len(xs) - lag,
+		}
+		result.Lags = append(result.Lags, entry)
+		if result.BestLag == nil || math.Abs(entry.Correlation) > math.Abs(result.BestLag.Correlation) {
+			best := entry
+			result.BestLag = &best
+		}
+	}
+	return result, nil
+}
+
+func pearson(xs, ys []float64) float64 {
+	if len(xs) < 2 || len(xs) != len(ys) {
+		return 0
+	}
+	mx, my := mean(xs), mean(ys)
+	var cov, vx, vy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+func medianInterval(points []models.Metrics) time.Duration {
+	if len(points) < 2 {
+		return 0
+	}
+	gaps := make([]float64, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		gaps = append(gaps, float64(points[i].CreatedAt.Sub(points[i-1].CreatedAt)))
+	}
+	sort.Float64s(gaps)
+	return time.Duration(percentile(gaps, 50))
+}
+
+// Heatmap averages a metric per weekday and hour as observed in loc.
+func (s *MetricsService) Heatmap(ctx context.Context, key string, from, to time.Time, loc *time.Location) ([]models.HeatmapCell, error) {
+	if !s.known(ctx, key) {
+		return nil, ErrUnknownMetric
+	}
+	buckets, err := s.store.QuarterHourTotals(ctx, key, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var sums [7][24]models.MetricBucket
+	for _, bucket := range buckets {
+		local := bucket.Start.In(loc)
+		cell := &sums[local.Weekday()][local.Hour()]
+		cell.Sum += bucket.Sum
+		cell.Count += bucket.Count
+	}
+	cells := []models.HeatmapCell{}
+	for weekday := range sums {
+		for hour, sum := range sums[weekday] {
+			if sum.Count == 0 {
+				continue
+			}
+			cells = append(cells, models.HeatmapCell{
+				Weekday: weekday,
+				Hour:    hour,
+				Average: sum.Sum / float64(sum.Count),
+				Samples: sum.Count,
+			})
+		}
+	}
+	return cells, nil
+}
+
+// downsample keeps n points spread evenly across points, always including the
+// first and last.
+func downsample(points []models.Metrics, n int) []models.Metrics {
+	if len(points) <= n || n < 2 {
+		return points
+	}
+	out := make([]models.Metrics, 0, n)
+	step := float64(len(points)-1) / float64(n-1)
+	for i := 0; i < n; i++ {
+		out = append(out, points[int(math.Round(step*float64(i)))])
+	}
+	return out
+}
+
+// SnapshotsAt returns the snapshots in effect at at1 and at2.
+func (s *MetricsService) SnapshotsAt(ctx context.Context, at1, at2 time.Time) (models.Metrics, models.Metrics, error) {
+	from, err := s.store.MetricsAt(ctx, at1)
+	if err != nil {
+		return models.Metrics{}, models.Metrics{}, err
+	}
+	to, err := s.store.MetricsAt(ctx, at2)
+	if err != nil {
+		return models.Metrics{}, models.Metrics{}, err
+	}
+	return s.derive(ctx, from), s.derive(ctx, to), nil
+}
+
+// Diff computes per-metric deltas, leaving out the keys in omit.
+func Diff(at1, at2 time.Time, from, to models.Metrics, omit []string) models.MetricsDiff {
+	skipped := make(map[string]bool, len(omit))
+	for _, key := range omit {
+		skipped[key] = true
+	}
+	diff := models.MetricsDiff{At1: at1, At2: at2, From: from, To: to, Deltas: map[string]models.MetricDelta{}}
+	for _, key := range models.MetricKeys {
+		if skipped[key] {
+			continue
+		}
+		a, _ := from.Value(key)
+		b, _ := to.Value(key)
+		delta := models.MetricDelta{From: a, To: b, Change: b - a}
+		if a != 0 {
+			percent := (b - a) / math.Abs(a) * 100
+			delta.Percent = &percent
+		}
+		diff.Deltas[key] = delta
+	}
+	return diff
+}
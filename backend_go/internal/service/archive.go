@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/archive"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+// archiveDayLimit caps the snapshots read for one day; a day with more
+// fails the run rather than archiving it partially.
+const archiveDayLimit = 500000
+
+// ArchiveService copies snapshots to object storage before retention
+// deletes them, one gzip CSV object per UTC day and run.
+type ArchiveService struct {
+	store  *store.Store
+	bucket *archive.S3Client
+	prefix string
+}
+
+type RestoreResult struct {
+	Objects  int `json:"objects"`
+	Restored int `json:"restored"`
+	Skipped  int `json:"skipped"`
+}
+
+func NewArchiveService(store *store.Store, bucket *archive.S3Client, prefix string) *ArchiveService {
+	return &ArchiveService{store: store, bucket: bucket, prefix: prefix}
+}
+
+// PruneBefore archives and then deletes snapshots older than cutoff. Each
+// day is deleted only after its object is stored, so a failed upload stops
+// the run with nothing lost.
+func (s *ArchiveService) PruneBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var deleted int64
+	for {
+		oldest, err := s.store.MetricsBetween(ctx, time.Unix(0, 0), cutoff.Add(-time.Nanosecond), 1)
+		if err != nil {
+			return deleted, err
+		}
+		if len(oldest) == 0 {
+			return deleted, nil
+		}
+		day := oldest[0].CreatedAt.UTC().Truncate(24 * time.Hour)
+		end := day.Add(24 * time.Hour)
+		if end.After(cutoff) {
+			end = cutoff
+		}
+		points, err := s.store.MetricsBetween(ctx, day, end.Add(-time.Nanosecond), archiveDayLimit)
+		if err != nil {
+			return deleted, err
+		}
+		if len(points) >= archiveDayLimit {
+			return deleted, fmt.Errorf("archive %s: more than %d snapshots", day.Format(time.DateOnly), archiveDayLimit)
+		}
+		first, last := points[0].CreatedAt, points[len(points)-1].CreatedAt
+		body, err := archive.EncodeMetrics(points)
+		if err != nil {
+			return deleted, err
+		}
+		key := fmt.Sprintf("%smetrics/%s/%d-%d.csv.gz", s.prefix, day.Format("2006/01/02"), first.Unix(), last.Unix())
+		if err := s.bucket.Put(ctx, key, "application/gzip", body); err != nil {
+			return deleted, fmt.Errorf("archive %s: %w", key, err)
+		}
+		n, err := s.store.DeleteMetricsBetween(ctx, first, last)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+		if n == 0 {
+			return deleted, fmt.Errorf("archive %s: no snapshots deleted", key)
+		}
+		log.Printf("archived %d metric snapshots to %s", len(points), key)
+	}
+}
+
+// List returns the archive objects under prefix, relative to the configured
+// archive prefix.
+func (s *ArchiveService) List(ctx context.Context, prefix string) ([]archive.Object, error) {
+	return s.bucket.List(ctx, s.prefix+"metrics/"+strings.TrimPrefix(prefix, "/"))
+}
+
+// Restore loads every archive object under prefix (e.g. "2024/03" for one
+// month) back into metrics_snapshot. Snapshots already present with the same
+// timestamp are skipped, so restoring twice is harmless.
+func (s *ArchiveService) Restore(ctx context.Context, prefix string) (RestoreResult, error) {
+	var result RestoreResult
+	objects, err := s.List(ctx, prefix)
+	if err != nil {
+		return result, err
+	}
+	for _, object := range objects {
+		if !strings.HasSuffix(object.Key, ".csv.gz") {
+			continue
+		}
+		data, err := s.bucket.Get(ctx, object.Key)
+		if err != nil {
+			return result, err
+		}
+		points, err := archive.DecodeMetrics(data)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", object.Key, err)
+		}
+		result.Objects++
+		if len(points) == 0 {
+			continue
+		}
+		err = s.store.WithTx(ctx, func(tx *store.Store) error {
+			missing, err := missingSnapshots(ctx, tx, points)
+			if err != nil {
+				return err
+			}
+			for start := 0; start < len(missing); start += importBatchSize {
+				end := min(start+importBatchSize, len(missing))
+				if err := tx.InsertMetricsBatch(ctx, missing[start:end]); err != nil {
+					return err
+				}
+			}
+			result.Skipped += len(points) - len(missing)
+			result.Restored += len(missing)
+			return nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", object.Key, err)
+		}
+	}
+	return result, nil
+}
+
+// missingSnapshots drops points whose timestamp is already stored.
+func missingSnapshots(ctx context.Context, db *store.Store, points []models.Metrics) ([]models.Metrics, error) {
+	existing, err := db.MetricsBetween(ctx, points[0].CreatedAt, points[len(points)-1].CreatedAt, len(points)*2+1)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int64]bool, len(existing))
+	for _, point := range existing {
+		seen[point.CreatedAt.UnixNano()] = true
+	}
+	out := make([]models.Metrics, 0, len(points))
+	for _, point := range points {
+		if !seen[point.CreatedAt.UnixNano()] {
+			out = append(out, point)
+		}
+	}
+	return out, nil
+}
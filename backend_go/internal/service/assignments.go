@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+var (
+	ErrInvalidAssignment = errors.New("invalid assignment")
+	ErrNotAssignee       = errors.New("only the assignee or an admin can change this assignment")
+)
+
+const maxAssignments = 200
+
+var assignmentStatuses = map[string]bool{
+	models.AssignmentOpen:       true,
+	models.AssignmentInProgress: true,
+	models.AssignmentDone:       true,
+}
+
+// Assignment names the user an insight is assigned to, by id or username.
+// With TeamID the assignment is scoped to that team, and the user must
+// belong to it or to a team below it.
+type Assignment struct {
+	UserID   int64
+	Username string
+	DueAt    *time.Time
+	Status   string
+	TeamID   *int64
+}
+
+// Assign makes an insight a task for a user, replacing any earlier
+// assignment, and tells a new assignee through EventInsightAssigned. Status
+// defaults to open.
+func (s *InsightsService) Assign(ctx context.Context, insightID int64, in Assignment, by string) (models.InsightAssignment, error) {
+	insight, err := s.store.InsightByID(ctx, insightID)
+	if err != nil {
+		return models.InsightAssignment{}, err
+	}
+	if insight.DeletedAt != nil {
+		return models.InsightAssignment{}, store.ErrNotFound
+	}
+	if in.Status == "" {
+		in.Status = models.AssignmentOpen
+	}
+	if !assignmentStatuses[in.Status] {
+		return models.InsightAssignment{}, fmt.Errorf("%w: status must be open, in-progress or done", ErrInvalidAssignment)
+	}
+	var user models.User
+	switch {
+	case in.UserID != 0:
+		user, err = s.store.UserByID(ctx, in.UserID)
+	case in.Username != "":
+		user, err = s.store.UserByUsername(ctx, in.Username)
+	default:
+		return models.InsightAssignment{}, fmt.Errorf("%w: user_id or username is required", ErrInvalidAssignment)
+	}
+	if errors.Is(err, store.ErrNotFound) || (err == nil && user.Disabled) {
+		return models.InsightAssignment{}, fmt.Errorf("%w: no active user %s", ErrInvalidAssignment, assigneeName(in))
+	}
+	if err != nil {
+		return models.InsightAssignment{}, err
+	}
+	if err := s.checkAssignmentTeam(ctx, in.TeamID, user); err != nil {
+		return models.InsightAssignment{}, err
+	}
+	a := models.InsightAssignment{
+		InsightID:  insightID,
+		UserID:     user.ID,
+		Status:     in.Status,
+		DueAt:      in.DueAt,
+		AssignedBy: by,
+		TeamID:     in.TeamID,
+	}
+	if a.Status == models.AssignmentDone {
+		now := time.Now()
+		a.DoneAt = &now
+	}
+	previous, err := s.store.AssignmentByInsight(ctx, insightID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return models.InsightAssignment{}, err
+	}
+	// The assignee hears about it once, not on every change of due date.
+	notice := err != nil || previous.UserID != user.ID
+	err = s.store.WithTx(ctx, func(tx *store.Store) error {
+		if err := tx.SaveAssignment(ctx, a); err != nil || !notice {
+			return err
+		}
+		return tx.EnqueueEvent(ctx, models.EventInsightAssigned, models.AssignmentNotice{
+			InsightID:  insightID,
+			UserID:     user.ID,
+			Username:   user.Username,
+			AssignedBy: by,
+			DueAt:      in.DueAt,
+			Title:      insight.Title,
+			Message:    insight.Message,
+			Severity:   insight.Severity,
+		})
+	})
+	if err != nil {
+		return models.InsightAssignment{}, err
+	}
+	return s.Assignment(ctx, insightID)
+}
+
+func (s *InsightsService) checkAssignmentTeam(ctx context.Context, teamID *int64, user models.User) error {
+	if teamID == nil {
+		return nil
+	}
+	team, err := s.store.TeamByID(ctx, *teamID)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("%w: team_id: no team %d", ErrInvalidAssignment, *teamID)
+	}
+	if err != nil {
+		return err
+	}
+	teams, err := s.store.ListTeams(ctx, team.OrgID)
+	if err != nil {
+		return err
+	}
+	memberships, err := s.store.TeamMembers(ctx, 0, user.ID)
+	if err != nil {
+		return err
+	}
+	scope := subtree(teams, team.ID)
+	for _, m := range memberships {
+		if slices.Contains(scope, m.TeamID) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in team %s", ErrInvalidAssignment, user.Username, team.Name)
+}
+
+func assigneeName(in Assignment) string {
+	if in.Username != "" {
+		return in.Username
+	}
+	return fmt.Sprintf("#%d", in.UserID)
+}
+
+func (s *InsightsService) Assignment(ctx context.Context, insightID int64) (models.InsightAssignment, error) {
+	a, err := s.store.AssignmentByInsight(ctx, insightID)
+	if err != nil {
+		return models.InsightAssignment{}, err
+	}
+	markOverdue(&a, time.Now())
+	return a, nil
+}
+
+// SetAssignmentStatus moves an assignment along the workflow. Only the
+// assignee or an admin may do so; any status can follow any other, so a done
+// task can be reopened.
+func (s *InsightsService) SetAssignmentStatus(ctx context.Context, insightID int64, status string, principal models.Principal) (models.InsightAssignment, error) {
+	if !assignmentStatuses[status] {
+		return models.InsightAssignment{}, fmt.Errorf("%w: status must be open, in-progress or done", ErrInvalidAssignment)
+	}
+	a, err := s.store.AssignmentByInsight(ctx, insightID)
+	if err != nil {
+		return models.InsightAssignment{}, err
+	}
+	if a.UserID != principal.UserID && principal.Role != models.RoleAdmin {
+		return models.InsightAssignment{}, ErrNotAssignee
+	}
+	var doneAt *time.Time
+	switch {
+	case status != models.AssignmentDone:
+	case a.DoneAt != nil:
+		doneAt = a.DoneAt
+	default:
+		now := time.Now()
+		doneAt = &now
+	}
+	if err := s.store.SetAssignmentStatus(ctx, insightID, status, doneAt); err != nil {
+		return models.InsightAssignment{}, err
+	}
+	return s.Assignment(ctx, insightID)
+}
+
+func (s *InsightsService) Unassign(ctx context.Context, insightID int64) error {
+	return s.store.DeleteAssignment(ctx, insightID)
+}
+
+// AssignmentsFor lists a user's assignments, by default those not yet done.
+func (s *InsightsService) AssignmentsFor(ctx context.Context, userID int64, statuses []string) ([]models.InsightAssignment, error) {
+	return s.listAssignments(statuses, func(statuses []string) ([]models.InsightAssignment, error) {
+		return s.store.UserAssignments(ctx, userID, statuses, maxAssignments)
+	})
+}
+
+// TeamAssignments is AssignmentsFor for the assignments scoped to any of
+// teamIDs.
+func (s *InsightsService) TeamAssignments(ctx context.Context, teamIDs []int64, statuses []string) ([]models.InsightAssignment, error) {
+	return s.listAssignments(statuses, func(statuses []string) ([]models.InsightAssignment, error) {
+		return s.store.TeamAssignments(ctx, teamIDs, statuses, maxAssignments)
+	})
+}
+
+func (s *InsightsService) listAssignments(statuses []string, list func([]string) ([]models.InsightAssignment, error)) ([]models.InsightAssignment, error) {
+	if len(statuses) == 0 {
+		statuses = []string{models.AssignmentOpen, models.AssignmentInProgress}
+	}
+	for _, status := range statuses {
+		if !assignmentStatuses[status] {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidAssignment, status)
+		}
+	}
+	items, err := list(statuses)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.InsightAssignment{}
+	}
+	now := time.Now()
+	for i := range items {
+		markOverdue(&items[i], now)
+	}
+	return items, nil
+}
+
+func markOverdue(a *models.InsightAssignment, now time.Time) {
+	a.Overdue = a.Status != models.AssignmentDone && a.DueAt != nil && a.DueAt.Before(now)
+}
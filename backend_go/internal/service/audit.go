@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditService keeps the audit log of logins, lockouts and other
+// security-relevant actions.
+type AuditService struct {
+	store *store.Store
+}
+
+func NewAuditService(store *store.Store) *AuditService {
+	return &AuditService{store: store}
+}
+
+// Record writes event to the audit log. A failure is logged rather than
+// returned so that auditing never blocks the action itself. Record on a nil
+// service does nothing.
+func (s *AuditService) Record(ctx context.Context, event models.AuditEvent) {
+	if s == nil {
+		return
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	event.Actor = truncateRunes(event.Actor, 64)
+	event.Detail = truncateRunes(event.Detail, 255)
+	if err := s.store.InsertAuditEvent(ctx, event); err != nil {
+		log.Printf("audit %s by %q: %v", event.Action, event.Actor, err)
+	}
+}
+
+// List returns the newest events matching q, 100 unless q asks for up to
+// 1000.
+func (s *AuditService) List(ctx context.Context, q models.AuditQuery) ([]models.AuditEvent, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultAuditLimit
+	}
+	q.Limit = min(q.Limit, maxAuditLimit)
+	items, err := s.store.ListAuditEvents(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.AuditEvent{}
+	}
+	return items, nil
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/auth"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+// principalCacheTTL bounds how long a revoked session can keep working on
+// other instances; revocations made on this instance take effect at once.
+const principalCacheTTL = 15 * time.Second
+
+const (
+	defaultMaxLoginFailures    = 5
+	defaultLockout             = 15 * time.Minute
+	defaultMaxClientFailures   = 20
+	defaultClientFailureWindow = 15 * time.Minute
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUnauthenticated    = errors.New("authentication required")
+	ErrInvalidUser        = errors.New("invalid user")
+	ErrOTPRequired        = errors.New("one-time code required")
+	ErrInvalidOTP         = errors.New("invalid or reused one-time code")
+	ErrTOTPEnabled        = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotSetUp       = errors.New("two-factor setup has not been started")
+)
+
+type cachedPrincipal struct {
+	principal models.Principal
+	until     time.Time
+}
+
+type AuthService struct {
+	store      *store.Store
+	signer     *auth.Signer
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	totpIssuer string
+	totpRoles  map[string]bool
+	policy     *PasswordPolicy
+	audit      *AuditService
+
+	maxFailures    int
+	lockout        time.Duration
+	clientFailures *failureWindow
+
+	mu    sync.Mutex
+	cache map[int64]cachedPrincipal
+}
+
+func NewAuthService(store *store.Store, signer *auth.Signer, accessTTL, refreshTTL time.Duration) *AuthService {
+	return &AuthService{
+		store:      store,
+		signer:     signer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		cache:      map[int64]cachedPrincipal{},
+		totpIssuer: "MyDashboard",
+		totpRoles:  map[string]bool{},
+		policy:     NewPasswordPolicy(8, 0),
+
+		maxFailures:    defaultMaxLoginFailures,
+		lockout:        defaultLockout,
+		clientFailures: newFailureWindow(defaultMaxClientFailures, defaultClientFailureWindow),
+	}
+}
+
+// WithTOTPPolicy sets the issuer shown in authenticator apps and the roles
+// that must enroll in two-factor authentication before using the API.
+func (s *AuthService) WithTOTPPolicy(issuer string, requiredRoles []string) *AuthService {
+	if issuer != "" {
+		s.totpIssuer = issuer
+	}
+	for _, role := range requiredRoles {
+		s.totpRoles[role] = true
+	}
+	return s
+}
+
+// WithPasswordPolicy sets the policy new passwords must meet.
+func (s *AuthService) WithPasswordPolicy(policy *PasswordPolicy) *AuthService {
+	s.policy = policy
+	return s
+}
+
+// WithAudit records logins, lockouts and rejected passwords in audit.
+func (s *AuthService) WithAudit(audit *AuditService) *AuthService {
+	s.audit = audit
+	return s
+}
+
+// WithLockout locks an account for lockout after maxFailures failed logins
+// in a row; zero maxFailures never locks.
+func (s *AuthService) WithLockout(maxFailures int, lockout time.Duration) *AuthService {
+	s.maxFailures, s.lockout = maxFailures, lockout
+	return s
+}
+
+// WithClientThrottle refuses logins from an address that failed maxFailures
+// times within window; zero maxFailures turns this off.
+func (s *AuthService) WithClientThrottle(maxFailures int, window time.Duration) *AuthService {
+	s.clientFailures = newFailureWindow(maxFailures, window)
+	return s
+}
+
+func (s *AuthService) PasswordPolicy() *PasswordPolicy {
+	return s.policy
+}
+
+// Login requires otp once the user has enabled two-factor authentication.
+// While the account or the client address is locked out it returns a
+// *LockoutError without checking the password.
+func (s *AuthService) Login(ctx context.Context, username, password, otp, userAgent, ip string) (models.TokenPair, error) {
+	username = strings.TrimSpace(username)
+	host := clientHost(ip)
+	now := time.Now()
+	if until, blocked := s.clientFailures.blockedUntil(host, now); blocked {
+		s.audit.Record(ctx, models.AuditEvent{Action: models.AuditLoginThrottled, Actor: username, IP: host, Detail: "too many failures from this address"})
+		return models.TokenPair{}, &LockoutError{Until: until}
+	}
+	user, err := s.store.UserByUsername(ctx, username)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.TokenPair{}, s.loginFailed(ctx, nil, username, host, "unknown user")
+	}
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		s.audit.Record(ctx, models.AuditEvent{Action: models.AuditLoginThrottled, Actor: username, UserID: &user.ID, IP: host, Detail: "account locked"})
+		return models.TokenPair{}, &LockoutError{Until: *user.LockedUntil}
+	}
+	ok, err := auth.CheckPassword(user.PasswordHash, password)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if !ok {
+		return models.TokenPair{}, s.loginFailed(ctx, &user, username, host, "wrong password")
+	}
+	if user.Disabled {
+		s.audit.Record(ctx, models.AuditEvent{Action: models.AuditLoginFailed, Actor: username, UserID: &user.ID, IP: host, Detail: "account disabled"})
+		return models.TokenPair{}, ErrInvalidCredentials
+	}
+	if user.TOTPEnabled {
+		if otp == "" {
+			return models.TokenPair{}, ErrOTPRequired
+		}
+		if err := s.useCode(ctx, user, otp); errors.Is(err, ErrInvalidOTP) {
+			if err := s.loginFailed(ctx, &user, username, host, "invalid one-time code"); !errors.Is(err, ErrInvalidCredentials) {
+				return models.TokenPair{}, err
+			}
+			return models.TokenPair{}, ErrInvalidOTP
+		} else if err != nil {
+			return models.TokenPair{}, err
+		}
+	}
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := s.store.ClearLoginFailures(ctx, user.ID); err != nil {
+			return models.TokenPair{}, err
+		}
+	}
+
+	refresh, err := auth.RandomToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	session, err := s.store.InsertSession(ctx, models.Session{
+		UserID:      user.ID,
+		RefreshHash: auth.HashToken(refresh),
+		UserAgent:   truncateRunes(userAgent, 255),
+		IP:          ip,
+		CreatedAt:   now,
+		LastUsedAt:  now,
+		ExpiresAt:   now.Add(s.refreshTTL),
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	s.audit.Record(ctx, models.AuditEvent{Action: models.AuditLoginSucceeded, Actor: username, UserID: &user.ID, IP: host})
+	return s.issue(user, session, refresh, now)
+}
+
+// loginFailed counts a failed login against the client address and, when
+// the username matched, the account. It returns ErrInvalidCredentials, or a
+// *LockoutError when this failure locked the account.
+func (s *AuthService) loginFailed(ctx context.Context, user *models.User, username, host, reason string) error {
+	now := time.Now()
+	s.clientFailures.add(host, now)
+	event := models.AuditEvent{Action: models.AuditLoginFailed, Actor: username, IP: host, Detail: reason}
+	if user == nil {
+		s.audit.Record(ctx, event)
+		return ErrInvalidCredentials
+	}
+	event.UserID = &user.ID
+	s.audit.Record(ctx, event)
+	updated, err := s.store.RecordLoginFailure(ctx, user.ID, s.maxFailures, now.Add(s.lockout))
+	if err != nil {
+		return err
+	}
+	if updated.LockedUntil == nil || !updated.LockedUntil.After(now) {
+		return ErrInvalidCredentials
+	}
+	s.audit.Record(ctx, models.AuditEvent{
+		Action: models.AuditAccountLocked,
+		Actor:  username,
+		UserID: &user.ID,
+		IP:     host,
+		Detail: fmt.Sprintf("%d failed logins; locked until %s", s.maxFailures, updated.LockedUntil.UTC().Format(time.RFC3339)),
+	})
+	return &LockoutError{Until: *updated.LockedUntil}
+}
+
+// Unlock lifts a user's lockout and clears their failure count.
+func (s *AuthService) Unlock(ctx context.Context, userID int64, by string) (models.User, error) {
+	if _, err := s.store.UserByID(ctx, userID); err != nil {
+		return models.User{}, err
+	}
+	if err := s.store.ClearLoginFailures(ctx, userID); err != nil {
+		return models.User{}, err
+	}
+	s.audit.Record(ctx, models.AuditEvent{Action: models.AuditAccountUnlocked, Actor: by, UserID: &userID})
+	return s.store.UserByID(ctx, userID)
+}
+
+// Refresh redeems a refresh token once and returns a new pair for the same
+// session. Presenting an already rotated token fails.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	hash := auth.HashToken(refreshToken)
+	session, err := s.store.SessionByRefreshHash(ctx, hash)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.TokenPair{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || !now.Before(session.ExpiresAt) {
+		return models.TokenPair{}, ErrUnauthenticated
+	}
+	user, err := s.store.UserByID(ctx, session.UserID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if user.Disabled {
+		return models.TokenPair{}, ErrUnauthenticated
+	}
+
+	refresh, err := auth.RandomToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	session.RefreshHash = auth.HashToken(refresh)
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(s.refreshTTL)
+	err = s.store.RotateRefresh(ctx, session.ID, hash, session.RefreshHash, now, session.ExpiresAt)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.TokenPair{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return s.issue(user, session, refresh, now)
+}
+
+func (s *AuthService) issue(user models.User, session models.Session, refresh string, now time.Time) (models.TokenPair, error) {
+	access, err := s.signer.Sign(auth.Claims{
+		SessionID: session.ID,
+		UserID:    user.ID,
+		Role:      user.Role,
+		ExpiresAt: now.Add(s.accessTTL).Unix(),
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	session.Current = true
+	return models.TokenPair{
+		AccessToken:        access,
+		RefreshToken:       refresh,
+		TokenType:          "Bearer",
+		ExpiresIn:          int(s.accessTTL.Seconds()),
+		Session:            session,
+		User:               user,
+		EnrollmentRequired: s.totpRoles[user.Role] && !user.TOTPEnabled,
+		IssuedAt:           now,
+	}, nil
+}
+
+// Authenticate verifies an access token and checks that its session is still
+// active, so revoked sessions stop working before the token expires.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (models.Principal, error) {
+	claims, err := s.signer.Verify(accessToken)
+	if err != nil {
+		return models.Principal{}, err
+	}
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[claims.SessionID]
+	s.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		return cached.principal, nil
+	}
+
+	principal, err := s.store.ActivePrincipal(ctx, claims.SessionID, now)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.Principal{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return models.Principal{}, err
+	}
+	principal.EnrollmentRequired = s.totpRoles[principal.Role] && !principal.TOTPEnabled
+	s.mu.Lock()
+	for id, entry := range s.cache {
+		if now.After(entry.until) {
+			delete(s.cache, id)
+		}
+	}
+	s.cache[claims.SessionID] = cachedPrincipal{principal: principal, until: now.Add(principalCacheTTL)}
+	s.mu.Unlock()
+	return principal, nil
+}
+
+func (s *AuthService) Sessions(ctx context.Context, principal models.Principal) ([]models.Session, error) {
+	sessions, err := s.store.ListUserSessions(ctx, principal.UserID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == principal.SessionID
+	}
+	return sessions, nil
+}
+
+func (s *AuthService) RevokeSession(ctx context.Context, principal models.Principal, sessionID int64) error {
+	if err := s.store.RevokeSession(ctx, principal.UserID, sessionID, time.Now()); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.cache, sessionID)
+	s.mu.Unlock()
+	return nil
+}
+
+// SetupTOTP starts enrollment with a fresh secret. It stays inactive until a
+// code is confirmed through VerifyTOTP; calling it again replaces the secret.
+func (s *AuthService) SetupTOTP(ctx context.Context, principal models.Principal) (models.TOTPEnrollment, error) {
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	err = s.store.SetTOTPSecret(ctx, principal.UserID, secret)
+	if errors.Is(err, store.ErrConflict) {
+		return models.TOTPEnrollment{}, ErrTOTPEnabled
+	}
+	if err != nil {
+		return models.TOTPEnrollment{}, err
+	}
+	return models.TOTPEnrollment{
+		Secret: secret,
+		URL:    auth.TOTPURL(s.totpIssuer, principal.Username, secret),
+	}, nil
+}
+
+// VerifyTOTP confirms enrollment with a code from the authenticator app.
+func (s *AuthService) VerifyTOTP(ctx context.Context, principal models.Principal, code string) error {
+	user, err := s.store.UserByID(ctx, principal.UserID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPSecret == "" {
+		return ErrTOTPNotSetUp
+	}
+	if err := s.useCode(ctx, user, code); err != nil {
+		return err
+	}
+	s.forgetUser(user.ID)
+	return nil
+}
+
+func (s *AuthService) useCode(ctx context.Context, user models.User, code string) error {
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return ErrInvalidOTP
+	}
+	err := s.store.UseTOTPStep(ctx, user.ID, step)
+	if errors.Is(err, store.ErrConflict) {
+		return ErrInvalidOTP
+	}
+	return err
+}
+
+func (s *AuthService) forgetUser(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, entry := range s.cache {
+		if entry.principal.UserID == userID {
+			delete(s.cache, id)
+		}
+	}
+}
+
+func (s *AuthService) CreateUser(ctx context.Context, user models.User, password string) (models.User, error) {
+	user, err := newUser(ctx, user, password, s.policy, s.audit)
+	if err != nil {
+		return models.User{}, err
+	}
+	return s.store.InsertUser(ctx, user)
+}
+
+// newUser checks a user about to be created and hashes their password. A
+// password the policy rejects is recorded in audit.
+func newUser(ctx context.Context, user models.User, password string, policy *PasswordPolicy, audit *AuditService) (models.User, error) {
+	user.Username = strings.TrimSpace(user.Username)
+	if user.Username == "" || len(user.Username) > 64 {
+		return models.User{}, fmt.Errorf("%w: username must be 1-64 characters", ErrInvalidUser)
+	}
+	if err := policy.Check(user.Username, password); err != nil {
+		audit.Record(ctx, models.AuditEvent{Action: models.AuditPasswordRejected, Actor: user.Username, Detail: err.Error()})
+		return models.User{}, err
+	}
+	if user.Role == "" {
+		user.Role = models.RoleViewer
+	}
+	switch user.Role {
+	case models.RoleAdmin, models.RoleAnalyst, models.RoleViewer:
+	default:
+		return models.User{}, fmt.Errorf("%w: unknown role %q", ErrInvalidUser, user.Role)
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+	user.PasswordHash = hash
+	return user, nil
+}
+
+func (s *AuthService) Users(ctx context.Context) ([]models.User, error) {
+	return s.store.ListUsers(ctx)
+}
+
+// EraseUserData handles a data-subject erasure request: the insights the
+// user wrote, their assignments, audit trail, sessions, contact details and
+// profile are removed, and records that only name them are anonymised. The
+// account itself is kept; see store.EraseUserData for the full list.
+func (s *AuthService) EraseUserData(ctx context.Context, userID int64) (models.ErasureReport, error) {
+	user, err := s.store.UserByID(ctx, userID)
+	if err != nil {
+		return models.ErasureReport{}, err
+	}
+	report, err := s.store.EraseUserData(ctx, user)
+	if err != nil {
+		return models.ErasureReport{}, err
+	}
+	s.forgetUser(userID)
+	report.UserID = user.ID
+	report.Username = user.Username
+	report.ErasedAt = time.Now().UTC()
+	log.Printf("erased data for user %d: %d sessions, %d embed tokens, %d insights, %d assignments, %d audit entries",
+		user.ID, report.SessionsDeleted, report.EmbedTokensUnlinked, report.InsightsDeleted, report.AssignmentsDeleted, report.AuditEntriesDeleted)
+	return report, nil
+}
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+var ErrInvalidBacklogItem = errors.New("invalid backlog item")
+
+var agingBuckets = []models.AgingBucket{
+	{Label: "<1d", MinHours: 0, MaxHours: 24},
+	{Label: "1-3d", MinHours: 24, MaxHours: 72},
+	{Label: "3-7d", MinHours: 72, MaxHours: 168},
+	{Label: "7-14d", MinHours: 168, MaxHours: 336},
+	{Label: ">14d", MinHours: 336},
+}
+
+var backlogPriorities = map[string]bool{"low": true, "normal": true, "high": true}
+
+type BacklogService struct {
+	store *store.Store
+	sla   time.Duration
+}
+
+func NewBacklogService(store *store.Store, sla time.Duration) *BacklogService {
+	return &BacklogService{
+		store: store,
+		sla:   sla,
+	}
+}
+
+func (s *BacklogService) Open(ctx context.Context, item models.BacklogItem) (models.BacklogItem, error) {
+	item.Title = strings.TrimSpace(item.Title)
+	if item.Title == "" {
+		return models.BacklogItem{}, fmt.Errorf("%w: title is required", ErrInvalidBacklogItem)
+	}
+	if item.Priority == "" {
+		item.Priority = "normal"
+	}
+	if !backlogPriorities[item.Priority] {
+		return models.BacklogItem{}, fmt.Errorf("%w: priority must be low, normal or high", ErrInvalidBacklogItem)
+	}
+	if item.OpenedAt.IsZero() {
+		item.OpenedAt = time.Now()
+	}
+	if item.DueAt.IsZero() {
+		item.DueAt = item.OpenedAt.Add(s.sla)
+	}
+	return s.store.InsertBacklogItem(ctx, item)
+}
+
+func (s *BacklogService) Resolve(ctx context.Context, id int64) error {
+	return s.store.ResolveBacklogItem(ctx, id, time.Now())
+}
+
+// Aging counts items due within the next day as at risk.
+func (s *BacklogService) Aging(ctx context.Context) (models.BacklogAging, error) {
+	items, err := s.store.OpenBacklogItems(ctx)
+	if err != nil {
+		return models.BacklogAging{}, err
+	}
+	now := time.Now()
+	report := models.BacklogAging{
+		Open:        len(items),
+		Buckets:     append([]models.AgingBucket(nil), agingBuckets...),
+		GeneratedAt: now,
+	}
+	for _, item := range items {
+		age := now.Sub(item.OpenedAt).Hours()
+		if age > report.OldestAgeHours {
+			report.OldestAgeHours = age
+		}
+		for i := range report.Buckets {
+			bucket := &report.Buckets[i]
+			if age >= bucket.MinHours && (bucket.MaxHours == 0 || age < bucket.MaxHours) {
+				bucket.Count++
+				break
+			}
+		}
+		switch {
+		case now.After(item.DueAt):
+			report.SLABreached++
+		case item.DueAt.Sub(now) < 24*time.Hour:
+			report.SLAAtRisk++
+		}
+	}
+	return report, nil
+}
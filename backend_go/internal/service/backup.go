@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	backupFormat  = "mydashboard-backup"
+	backupVersion = 1
+	restoreBatch  = 500
+)
+
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// A backup is gzip-compressed JSON lines: a BackupManifest, then for each
+// table a store.TableHeader followed by one JSON array per row.
+type BackupManifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Tables    []string  `json:"tables"`
+}
+
+type BackupReport struct {
+	CreatedAt time.Time      `json:"created_at"`
+	Rows      map[string]int `json:"rows"`
+	// Skipped counts restored rows left out because the user they name does
+	// not exist, as when moving a backup to another environment.
+	Skipped map[string]int `json:"skipped,omitempty"`
+}
+
+type BackupService struct {
+	store *store.Store
+}
+
+func NewBackupService(store *store.Store) *BackupService {
+	return &BackupService{store: store}
+}
+
+// Write dumps metrics, insights and configuration tables to w.
+func (s *BackupService) Write(ctx context.Context, w io.Writer) (BackupReport, error) {
+	report := BackupReport{CreatedAt: time.Now().UTC(), Rows: map[string]int{}}
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	manifest := BackupManifest{
+		Format:    backupFormat,
+		Version:   backupVersion,
+		CreatedAt: report.CreatedAt,
+		Tables:    store.BackupTables,
+	}
+	if err := enc.Encode(manifest); err != nil {
+		return report, err
+	}
+	for _, table := range store.BackupTables {
+		err := s.store.DumpTable(ctx, table,
+			func(header store.TableHeader) error { return enc.Encode(header) },
+			func(row []any) error {
+				report.Rows[table]++
+				return enc.Encode(row)
+			})
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, zw.Close()
+}
+
+// Restore replaces the backed-up tables with the contents of r. Nothing
+// changes unless the whole archive loads.
+func (s *BackupService) Restore(ctx context.Context, r io.Reader) (BackupReport, error) {
+	report := BackupReport{Rows: map[string]int{}}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return report, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+	}
+	dec := json.NewDecoder(zr)
+	dec.UseNumber()
+
+	var manifest BackupManifest
+	if err := dec.Decode(&manifest); err != nil || manifest.Format != backupFormat {
+		return report, fmt.Errorf("%w: not a %s archive", ErrInvalidBackup, backupFormat)
+	}
+	if manifest.Version != backupVersion {
+		return report, fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, manifest.Version)
+	}
+	for _, table := range manifest.Tables {
+		if !slices.Contains(store.BackupTables, table) {
+			return report, fmt.Errorf("%w: unknown table %s", ErrInvalidBackup, table)
+		}
+	}
+	report.CreatedAt = manifest.CreatedAt
+
+	restore, err := s.store.BeginRestore(ctx, manifest.Tables)
+	if err != nil {
+		return report, err
+	}
+	defer restore.Rollback()
+
+	var header store.TableHeader
+	var timeColumns []int
+	var batch [][]any
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		inserted, err := restore.Insert(ctx, header.Table, header.Columns, batch)
+		report.Rows[header.Table] += inserted
+		if skipped := len(batch) - inserted; err == nil && skipped > 0 {
+			if report.Skipped == nil {
+				report.Skipped = map[string]int{}
+			}
+			report.Skipped[header.Table] += skipped
+		}
+		batch = batch[:0]
+		return err
+	}
+	for {
+		var line json.RawMessage
+		err := dec.Decode(&line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+		}
+		if len(line) > 0 && line[0] == '{' {
+			if err := flush(); err != nil {
+				return report, err
+			}
+			header = store.TableHeader{}
+			if err := json.Unmarshal(line, &header); err != nil || !slices.Contains(manifest.Tables, header.Table) {
+				return report, fmt.Errorf("%w: bad table header", ErrInvalidBackup)
+			}
+			timeColumns = timeColumns[:0]
+			for i, column := range header.Columns {
+				if slices.Contains(header.TimeColumns, column) {
+					timeColumns = append(timeColumns, i)
+				}
+			}
+			report.Rows[header.Table] = 0
+			continue
+		}
+		if header.Table == "" {
+			return report, fmt.Errorf("%w: row before table header", ErrInvalidBackup)
+		}
+		row, err := decodeBackupRow(line, timeColumns)
+		if err != nil {
+			return report, fmt.Errorf("%w: %s: %v", ErrInvalidBackup, header.Table, err)
+		}
+		batch = append(batch, row)
+		if len(batch) >= restoreBatch {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return report, err
+	}
+	return report, restore.Commit()
+}
+
+func decodeBackupRow(line json.RawMessage, timeColumns []int) ([]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var row []any
+	if err := dec.Decode(&row); err != nil {
+		return nil, err
+	}
+	for _, i := range timeColumns {
+		if i >= len(row) {
+			break
+		}
+		value, ok := row[i].(string)
+		if !ok {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, err
+		}
+		row[i] = parsed
+	}
+	return row, nil
+}
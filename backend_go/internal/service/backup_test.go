@@ -0,0 +1,271 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"mydashboard-backend/internal/store"
+)
+
+var backupCreated = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// backupRows are what the mock database holds, by table; the other backup
+// tables are empty.
+func backupRows() map[string]*sqlmock.Rows {
+	return map[string]*sqlmock.Rows{
+		"teams": sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("id").OfType("BIGINT", int64(0)),
+			sqlmock.NewColumn("name").OfType("VARCHAR", ""),
+			sqlmock.NewColumn("created_at").OfType("TIMESTAMP", time.Time{}),
+		).AddRow(int64(1), []byte("Growth"), backupCreated),
+		"team_members": sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("team_id").OfType("BIGINT", int64(0)),
+			sqlmock.NewColumn("user_id").OfType("BIGINT", int64(0)),
+			sqlmock.NewColumn("role").OfType("VARCHAR", ""),
+		).AddRow(int64(1), int64(5), []byte("lead")).AddRow(int64(1), int64(6), []byte("member")),
+		"insight_assignments": sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("insight_id").OfType("BIGINT", int64(0)),
+			sqlmock.NewColumn("user_id").OfType("BIGINT", int64(0)),
+			sqlmock.NewColumn("due_at").OfType("TIMESTAMP", time.Time{}),
+		).AddRow(int64(9), int64(5), nil),
+	}
+}
+
+func newBackupMock(t *testing.T) (*BackupService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	return NewBackupService(store.New(db)), mock
+}
+
+func dumpBackup(t *testing.T) []byte {
+	t.Helper()
+	backups, mock := newBackupMock(t)
+	rows := backupRows()
+	for _, table := range store.BackupTables {
+		result, ok := rows[table]
+		if !ok {
+			result = sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("id").OfType("BIGINT", int64(0)))
+		}
+		mock.ExpectQuery("^SELECT \\* FROM " + table + "$").WillReturnRows(result)
+	}
+	var archive bytes.Buffer
+	report, err := backups.Write(context.Background(), &archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows["team_members"] != 2 || report.Rows["insight_assignments"] != 1 {
+		t.Fatalf("dumped %v", report.Rows)
+	}
+	return archive.Bytes()
+}
+
+func expectEmptied(mock sqlmock.Sqlmock) {
+	for i := len(store.BackupTables) - 1; i >= 0; i-- {
+		mock.ExpectExec("^DELETE FROM " + store.BackupTables[i] + "$").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+}
+
+func insertQuery(table string, columns ...string) string {
+	return "^" + regexp.QuoteMeta("INSERT INTO "+table+" (`"+strings.Join(columns, "`, `")+"`) VALUES ")
+}
+
+// TestBackupRoundTripKeepsMembershipsAndAssignments restores what it dumped.
+// Emptying teams and insights cascades to their memberships and
+// assignments, so those have to come back from the archive.
+func TestBackupRoundTripKeepsMembershipsAndAssignments(t *testing.T) {
+	archive := dumpBackup(t)
+
+	backups, mock := newBackupMock(t)
+	mock.ExpectBegin()
+	expectEmptied(mock)
+	mock.ExpectExec(insertQuery("teams", "id", "name", "created_at")).
+		WithArgs("1", "Growth", backupCreated).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("^SELECT id FROM users$").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(5)))
+	mock.ExpectExec(insertQuery("team_members", "team_id", "user_id", "role")).
+		WithArgs("1", "5", "lead").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertQuery("insight_assignments", "insight_id", "user_id", "due_at")).
+		WithArgs("9", "5", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	report, err := backups.Restore(context.Background(), bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows["teams"] != 1 || report.Rows["team_members"] != 1 || report.Rows["insight_assignments"] != 1 {
+		t.Errorf("restored %v", report.Rows)
+	}
+	if report.Skipped["team_members"] != 1 {
+		t.Errorf("skipped %v, want the membership of the missing user", report.Skipped)
+	}
+}
+
+// archiveOf writes lines as a backup archive.
+func archiveOf(t *testing.T, lines ...any) []byte {
+	t.Helper()
+	var archive bytes.Buffer
+	zw := gzip.NewWriter(&archive)
+	enc := json.NewEncoder(zw)
+	for _, line := range lines {
+		if raw, ok := line.(string); ok {
+			zw.Write([]byte(raw + "\n"))
+			continue
+		}
+		if err := enc.Encode(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archive.Bytes()
+}
+
+func manifestOf(tables ...string) BackupManifest {
+	return BackupManifest{Format: backupFormat, Version: backupVersion, CreatedAt: backupCreated, Tables: tables}
+}
+
+func TestBackupWritesHeadersWithTimeColumns(t *testing.T) {
+	archive := dumpBackup(t)
+	zr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(zr)
+	var manifest BackupManifest
+	if err := dec.Decode(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Format != backupFormat || manifest.Version != backupVersion || len(manifest.Tables) != len(store.BackupTables) {
+		t.Fatalf("manifest %+v", manifest)
+	}
+	var header store.TableHeader
+	for dec.More() {
+		var line json.RawMessage
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		if line[0] == '{' {
+			header = store.TableHeader{}
+			if err := json.Unmarshal(line, &header); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if header.Table != "teams" {
+			continue
+		}
+		if len(header.TimeColumns) != 1 || header.TimeColumns[0] != "created_at" {
+			t.Errorf("teams header %+v, want created_at as a time column", header)
+		}
+		var row []any
+		if err := json.Unmarshal(line, &row); err != nil {
+			t.Fatal(err)
+		}
+		if row[2] != backupCreated.Format(time.RFC3339Nano) {
+			t.Errorf("created_at written as %v", row[2])
+		}
+	}
+}
+
+func TestRestoreRollsBackInvalidArchive(t *testing.T) {
+	backups, mock := newBackupMock(t)
+	archive := archiveOf(t,
+		manifestOf("orgs", "teams"),
+		store.TableHeader{Table: "orgs", Columns: []string{"id", "name", "created_at"}, TimeColumns: []string{"created_at"}},
+		[]any{1, "Sales", backupCreated},
+		store.TableHeader{Table: "teams", Columns: []string{"id", "org_id", "name"}},
+		`[2, 1, "Growth"`,
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM teams$").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("^DELETE FROM orgs$").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertQuery("orgs", "id", "name", "created_at")).
+		WithArgs("1", "Sales", backupCreated).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	_, err := backups.Restore(context.Background(), bytes.NewReader(archive))
+	if !errors.Is(err, ErrInvalidBackup) {
+		t.Fatalf("err = %v, want ErrInvalidBackup", err)
+	}
+}
+
+func TestRestoreRejectsUnknownTables(t *testing.T) {
+	backups, mock := newBackupMock(t)
+	ctx := context.Background()
+
+	// Rejected before the database is touched.
+	archive := archiveOf(t, manifestOf("insights", "users"))
+	if _, err := backups.Restore(ctx, bytes.NewReader(archive)); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("manifest with users: err = %v, want ErrInvalidBackup", err)
+	}
+
+	// A table the manifest does not list.
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM orgs$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	archive = archiveOf(t,
+		manifestOf("orgs"),
+		store.TableHeader{Table: "sessions", Columns: []string{"id"}},
+		[]any{1},
+	)
+	if _, err := backups.Restore(ctx, bytes.NewReader(archive)); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("header for sessions: err = %v, want ErrInvalidBackup", err)
+	}
+}
+
+func TestRestoreRejectsUnknownColumns(t *testing.T) {
+	backups, mock := newBackupMock(t)
+	ctx := context.Background()
+
+	// A malformed column name never reaches the database.
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM orgs$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	archive := archiveOf(t,
+		manifestOf("orgs"),
+		store.TableHeader{Table: "orgs", Columns: []string{"id", "name`) VALUES (1); --"}},
+		[]any{1, "Sales"},
+	)
+	if _, err := backups.Restore(ctx, bytes.NewReader(archive)); err == nil {
+		t.Error("restored a malformed column")
+	}
+
+	// A column the table does not have fails in the database and rolls
+	// everything back.
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM orgs$").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertQuery("orgs", "id", "colour")).
+		WillReturnError(errors.New("Error 1054: Unknown column 'colour' in 'field list'"))
+	mock.ExpectRollback()
+	archive = archiveOf(t,
+		manifestOf("orgs"),
+		store.TableHeader{Table: "orgs", Columns: []string{"id", "colour"}},
+		[]any{1, "red"},
+	)
+	if _, err := backups.Restore(ctx, bytes.NewReader(archive)); err == nil {
+		t.Error("restored an unknown column")
+	}
+}
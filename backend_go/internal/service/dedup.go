@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+// WithDedup stops the service from storing a snapshot equal to the one
+// before it. The previous snapshot's valid_until moves forward instead, so a
+// flat period is one row per window rather than one per tick. Values are
+// equal when they differ by at most tolerance as a fraction of the larger
+// one; zero means exactly equal. A zero window turns it off.
+func (s *MetricsService) WithDedup(window time.Duration, tolerance float64) *MetricsService {
+	s.dedupWindow = window
+	s.dedupTolerance = tolerance
+	return s
+}
+
+// collapse drops the snapshots of batch that repeat the one before them,
+// starting from the newest stored snapshot, and extends that one if the
+// first rows repeat it. Call it with the transaction that writes the rows
+// it returns, so a failed write also undoes the extension.
+func (s *MetricsService) collapse(ctx context.Context, tx *store.Store, batch []models.Metrics) ([]models.Metrics, error) {
+	if s.dedupWindow <= 0 || len(batch) == 0 {
+		return batch, nil
+	}
+	latest, err := tx.LatestMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var extend time.Time
+	kept := make([]models.Metrics, 0, len(batch))
+	for _, metrics := range batch {
+		switch {
+		case len(kept) > 0 && s.repeats(kept[len(kept)-1], metrics):
+			until := metrics.CreatedAt
+			kept[len(kept)-1].ValidUntil = &until
+		case len(kept) == 0 && !latest.CreatedAt.IsZero() && s.repeats(latest, metrics):
+			extend = metrics.CreatedAt
+		default:
+			kept = append(kept, metrics)
+		}
+	}
+	if !extend.IsZero() {
+		if err := tx.ExtendLatestMetrics(ctx, extend); err != nil {
+			return nil, err
+		}
+	}
+	return kept, nil
+}
+
+// repeats reports whether next can be folded into previous: it is newer,
+// within the window of it and has the same values.
+func (s *MetricsService) repeats(previous, next models.Metrics) bool {
+	if !next.CreatedAt.After(previous.CreatedAt) || next.CreatedAt.Sub(previous.CreatedAt) > s.dedupWindow {
+		return false
+	}
+	if !s.near(previous.Revenue, next.Revenue) ||
+		!s.near(previous.Growth, next.Growth) ||
+		!s.near(previous.Sentiment, next.Sentiment) ||
+		!s.near(float64(previous.Backlog), float64(next.Backlog)) ||
+		len(previous.Derived) != len(next.Derived) {
+		return false
+	}
+	for name, value := range next.Derived {
+		before, ok := previous.Derived[name]
+		if !ok || !s.near(before, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *MetricsService) near(a, b float64) bool {
+	return math.Abs(a-b) <= s.dedupTolerance*math.Max(math.Abs(a), math.Abs(b))
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+func TestIngestReturnsStoredRows(t *testing.T) {
+	st, err := store.NewMemory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	metrics := NewMetricsService(st, NewSimulation()).
+		WithDerived(NewDerivedMetricService(st)).
+		WithDedup(time.Hour, 0)
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	snapshot := func(minute int, revenue float64) models.Metrics {
+		return models.Metrics{Revenue: revenue, Growth: 1, Sentiment: 0.5, Backlog: 3, CreatedAt: start.Add(time.Duration(minute) * time.Minute)}
+	}
+
+	saved, _, err := metrics.Ingest(ctx, []models.Metrics{snapshot(0, 100), snapshot(1, 100), snapshot(2, 120)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 2 || saved[0].Revenue != 100 || saved[1].Revenue != 120 {
+		t.Fatalf("saved %+v, want the two distinct snapshots", saved)
+	}
+	if saved[0].ValidUntil == nil || !saved[0].ValidUntil.Equal(start.Add(time.Minute)) {
+		t.Errorf("first row valid until %v, want the folded snapshot's time", saved[0].ValidUntil)
+	}
+
+	saved, _, err = metrics.Ingest(ctx, []models.Metrics{snapshot(3, 120)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 0 {
+		t.Errorf("saved %+v, want nothing for a repeat", saved)
+	}
+	latest, err := st.LatestMetrics(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.ValidUntil == nil || !latest.ValidUntil.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("latest valid until %v, want it extended to the repeat", latest.ValidUntil)
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/rules"
+	"mydashboard-backend/internal/store"
+)
+
+// derivedRefresh bounds how long another instance's changes to definitions
+// take to show up here.
+const derivedRefresh = 30 * time.Second
+
+var ErrInvalidDerivedMetric = errors.New("invalid derived metric")
+
+var derivedName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type compiledDerived struct {
+	models.DerivedMetric
+	formula rules.Formula
+}
+
+// DerivedMetricService keeps the derived metric definitions and evaluates
+// them over snapshots. Expressions may only reference stored metrics, so
+// definitions cannot depend on one another.
+type DerivedMetricService struct {
+	store *store.Store
+
+	mu       sync.Mutex
+	defs     []compiledDerived
+	loadedAt time.Time
+}
+
+func NewDerivedMetricService(store *store.Store) *DerivedMetricService {
+	return &DerivedMetricService{store: store}
+}
+
+func (s *DerivedMetricService) List(ctx context.Context) ([]models.DerivedMetric, error) {
+	items, err := s.store.ListDerivedMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.DerivedMetric{}
+	}
+	return items, nil
+}
+
+// Save creates or replaces the definition named item.Name.
+func (s *DerivedMetricService) Save(ctx context.Context, item models.DerivedMetric) error {
+	if err := validateDerived(&item); err != nil {
+		return err
+	}
+	if err := s.store.SaveDerivedMetric(ctx, item); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *DerivedMetricService) Delete(ctx context.Context, name string) error {
+	if err := s.store.DeleteDerivedMetric(ctx, name); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+func (s *DerivedMetricService) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loadedAt = time.Time{}
+}
+
+// definitions returns the compiled definitions. When they cannot be reloaded
+// the previous ones are kept, so reads do not fail over derived values.
+func (s *DerivedMetricService) definitions(ctx context.Context) []compiledDerived {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.loadedAt) < derivedRefresh {
+		return s.defs
+	}
+	items, err := s.store.ListDerivedMetrics(ctx)
+	if err != nil {
+		log.Printf("load derived metrics failed: %v", err)
+		return s.defs
+	}
+	defs := make([]compiledDerived, 0, len(items))
+	for _, item := range items {
+		formula, err := rules.ParseFormula(item.Expression)
+		if err != nil {
+			log.Printf("derived metric %s: %v", item.Name, err)
+			continue
+		}
+		defs = append(defs, compiledDerived{DerivedMetric: item, formula: formula})
+	}
+	s.defs, s.loadedAt = defs, time.Now()
+	return defs
+}
+
+// Names lists the derived metrics, sorted.
+func (s *DerivedMetricService) Names(ctx context.Context) []string {
+	var names []string
+	for _, def := range s.definitions(ctx) {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+// Apply fills in the query-time metrics of points. Ingest-time values come
+// from the store as they were written.
+func (s *DerivedMetricService) Apply(ctx context.Context, points []models.Metrics) {
+	defs := s.definitions(ctx)
+	for i := range points {
+		points[i] = evaluate(defs, points[i], models.DerivedAtQuery)
+	}
+}
+
+// Stamp computes the ingest-time metrics of snapshots about to be written.
+func (s *DerivedMetricService) Stamp(ctx context.Context, items []models.Metrics) {
+	defs := s.definitions(ctx)
+	for i := range items {
+		items[i] = evaluate(defs, items[i], models.DerivedAtIngest)
+	}
+}
+
+// evaluate returns metrics with the values of the definitions in mode. The
+// map is copied rather than written to, since the memory store hands out the
+// map it keeps.
+func evaluate(defs []compiledDerived, metrics models.Metrics, mode string) models.Metrics {
+	var vars map[string]float64
+	derived := maps.Clone(metrics.Derived)
+	for _, def := range defs {
+		if def.Mode != mode {
+			continue
+		}
+		if vars == nil {
+			vars = make(map[string]float64, len(models.MetricKeys))
+			for _, key := range models.MetricKeys {
+				vars[key], _ = metrics.Value(key)
+			}
+		}
+		value, err := def.formula.Eval(vars)
+		if err != nil {
+			delete(derived, def.Name)
+			continue
+		}
+		if derived == nil {
+			derived = map[string]float64{}
+		}
+		derived[def.Name] = value
+	}
+	metrics.Derived = derived
+	return metrics
+}
+
+func derivedKeys(metrics models.Metrics) []string {
+	keys := make([]string, 0, len(metrics.Derived))
+	for key := range metrics.Derived {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func validateDerived(item *models.DerivedMetric) error {
+	item.Name = strings.ToLower(strings.TrimSpace(item.Name))
+	item.Expression = strings.TrimSpace(item.Expression)
+	if !derivedName.MatchString(item.Name) {
+		return fmt.Errorf("%w: name must be lowercase letters, digits and underscores", ErrInvalidDerivedMetric)
+	}
+	// "derived" would collide with the /metrics/derived routes.
+	if slices.Contains(models.MetricKeys, item.Name) || item.Name == "derived" {
+		return fmt.Errorf("%w: %s is reserved", ErrInvalidDerivedMetric, item.Name)
+	}
+	if item.Mode == "" {
+		item.Mode = models.DerivedAtQuery
+	}
+	if item.Mode != models.DerivedAtQuery && item.Mode != models.DerivedAtIngest {
+		return fmt.Errorf("%w: mode must be %s or %s", ErrInvalidDerivedMetric, models.DerivedAtQuery, models.DerivedAtIngest)
+	}
+	if len(item.Expression) > 512 || len(item.Description) > 255 {
+		return fmt.Errorf("%w: expression or description is too long", ErrInvalidDerivedMetric)
+	}
+	formula, err := rules.ParseFormula(item.Expression)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDerivedMetric, err)
+	}
+	for _, name := range rules.FormulaVars(formula) {
+		if !slices.Contains(models.MetricKeys, name) {
+			return fmt.Errorf("%w: unknown metric %q, expressions may use %s", ErrInvalidDerivedMetric, name, strings.Join(models.MetricKeys, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	digestWindow    = 24 * time.Hour
+	digestAlerts    = 3
+	digestAnomalies = 3
+	// A move between snapshots counts as unusual when its robust z-score,
+	// the distance from the day's median move in units of the scaled median
+	// absolute deviation, reaches this.
+	anomalyThreshold = 3.5
+)
+
+// metricMove is a change of a metric between two consecutive snapshots and
+// how unusual it was for the day.
+type metricMove struct {
+	key   string
+	at    time.Time
+	delta float64
+	z     float64
+}
+
+// GenerateDigest writes one insight per locale summing up the last day: how
+// the metrics moved, the alerts that fired and the most unusual moves. It
+// backs the generate-digest job, for readers who want one insight a day
+// rather than one per tick.
+func (s *InsightsService) GenerateDigest(ctx context.Context) error {
+	now := time.Now()
+	from := now.Add(-digestWindow)
+	start, err := s.store.MetricsAt(ctx, from)
+	if errors.Is(err, store.ErrNotFound) {
+		log.Printf("insight digest skipped: no snapshot older than %s", digestWindow)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	points, err := s.store.MetricsBetween(ctx, from, now, maxRangeRows)
+	if err != nil {
+		return err
+	}
+	end := start
+	if len(points) > 0 {
+		end = points[len(points)-1]
+	}
+	alerts, err := s.store.ListAlerts(ctx, false, maxRangeRows)
+	if err != nil {
+		return err
+	}
+	var fired []models.Alert
+	open := 0
+	for _, alert := range alerts {
+		if alert.CreatedAt.Before(from) {
+			break
+		}
+		fired = append(fired, alert)
+		if alert.AcknowledgedAt == nil {
+			open++
+		}
+	}
+	moves := unusualMoves(append([]models.Metrics{start}, points...), digestAnomalies)
+
+	var errs []error
+	for _, locale := range s.locales {
+		parts := []string{i18n.T(locale, "summary.message",
+			formatDelta(start.Revenue, end.Revenue, "B"),
+			formatDelta(start.Growth, end.Growth, "%"),
+			formatDelta(start.Sentiment, end.Sentiment, "%"),
+			formatDelta(float64(start.Backlog), float64(end.Backlog), "K"),
+		)}
+		if len(fired) == 0 {
+			parts = append(parts, i18n.T(locale, "digest.alerts.none"))
+		} else {
+			titles := make([]string, 0, digestAlerts)
+			for _, alert := range fired[:min(len(fired), digestAlerts)] {
+				titles = append(titles, alert.Title)
+			}
+			parts = append(parts, i18n.T(locale, "digest.alerts", len(fired), open,
+				strings.Join(titles, i18n.T(locale, "insight.separator"))))
+		}
+		if len(moves) > 0 {
+			described := make([]string, len(moves))
+			for i, move := range moves {
+				described[i] = fmt.Sprintf("%s %s %+.2f (%.1fσ)", move.key, move.at.Format("15:04"), move.delta, move.z)
+			}
+			parts = append(parts, i18n.T(locale, "digest.anomalies", strings.Join(described, i18n.T(locale, "insight.separator"))))
+		}
+		severity := models.SeverityInfo
+		if open > 0 {
+			severity = models.SeverityWarning
+		}
+		_, err := s.save(ctx, models.Insight{
+			Title:    i18n.T(locale, "digest.title", now.Format("2006-01-02")),
+			Message:  strings.Join(parts, i18n.T(locale, "digest.joiner")),
+			Source:   "digest",
+			Metrics:  insightLinks(end, []models.Metrics{start, end}, "overview"),
+			Locale:   locale,
+			Severity: severity,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", locale, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unusualMoves returns up to n of the snapshot-to-snapshot moves whose
+// robust z-score reaches anomalyThreshold, most unusual first. The median
+// and its absolute deviation are used rather than the mean and standard
+// deviation so a single spike does not hide itself by widening the spread.
+func unusualMoves(points []models.Metrics, n int) []metricMove {
+	var moves []metricMove
+	if len(points) < 3 {
+		return moves
+	}
+	for _, key := range models.MetricKeys {
+		deltas := make([]float64, len(points)-1)
+		for i := range deltas {
+			previous, _ := points[i].Value(key)
+			current, _ := points[i+1].Value(key)
+			deltas[i] = current - previous
+		}
+		center := percentile(sortedCopy(deltas), 50)
+		deviations := make([]float64, len(deltas))
+		for i, delta := range deltas {
+			deviations[i] = math.Abs(delta - center)
+		}
+		// The constants scale the deviations to a standard deviation for
+		// normal data. When more than half the moves are identical, as with
+		// a steady simulation, the median deviation is zero and the mean one
+		// is used instead.
+		spread := 1.4826 * percentile(sortedCopy(deviations), 50)
+		if spread == 0 {
+			spread = 1.2533 * mean(deviations)
+		}
+		if spread == 0 {
+			continue
+		}
+		for i, delta := range deltas {
+			if z := math.Abs(delta-center) / spread; z >= anomalyThreshold {
+				moves = append(moves, metricMove{key: key, at: points[i+1].CreatedAt, delta: delta, z: z})
+			}
+		}
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].z > moves[j].z })
+	return moves[:min(len(moves), n)]
+}
+
+func sortedCopy(values []float64) []float64 {
+	sorted := slices.Clone(values)
+	sort.Float64s(sorted)
+	return sorted
+}
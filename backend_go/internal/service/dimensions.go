@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	maxDimensionBatch     = 10000
+	maxDimensionMemberLen = 128
+	maxLeaderboardLimit   = 100
+)
+
+var ErrInvalidDimension = errors.New("invalid dimension value")
+
+var dimensionName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// additiveMetrics are the metrics whose members add up to the whole, so a
+// member's share of the total means something. Growth and sentiment do not.
+var additiveMetrics = []string{"revenue", "backlog"}
+
+// DimensionService stores the breakdown of metrics by dimensions such as
+// region or product and ranks the members by contribution.
+type DimensionService struct {
+	store *store.Store
+}
+
+func NewDimensionService(store *store.Store) *DimensionService {
+	return &DimensionService{store: store}
+}
+
+// Ingest stores a batch of dimension values. Values without a time are taken
+// to be recorded now. The batch is rejected as a whole if any value is
+// invalid.
+func (s *DimensionService) Ingest(ctx context.Context, values []models.DimensionValue) (int, error) {
+	if len(values) > maxDimensionBatch {
+		return 0, fmt.Errorf("%w: at most %d values per request", ErrInvalidDimension, maxDimensionBatch)
+	}
+	now := time.Now()
+	for i := range values {
+		if err := validateDimension(values[i].Metric, values[i].Dimension); err != nil {
+			return 0, fmt.Errorf("%w: value %d: %v", ErrInvalidDimension, i, err)
+		}
+		if values[i].Member == "" || len(values[i].Member) > maxDimensionMemberLen {
+			return 0, fmt.Errorf("%w: value %d: member must be 1 to %d characters", ErrInvalidDimension, i, maxDimensionMemberLen)
+		}
+		if values[i].RecordedAt.IsZero() {
+			values[i].RecordedAt = now
+		}
+	}
+	if err := s.store.InsertDimensionValues(ctx, values); err != nil {
+		return 0, err
+	}
+	return len(values), nil
+}
+
+// Top ranks the members of dimension by their total of metric over
+// [from, to] and returns the first limit of them. Ties are broken by member
+// name so the order is stable.
+func (s *DimensionService) Top(ctx context.Context, metric, dimension string, from, to time.Time, limit int) (models.Leaderboard, error) {
+	if err := validateDimension(metric, dimension); err != nil {
+		return models.Leaderboard{}, fmt.Errorf("%w: %v", ErrInvalidDimension, err)
+	}
+	limit = min(max(limit, 1), maxLeaderboardLimit)
+	totals, err := s.store.DimensionTotals(ctx, metric, dimension, from, to)
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Value != totals[j].Value {
+			return totals[i].Value > totals[j].Value
+		}
+		return totals[i].Member < totals[j].Member
+	})
+	board := models.Leaderboard{
+		Metric:       metric,
+		Dimension:    dimension,
+		From:         from,
+		To:           to,
+		Members:      len(totals),
+		Contributors: []models.Contributor{},
+	}
+	for _, total := range totals {
+		board.Total += total.Value
+	}
+	for i, total := range totals {
+		if i >= limit {
+			board.Others += total.Value
+			continue
+		}
+		total.Rank = i + 1
+		if board.Total != 0 {
+			total.Share = total.Value / board.Total
+		}
+		board.Contributors = append(board.Contributors, total)
+	}
+	return board, nil
+}
+
+func validateDimension(metric, dimension string) error {
+	if !slices.Contains(additiveMetrics, metric) {
+		return fmt.Errorf("metric must be one of %v", additiveMetrics)
+	}
+	if !dimensionName.MatchString(dimension) {
+		return errors.New("dimension must be lowercase letters, digits and underscores")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+// syncChunkSize bounds the snapshots sent in one push.
+const syncChunkSize = 5000
+
+// EdgeSync pushes the segments of a file-backed store to a central instance
+// through POST /api/sync/push. Rows stay in the local segments until they are
+// acknowledged, so an edge that is offline for a while catches up on the
+// first run after connectivity returns.
+type EdgeSync struct {
+	store      *store.Store
+	url        string
+	token      string
+	node       string
+	httpClient *http.Client
+}
+
+// NewEdgeSync pushes to baseURL, the central instance's address.
+func NewEdgeSync(store *store.Store, baseURL, token, node string) *EdgeSync {
+	return &EdgeSync{
+		store: store,
+		url:   strings.TrimSuffix(baseURL, "/") + "/api/sync/push",
+		token: token,
+		node:  node,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Run pushes the unacknowledged rows of every segment, oldest first, and
+// stops at the first failure so segments arrive in order.
+func (s *EdgeSync) Run(ctx context.Context) error {
+	for _, segment := range s.store.MetricSegments() {
+		if segment.Synced >= segment.Count {
+			continue
+		}
+		if err := s.pushSegment(ctx, segment); err != nil {
+			return fmt.Errorf("sync segment %s: %w", segment.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *EdgeSync) pushSegment(ctx context.Context, segment store.MetricSegment) error {
+	rows, err := s.store.ReadMetricSegment(segment.Name)
+	if err != nil {
+		return err
+	}
+	var inserted, duplicates, conflicts int
+	for start := segment.Synced; start < len(rows); start += syncChunkSize {
+		push := models.SyncPush{
+			Node:    s.node,
+			Segment: segment.Name,
+			Offset:  segment.Offset + start,
+			Metrics: rows[start:min(start+syncChunkSize, len(rows))],
+		}
+		result, status, err := s.post(ctx, push)
+		if err != nil {
+			return err
+		}
+		if err := s.store.AckMetricSegment(segment.Name, result.Acked); err != nil {
+			return err
+		}
+		if status == http.StatusConflict {
+			// The central instance has less than we thought, e.g. after
+			// losing its cursors; the next run resends from its position.
+			return fmt.Errorf("central instance has %d rows, rewinding", result.Acked)
+		}
+		inserted += result.Inserted
+		duplicates += result.Duplicates
+		conflicts += len(result.Conflicts)
+	}
+	log.Printf("synced segment %s: %d inserted, %d duplicates, %d conflicts kept central values",
+		segment.Name, inserted, duplicates, conflicts)
+	return nil
+}
+
+func (s *EdgeSync) post(ctx context.Context, push models.SyncPush) (models.SyncResult, int, error) {
+	var result models.SyncResult
+	body, err := json.Marshal(push)
+	if err != nil {
+		return result, 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return result, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return result, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
+		return result, resp.StatusCode, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, resp.StatusCode, fmt.Errorf("decode sync result: %w", err)
+	}
+	return result, resp.StatusCode, nil
+}
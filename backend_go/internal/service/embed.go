@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/auth"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	EmbedTokenPrefix = "emb_"
+	maxEmbedTokenTTL = 366 * 24 * time.Hour
+)
+
+var ErrInvalidEmbedToken = errors.New("invalid embed token")
+
+type cachedEmbed struct {
+	token models.EmbedToken
+	until time.Time
+}
+
+// EmbedService issues read-only tokens for wallboards and embedded views.
+// Lookups are cached like sessions, so a revocation made on another instance
+// takes up to principalCacheTTL to apply there.
+type EmbedService struct {
+	store *store.Store
+
+	mu    sync.Mutex
+	cache map[string]cachedEmbed
+}
+
+func NewEmbedService(store *store.Store) *EmbedService {
+	return &EmbedService{store: store, cache: map[string]cachedEmbed{}}
+}
+
+func (s *EmbedService) Create(ctx context.Context, name string, metrics []string, ttl time.Duration, createdBy *int64) (models.EmbedToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 128 {
+		return models.EmbedToken{}, fmt.Errorf("%w: name must be 1-128 characters", ErrInvalidEmbedToken)
+	}
+	if ttl <= 0 || ttl > maxEmbedTokenTTL {
+		return models.EmbedToken{}, fmt.Errorf("%w: ttl must be positive and at most 366 days", ErrInvalidEmbedToken)
+	}
+	if len(metrics) == 0 {
+		return models.EmbedToken{}, fmt.Errorf("%w: at least one metric is required", ErrInvalidEmbedToken)
+	}
+	seen := map[string]bool{}
+	keys := make([]string, 0, len(metrics))
+	for _, key := range metrics {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if _, ok := (models.Metrics{}).Value(key); !ok {
+			return models.EmbedToken{}, fmt.Errorf("%w: %s", ErrUnknownMetric, key)
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	secret, err := auth.RandomToken()
+	if err != nil {
+		return models.EmbedToken{}, err
+	}
+	plain := EmbedTokenPrefix + secret
+	now := time.Now()
+	token, err := s.store.InsertEmbedToken(ctx, models.EmbedToken{
+		Name:      name,
+		TokenHash: auth.HashToken(plain),
+		Metrics:   keys,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return models.EmbedToken{}, err
+	}
+	token.Token = plain
+	return token, nil
+}
+
+func (s *EmbedService) Resolve(ctx context.Context, plain string) (models.EmbedToken, error) {
+	hash := auth.HashToken(plain)
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[hash]
+	s.mu.Unlock()
+	if ok && now.Before(cached.until) && now.Before(cached.token.ExpiresAt) {
+		return cached.token, nil
+	}
+
+	token, err := s.store.ActiveEmbedToken(ctx, hash, now)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.EmbedToken{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return models.EmbedToken{}, err
+	}
+	s.mu.Lock()
+	for key, entry := range s.cache {
+		if now.After(entry.until) {
+			delete(s.cache, key)
+		}
+	}
+	s.cache[hash] = cachedEmbed{token: token, until: now.Add(principalCacheTTL)}
+	s.mu.Unlock()
+	return token, nil
+}
+
+func (s *EmbedService) List(ctx context.Context) ([]models.EmbedToken, error) {
+	return s.store.ListEmbedTokens(ctx)
+}
+
+func (s *EmbedService) Revoke(ctx context.Context, id int64) error {
+	if err := s.store.RevokeEmbedToken(ctx, id, time.Now()); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	for key, entry := range s.cache {
+		if entry.token.ID == id {
+			delete(s.cache, key)
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
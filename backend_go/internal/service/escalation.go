@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/auth"
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	maxEscalationSteps = 10
+	escalationBatch    = 50
+)
+
+// EscalationService raises an alert when a rule with escalation steps fires
+// and notifies the steps' channels in turn until someone acknowledges it,
+// through the API or the link included in every notification.
+type EscalationService struct {
+	store     *store.Store
+	publicURL string
+}
+
+// NewEscalationService builds acknowledgment links on publicURL, the address
+// the notified people can reach this service at. Without it notifications
+// carry no link and alerts are acknowledged through the API only.
+func NewEscalationService(store *store.Store, publicURL string) *EscalationService {
+	return &EscalationService{store: store, publicURL: strings.TrimSuffix(publicURL, "/")}
+}
+
+// Raise opens an alert for insight, created by rule, and notifies the steps
+// that are due right away. While the rule has an unacknowledged alert it does
+// not raise another one.
+func (s *EscalationService) Raise(ctx context.Context, rule models.InsightRule, insight models.Insight) error {
+	if len(rule.Escalation) == 0 {
+		return nil
+	}
+	if _, err := s.store.OpenAlertForRule(ctx, rule.ID); !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	// The token is kept as is rather than hashed since every step repeats
+	// the link; it can only acknowledge this alert.
+	token, err := auth.RandomToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	next := now.Add(time.Duration(rule.Escalation[0].AfterMinutes) * time.Minute)
+	alert, err := s.store.InsertAlert(ctx, models.Alert{
+		RuleID:     rule.ID,
+		InsightID:  insight.ID,
+		Title:      insight.Title,
+		Message:    insight.Message,
+		Severity:   insight.Severity,
+		Locale:     insight.Locale,
+		NextStepAt: &next,
+		AckToken:   token,
+	})
+	if err != nil {
+		return err
+	}
+	if next.After(now) {
+		return nil
+	}
+	return s.escalate(ctx, alert, rule.Escalation)
+}
+
+// Run notifies the escalation steps that have come due.
+func (s *EscalationService) Run(ctx context.Context) error {
+	alerts, err := s.store.DueAlerts(ctx, time.Now(), escalationBatch)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, alert := range alerts {
+		rule, err := s.store.InsightRuleByID(ctx, alert.RuleID)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			// The rule is gone, so there is nobody left to escalate to.
+			rule = models.InsightRule{}
+		case err != nil:
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.escalate(ctx, alert, rule.Escalation); err != nil {
+			errs = append(errs, fmt.Errorf("alert %d: %w", alert.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// escalate notifies the alert's next step and every later one already due,
+// each together with moving the alert on so a step is notified once.
+func (s *EscalationService) escalate(ctx context.Context, alert models.Alert, steps []models.EscalationStep) error {
+	now := time.Now()
+	for {
+		if alert.Step >= len(steps) {
+			// The rule lost steps since the alert was raised.
+			err := s.store.AdvanceAlert(ctx, alert.ID, alert.Step, alert.Step, nil)
+			if errors.Is(err, store.ErrConflict) {
+				return nil
+			}
+			return err
+		}
+		var next *time.Time
+		if alert.Step+1 < len(steps) {
+			at := alert.CreatedAt.Add(time.Duration(steps[alert.Step+1].AfterMinutes) * time.Minute)
+			next = &at
+		}
+		step := steps[alert.Step]
+		err := s.store.WithTx(ctx, func(tx *store.Store) error {
+			if err := tx.AdvanceAlert(ctx, alert.ID, alert.Step, alert.Step+1, next); err != nil {
+				return err
+			}
+			return tx.EnqueueEvent(ctx, models.EventAlertEscalated, s.notification(alert, step))
+		})
+		if errors.Is(err, store.ErrConflict) {
+			// Acknowledged or escalated by someone else meanwhile.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("alert %d escalated to channel %d (step %d)", alert.ID, step.ChannelID, alert.Step+1)
+		alert.Step++
+		if next == nil || next.After(now) {
+			return nil
+		}
+	}
+}
+
+func (s *EscalationService) notification(alert models.Alert, step models.EscalationStep) models.AlertEscalation {
+	escalation := models.AlertEscalation{
+		AlertID:   alert.ID,
+		RuleID:    alert.RuleID,
+		InsightID: alert.InsightID,
+		Step:      alert.Step + 1,
+		ChannelID: step.ChannelID,
+		Title:     alert.Title,
+		Message:   alert.Message,
+		Severity:  alert.Severity,
+		Source:    "rule",
+	}
+	if alert.Step > 0 {
+		escalation.Title = i18n.T(alert.Locale, "alert.escalated", alert.Title, alert.Step+1)
+	}
+	if s.publicURL != "" {
+		escalation.AckURL = s.publicURL + "/api/alerts/ack?" + url.Values{"token": {alert.AckToken}}.Encode()
+		escalation.Message += " " + i18n.T(alert.Locale, "alert.ack", escalation.AckURL)
+	}
+	return escalation
+}
+
+func (s *EscalationService) List(ctx context.Context, openOnly bool, limit int) ([]models.Alert, error) {
+	items, err := s.store.ListAlerts(ctx, openOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Alert{}
+	}
+	return items, nil
+}
+
+func (s *EscalationService) Acknowledge(ctx context.Context, id int64, by string) (models.Alert, error) {
+	return s.store.AcknowledgeAlert(ctx, id, by, time.Now())
+}
+
+// AcknowledgeToken acknowledges the alert a notification link points at.
+func (s *EscalationService) AcknowledgeToken(ctx context.Context, token string) (models.Alert, error) {
+	if token == "" {
+		return models.Alert{}, store.ErrNotFound
+	}
+	alert, err := s.store.AlertByAckToken(ctx, token)
+	if err != nil {
+		return models.Alert{}, err
+	}
+	return s.Acknowledge(ctx, alert.ID, "link")
+}
+
+// validateEscalation checks the steps of a rule; channels must exist and
+// steps must be in the order they fire.
+func (s *InsightsService) validateEscalation(ctx context.Context, steps []models.EscalationStep) error {
+	if len(steps) > maxEscalationSteps {
+		return fmt.Errorf("%w: at most %d escalation steps", ErrInvalidRule, maxEscalationSteps)
+	}
+	for i, step := range steps {
+		if step.AfterMinutes < 0 || i > 0 && step.AfterMinutes < steps[i-1].AfterMinutes {
+			return fmt.Errorf("%w: escalation after_minutes must be non-negative and in ascending order", ErrInvalidRule)
+		}
+		if _, err := s.store.NotificationChannelByID(ctx, step.ChannelID); errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("%w: unknown notification channel %d", ErrInvalidRule, step.ChannelID)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
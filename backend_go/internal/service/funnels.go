@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	maxFunnelStages      = 20
+	maxFunnelEventsBatch = 10000
+	maxFunnelEvents      = 500000
+	maxCohortPeriods     = 52
+	maxFunnelSubjectLen  = 128
+)
+
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+var ErrInvalidFunnel = errors.New("invalid funnel")
+
+var funnelName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// FunnelService keeps funnel definitions and their stage events and turns
+// the events into conversion reports and cohort retention matrices. Reports
+// are computed from the raw events, at most maxFunnelEvents per request.
+type FunnelService struct {
+	store *store.Store
+	loc   *time.Location
+}
+
+func NewFunnelService(store *store.Store) *FunnelService {
+	return &FunnelService{store: store, loc: time.Local}
+}
+
+// WithLocation sets the time zone cohort periods start in.
+func (s *FunnelService) WithLocation(loc *time.Location) *FunnelService {
+	if loc != nil {
+		s.loc = loc
+	}
+	return s
+}
+
+func (s *FunnelService) List(ctx context.Context) ([]models.Funnel, error) {
+	items, err := s.store.ListFunnels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Funnel{}
+	}
+	return items, nil
+}
+
+func (s *FunnelService) Save(ctx context.Context, funnel models.Funnel) error {
+	if !funnelName.MatchString(funnel.Name) {
+		return fmt.Errorf("%w: name must be lowercase letters, digits and underscores", ErrInvalidFunnel)
+	}
+	if len(funnel.Stages) == 0 || len(funnel.Stages) > maxFunnelStages {
+		return fmt.Errorf("%w: between 1 and %d stages", ErrInvalidFunnel, maxFunnelStages)
+	}
+	for i, stage := range funnel.Stages {
+		if !funnelName.MatchString(stage) || slices.Contains(funnel.Stages[:i], stage) {
+			return fmt.Errorf("%w: stage %q must be unique lowercase letters, digits and underscores", ErrInvalidFunnel, stage)
+		}
+	}
+	return s.store.SaveFunnel(ctx, funnel)
+}
+
+func (s *FunnelService) Delete(ctx context.Context, name string) error {
+	return s.store.DeleteFunnel(ctx, name)
+}
+
+// Ingest stores stage events of the named funnel. Events without a time are
+// taken to happen now. The batch is rejected as a whole if any event names
+// a stage the funnel does not have.
+func (s *FunnelService) Ingest(ctx context.Context, name string, events []models.FunnelEvent) (int, error) {
+	funnel, err := s.store.FunnelByName(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) > maxFunnelEventsBatch {
+		return 0, fmt.Errorf("%w: at most %d events per request", ErrInvalidFunnel, maxFunnelEventsBatch)
+	}
+	now := time.Now()
+	for i := range events {
+		if !slices.Contains(funnel.Stages, events[i].Stage) {
+			return 0, fmt.Errorf("%w: event %d: unknown stage %q", ErrInvalidFunnel, i, events[i].Stage)
+		}
+		if events[i].Subject == "" || len(events[i].Subject) > maxFunnelSubjectLen {
+			return 0, fmt.Errorf("%w: event %d: subject must be 1 to %d characters", ErrInvalidFunnel, i, maxFunnelSubjectLen)
+		}
+		if events[i].OccurredAt.IsZero() {
+			events[i].OccurredAt = now
+		}
+	}
+	if err := s.store.InsertFunnelEvents(ctx, name, events); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// Report counts the subjects that went through the stages in order within
+// [from, to]. A subject counts for a stage once it has an event of that
+// stage after reaching all earlier ones.
+func (s *FunnelService) Report(ctx context.Context, name string, from, to time.Time) (models.FunnelReport, error) {
+	funnel, err := s.store.FunnelByName(ctx, name)
+	if err != nil {
+		return models.FunnelReport{}, err
+	}
+	events, err := s.store.FunnelEvents(ctx, name, from, to, maxFunnelEvents)
+	if err != nil {
+		return models.FunnelReport{}, err
+	}
+	progress := map[string]int{}
+	counts := make([]int, len(funnel.Stages))
+	for _, event := range events {
+		reached := progress[event.Subject]
+		if reached < len(funnel.Stages) && funnel.Stages[reached] == event.Stage {
+			counts[reached]++
+			progress[event.Subject] = reached + 1
+		}
+	}
+	report := models.FunnelReport{
+		Funnel:    name,
+		From:      from,
+		To:        to,
+		Stages:    make([]models.FunnelStage, len(funnel.Stages)),
+		Truncated: len(events) == maxFunnelEvents,
+	}
+	for i, stage := range funnel.Stages {
+		report.Stages[i] = models.FunnelStage{
+			Stage:      stage,
+			Subjects:   counts[i],
+			Conversion: ratio(counts[i], counts[max(i-1, 0)]),
+			Overall:    ratio(counts[i], counts[0]),
+		}
+	}
+	return report, nil
+}
+
+// Retention builds a cohort matrix over [from, to]. Subjects join the cohort
+// of the period of their first cohortStage event and count as retained in a
+// later period if they have an activeStage event in it, or any event when
+// activeStage is empty. At most periods periods are reported per cohort.
+func (s *FunnelService) Retention(ctx context.Context, name string, from, to time.Time, period, cohortStage, activeStage string, periods int) (models.CohortMatrix, error) {
+	funnel, err := s.store.FunnelByName(ctx, name)
+	if err != nil {
+		return models.CohortMatrix{}, err
+	}
+	if period != PeriodDay && period != PeriodWeek && period != PeriodMonth {
+		return models.CohortMatrix{}, fmt.Errorf("%w: period must be day, week or month", ErrInvalidFunnel)
+	}
+	if cohortStage == "" {
+		cohortStage = funnel.Stages[0]
+	}
+	if !slices.Contains(funnel.Stages, cohortStage) || activeStage != "" && !slices.Contains(funnel.Stages, activeStage) {
+		return models.CohortMatrix{}, fmt.Errorf("%w: unknown stage", ErrInvalidFunnel)
+	}
+	periods = min(max(periods, 1), maxCohortPeriods)
+	events, err := s.store.FunnelEvents(ctx, name, from, to, maxFunnelEvents)
+	if err != nil {
+		return models.CohortMatrix{}, err
+	}
+
+	type member struct {
+		cohort time.Time
+		active map[int]bool
+	}
+	members := map[string]*member{}
+	cohorts := map[time.Time]*models.Cohort{}
+	var starts []time.Time
+	for _, event := range events {
+		m, ok := members[event.Subject]
+		if !ok {
+			if event.Stage != cohortStage {
+				continue
+			}
+			start := s.periodStart(event.OccurredAt, period)
+			m = &member{cohort: start, active: map[int]bool{}}
+			members[event.Subject] = m
+			if cohorts[start] == nil {
+				cohorts[start] = &models.Cohort{Start: start, Retained: make([]int, periods), Rates: make([]float64, periods)}
+				starts = append(starts, start)
+			}
+			cohorts[start].Size++
+			continue
+		}
+		if activeStage != "" && event.Stage != activeStage {
+			continue
+		}
+		n := s.periodsBetween(m.cohort, s.periodStart(event.OccurredAt, period), period)
+		if n >= 1 && n <= periods && !m.active[n] {
+			m.active[n] = true
+			cohorts[m.cohort].Retained[n-1]++
+		}
+	}
+
+	matrix := models.CohortMatrix{
+		Funnel:      name,
+		Period:      period,
+		CohortStage: cohortStage,
+		ActiveStage: activeStage,
+		Cohorts:     make([]models.Cohort, 0, len(starts)),
+		Truncated:   len(events) == maxFunnelEvents,
+	}
+	for _, start := range starts {
+		cohort := cohorts[start]
+		for i, retained := range cohort.Retained {
+			cohort.Rates[i] = ratio(retained, cohort.Size)
+		}
+		matrix.Cohorts = append(matrix.Cohorts, *cohort)
+	}
+	return matrix, nil
+}
+
+// periodStart returns the start of the day, the week (from Monday) or the
+// month containing t.
+func (s *FunnelService) periodStart(t time.Time, period string) time.Time {
+	t = t.In(s.loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+	switch period {
+	case PeriodWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case PeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// periodsBetween counts whole periods between two period starts, by calendar
+// date so daylight saving changes do not shift it.
+func (s *FunnelService) periodsBetween(from, to time.Time, period string) int {
+	if period == PeriodMonth {
+		return (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	}
+	days := int(time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC).
+		Sub(time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+	if period == PeriodWeek {
+		return days / 7
+	}
+	return days
+}
+
+func ratio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
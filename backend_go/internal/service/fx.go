@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrFXUnavailable   = errors.New("exchange rates unavailable")
+)
+
+// FXRates holds exchange rates as units of each currency per one unit of
+// the base currency. Rates come from configuration and, when a source URL
+// is set, are replaced by Refresh.
+type FXRates struct {
+	source     string
+	httpClient *http.Client
+
+	mu    sync.RWMutex
+	base  string
+	rates map[string]float64
+}
+
+// ParseFXRates reads entries such as "CNY=7.12".
+func ParseFXRates(values []string) (map[string]float64, error) {
+	rates := make(map[string]float64, len(values))
+	for _, value := range values {
+		code, rate, ok := strings.Cut(value, "=")
+		parsed, err := strconv.ParseFloat(rate, 64)
+		if !ok || err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid rate %q (want CODE=rate)", value)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(code))] = parsed
+	}
+	return rates, nil
+}
+
+func NewFXRates(base string, rates map[string]float64) *FXRates {
+	return &FXRates{
+		base:  strings.ToUpper(base),
+		rates: rates,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// WithSource sets a URL returning {"base": "USD", "rates": {"CNY": 7.12}},
+// the shape most public rate APIs use.
+func (f *FXRates) WithSource(url string) *FXRates {
+	f.source = url
+	return f
+}
+
+func (f *FXRates) Refresh(ctx context.Context) error {
+	if f.source == "" {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.source, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch exchange rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch exchange rates: status %d", resp.StatusCode)
+	}
+	var payload struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return fmt.Errorf("decode exchange rates: %w", err)
+	}
+	if payload.Base == "" || len(payload.Rates) == 0 {
+		return errors.New("decode exchange rates: missing base or rates")
+	}
+	rates := make(map[string]float64, len(payload.Rates))
+	for code, rate := range payload.Rates {
+		if rate > 0 {
+			rates[strings.ToUpper(code)] = rate
+		}
+	}
+	f.mu.Lock()
+	f.base, f.rates = strings.ToUpper(payload.Base), rates
+	f.mu.Unlock()
+	return nil
+}
+
+// Rate returns how many units of to one unit of from is worth.
+func (f *FXRates) Rate(from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+	if f == nil {
+		return 0, ErrFXUnavailable
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	lookup := func(code string) (float64, bool) {
+		if code == f.base {
+			return 1, true
+		}
+		rate, ok := f.rates[code]
+		return rate, ok
+	}
+	fromRate, ok := lookup(from)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, from)
+	}
+	toRate, ok := lookup(to)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+	return toRate / fromRate, nil
+}
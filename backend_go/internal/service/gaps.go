@@ -0,0 +1,179 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+var ErrUnknownFill = errors.New("unknown fill method")
+
+const (
+	FillNone     = "none"
+	FillNull     = "null"
+	FillPrevious = "previous"
+	FillLinear   = "linear"
+	// FillHeld marks a repeat of a snapshot within its valid_until. It is
+	// not a ?fill= method: the repeats are added whatever the method.
+	FillHeld = "held"
+)
+
+const (
+	// gapSample is how many intervals are looked at to infer the step.
+	gapSample = 16
+	// maxGapFill caps the points added for one gap; a longer gap is filled
+	// with a coarser step.
+	maxGapFill = 500
+)
+
+func CheckFill(method string) error {
+	switch method {
+	case "", FillNone, FillNull, FillPrevious, FillLinear:
+		return nil
+	}
+	return fmt.Errorf("%w %q", ErrUnknownFill, method)
+}
+
+// GapFiller adds points to a series where snapshots are missing, so charts
+// do not join points far apart with a straight line. An interval longer than
+// twice the step is a gap. With fill=null one empty point marks it, with
+// previous the last values repeat every step and with linear they are
+// interpolated. A snapshot with valid_until is repeated up to that time
+// whatever the method, since its values are known to have held; those
+// repeats are marked FillHeld rather than with the method, so fill=null
+// leaves them as they are and nulls only real gaps.
+//
+// Without a step, the median of the first intervals is used, so points are
+// held back until enough have been seen.
+type GapFiller struct {
+	method string
+	step   time.Duration
+	emit   func(point models.Metrics, filled string) error
+
+	last    models.Metrics
+	started bool
+	pending []models.Metrics
+}
+
+// NewGapFiller calls emit with every point in order. filled is empty for a
+// stored snapshot, FillHeld for a valid_until repeat and the method for a
+// point added for a gap.
+func NewGapFiller(method string, step time.Duration, emit func(point models.Metrics, filled string) error) *GapFiller {
+	return &GapFiller{method: method, step: step, emit: emit}
+}
+
+// Add takes the next point of the series. Even with fill=none it goes
+// through the step logic, since valid_until repeats are added whatever the
+// method.
+func (f *GapFiller) Add(point models.Metrics) error {
+	if f.step <= 0 {
+		f.pending = append(f.pending, point)
+		if len(f.pending) <= gapSample {
+			return nil
+		}
+		return f.release()
+	}
+	return f.add(point)
+}
+
+// Close writes the points still held back.
+func (f *GapFiller) Close() error {
+	if f.step <= 0 && len(f.pending) > 0 {
+		return f.release()
+	}
+	return nil
+}
+
+func (f *GapFiller) release() error {
+	f.step = medianInterval(f.pending)
+	pending := f.pending
+	f.pending = nil
+	for _, point := range pending {
+		if err := f.add(point); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *GapFiller) add(point models.Metrics) error {
+	if f.started && f.step > 0 {
+		if err := f.fill(f.last, point); err != nil {
+			return err
+		}
+	}
+	f.last, f.started = point, true
+	return f.emit(point, "")
+}
+
+func (f *GapFiller) fill(previous, next models.Metrics) error {
+	from := previous.CreatedAt
+	if until := previous.ValidUntil; until != nil && until.After(from) {
+		if !until.Before(next.CreatedAt) {
+			return f.between(from, next.CreatedAt, FillHeld, func(at time.Time) models.Metrics { return repeat(previous, at) })
+		}
+		if err := f.between(from, *until, FillHeld, func(at time.Time) models.Metrics { return repeat(previous, at) }); err != nil {
+			return err
+		}
+		if err := f.emit(repeat(previous, *until), FillHeld); err != nil {
+			return err
+		}
+		from = *until
+	}
+	if next.CreatedAt.Sub(from) <= 2*f.step {
+		return nil
+	}
+	// FillNone, and no method at all, add nothing for a real gap.
+	switch f.method {
+	case FillNull:
+		return f.emit(models.Metrics{CreatedAt: from.Add(f.step)}, FillNull)
+	case FillPrevious:
+		return f.between(from, next.CreatedAt, FillPrevious, func(at time.Time) models.Metrics { return repeat(previous, at) })
+	case FillLinear:
+		return f.between(from, next.CreatedAt, FillLinear, func(at time.Time) models.Metrics {
+			return interpolate(previous, next, float64(at.Sub(from))/float64(next.CreatedAt.Sub(from)), at)
+		})
+	}
+	return nil
+}
+
+// between emits a point every step after from, stopping half a step short
+// of to, marked with filled.
+func (f *GapFiller) between(from, to time.Time, filled string, point func(at time.Time) models.Metrics) error {
+	step := max(f.step, to.Sub(from)/maxGapFill)
+	for at := from.Add(step); to.Sub(at) > step/2; at = at.Add(step) {
+		if err := f.emit(point(at), filled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func repeat(metrics models.Metrics, at time.Time) models.Metrics {
+	metrics.CreatedAt = at
+	metrics.ValidUntil = nil
+	return metrics
+}
+
+func interpolate(a, b models.Metrics, t float64, at time.Time) models.Metrics {
+	lerp := func(x, y float64) float64 { return x + (y-x)*t }
+	point := models.Metrics{
+		Revenue:   lerp(a.Revenue, b.Revenue),
+		Growth:    lerp(a.Growth, b.Growth),
+		Sentiment: lerp(a.Sentiment, b.Sentiment),
+		Backlog:   int(math.Round(lerp(float64(a.Backlog), float64(b.Backlog)))),
+		CreatedAt: at,
+	}
+	for name, x := range a.Derived {
+		if y, ok := b.Derived[name]; ok {
+			if point.Derived == nil {
+				point.Derived = make(map[string]float64, len(a.Derived))
+			}
+			point.Derived[name] = lerp(x, y)
+		}
+	}
+	return point
+}
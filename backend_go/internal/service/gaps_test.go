@@ -0,0 +1,81 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+func TestGapFillerMarksHeldRepeats(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minute int) time.Time { return start.Add(time.Duration(minute) * time.Minute) }
+	until := at(3)
+
+	type emitted struct {
+		minute  int
+		revenue float64
+		filled  string
+	}
+	var got []emitted
+	filler := NewGapFiller(FillNull, time.Minute, func(point models.Metrics, filled string) error {
+		got = append(got, emitted{int(point.CreatedAt.Sub(start) / time.Minute), point.Revenue, filled})
+		return nil
+	})
+	for _, point := range []models.Metrics{
+		{Revenue: 100, CreatedAt: at(0), ValidUntil: &until},
+		{Revenue: 120, CreatedAt: at(8)},
+	} {
+		if err := filler.Add(point); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []emitted{
+		{0, 100, ""},
+		{1, 100, FillHeld},
+		{2, 100, FillHeld},
+		{3, 100, FillHeld},
+		{4, 0, FillNull},
+		{8, 120, ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("point %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGapFillerHoldsWithoutFill(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minute int) time.Time { return start.Add(time.Duration(minute) * time.Minute) }
+	until := at(3)
+
+	for _, method := range []string{FillNone, ""} {
+		var got []string
+		filler := NewGapFiller(method, time.Minute, func(point models.Metrics, filled string) error {
+			got = append(got, point.CreatedAt.Format("15:04")+" "+filled)
+			return nil
+		})
+		for _, point := range []models.Metrics{
+			{Revenue: 100, CreatedAt: at(0), ValidUntil: &until},
+			{Revenue: 120, CreatedAt: at(8)},
+		} {
+			if err := filler.Add(point); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := filler.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"09:00 ", "09:01 held", "09:02 held", "09:03 held", "09:08 "}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("fill=%q: got %q, want %q", method, got, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const (
+	healthResolution   = time.Minute
+	healthRetention    = 7 * 24 * time.Hour
+	healthCheckTimeout = 5 * time.Second
+)
+
+var uptimeWindows = []struct {
+	label  string
+	window time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+type HealthCheck func(ctx context.Context) error
+
+type healthTrack struct {
+	name    string
+	check   HealthCheck
+	buckets []models.HealthBucket
+	last    time.Time
+	ok      bool
+	latency time.Duration
+}
+
+// HealthService runs dependency checks and keeps per-minute results for a
+// week in memory. History starts over when the process restarts.
+type HealthService struct {
+	mu     sync.Mutex
+	tracks []*healthTrack
+}
+
+func NewHealthService() *HealthService {
+	return &HealthService{}
+}
+
+func (s *HealthService) Register(name string, check HealthCheck) *HealthService {
+	s.tracks = append(s.tracks, &healthTrack{name: name, check: check})
+	return s
+}
+
+// Run executes every check once. Failures are recorded, not returned, so the
+// scheduler job itself stays healthy while a dependency is down.
+func (s *HealthService) Run(ctx context.Context) error {
+	for _, track := range s.tracks {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		started := time.Now()
+		err := track.check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Printf("health check %s failed: %v", track.name, err)
+		}
+		s.record(track, started, time.Since(started), err == nil)
+	}
+	return nil
+}
+
+func (s *HealthService) record(track *healthTrack, at time.Time, latency time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	track.last, track.ok, track.latency = at, ok, latency
+
+	start := at.Truncate(healthResolution)
+	if n := len(track.buckets); n == 0 || !track.buckets[n-1].Start.Equal(start) {
+		track.buckets = append(track.buckets, models.HealthBucket{Start: start})
+	}
+	bucket := &track.buckets[len(track.buckets)-1]
+	bucket.Checks++
+	if !ok {
+		bucket.Failures++
+	}
+
+	cutoff := at.Add(-healthRetention)
+	drop := 0
+	for drop < len(track.buckets) && track.buckets[drop].Start.Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		track.buckets = append(track.buckets[:0], track.buckets[drop:]...)
+	}
+}
+
+// History reports every dependency with its uptime and the buckets within
+// window merged to the given step.
+func (s *HealthService) History(window, step time.Duration) ([]models.DependencyHealth, error) {
+	if window < healthResolution || window > healthRetention {
+		return nil, errors.New("window must be between 1m and 7d")
+	}
+	if step < healthResolution || step > window {
+		return nil, errors.New("bucket must be between 1m and the window")
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]models.DependencyHealth, 0, len(s.tracks))
+	for _, track := range s.tracks {
+		dep := models.DependencyHealth{
+			Name:        track.name,
+			Status:      models.HealthDown,
+			LastChecked: track.last,
+			LatencyMs:   float64(track.latency.Microseconds()) / 1000,
+			Uptime:      map[string]float64{},
+			History:     []models.HealthBucket{},
+		}
+		if track.ok {
+			dep.Status = models.HealthUp
+		}
+		for _, w := range uptimeWindows {
+			checks, failures := 0, 0
+			cutoff := now.Add(-w.window)
+			for _, bucket := range track.buckets {
+				if !bucket.Start.Before(cutoff) {
+					checks += bucket.Checks
+					failures += bucket.Failures
+				}
+			}
+			if checks > 0 {
+				dep.Uptime[w.label] = float64(checks-failures) / float64(checks) * 100
+			}
+		}
+		cutoff := now.Add(-window)
+		for _, bucket := range track.buckets {
+			if bucket.Start.Before(cutoff) {
+				continue
+			}
+			start := bucket.Start.Truncate(step)
+			if n := len(dep.History); n == 0 || !dep.History[n-1].Start.Equal(start) {
+				dep.History = append(dep.History, models.HealthBucket{Start: start})
+			}
+			merged := &dep.History[len(dep.History)-1]
+			merged.Checks += bucket.Checks
+			merged.Failures += bucket.Failures
+		}
+		out = append(out, dep)
+	}
+	return out, nil
+}
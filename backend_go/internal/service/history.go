@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// History returns one page of raw snapshots in [from, to], oldest first, and
+// the cursor of the next page, which is empty after the last one. A cursor
+// from an earlier page takes precedence over from.
+func (s *MetricsService) History(ctx context.Context, from, to time.Time, cursor string, pageSize int) ([]models.MetricSnapshot, string, error) {
+	afterAt, afterID := from, int64(0)
+	if cursor != "" {
+		var err error
+		if afterAt, afterID, err = decodeHistoryCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+	// One extra row tells whether another page follows without a second query.
+	rows, err := s.store.MetricsAfter(ctx, afterAt, afterID, to, pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+	defs := s.derived.definitions(ctx)
+	for i := range rows {
+		rows[i].Metrics = evaluate(defs, rows[i].Metrics, models.DerivedAtQuery)
+	}
+	if len(rows) <= pageSize {
+		return rows, "", nil
+	}
+	rows = rows[:pageSize]
+	last := rows[len(rows)-1]
+	return rows, encodeHistoryCursor(last.CreatedAt, last.ID), nil
+}
+
+// A cursor is the keyset position of the last row served. It is opaque to
+// clients so the encoding can change.
+func encodeHistoryCursor(at time.Time, id int64) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d.%d", at.UnixNano(), id))
+}
+
+func decodeHistoryCursor(cursor string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	at, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	rowID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || rowID < 0 {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return time.Unix(0, at).UTC(), rowID, nil
+}
+
+// TrendCheckpoint is Trend plus a checkpoint for TrendSince, the keyset
+// position of the newest snapshot in the window.
+func (s *MetricsService) TrendCheckpoint(ctx context.Context, window int) ([]models.Metrics, string, error) {
+	points, err := s.Trend(ctx, window)
+	if err != nil || len(points) == 0 {
+		return points, "", err
+	}
+	last := points[len(points)-1].CreatedAt
+	// Trend carries no row ids, so read the ids stored at the last timestamp.
+	rows, err := s.store.MetricsAfter(ctx, last, 0, last, maxRangeRows)
+	if err != nil {
+		return nil, "", err
+	}
+	var id int64
+	if len(rows) > 0 {
+		id = rows[len(rows)-1].ID
+	}
+	return points, encodeHistoryCursor(last, id), nil
+}
+
+// TrendSince returns the snapshots stored after checkpoint, oldest first,
+// and the checkpoint to pass next time. When more than window snapshots
+// were added the client is too far behind to append, so reset is set and
+// the latest window is returned instead. Snapshots are ordered by their
+// timestamp, so ones backdated before the checkpoint are not picked up.
+func (s *MetricsService) TrendSince(ctx context.Context, checkpoint string, window int) (points []models.Metrics, next string, reset bool, err error) {
+	afterAt, afterID, err := decodeHistoryCursor(checkpoint)
+	if err != nil {
+		return nil, "", false, err
+	}
+	rows, err := s.store.MetricsAfter(ctx, afterAt, afterID, time.Now().Add(time.Hour), window+1)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if len(rows) > window {
+		points, next, err = s.TrendCheckpoint(ctx, window)
+		return points, next, true, err
+	}
+	if len(rows) == 0 {
+		return []models.Metrics{}, checkpoint, false, nil
+	}
+	defs := s.derived.definitions(ctx)
+	points = make([]models.Metrics, len(rows))
+	for i, row := range rows {
+		points[i] = evaluate(defs, row.Metrics, models.DerivedAtQuery)
+	}
+	last := rows[len(rows)-1]
+	return points, encodeHistoryCursor(last.CreatedAt, last.ID), false, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+var (
+	ErrIdempotencyInFlight = errors.New("idempotency key is still in use by another request")
+	ErrIdempotencyMismatch = errors.New("idempotency key was already used with a different request body")
+)
+
+type IdempotencyService struct {
+	store *store.Store
+	ttl   time.Duration
+}
+
+func NewIdempotencyService(store *store.Store, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+// Begin reserves the key for this request. A non-nil replay means the request
+// already completed and its stored response should be returned as-is.
+func (s *IdempotencyService) Begin(ctx context.Context, scope, key string, body []byte) (string, *models.IdempotencyRecord, error) {
+	id := hashHex([]byte(scope + "\n" + key))
+	requestHash := hashHex(body)
+	record, reserved, err := s.store.ReserveIdempotencyKey(ctx, id, requestHash, s.ttl)
+	if err != nil {
+		return "", nil, err
+	}
+	if reserved {
+		return id, nil, nil
+	}
+	if record.RequestHash != requestHash {
+		return "", nil, ErrIdempotencyMismatch
+	}
+	if !record.Completed() {
+		return "", nil, ErrIdempotencyInFlight
+	}
+	return id, &record, nil
+}
+
+func (s *IdempotencyService) Complete(ctx context.Context, id string, statusCode int, body []byte) error {
+	return s.store.CompleteIdempotencyKey(ctx, id, statusCode, body)
+}
+
+func (s *IdempotencyService) Release(ctx context.Context, id string) error {
+	return s.store.ReleaseIdempotencyKey(ctx, id)
+}
+
+func hashHex(value []byte) string {
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/rules"
+)
+
+var ErrInvalidRule = errors.New("invalid insight rule")
+
+var severities = map[string]bool{
+	models.SeverityInfo:     true,
+	models.SeverityWarning:  true,
+	models.SeverityCritical: true,
+}
+
+func (s *InsightsService) ListRules(ctx context.Context) ([]models.InsightRule, error) {
+	items, err := s.store.ListInsightRules(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.InsightRule{}
+	}
+	return items, nil
+}
+
+func (s *InsightsService) CreateRule(ctx context.Context, rule models.InsightRule) (models.InsightRule, error) {
+	if err := validateRule(&rule); err != nil {
+		return models.InsightRule{}, err
+	}
+	if err := s.validateEscalation(ctx, rule.Escalation); err != nil {
+		return models.InsightRule{}, err
+	}
+	if err := checkTeam(ctx, s.store, rule.TeamID, ErrInvalidRule); err != nil {
+		return models.InsightRule{}, err
+	}
+	return s.store.InsertInsightRule(ctx, rule)
+}
+
+func (s *InsightsService) UpdateRule(ctx context.Context, rule models.InsightRule) (models.InsightRule, error) {
+	if err := validateRule(&rule); err != nil {
+		return models.InsightRule{}, err
+	}
+	if err := s.validateEscalation(ctx, rule.Escalation); err != nil {
+		return models.InsightRule{}, err
+	}
+	if err := checkTeam(ctx, s.store, rule.TeamID, ErrInvalidRule); err != nil {
+		return models.InsightRule{}, err
+	}
+	return s.store.UpdateInsightRule(ctx, rule)
+}
+
+func (s *InsightsService) DeleteRule(ctx context.Context, id int64) error {
+	return s.store.DeleteInsightRule(ctx, id)
+}
+
+func validateRule(rule *models.InsightRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Title = strings.TrimSpace(rule.Title)
+	rule.Template = strings.TrimSpace(rule.Template)
+	if rule.Name == "" || rule.Title == "" || rule.Template == "" {
+		return fmt.Errorf("%w: name, title and template are required", ErrInvalidRule)
+	}
+	if rule.Severity == "" {
+		rule.Severity = models.SeverityInfo
+	}
+	if !severities[rule.Severity] {
+		return fmt.Errorf("%w: severity must be info, warning or critical", ErrInvalidRule)
+	}
+	if rule.Locale == "" {
+		rule.Locale = i18n.Default
+	}
+	if !i18n.Supported(rule.Locale) {
+		return fmt.Errorf("%w: unsupported locale %q", ErrInvalidRule, rule.Locale)
+	}
+	_, err := parseCondition(rule.Condition)
+	return err
+}
+
+// parseCondition parses a rule condition and checks that it only refers to
+// known metrics.
+func parseCondition(condition string) (rules.Expr, error) {
+	expr, err := rules.Parse(condition)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	vars := metricVars(models.Metrics{})
+	for _, name := range rules.Vars(expr) {
+		if _, ok := vars[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown metric %q in condition", ErrInvalidRule, name)
+		}
+	}
+	return expr, nil
+}
+
+// applyRules evaluates every enabled rule against metrics. A rule that fails
+// to evaluate is logged and skipped so one bad rule cannot block the rest.
+// In a scheduled run a matching rule within its minimum interval is skipped.
+func (s *InsightsService) applyRules(ctx context.Context, metrics models.Metrics, scheduled bool) ([]models.Insight, error) {
+	items, err := s.store.ListInsightRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	vars := metricVars(metrics)
+	var generated []models.Insight
+	for _, rule := range items {
+		expr, err := rules.Parse(rule.Condition)
+		if err != nil {
+			log.Printf("insight rule %q skipped: %v", rule.Name, err)
+			continue
+		}
+		matched, err := expr.Eval(vars)
+		if err != nil {
+			log.Printf("insight rule %q skipped: %v", rule.Name, err)
+			continue
+		}
+		if !matched || scheduled && !s.due(InsightKindRule, strconv.FormatInt(rule.ID, 10), time.Now()) {
+			continue
+		}
+		insight, err := s.save(ctx, models.Insight{
+			Title:    rule.Title,
+			Message:  rules.Render(rule.Template, vars),
+			Source:   "rule",
+			Locale:   rule.Locale,
+			Severity: rule.Severity,
+			Metrics:  ruleLinks(metrics, rules.Vars(expr)),
+		})
+		if err != nil {
+			return generated, err
+		}
+		generated = append(generated, insight)
+		if s.escalations != nil {
+			if err := s.escalations.Raise(ctx, rule, insight); err != nil {
+				log.Printf("insight rule %q: raise alert: %v", rule.Name, err)
+			}
+		}
+	}
+	return generated, nil
+}
+
+func metricVars(metrics models.Metrics) map[string]float64 {
+	vars := make(map[string]float64, len(models.MetricKeys))
+	for _, key := range models.MetricKeys {
+		vars[key], _ = metrics.Value(key)
+	}
+	return vars
+}
+
+func ruleLinks(metrics models.Metrics, keys []string) []models.InsightMetricLink {
+	links := make([]models.InsightMetricLink, 0, len(keys))
+	for _, key := range keys {
+		links = append(links, models.InsightMetricLink{MetricKey: key, WindowStart: metrics.CreatedAt, WindowEnd: metrics.CreatedAt})
+	}
+	return links
+}
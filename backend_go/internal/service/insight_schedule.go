@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/scheduler"
+)
+
+const (
+	InsightKindOverview = "overview"
+	InsightKindRule     = "rule"
+)
+
+// ParseInsightIntervals reads entries such as "overview=1h" or "rule=10m".
+func ParseInsightIntervals(values []string) (map[string]time.Duration, error) {
+	intervals := make(map[string]time.Duration, len(values))
+	for _, value := range values {
+		kind, spec, ok := strings.Cut(value, "=")
+		kind = strings.TrimSpace(kind)
+		interval, err := time.ParseDuration(strings.TrimSpace(spec))
+		if !ok || err != nil || interval < 0 {
+			return nil, fmt.Errorf("invalid interval %q (want kind=duration)", value)
+		}
+		if kind != InsightKindOverview && kind != InsightKindRule {
+			return nil, fmt.Errorf("invalid interval %q: kind must be %s or %s", value, InsightKindOverview, InsightKindRule)
+		}
+		intervals[kind] = interval
+	}
+	return intervals, nil
+}
+
+// WithActiveHours limits the scheduled AI overview to the minutes matched
+// by hours, a cron expression such as "* 9-17 * * 1-5", read in loc. Rule
+// insights are still evaluated outside those hours since they raise alerts.
+func (s *InsightsService) WithActiveHours(hours scheduler.Schedule, loc *time.Location) *InsightsService {
+	s.activeHours = hours
+	if loc != nil {
+		s.loc = loc
+	}
+	return s
+}
+
+// WithMinIntervals sets how long the scheduled generator waits before
+// writing another insight of a kind: per locale for the overview and per
+// rule for rule insights.
+func (s *InsightsService) WithMinIntervals(intervals map[string]time.Duration) *InsightsService {
+	s.minIntervals = intervals
+	return s
+}
+
+func (s *InsightsService) inActiveHours(now time.Time) bool {
+	if s.activeHours == nil {
+		return true
+	}
+	minute := now.In(s.loc).Truncate(time.Minute)
+	return s.activeHours.Next(minute.Add(-time.Nanosecond)).Equal(minute)
+}
+
+// due reports whether an insight of kind for key may be written at now and,
+// if so, counts it as written.
+func (s *InsightsService) due(kind, key string, now time.Time) bool {
+	interval := s.minIntervals[kind]
+	if interval <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key = kind + ":" + key
+	if last, ok := s.lastGenerated[key]; ok && now.Sub(last) < interval {
+		return false
+	}
+	s.lastGenerated[key] = now
+	return true
+}
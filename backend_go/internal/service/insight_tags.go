@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"mydashboard-backend/internal/models"
+)
+
+const (
+	maxInsightTags   = 10
+	maxInsightTagLen = 32
+)
+
+// NormalizeInsightTags lowercases and trims tags, drops duplicates and sorts
+// them. A tag is up to 32 letters, digits, - or _, so "pricing" and "emea"
+// are fine but "north america" is not.
+func NormalizeInsightTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if err := checkInsightTag(tag); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	if len(out) > maxInsightTags {
+		return nil, fmt.Errorf("%w: at most %d tags", ErrInvalidInsight, maxInsightTags)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func checkInsightTag(tag string) error {
+	if tag == "" || utf8.RuneCountInString(tag) > maxInsightTagLen || strings.ContainsFunc(tag, invalidTagRune) {
+		return fmt.Errorf("%w: tag %q must be 1 to %d letters, digits, - or _", ErrInvalidInsight, tag, maxInsightTagLen)
+	}
+	return nil
+}
+
+func invalidTagRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+}
+
+// Tags lists the tags in use, most used first.
+func (s *InsightsService) Tags(ctx context.Context) ([]models.InsightTag, error) {
+	tags, err := s.store.InsightTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = []models.InsightTag{}
+	}
+	return tags, nil
+}
+
+// Tagged returns the newest insights carrying every one of tags.
+func (s *InsightsService) Tagged(ctx context.Context, locale string, tags []string, limit int) ([]models.Insight, error) {
+	tags, err := NormalizeInsightTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.store.TaggedInsights(ctx, locale, tags, limit)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Insight{}
+	}
+	return items, nil
+}
+
+// SetTags replaces the tags of an insight. Tags are not part of the edited
+// text, so the insight's version stays the same.
+func (s *InsightsService) SetTags(ctx context.Context, id int64, tags []string) (models.Insight, error) {
+	tags, err := NormalizeInsightTags(tags)
+	if err != nil {
+		return models.Insight{}, err
+	}
+	if err := s.store.SetInsightTags(ctx, id, tags); err != nil {
+		return models.Insight{}, err
+	}
+	return s.store.InsightByID(ctx, id)
+}
+
+// RemoveTag takes tag off one insight; removing a tag it does not carry is
+// not an error.
+func (s *InsightsService) RemoveTag(ctx context.Context, id int64, tag string) (models.Insight, error) {
+	insight, err := s.store.InsightByID(ctx, id)
+	if err != nil {
+		return models.Insight{}, err
+	}
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return s.SetTags(ctx, id, slices.DeleteFunc(insight.Tags, func(t string) bool { return t == tag }))
+}
+
+// RenameTag moves every insight from one tag to another, merging them when
+// both are in use, and reports how many insights were retagged.
+func (s *InsightsService) RenameTag(ctx context.Context, from, to string) (int64, error) {
+	from = strings.ToLower(strings.TrimSpace(from))
+	to = strings.ToLower(strings.TrimSpace(to))
+	if err := checkInsightTag(to); err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 0, fmt.Errorf("%w: tag is already called %q", ErrInvalidInsight, to)
+	}
+	return s.store.RenameInsightTag(ctx, from, to)
+}
+
+// DeleteTag removes tag from every insight.
+func (s *InsightsService) DeleteTag(ctx context.Context, tag string) (int64, error) {
+	return s.store.DeleteInsightTag(ctx, strings.ToLower(strings.TrimSpace(tag)))
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// WithTrashRetention sets how long deleted insights stay restorable before
+// PurgeTrash removes them for good.
+func (s *InsightsService) WithTrashRetention(retention time.Duration) *InsightsService {
+	if retention > 0 {
+		s.trashRetention = retention
+	}
+	return s
+}
+
+// Delete moves an insight to the trash; it disappears from feeds at once.
+func (s *InsightsService) Delete(ctx context.Context, id int64) error {
+	if err := s.store.SoftDeleteInsight(ctx, id, time.Now()); err != nil {
+		return err
+	}
+	s.forget(id)
+	return nil
+}
+
+// Restore brings back an insight that is still in the trash.
+func (s *InsightsService) Restore(ctx context.Context, id int64) (models.Insight, error) {
+	if err := s.store.RestoreInsight(ctx, id, time.Now().Add(-s.trashRetention)); err != nil {
+		return models.Insight{}, err
+	}
+	return s.store.InsightByID(ctx, id)
+}
+
+// Trash lists restorable insights, most recently deleted first.
+func (s *InsightsService) Trash(ctx context.Context, limit int) ([]models.Insight, error) {
+	items, err := s.store.DeletedInsights(ctx, time.Now().Add(-s.trashRetention), limit)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Insight{}
+	}
+	return items, nil
+}
+
+// PurgeTrash permanently deletes insights that have been in the trash longer
+// than the retention; it backs the purge-insights job.
+func (s *InsightsService) PurgeTrash(ctx context.Context) error {
+	purged, err := s.store.PurgeDeletedInsights(ctx, time.Now().Add(-s.trashRetention))
+	if purged > 0 {
+		log.Printf("purged %d deleted insights", purged)
+	}
+	return err
+}
+
+// forget drops an insight from the fallback cache so a degraded feed does
+// not serve a deleted or outdated copy.
+func (s *InsightsService) forget(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for locale, items := range s.cached {
+		kept := items[:0]
+		for _, item := range items {
+			if item.ID != id {
+				kept = append(kept, item)
+			}
+		}
+		s.cached[locale] = kept
+	}
+}
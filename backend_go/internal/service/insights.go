@@ -7,47 +7,131 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"mydashboard-backend/internal/ai"
+	"mydashboard-backend/internal/i18n"
 	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/scheduler"
 	"mydashboard-backend/internal/store"
 )
 
+var ErrInvalidInsight = errors.New("invalid insight")
+
 type InsightsService struct {
 	store *store.Store
 	ai    ai.AIChatBot
+
+	mu     sync.RWMutex
+	cached map[string][]models.Insight
+
+	dedupWindow    time.Duration
+	locales        []string
+	trashRetention time.Duration
+	escalations    *EscalationService
+
+	activeHours   scheduler.Schedule
+	loc           *time.Location
+	minIntervals  map[string]time.Duration
+	lastGenerated map[string]time.Time
 }
 
 func NewInsightsService(store *store.Store, bot ai.AIChatBot) *InsightsService {
 	return &InsightsService{
-		store: store,
-		ai:    bot,
+		store:          store,
+		ai:             bot,
+		cached:         map[string][]models.Insight{},
+		locales:        []string{i18n.Default},
+		trashRetention: defaultTrashRetention,
+		loc:            time.Local,
+		lastGenerated:  map[string]time.Time{},
 	}
 }
 
-func (s *InsightsService) Latest(ctx context.Context, limit int) ([]models.Insight, error) {
-	items, err := s.store.LatestInsights(ctx, limit)
+// WithDedupWindow suppresses insights whose fingerprint matches one created
+// within window; the earlier insight's repeat_count is bumped instead.
+func (s *InsightsService) WithDedupWindow(window time.Duration) *InsightsService {
+	s.dedupWindow = window
+	return s
+}
+
+// WithLocales sets the locales the auto generator writes insights in.
+// Unsupported locales are ignored.
+func (s *InsightsService) WithLocales(locales []string) *InsightsService {
+	var supported []string
+	for _, locale := range locales {
+		if i18n.Supported(locale) {
+			supported = append(supported, locale)
+		}
+	}
+	if len(supported) > 0 {
+		s.locales = supported
+	}
+	return s
+}
+
+// WithEscalation raises alerts for rules with escalation steps when they
+// fire.
+func (s *InsightsService) WithEscalation(escalations *EscalationService) *InsightsService {
+	s.escalations = escalations
+	return s
+}
+
+// Latest reports degraded=true when the store is unavailable and the last
+// cached feed is served instead. An empty feed is seeded with a generated
+// overview, or stays empty when no AI client is configured.
+func (s *InsightsService) Latest(ctx context.Context, locale string, limit int) ([]models.Insight, bool, error) {
+	items, err := s.store.LatestInsights(ctx, locale, limit)
 	if err != nil {
-		return nil, err
+		if cached, ok := s.cachedInsights(locale, limit); ok {
+			return cached, true, nil
+		}
+		return nil, false, err
+	}
+	if len(items) == 0 && s.ai == nil {
+		return []models.Insight{}, false, nil
 	}
 	if len(items) == 0 {
 		metrics, err := s.store.LatestMetrics(ctx)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		if metrics.CreatedAt.IsZero() {
 			metrics = defaultMetrics()
 		}
-		seed, err := s.generateInsight(ctx, metrics, "overview", "auto")
+		seed, err := s.generateInsight(ctx, metrics, nil, "overview", "auto", locale)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		items = []models.Insight{seed}
 	}
-	return items, nil
+	s.remember(locale, items)
+	return items, false, nil
 }
 
-func (s *InsightsService) Create(ctx context.Context, metricKey string) (models.Insight, error) {
+func (s *InsightsService) remember(locale string, items []models.Insight) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cached[locale] = append([]models.Insight(nil), items...)
+}
+
+func (s *InsightsService) cachedInsights(locale string, limit int) ([]models.Insight, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cached := s.cached[locale]
+	if len(cached) == 0 {
+		return nil, false
+	}
+	if limit > len(cached) {
+		limit = len(cached)
+	}
+	return append([]models.Insight(nil), cached[:limit]...), true
+}
+
+func (s *InsightsService) Create(ctx context.Context, metricKey, locale string) (models.Insight, error) {
 	metrics, err := s.store.LatestMetrics(ctx)
 	if err != nil {
 		return models.Insight{}, err
@@ -55,60 +139,365 @@ func (s *InsightsService) Create(ctx context.Context, metricKey string) (models.
 	if metrics.CreatedAt.IsZero() {
 		metrics = defaultMetrics()
 	}
-	return s.generateInsight(ctx, metrics, metricKey, "metric")
+	return s.generateInsight(ctx, metrics, nil, metricKey, "metric", locale)
 }
 
-func (s *InsightsService) GenerateAuto(ctx context.Context, metrics models.Metrics) (models.Insight, error) {
-	return s.generateInsight(ctx, metrics, "overview", "auto")
+const (
+	maxInsightTitle   = 120
+	maxInsightMessage = 2000
+	maxInsightSource  = 32
+)
+
+// generatedSources are the sources the service writes itself, so a posted
+// insight cannot pass for a generated one.
+var generatedSources = map[string]bool{"auto": true, "metric": true, "manual": true, "rule": true, "digest": true}
+
+// InsightPost is human-written commentary. Source defaults to "commentary"
+// and Severity to info; MetricKey optionally links the insight to a metric
+// as of the latest snapshot.
+type InsightPost struct {
+	Title     string
+	Message   string
+	Source    string
+	Severity  string
+	MetricKey string
+	Tags      []string
+	Author    string
+	Locale    string
 }
 
-func (s *InsightsService) generateInsight(ctx context.Context, metrics models.Metrics, focusKey string, source string) (models.Insight, error) {
-	if s.ai == nil {
-		return models.Insight{}, errors.New("ai client not configured")
+// Post stores post as written, without passing it through the AI client or
+// the repeat dedup.
+func (s *InsightsService) Post(ctx context.Context, post InsightPost) (models.Insight, error) {
+	insight := models.Insight{
+		Title:    strings.TrimSpace(post.Title),
+		Message:  strings.TrimSpace(post.Message),
+		Source:   strings.ToLower(strings.TrimSpace(post.Source)),
+		Author:   post.Author,
+		Locale:   post.Locale,
+		Severity: post.Severity,
+	}
+	if insight.Source == "" {
+		insight.Source = "commentary"
 	}
-	trend, err := s.store.Trend(ctx, 12)
+	if insight.Severity == "" {
+		insight.Severity = models.SeverityInfo
+	}
+	switch {
+	case insight.Title == "" || insight.Message == "":
+		return models.Insight{}, fmt.Errorf("%w: title and message must not be empty", ErrInvalidInsight)
+	case utf8.RuneCountInString(insight.Title) > maxInsightTitle:
+		return models.Insight{}, fmt.Errorf("%w: title is longer than %d characters", ErrInvalidInsight, maxInsightTitle)
+	case utf8.RuneCountInString(insight.Message) > maxInsightMessage:
+		return models.Insight{}, fmt.Errorf("%w: message is longer than %d characters", ErrInvalidInsight, maxInsightMessage)
+	case len(insight.Source) > maxInsightSource || strings.ContainsFunc(insight.Source, invalidSourceRune):
+		return models.Insight{}, fmt.Errorf("%w: source must be at most %d letters, digits, - or _", ErrInvalidInsight, maxInsightSource)
+	case generatedSources[insight.Source]:
+		return models.Insight{}, fmt.Errorf("%w: source %q is reserved for generated insights", ErrInvalidInsight, insight.Source)
+	case !severities[insight.Severity]:
+		return models.Insight{}, fmt.Errorf("%w: severity must be info, warning or critical", ErrInvalidInsight)
+	}
+	tags, err := NormalizeInsightTags(post.Tags)
 	if err != nil {
 		return models.Insight{}, err
 	}
-	systemPrompt, userPrompt := buildDeepSeekPrompt(metrics, trend, focusKey)
+	if len(tags) > 0 {
+		insight.Tags = tags
+	}
+	if post.MetricKey != "" {
+		metrics, err := s.store.LatestMetrics(ctx)
+		if err != nil {
+			return models.Insight{}, err
+		}
+		if _, ok := metrics.Value(post.MetricKey); !ok {
+			return models.Insight{}, fmt.Errorf("%w: %s", ErrUnknownMetric, post.MetricKey)
+		}
+		if !metrics.CreatedAt.IsZero() {
+			insight.Metrics = insightLinks(metrics, nil, post.MetricKey)
+		}
+	}
+	insight.Fingerprint = insightFingerprint(insight.Message)
+	return s.store.InsertInsight(ctx, insight)
+}
+
+func invalidSourceRune(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+}
+
+// GenerateAuto writes insights for every matching rule plus one AI overview
+// insight per configured locale.
+func (s *InsightsService) GenerateAuto(ctx context.Context, metrics models.Metrics) ([]models.Insight, error) {
+	return s.runPipeline(ctx, metrics, nil, "auto", false)
+}
+
+// GenerateLatest runs the auto pipeline against the newest snapshot within
+// the active hours and minimum intervals; it backs the scheduled
+// generate-insights job.
+func (s *InsightsService) GenerateLatest(ctx context.Context) error {
+	metrics, err := s.store.LatestMetrics(ctx)
+	if err != nil {
+		return err
+	}
+	if metrics.CreatedAt.IsZero() {
+		metrics = defaultMetrics()
+	}
+	_, err = s.runPipeline(ctx, metrics, nil, "auto", true)
+	return err
+}
+
+// GenerateRange runs the auto pipeline as of the last snapshot in [from, to],
+// using that range (downsampled) as the trend.
+func (s *InsightsService) GenerateRange(ctx context.Context, from, to time.Time) ([]models.Insight, error) {
+	points, err := s.store.MetricsBetween(ctx, from, to, maxRangeRows)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, ErrNoData
+	}
+	return s.runPipeline(ctx, points[len(points)-1], downsample(points, 12), "manual", false)
+}
+
+type InsightRangeJob struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// GenerateRangeJob is the JobHandler for models.JobKindInsightsGenerate.
+func (s *InsightsService) GenerateRangeJob(ctx context.Context, payload json.RawMessage, progress func(int)) (any, error) {
+	var window InsightRangeJob
+	if err := json.Unmarshal(payload, &window); err != nil {
+		return nil, err
+	}
+	items, err := s.GenerateRange(ctx, window.From, window.To)
+	if err != nil && len(items) == 0 {
+		return nil, err
+	}
+	return items, nil
+}
+
+// runPipeline applies the rules and writes an overview per locale. A
+// scheduled run skips what the active hours and minimum intervals hold back,
+// and the overviews altogether when no AI client is configured.
+func (s *InsightsService) runPipeline(ctx context.Context, metrics models.Metrics, trend []models.Metrics, source string, scheduled bool) ([]models.Insight, error) {
+	generated, err := s.applyRules(ctx, metrics, scheduled)
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	now := time.Now()
+	for _, locale := range s.locales {
+		if scheduled && (s.ai == nil || !s.inActiveHours(now) || !s.due(InsightKindOverview, locale, now)) {
+			continue
+		}
+		insight, err := s.generateInsight(ctx, metrics, trend, "overview", source, locale)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", locale, err))
+			continue
+		}
+		generated = append(generated, insight)
+	}
+	return generated, errors.Join(errs...)
+}
+
+// generateInsight reads the latest 12 snapshots as the trend when trend is nil.
+func (s *InsightsService) generateInsight(ctx context.Context, metrics models.Metrics, trend []models.Metrics, focusKey, source, locale string) (models.Insight, error) {
+	if s.ai == nil {
+		return models.Insight{}, errors.New("ai client not configured")
+	}
+	if trend == nil {
+		var err error
+		trend, err = s.store.Trend(ctx, 12)
+		if err != nil {
+			return models.Insight{}, err
+		}
+	}
+	systemPrompt, userPrompt := buildDeepSeekPrompt(metrics, trend, focusKey, locale)
 	message, err := s.ai.Chat(ctx, systemPrompt, userPrompt)
 	if err != nil {
 		return models.Insight{}, err
 	}
-	message = normalizeInsight(message, 300)
-	return s.store.InsertInsight(ctx, models.Insight{
-		Title:   "AI 战略顾问",
-		Message: message,
-		Source:  source,
+	maxRunes, _ := strconv.Atoi(i18n.T(locale, "prompt.max_characters"))
+	message = normalizeInsight(message, maxRunes, locale)
+	return s.save(ctx, models.Insight{
+		Title:    i18n.T(locale, "insight.title"),
+		Message:  message,
+		Source:   source,
+		Metrics:  insightLinks(metrics, trend, focusKey),
+		Locale:   locale,
+		Severity: models.SeverityInfo,
 	})
 }
 
-func buildDeepSeekPrompt(metrics models.Metrics, trend []models.Metrics, focusKey string) (string, string) {
-	systemPrompt := "你是企业战略分析师。基于提供的数据做真实、克制的分析，不编造背景或外部事实。必须输出严格JSON：{\"analysis\":\"...\",\"suggestions\":[\"...\",\"...\"]}。analysis 为连续中文正文，不要标题、分段、列表、符号或Markdown。suggestions 为 2-4 条行动建议短句。总长度不超过300字。"
+func (s *InsightsService) save(ctx context.Context, insight models.Insight) (models.Insight, error) {
+	insight.Fingerprint = insightFingerprint(insight.Message)
+	if s.dedupWindow > 0 {
+		existing, err := s.store.RecentInsightByFingerprint(ctx, insight.Fingerprint, time.Now().Add(-s.dedupWindow))
+		if err == nil {
+			if err := s.store.BumpInsightRepeat(ctx, existing.ID, time.Now()); err != nil {
+				return models.Insight{}, err
+			}
+			existing.RepeatCount++
+			return existing, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return models.Insight{}, err
+		}
+	}
+	return s.store.InsertInsight(ctx, insight)
+}
+
+// insightFingerprint ignores digits, punctuation and spacing so messages that
+// only differ in the quoted figures collapse to the same fingerprint.
+func insightFingerprint(message string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(message) {
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return hashHex([]byte(b.String()))
+}
+
+// insightLinks records which metrics and snapshot window fed the prompt; the
+// overview focus covers every metric.
+func insightLinks(metrics models.Metrics, trend []models.Metrics, focusKey string) []models.InsightMetricLink {
+	start, end := metrics.CreatedAt, metrics.CreatedAt
+	if len(trend) > 0 {
+		start = trend[0].CreatedAt
+		if last := trend[len(trend)-1].CreatedAt; last.After(end) {
+			end = last
+		}
+	}
+	keys := models.MetricKeys
+	if _, ok := metrics.Value(focusKey); ok {
+		keys = []string{focusKey}
+	}
+	links := make([]models.InsightMetricLink, 0, len(keys))
+	for _, key := range keys {
+		links = append(links, models.InsightMetricLink{MetricKey: key, WindowStart: start, WindowEnd: end})
+	}
+	return links
+}
+
+// Context returns the insight with the snapshots it was generated from.
+func (s *InsightsService) Context(ctx context.Context, id int64) (models.InsightContext, error) {
+	insight, err := s.store.InsightByID(ctx, id)
+	if err != nil {
+		return models.InsightContext{}, err
+	}
+	if insight.DeletedAt != nil {
+		return models.InsightContext{}, store.ErrNotFound
+	}
+	result := models.InsightContext{Insight: insight, Snapshots: []models.Metrics{}}
+	if len(insight.Metrics) == 0 {
+		return result, nil
+	}
+	window := insight.Metrics[0]
+	snapshots, err := s.store.MetricsBetween(ctx, window.WindowStart, window.WindowEnd, maxRangeRows)
+	if err != nil {
+		return models.InsightContext{}, err
+	}
+	if snapshots != nil {
+		result.Snapshots = snapshots
+	}
+	return result, nil
+}
+
+// InsightEdit changes an insight's wording or severity; nil fields keep
+// the current value.
+type InsightEdit struct {
+	Title    *string
+	Message  *string
+	Severity *string
+}
+
+// Update applies edit only if the insight is still at version, so two people
+// editing the same insight cannot overwrite each other. A stale version
+// reports store.ErrConflict.
+func (s *InsightsService) Update(ctx context.Context, id int64, version int, edit InsightEdit) (models.Insight, error) {
+	insight, err := s.store.InsightByID(ctx, id)
+	if err != nil {
+		return models.Insight{}, err
+	}
+	if insight.DeletedAt != nil {
+		return models.Insight{}, store.ErrNotFound
+	}
+	if insight.Version != version {
+		return insight, store.ErrConflict
+	}
+	if edit.Title != nil {
+		insight.Title = strings.TrimSpace(*edit.Title)
+	}
+	if edit.Message != nil {
+		insight.Message = strings.TrimSpace(*edit.Message)
+	}
+	if edit.Severity != nil {
+		insight.Severity = *edit.Severity
+	}
+	if insight.Title == "" || insight.Message == "" {
+		return models.Insight{}, fmt.Errorf("%w: title and message must not be empty", ErrInvalidInsight)
+	}
+	if !severities[insight.Severity] {
+		return models.Insight{}, fmt.Errorf("%w: severity must be info, warning or critical", ErrInvalidInsight)
+	}
+	if err := s.store.UpdateInsight(ctx, insight, version); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			current, lookupErr := s.store.InsightByID(ctx, id)
+			if lookupErr == nil {
+				return current, err
+			}
+		}
+		return models.Insight{}, err
+	}
+	s.forget(id)
+	return s.store.InsightByID(ctx, id)
+}
+
+// Between lists insights created in [from, to], optionally limited to some
+// severities, for annotating charts and calendars.
+func (s *InsightsService) Between(ctx context.Context, locale string, from, to time.Time, severities []string, limit int) ([]models.Insight, error) {
+	items, err := s.store.InsightsBetween(ctx, locale, from, to, severities, limit)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Insight{}
+	}
+	return items, nil
+}
+
+func buildDeepSeekPrompt(metrics models.Metrics, trend []models.Metrics, focusKey, locale string) (string, string) {
+	systemPrompt := i18n.T(locale, "prompt.system")
 
 	focus := focusKey
 	if focus == "" {
 		focus = "overview"
 	}
 
-	trendSummary := "趋势数据不足"
+	trendSummary := i18n.T(locale, "prompt.trend.missing")
 	if len(trend) >= 2 {
 		first := trend[0]
 		last := trend[len(trend)-1]
-		trendSummary = "趋势起止：" +
-			first.CreatedAt.Format("15:04") + " -> " + last.CreatedAt.Format("15:04") +
-			"，营收 " + formatDelta(first.Revenue, last.Revenue, "B") +
-			"，增长 " + formatDelta(first.Growth, last.Growth, "%") +
-			"，情绪 " + formatDelta(first.Sentiment, last.Sentiment, "%") +
-			"，积压 " + formatDelta(float64(first.Backlog), float64(last.Backlog), "K")
+		trendSummary = i18n.T(locale, "prompt.trend",
+			first.CreatedAt.Format("15:04"),
+			last.CreatedAt.Format("15:04"),
+			formatDelta(first.Revenue, last.Revenue, "B"),
+			formatDelta(first.Growth, last.Growth, "%"),
+			formatDelta(first.Sentiment, last.Sentiment, "%"),
+			formatDelta(float64(first.Backlog), float64(last.Backlog), "K"),
+		)
 	}
 
-	userPrompt := "公司实时指标：营收 " +
-		formatFloat(metrics.Revenue, 2) + "B，增长 " + formatFloat(metrics.Growth, 1) +
-		"%，情绪 " + formatFloat(metrics.Sentiment, 0) + "%，积压 " +
-		strconv.Itoa(metrics.Backlog) + "K。更新时间：" + metrics.CreatedAt.Format("15:04") +
-		"。关注点：" + focus + "。" + trendSummary +
-		"。请给出真实分析与行动建议。"
+	userPrompt := i18n.T(locale, "prompt.user",
+		formatFloat(metrics.Revenue, 2),
+		formatFloat(metrics.Growth, 1),
+		formatFloat(metrics.Sentiment, 0),
+		metrics.Backlog,
+		metrics.CreatedAt.Format("15:04"),
+		focus,
+		trendSummary,
+	)
 
 	return systemPrompt, userPrompt
 }
@@ -127,9 +516,9 @@ func formatFloat(value float64, decimals int) string {
 	return fmt.Sprintf(format, value)
 }
 
-func normalizeInsight(message string, maxRunes int) string {
+func normalizeInsight(message string, maxRunes int, locale string) string {
 	trimmed := strings.TrimSpace(message)
-	trimmed = tryFormatInsightJSON(trimmed)
+	trimmed = tryFormatInsightJSON(trimmed, locale)
 	trimmed = stripMarkdown(trimmed)
 	trimmed = strings.ReplaceAll(trimmed, "\n", " ")
 	trimmed = strings.Join(strings.Fields(trimmed), " ")
@@ -164,7 +553,7 @@ type insightJSON struct {
 	Suggestions []string `json:"suggestions"`
 }
 
-func tryFormatInsightJSON(value string) string {
+func tryFormatInsightJSON(value, locale string) string {
 	raw := strings.TrimSpace(value)
 	if raw == "" {
 		return raw
@@ -203,5 +592,5 @@ func tryFormatInsightJSON(value string) string {
 	if len(suggestions) == 0 {
 		return analysis
 	}
-	return analysis + " 建议：" + strings.Join(suggestions, "；")
+	return analysis + i18n.T(locale, "insight.suggestions") + strings.Join(suggestions, i18n.T(locale, "insight.separator"))
 }
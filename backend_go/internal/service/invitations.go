@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/auth"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/notify"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	InvitationTokenPrefix = "inv_"
+	defaultInvitationTTL  = 72 * time.Hour
+	maxInvitationTTL      = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidInvitation = errors.New("invalid invitation")
+	ErrInvitationClosed  = errors.New("invitation was already used, revoked or has expired")
+)
+
+// InvitationService lets admins invite people by email instead of creating
+// accounts and passing passwords around. The invitee follows the link in
+// the mail and picks their own username and password; the account gets the
+// role, and the team membership, the admin chose.
+type InvitationService struct {
+	store        *store.Store
+	sender       notify.DirectSender
+	ttl          time.Duration
+	dashboardURL string
+	policy       *PasswordPolicy
+	audit        *AuditService
+}
+
+func NewInvitationService(store *store.Store) *InvitationService {
+	return &InvitationService{store: store, ttl: defaultInvitationTTL, policy: NewPasswordPolicy(8, 0)}
+}
+
+// WithPasswordPolicy sets the policy invitees' passwords must meet, the same
+// one AuthService enforces.
+func (s *InvitationService) WithPasswordPolicy(policy *PasswordPolicy) *InvitationService {
+	s.policy = policy
+	return s
+}
+
+// WithAudit records passwords rejected on acceptance in audit.
+func (s *InvitationService) WithAudit(audit *AuditService) *InvitationService {
+	s.audit = audit
+	return s
+}
+
+// WithSender mails invitations through sender. Without one, or without a
+// dashboard URL to link to, the admin has to pass the link on.
+func (s *InvitationService) WithSender(sender notify.DirectSender) *InvitationService {
+	s.sender = sender
+	return s
+}
+
+// WithTTL sets how long an invitation stays valid when the admin does not
+// say.
+func (s *InvitationService) WithTTL(ttl time.Duration) *InvitationService {
+	if ttl > 0 {
+		s.ttl = ttl
+	}
+	return s
+}
+
+// WithDashboardURL sets the frontend address; invitation links point to its
+// /invite page.
+func (s *InvitationService) WithDashboardURL(dashboardURL string) *InvitationService {
+	s.dashboardURL = strings.TrimRight(dashboardURL, "/")
+	return s
+}
+
+// Create stores an invitation and mails it. The token is only returned
+// here. A ttl of zero means the default.
+func (s *InvitationService) Create(ctx context.Context, inv models.Invitation, ttl time.Duration, by string) (models.Invitation, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(inv.Email))
+	if err != nil || len(address.Address) > 255 {
+		return models.Invitation{}, fmt.Errorf("%w: email must be a valid address", ErrInvalidInvitation)
+	}
+	inv.Email = address.Address
+	if inv.Role == "" {
+		inv.Role = models.RoleViewer
+	}
+	switch inv.Role {
+	case models.RoleAdmin, models.RoleAnalyst, models.RoleViewer:
+	default:
+		return models.Invitation{}, fmt.Errorf("%w: unknown role %q", ErrInvalidInvitation, inv.Role)
+	}
+	if err := checkTeam(ctx, s.store, inv.TeamID, ErrInvalidInvitation); err != nil {
+		return models.Invitation{}, err
+	}
+	switch {
+	case inv.TeamID == nil:
+		inv.TeamRole = ""
+	case inv.TeamRole == "":
+		inv.TeamRole = models.TeamRoleMember
+	case inv.TeamRole != models.TeamRoleMember && inv.TeamRole != models.TeamRoleLead:
+		return models.Invitation{}, fmt.Errorf("%w: team_role must be %s or %s", ErrInvalidInvitation, models.TeamRoleMember, models.TeamRoleLead)
+	}
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+	if ttl < 0 || ttl > maxInvitationTTL {
+		return models.Invitation{}, fmt.Errorf("%w: ttl must be positive and at most 30 days", ErrInvalidInvitation)
+	}
+
+	secret, err := auth.RandomToken()
+	if err != nil {
+		return models.Invitation{}, err
+	}
+	plain := InvitationTokenPrefix + secret
+	now := time.Now()
+	inv.TokenHash = auth.HashToken(plain)
+	inv.InvitedBy = by
+	inv.CreatedAt, inv.ExpiresAt = now, now.Add(ttl)
+	inv.AcceptedAt, inv.UserID, inv.RevokedAt = nil, nil, nil
+	inv, err = s.store.InsertInvitation(ctx, inv)
+	if err != nil {
+		return models.Invitation{}, err
+	}
+	inv.Status = invitationStatus(inv, now)
+	inv.Token = plain
+	if s.dashboardURL != "" {
+		inv.URL = s.dashboardURL + "/invite?token=" + url.QueryEscape(plain)
+	}
+	emailed := s.send(ctx, inv)
+	inv.Emailed = &emailed
+	return inv, nil
+}
+
+// send mails the invitation and reports whether it went out. A failure is
+// logged rather than returned, so the admin still gets the link.
+func (s *InvitationService) send(ctx context.Context, inv models.Invitation) bool {
+	if s.sender == nil || inv.URL == "" {
+		return false
+	}
+	payload, err := json.Marshal(models.InvitationNotice{
+		Title:     "You are invited to the dashboard",
+		Message:   fmt.Sprintf("%s invited you to join as %s.", inv.InvitedBy, inv.Role),
+		InviteURL: inv.URL,
+		InvitedBy: inv.InvitedBy,
+		Role:      inv.Role,
+		ExpiresAt: inv.ExpiresAt,
+	})
+	if err != nil {
+		return false
+	}
+	event := notify.Event{ID: inv.ID, Type: models.EventUserInvited, Payload: payload, CreatedAt: inv.CreatedAt}
+	if err := s.sender.Send(ctx, inv.Email, event); err != nil {
+		log.Printf("invitation %d: %s: %v", inv.ID, s.sender.Name(), err)
+		return false
+	}
+	return true
+}
+
+func (s *InvitationService) List(ctx context.Context) ([]models.Invitation, error) {
+	items, err := s.store.ListInvitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Invitation{}
+	}
+	now := time.Now()
+	for i := range items {
+		items[i].Status = invitationStatus(items[i], now)
+	}
+	return items, nil
+}
+
+// Revoke withdraws an invitation that has not been accepted yet.
+func (s *InvitationService) Revoke(ctx context.Context, id int64) error {
+	return s.store.RevokeInvitation(ctx, id, time.Now())
+}
+
+// Accept creates the invited account. The user, their team membership, the
+// email address for their notifications and the invitation's acceptance
+// are written together, so a taken username leaves the invitation usable.
+func (s *InvitationService) Accept(ctx context.Context, token string, user models.User, password string) (models.User, error) {
+	inv, err := s.store.InvitationByHash(ctx, auth.HashToken(token))
+	if err != nil {
+		return models.User{}, err
+	}
+	now := time.Now()
+	if invitationStatus(inv, now) != models.InvitationPending {
+		return models.User{}, ErrInvitationClosed
+	}
+	user.Role = inv.Role
+	user, err = newUser(ctx, user, password, s.policy, s.audit)
+	if err != nil {
+		return models.User{}, err
+	}
+	err = s.store.WithTx(ctx, func(tx *store.Store) error {
+		created, err := tx.InsertUser(ctx, user)
+		if err != nil {
+			return err
+		}
+		user = created
+		if err := tx.AcceptInvitation(ctx, inv.ID, user.ID, now); errors.Is(err, store.ErrNotFound) {
+			return ErrInvitationClosed
+		} else if err != nil {
+			return err
+		}
+		if inv.TeamID != nil {
+			member := models.TeamMember{TeamID: *inv.TeamID, UserID: user.ID, Role: inv.TeamRole}
+			if err := tx.SaveTeamMember(ctx, member); err != nil {
+				return err
+			}
+		}
+		return tx.SaveNotificationPreferences(ctx, models.NotificationPreferences{
+			UserID: user.ID,
+			Email:  inv.Email,
+			Rules:  []models.NotificationRule{},
+		})
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func invitationStatus(inv models.Invitation, now time.Time) string {
+	switch {
+	case inv.AcceptedAt != nil:
+		return models.InvitationAccepted
+	case inv.RevokedAt != nil:
+		return models.InvitationRevoked
+	case !inv.ExpiresAt.After(now):
+		return models.InvitationExpired
+	}
+	return models.InvitationPending
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	jobMaxAttempts   = 3
+	jobProgressEvery = time.Second
+)
+
+var ErrUnknownJobKind = errors.New("unknown job kind")
+
+// JobHandler runs one queued job. progress takes a percentage in [0, 100];
+// the returned result is stored as JSON on the job.
+type JobHandler func(ctx context.Context, payload json.RawMessage, progress func(int)) (any, error)
+
+type JobQueue struct {
+	store    *store.Store
+	timeout  time.Duration
+	handlers map[string]JobHandler
+}
+
+func NewJobQueue(store *store.Store, timeout time.Duration) *JobQueue {
+	return &JobQueue{
+		store:    store,
+		timeout:  timeout,
+		handlers: map[string]JobHandler{},
+	}
+}
+
+func (q *JobQueue) Handle(kind string, handler JobHandler) *JobQueue {
+	q.handlers[kind] = handler
+	return q
+}
+
+func (q *JobQueue) Enqueue(ctx context.Context, kind string, payload any) (models.Job, error) {
+	if _, ok := q.handlers[kind]; !ok {
+		return models.Job{}, fmt.Errorf("%w: %s", ErrUnknownJobKind, kind)
+	}
+	return q.store.EnqueueJob(ctx, kind, payload)
+}
+
+func (q *JobQueue) Get(ctx context.Context, id int64) (models.Job, error) {
+	return q.store.JobByID(ctx, id)
+}
+
+// Work drains the queue one job at a time and returns once it is empty.
+func (q *JobQueue) Work(ctx context.Context) error {
+	for ctx.Err() == nil {
+		job, err := q.store.ClaimJob(ctx, time.Now().Add(-2*q.timeout), jobMaxAttempts)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		result, runErr := q.run(ctx, job)
+		if runErr != nil {
+			log.Printf("job %d (%s) failed: %v", job.ID, job.Kind, runErr)
+		}
+		if err := q.store.FinishJob(context.WithoutCancel(ctx), job.ID, result, runErr); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (q *JobQueue) run(ctx context.Context, job models.Job) (any, error) {
+	handler, ok := q.handlers[job.Kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownJobKind, job.Kind)
+	}
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+
+	var reported time.Time
+	progress := func(percent int) {
+		if time.Since(reported) < jobProgressEvery {
+			return
+		}
+		reported = time.Now()
+		if err := q.store.UpdateJobProgress(ctx, job.ID, min(max(percent, 0), 99)); err != nil {
+			log.Printf("job %d progress update failed: %v", job.ID, err)
+		}
+	}
+	return handler(ctx, job.Payload, progress)
+}
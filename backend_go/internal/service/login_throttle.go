@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrTooManyAttempts is returned, as a *LockoutError, while an account or a
+// client address is locked out of logging in.
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+type LockoutError struct {
+	Until time.Time
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("%v; try again after %s", ErrTooManyAttempts, e.Until.UTC().Format(time.RFC3339))
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// maxThrottledClients bounds how many addresses failureWindow tracks before
+// it sweeps out the ones with no recent failures.
+const maxThrottledClients = 10000
+
+// failureWindow counts failed logins per client address over a sliding
+// window. It is per process: it slows down guessing from one address,
+// while the per-account lockout, kept in the database, holds across
+// instances.
+type failureWindow struct {
+	mu       sync.Mutex
+	limit    int
+	window   time.Duration
+	failures map[string][]time.Time
+}
+
+func newFailureWindow(limit int, window time.Duration) *failureWindow {
+	return &failureWindow{limit: limit, window: window, failures: map[string][]time.Time{}}
+}
+
+// blockedUntil reports whether key has had limit failures within the window
+// and, if so, when the oldest of them ages out.
+func (f *failureWindow) blockedUntil(key string, now time.Time) (time.Time, bool) {
+	if f.limit <= 0 {
+		return time.Time{}, false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	times := f.prune(key, now)
+	if len(times) < f.limit {
+		return time.Time{}, false
+	}
+	return times[len(times)-f.limit].Add(f.window), true
+}
+
+func (f *failureWindow) add(key string, now time.Time) {
+	if f.limit <= 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.failures) >= maxThrottledClients {
+		for other := range f.failures {
+			f.prune(other, now)
+		}
+	}
+	f.failures[key] = append(f.prune(key, now), now)
+}
+
+func (f *failureWindow) prune(key string, now time.Time) []time.Time {
+	times := f.failures[key]
+	i := 0
+	for i < len(times) && !times[i].After(now.Add(-f.window)) {
+		i++
+	}
+	times = times[i:]
+	if len(times) == 0 {
+		delete(f.failures, key)
+		return nil
+	}
+	f.failures[key] = times
+	return times
+}
+
+// clientHost strips the port from a remote address.
+func clientHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	maxMetaLabel       = 64
+	maxMetaDescription = 255
+	maxMetaUnit        = 16
+	maxMetaThresholds  = 10
+)
+
+var ErrInvalidMetricMeta = errors.New("invalid metric metadata")
+
+var (
+	metaTenant = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+	metaColor  = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+)
+
+// MetricMetaService serves the display metadata of metrics. Built-in labels
+// and descriptions come from the message catalogs and units from the metric
+// catalog; admins override them for every tenant (DefaultTenant) or for one.
+type MetricMetaService struct {
+	store   *store.Store
+	metrics *MetricsService
+	catalog *MetricCatalog
+}
+
+func NewMetricMetaService(store *store.Store, metrics *MetricsService) *MetricMetaService {
+	return &MetricMetaService{store: store, metrics: metrics}
+}
+
+// WithCatalog takes the default unit of each metric from catalog.
+func (s *MetricMetaService) WithCatalog(catalog *MetricCatalog) *MetricMetaService {
+	s.catalog = catalog
+	return s
+}
+
+// List returns the metadata of every metric, stored and derived, as seen by
+// tenant, with labels resolved for locale.
+func (s *MetricMetaService) List(ctx context.Context, tenant, locale string) ([]models.MetricMeta, error) {
+	tenant = metaTenantOrDefault(tenant)
+	overrides, err := s.store.ListMetricMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var units map[string]models.MetricDefinition
+	if s.catalog != nil {
+		units, _, _ = s.catalog.Definitions("")
+	}
+	keys := s.metrics.Keys(ctx)
+	items := make([]models.MetricMeta, 0, len(keys))
+	for _, key := range keys {
+		meta := builtinMetricMeta(key)
+		meta.Unit = units[key].Unit
+		for _, layer := range []string{DefaultTenant, tenant} {
+			for _, override := range overrides {
+				if override.Tenant == layer && override.Key == key {
+					applyMetricMeta(&meta, override)
+				}
+			}
+			if tenant == DefaultTenant {
+				break
+			}
+		}
+		meta.Label = localized(meta.Labels, locale, key)
+		meta.Description = localized(meta.Descriptions, locale, "")
+		items = append(items, meta)
+	}
+	return items, nil
+}
+
+// Overrides lists what admins have saved, for every tenant.
+func (s *MetricMetaService) Overrides(ctx context.Context) ([]models.MetricMetaOverride, error) {
+	items, err := s.store.ListMetricMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.MetricMetaOverride{}
+	}
+	return items, nil
+}
+
+// Save replaces the override of item.Key for item.Tenant.
+func (s *MetricMetaService) Save(ctx context.Context, item models.MetricMetaOverride) (models.MetricMetaOverride, error) {
+	item.Tenant = metaTenantOrDefault(item.Tenant)
+	if err := s.validate(ctx, &item); err != nil {
+		return models.MetricMetaOverride{}, err
+	}
+	if err := s.store.SaveMetricMeta(ctx, item); err != nil {
+		return models.MetricMetaOverride{}, err
+	}
+	items, err := s.store.ListMetricMeta(ctx)
+	if err != nil {
+		return models.MetricMetaOverride{}, err
+	}
+	for _, saved := range items {
+		if saved.Tenant == item.Tenant && saved.Key == item.Key {
+			return saved, nil
+		}
+	}
+	return item, nil
+}
+
+// Delete drops the override, so the metric falls back to the default
+// tenant's or the built-in metadata.
+func (s *MetricMetaService) Delete(ctx context.Context, tenant, key string) error {
+	return s.store.DeleteMetricMeta(ctx, metaTenantOrDefault(tenant), key)
+}
+
+func (s *MetricMetaService) validate(ctx context.Context, item *models.MetricMetaOverride) error {
+	if !metaTenant.MatchString(item.Tenant) {
+		return fmt.Errorf("%w: tenant must match %s", ErrInvalidMetricMeta, metaTenant)
+	}
+	if !slices.Contains(s.metrics.Keys(ctx), item.Key) {
+		return fmt.Errorf("%w %q", ErrUnknownMetric, item.Key)
+	}
+	for field, texts := range map[string]map[string]string{"labels": item.Labels, "descriptions": item.Descriptions} {
+		limit := maxMetaLabel
+		if field == "descriptions" {
+			limit = maxMetaDescription
+		}
+		for locale, text := range texts {
+			if !i18n.Supported(locale) {
+				return fmt.Errorf("%w: %s: unsupported locale %q (want one of %s)", ErrInvalidMetricMeta, field, locale, strings.Join(i18n.Locales(), ", "))
+			}
+			texts[locale] = strings.TrimSpace(text)
+			if utf8.RuneCountInString(texts[locale]) > limit {
+				return fmt.Errorf("%w: %s.%s: at most %d characters", ErrInvalidMetricMeta, field, locale, limit)
+			}
+		}
+	}
+	item.Unit = strings.TrimSpace(item.Unit)
+	if utf8.RuneCountInString(item.Unit) > maxMetaUnit {
+		return fmt.Errorf("%w: unit: at most %d characters", ErrInvalidMetricMeta, maxMetaUnit)
+	}
+	if item.Color != "" && !metaColor.MatchString(item.Color) {
+		return fmt.Errorf("%w: color must be a hex color such as #1f77b4", ErrInvalidMetricMeta)
+	}
+	if len(item.Thresholds) > maxMetaThresholds {
+		return fmt.Errorf("%w: at most %d thresholds", ErrInvalidMetricMeta, maxMetaThresholds)
+	}
+	for i, threshold := range item.Thresholds {
+		if !metaColor.MatchString(threshold.Color) {
+			return fmt.Errorf("%w: thresholds[%d]: color must be a hex color such as #d62728", ErrInvalidMetricMeta, i)
+		}
+		if utf8.RuneCountInString(threshold.Label) > maxMetaLabel {
+			return fmt.Errorf("%w: thresholds[%d]: label: at most %d characters", ErrInvalidMetricMeta, i, maxMetaLabel)
+		}
+		if i > 0 && threshold.Value <= item.Thresholds[i-1].Value {
+			return fmt.Errorf("%w: thresholds must be in ascending order of value", ErrInvalidMetricMeta)
+		}
+	}
+	return nil
+}
+
+func metaTenantOrDefault(tenant string) string {
+	if tenant = strings.TrimSpace(tenant); tenant == "" {
+		return DefaultTenant
+	}
+	return tenant
+}
+
+func builtinMetricMeta(key string) models.MetricMeta {
+	meta := models.MetricMeta{Key: key}
+	for _, locale := range i18n.Locales() {
+		if label, ok := i18n.Lookup(locale, "metric."+key); ok {
+			if meta.Labels == nil {
+				meta.Labels = map[string]string{}
+			}
+			meta.Labels[locale] = label
+		}
+		if description, ok := i18n.Lookup(locale, "metric."+key+".description"); ok {
+			if meta.Descriptions == nil {
+				meta.Descriptions = map[string]string{}
+			}
+			meta.Descriptions[locale] = description
+		}
+	}
+	return meta
+}
+
+// applyMetricMeta lays override over meta; empty fields keep what meta has.
+func applyMetricMeta(meta *models.MetricMeta, override models.MetricMetaOverride) {
+	meta.Labels = mergeTexts(meta.Labels, override.Labels)
+	meta.Descriptions = mergeTexts(meta.Descriptions, override.Descriptions)
+	if override.Unit != "" {
+		meta.Unit = override.Unit
+	}
+	if override.Color != "" {
+		meta.Color = override.Color
+	}
+	if len(override.Thresholds) > 0 {
+		meta.Thresholds = override.Thresholds
+	}
+	if meta.UpdatedAt == nil || override.UpdatedAt.After(*meta.UpdatedAt) {
+		updated := override.UpdatedAt
+		meta.UpdatedAt = &updated
+	}
+}
+
+func mergeTexts(base, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+	for locale, text := range base {
+		merged[locale] = text
+	}
+	for locale, text := range override {
+		if text != "" {
+			merged[locale] = text
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// localized picks the text for locale, then the default locale's, then
+// fallback.
+func localized(texts map[string]string, locale, fallback string) string {
+	if text, ok := texts[locale]; ok {
+		return text
+	}
+	if text, ok := texts[i18n.Default]; ok {
+		return text
+	}
+	return fallback
+}
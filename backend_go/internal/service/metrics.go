@@ -2,16 +2,69 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"maps"
+	"math"
+	"slices"
+	"sync"
 	"time"
 
 	"mydashboard-backend/internal/models"
 	"mydashboard-backend/internal/store"
 )
 
+const (
+	importBatchSize = 500
+	trendPageSize   = 5000
+)
+
 type MetricsService struct {
 	store     *store.Store
 	simulator *Simulation
+
+	mu     sync.RWMutex
+	cached models.Metrics
+
+	batchSize int
+	pendingMu sync.Mutex
+	pending   []models.Metrics
+	// pendingSplit holds the dimension values of the pending snapshots.
+	pendingSplit []models.DimensionValue
+
+	validator *MetricValidator
+	derived   *DerivedMetricService
+	surveys   *SurveyService
+
+	// slowWrite is the insert latency above which simulation backs off, see
+	// WithBackpressure.
+	slowWrite time.Duration
+	pressure  simulationPressure
+
+	dedupWindow    time.Duration
+	dedupTolerance float64
+}
+
+type simulationPressure struct {
+	mu           sync.Mutex
+	backoffUntil time.Time
+	lastWrite    time.Duration
+	skipped      int64
+	coalesced    int64
+	// episode counts the ticks skipped or coalesced in the current backoff,
+	// logged once it ends.
+	episode int64
+}
+
+// SimulationStats reports how the simulation loop is keeping up with the
+// store.
+type SimulationStats struct {
+	Pending     int     `json:"pending"`
+	Skipped     int64   `json:"skipped_ticks"`
+	Coalesced   int64   `json:"coalesced_ticks"`
+	LastWriteMs float64 `json:"last_write_ms"`
+	BackingOff  bool    `json:"backing_off"`
 }
 
 func NewMetricsService(store *store.Store, simulator *Simulation) *MetricsService {
@@ -21,10 +74,75 @@ func NewMetricsService(store *store.Store, simulator *Simulation) *MetricsServic
 	}
 }
 
-func (s *MetricsService) Latest(ctx context.Context) (models.Metrics, error) {
+// WithBatching makes SimulateTick buffer generated snapshots and write them
+// with one INSERT per batch. A size of 1 or less keeps per-tick inserts.
+func (s *MetricsService) WithBatching(size int) *MetricsService {
+	s.batchSize = size
+	return s
+}
+
+// WithBackpressure makes SimulateTick back off when writes to the store take
+// longer than threshold: for as long as the slow write took, ticks are
+// skipped, or with batching buffered without flushing, so they coalesce into
+// the next batch. Zero turns it off.
+func (s *MetricsService) WithBackpressure(threshold time.Duration) *MetricsService {
+	s.slowWrite = threshold
+	return s
+}
+
+// Latest reports degraded=true when the store is unavailable and the last
+// cached snapshot is served instead.
+// WithValidation checks ingested and imported snapshots against bounds.
+// Simulated snapshots are not checked.
+func (s *MetricsService) WithValidation(validator *MetricValidator) *MetricsService {
+	s.validator = validator
+	return s
+}
+
+// WithDerived adds derived metrics to the snapshots read through the service
+// and stores ingest-time ones with the snapshots it writes.
+func (s *MetricsService) WithDerived(derived *DerivedMetricService) *MetricsService {
+	s.derived = derived
+	return s
+}
+
+// WithSurveySentiment makes the rolling NPS of surveys the sentiment of the
+// snapshots the service simulates or ingests, once there are enough
+// responses for it.
+func (s *MetricsService) WithSurveySentiment(surveys *SurveyService) *MetricsService {
+	s.surveys = surveys
+	return s
+}
+
+func (s *MetricsService) surveySentiment(metrics models.Metrics) models.Metrics {
+	if sentiment, ok := s.surveys.Sentiment(); ok {
+		metrics.Sentiment = sentiment
+	}
+	return metrics
+}
+
+// Keys lists the stored metrics followed by the derived ones.
+func (s *MetricsService) Keys(ctx context.Context) []string {
+	return append(slices.Clone(models.MetricKeys), s.derived.Names(ctx)...)
+}
+
+func (s *MetricsService) known(ctx context.Context, key string) bool {
+	return slices.Contains(s.Keys(ctx), key)
+}
+
+func (s *MetricsService) derive(ctx context.Context, metrics models.Metrics) models.Metrics {
+	points := []models.Metrics{metrics}
+	s.derived.Apply(ctx, points)
+	return points[0]
+}
+
+func (s *MetricsService) Latest(ctx context.Context) (models.Metrics, bool, error) {
 	metrics, err := s.store.LatestMetrics(ctx)
 	if err != nil {
-		return models.Metrics{}, err
+		if cached, ok := s.cachedMetrics(); ok {
+			return s.derive(ctx, cached), true, nil
+		}
+		return models.Metrics{}, false, err
 	}
 	if metrics.CreatedAt.IsZero() {
 		metrics = defaultMetrics()
@@ -32,7 +150,11 @@ func (s *MetricsService) Latest(ctx context.Context) (models.Metrics, error) {
 			log.Printf("seed metrics failed: %v", err)
 		}
 	}
-	return metrics, nil
+	if cached, ok := s.cachedMetrics(); ok && cached.CreatedAt.After(metrics.CreatedAt) {
+		metrics = cached
+	}
+	s.remember(metrics)
+	return s.derive(ctx, metrics), false, nil
 }
 
 func (s *MetricsService) Trend(ctx context.Context, window int) ([]models.Metrics, error) {
@@ -49,9 +171,61 @@ func (s *MetricsService) Trend(ctx context.Context, window int) ([]models.Metric
 			}
 		}
 	}
+	s.derived.Apply(ctx, points)
 	return points, nil
 }
 
+// TrendRange calls fn for every snapshot in [from, to], oldest first. The
+// store is read in keyset pages of trendPageSize, so a month of per-second
+// data streams through without being loaded at once. An error from fn stops
+// the walk and is returned.
+func (s *MetricsService) TrendRange(ctx context.Context, from, to time.Time, fn func(models.Metrics) error) error {
+	defs := s.derived.definitions(ctx)
+	it := s.store.IterateMetrics(from, to, trendPageSize)
+	for it.Next(ctx) {
+		if err := fn(evaluate(defs, it.Metrics(), models.DerivedAtQuery)); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// MetricTrend returns the last window points of one metric. Extra history is
+// read so the smoothed series is already warmed up at its first point.
+func (s *MetricsService) MetricTrend(ctx context.Context, key string, window int, method string, span int) ([]models.MetricPoint, error) {
+	if !s.known(ctx, key) {
+		return nil, ErrUnknownMetric
+	}
+	if err := checkSmoothing(method); err != nil {
+		return nil, err
+	}
+	warmup := 0
+	if method != "" && method != SmoothNone {
+		warmup = span - 1
+		if method == SmoothEMA {
+			warmup = span * 3
+		}
+	}
+	points, err := s.Trend(ctx, window+warmup)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(points))
+	for i, point := range points {
+		values[i], _ = point.Value(key)
+	}
+	smoothed := smooth(values, method, span)
+	start := 0
+	if len(points) > window {
+		start = len(points) - window
+	}
+	series := make([]models.MetricPoint, 0, len(points)-start)
+	for i := start; i < len(points); i++ {
+		series = append(series, models.MetricPoint{Timestamp: points[i].CreatedAt, Value: smoothed[i]})
+	}
+	return series, nil
+}
+
 func (s *MetricsService) Simulate(ctx context.Context) (models.Metrics, error) {
 	metrics, err := s.store.LatestMetrics(ctx)
 	if err != nil {
@@ -60,40 +234,383 @@ func (s *MetricsService) Simulate(ctx context.Context) (models.Metrics, error) {
 	if metrics.CreatedAt.IsZero() {
 		metrics = defaultMetrics()
 	}
-	next := s.simulator.NextMetrics(metrics)
-	if err := s.store.InsertMetrics(ctx, next); err != nil {
+	next := s.surveySentiment(s.simulator.NextMetrics(metrics))
+	next = evaluate(s.derived.definitions(ctx), next, models.DerivedAtIngest)
+	err = s.store.WithTx(ctx, func(tx *store.Store) error {
+		rows, err := s.collapse(ctx, tx, []models.Metrics{next})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := tx.InsertMetrics(ctx, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Metrics{}, err
+	}
+	if err := s.store.InsertDimensionValues(ctx, s.simulator.Split(next)); err != nil {
 		return models.Metrics{}, err
 	}
-	return next, nil
+	s.remember(next)
+	return s.derive(ctx, next), nil
 }
 
-func (s *MetricsService) StartSimulation(ctx context.Context, metricEvery, insightEvery time.Duration, insights *InsightsService) {
-	metricsTicker := time.NewTicker(metricEvery)
-	insightTicker := time.NewTicker(insightEvery)
-	defer metricsTicker.Stop()
-	defer insightTicker.Stop()
+func (s *MetricsService) remember(metrics models.Metrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cached = metrics
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-metricsTicker.C:
-			if _, err := s.Simulate(ctx); err != nil {
-				log.Printf("simulate metrics failed: %v", err)
-			}
-		case <-insightTicker.C:
-			metrics, err := s.store.LatestMetrics(ctx)
-			if err != nil {
-				continue
+func (s *MetricsService) cachedMetrics() (models.Metrics, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cached, !s.cached.CreatedAt.IsZero()
+}
+
+// Ingest validates the whole batch before writing any of it and returns the
+// rows it stored. In flag mode the snapshots are written and the violations
+// returned alongside. With WithDedup, snapshots repeating the previous one
+// are accepted but not stored, so they are missing from the result; the
+// extension of the previous snapshot and the inserts happen in one
+// transaction.
+func (s *MetricsService) Ingest(ctx context.Context, items []models.Metrics) ([]models.Metrics, []models.MetricViolation, error) {
+	now := time.Now()
+	for i := range items {
+		if items[i].CreatedAt.IsZero() {
+			items[i].CreatedAt = now
+		}
+		// Derived values are computed here, not taken from the client.
+		items[i].Derived = nil
+		items[i] = s.surveySentiment(items[i])
+	}
+	violations, err := s.validate(ctx, items)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(violations) > 0 {
+		log.Printf("ingested %d snapshots with %d flagged values", len(items), len(violations))
+	}
+	s.derived.Stamp(ctx, items)
+	var saved []models.Metrics
+	err = s.store.WithTx(ctx, func(tx *store.Store) error {
+		rows, err := s.collapse(ctx, tx, items)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := tx.InsertMetricsAt(ctx, row); err != nil {
+				return err
 			}
-			if metrics.CreatedAt.IsZero() {
-				metrics = defaultMetrics()
+		}
+		saved = rows
+		return nil
+	})
+	if err != nil {
+		return nil, violations, err
+	}
+	return saved, violations, nil
+}
+
+// Record writes collector readings as one snapshot: the latest snapshot
+// with the collected metrics replaced, stamped with the newest reading.
+// Only base metrics can be collected; derived ones are computed as usual.
+func (s *MetricsService) Record(ctx context.Context, values []models.MetricValue) error {
+	latest, _, err := s.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	next := latest
+	next.Derived = nil
+	next.CreatedAt = time.Time{}
+	for _, value := range values {
+		switch value.Metric {
+		case "revenue":
+			next.Revenue = value.Value
+		case "growth":
+			next.Growth = value.Value
+		case "sentiment":
+			next.Sentiment = value.Value
+		case "backlog":
+			next.Backlog = int(math.Round(value.Value))
+		default:
+			return fmt.Errorf("%w: %q", ErrUnknownMetric, value.Metric)
+		}
+		if value.At.After(next.CreatedAt) {
+			next.CreatedAt = value.At
+		}
+	}
+	_, _, err = s.Ingest(ctx, []models.Metrics{next})
+	return err
+}
+
+// ImportJob is the JobHandler for models.JobKindMetricsImport. The payload is
+// a JSON array of snapshots, written in batches of importBatchSize.
+func (s *MetricsService) ImportJob(ctx context.Context, payload json.RawMessage, progress func(int)) (any, error) {
+	var items []models.Metrics
+	if err := json.Unmarshal(payload, &items); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range items {
+		if items[i].CreatedAt.IsZero() {
+			items[i].CreatedAt = now
+		}
+		// Derived values are computed here, not taken from the client.
+		items[i].Derived = nil
+	}
+	violations, err := s.validate(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{"count": 0}
+	if len(violations) > 0 {
+		result["flagged"] = violations[:min(len(violations), maxReportedViolations)]
+		result["flagged_count"] = len(violations)
+	}
+	s.derived.Stamp(ctx, items)
+	// One transaction, so a failed batch leaves none of the import behind
+	// and a retried job does not duplicate the batches that succeeded.
+	err = s.store.WithTx(ctx, func(tx *store.Store) error {
+		for start := 0; start < len(items); start += importBatchSize {
+			end := min(start+importBatchSize, len(items))
+			if err := tx.InsertMetricsBatch(ctx, items[start:end]); err != nil {
+				return err
 			}
-			if _, err := insights.GenerateAuto(ctx, metrics); err != nil {
-				log.Printf("simulate insight failed: %v", err)
+			progress(end * 100 / len(items))
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	result["count"] = len(items)
+	return result, nil
+}
+
+// RecomputeRange is the payload of models.JobKindMetricsRecompute.
+type RecomputeRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// RecomputeJob is the JobHandler for models.JobKindMetricsRecompute. It
+// evaluates the ingest-time derived metrics of the snapshots in the range
+// again with the current definitions, after corrections, backfills or a
+// changed formula. Rollups and anomaly scores are computed from the
+// snapshots when read, so they follow without being rebuilt here.
+func (s *MetricsService) RecomputeJob(ctx context.Context, payload json.RawMessage, progress func(int)) (any, error) {
+	var window RecomputeRange
+	if err := json.Unmarshal(payload, &window); err != nil {
+		return nil, err
+	}
+	defs := s.derived.definitions(ctx)
+	restamp := func(metrics models.Metrics) models.Metrics {
+		metrics.Derived = nil
+		return evaluate(defs, metrics, models.DerivedAtIngest)
+	}
+	var scanned, updated int
+	var changed []models.MetricSnapshot
+	flush := func() error {
+		if len(changed) == 0 {
+			return nil
+		}
+		if err := s.store.UpdateMetricsDerived(ctx, changed); err != nil {
+			return err
+		}
+		updated += len(changed)
+		changed = changed[:0]
+		return nil
+	}
+	span := window.To.Sub(window.From)
+	it := s.store.IterateMetrics(window.From, window.To, importBatchSize)
+	for it.Next(ctx) {
+		scanned++
+		stored := it.Metrics()
+		metrics := restamp(stored)
+		if !maps.Equal(stored.Derived, metrics.Derived) {
+			changed = append(changed, models.MetricSnapshot{ID: it.ID(), Metrics: metrics})
+		}
+		if len(changed) >= importBatchSize {
+			if err := flush(); err != nil {
+				return map[string]any{"scanned": scanned, "updated": updated}, err
 			}
 		}
+		if span > 0 {
+			progress(int(stored.CreatedAt.Sub(window.From) * 100 / span))
+		}
+	}
+	err := it.Err()
+	if err == nil {
+		err = flush()
+	}
+	result := map[string]any{"scanned": scanned, "updated": updated}
+	if err != nil {
+		return result, err
 	}
+	s.mu.Lock()
+	if at := s.cached.CreatedAt; !at.IsZero() && !at.Before(window.From) && !at.After(window.To) {
+		s.cached = restamp(s.cached)
+	}
+	s.mu.Unlock()
+	return result, nil
+}
+
+// SimulateTick produces one simulated snapshot, buffering it when batching
+// is enabled.
+func (s *MetricsService) SimulateTick(ctx context.Context) error {
+	backingOff := s.backingOff()
+	if s.batchSize <= 1 {
+		if backingOff {
+			s.skipTick(&s.pressure.skipped)
+			return nil
+		}
+		started := time.Now()
+		_, err := s.Simulate(ctx)
+		s.recordWrite(time.Since(started))
+		return err
+	}
+	if backingOff && s.PendingCount() >= s.batchSize*10 {
+		// The buffer is at the cap FlushPending trims to; more would only
+		// be dropped again.
+		s.skipTick(&s.pressure.skipped)
+		return nil
+	}
+	previous, ok := s.cachedMetrics()
+	if !ok {
+		latest, err := s.store.LatestMetrics(ctx)
+		if err != nil {
+			return err
+		}
+		previous = latest
+		if previous.CreatedAt.IsZero() {
+			previous = defaultMetrics()
+		}
+	}
+	next := s.surveySentiment(s.simulator.NextMetrics(previous))
+	next = evaluate(s.derived.definitions(ctx), next, models.DerivedAtIngest)
+	s.remember(next)
+
+	s.pendingMu.Lock()
+	s.pending = append(s.pending, next)
+	s.pendingSplit = append(s.pendingSplit, s.simulator.Split(next)...)
+	full := len(s.pending) >= s.batchSize
+	s.pendingMu.Unlock()
+	if full && backingOff {
+		s.skipTick(&s.pressure.coalesced)
+		return nil
+	}
+	if full {
+		return s.FlushPending(ctx)
+	}
+	return nil
+}
+
+func (s *MetricsService) backingOff() bool {
+	if s.slowWrite <= 0 {
+		return false
+	}
+	s.pressure.mu.Lock()
+	defer s.pressure.mu.Unlock()
+	return time.Now().Before(s.pressure.backoffUntil)
+}
+
+func (s *MetricsService) skipTick(counter *int64) {
+	s.pressure.mu.Lock()
+	defer s.pressure.mu.Unlock()
+	*counter++
+	s.pressure.episode++
+}
+
+// recordWrite starts a backoff after a slow write and ends it after a fast
+// one, logging once per backoff rather than once per tick.
+func (s *MetricsService) recordWrite(took time.Duration) {
+	if s.slowWrite <= 0 {
+		return
+	}
+	s.pressure.mu.Lock()
+	defer s.pressure.mu.Unlock()
+	s.pressure.lastWrite = took
+	if took > s.slowWrite {
+		if s.pressure.backoffUntil.IsZero() {
+			log.Printf("simulation: write took %s (threshold %s), backing off", took.Round(time.Millisecond), s.slowWrite)
+		}
+		s.pressure.backoffUntil = time.Now().Add(took)
+		return
+	}
+	if !s.pressure.backoffUntil.IsZero() {
+		log.Printf("simulation: writes recovered (%s), %d ticks skipped or coalesced while backing off", took.Round(time.Millisecond), s.pressure.episode)
+		s.pressure.backoffUntil = time.Time{}
+		s.pressure.episode = 0
+	}
+}
+
+// SimulationStats reports the buffer and the ticks lost to backpressure
+// since start.
+func (s *MetricsService) SimulationStats() SimulationStats {
+	pending := s.PendingCount()
+	s.pressure.mu.Lock()
+	defer s.pressure.mu.Unlock()
+	return SimulationStats{
+		Pending:     pending,
+		Skipped:     s.pressure.skipped,
+		Coalesced:   s.pressure.coalesced,
+		LastWriteMs: float64(s.pressure.lastWrite.Microseconds()) / 1000,
+		BackingOff:  time.Now().Before(s.pressure.backoffUntil),
+	}
+}
+
+// FlushPending keeps unwritten rows for the next attempt, capped so a long
+// outage cannot grow the buffer without bound.
+func (s *MetricsService) FlushPending(ctx context.Context) error {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	if len(s.pending) == 0 {
+		return nil
+	}
+	started := time.Now()
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		rows, err := s.collapse(ctx, tx, s.pending)
+		if err != nil {
+			return err
+		}
+		return tx.InsertMetricsBatch(ctx, rows)
+	})
+	s.recordWrite(time.Since(started))
+	if err != nil {
+		if limit := s.batchSize * 10; len(s.pending) > limit {
+			s.pending = s.pending[len(s.pending)-limit:]
+			s.pendingSplit = slices.DeleteFunc(s.pendingSplit, func(value models.DimensionValue) bool {
+				return value.RecordedAt.Before(s.pending[0].CreatedAt)
+			})
+		}
+		return fmt.Errorf("flush simulated metrics (%d rows): %w", len(s.pending), err)
+	}
+	s.pending = s.pending[:0]
+	// The snapshots are written, so a failed split is dropped rather than
+	// retried alongside later ones.
+	split := s.pendingSplit
+	s.pendingSplit = nil
+	for start := 0; start < len(split); start += maxDimensionBatch {
+		if err := s.store.InsertDimensionValues(ctx, split[start:min(start+maxDimensionBatch, len(split))]); err != nil {
+			return fmt.Errorf("flush simulated dimension values (%d rows): %w", len(split), err)
+		}
+	}
+	return nil
+}
+
+// PendingCount reports how many simulated snapshots are waiting for a flush.
+func (s *MetricsService) PendingCount() int {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	return len(s.pending)
+}
+
+// PruneBefore deletes snapshots older than cutoff.
+func (s *MetricsService) PruneBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return s.store.DeleteMetricsBefore(ctx, cutoff)
 }
 
 func defaultMetrics() models.Metrics {
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/notify"
+	"mydashboard-backend/internal/store"
+)
+
+var ErrInvalidPreferences = errors.New("invalid notification preferences")
+
+// notificationCategories maps the categories users choose from to the
+// outbox events they cover.
+var notificationCategories = map[string]string{
+	"insights":    models.EventInsightCreated,
+	"alerts":      models.EventAlertEscalated,
+	"digest":      models.EventDailySummary,
+	"assignments": models.EventInsightAssigned,
+}
+
+var slackUserID = regexp.MustCompile(`^[UW][A-Z0-9]{2,30}$`)
+
+// NotificationPreferenceService keeps each user's personal notification
+// settings and, as a notifier for the outbox dispatcher, delivers events to
+// users directly. Escalations reach a user on their first step only, and
+// assignments only the assignee.
+type NotificationPreferenceService struct {
+	store   *store.Store
+	senders map[string]notify.DirectSender
+}
+
+func NewNotificationPreferenceService(store *store.Store) *NotificationPreferenceService {
+	return &NotificationPreferenceService{store: store, senders: map[string]notify.DirectSender{}}
+}
+
+// WithSender delivers rules of the given delivery, email or slack, through
+// sender. Rules for a delivery without a sender are kept but not delivered.
+func (s *NotificationPreferenceService) WithSender(delivery string, sender notify.DirectSender) *NotificationPreferenceService {
+	s.senders[delivery] = sender
+	return s
+}
+
+// Deliveries lists the deliveries this server can currently send.
+func (s *NotificationPreferenceService) Deliveries() []string {
+	out := []string{models.DeliveryNone}
+	for delivery := range s.senders {
+		out = append(out, delivery)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Get returns a user's settings; a user who never saved any receives
+// nothing.
+func (s *NotificationPreferenceService) Get(ctx context.Context, userID int64) (models.NotificationPreferences, error) {
+	prefs, err := s.store.NotificationPreferences(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return models.NotificationPreferences{UserID: userID, Rules: []models.NotificationRule{}}, nil
+	}
+	return prefs, err
+}
+
+func (s *NotificationPreferenceService) Save(ctx context.Context, prefs models.NotificationPreferences) (models.NotificationPreferences, error) {
+	if err := validatePreferences(&prefs); err != nil {
+		return models.NotificationPreferences{}, err
+	}
+	if err := s.store.SaveNotificationPreferences(ctx, prefs); err != nil {
+		return models.NotificationPreferences{}, err
+	}
+	return s.store.NotificationPreferences(ctx, prefs.UserID)
+}
+
+func validatePreferences(prefs *models.NotificationPreferences) error {
+	prefs.Email = strings.TrimSpace(prefs.Email)
+	prefs.SlackUserID = strings.TrimSpace(prefs.SlackUserID)
+	if prefs.Email != "" {
+		addr, err := mail.ParseAddress(prefs.Email)
+		if err != nil || addr.Name != "" {
+			return fmt.Errorf("%w: email must be a plain address such as ana@example.com", ErrInvalidPreferences)
+		}
+	}
+	if prefs.SlackUserID != "" && !slackUserID.MatchString(prefs.SlackUserID) {
+		return fmt.Errorf("%w: slack_user_id must be a Slack member id such as U012AB3CD", ErrInvalidPreferences)
+	}
+	if prefs.Rules == nil {
+		prefs.Rules = []models.NotificationRule{}
+	}
+	var seen []string
+	for i := range prefs.Rules {
+		rule := &prefs.Rules[i]
+		if _, ok := notificationCategories[rule.Category]; !ok {
+			return fmt.Errorf("%w: category must be insights, alerts, digest or assignments", ErrInvalidPreferences)
+		}
+		if slices.Contains(seen, rule.Category) {
+			return fmt.Errorf("%w: more than one rule for %s", ErrInvalidPreferences, rule.Category)
+		}
+		seen = append(seen, rule.Category)
+		switch rule.Delivery {
+		case models.DeliveryNone:
+		case models.DeliveryEmail:
+			if prefs.Email == "" {
+				return fmt.Errorf("%w: %s by email needs an email address", ErrInvalidPreferences, rule.Category)
+			}
+		case models.DeliverySlack:
+			if prefs.SlackUserID == "" {
+				return fmt.Errorf("%w: %s by slack needs a slack_user_id", ErrInvalidPreferences, rule.Category)
+			}
+		default:
+			return fmt.Errorf("%w: delivery must be email, slack or none", ErrInvalidPreferences)
+		}
+		if rule.MinSeverity != "" && !severities[rule.MinSeverity] {
+			return fmt.Errorf("%w: min_severity must be info, warning or critical", ErrInvalidPreferences)
+		}
+	}
+	return nil
+}
+
+func (s *NotificationPreferenceService) Name() string {
+	return "user preferences"
+}
+
+func (s *NotificationPreferenceService) Notify(ctx context.Context, event notify.Event) error {
+	targets, err := s.Targets(ctx, event)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, target := range targets {
+		if err := s.NotifyTarget(ctx, target, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Targets lists the ids of the users whose settings ask for the event.
+func (s *NotificationPreferenceService) Targets(ctx context.Context, event notify.Event) ([]string, error) {
+	if eventCategory(event) == "" || len(s.senders) == 0 {
+		return nil, nil
+	}
+	all, err := s.store.ActiveNotificationPreferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, prefs := range all {
+		if _, _, ok := s.recipient(prefs, event); ok {
+			targets = append(targets, strconv.FormatInt(prefs.UserID, 10))
+		}
+	}
+	return targets, nil
+}
+
+// NotifyTarget sends the event to one user by id, following the settings
+// they have now. A user who has since opted out is skipped.
+func (s *NotificationPreferenceService) NotifyTarget(ctx context.Context, target string, event notify.Event) error {
+	userID, err := strconv.ParseInt(target, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid user target %q", target)
+	}
+	prefs, err := s.store.NotificationPreferences(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sender, to, ok := s.recipient(prefs, event)
+	if !ok {
+		return nil
+	}
+	if err := sender.Send(ctx, to, event); err != nil {
+		return fmt.Errorf("%s to user %d: %w", sender.Name(), prefs.UserID, err)
+	}
+	return nil
+}
+
+func eventCategory(event notify.Event) string {
+	for name, eventType := range notificationCategories {
+		if eventType == event.Type {
+			return name
+		}
+	}
+	return ""
+}
+
+// recipient picks the sender and address the user's rule for the event's
+// category asks for, or reports that the user should not receive it.
+func (s *NotificationPreferenceService) recipient(prefs models.NotificationPreferences, event notify.Event) (notify.DirectSender, string, bool) {
+	category := eventCategory(event)
+	var payload struct {
+		Severity string `json:"severity"`
+		Step     int    `json:"step"`
+		UserID   int64  `json:"user_id"`
+	}
+	_ = json.Unmarshal(event.Payload, &payload)
+	switch {
+	case category == "":
+		return nil, "", false
+	case event.Type == models.EventAlertEscalated && payload.Step > 1:
+		return nil, "", false
+	case event.Type == models.EventInsightAssigned && prefs.UserID != payload.UserID:
+		return nil, "", false
+	}
+	i := slices.IndexFunc(prefs.Rules, func(rule models.NotificationRule) bool { return rule.Category == category })
+	if i < 0 {
+		return nil, "", false
+	}
+	rule := prefs.Rules[i]
+	if payload.Severity != "" && rule.MinSeverity != "" && severityOrder[payload.Severity] < severityOrder[rule.MinSeverity] {
+		return nil, "", false
+	}
+	sender, ok := s.senders[rule.Delivery]
+	if !ok {
+		return nil, "", false
+	}
+	to := prefs.Email
+	if rule.Delivery == models.DeliverySlack {
+		to = prefs.SlackUserID
+	}
+	return sender, to, true
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/notify"
+	"mydashboard-backend/internal/store"
+)
+
+const eventChannelTest = "channel.test"
+
+var ErrInvalidChannel = errors.New("invalid notification channel")
+
+var (
+	channelKinds  = []string{models.ChannelWebhook, models.ChannelDingTalk, models.ChannelFeishu, models.ChannelTeams, models.ChannelPagerDuty, models.ChannelOpsgenie}
+	channelEvents = []string{models.EventInsightCreated, models.EventDailySummary}
+	pagerURLs     = map[string]string{
+		models.ChannelPagerDuty: notify.PagerDutyEventsURL,
+		models.ChannelOpsgenie:  notify.OpsgenieAlertsURL,
+	}
+	severityOrder = map[string]int{
+		models.SeverityInfo:     0,
+		models.SeverityWarning:  1,
+		models.SeverityCritical: 2,
+	}
+)
+
+// NotificationService manages per-channel destinations and is itself a
+// notifier for the outbox dispatcher. Channels are read on every delivery so
+// changes apply to the next event without a restart.
+type NotificationService struct {
+	store        *store.Store
+	dashboardURL string
+}
+
+func NewNotificationService(store *store.Store) *NotificationService {
+	return &NotificationService{store: store}
+}
+
+// WithDashboardURL sets where notifiers that support links point back to.
+func (s *NotificationService) WithDashboardURL(url string) *NotificationService {
+	s.dashboardURL = url
+	return s
+}
+
+func (s *NotificationService) Name() string {
+	return "channels"
+}
+
+func (s *NotificationService) Notify(ctx context.Context, event notify.Event) error {
+	targets, err := s.Targets(ctx, event)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, target := range targets {
+		if err := s.NotifyTarget(ctx, target, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Targets lists the ids of the enabled channels the event goes to.
+func (s *NotificationService) Targets(ctx context.Context, event notify.Event) ([]string, error) {
+	channels, err := s.store.ListNotificationChannels(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, channel := range channels {
+		if channelWants(channel, event) {
+			targets = append(targets, strconv.FormatInt(channel.ID, 10))
+		}
+	}
+	return targets, nil
+}
+
+// NotifyTarget sends the event to one channel by id. A channel deleted or
+// disabled since the event was fanned out is skipped.
+func (s *NotificationService) NotifyTarget(ctx context.Context, target string, event notify.Event) error {
+	id, err := strconv.ParseInt(target, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid channel target %q", target)
+	}
+	channel, err := s.store.NotificationChannelByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !channel.Enabled {
+		return nil
+	}
+	notifier := s.channelNotifier(channel)
+	if err := notifier.Notify(ctx, s.withSnapshot(ctx, event)); err != nil {
+		return errors.New(notifier.Name() + ": " + err.Error())
+	}
+	return nil
+}
+
+func channelWants(channel models.NotificationChannel, event notify.Event) bool {
+	var payload struct {
+		Severity  string `json:"severity"`
+		ChannelID int64  `json:"channel_id"`
+	}
+	_ = json.Unmarshal(event.Payload, &payload)
+	switch {
+	case event.Type == models.EventAlertEscalated:
+		// Escalations name their channel and skip its filters.
+		return channel.ID == payload.ChannelID
+	case !slices.Contains(channel.EventTypes, event.Type):
+		return false
+	case payload.Severity != "" && severityOrder[payload.Severity] < severityOrder[channel.MinSeverity]:
+		return false
+	}
+	return true
+}
+
+// withSnapshot attaches the metrics in effect when an insight was created,
+// so channels can show the values behind it. Failures leave the event as is.
+func (s *NotificationService) withSnapshot(ctx context.Context, event notify.Event) notify.Event {
+	if event.Type != models.EventInsightCreated {
+		return event
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(event.Payload, &fields); err != nil {
+		return event
+	}
+	snapshot, err := s.store.MetricsAt(ctx, event.CreatedAt)
+	if err != nil {
+		return event
+	}
+	if fields["snapshot"], err = json.Marshal(snapshot); err != nil {
+		return event
+	}
+	if payload, err := json.Marshal(fields); err == nil {
+		event.Payload = payload
+	}
+	return event
+}
+
+func (s *NotificationService) channelNotifier(channel models.NotificationChannel) notify.Notifier {
+	switch channel.Kind {
+	case models.ChannelDingTalk:
+		return notify.NewDingTalkNotifier(channel.Name, channel.WebhookURL, channel.Secret)
+	case models.ChannelFeishu:
+		return notify.NewFeishuNotifier(channel.Name, channel.WebhookURL, channel.Secret)
+	case models.ChannelTeams:
+		return notify.NewTeamsNotifier(channel.Name, channel.WebhookURL, s.dashboardURL)
+	case models.ChannelPagerDuty:
+		return notify.NewPagerDutyNotifier(channel.Name, channel.WebhookURL, channel.Secret)
+	case models.ChannelOpsgenie:
+		return notify.NewOpsgenieNotifier(channel.Name, channel.WebhookURL, channel.Secret)
+	default:
+		return notify.NewWebhookNotifier(channel.WebhookURL)
+	}
+}
+
+func (s *NotificationService) List(ctx context.Context) ([]models.NotificationChannel, error) {
+	items, err := s.store.ListNotificationChannels(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.NotificationChannel{}
+	}
+	return items, nil
+}
+
+func (s *NotificationService) Create(ctx context.Context, channel models.NotificationChannel) (models.NotificationChannel, error) {
+	if err := validateChannel(&channel); err != nil {
+		return models.NotificationChannel{}, err
+	}
+	return s.store.InsertNotificationChannel(ctx, channel)
+}
+
+// Update replaces the channel. A nil secret keeps the stored one, since the
+// API never returns it.
+func (s *NotificationService) Update(ctx context.Context, channel models.NotificationChannel, secret *string) (models.NotificationChannel, error) {
+	existing, err := s.store.NotificationChannelByID(ctx, channel.ID)
+	if err != nil {
+		return models.NotificationChannel{}, err
+	}
+	channel.Secret = existing.Secret
+	if secret != nil {
+		channel.Secret = *secret
+	}
+	if err := validateChannel(&channel); err != nil {
+		return models.NotificationChannel{}, err
+	}
+	return s.store.UpdateNotificationChannel(ctx, channel)
+}
+
+func (s *NotificationService) Delete(ctx context.Context, id int64) error {
+	return s.store.DeleteNotificationChannel(ctx, id)
+}
+
+// Test sends a sample message straight to the channel, bypassing the outbox
+// and the channel's filters.
+func (s *NotificationService) Test(ctx context.Context, id int64) error {
+	channel, err := s.store.NotificationChannelByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	payload, _ := json.Marshal(map[string]string{
+		"title":   "MyDashboard",
+		"message": "Test message for notification channel " + channel.Name,
+	})
+	return s.channelNotifier(channel).Notify(ctx, notify.Event{
+		Type:      eventChannelTest,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	})
+}
+
+func validateChannel(channel *models.NotificationChannel) error {
+	channel.Name = strings.TrimSpace(channel.Name)
+	channel.WebhookURL = strings.TrimSpace(channel.WebhookURL)
+	if channel.Name == "" || len(channel.Name) > 128 {
+		return fmt.Errorf("%w: name must be 1-128 characters", ErrInvalidChannel)
+	}
+	if !slices.Contains(channelKinds, channel.Kind) {
+		return fmt.Errorf("%w: kind must be one of %s", ErrInvalidChannel, strings.Join(channelKinds, ", "))
+	}
+	// Paging services authenticate with the secret and post to their public
+	// endpoint unless another one, e.g. an EU region, is given.
+	switch channel.Kind {
+	case models.ChannelPagerDuty, models.ChannelOpsgenie:
+		if channel.Secret == "" {
+			return fmt.Errorf("%w: %s channels need the integration key as secret", ErrInvalidChannel, channel.Kind)
+		}
+		if channel.WebhookURL == "" {
+			channel.WebhookURL = pagerURLs[channel.Kind]
+		}
+	}
+	target, err := url.Parse(channel.WebhookURL)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+		return fmt.Errorf("%w: webhook_url must be an http(s) URL", ErrInvalidChannel)
+	}
+	if len(channel.EventTypes) == 0 {
+		channel.EventTypes = []string{models.EventInsightCreated}
+	}
+	for _, eventType := range channel.EventTypes {
+		if !slices.Contains(channelEvents, eventType) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidChannel, eventType)
+		}
+	}
+	if channel.MinSeverity == "" {
+		channel.MinSeverity = models.SeverityCritical
+	}
+	if !severities[channel.MinSeverity] {
+		return fmt.Errorf("%w: min_severity must be info, warning or critical", ErrInvalidChannel)
+	}
+	return nil
+}
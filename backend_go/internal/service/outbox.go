@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/notify"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	outboxBatchSize   = 20
+	outboxMaxAttempts = 10
+	// outboxLease is how long a claimed event or delivery belongs to this
+	// instance; claims held by an instance that died are retried after it.
+	outboxLease = 2 * time.Minute
+)
+
+// OutboxDispatcher delivers outbox events in two steps. New events are
+// claimed and fanned out into one delivery per target: a notifier, or each
+// channel or user of a notifier that implements notify.Fanout. Deliveries
+// are then claimed and sent one by one, so a failure only retries its own
+// target and replicas never send the same row twice.
+type OutboxDispatcher struct {
+	store     *store.Store
+	notifiers []notify.Notifier
+	silences  *SilenceService
+}
+
+func NewOutboxDispatcher(store *store.Store, notifiers ...notify.Notifier) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		store:     store,
+		notifiers: notifiers,
+	}
+}
+
+// WithSilences skips delivery of events matched by an active silence. They
+// are marked silenced instead of delivered.
+func (d *OutboxDispatcher) WithSilences(silences *SilenceService) *OutboxDispatcher {
+	d.silences = silences
+	return d
+}
+
+func (d *OutboxDispatcher) DispatchPending(ctx context.Context) error {
+	if err := d.fanOut(ctx); err != nil {
+		return err
+	}
+	return d.deliverPending(ctx)
+}
+
+// fanOut turns claimed events into deliveries. An event whose targets cannot
+// be listed stays claimed and is fanned out again once its lease lapses.
+func (d *OutboxDispatcher) fanOut(ctx context.Context) error {
+	events, err := d.store.ClaimOutbox(ctx, outboxBatchSize, outboxLease)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		notification := notify.Event{
+			ID:        event.ID,
+			Type:      event.EventType,
+			Payload:   event.Payload,
+			CreatedAt: event.CreatedAt,
+		}
+		if d.silences != nil {
+			silenceID, silenced, err := d.silences.Match(ctx, notification)
+			if err != nil {
+				return err
+			}
+			if silenced {
+				if err := d.store.MarkOutboxSilenced(ctx, event.ID, silenceID); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		targets, err := d.targets(ctx, notification)
+		if err != nil {
+			return fmt.Errorf("outbox event %d: %w", event.ID, err)
+		}
+		if err := d.store.FanOutOutbox(ctx, event.ID, targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *OutboxDispatcher) targets(ctx context.Context, event notify.Event) ([]string, error) {
+	var targets []string
+	for _, notifier := range d.notifiers {
+		fanout, ok := notifier.(notify.Fanout)
+		if !ok {
+			targets = append(targets, notifier.Name())
+			continue
+		}
+		names, err := fanout.Targets(ctx, event)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", notifier.Name(), err)
+		}
+		for _, name := range names {
+			targets = append(targets, notifier.Name()+"/"+name)
+		}
+	}
+	return targets, nil
+}
+
+func (d *OutboxDispatcher) deliverPending(ctx context.Context) error {
+	deliveries, err := d.store.ClaimDeliveries(ctx, outboxBatchSize, outboxLease)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		event := delivery.Event
+		deliverErr := d.deliver(ctx, delivery.Target, notify.Event{
+			ID:        event.ID,
+			Type:      event.EventType,
+			Payload:   event.Payload,
+			CreatedAt: event.CreatedAt,
+		})
+		if deliverErr == nil {
+			if err := d.store.MarkDeliveryDelivered(ctx, delivery.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		attempts := delivery.Attempts + 1
+		giveUp := attempts >= outboxMaxAttempts
+		nextAttempt := time.Now().Add(time.Duration(attempts) * 5 * time.Second)
+		if giveUp {
+			log.Printf("outbox event %d to %s dropped after %d attempts: %v", event.ID, delivery.Target, attempts, deliverErr)
+		}
+		if err := d.store.MarkDeliveryFailed(ctx, delivery.ID, deliverErr, nextAttempt, giveUp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver sends event to the notifier named by target, or to one target of
+// a fanning-out notifier when target is "notifier/target".
+func (d *OutboxDispatcher) deliver(ctx context.Context, target string, event notify.Event) error {
+	for _, notifier := range d.notifiers {
+		if target == notifier.Name() {
+			return notifier.Notify(ctx, event)
+		}
+		fanout, ok := notifier.(notify.Fanout)
+		if !ok {
+			continue
+		}
+		if name, found := strings.CutPrefix(target, notifier.Name()+"/"); found {
+			return fanout.NotifyTarget(ctx, name, event)
+		}
+	}
+	return fmt.Errorf("%s: notifier no longer configured", target)
+}
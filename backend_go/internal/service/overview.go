@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	overviewSpan        = 24 * time.Hour
+	overviewPoints      = 48
+	overviewInsightPool = 20
+)
+
+// Overview is the caller-independent part of the landing page: snapshots
+// in base units with derived values, before any redaction or unit
+// conversion, which depend on who asks.
+type Overview struct {
+	Latest     models.Metrics
+	Baseline   models.Metrics
+	Trend      []models.Metrics
+	OpenAlerts int
+	Insights   []models.Insight
+	Degraded   bool
+	BuiltAt    time.Time
+}
+
+// OverviewService materializes the overview per locale. Refresh rebuilds
+// the locales requested so far on a schedule, so requests are normally
+// answered from memory; an entry older than ttl is rebuilt on demand.
+type OverviewService struct {
+	store    *store.Store
+	metrics  *MetricsService
+	insights *InsightsService
+	ttl      time.Duration
+
+	build   sync.Mutex
+	mu      sync.RWMutex
+	entries map[string]Overview
+}
+
+func NewOverviewService(store *store.Store, metrics *MetricsService, insights *InsightsService, ttl time.Duration) *OverviewService {
+	return &OverviewService{store: store, metrics: metrics, insights: insights, ttl: ttl, entries: map[string]Overview{}}
+}
+
+func (s *OverviewService) Get(ctx context.Context, locale string) (Overview, error) {
+	if overview, ok := s.cached(locale); ok {
+		return overview, nil
+	}
+	s.build.Lock()
+	defer s.build.Unlock()
+	// Another request may have built it while we waited.
+	if overview, ok := s.cached(locale); ok {
+		return overview, nil
+	}
+	return s.rebuild(ctx, locale)
+}
+
+func (s *OverviewService) cached(locale string) (Overview, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	overview, ok := s.entries[locale]
+	return overview, ok && time.Since(overview.BuiltAt) < s.ttl
+}
+
+// Refresh is the scheduler job keeping the cached locales warm.
+func (s *OverviewService) Refresh(ctx context.Context) error {
+	s.mu.RLock()
+	locales := make([]string, 0, len(s.entries))
+	for locale := range s.entries {
+		locales = append(locales, locale)
+	}
+	s.mu.RUnlock()
+
+	s.build.Lock()
+	defer s.build.Unlock()
+	var errs []error
+	for _, locale := range locales {
+		if _, err := s.rebuild(ctx, locale); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *OverviewService) rebuild(ctx context.Context, locale string) (Overview, error) {
+	now := time.Now()
+	latest, degraded, err := s.metrics.Latest(ctx)
+	if err != nil {
+		return Overview{}, err
+	}
+	overview := Overview{Latest: latest, Baseline: latest, Trend: []models.Metrics{}, Insights: []models.Insight{}, Degraded: degraded, BuiltAt: now}
+
+	points, err := s.store.MetricsBetween(ctx, now.Add(-overviewSpan), now, maxRangeRows)
+	if err != nil {
+		log.Printf("overview trend failed: %v", err)
+		overview.Degraded = true
+	} else if len(points) > 0 {
+		s.metrics.derived.Apply(ctx, points)
+		overview.Trend = downsample(points, overviewPoints)
+		overview.Baseline = points[0]
+	}
+	baseline, err := s.store.MetricsAt(ctx, now.Add(-overviewSpan))
+	switch {
+	case err == nil:
+		overview.Baseline = s.metrics.derive(ctx, baseline)
+	case !errors.Is(err, store.ErrNotFound):
+		log.Printf("overview baseline failed: %v", err)
+		overview.Degraded = true
+	}
+
+	alerts, err := s.store.ListAlerts(ctx, true, maxRangeRows)
+	if err != nil {
+		log.Printf("overview alerts failed: %v", err)
+		overview.Degraded = true
+	}
+	overview.OpenAlerts = len(alerts)
+
+	items, insightsDegraded, err := s.insights.Latest(ctx, locale, overviewInsightPool)
+	if err != nil {
+		log.Printf("overview insights failed: %v", err)
+		overview.Degraded = true
+	} else {
+		overview.Insights = items
+		overview.Degraded = overview.Degraded || insightsDegraded
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[locale] = overview
+	return overview, nil
+}
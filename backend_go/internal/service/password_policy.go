@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrWeakPassword = errors.New("password rejected")
+
+// commonPasswords top every public breach corpus, so they are rejected even
+// without a breach list configured.
+var commonPasswords = []string{
+	"12345678", "123456789", "1234567890", "0123456789", "11111111",
+	"00000000", "87654321", "password", "password1", "password12",
+	"password123", "passw0rd", "p@ssw0rd", "qwerty123", "qwertyuiop",
+	"1q2w3e4r", "1qaz2wsx", "zaq12wsx", "q1w2e3r4", "abc12345",
+	"abcd1234", "asdfghjkl", "iloveyou", "sunshine", "princess",
+	"football", "baseball", "superman", "starwars", "trustno1",
+	"welcome1", "welcome123", "letmein1", "admin123", "administrator",
+	"changeme", "dashboard", "mydashboard",
+}
+
+// PasswordPolicy decides which passwords new accounts may use: at least
+// MinLength characters, drawn from at least MinClasses of lower case, upper
+// case, digits and symbols, not containing the username and not known from
+// a breach.
+type PasswordPolicy struct {
+	MinLength  int `json:"min_length"`
+	MinClasses int `json:"min_classes"`
+
+	breached map[string]bool
+}
+
+func NewPasswordPolicy(minLength, minClasses int) *PasswordPolicy {
+	p := &PasswordPolicy{
+		MinLength:  max(minLength, 8),
+		MinClasses: min(max(minClasses, 0), 4),
+		breached:   make(map[string]bool, len(commonPasswords)),
+	}
+	for _, password := range commonPasswords {
+		p.breached[sha1Hex(password)] = true
+	}
+	return p
+}
+
+// LoadBreachList adds the passwords listed in path to those rejected and
+// returns how many lines it read. A line is either a password or, as in the
+// Have I Been Pwned downloads, the hex SHA-1 of one optionally followed by
+// ":count".
+func (p *PasswordPolicy) LoadBreachList(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		if _, err := hex.DecodeString(hash); err == nil && len(hash) == 2*sha1.Size {
+			p.breached[strings.ToUpper(hash)] = true
+		} else {
+			p.breached[sha1Hex(line)] = true
+		}
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// Check returns ErrWeakPassword, wrapped in ErrInvalidUser, with the reason
+// when password does not meet the policy.
+func (p *PasswordPolicy) Check(username, password string) error {
+	reject := func(reason string, args ...any) error {
+		return fmt.Errorf("%w: %w: %s", ErrInvalidUser, ErrWeakPassword, fmt.Sprintf(reason, args...))
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return reject("must be at least %d characters", p.MinLength)
+	}
+	if passwordClasses(password) < p.MinClasses {
+		return reject("must mix at least %d of lower case, upper case, digits and symbols", p.MinClasses)
+	}
+	if len(username) >= 3 && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return reject("must not contain the username")
+	}
+	if p.breached[sha1Hex(password)] || p.breached[sha1Hex(strings.ToLower(password))] {
+		return reject("appears in a list of breached passwords")
+	}
+	return nil
+}
+
+func passwordClasses(password string) int {
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
+
+func sha1Hex(value string) string {
+	sum := sha1.Sum([]byte(value))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/models"
+)
+
+const (
+	maxDisplayName = 128
+	maxAvatarURL   = 512
+)
+
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// Profile returns the signed-in user's own account.
+func (s *AuthService) Profile(ctx context.Context, principal models.Principal) (models.User, error) {
+	return s.store.UserByID(ctx, principal.UserID)
+}
+
+// UpdateProfile replaces the display name, locale, timezone and avatar of
+// the signed-in user. Locale and timezone, when set, take the place of the
+// request's Accept-Language and the server timezone for that user.
+func (s *AuthService) UpdateProfile(ctx context.Context, principal models.Principal, profile models.User) (models.User, error) {
+	profile.DisplayName = strings.TrimSpace(profile.DisplayName)
+	if utf8.RuneCountInString(profile.DisplayName) > maxDisplayName {
+		return models.User{}, fmt.Errorf("%w: display_name must be at most %d characters", ErrInvalidProfile, maxDisplayName)
+	}
+	if profile.Locale != "" {
+		locale, ok := i18n.Match(profile.Locale)
+		if !ok {
+			return models.User{}, fmt.Errorf("%w: locale %q is not supported", ErrInvalidProfile, profile.Locale)
+		}
+		profile.Locale = locale
+	}
+	if profile.Timezone != "" {
+		if _, err := time.LoadLocation(profile.Timezone); err != nil || profile.Timezone == "Local" {
+			return models.User{}, fmt.Errorf("%w: timezone must be an IANA timezone such as Asia/Shanghai", ErrInvalidProfile)
+		}
+	}
+	if profile.AvatarURL != "" {
+		parsed, err := url.Parse(profile.AvatarURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" || len(profile.AvatarURL) > maxAvatarURL {
+			return models.User{}, fmt.Errorf("%w: avatar_url must be an http or https URL of at most %d characters", ErrInvalidProfile, maxAvatarURL)
+		}
+	}
+
+	before, err := s.store.UserByID(ctx, principal.UserID)
+	if err != nil {
+		return models.User{}, err
+	}
+	profile.ID = principal.UserID
+	user, err := s.store.UpdateProfile(ctx, profile)
+	if err != nil {
+		return models.User{}, err
+	}
+	s.forgetUser(user.ID)
+
+	var changed []string
+	for _, field := range []struct{ name, old, new string }{
+		{"display_name", before.DisplayName, user.DisplayName},
+		{"locale", before.Locale, user.Locale},
+		{"timezone", before.Timezone, user.Timezone},
+		{"avatar_url", before.AvatarURL, user.AvatarURL},
+	} {
+		if field.old != field.new {
+			changed = append(changed, field.name)
+		}
+	}
+	if len(changed) > 0 {
+		s.audit.Record(ctx, models.AuditEvent{
+			Action: models.AuditProfileUpdated,
+			Actor:  principal.Username,
+			UserID: &user.ID,
+			Detail: "changed " + strings.Join(changed, ", "),
+		})
+	}
+	return user, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mydashboard-backend/internal/auth"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+func newProfileTest(t *testing.T) (*AuthService, *AuditService, models.Principal) {
+	t.Helper()
+	st, err := store.NewMemory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit := NewAuditService(st)
+	svc := NewAuthService(st, auth.NewSigner([]byte("profile-test")), time.Minute, time.Hour).WithAudit(audit)
+	user, err := svc.CreateUser(context.Background(), models.User{Username: "ana", Role: models.RoleViewer}, "profile-Passw0rd!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc, audit, models.Principal{UserID: user.ID, Username: user.Username, Role: user.Role}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	svc, audit, principal := newProfileTest(t)
+	ctx := context.Background()
+
+	user, err := svc.UpdateProfile(ctx, principal, models.User{
+		DisplayName: "  Ana Lima  ",
+		Locale:      "en_gb",
+		Timezone:    "America/Sao_Paulo",
+		AvatarURL:   "https://example.com/ana.png",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.DisplayName != "Ana Lima" || user.Locale != "en-US" || user.Timezone != "America/Sao_Paulo" || user.AvatarURL != "https://example.com/ana.png" {
+		t.Errorf("got %+v", user)
+	}
+
+	// Fields left out are cleared.
+	user, err = svc.UpdateProfile(ctx, principal, models.User{DisplayName: "Ana Lima"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Locale != "" || user.Timezone != "" || user.AvatarURL != "" {
+		t.Errorf("got %+v, want locale, timezone and avatar cleared", user)
+	}
+
+	// An unchanged profile is not audited.
+	if _, err := svc.UpdateProfile(ctx, principal, models.User{DisplayName: "Ana Lima"}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := audit.List(ctx, models.AuditQuery{Action: models.AuditProfileUpdated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var details []string
+	for _, event := range events {
+		details = append(details, event.Detail)
+		if event.Actor != "ana" || event.UserID == nil || *event.UserID != principal.UserID {
+			t.Errorf("event %+v, want it by and about ana", event)
+		}
+	}
+	slices.Sort(details)
+	want := []string{
+		"changed display_name, locale, timezone, avatar_url",
+		"changed locale, timezone, avatar_url",
+	}
+	if !slices.Equal(details, want) {
+		t.Errorf("audit details %q, want %q", details, want)
+	}
+}
+
+func TestUpdateProfileRejectsInvalidFields(t *testing.T) {
+	svc, _, principal := newProfileTest(t)
+	for name, profile := range map[string]models.User{
+		"long display name":   {DisplayName: strings.Repeat("名", maxDisplayName+1)},
+		"unsupported locale":  {Locale: "fr-FR"},
+		"unknown timezone":    {Timezone: "Mars/Olympus_Mons"},
+		"local timezone":      {Timezone: "Local"},
+		"avatar scheme":       {AvatarURL: "javascript:alert(1)"},
+		"avatar without host": {AvatarURL: "https:///ana.png"},
+		"long avatar":         {AvatarURL: "https://example.com/" + strings.Repeat("a", maxAvatarURL)},
+	} {
+		if _, err := svc.UpdateProfile(context.Background(), principal, profile); !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("%s: err = %v, want ErrInvalidProfile", name, err)
+		}
+	}
+}
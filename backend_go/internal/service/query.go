@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/rules"
+	"mydashboard-backend/internal/store"
+)
+
+const maxQueryLength = 512
+
+var ErrInvalidQuery = errors.New("invalid expression")
+
+// MetricQuery is a compiled series expression. Metrics lists the metrics it
+// reads, so callers can check access before running it, and lookback how much
+// history before the requested range its rate and delta windows need.
+type MetricQuery struct {
+	Expr     string
+	Metrics  []string
+	root     rules.QueryNode
+	lookback time.Duration
+}
+
+// CompileQuery parses expr and checks it against the functions below and the
+// known metrics. Expressions work on values in base units:
+//
+//	delta(x, d)  change of x since the last snapshot at least d earlier
+//	rate(x, d)   that change scaled to the average change per d
+//	sma(x, n)    simple moving average over n snapshots
+//	ema(x, n)    exponential moving average with span n
+//	+ - * /      between series and numbers, point by point
+func (s *MetricsService) CompileQuery(ctx context.Context, expr string) (*MetricQuery, error) {
+	if len(expr) > maxQueryLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalidQuery, maxQueryLength)
+	}
+	root, err := rules.ParseQuery(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	q := &MetricQuery{Expr: expr, root: root}
+	keys := s.Keys(ctx)
+	var check func(node rules.QueryNode, window time.Duration) error
+	check = func(node rules.QueryNode, window time.Duration) error {
+		switch node := node.(type) {
+		case rules.QueryNumber:
+			return nil
+		case rules.QueryMetric:
+			if !slices.Contains(keys, string(node)) {
+				return fmt.Errorf("%w: %s", ErrUnknownMetric, node)
+			}
+			if !slices.Contains(q.Metrics, string(node)) {
+				q.Metrics = append(q.Metrics, string(node))
+			}
+			q.lookback = max(q.lookback, window)
+			return nil
+		case rules.QueryNeg:
+			return check(node.Inner, window)
+		case rules.QueryBinary:
+			if err := check(node.Left, window); err != nil {
+				return err
+			}
+			return check(node.Right, window)
+		case rules.QueryCall:
+			switch node.Func {
+			case "delta", "rate":
+				d, ok := queryArg[rules.QueryDuration](node, 1)
+				if !ok {
+					return fmt.Errorf("%w: %s takes a series and a duration", ErrInvalidQuery, node.Func)
+				}
+				return check(node.Args[0], window+time.Duration(d))
+			case SmoothSMA, SmoothEMA:
+				n, ok := queryArg[rules.QueryNumber](node, 1)
+				if !ok || n < 1 || n != rules.QueryNumber(math.Trunc(float64(n))) {
+					return fmt.Errorf("%w: %s takes a series and a whole number of snapshots", ErrInvalidQuery, node.Func)
+				}
+				return check(node.Args[0], window)
+			}
+			return fmt.Errorf("%w: unknown function %s", ErrInvalidQuery, node.Func)
+		}
+		return fmt.Errorf("%w: a duration can only be a function argument", ErrInvalidQuery)
+	}
+	if err := check(root, 0); err != nil {
+		return nil, err
+	}
+	if len(q.Metrics) == 0 {
+		return nil, fmt.Errorf("%w: no metric referenced", ErrInvalidQuery)
+	}
+	sort.Strings(q.Metrics)
+	return q, nil
+}
+
+// queryArg returns argument i of a two-argument call as a T.
+func queryArg[T rules.QueryNode](call rules.QueryCall, i int) (T, bool) {
+	var zero T
+	if len(call.Args) != 2 {
+		return zero, false
+	}
+	arg, ok := call.Args[i].(T)
+	return arg, ok
+}
+
+// RunQuery evaluates q over the snapshots in [from, to]. Points the
+// expression is undefined at, such as the start of a rate window or a
+// division by zero, are left out. With maxPoints > 0 the result is averaged
+// into at most that many buckets of equal duration.
+func (s *MetricsService) RunQuery(ctx context.Context, q *MetricQuery, from, to time.Time, maxPoints int) ([]models.MetricPoint, error) {
+	start := from.Add(-q.lookback)
+	points, err := s.store.MetricsBetween(ctx, start, to, maxRangeRows)
+	if err != nil {
+		return nil, err
+	}
+	if q.lookback > 0 {
+		// Snapshots are not evenly spaced, so the one a window at from
+		// reaches back to may lie before start.
+		before, err := s.store.MetricsAt(ctx, start.Add(-time.Nanosecond))
+		switch {
+		case err == nil:
+			points = append([]models.Metrics{before}, points...)
+		case !errors.Is(err, store.ErrNotFound):
+			return nil, err
+		}
+	}
+	s.derived.Apply(ctx, points)
+	values := evalQuery(q.root, points)
+	out := []models.MetricPoint{}
+	for i, point := range points {
+		if point.CreatedAt.Before(from) || math.IsNaN(values[i]) || math.IsInf(values[i], 0) {
+			continue
+		}
+		out = append(out, models.MetricPoint{Timestamp: point.CreatedAt, Value: values[i]})
+	}
+	return bucketPoints(out, from, to, maxPoints), nil
+}
+
+// QueryTrend evaluates q over the last window snapshots.
+func (s *MetricsService) QueryTrend(ctx context.Context, q *MetricQuery, window int) ([]models.MetricPoint, error) {
+	points, err := s.Trend(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return []models.MetricPoint{}, nil
+	}
+	return s.RunQuery(ctx, q, points[0].CreatedAt, points[len(points)-1].CreatedAt, 0)
+}
+
+// evalQuery returns one value per point, NaN where it is undefined. The
+// expression was checked by CompileQuery.
+func evalQuery(node rules.QueryNode, points []models.Metrics) []float64 {
+	out := make([]float64, len(points))
+	switch node := node.(type) {
+	case rules.QueryNumber:
+		for i := range out {
+			out[i] = float64(node)
+		}
+	case rules.QueryMetric:
+		for i, point := range points {
+			value, ok := point.Value(string(node))
+			if !ok {
+				value = math.NaN()
+			}
+			out[i] = value
+		}
+	case rules.QueryNeg:
+		for i, value := range evalQuery(node.Inner, points) {
+			out[i] = -value
+		}
+	case rules.QueryBinary:
+		left, right := evalQuery(node.Left, points), evalQuery(node.Right, points)
+		for i := range out {
+			switch node.Op {
+			case '+':
+				out[i] = left[i] + right[i]
+			case '-':
+				out[i] = left[i] - right[i]
+			case '*':
+				out[i] = left[i] * right[i]
+			case '/':
+				out[i] = math.NaN()
+				if right[i] != 0 {
+					out[i] = left[i] / right[i]
+				}
+			}
+		}
+	case rules.QueryCall:
+		values := evalQuery(node.Args[0], points)
+		switch node.Func {
+		case "delta", "rate":
+			window := time.Duration(node.Args[1].(rules.QueryDuration))
+			j := -1
+			for i, point := range points {
+				// j is the last snapshot at least window before this one.
+				for j+1 < i && !points[j+1].CreatedAt.After(point.CreatedAt.Add(-window)) {
+					j++
+				}
+				out[i] = math.NaN()
+				if j < 0 || points[j].CreatedAt.After(point.CreatedAt.Add(-window)) {
+					continue
+				}
+				out[i] = values[i] - values[j]
+				if node.Func == "rate" {
+					out[i] *= float64(window) / float64(point.CreatedAt.Sub(points[j].CreatedAt))
+				}
+			}
+		default:
+			out = smoothDefined(values, node.Func, int(node.Args[1].(rules.QueryNumber)))
+		}
+	}
+	return out
+}
+
+// smoothDefined smooths the defined values only, so one undefined point does
+// not spread through the average.
+func smoothDefined(values []float64, method string, span int) []float64 {
+	var index []int
+	var defined []float64
+	for i, value := range values {
+		if !math.IsNaN(value) {
+			index = append(index, i)
+			defined = append(defined, value)
+		}
+	}
+	out := make([]float64, len(values))
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	for k, value := range smooth(defined, method, span) {
+		out[index[k]] = value
+	}
+	return out
+}
+
+// bucketPoints averages points into maxPoints buckets of equal duration over
+// [from, to] when there are more points than that.
+func bucketPoints(points []models.MetricPoint, from, to time.Time, maxPoints int) []models.MetricPoint {
+	if maxPoints <= 0 || len(points) <= maxPoints || !to.After(from) {
+		return points
+	}
+	step := max(to.Sub(from)/time.Duration(maxPoints), time.Nanosecond)
+	out := make([]models.MetricPoint, 0, maxPoints)
+	var sum float64
+	var count int
+	var bucket time.Time
+	for _, point := range points {
+		start := from.Add(point.Timestamp.Sub(from) / step * step)
+		if count > 0 && !start.Equal(bucket) {
+			out = append(out, models.MetricPoint{Timestamp: bucket, Value: sum / float64(count)})
+			sum, count = 0, 0
+		}
+		bucket = start
+		sum += point.Value
+		count++
+	}
+	if count > 0 {
+		out = append(out, models.MetricPoint{Timestamp: bucket, Value: sum / float64(count)})
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"mydashboard-backend/internal/models"
+)
+
+const (
+	RedactMask   = "mask"
+	RedactBucket = "bucket"
+)
+
+var ErrMetricRestricted = errors.New("metric is not available for your role")
+
+type RedactionRule struct {
+	Mode string
+	Step float64
+}
+
+// Redactor hides or coarsens configured metrics for every role that is not
+// exempt. Anonymous callers have no role and are never exempt.
+type Redactor struct {
+	rules  map[string]RedactionRule
+	exempt map[string]bool
+}
+
+// ParseRedaction reads rules such as "revenue:bucket:0.5,backlog:mask".
+func ParseRedaction(spec string) (map[string]RedactionRule, error) {
+	rules := map[string]RedactionRule{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		key := strings.ToLower(parts[0])
+		if _, ok := (models.Metrics{}).Value(key); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownMetric, key)
+		}
+		switch {
+		case len(parts) == 2 && parts[1] == RedactMask:
+			rules[key] = RedactionRule{Mode: RedactMask}
+		case len(parts) == 3 && parts[1] == RedactBucket:
+			step, err := strconv.ParseFloat(parts[2], 64)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid bucket size in %q", item)
+			}
+			rules[key] = RedactionRule{Mode: RedactBucket, Step: step}
+		default:
+			return nil, fmt.Errorf("invalid redaction rule %q (want key:mask or key:bucket:size)", item)
+		}
+	}
+	return rules, nil
+}
+
+func NewRedactor(rules map[string]RedactionRule, exemptRoles []string) *Redactor {
+	exempt := map[string]bool{}
+	for _, role := range exemptRoles {
+		exempt[role] = true
+	}
+	return &Redactor{rules: rules, exempt: exempt}
+}
+
+func (r *Redactor) rule(role, key string) (RedactionRule, bool) {
+	if r == nil || r.exempt[role] {
+		return RedactionRule{}, false
+	}
+	rule, ok := r.rules[key]
+	return rule, ok
+}
+
+// hidesDerived reports whether role is subject to any rule. Derived metrics
+// may be computed from a redacted one, so such roles see none of them.
+func (r *Redactor) hidesDerived(role string) bool {
+	return r != nil && !r.exempt[role] && len(r.rules) > 0
+}
+
+// Metrics returns the snapshot as role may see it plus the keys that were
+// altered. Masked values are zeroed; clients should use the key list rather
+// than the zero to decide what to display.
+func (r *Redactor) Metrics(role string, metrics models.Metrics) (models.Metrics, []string) {
+	var redacted []string
+	for _, key := range models.MetricKeys {
+		rule, ok := r.rule(role, key)
+		if !ok {
+			continue
+		}
+		value, _ := metrics.Value(key)
+		metrics = withValue(metrics, key, rule.apply(value))
+		redacted = append(redacted, key)
+	}
+	if r.hidesDerived(role) && len(metrics.Derived) > 0 {
+		redacted = append(redacted, derivedKeys(metrics)...)
+		metrics.Derived = nil
+	}
+	return metrics, redacted
+}
+
+// Series redacts a copy so cached slices held by the service stay precise.
+func (r *Redactor) Series(role string, series []models.Metrics) ([]models.Metrics, []string) {
+	_, redacted := r.Metrics(role, models.Metrics{})
+	if len(redacted) == 0 {
+		return series, nil
+	}
+	out := make([]models.Metrics, len(series))
+	for i := range series {
+		out[i], _ = r.Metrics(role, series[i])
+	}
+	return out, redacted
+}
+
+// Points coarsens a single-metric series. Masked metrics are refused rather
+// than returned as a flat line of zeros.
+func (r *Redactor) Points(role, key string, points []models.MetricPoint) ([]models.MetricPoint, bool, error) {
+	if !slices.Contains(models.MetricKeys, key) && r.hidesDerived(role) {
+		return nil, false, ErrMetricRestricted
+	}
+	rule, ok := r.rule(role, key)
+	if !ok {
+		return points, false, nil
+	}
+	if rule.Mode == RedactMask {
+		return nil, false, ErrMetricRestricted
+	}
+	out := make([]models.MetricPoint, len(points))
+	for i, point := range points {
+		point.Value = rule.apply(point.Value)
+		out[i] = point
+	}
+	return out, true, nil
+}
+
+// Restricted reports whether aggregates over key (distribution, heatmap,
+// correlation) must be refused because they would reveal precise values.
+func (r *Redactor) Restricted(role string, keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := r.rule(role, key); ok {
+			return true
+		}
+		if !slices.Contains(models.MetricKeys, key) && r.hidesDerived(role) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule RedactionRule) apply(value float64) float64 {
+	if rule.Mode == RedactBucket {
+		return math.Floor(value/rule.Step) * rule.Step
+	}
+	return 0
+}
+
+// Keep zeroes every metric outside keys and reports which ones it dropped.
+// Derived metrics are always dropped since keys only name stored ones.
+func Keep(metrics models.Metrics, keys []string) (models.Metrics, []string) {
+	var dropped []string
+	for _, key := range models.MetricKeys {
+		if !slices.Contains(keys, key) {
+			metrics = withValue(metrics, key, 0)
+			dropped = append(dropped, key)
+		}
+	}
+	dropped = append(dropped, derivedKeys(metrics)...)
+	metrics.Derived = nil
+	return metrics, dropped
+}
+
+func withValue(metrics models.Metrics, key string, value float64) models.Metrics {
+	switch key {
+	case "revenue":
+		metrics.Revenue = value
+	case "growth":
+		metrics.Growth = value
+	case "sentiment":
+		metrics.Sentiment = value
+	case "backlog":
+		metrics.Backlog = int(value)
+	}
+	return metrics
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/rules"
+)
+
+// maxPreviewFirings bounds the firings listed by PreviewRule; counting goes on
+// past it.
+const maxPreviewFirings = 500
+
+// PreviewRule evaluates rule against every snapshot in [from, to] without
+// saving anything. Only the condition has to be valid; the message of each
+// firing is rendered from the template when one is given.
+func (s *InsightsService) PreviewRule(ctx context.Context, rule models.InsightRule, from, to time.Time) (models.RulePreview, error) {
+	expr, err := parseCondition(rule.Condition)
+	if err != nil {
+		return models.RulePreview{}, err
+	}
+	preview := models.RulePreview{From: from, To: to, Firings: []models.RuleFiring{}}
+	var current *models.RuleFiring
+	it := s.store.IterateMetrics(from, to, trendPageSize)
+	for it.Next(ctx) {
+		metrics := it.Metrics()
+		vars := metricVars(metrics)
+		preview.Evaluated++
+		matched, err := expr.Eval(vars)
+		if err != nil {
+			return models.RulePreview{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+		if !matched {
+			current = nil
+			continue
+		}
+		preview.Matched++
+		if current != nil {
+			current.End = metrics.CreatedAt
+			current.Snapshots++
+			continue
+		}
+		if len(preview.Firings) == maxPreviewFirings {
+			preview.Truncated = true
+			continue
+		}
+		firing := models.RuleFiring{Start: metrics.CreatedAt, End: metrics.CreatedAt, Snapshots: 1}
+		if rule.Template != "" {
+			firing.Message = rules.Render(rule.Template, vars)
+		}
+		preview.Firings = append(preview.Firings, firing)
+		current = &preview.Firings[len(preview.Firings)-1]
+	}
+	return preview, it.Err()
+}
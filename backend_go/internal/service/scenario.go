@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const (
+	maxScenarioHorizon = 365
+	maxScenarioBuckets = 5000
+	minScenarioBuckets = 3
+
+	// Smoothing factors of the level and the trend in Holt's method.
+	forecastAlpha = 0.5
+	forecastBeta  = 0.2
+)
+
+var ErrInvalidScenario = errors.New("invalid scenario")
+
+// SimulateScenario projects every metric horizon steps ahead from the
+// history before now, once as is and once with the scenario's changes, so
+// the two can be compared. The projection uses Holt's linear trend method on
+// the history averaged into step-long buckets. Derived metrics are computed
+// from the projected stored ones, so they reflect the changes too.
+func (s *MetricsService) SimulateScenario(ctx context.Context, scenario models.Scenario) (models.ScenarioProjection, error) {
+	if err := validateScenario(scenario); err != nil {
+		return models.ScenarioProjection{}, err
+	}
+	now := time.Now()
+	from := now.Add(-scenario.History)
+	points, err := s.store.MetricsBetween(ctx, from, now, maxRangeRows)
+	if err != nil {
+		return models.ScenarioProjection{}, err
+	}
+	history := bucketMetrics(points, from, scenario.Step)
+	if len(history) < minScenarioBuckets {
+		return models.ScenarioProjection{}, fmt.Errorf("%w: need at least %d steps of history", ErrNoData, minScenarioBuckets)
+	}
+
+	last := history[len(history)-1].CreatedAt
+	baseline := make([]models.Metrics, scenario.Horizon)
+	for i := range baseline {
+		baseline[i].CreatedAt = last.Add(time.Duration(i+1) * scenario.Step)
+	}
+	changed := slices.Clone(baseline)
+	for _, key := range models.MetricKeys {
+		values := make([]float64, len(history))
+		for i, point := range history {
+			values[i], _ = point.Value(key)
+		}
+		projected := holtForecast(values, scenario.Horizon)
+		for i, value := range projected {
+			baseline[i] = withProjected(baseline[i], key, value)
+			changed[i] = withProjected(changed[i], key, applyChanges(scenario.Changes, key, value))
+		}
+	}
+	defs := s.derived.definitions(ctx)
+	for _, series := range [][]models.Metrics{history, baseline, changed} {
+		for i := range series {
+			series[i].Derived = nil
+			series[i] = evaluate(defs, series[i], models.DerivedAtIngest)
+			series[i] = evaluate(defs, series[i], models.DerivedAtQuery)
+		}
+	}
+
+	projection := models.ScenarioProjection{
+		From:    last,
+		Step:    scenario.Step.String(),
+		Horizon: scenario.Horizon,
+		Changes: scenario.Changes,
+	}
+	for _, key := range s.Keys(ctx) {
+		projection.Series = append(projection.Series, models.ScenarioSeries{
+			Metric:   key,
+			History:  seriesOf(history, key),
+			Baseline: seriesOf(baseline, key),
+			Scenario: seriesOf(changed, key),
+		})
+	}
+	return projection, nil
+}
+
+func validateScenario(scenario models.Scenario) error {
+	if scenario.Step <= 0 || scenario.History < scenario.Step {
+		return fmt.Errorf("%w: step must be positive and history at least one step", ErrInvalidScenario)
+	}
+	if scenario.History/scenario.Step > maxScenarioBuckets {
+		return fmt.Errorf("%w: history spans more than %d steps", ErrInvalidScenario, maxScenarioBuckets)
+	}
+	if scenario.Horizon < 1 || scenario.Horizon > maxScenarioHorizon {
+		return fmt.Errorf("%w: horizon must be between 1 and %d steps", ErrInvalidScenario, maxScenarioHorizon)
+	}
+	for _, change := range scenario.Changes {
+		if !slices.Contains(models.MetricKeys, change.Metric) {
+			return fmt.Errorf("%w: changes apply to %v only", ErrInvalidScenario, models.MetricKeys)
+		}
+	}
+	return nil
+}
+
+func applyChanges(changes []models.ScenarioChange, key string, value float64) float64 {
+	for _, change := range changes {
+		if change.Metric == key {
+			value = value*(1+change.Percent/100) + change.Delta
+		}
+	}
+	return value
+}
+
+// bucketMetrics averages points into step-long buckets starting at from.
+// A bucket without snapshots repeats the previous one.
+func bucketMetrics(points []models.Metrics, from time.Time, step time.Duration) []models.Metrics {
+	var out []models.Metrics
+	sums := make([]float64, len(models.MetricKeys))
+	count := 0
+	var bucket time.Time
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		avg := models.Metrics{CreatedAt: bucket}
+		for k, key := range models.MetricKeys {
+			avg = withProjected(avg, key, sums[k]/float64(count))
+		}
+		for len(out) > 0 && out[len(out)-1].CreatedAt.Add(step).Before(bucket) {
+			gap := out[len(out)-1]
+			gap.CreatedAt = gap.CreatedAt.Add(step)
+			out = append(out, gap)
+		}
+		out = append(out, avg)
+		clear(sums)
+		count = 0
+	}
+	for _, point := range points {
+		start := from.Add(point.CreatedAt.Sub(from) / step * step)
+		if !start.Equal(bucket) {
+			flush()
+			bucket = start
+		}
+		for k, key := range models.MetricKeys {
+			value, _ := point.Value(key)
+			sums[k] += value
+		}
+		count++
+	}
+	flush()
+	return out
+}
+
+// withProjected rounds the backlog, which is stored as a whole number, rather
+// than truncating it.
+func withProjected(metrics models.Metrics, key string, value float64) models.Metrics {
+	if key == "backlog" {
+		value = math.Round(value)
+	}
+	return withValue(metrics, key, value)
+}
+
+// holtForecast continues the level and trend of values for horizon steps.
+func holtForecast(values []float64, horizon int) []float64 {
+	level, trend := values[0], values[1]-values[0]
+	for _, value := range values[1:] {
+		previous := level
+		level = forecastAlpha*value + (1-forecastAlpha)*(level+trend)
+		trend = forecastBeta*(level-previous) + (1-forecastBeta)*trend
+	}
+	out := make([]float64, horizon)
+	for h := range out {
+		out[h] = level + float64(h+1)*trend
+	}
+	return out
+}
+
+func seriesOf(points []models.Metrics, key string) []models.MetricPoint {
+	out := make([]models.MetricPoint, 0, len(points))
+	for _, point := range points {
+		if value, ok := point.Value(key); ok && !math.IsNaN(value) {
+			out = append(out, models.MetricPoint{Timestamp: point.CreatedAt, Value: value})
+		}
+	}
+	return out
+}
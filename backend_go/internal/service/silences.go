@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/notify"
+	"mydashboard-backend/internal/scheduler"
+	"mydashboard-backend/internal/store"
+)
+
+var ErrInvalidSilence = errors.New("invalid silence")
+
+// SilenceService manages silences and maintenance windows and decides which
+// outbox events they suppress.
+type SilenceService struct {
+	store *store.Store
+	loc   *time.Location
+}
+
+func NewSilenceService(store *store.Store) *SilenceService {
+	return &SilenceService{store: store, loc: time.Local}
+}
+
+// WithLocation sets the time zone maintenance schedules are evaluated in.
+func (s *SilenceService) WithLocation(loc *time.Location) *SilenceService {
+	if loc != nil {
+		s.loc = loc
+	}
+	return s
+}
+
+func (s *SilenceService) List(ctx context.Context, includeExpired bool) ([]models.Silence, error) {
+	items, err := s.store.ListSilences(ctx, includeExpired)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range items {
+		items[i].Active = s.active(items[i], now)
+	}
+	if items == nil {
+		items = []models.Silence{}
+	}
+	return items, nil
+}
+
+func (s *SilenceService) Create(ctx context.Context, silence models.Silence) (models.Silence, error) {
+	if err := validateSilence(&silence); err != nil {
+		return models.Silence{}, err
+	}
+	created, err := s.store.InsertSilence(ctx, silence)
+	if err != nil {
+		return models.Silence{}, err
+	}
+	created.Active = s.active(created, time.Now())
+	return created, nil
+}
+
+func (s *SilenceService) Delete(ctx context.Context, id int64) error {
+	return s.store.DeleteSilence(ctx, id)
+}
+
+// Match returns the id of an active silence that matches event.
+func (s *SilenceService) Match(ctx context.Context, event notify.Event) (int64, bool, error) {
+	silences, err := s.store.ListSilences(ctx, false)
+	if err != nil || len(silences) == 0 {
+		return 0, false, err
+	}
+	fields := map[string]any{}
+	_ = json.Unmarshal(event.Payload, &fields)
+	now := time.Now()
+	for _, silence := range silences {
+		if s.active(silence, now) && matches(silence.Matchers, event.Type, fields) {
+			return silence.ID, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func (s *SilenceService) active(silence models.Silence, now time.Time) bool {
+	if now.Before(silence.StartsAt) || silence.EndsAt != nil && !now.Before(*silence.EndsAt) {
+		return false
+	}
+	if silence.Kind != models.SilenceKindMaintenance {
+		return true
+	}
+	schedule, err := scheduler.Parse(silence.Schedule)
+	if err != nil {
+		return false
+	}
+	duration, err := time.ParseDuration(silence.Duration)
+	if err != nil {
+		return false
+	}
+	// The window is open when the schedule activated within the last
+	// duration, and not before the window series started.
+	since := now.Add(-duration).In(s.loc)
+	if since.Before(silence.StartsAt) {
+		since = silence.StartsAt.Add(-time.Nanosecond).In(s.loc)
+	}
+	return !schedule.Next(since).After(now)
+}
+
+func matches(matchers map[string]string, eventType string, fields map[string]any) bool {
+	for key, want := range matchers {
+		if key == "event_type" {
+			if eventType != want {
+				return false
+			}
+			continue
+		}
+		if got, ok := fields[key].(string); !ok || got != want {
+			return false
+		}
+	}
+	return true
+}
+
+func validateSilence(silence *models.Silence) error {
+	silence.Comment = strings.TrimSpace(silence.Comment)
+	if silence.Kind == "" {
+		silence.Kind = models.SilenceKindSilence
+	}
+	if silence.Matchers == nil {
+		silence.Matchers = map[string]string{}
+	}
+	for key := range silence.Matchers {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("%w: matcher names must not be empty", ErrInvalidSilence)
+		}
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now()
+	}
+	if silence.EndsAt != nil && !silence.EndsAt.After(silence.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSilence)
+	}
+	switch silence.Kind {
+	case models.SilenceKindSilence:
+		if silence.EndsAt == nil {
+			return fmt.Errorf("%w: ends_at is required", ErrInvalidSilence)
+		}
+		if silence.Schedule != "" || silence.Duration != "" {
+			return fmt.Errorf("%w: schedule and duration are only used by maintenance windows", ErrInvalidSilence)
+		}
+	case models.SilenceKindMaintenance:
+		if _, err := scheduler.Parse(silence.Schedule); err != nil {
+			return fmt.Errorf("%w: schedule: %v", ErrInvalidSilence, err)
+		}
+		duration, err := time.ParseDuration(silence.Duration)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("%w: duration must be a positive duration such as 2h", ErrInvalidSilence)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be silence or maintenance", ErrInvalidSilence)
+	}
+	return nil
+}
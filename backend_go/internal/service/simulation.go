@@ -1,21 +1,34 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"math/rand"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"mydashboard-backend/internal/models"
 )
 
+const maxSimulatedSeries = 50
+
+var ErrInvalidSeries = errors.New("invalid simulated series")
+
 type Simulation struct {
 	rng *rand.Rand
 	mu  sync.Mutex
+
+	series []models.SimulatedSeries
+	levels map[[2]string]float64
 }
 
 func NewSimulation() *Simulation {
 	return &Simulation{
-		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		levels: map[[2]string]float64{},
 	}
 }
 
@@ -32,6 +45,114 @@ func (s *Simulation) NextMetrics(previous models.Metrics) models.Metrics {
 	}
 }
 
+// ParseSimulatedSeries reads entries such as "region/emea=0.5:0.02", each
+// being dimension/member=weight:volatility.
+func ParseSimulatedSeries(values []string) ([]models.SimulatedSeries, error) {
+	series := make([]models.SimulatedSeries, 0, len(values))
+	for _, value := range values {
+		name, spec, ok := strings.Cut(value, "=")
+		dimension, member, named := strings.Cut(name, "/")
+		weight, volatility, profiled := strings.Cut(spec, ":")
+		parsedWeight, err := strconv.ParseFloat(weight, 64)
+		parsedVolatility, verr := strconv.ParseFloat(volatility, 64)
+		if !ok || !named || !profiled || err != nil || verr != nil {
+			return nil, fmt.Errorf("invalid series %q (want dimension/member=weight:volatility)", value)
+		}
+		series = append(series, models.SimulatedSeries{
+			Dimension:  strings.TrimSpace(dimension),
+			Member:     strings.TrimSpace(member),
+			Weight:     parsedWeight,
+			Volatility: parsedVolatility,
+		})
+	}
+	return series, nil
+}
+
+func (s *Simulation) Series() []models.SimulatedSeries {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.SimulatedSeries{}, s.series...)
+}
+
+// SetSeries replaces the simulated series. Members that stay keep their
+// drifted share; new ones start from their weight.
+func (s *Simulation) SetSeries(series []models.SimulatedSeries) error {
+	if err := validateSeries(series); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	levels := make(map[[2]string]float64, len(series))
+	for _, item := range series {
+		key := [2]string{item.Dimension, item.Member}
+		levels[key] = 1
+		if level, ok := s.levels[key]; ok {
+			levels[key] = level
+		}
+	}
+	s.series = append([]models.SimulatedSeries(nil), series...)
+	s.levels = levels
+	return nil
+}
+
+// Split drifts every series' share and divides the revenue and backlog of
+// metrics across the members of each dimension by their shares, so the
+// members of a dimension add up to the total.
+func (s *Simulation) Split(metrics models.Metrics) []models.DimensionValue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.series) == 0 {
+		return nil
+	}
+	totals := map[string]float64{}
+	for _, item := range s.series {
+		key := [2]string{item.Dimension, item.Member}
+		// A log-normal step keeps the level positive; the clamp keeps one
+		// member from drifting to all or nothing.
+		s.levels[key] = clamp(s.levels[key]*math.Exp(item.Volatility*s.rng.NormFloat64()), 0.2, 5)
+		totals[item.Dimension] += item.Weight * s.levels[key]
+	}
+	values := make([]models.DimensionValue, 0, 2*len(s.series))
+	for _, item := range s.series {
+		share := item.Weight * s.levels[[2]string{item.Dimension, item.Member}] / totals[item.Dimension]
+		for _, metric := range additiveMetrics {
+			total, _ := metrics.Value(metric)
+			values = append(values, models.DimensionValue{
+				Metric:     metric,
+				Dimension:  item.Dimension,
+				Member:     item.Member,
+				Value:      total * share,
+				RecordedAt: metrics.CreatedAt,
+			})
+		}
+	}
+	return values
+}
+
+func validateSeries(series []models.SimulatedSeries) error {
+	if len(series) > maxSimulatedSeries {
+		return fmt.Errorf("%w: at most %d series", ErrInvalidSeries, maxSimulatedSeries)
+	}
+	seen := map[[2]string]bool{}
+	for i, item := range series {
+		key := [2]string{item.Dimension, item.Member}
+		switch {
+		case !dimensionName.MatchString(item.Dimension):
+			return fmt.Errorf("%w: series %d: dimension must be lowercase letters, digits and underscores", ErrInvalidSeries, i)
+		case item.Member == "" || len(item.Member) > maxDimensionMemberLen:
+			return fmt.Errorf("%w: series %d: member must be 1 to %d characters", ErrInvalidSeries, i, maxDimensionMemberLen)
+		case seen[key]:
+			return fmt.Errorf("%w: series %d: %s/%s is listed twice", ErrInvalidSeries, i, item.Dimension, item.Member)
+		case !(item.Weight > 0) || math.IsInf(item.Weight, 0):
+			return fmt.Errorf("%w: series %d: weight must be positive", ErrInvalidSeries, i)
+		case !(item.Volatility >= 0 && item.Volatility <= 1):
+			return fmt.Errorf("%w: series %d: volatility must be between 0 and 1", ErrInvalidSeries, i)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
 func clamp(value, min, max float64) float64 {
 	if value < min {
 		return min
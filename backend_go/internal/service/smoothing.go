@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnknownMetric    = errors.New("unknown metric key")
+	ErrUnknownSmoothing = errors.New("unknown smoothing method")
+)
+
+const (
+	SmoothNone = "none"
+	SmoothSMA  = "sma"
+	SmoothEMA  = "ema"
+)
+
+func checkSmoothing(method string) error {
+	switch method {
+	case "", SmoothNone, SmoothSMA, SmoothEMA:
+		return nil
+	}
+	return fmt.Errorf("%w %q", ErrUnknownSmoothing, method)
+}
+
+func smooth(values []float64, method string, span int) []float64 {
+	if span < 1 {
+		span = 1
+	}
+	switch method {
+	case SmoothSMA:
+		return movingAverage(values, span)
+	case SmoothEMA:
+		return exponentialAverage(values, span)
+	}
+	return values
+}
+
+func movingAverage(values []float64, span int) []float64 {
+	out := make([]float64, len(values))
+	sum := 0.0
+	for i, value := range values {
+		sum += value
+		if i >= span {
+			sum -= values[i-span]
+		}
+		n := span
+		if i+1 < span {
+			n = i + 1
+		}
+		out[i] = sum / float64(n)
+	}
+	return out
+}
+
+func exponentialAverage(values []float64, span int) []float64 {
+	out := make([]float64, len(values))
+	alpha := 2 / float64(span+1)
+	for i, value := range values {
+		if i == 0 {
+			out[i] = value
+			continue
+		}
+		out[i] = alpha*value + (1-alpha)*out[i-1]
+	}
+	return out
+}
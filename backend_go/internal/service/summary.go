@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const summaryWindow = 24 * time.Hour
+
+// PublishDailySummary compares the latest snapshot with the one in effect a
+// day earlier and queues the result for notification channels.
+func (s *MetricsService) PublishDailySummary(ctx context.Context) error {
+	now := time.Now()
+	from, to, err := s.SnapshotsAt(ctx, now.Add(-summaryWindow), now)
+	if errors.Is(err, store.ErrNotFound) {
+		log.Printf("daily summary skipped: no snapshot older than %s", summaryWindow)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	locale := i18n.Default
+	summary := models.DailySummary{
+		Title: i18n.T(locale, "summary.title", now.Format("2006-01-02")),
+		Message: i18n.T(locale, "summary.message",
+			formatDelta(from.Revenue, to.Revenue, "B"),
+			formatDelta(from.Growth, to.Growth, "%"),
+			formatDelta(from.Sentiment, to.Sentiment, "%"),
+			formatDelta(float64(from.Backlog), float64(to.Backlog), "K"),
+		),
+		From:     from.CreatedAt,
+		To:       to.CreatedAt,
+		Snapshot: to,
+		Deltas:   Diff(from.CreatedAt, to.CreatedAt, from, to, nil).Deltas,
+	}
+	return s.store.EnqueueEvent(ctx, models.EventDailySummary, summary)
+}
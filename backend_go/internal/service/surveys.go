@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	maxSurveyBatch     = 10000
+	maxSurveyResponses = 500000
+	maxNPSTrendPoints  = 1000
+)
+
+var ErrInvalidSurvey = errors.New("invalid survey response")
+
+var surveySegment = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// SurveyService stores NPS survey responses and reports the score over a
+// range, overall and per segment. Refresh keeps the NPS of the last window
+// so the metrics service can use it as the sentiment of new snapshots.
+type SurveyService struct {
+	store        *store.Store
+	window       time.Duration
+	minResponses int
+
+	mu        sync.RWMutex
+	sentiment float64
+	ready     bool
+}
+
+func NewSurveyService(store *store.Store) *SurveyService {
+	return &SurveyService{store: store, window: 30 * 24 * time.Hour, minResponses: 30}
+}
+
+// WithSentimentWindow sets the rolling window Refresh computes the NPS over
+// and how many responses it needs before the value is used.
+func (s *SurveyService) WithSentimentWindow(window time.Duration, minResponses int) *SurveyService {
+	if window > 0 {
+		s.window = window
+	}
+	s.minResponses = max(minResponses, 1)
+	return s
+}
+
+// Ingest stores a batch of responses. Responses without a time are taken to
+// arrive now. The batch is rejected as a whole if any response is invalid.
+func (s *SurveyService) Ingest(ctx context.Context, responses []models.SurveyResponse) (int, error) {
+	if len(responses) > maxSurveyBatch {
+		return 0, fmt.Errorf("%w: at most %d responses per request", ErrInvalidSurvey, maxSurveyBatch)
+	}
+	now := time.Now()
+	for i := range responses {
+		if responses[i].Score < 0 || responses[i].Score > 10 {
+			return 0, fmt.Errorf("%w: response %d: score must be between 0 and 10", ErrInvalidSurvey, i)
+		}
+		if responses[i].Segment != "" && !surveySegment.MatchString(responses[i].Segment) {
+			return 0, fmt.Errorf("%w: response %d: segment must be up to 64 letters, digits, '_', '.' or '-'", ErrInvalidSurvey, i)
+		}
+		if responses[i].RespondedAt.IsZero() {
+			responses[i].RespondedAt = now
+		}
+	}
+	if err := s.store.InsertSurveyResponses(ctx, responses); err != nil {
+		return 0, err
+	}
+	return len(responses), nil
+}
+
+// NPS reports the score of the responses in [from, to], overall and for each
+// segment, segments in name order.
+func (s *SurveyService) NPS(ctx context.Context, from, to time.Time) (models.NPSReport, error) {
+	responses, err := s.store.SurveyResponses(ctx, from, to, maxSurveyResponses)
+	if err != nil {
+		return models.NPSReport{}, err
+	}
+	report := models.NPSReport{
+		From:      from,
+		To:        to,
+		Segments:  []models.NPSScore{},
+		Truncated: len(responses) == maxSurveyResponses,
+	}
+	segments := map[string]*models.NPSScore{}
+	for _, response := range responses {
+		countScore(&report.Overall, response.Score)
+		if response.Segment == "" {
+			continue
+		}
+		segment := segments[response.Segment]
+		if segment == nil {
+			segment = &models.NPSScore{Segment: response.Segment}
+			segments[response.Segment] = segment
+		}
+		countScore(segment, response.Score)
+	}
+	report.Overall.NPS = netPromoterScore(report.Overall)
+	for _, segment := range segments {
+		segment.NPS = netPromoterScore(*segment)
+		report.Segments = append(report.Segments, *segment)
+	}
+	sort.Slice(report.Segments, func(i, j int) bool { return report.Segments[i].Segment < report.Segments[j].Segment })
+	return report, nil
+}
+
+// Trend returns the NPS of the window before each step from from to to,
+// limited to segment when it is set. Points without responses in their
+// window are left out.
+func (s *SurveyService) Trend(ctx context.Context, from, to time.Time, window, step time.Duration, segment string) ([]models.MetricPoint, error) {
+	if window <= 0 || step <= 0 {
+		return nil, fmt.Errorf("%w: window and step must be positive", ErrInvalidSurvey)
+	}
+	if to.Sub(from)/step >= maxNPSTrendPoints {
+		return nil, fmt.Errorf("%w: more than %d points", ErrInvalidSurvey, maxNPSTrendPoints)
+	}
+	responses, err := s.store.SurveyResponses(ctx, from.Add(-window), to, maxSurveyResponses)
+	if err != nil {
+		return nil, err
+	}
+	if segment != "" {
+		filtered := responses[:0]
+		for _, response := range responses {
+			if response.Segment == segment {
+				filtered = append(filtered, response)
+			}
+		}
+		responses = filtered
+	}
+	out := []models.MetricPoint{}
+	var score models.NPSScore
+	start, end := 0, 0
+	for t := from; !t.After(to); t = t.Add(step) {
+		// The window of t is (t-window, t]; responses enter at end and
+		// leave at start as it slides.
+		for ; end < len(responses) && !responses[end].RespondedAt.After(t); end++ {
+			countScore(&score, responses[end].Score)
+		}
+		for ; start < end && !responses[start].RespondedAt.After(t.Add(-window)); start++ {
+			uncountScore(&score, responses[start].Score)
+		}
+		if score.Responses > 0 {
+			out = append(out, models.MetricPoint{Timestamp: t, Value: netPromoterScore(score)})
+		}
+	}
+	return out, nil
+}
+
+// Refresh recomputes the NPS of the last window. With fewer than the
+// minimum responses the sentiment is left to the snapshots themselves.
+func (s *SurveyService) Refresh(ctx context.Context) error {
+	now := time.Now()
+	report, err := s.NPS(ctx, now.Add(-s.window), now)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ready = report.Overall.Responses >= s.minResponses
+	// Sentiment is a percentage, so the NPS range of -100 to 100 is mapped
+	// onto 0 to 100.
+	s.sentiment = (report.Overall.NPS + 100) / 2
+	return nil
+}
+
+// Sentiment returns the sentiment the last Refresh derived from the rolling
+// NPS, and false when there were too few responses or s is nil.
+func (s *SurveyService) Sentiment() (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sentiment, s.ready
+}
+
+func countScore(score *models.NPSScore, value int) {
+	score.Responses++
+	switch {
+	case value >= 9:
+		score.Promoters++
+	case value >= 7:
+		score.Passives++
+	default:
+		score.Detractors++
+	}
+}
+
+func uncountScore(score *models.NPSScore, value int) {
+	score.Responses--
+	switch {
+	case value >= 9:
+		score.Promoters--
+	case value >= 7:
+		score.Passives--
+	default:
+		score.Detractors--
+	}
+}
+
+func netPromoterScore(score models.NPSScore) float64 {
+	return (ratio(score.Promoters, score.Responses) - ratio(score.Detractors, score.Responses)) * 100
+}
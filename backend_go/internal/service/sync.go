@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+// maxSyncPush bounds the snapshots accepted in one push.
+const maxSyncPush = 10000
+
+var (
+	ErrInvalidSyncPush = errors.New("invalid sync push")
+	// ErrSyncGap means a push starts past what was received; the result's
+	// Acked tells the edge where to resume.
+	ErrSyncGap = errors.New("sync push leaves a gap")
+)
+
+var syncNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// SyncService receives metrics pushed by edge instances. Each edge segment
+// has a cursor of rows received so far: rows before it are skipped, so an
+// edge that lost an acknowledgement can safely resend, and a push starting
+// past it is refused so nothing is silently missed.
+type SyncService struct {
+	store *store.Store
+}
+
+func NewSyncService(store *store.Store) *SyncService {
+	return &SyncService{store: store}
+}
+
+// Push stores the new rows of a push and advances the segment's cursor in
+// one transaction. A snapshot whose timestamp is already stored is not
+// inserted again; if its values differ it is reported as a conflict and the
+// stored values win.
+func (s *SyncService) Push(ctx context.Context, push models.SyncPush) (models.SyncResult, error) {
+	var result models.SyncResult
+	if !syncNamePattern.MatchString(push.Node) || !syncNamePattern.MatchString(push.Segment) {
+		return result, fmt.Errorf("%w: node and segment must be 1-64 letters, digits, '.', '_' or '-'", ErrInvalidSyncPush)
+	}
+	if push.Offset < 0 || len(push.Metrics) > maxSyncPush {
+		return result, fmt.Errorf("%w: offset must be non-negative and at most %d metrics sent", ErrInvalidSyncPush, maxSyncPush)
+	}
+	for _, metrics := range push.Metrics {
+		if metrics.CreatedAt.IsZero() {
+			return result, fmt.Errorf("%w: created_at is required", ErrInvalidSyncPush)
+		}
+	}
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		acked, err := tx.SyncCursor(ctx, push.Node, push.Segment)
+		if err != nil {
+			return err
+		}
+		result.Acked = acked
+		if push.Offset > acked {
+			return ErrSyncGap
+		}
+		rows := push.Metrics[min(acked-push.Offset, len(push.Metrics)):]
+		if len(rows) == 0 {
+			return nil
+		}
+		fresh, err := s.classify(ctx, tx, rows, &result)
+		if err != nil {
+			return err
+		}
+		for start := 0; start < len(fresh); start += importBatchSize {
+			end := min(start+importBatchSize, len(fresh))
+			if err := tx.InsertMetricsBatch(ctx, fresh[start:end]); err != nil {
+				return err
+			}
+		}
+		result.Inserted = len(fresh)
+		result.Acked = push.Offset + len(push.Metrics)
+		return tx.SetSyncCursor(ctx, push.Node, push.Segment, result.Acked)
+	})
+	if err != nil && !errors.Is(err, ErrSyncGap) {
+		return models.SyncResult{}, err
+	}
+	return result, err
+}
+
+// classify returns the rows whose timestamp is not stored yet, counting the
+// others as duplicates or conflicts.
+func (s *SyncService) classify(ctx context.Context, tx *store.Store, rows []models.Metrics, result *models.SyncResult) ([]models.Metrics, error) {
+	from, to := rows[0].CreatedAt, rows[0].CreatedAt
+	for _, metrics := range rows {
+		if metrics.CreatedAt.Before(from) {
+			from = metrics.CreatedAt
+		}
+		if metrics.CreatedAt.After(to) {
+			to = metrics.CreatedAt
+		}
+	}
+	existing, err := tx.MetricsBetween(ctx, from, to, len(rows)*2+1)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[int64]models.Metrics, len(existing))
+	for _, metrics := range existing {
+		stored[metrics.CreatedAt.UnixNano()] = metrics
+	}
+	fresh := make([]models.Metrics, 0, len(rows))
+	for _, metrics := range rows {
+		key := metrics.CreatedAt.UnixNano()
+		previous, ok := stored[key]
+		switch {
+		case !ok:
+			fresh = append(fresh, metrics)
+			stored[key] = metrics
+		case sameMetrics(previous, metrics):
+			result.Duplicates++
+		default:
+			result.Conflicts = append(result.Conflicts, metrics)
+		}
+	}
+	return fresh, nil
+}
+
+func sameMetrics(a, b models.Metrics) bool {
+	return a.Revenue == b.Revenue && a.Growth == b.Growth && a.Sentiment == b.Sentiment && a.Backlog == b.Backlog
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	minPacingHistory = 14 * 24 * time.Hour
+	maxPacingHistory = 90 * 24 * time.Hour
+)
+
+var ErrInvalidTarget = errors.New("invalid target")
+
+// TargetService keeps quarterly metric targets and reports whether the
+// metrics are on pace to hit them.
+type TargetService struct {
+	store *store.Store
+	loc   *time.Location
+}
+
+func NewTargetService(store *store.Store) *TargetService {
+	return &TargetService{store: store, loc: time.Local}
+}
+
+// WithLocation sets the time zone quarters start in.
+func (s *TargetService) WithLocation(loc *time.Location) *TargetService {
+	if loc != nil {
+		s.loc = loc
+	}
+	return s
+}
+
+func (s *TargetService) List(ctx context.Context, quarter string) ([]models.Target, error) {
+	items, err := s.store.ListTargets(ctx, quarter)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Target{}
+	}
+	return items, nil
+}
+
+func (s *TargetService) Save(ctx context.Context, target models.Target) error {
+	if !slices.Contains(models.MetricKeys, target.Metric) {
+		return fmt.Errorf("%w: metric must be one of %v", ErrInvalidTarget, models.MetricKeys)
+	}
+	if _, _, err := s.quarterBounds(target.Quarter); err != nil {
+		return err
+	}
+	if math.IsNaN(target.Value) || math.IsInf(target.Value, 0) {
+		return fmt.Errorf("%w: value must be a number", ErrInvalidTarget)
+	}
+	if target.Direction == "" {
+		target.Direction = models.TargetAbove
+	}
+	if target.Direction != models.TargetAbove && target.Direction != models.TargetBelow {
+		return fmt.Errorf("%w: direction must be above or below", ErrInvalidTarget)
+	}
+	return s.store.SaveTarget(ctx, target)
+}
+
+func (s *TargetService) Delete(ctx context.Context, metric, quarter string) error {
+	return s.store.DeleteTarget(ctx, metric, quarter)
+}
+
+// CurrentQuarter names the quarter now falls in, such as "2026-Q4".
+func (s *TargetService) CurrentQuarter() string {
+	now := time.Now().In(s.loc)
+	return fmt.Sprintf("%d-Q%d", now.Year(), (int(now.Month())-1)/3+1)
+}
+
+// Pacing projects each metric with a target in quarter to the end of the
+// quarter and compares it with the target. The projection continues the
+// daily averages of the recent history, between 14 and 90 days of it, with
+// the same Holt method scenarios use. For a quarter that has ended the
+// value at its end is reported instead.
+func (s *TargetService) Pacing(ctx context.Context, quarter string) ([]models.TargetPacing, error) {
+	start, end, err := s.quarterBounds(quarter)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := s.store.ListTargets(ctx, quarter)
+	if err != nil {
+		return nil, err
+	}
+	out := []models.TargetPacing{}
+	if len(targets) == 0 {
+		return out, nil
+	}
+
+	now := time.Now()
+	if !now.Before(end) {
+		final, err := s.store.MetricsAt(ctx, end)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("%w: no snapshot before the end of %s", ErrNoData, quarter)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			value, _ := final.Value(target.Metric)
+			out = append(out, pace(target, end, value, value, 0))
+		}
+		return out, nil
+	}
+
+	from := now.Add(-min(max(now.Sub(start), minPacingHistory), maxPacingHistory))
+	points, err := s.store.MetricsBetween(ctx, from, now, maxRangeRows)
+	if err != nil {
+		return nil, err
+	}
+	history := bucketMetrics(points, from, 24*time.Hour)
+	if len(history) < minScenarioBuckets {
+		return nil, fmt.Errorf("%w: need at least %d days of history", ErrNoData, minScenarioBuckets)
+	}
+	latest := points[len(points)-1]
+	daysLeft := end.Sub(now).Hours() / 24
+	// The forecast runs from the last daily bucket, which starts before now.
+	steps := int(math.Ceil(end.Sub(history[len(history)-1].CreatedAt).Hours() / 24))
+	for _, target := range targets {
+		values := make([]float64, len(history))
+		for i, point := range history {
+			values[i], _ = point.Value(target.Metric)
+		}
+		projected := holtForecast(values, steps)
+		current, _ := latest.Value(target.Metric)
+		out = append(out, pace(target, end, current, projected[len(projected)-1], daysLeft))
+	}
+	return out, nil
+}
+
+func pace(target models.Target, end time.Time, current, projected, daysLeft float64) models.TargetPacing {
+	pacing := models.TargetPacing{
+		Target:     target,
+		Current:    current,
+		Projected:  projected,
+		Margin:     projected - target.Value,
+		QuarterEnd: end,
+		DaysLeft:   daysLeft,
+	}
+	if target.Direction == models.TargetBelow {
+		pacing.Margin = -pacing.Margin
+	}
+	if target.Value != 0 {
+		pacing.MarginPercent = pacing.Margin / math.Abs(target.Value) * 100
+	}
+	pacing.OnTrack = pacing.Margin >= 0
+	if daysLeft > 0 {
+		pacing.RequiredPerDay = (target.Value - current) / daysLeft
+		pacing.ProjectedPerDay = (projected - current) / daysLeft
+	}
+	return pacing
+}
+
+// quarterBounds parses a quarter such as "2026-Q4" into its start and the
+// start of the next one.
+func (s *TargetService) quarterBounds(quarter string) (time.Time, time.Time, error) {
+	year, q, ok := parseQuarter(quarter)
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: quarter must look like 2026-Q4", ErrInvalidTarget)
+	}
+	start := time.Date(year, time.Month(3*(q-1)+1), 1, 0, 0, 0, 0, s.loc)
+	return start, start.AddDate(0, 3, 0), nil
+}
+
+func parseQuarter(quarter string) (int, int, bool) {
+	if len(quarter) != 7 || quarter[4:6] != "-Q" {
+		return 0, 0, false
+	}
+	year, err := strconv.Atoi(quarter[:4])
+	q := int(quarter[6] - '0')
+	return year, q, err == nil && year > 0 && q >= 1 && q <= 4
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const maxTeamName = 64
+
+var (
+	ErrInvalidTeam  = errors.New("invalid team")
+	ErrTeamNotEmpty = errors.New("team or org still has teams below it")
+)
+
+// TeamService keeps the org and team hierarchy and who belongs to which
+// team. Widgets, insight rules and assignments name a team in team_id; a
+// team's view of them includes those of every team below it.
+type TeamService struct {
+	store *store.Store
+}
+
+func NewTeamService(store *store.Store) *TeamService {
+	return &TeamService{store: store}
+}
+
+func (s *TeamService) Orgs(ctx context.Context) ([]models.Org, error) {
+	items, err := s.store.ListOrgs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Org{}
+	}
+	return items, nil
+}
+
+func (s *TeamService) Org(ctx context.Context, id int64) (models.Org, error) {
+	return s.store.OrgByID(ctx, id)
+}
+
+// SaveOrg creates org when its ID is zero and renames it otherwise.
+func (s *TeamService) SaveOrg(ctx context.Context, org models.Org) (models.Org, error) {
+	name, err := teamName(org.Name)
+	if err != nil {
+		return models.Org{}, err
+	}
+	org.Name = name
+	return s.store.SaveOrg(ctx, org)
+}
+
+// DeleteOrg removes an org that has no teams left.
+func (s *TeamService) DeleteOrg(ctx context.Context, id int64) error {
+	if _, err := s.store.OrgByID(ctx, id); err != nil {
+		return err
+	}
+	teams, err := s.store.ListTeams(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(teams) > 0 {
+		return ErrTeamNotEmpty
+	}
+	return s.store.DeleteOrg(ctx, id)
+}
+
+// Teams lists the teams of an org, or of every org when orgID is zero.
+func (s *TeamService) Teams(ctx context.Context, orgID int64) ([]models.Team, error) {
+	if orgID != 0 {
+		if _, err := s.store.OrgByID(ctx, orgID); err != nil {
+			return nil, err
+		}
+	}
+	items, err := s.store.ListTeams(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Team{}
+	}
+	return items, nil
+}
+
+func (s *TeamService) Team(ctx context.Context, id int64) (models.Team, error) {
+	return s.store.TeamByID(ctx, id)
+}
+
+// SaveTeam creates team when its ID is zero and updates it otherwise. A team
+// stays in the org it was created in; its parent must be in that org too and
+// must not be the team itself or one below it.
+func (s *TeamService) SaveTeam(ctx context.Context, team models.Team) (models.Team, error) {
+	name, err := teamName(team.Name)
+	if err != nil {
+		return models.Team{}, err
+	}
+	team.Name = name
+	if team.ID != 0 {
+		existing, err := s.store.TeamByID(ctx, team.ID)
+		if err != nil {
+			return models.Team{}, err
+		}
+		team.OrgID = existing.OrgID
+	}
+	if _, err := s.store.OrgByID(ctx, team.OrgID); errors.Is(err, store.ErrNotFound) {
+		return models.Team{}, fmt.Errorf("%w: no org %d", ErrInvalidTeam, team.OrgID)
+	} else if err != nil {
+		return models.Team{}, err
+	}
+	if team.ParentID != nil {
+		teams, err := s.store.ListTeams(ctx, team.OrgID)
+		if err != nil {
+			return models.Team{}, err
+		}
+		if !slices.ContainsFunc(teams, func(t models.Team) bool { return t.ID == *team.ParentID }) {
+			return models.Team{}, fmt.Errorf("%w: parent_id: no team %d in org %d", ErrInvalidTeam, *team.ParentID, team.OrgID)
+		}
+		if team.ID != 0 && slices.Contains(subtree(teams, team.ID), *team.ParentID) {
+			return models.Team{}, fmt.Errorf("%w: parent_id: team %d is this team or below it", ErrInvalidTeam, *team.ParentID)
+		}
+	}
+	return s.store.SaveTeam(ctx, team)
+}
+
+// DeleteTeam removes a team with no teams below it. Whatever was scoped to it
+// becomes unscoped.
+func (s *TeamService) DeleteTeam(ctx context.Context, id int64) error {
+	team, err := s.store.TeamByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	teams, err := s.store.ListTeams(ctx, team.OrgID)
+	if err != nil {
+		return err
+	}
+	if len(subtree(teams, id)) > 1 {
+		return ErrTeamNotEmpty
+	}
+	return s.store.DeleteTeam(ctx, id)
+}
+
+// Subtree returns the ID of a team and of every team below it.
+func (s *TeamService) Subtree(ctx context.Context, id int64) ([]int64, error) {
+	team, err := s.store.TeamByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := s.store.ListTeams(ctx, team.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	return subtree(teams, id), nil
+}
+
+func (s *TeamService) Members(ctx context.Context, teamID int64) ([]models.TeamMember, error) {
+	if _, err := s.store.TeamByID(ctx, teamID); err != nil {
+		return nil, err
+	}
+	items, err := s.store.TeamMembers(ctx, teamID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.TeamMember{}
+	}
+	return items, nil
+}
+
+// SetMember adds an active user to a team or changes their role in it. Role
+// defaults to member.
+func (s *TeamService) SetMember(ctx context.Context, teamID, userID int64, role string) (models.TeamMember, error) {
+	switch role {
+	case "":
+		role = models.TeamRoleMember
+	case models.TeamRoleMember, models.TeamRoleLead:
+	default:
+		return models.TeamMember{}, fmt.Errorf("%w: role must be %s or %s", ErrInvalidTeam, models.TeamRoleMember, models.TeamRoleLead)
+	}
+	if _, err := s.store.TeamByID(ctx, teamID); err != nil {
+		return models.TeamMember{}, err
+	}
+	user, err := s.store.UserByID(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && user.Disabled) {
+		return models.TeamMember{}, fmt.Errorf("%w: no active user #%d", ErrInvalidTeam, userID)
+	}
+	if err != nil {
+		return models.TeamMember{}, err
+	}
+	if err := s.store.SaveTeamMember(ctx, models.TeamMember{TeamID: teamID, UserID: userID, Role: role}); err != nil {
+		return models.TeamMember{}, err
+	}
+	members, err := s.store.TeamMembers(ctx, teamID, userID)
+	if err != nil {
+		return models.TeamMember{}, err
+	}
+	if len(members) == 0 {
+		return models.TeamMember{}, store.ErrNotFound
+	}
+	return members[0], nil
+}
+
+func (s *TeamService) RemoveMember(ctx context.Context, teamID, userID int64) error {
+	return s.store.DeleteTeamMember(ctx, teamID, userID)
+}
+
+// UserTeams lists the teams a user belongs to, with their role in each.
+func (s *TeamService) UserTeams(ctx context.Context, userID int64) ([]models.TeamMembership, error) {
+	members, err := s.store.TeamMembers(ctx, 0, userID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]models.TeamMembership, 0, len(members))
+	for _, member := range members {
+		team, err := s.store.TeamByID(ctx, member.TeamID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, models.TeamMembership{Team: team, Role: member.Role})
+	}
+	return items, nil
+}
+
+func teamName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxTeamName {
+		return "", fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidTeam, maxTeamName)
+	}
+	return name, nil
+}
+
+// subtree returns id and the IDs of the teams below it, walking down from id
+// so a cycle in stored data cannot loop.
+func subtree(teams []models.Team, id int64) []int64 {
+	ids := []int64{id}
+	for i := 0; i < len(ids); i++ {
+		for _, team := range teams {
+			if team.ParentID != nil && *team.ParentID == ids[i] && !slices.Contains(ids, team.ID) {
+				ids = append(ids, team.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// checkTeam returns invalid, wrapped, when teamID is set but names no team.
+func checkTeam(ctx context.Context, st *store.Store, teamID *int64, invalid error) error {
+	if teamID == nil {
+		return nil
+	}
+	_, err := st.TeamByID(ctx, *teamID)
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("%w: team_id: no team %d", invalid, *teamID)
+	}
+	return err
+}
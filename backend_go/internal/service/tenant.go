@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"mydashboard-backend/internal/i18n"
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	defaultTenantName = "MyDashboard"
+	maxTenantName     = 64
+	maxTenantLogoURL  = 512
+	maxTenantPalette  = 12
+)
+
+var ErrInvalidTenantSettings = errors.New("invalid tenant settings")
+
+// TenantSettingsService keeps per-tenant branding and defaults. Fields a
+// tenant leaves empty come from the default tenant's settings, then from the
+// built-in ones: the server's timezone, when it has a name, and the revenue
+// currency.
+type TenantSettingsService struct {
+	store    *store.Store
+	location *time.Location
+	catalog  *MetricCatalog
+}
+
+func NewTenantSettingsService(store *store.Store) *TenantSettingsService {
+	return &TenantSettingsService{store: store, location: time.Local}
+}
+
+func (s *TenantSettingsService) WithLocation(loc *time.Location) *TenantSettingsService {
+	s.location = loc
+	return s
+}
+
+// WithCatalog checks saved currencies against the exchange rates the
+// catalog converts with.
+func (s *TenantSettingsService) WithCatalog(catalog *MetricCatalog) *TenantSettingsService {
+	s.catalog = catalog
+	return s
+}
+
+// Get returns the settings tenant sees, with the defaults filled in.
+func (s *TenantSettingsService) Get(ctx context.Context, tenant string) (models.TenantSettings, error) {
+	tenant = metaTenantOrDefault(tenant)
+	settings := s.builtin()
+	settings.Tenant = tenant
+	for _, layer := range []string{DefaultTenant, tenant} {
+		saved, err := s.store.TenantSettings(ctx, layer)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return models.TenantSettings{}, err
+		}
+		applyTenantSettings(&settings, saved)
+		if layer == tenant {
+			break
+		}
+	}
+	return settings, nil
+}
+
+// Save replaces what is stored for settings.Tenant.
+func (s *TenantSettingsService) Save(ctx context.Context, settings models.TenantSettings) (models.TenantSettings, error) {
+	settings.Tenant = metaTenantOrDefault(settings.Tenant)
+	if err := s.validate(&settings); err != nil {
+		return models.TenantSettings{}, err
+	}
+	if err := s.store.SaveTenantSettings(ctx, settings); err != nil {
+		return models.TenantSettings{}, err
+	}
+	return s.Get(ctx, settings.Tenant)
+}
+
+// Delete drops a tenant's settings, so it falls back to the defaults.
+func (s *TenantSettingsService) Delete(ctx context.Context, tenant string) error {
+	return s.store.DeleteTenantSettings(ctx, metaTenantOrDefault(tenant))
+}
+
+func (s *TenantSettingsService) builtin() models.TenantSettings {
+	settings := models.TenantSettings{Name: defaultTenantName, Locale: i18n.Default}
+	// "Local" names no zone the frontend could use; it keeps the browser's.
+	if name := s.location.String(); name != "Local" {
+		settings.Timezone = name
+	}
+	if s.catalog != nil {
+		if defs, _, err := s.catalog.Definitions(""); err == nil {
+			settings.Currency = defs["revenue"].Currency
+		}
+	}
+	return settings
+}
+
+func (s *TenantSettingsService) validate(settings *models.TenantSettings) error {
+	if !metaTenant.MatchString(settings.Tenant) {
+		return fmt.Errorf("%w: tenant must match %s", ErrInvalidTenantSettings, metaTenant)
+	}
+	settings.Name = strings.TrimSpace(settings.Name)
+	if utf8.RuneCountInString(settings.Name) > maxTenantName {
+		return fmt.Errorf("%w: name: at most %d characters", ErrInvalidTenantSettings, maxTenantName)
+	}
+	if settings.LogoURL != "" {
+		if len(settings.LogoURL) > maxTenantLogoURL {
+			return fmt.Errorf("%w: logo_url: at most %d characters", ErrInvalidTenantSettings, maxTenantLogoURL)
+		}
+		u, err := url.Parse(settings.LogoURL)
+		if err != nil || !(u.Scheme == "https" || u.Scheme == "http") && !(u.Scheme == "" && u.Host == "" && strings.HasPrefix(u.Path, "/")) {
+			return fmt.Errorf("%w: logo_url must be an http(s) URL or an absolute path", ErrInvalidTenantSettings)
+		}
+	}
+	if len(settings.Palette) > maxTenantPalette {
+		return fmt.Errorf("%w: palette: at most %d colors", ErrInvalidTenantSettings, maxTenantPalette)
+	}
+	for i, color := range settings.Palette {
+		if !metaColor.MatchString(color) {
+			return fmt.Errorf("%w: palette[%d] must be a hex color such as #1f77b4", ErrInvalidTenantSettings, i)
+		}
+	}
+	if settings.Locale != "" {
+		locale, ok := i18n.Match(settings.Locale)
+		if !ok {
+			return fmt.Errorf("%w: locale must be one of %s", ErrInvalidTenantSettings, strings.Join(i18n.Locales(), ", "))
+		}
+		settings.Locale = locale
+	}
+	if settings.Timezone != "" {
+		if _, err := time.LoadLocation(settings.Timezone); err != nil {
+			return fmt.Errorf("%w: timezone: %v", ErrInvalidTenantSettings, err)
+		}
+	}
+	if settings.Currency != "" {
+		settings.Currency = strings.ToUpper(settings.Currency)
+		if s.catalog != nil {
+			if _, _, err := s.catalog.Definitions(settings.Currency); err != nil {
+				return fmt.Errorf("%w: currency: %v", ErrInvalidTenantSettings, err)
+			}
+		}
+	}
+	return nil
+}
+
+// applyTenantSettings lays saved over settings; empty fields keep what
+// settings has.
+func applyTenantSettings(settings *models.TenantSettings, saved models.TenantSettings) {
+	if saved.Name != "" {
+		settings.Name = saved.Name
+	}
+	if saved.LogoURL != "" {
+		settings.LogoURL = saved.LogoURL
+	}
+	if len(saved.Palette) > 0 {
+		settings.Palette = saved.Palette
+	}
+	if saved.Locale != "" {
+		settings.Locale = saved.Locale
+	}
+	if saved.Timezone != "" {
+		settings.Timezone = saved.Timezone
+	}
+	if saved.Currency != "" {
+		settings.Currency = saved.Currency
+	}
+	if saved.UpdatedAt != nil && (settings.UpdatedAt == nil || saved.UpdatedAt.After(*settings.UpdatedAt)) {
+		settings.UpdatedAt = saved.UpdatedAt
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"mydashboard-backend/internal/models"
+)
+
+// scaleLabels relabel converted metrics, whose configured unit may name the
+// original currency (as "亿元" does).
+var scaleLabels = map[float64]string{
+	1:    "",
+	1e3:  "K",
+	1e6:  "M",
+	1e8:  "亿",
+	1e9:  "B",
+	1e12: "T",
+}
+
+const maxPrecision = 6
+
+// DefaultMetricDefinitions matches the units the dashboard has always shown,
+// with as many decimals as the cards display.
+func DefaultMetricDefinitions() map[string]models.MetricDefinition {
+	return map[string]models.MetricDefinition{
+		"revenue":   {Key: "revenue", Unit: "B", Currency: "USD", Scale: 1e9, Precision: precision(2)},
+		"growth":    {Key: "growth", Unit: "%", Precision: precision(2)},
+		"sentiment": {Key: "sentiment", Unit: "%", Precision: precision(0)},
+		"backlog":   {Key: "backlog", Unit: "K", Scale: 1e3},
+	}
+}
+
+func precision(digits int) *int {
+	return &digits
+}
+
+// ParseMetricUnits overrides definitions with entries such as
+// "revenue:亿元:CNY:1e8", each being key:unit[:currency[:scale]].
+func ParseMetricUnits(spec string, defs map[string]models.MetricDefinition) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		key := strings.ToLower(parts[0])
+		if _, ok := (models.Metrics{}).Value(key); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownMetric, key)
+		}
+		if len(parts) < 2 || len(parts) > 4 {
+			return fmt.Errorf("invalid unit %q (want key:unit[:currency[:scale]])", item)
+		}
+		def := models.MetricDefinition{Key: key, Unit: parts[1], Precision: defs[key].Precision}
+		if len(parts) > 2 && parts[2] != "" {
+			if key == "backlog" {
+				return fmt.Errorf("invalid unit %q: backlog is a count", item)
+			}
+			def.Currency = strings.ToUpper(parts[2])
+		}
+		if len(parts) > 3 {
+			scale, err := strconv.ParseFloat(parts[3], 64)
+			if err != nil || scale <= 0 {
+				return fmt.Errorf("invalid scale in %q", item)
+			}
+			def.Scale = scale
+		}
+		defs[key] = def
+	}
+	return nil
+}
+
+// ParseMetricPrecision overrides the decimals of definitions with entries
+// such as "revenue:2,sentiment:0". The key "*" sets the precision of metrics
+// without one of their own, derived metrics included, and is returned.
+func ParseMetricPrecision(spec string, defs map[string]models.MetricDefinition) (*int, error) {
+	var fallback *int
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		digits, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || digits < 0 || digits > maxPrecision {
+			return nil, fmt.Errorf("invalid precision %q (want key:digits with 0-%d digits)", item, maxPrecision)
+		}
+		if key == "*" {
+			fallback = precision(digits)
+			continue
+		}
+		if _, ok := (models.Metrics{}).Value(key); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownMetric, key)
+		}
+		def, ok := defs[key]
+		if !ok {
+			def = models.MetricDefinition{Key: key}
+		}
+		def.Precision = precision(digits)
+		defs[key] = def
+	}
+	return fallback, nil
+}
+
+// MetricCatalog describes metric units and converts currency metrics into
+// the currency a client asks for. The scale is kept, so USD billions become
+// CNY billions.
+type MetricCatalog struct {
+	defs     map[string]models.MetricDefinition
+	fx       *FXRates
+	fallback *int
+}
+
+func NewMetricCatalog(defs map[string]models.MetricDefinition, fx *FXRates) *MetricCatalog {
+	return &MetricCatalog{defs: defs, fx: fx}
+}
+
+// WithDefaultPrecision rounds metrics without a precision of their own,
+// derived metrics included. Nil leaves them as stored.
+func (c *MetricCatalog) WithDefaultPrecision(digits *int) *MetricCatalog {
+	c.fallback = digits
+	return c
+}
+
+// Definitions returns the definitions as seen in currency, plus the factor
+// each metric's values must be multiplied by. An empty currency converts
+// nothing.
+func (c *MetricCatalog) Definitions(currency string) (map[string]models.MetricDefinition, map[string]float64, error) {
+	defs := make(map[string]models.MetricDefinition, len(models.MetricKeys))
+	factors := make(map[string]float64, len(models.MetricKeys))
+	for _, key := range models.MetricKeys {
+		def, ok := c.defs[key]
+		if !ok {
+			def = models.MetricDefinition{Key: key}
+		}
+		if def.Precision == nil && key != "backlog" {
+			def.Precision = c.fallback
+		}
+		factors[key] = 1
+		if currency != "" && def.Currency != "" {
+			rate, err := c.fx.Rate(def.Currency, currency)
+			if err != nil {
+				return nil, nil, err
+			}
+			factors[key] = rate
+			def.Currency = strings.ToUpper(currency)
+			if label, ok := scaleLabels[def.Scale]; ok {
+				def.Unit = label
+			}
+		}
+		defs[key] = def
+	}
+	return defs, factors, nil
+}
+
+func (c *MetricCatalog) List(currency string) ([]models.MetricDefinition, error) {
+	defs, _, err := c.Definitions(currency)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]models.MetricDefinition, 0, len(defs))
+	for _, key := range models.MetricKeys {
+		list = append(list, defs[key])
+	}
+	return list, nil
+}
+
+// Convert applies factors from Definitions to a snapshot.
+func Convert(metrics models.Metrics, factors map[string]float64) models.Metrics {
+	for _, key := range models.MetricKeys {
+		if factor := factors[key]; factor != 0 && factor != 1 {
+			value, _ := metrics.Value(key)
+			metrics = withValue(metrics, key, value*factor)
+		}
+	}
+	return metrics
+}
+
+// Round applies the configured precision to a snapshot about to be sent.
+// It works on a copy; stored values keep their full precision. A nil catalog
+// rounds nothing.
+func (c *MetricCatalog) Round(metrics models.Metrics) models.Metrics {
+	if c == nil {
+		return metrics
+	}
+	for _, key := range models.MetricKeys {
+		if key != "backlog" {
+			value, _ := metrics.Value(key)
+			metrics = withValue(metrics, key, c.RoundValue(key, value))
+		}
+	}
+	if c.fallback != nil && len(metrics.Derived) > 0 {
+		derived := make(map[string]float64, len(metrics.Derived))
+		for key, value := range metrics.Derived {
+			derived[key] = roundTo(value, *c.fallback)
+		}
+		metrics.Derived = derived
+	}
+	return metrics
+}
+
+// RoundValue rounds one value of the metric key, base or derived.
+func (c *MetricCatalog) RoundValue(key string, value float64) float64 {
+	if c == nil {
+		return value
+	}
+	digits := c.fallback
+	if def, ok := c.defs[key]; ok && def.Precision != nil {
+		digits = def.Precision
+	}
+	if digits == nil {
+		return value
+	}
+	return roundTo(value, *digits)
+}
+
+func roundTo(value float64, digits int) float64 {
+	scale := math.Pow10(digits)
+	rounded := math.Round(value*scale) / scale
+	if math.IsInf(rounded, 0) || math.IsNaN(rounded) {
+		return value
+	}
+	return rounded
+}
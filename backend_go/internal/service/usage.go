@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	DefaultTenant   = "default"
+	usageBucket     = time.Hour
+	maxUsageBacklog = 10000
+)
+
+type usageKey struct {
+	bucket time.Time
+	tenant string
+	caller string
+}
+
+// UsageService counts requests in memory and writes them out in batches, so
+// accounting adds no query to the request path.
+type UsageService struct {
+	store *store.Store
+
+	mu       sync.Mutex
+	counters map[usageKey]*models.UsageCounter
+}
+
+func NewUsageService(store *store.Store) *UsageService {
+	return &UsageService{
+		store:    store,
+		counters: map[usageKey]*models.UsageCounter{},
+	}
+}
+
+// Record accounts one request to caller, the authenticated identity the
+// server resolved ("user:<id>", "admin" or "embed:<id>"), or "" for anonymous
+// requests. Nothing a client merely claims in a header is trusted, and since
+// tenants are not authenticated all usage counts against DefaultTenant.
+func (s *UsageService) Record(caller string, bytesIn, bytesOut int64, failed bool) {
+	key := usageKey{bucket: time.Now().Truncate(usageBucket), tenant: DefaultTenant, caller: caller}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, ok := s.counters[key]
+	if !ok {
+		if len(s.counters) >= maxUsageBacklog {
+			return
+		}
+		counter = &models.UsageCounter{BucketStart: key.bucket, Tenant: DefaultTenant, Caller: caller}
+		s.counters[key] = counter
+	}
+	counter.Requests++
+	counter.BytesIn += bytesIn
+	counter.BytesOut += bytesOut
+	if failed {
+		counter.Errors++
+	}
+}
+
+// Flush writes the pending counters; they are merged back on failure so no
+// traffic is lost to a short outage.
+func (s *UsageService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.counters
+	s.counters = map[usageKey]*models.UsageCounter{}
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	batch := make([]models.UsageCounter, 0, len(pending))
+	for _, counter := range pending {
+		batch = append(batch, *counter)
+	}
+	if err := s.store.AddUsage(ctx, batch); err != nil {
+		s.mu.Lock()
+		for key, counter := range pending {
+			if current, ok := s.counters[key]; ok {
+				current.Requests += counter.Requests
+				current.Errors += counter.Errors
+				current.BytesIn += counter.BytesIn
+				current.BytesOut += counter.BytesOut
+				continue
+			}
+			s.counters[key] = counter
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Report includes counters not yet flushed so it reflects the last few
+// seconds of traffic too. from is widened to its hourly bucket.
+func (s *UsageService) Report(ctx context.Context, from, to time.Time, byKey bool) (models.UsageReport, error) {
+	from = from.Truncate(usageBucket)
+	rows, err := s.store.UsageReport(ctx, from, to, byKey)
+	if err != nil {
+		return models.UsageReport{}, err
+	}
+
+	type rowKey struct{ tenant, caller string }
+	index := make(map[rowKey]int, len(rows))
+	for i, row := range rows {
+		index[rowKey{row.Tenant, row.Caller}] = i
+	}
+	s.mu.Lock()
+	for key, counter := range s.counters {
+		if key.bucket.Before(from) || !key.bucket.Before(to) {
+			continue
+		}
+		k := rowKey{tenant: key.tenant}
+		if byKey {
+			k.caller = key.caller
+		}
+		i, ok := index[k]
+		if !ok {
+			rows = append(rows, models.UsageRow{Tenant: k.tenant, Caller: k.caller})
+			i = len(rows) - 1
+			index[k] = i
+		}
+		rows[i].Requests += counter.Requests
+		rows[i].Errors += counter.Errors
+		rows[i].BytesIn += counter.BytesIn
+		rows[i].BytesOut += counter.BytesOut
+	}
+	s.mu.Unlock()
+
+	sort.SliceStable(rows, func(a, b int) bool { return rows[a].Requests > rows[b].Requests })
+	groupBy := "tenant"
+	if byKey {
+		groupBy = "key"
+	}
+	return models.UsageReport{From: from, To: to, GroupBy: groupBy, Rows: rows}, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mydashboard-backend/internal/models"
+)
+
+const maxReportedViolations = 20
+
+var ErrImplausibleMetrics = errors.New("implausible metric values")
+
+// MetricBound limits one metric. Nil fields are not checked; MaxDelta is the
+// largest change allowed between consecutive snapshots.
+type MetricBound struct {
+	Min      *float64
+	Max      *float64
+	MaxDelta *float64
+}
+
+// ValidationError carries every violation found in a batch that was
+// rejected.
+type ValidationError struct {
+	Violations []models.MetricViolation
+}
+
+func (e *ValidationError) Error() string {
+	shown := min(len(e.Violations), maxReportedViolations)
+	parts := make([]string, 0, shown+1)
+	for _, v := range e.Violations[:shown] {
+		parts = append(parts, fmt.Sprintf("item %d %s=%g exceeds %s %g", v.Index, v.Metric, v.Value, v.Rule, v.Limit))
+	}
+	if extra := len(e.Violations) - shown; extra > 0 {
+		parts = append(parts, fmt.Sprintf("and %d more", extra))
+	}
+	return fmt.Sprintf("%s: %s", ErrImplausibleMetrics, strings.Join(parts, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrImplausibleMetrics
+}
+
+// ParseMetricBounds reads rules such as "revenue:0:100:5,backlog:0::50",
+// each being key:min:max:maxDelta with empty fields left unchecked.
+func ParseMetricBounds(spec string) (map[string]MetricBound, error) {
+	bounds := map[string]MetricBound{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		key := strings.ToLower(parts[0])
+		if _, ok := (models.Metrics{}).Value(key); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownMetric, key)
+		}
+		if len(parts) < 2 || len(parts) > 4 {
+			return nil, fmt.Errorf("invalid bound %q (want key:min:max:maxDelta)", item)
+		}
+		var limits [3]*float64
+		for i, part := range parts[1:] {
+			if part == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bound %q: %w", item, err)
+			}
+			limits[i] = &value
+		}
+		if limits[0] != nil && limits[1] != nil && *limits[0] > *limits[1] {
+			return nil, fmt.Errorf("invalid bound %q: min is above max", item)
+		}
+		bounds[key] = MetricBound{Min: limits[0], Max: limits[1], MaxDelta: limits[2]}
+	}
+	return bounds, nil
+}
+
+type MetricValidator struct {
+	bounds   map[string]MetricBound
+	flagOnly bool
+}
+
+// NewMetricValidator rejects batches with violations, or with flagOnly
+// accepts them and only reports the violations.
+func NewMetricValidator(bounds map[string]MetricBound, flagOnly bool) *MetricValidator {
+	return &MetricValidator{bounds: bounds, flagOnly: flagOnly}
+}
+
+func (v *MetricValidator) checksDelta() bool {
+	for _, bound := range v.bounds {
+		if bound.MaxDelta != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Check compares items with each other in time order; previous, when set,
+// is the stored snapshot the earliest items are compared against.
+func (v *MetricValidator) Check(items []models.Metrics, previous *models.Metrics) []models.MetricViolation {
+	if v == nil || len(v.bounds) == 0 {
+		return nil
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return items[order[a]].CreatedAt.Before(items[order[b]].CreatedAt) })
+
+	var violations []models.MetricViolation
+	for _, i := range order {
+		item := items[i]
+		for _, key := range models.MetricKeys {
+			bound, ok := v.bounds[key]
+			if !ok {
+				continue
+			}
+			value, _ := item.Value(key)
+			violation := models.MetricViolation{Index: i, Metric: key, Value: value}
+			switch {
+			case bound.Min != nil && value < *bound.Min:
+				violation.Rule, violation.Limit = "min", *bound.Min
+			case bound.Max != nil && value > *bound.Max:
+				violation.Rule, violation.Limit = "max", *bound.Max
+			case bound.MaxDelta != nil && previous != nil && previous.CreatedAt.Before(item.CreatedAt):
+				last, _ := previous.Value(key)
+				if math.Abs(value-last) > *bound.MaxDelta {
+					violation.Rule, violation.Limit = "delta", *bound.MaxDelta
+				}
+			}
+			if violation.Rule != "" {
+				violations = append(violations, violation)
+			}
+		}
+		previous = &items[i]
+	}
+	sort.SliceStable(violations, func(a, b int) bool { return violations[a].Index < violations[b].Index })
+	return violations
+}
+
+// validate returns the violations to report, or a ValidationError when the
+// batch must be rejected.
+func (s *MetricsService) validate(ctx context.Context, items []models.Metrics) ([]models.MetricViolation, error) {
+	if s.validator == nil || len(s.validator.bounds) == 0 {
+		return nil, nil
+	}
+	var previous *models.Metrics
+	if s.validator.checksDelta() {
+		latest, err := s.store.LatestMetrics(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !latest.CreatedAt.IsZero() {
+			previous = &latest
+		}
+	}
+	violations := s.validator.Check(items, previous)
+	if len(violations) > 0 && !s.validator.flagOnly {
+		return nil, &ValidationError{Violations: violations}
+	}
+	return violations, nil
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+	"mydashboard-backend/internal/store"
+)
+
+const (
+	maxWidgetSeries      = 10
+	maxWidgetParams      = 5
+	maxWidgetParamValues = 50
+	defaultWidgetWindow  = 24
+	maxWidgetWindow      = 1000
+)
+
+var ErrInvalidWidget = errors.New("invalid widget")
+
+var (
+	widgetName       = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+	widgetParamValue = regexp.MustCompile(`^[A-Za-z0-9_.]{1,64}$`)
+	widgetParamRef   = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*)\s*\}\}`)
+)
+
+// WidgetService keeps the widget definitions. Widgets are metric
+// expressions, not SQL, so they read through the same code paths and
+// restrictions as /api/metrics/query.
+type WidgetService struct {
+	store   *store.Store
+	metrics *MetricsService
+}
+
+func NewWidgetService(store *store.Store, metrics *MetricsService) *WidgetService {
+	return &WidgetService{store: store, metrics: metrics}
+}
+
+// PreparedWidget is a widget with its parameters filled in and its series
+// compiled, ready for the caller to check access and run.
+type PreparedWidget struct {
+	Widget  models.Widget
+	Params  map[string]string
+	Queries []*MetricQuery
+}
+
+func (s *WidgetService) List(ctx context.Context) ([]models.Widget, error) {
+	items, err := s.store.ListWidgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []models.Widget{}
+	}
+	return items, nil
+}
+
+func (s *WidgetService) Get(ctx context.Context, name string) (models.Widget, error) {
+	return s.store.WidgetByName(ctx, name)
+}
+
+// Save creates or replaces the widget named widget.Name. Every series is
+// compiled with each allowed value of each parameter, so a saved widget
+// cannot fail to compile later.
+func (s *WidgetService) Save(ctx context.Context, widget models.Widget) (models.Widget, error) {
+	if err := s.validate(ctx, &widget); err != nil {
+		return models.Widget{}, err
+	}
+	if err := s.store.SaveWidget(ctx, widget); err != nil {
+		return models.Widget{}, err
+	}
+	return s.store.WidgetByName(ctx, widget.Name)
+}
+
+func (s *WidgetService) Delete(ctx context.Context, name string) error {
+	return s.store.DeleteWidget(ctx, name)
+}
+
+func (s *WidgetService) validate(ctx context.Context, widget *models.Widget) error {
+	if !widgetName.MatchString(widget.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrInvalidWidget, widgetName)
+	}
+	widget.Title = strings.TrimSpace(widget.Title)
+	if widget.Title == "" {
+		widget.Title = widget.Name
+	}
+	switch widget.Kind {
+	case "":
+		widget.Kind = models.WidgetSeriesKind
+	case models.WidgetSeriesKind, models.WidgetStatKind:
+	default:
+		return fmt.Errorf("%w: kind must be %s or %s", ErrInvalidWidget, models.WidgetSeriesKind, models.WidgetStatKind)
+	}
+	if widget.Window < 0 || widget.Window > maxWidgetWindow {
+		return fmt.Errorf("%w: window must be between 0 and %d", ErrInvalidWidget, maxWidgetWindow)
+	}
+	if err := checkTeam(ctx, s.store, widget.TeamID, ErrInvalidWidget); err != nil {
+		return err
+	}
+	if len(widget.Series) == 0 || len(widget.Series) > maxWidgetSeries {
+		return fmt.Errorf("%w: between 1 and %d series required", ErrInvalidWidget, maxWidgetSeries)
+	}
+	if len(widget.Params) > maxWidgetParams {
+		return fmt.Errorf("%w: at most %d params", ErrInvalidWidget, maxWidgetParams)
+	}
+	if widget.Params == nil {
+		widget.Params = []models.WidgetParam{}
+	}
+	defaults := map[string]string{}
+	for i, param := range widget.Params {
+		if !widgetName.MatchString(param.Name) {
+			return fmt.Errorf("%w: param %d: name must match %s", ErrInvalidWidget, i, widgetName)
+		}
+		if _, ok := defaults[param.Name]; ok {
+			return fmt.Errorf("%w: param %s declared twice", ErrInvalidWidget, param.Name)
+		}
+		if len(param.Allowed) == 0 || len(param.Allowed) > maxWidgetParamValues {
+			return fmt.Errorf("%w: param %s: between 1 and %d allowed values required", ErrInvalidWidget, param.Name, maxWidgetParamValues)
+		}
+		for _, value := range param.Allowed {
+			if !widgetParamValue.MatchString(value) {
+				return fmt.Errorf("%w: param %s: invalid value %q", ErrInvalidWidget, param.Name, value)
+			}
+		}
+		if param.Default == "" {
+			widget.Params[i].Default = param.Allowed[0]
+		} else if !slices.Contains(param.Allowed, param.Default) {
+			return fmt.Errorf("%w: param %s: default %q is not an allowed value", ErrInvalidWidget, param.Name, param.Default)
+		}
+		defaults[param.Name] = widget.Params[i].Default
+	}
+	for i, series := range widget.Series {
+		if strings.TrimSpace(series.Label) == "" {
+			widget.Series[i].Label = fmt.Sprintf("series %d", i+1)
+		}
+		for _, ref := range widgetParamRef.FindAllStringSubmatch(series.Label+" "+series.Expr, -1) {
+			if _, ok := defaults[ref[1]]; !ok {
+				return fmt.Errorf("%w: series %d: undeclared param %s", ErrInvalidWidget, i, ref[1])
+			}
+		}
+		for _, param := range widget.Params {
+			for _, value := range param.Allowed {
+				values := map[string]string{}
+				for name, value := range defaults {
+					values[name] = value
+				}
+				values[param.Name] = value
+				if _, err := s.metrics.CompileQuery(ctx, fillWidgetParams(series.Expr, values)); err != nil {
+					return fmt.Errorf("%w: series %d with %s=%s: %v", ErrInvalidWidget, i, param.Name, value, err)
+				}
+			}
+		}
+		if _, err := s.metrics.CompileQuery(ctx, fillWidgetParams(series.Expr, defaults)); err != nil {
+			return fmt.Errorf("%w: series %d: %v", ErrInvalidWidget, i, err)
+		}
+	}
+	return nil
+}
+
+func fillWidgetParams(text string, values map[string]string) string {
+	return widgetParamRef.ReplaceAllStringFunc(text, func(ref string) string {
+		return values[widgetParamRef.FindStringSubmatch(ref)[1]]
+	})
+}
+
+// Prepare fills in the widget's parameters from values, falling back to the
+// defaults, and compiles its series. Values outside a parameter's allowed
+// list are rejected; unknown names are ignored.
+func (s *WidgetService) Prepare(ctx context.Context, name string, values map[string]string) (PreparedWidget, error) {
+	widget, err := s.store.WidgetByName(ctx, name)
+	if err != nil {
+		return PreparedWidget{}, err
+	}
+	params := make(map[string]string, len(widget.Params))
+	for _, param := range widget.Params {
+		value, ok := values[param.Name]
+		if !ok || value == "" {
+			value = param.Default
+		}
+		if !slices.Contains(param.Allowed, value) {
+			return PreparedWidget{}, fmt.Errorf("%w: %s must be one of %s", ErrInvalidWidget, param.Name, strings.Join(param.Allowed, ", "))
+		}
+		params[param.Name] = value
+	}
+	prepared := PreparedWidget{Widget: widget, Params: params}
+	for _, series := range widget.Series {
+		q, err := s.metrics.CompileQuery(ctx, fillWidgetParams(series.Expr, params))
+		if err != nil {
+			// The metrics it referenced may have been removed since.
+			return PreparedWidget{}, fmt.Errorf("%w: %v", ErrInvalidWidget, err)
+		}
+		prepared.Queries = append(prepared.Queries, q)
+	}
+	return prepared, nil
+}
+
+// Data runs a prepared widget, over [from, to] when from is set and over
+// the widget's window of recent snapshots otherwise.
+func (s *WidgetService) Data(ctx context.Context, prepared PreparedWidget, from, to time.Time, maxPoints int) (models.WidgetData, error) {
+	widget := prepared.Widget
+	data := models.WidgetData{
+		Widget: widget.Name,
+		Title:  fillWidgetParams(widget.Title, prepared.Params),
+		Kind:   widget.Kind,
+		Params: prepared.Params,
+		Series: []models.WidgetSeriesData{},
+	}
+	window := widget.Window
+	if window == 0 {
+		window = defaultWidgetWindow
+	}
+	for i, q := range prepared.Queries {
+		var points []models.MetricPoint
+		var err error
+		if from.IsZero() {
+			points, err = s.metrics.QueryTrend(ctx, q, max(window, 3))
+		} else {
+			points, err = s.metrics.RunQuery(ctx, q, from, to, maxPoints)
+		}
+		if err != nil {
+			return models.WidgetData{}, err
+		}
+		series := models.WidgetSeriesData{
+			Label:   fillWidgetParams(widget.Series[i].Label, prepared.Params),
+			Expr:    q.Expr,
+			Metrics: q.Metrics,
+		}
+		if widget.Kind == models.WidgetStatKind {
+			if len(points) > 0 {
+				series.Value = &points[len(points)-1].Value
+			}
+		} else {
+			series.Points = points
+		}
+		data.Series = append(data.Series, series)
+	}
+	return data, nil
+}
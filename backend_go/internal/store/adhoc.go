@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+// QueryableTables are the tables and columns ad-hoc queries may read.
+// Columns identifying people, such as funnel subjects, are left out.
+var QueryableTables = map[string][]string{
+	"metrics_snapshot":  {"id", "revenue", "growth", "sentiment", "backlog", "created_at"},
+	"metric_dimensions": {"id", "metric", "dimension", "member", "value", "recorded_at"},
+	"metric_targets":    {"metric", "quarter", "value", "direction"},
+	"survey_responses":  {"id", "score", "segment", "responded_at"},
+	"funnel_events":     {"id", "funnel", "stage", "occurred_at"},
+	"insights":          {"id", "title", "source", "locale", "created_at"},
+	"alerts":            {"id", "rule_id", "severity", "step", "acknowledged_at", "created_at"},
+}
+
+var (
+	adHocOps = map[string]string{
+		"=": "=", "!=": "<>", "<": "<", "<=": "<=", ">": ">", ">=": ">=", "in": "IN",
+	}
+	adHocFuncs = []string{"count", "sum", "avg", "min", "max"}
+)
+
+// AdHocQuery runs a query checked by service.AdHocQueryService in a
+// read-only transaction. Identifiers are checked against QueryableTables
+// again here, since they are written into the SQL. One row beyond the limit
+// is read to tell whether the result was truncated.
+func (s *Store) AdHocQuery(ctx context.Context, q models.AdHocQuery, timeout time.Duration) (models.AdHocResult, error) {
+	if s.mem != nil {
+		return models.AdHocResult{}, ErrUnsupported
+	}
+	query, args, err := buildAdHocQuery(q, timeout)
+	if err != nil {
+		return models.AdHocResult{}, err
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return models.AdHocResult{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	started := time.Now()
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return models.AdHocResult{}, s.done("ad-hoc query", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.AdHocResult{}, s.done("ad-hoc query", err)
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return models.AdHocResult{}, s.done("ad-hoc query", err)
+	}
+
+	result := models.AdHocResult{Rows: [][]any{}}
+	for _, column := range types {
+		result.Columns = append(result.Columns, column.Name())
+	}
+	values := make([]any, len(types))
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(result.Rows) == q.Limit {
+			result.Truncated = true
+			break
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return models.AdHocResult{}, s.done("ad-hoc query", err)
+		}
+		row := make([]any, len(values))
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+				if types[i].DatabaseTypeName() == "DECIMAL" {
+					if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+						value = f
+					}
+				}
+			}
+			row[i] = value
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return models.AdHocResult{}, s.done("ad-hoc query", err)
+	}
+	result.ElapsedMs = float64(time.Since(started).Microseconds()) / 1000
+	s.breaker.Record(nil)
+	return result, nil
+}
+
+func buildAdHocQuery(q models.AdHocQuery, timeout time.Duration) (string, []any, error) {
+	columns, ok := QueryableTables[q.Table]
+	if !ok {
+		return "", nil, fmt.Errorf("table %q is not queryable", q.Table)
+	}
+	column := func(name string) (string, error) {
+		if !slices.Contains(columns, name) {
+			return "", fmt.Errorf("column %q is not queryable", name)
+		}
+		return "`" + name + "`", nil
+	}
+	var b strings.Builder
+	var args []any
+	fmt.Fprintf(&b, "SELECT /*+ MAX_EXECUTION_TIME(%d) */ ", timeout.Milliseconds())
+	var fields, aliases []string
+	for _, name := range q.Select {
+		quoted, err := column(name)
+		if err != nil {
+			return "", nil, err
+		}
+		fields = append(fields, quoted)
+	}
+	for _, agg := range q.Aggregates {
+		if !slices.Contains(adHocFuncs, agg.Func) || !columnName.MatchString(agg.As) {
+			return "", nil, fmt.Errorf("invalid aggregate %s(%s) as %q", agg.Func, agg.Column, agg.As)
+		}
+		target := "*"
+		if agg.Column != "*" {
+			quoted, err := column(agg.Column)
+			if err != nil {
+				return "", nil, err
+			}
+			target = quoted
+		}
+		fields = append(fields, strings.ToUpper(agg.Func)+"("+target+") AS `"+agg.As+"`")
+		aliases = append(aliases, agg.As)
+	}
+	b.WriteString(strings.Join(fields, ", "))
+	b.WriteString(" FROM `" + q.Table + "`")
+	for i, filter := range q.Where {
+		quoted, err := column(filter.Column)
+		if err != nil {
+			return "", nil, err
+		}
+		op, ok := adHocOps[filter.Op]
+		if !ok {
+			return "", nil, fmt.Errorf("operator %q is not supported", filter.Op)
+		}
+		if i == 0 {
+			b.WriteString(" WHERE ")
+		} else {
+			b.WriteString(" AND ")
+		}
+		if list, ok := filter.Value.([]any); ok && op == "IN" {
+			if len(list) == 0 {
+				b.WriteString("FALSE")
+				continue
+			}
+			b.WriteString(quoted + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(list)), ", ") + ")")
+			args = append(args, list...)
+			continue
+		}
+		b.WriteString(quoted + " " + op + " ?")
+		args = append(args, filter.Value)
+	}
+	if len(q.GroupBy) > 0 {
+		group := make([]string, 0, len(q.GroupBy))
+		for _, name := range q.GroupBy {
+			quoted, err := column(name)
+			if err != nil {
+				return "", nil, err
+			}
+			group = append(group, quoted)
+		}
+		b.WriteString(" GROUP BY " + strings.Join(group, ", "))
+	}
+	for i, order := range q.OrderBy {
+		quoted := "`" + order.Column + "`"
+		if !slices.Contains(aliases, order.Column) {
+			var err error
+			if quoted, err = column(order.Column); err != nil {
+				return "", nil, err
+			}
+		}
+		if i == 0 {
+			b.WriteString(" ORDER BY ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(quoted)
+		if order.Desc {
+			b.WriteString(" DESC")
+		}
+	}
+	fmt.Fprintf(&b, " LIMIT %d", q.Limit+1)
+	return b.String(), args, nil
+}
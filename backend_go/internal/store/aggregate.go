@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+var ErrUnknownColumn = errors.New("store: unknown metric column")
+
+var metricColumns = map[string]string{
+	"revenue":   "revenue",
+	"growth":    "growth",
+	"sentiment": "sentiment",
+	"backlog":   "backlog",
+}
+
+// QuarterHourTotals sums a metric per quarter hour of stored time. Callers
+// regroup the buckets in their own timezone, which SQL-side DAYOFWEEK and
+// HOUR cannot do across DST changes or half-hour offsets.
+func (s *Store) QuarterHourTotals(ctx context.Context, key string, from, to time.Time) ([]models.MetricBucket, error) {
+	if s.mem != nil {
+		return s.mem.quarterHourTotals(key, from, to), nil
+	}
+	column, ok := metricColumns[key]
+	if !ok {
+		return nil, ErrUnknownColumn
+	}
+	query := `
+		SELECT CAST(DATE_FORMAT(created_at, '%Y-%m-%d %H:00:00') AS DATETIME) + INTERVAL FLOOR(MINUTE(created_at) / 15) * 15 MINUTE AS bucket,
+			SUM(` + column + `), COUNT(*)
+		FROM metrics_snapshot
+		WHERE created_at >= ? AND created_at <= ?
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, s.done("quarter hour totals", err)
+	}
+	defer rows.Close()
+
+	var buckets []models.MetricBucket
+	for rows.Next() {
+		var bucket models.MetricBucket
+		if err := rows.Scan(&bucket.Start, &bucket.Sum, &bucket.Count); err != nil {
+			return nil, s.done("quarter hour totals", err)
+		}
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("quarter hour totals", err)
+	}
+	s.breaker.Record(nil)
+	return buckets, nil
+}
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const alertColumns = "id, rule_id, insight_id, title, message, severity, locale, step, next_step_at, ack_token, acknowledged_at, acknowledged_by, created_at"
+
+func scanAlert(row rowScanner) (models.Alert, error) {
+	var alert models.Alert
+	var nextStepAt, acknowledgedAt sql.NullTime
+	err := row.Scan(
+		&alert.ID,
+		&alert.RuleID,
+		&alert.InsightID,
+		&alert.Title,
+		&alert.Message,
+		&alert.Severity,
+		&alert.Locale,
+		&alert.Step,
+		&nextStepAt,
+		&alert.AckToken,
+		&acknowledgedAt,
+		&alert.AcknowledgedBy,
+		&alert.CreatedAt,
+	)
+	if nextStepAt.Valid {
+		alert.NextStepAt = &nextStepAt.Time
+	}
+	if acknowledgedAt.Valid {
+		alert.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	return alert, err
+}
+
+func (s *Store) queryAlerts(ctx context.Context, op, where string, args ...any) ([]models.Alert, error) {
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts
+		WHERE ` + where
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, s.done(op, err)
+	}
+	defer rows.Close()
+
+	var alerts []models.Alert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, s.done(op, err)
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done(op, err)
+	}
+	s.breaker.Record(nil)
+	return alerts, nil
+}
+
+func (s *Store) alertWhere(ctx context.Context, op, where string, args ...any) (models.Alert, error) {
+	alerts, err := s.queryAlerts(ctx, op, where+" LIMIT 1", args...)
+	if err != nil {
+		return models.Alert{}, err
+	}
+	if len(alerts) == 0 {
+		return models.Alert{}, ErrNotFound
+	}
+	return alerts[0], nil
+}
+
+// ListAlerts returns the newest alerts first, only unacknowledged ones when
+// openOnly is set.
+func (s *Store) ListAlerts(ctx context.Context, openOnly bool, limit int) ([]models.Alert, error) {
+	if s.mem != nil {
+		return s.mem.listAlerts(openOnly, limit), nil
+	}
+	where := "1 = 1"
+	if openOnly {
+		where = "acknowledged_at IS NULL"
+	}
+	return s.queryAlerts(ctx, "list alerts", where+" ORDER BY id DESC LIMIT ?", limit)
+}
+
+func (s *Store) AlertByID(ctx context.Context, id int64) (models.Alert, error) {
+	if s.mem != nil {
+		return s.mem.alertWhere(func(alert models.Alert) bool { return alert.ID == id })
+	}
+	return s.alertWhere(ctx, "alert by id", "id = ?", id)
+}
+
+func (s *Store) AlertByAckToken(ctx context.Context, token string) (models.Alert, error) {
+	if s.mem != nil {
+		return s.mem.alertWhere(func(alert models.Alert) bool { return alert.AckToken == token })
+	}
+	return s.alertWhere(ctx, "alert by ack token", "ack_token = ?", token)
+}
+
+// OpenAlertForRule returns the unacknowledged alert of a rule, if any.
+func (s *Store) OpenAlertForRule(ctx context.Context, ruleID int64) (models.Alert, error) {
+	if s.mem != nil {
+		return s.mem.alertWhere(func(alert models.Alert) bool { return alert.RuleID == ruleID && alert.AcknowledgedAt == nil })
+	}
+	return s.alertWhere(ctx, "open alert for rule", "rule_id = ? AND acknowledged_at IS NULL ORDER BY id DESC", ruleID)
+}
+
+// DueAlerts returns unacknowledged alerts whose next step is due at now.
+func (s *Store) DueAlerts(ctx context.Context, now time.Time, limit int) ([]models.Alert, error) {
+	if s.mem != nil {
+		return s.mem.dueAlerts(now, limit), nil
+	}
+	return s.queryAlerts(ctx, "due alerts", "acknowledged_at IS NULL AND next_step_at <= ? ORDER BY next_step_at LIMIT ?", now, limit)
+}
+
+func (s *Store) InsertAlert(ctx context.Context, alert models.Alert) (models.Alert, error) {
+	if s.mem != nil {
+		return s.mem.insertAlert(alert), nil
+	}
+	const query = `
+		INSERT INTO alerts (rule_id, insight_id, title, message, severity, locale, step, next_step_at, ack_token)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Alert{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query,
+		alert.RuleID,
+		alert.InsightID,
+		alert.Title,
+		alert.Message,
+		alert.Severity,
+		alert.Locale,
+		alert.Step,
+		alert.NextStepAt,
+		alert.AckToken,
+	)
+	if err := s.done("insert alert", err); err != nil {
+		return models.Alert{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Alert{}, err
+	}
+	return s.AlertByID(ctx, id)
+}
+
+// AdvanceAlert moves an alert from step from to step to and schedules its
+// next step, or none when next is nil. It returns ErrConflict when the alert
+// has moved on or been acknowledged in the meantime.
+func (s *Store) AdvanceAlert(ctx context.Context, id int64, from, to int, next *time.Time) error {
+	if s.mem != nil {
+		return s.mem.advanceAlert(id, from, to, next)
+	}
+	const query = `
+		UPDATE alerts
+		SET step = ?, next_step_at = ?
+		WHERE id = ? AND step = ? AND acknowledged_at IS NULL
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, to, next, id, from)
+	if err := s.done("advance alert", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// AcknowledgeAlert stops the escalation of an alert. Acknowledging it again
+// keeps the first acknowledgment.
+func (s *Store) AcknowledgeAlert(ctx context.Context, id int64, by string, at time.Time) (models.Alert, error) {
+	if s.mem != nil {
+		return s.mem.acknowledgeAlert(id, by, at)
+	}
+	const query = `
+		UPDATE alerts
+		SET acknowledged_at = ?, acknowledged_by = ?, next_step_at = NULL
+		WHERE id = ? AND acknowledged_at IS NULL
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Alert{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, query, at, by, id); err != nil {
+		return models.Alert{}, s.done("acknowledge alert", err)
+	}
+	s.breaker.Record(nil)
+	return s.AlertByID(ctx, id)
+}
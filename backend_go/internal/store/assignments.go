@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const assignmentColumns = "a.insight_id, a.user_id, u.username, a.status, a.due_at, a.assigned_by, a.team_id, a.created_at, a.updated_at, a.done_at"
+
+// prefixedScanner scans the leading columns of a row into extra and the rest
+// into the destinations passed to Scan, so a joined row can reuse
+// scanInsight.
+type prefixedScanner struct {
+	row   rowScanner
+	extra []any
+}
+
+func (p prefixedScanner) Scan(dest ...any) error {
+	return p.row.Scan(append(p.extra, dest...)...)
+}
+
+func assignmentDest(a *models.InsightAssignment, due, done *sql.NullTime) []any {
+	return []any{&a.InsightID, &a.UserID, &a.Username, &a.Status, due, &a.AssignedBy, &a.TeamID, &a.CreatedAt, &a.UpdatedAt, done}
+}
+
+func fillAssignmentTimes(a *models.InsightAssignment, due, done sql.NullTime) {
+	if due.Valid {
+		a.DueAt = &due.Time
+	}
+	if done.Valid {
+		a.DoneAt = &done.Time
+	}
+}
+
+// SaveAssignment assigns an insight, replacing any earlier assignment.
+func (s *Store) SaveAssignment(ctx context.Context, a models.InsightAssignment) error {
+	if s.mem != nil {
+		s.mem.saveAssignment(a)
+		return nil
+	}
+	const query = `
+		INSERT INTO insight_assignments (insight_id, user_id, status, due_at, assigned_by, team_id, done_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			user_id = VALUES(user_id),
+			status = VALUES(status),
+			due_at = VALUES(due_at),
+			assigned_by = VALUES(assigned_by),
+			team_id = VALUES(team_id),
+			done_at = VALUES(done_at),
+			created_at = CURRENT_TIMESTAMP
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, a.InsightID, a.UserID, a.Status, a.DueAt, a.AssignedBy, a.TeamID, a.DoneAt)
+	return s.done("save assignment", err)
+}
+
+func (s *Store) AssignmentByInsight(ctx context.Context, insightID int64) (models.InsightAssignment, error) {
+	if s.mem != nil {
+		return s.mem.assignmentByInsight(insightID)
+	}
+	const query = `
+		SELECT ` + assignmentColumns + `
+		FROM insight_assignments a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.insight_id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.InsightAssignment{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var a models.InsightAssignment
+	var due, done sql.NullTime
+	err := s.db.QueryRowContext(ctx, query, insightID).Scan(assignmentDest(&a, &due, &done)...)
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.InsightAssignment{}, ErrNotFound
+	}
+	if err != nil {
+		return models.InsightAssignment{}, s.done("assignment by insight", err)
+	}
+	fillAssignmentTimes(&a, due, done)
+	s.breaker.Record(nil)
+	return a, nil
+}
+
+// SetAssignmentStatus moves an assignment to status, recording doneAt, which
+// is nil unless the status is done.
+func (s *Store) SetAssignmentStatus(ctx context.Context, insightID int64, status string, doneAt *time.Time) error {
+	if s.mem != nil {
+		return s.mem.setAssignmentStatus(insightID, status, doneAt)
+	}
+	const query = `
+		UPDATE insight_assignments
+		SET status = ?, done_at = ?
+		WHERE insight_id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, status, doneAt, insightID)
+	if err := s.done("set assignment status", err); err != nil {
+		return err
+	}
+	// An unchanged status affects no rows, so check the row exists instead.
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+	var one int
+	err = s.db.QueryRowContext(ctx, "SELECT 1 FROM insight_assignments WHERE insight_id = ?", insightID).Scan(&one)
+	if isNoRows(err) {
+		return ErrNotFound
+	}
+	return s.done("set assignment status", err)
+}
+
+func (s *Store) DeleteAssignment(ctx context.Context, insightID int64) error {
+	if s.mem != nil {
+		return s.mem.deleteAssignment(insightID)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM insight_assignments WHERE insight_id = ?", insightID)
+	if err := s.done("delete assignment", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		if err == nil {
+			err = ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// UserAssignments lists the assignments of a user on insights outside the
+// trash, optionally only those in statuses, with the insight attached. The
+// earliest due come first and those without a due date last.
+func (s *Store) UserAssignments(ctx context.Context, userID int64, statuses []string, limit int) ([]models.InsightAssignment, error) {
+	if s.mem != nil {
+		return s.mem.listAssignments(func(a models.InsightAssignment) bool { return a.UserID == userID }, statuses, limit), nil
+	}
+	return s.listAssignments(ctx, "user assignments", "a.user_id = ?", []any{userID}, statuses, limit)
+}
+
+// TeamAssignments is UserAssignments for the assignments scoped to any of
+// teamIDs.
+func (s *Store) TeamAssignments(ctx context.Context, teamIDs []int64, statuses []string, limit int) ([]models.InsightAssignment, error) {
+	if s.mem != nil {
+		return s.mem.listAssignments(func(a models.InsightAssignment) bool {
+			return a.TeamID != nil && slices.Contains(teamIDs, *a.TeamID)
+		}, statuses, limit), nil
+	}
+	if len(teamIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(teamIDs))
+	for i, id := range teamIDs {
+		args[i] = id
+	}
+	where := "a.team_id IN (?" + strings.Repeat(", ?", len(teamIDs)-1) + ")"
+	return s.listAssignments(ctx, "team assignments", where, args, statuses, limit)
+}
+
+func (s *Store) listAssignments(ctx context.Context, op, where string, args []any, statuses []string, limit int) ([]models.InsightAssignment, error) {
+	query := `
+		SELECT ` + assignmentColumns + `, i.` + strings.ReplaceAll(insightColumns, ", ", ", i.") + `
+		FROM insight_assignments a
+		JOIN users u ON u.id = a.user_id
+		JOIN insights i ON i.id = a.insight_id
+		WHERE ` + where + ` AND i.deleted_at IS NULL`
+	if len(statuses) > 0 {
+		query += ` AND a.status IN (?` + strings.Repeat(", ?", len(statuses)-1) + `)`
+		for _, status := range statuses {
+			args = append(args, status)
+		}
+	}
+	query += `
+		ORDER BY a.due_at IS NULL, a.due_at, a.created_at
+		LIMIT ?`
+	args = append(args, limit)
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, s.done(op, err)
+	}
+	defer rows.Close()
+
+	var out []models.InsightAssignment
+	var insights []models.Insight
+	for rows.Next() {
+		var a models.InsightAssignment
+		var due, done sql.NullTime
+		insight, err := scanInsight(prefixedScanner{row: rows, extra: assignmentDest(&a, &due, &done)})
+		if err != nil {
+			return nil, s.done(op, err)
+		}
+		fillAssignmentTimes(&a, due, done)
+		out = append(out, a)
+		insights = append(insights, insight)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done(op, err)
+	}
+	if err := s.loadInsightTags(ctx, insights); err != nil {
+		return nil, s.done(op, err)
+	}
+	for i := range out {
+		out[i].Insight = &insights[i]
+	}
+	s.breaker.Record(nil)
+	return out, nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+
+	"mydashboard-backend/internal/models"
+)
+
+func (s *Store) InsertAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	if s.mem != nil {
+		s.mem.insertAuditEvent(event)
+		return nil
+	}
+	const query = `
+		INSERT INTO audit_log (action, actor, user_id, ip, detail, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, event.Action, event.Actor, event.UserID, event.IP, event.Detail, event.CreatedAt)
+	return s.done("insert audit event", err)
+}
+
+// ListAuditEvents returns the events matching q, newest first.
+func (s *Store) ListAuditEvents(ctx context.Context, q models.AuditQuery) ([]models.AuditEvent, error) {
+	if s.mem != nil {
+		return s.mem.listAuditEvents(q), nil
+	}
+	query := `
+		SELECT id, action, actor, user_id, ip, detail, created_at
+		FROM audit_log
+		WHERE 1 = 1`
+	var args []any
+	if q.Action != "" {
+		query += " AND action = ?"
+		args = append(args, q.Action)
+	}
+	if q.UserID != 0 {
+		query += " AND user_id = ?"
+		args = append(args, q.UserID)
+	}
+	if !q.Since.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, q.Since)
+	}
+	query += `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`
+	args = append(args, q.Limit)
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, s.done("list audit events", err)
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var e models.AuditEvent
+		if err := rows.Scan(&e.ID, &e.Action, &e.Actor, &e.UserID, &e.IP, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, s.done("list audit events", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list audit events", err)
+	}
+	s.breaker.Record(nil)
+	return events, nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+var ErrNotFound = errors.New("store: not found")
+
+func (s *Store) InsertBacklogItem(ctx context.Context, item models.BacklogItem) (models.BacklogItem, error) {
+	if s.mem != nil {
+		return s.mem.insertBacklogItem(item), nil
+	}
+	const query = `
+		INSERT INTO backlog_items (title, priority, opened_at, due_at)
+		VALUES (?, ?, ?, ?)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.BacklogItem{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, item.Title, item.Priority, item.OpenedAt, item.DueAt)
+	if err := s.done("insert backlog item", err); err != nil {
+		return models.BacklogItem{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.BacklogItem{}, err
+	}
+	item.ID = id
+	return item, nil
+}
+
+func (s *Store) ResolveBacklogItem(ctx context.Context, id int64, at time.Time) error {
+	if s.mem != nil {
+		return s.mem.resolveBacklogItem(id, at)
+	}
+	const query = `
+		UPDATE backlog_items
+		SET resolved_at = ?
+		WHERE id = ? AND resolved_at IS NULL
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, at, id)
+	if err := s.done("resolve backlog item", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) OpenBacklogItems(ctx context.Context) ([]models.BacklogItem, error) {
+	if s.mem != nil {
+		return s.mem.openBacklogItems(), nil
+	}
+	const query = `
+		SELECT id, title, priority, opened_at, due_at
+		FROM backlog_items
+		WHERE resolved_at IS NULL
+		ORDER BY opened_at
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("open backlog items", err)
+	}
+	defer rows.Close()
+
+	var items []models.BacklogItem
+	for rows.Next() {
+		var item models.BacklogItem
+		if err := rows.Scan(&item.ID, &item.Title, &item.Priority, &item.OpenedAt, &item.DueAt); err != nil {
+			return nil, s.done("open backlog items", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("open backlog items", err)
+	}
+	s.breaker.Record(nil)
+	return items, nil
+}
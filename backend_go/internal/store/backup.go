@@ -0,0 +1,232 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BackupTables are the tables a backup covers, parents before children.
+// Sessions, tokens, users and operational queues are deliberately left out.
+// Team memberships and insight assignments are kept because emptying teams
+// and insights on restore would otherwise cascade them away; they name
+// users, see userReferences.
+var BackupTables = []string{
+	"metrics_snapshot",
+	"insights",
+	"insight_metrics",
+	"insight_tags",
+	"orgs",
+	"teams",
+	"team_members",
+	"insight_assignments",
+	"insight_rules",
+	"notification_channels",
+	"alert_silences",
+	"derived_metrics",
+	"funnels",
+	"funnel_events",
+	"survey_responses",
+	"metric_dimensions",
+	"metric_targets",
+	"widgets",
+	"metric_meta",
+	"tenant_settings",
+	"scheduled_jobs",
+}
+
+// userReferences are the user references of backup tables. Users are not
+// backed up, so a restored row whose user does not exist in the target
+// database is skipped rather than failing the restore.
+var userReferences = map[string]string{
+	"team_members":        "user_id",
+	"insight_assignments": "user_id",
+}
+
+var columnName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// TableHeader describes the rows that follow it in a dump. TimeColumns lists
+// the columns holding timestamps so they can be parsed back on restore.
+type TableHeader struct {
+	Table       string   `json:"table"`
+	Columns     []string `json:"columns"`
+	TimeColumns []string `json:"time_columns,omitempty"`
+}
+
+// DumpTable streams every row of a backup table, calling header once before
+// the first row. Text comes back as strings. Bulk reads are bounded by ctx
+// only, not the per-query timeout.
+func (s *Store) DumpTable(ctx context.Context, table string, header func(TableHeader) error, row func([]any) error) error {
+	if s.mem != nil {
+		return ErrUnsupported
+	}
+	if !slices.Contains(BackupTables, table) {
+		return fmt.Errorf("%s is not a backup table", table)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM "+table)
+	if err != nil {
+		return s.done("dump "+table, err)
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return s.done("dump "+table, err)
+	}
+	head := TableHeader{Table: table}
+	for _, column := range types {
+		head.Columns = append(head.Columns, column.Name())
+		switch column.DatabaseTypeName() {
+		case "TIMESTAMP", "DATETIME", "DATE":
+			head.TimeColumns = append(head.TimeColumns, column.Name())
+		}
+	}
+	if err := header(head); err != nil {
+		return err
+	}
+
+	values := make([]any, len(head.Columns))
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return s.done("dump "+table, err)
+		}
+		out := make([]any, len(values))
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			out[i] = value
+		}
+		if err := row(out); err != nil {
+			return err
+		}
+	}
+	return s.done("dump "+table, rows.Err())
+}
+
+// Restore replaces the contents of backup tables inside one transaction.
+type Restore struct {
+	store *Store
+	tx    *instrumentedTx
+	users map[string]bool
+}
+
+// BeginRestore empties tables, children first, in a new transaction that
+// Commit makes visible.
+func (s *Store) BeginRestore(ctx context.Context, tables []string) (*Restore, error) {
+	if s.mem != nil {
+		return nil, ErrUnsupported
+	}
+	for _, table := range tables {
+		if !slices.Contains(BackupTables, table) {
+			return nil, fmt.Errorf("%s is not a backup table", table)
+		}
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, s.done("begin restore", err)
+	}
+	for i := len(BackupTables) - 1; i >= 0; i-- {
+		if !slices.Contains(tables, BackupTables[i]) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+BackupTables[i]); err != nil {
+			_ = tx.Rollback()
+			return nil, s.done("restore "+BackupTables[i], err)
+		}
+	}
+	return &Restore{store: s, tx: tx}, nil
+}
+
+// Insert writes rows with their original ids and reports how many it wrote;
+// rows naming a user the database does not have are skipped. Values for
+// time columns must already be time.Time.
+func (r *Restore) Insert(ctx context.Context, table string, columns []string, rows [][]any) (int, error) {
+	if !slices.Contains(BackupTables, table) {
+		return 0, fmt.Errorf("%s is not a backup table", table)
+	}
+	for _, column := range columns {
+		if !columnName.MatchString(column) {
+			return 0, fmt.Errorf("invalid column name %q", column)
+		}
+	}
+	for _, row := range rows {
+		if len(row) != len(columns) {
+			return 0, fmt.Errorf("%s: row has %d values for %d columns", table, len(row), len(columns))
+		}
+	}
+	if column, ok := userReferences[table]; ok {
+		var err error
+		if rows, err = r.knownUsers(ctx, table, slices.Index(columns, column), rows); err != nil {
+			return 0, err
+		}
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	var query strings.Builder
+	query.WriteString("INSERT INTO " + table + " (`" + strings.Join(columns, "`, `") + "`) VALUES ")
+	args := make([]any, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString(placeholders)
+		args = append(args, row...)
+	}
+	if _, err := r.tx.ExecContext(ctx, query.String(), args...); err != nil {
+		return 0, r.store.done("restore "+table, err)
+	}
+	return len(rows), nil
+}
+
+// knownUsers keeps the rows whose user, in column i, exists. The user ids
+// are read once per restore.
+func (r *Restore) knownUsers(ctx context.Context, table string, i int, rows [][]any) ([][]any, error) {
+	if i < 0 {
+		return nil, fmt.Errorf("%s: missing column %s", table, userReferences[table])
+	}
+	if r.users == nil {
+		result, err := r.tx.QueryContext(ctx, "SELECT id FROM users")
+		if err != nil {
+			return nil, r.store.done("restore "+table, err)
+		}
+		defer result.Close()
+		r.users = map[string]bool{}
+		for result.Next() {
+			var id int64
+			if err := result.Scan(&id); err != nil {
+				return nil, r.store.done("restore "+table, err)
+			}
+			r.users[strconv.FormatInt(id, 10)] = true
+		}
+		if err := result.Err(); err != nil {
+			return nil, r.store.done("restore "+table, err)
+		}
+	}
+	return slices.DeleteFunc(rows, func(row []any) bool {
+		return !r.users[fmt.Sprint(row[i])]
+	}), nil
+}
+
+func (r *Restore) Commit() error {
+	return r.store.done("commit restore", r.tx.Commit())
+}
+
+func (r *Restore) Rollback() error {
+	return r.tx.Rollback()
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDumpTableHeaderAndRows(t *testing.T) {
+	st, mock := newMock(t)
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT \\* FROM insight_rules$").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("id").OfType("BIGINT", int64(0)),
+		sqlmock.NewColumn("name").OfType("VARCHAR", ""),
+		sqlmock.NewColumn("created_at").OfType("TIMESTAMP", time.Time{}),
+		sqlmock.NewColumn("day").OfType("DATE", time.Time{}),
+	).AddRow(int64(3), []byte("revenue drop"), created, created))
+
+	var header TableHeader
+	var rows [][]any
+	err := st.DumpTable(context.Background(), "insight_rules",
+		func(h TableHeader) error { header = h; return nil },
+		func(row []any) error { rows = append(rows, row); return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(header.Columns, []string{"id", "name", "created_at", "day"}) ||
+		!slices.Equal(header.TimeColumns, []string{"created_at", "day"}) {
+		t.Errorf("header %+v", header)
+	}
+	if len(rows) != 1 || rows[0][1] != "revenue drop" || rows[0][2] != created {
+		t.Errorf("rows %v, want text as strings and times as they are", rows)
+	}
+}
+
+func TestBackupRejectsUnknownTables(t *testing.T) {
+	st, _ := newMock(t)
+	ctx := context.Background()
+	if err := st.DumpTable(ctx, "users", nil, nil); err == nil {
+		t.Error("dumped users")
+	}
+	if _, err := st.BeginRestore(ctx, []string{"insights", "sessions"}); err == nil {
+		t.Error("began restoring sessions")
+	}
+}
+
+func TestRestoreInsertRejectsBadRows(t *testing.T) {
+	st, mock := newMock(t)
+	ctx := context.Background()
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM insight_rules$").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	restore, err := st.BeginRestore(ctx, []string{"insight_rules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restore.Insert(ctx, "users", []string{"id"}, [][]any{{1}}); err == nil {
+		t.Error("inserted into users")
+	}
+	if _, err := restore.Insert(ctx, "insight_rules", []string{"id", "name`) VALUES (1); --"}, [][]any{{1, "x"}}); err == nil {
+		t.Error("accepted an invalid column name")
+	}
+	if _, err := restore.Insert(ctx, "insight_rules", []string{"id", "name"}, [][]any{{1}}); err == nil {
+		t.Error("accepted a short row")
+	}
+	if err := restore.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
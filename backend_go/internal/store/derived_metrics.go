@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"mydashboard-backend/internal/models"
+)
+
+// derivedColumn stores a snapshot without derived values as NULL.
+func derivedColumn(values map[string]float64) (any, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(values)
+}
+
+func decodeDerived(raw []byte, metrics *models.Metrics) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, &metrics.Derived)
+}
+
+// UpdateMetricsDerived rewrites the stored derived values of snapshots,
+// matched by id.
+func (s *Store) UpdateMetricsDerived(ctx context.Context, rows []models.MetricSnapshot) error {
+	if s.mem != nil {
+		return s.mem.updateMetricsDerived(rows)
+	}
+	const query = `UPDATE metrics_snapshot SET derived = ? WHERE id = ?`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	for _, row := range rows {
+		derived, err := derivedColumn(row.Derived)
+		if err != nil {
+			return err
+		}
+		if _, err := s.db.ExecContext(ctx, query, derived, row.ID); err != nil {
+			return s.done("update metrics derived", err)
+		}
+	}
+	return s.done("update metrics derived", nil)
+}
+
+func (s *Store) ListDerivedMetrics(ctx context.Context) ([]models.DerivedMetric, error) {
+	if s.mem != nil {
+		return s.mem.listDerivedMetrics(), nil
+	}
+	const query = `
+		SELECT name, expression, mode, description, created_at
+		FROM derived_metrics
+		ORDER BY name
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list derived metrics", err)
+	}
+	defer rows.Close()
+
+	var items []models.DerivedMetric
+	for rows.Next() {
+		var item models.DerivedMetric
+		if err := rows.Scan(&item.Name, &item.Expression, &item.Mode, &item.Description, &item.CreatedAt); err != nil {
+			return nil, s.done("list derived metrics", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list derived metrics", err)
+	}
+	s.breaker.Record(nil)
+	return items, nil
+}
+
+// SaveDerivedMetric creates the definition or replaces the one with the same
+// name, keeping its creation time.
+func (s *Store) SaveDerivedMetric(ctx context.Context, item models.DerivedMetric) error {
+	if s.mem != nil {
+		s.mem.saveDerivedMetric(item)
+		return nil
+	}
+	const query = `
+		INSERT INTO derived_metrics (name, expression, mode, description)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE expression = VALUES(expression), mode = VALUES(mode), description = VALUES(description)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, item.Name, item.Expression, item.Mode, item.Description)
+	return s.done("save derived metric", err)
+}
+
+// DeleteDerivedMetric removes a definition. Values already stored with
+// snapshots by an ingest-time definition stay in place.
+func (s *Store) DeleteDerivedMetric(ctx context.Context, name string) error {
+	if s.mem != nil {
+		return s.mem.deleteDerivedMetric(name)
+	}
+	const query = `
+		DELETE FROM derived_metrics
+		WHERE name = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, name)
+	if err := s.done("delete derived metric", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+func (s *Store) InsertDimensionValues(ctx context.Context, values []models.DimensionValue) error {
+	if s.mem != nil {
+		s.mem.insertDimensionValues(values)
+		return nil
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO metric_dimensions (metric, dimension, member, value, recorded_at) VALUES ")
+	args := make([]any, 0, len(values)*5)
+	for i, value := range values {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?, ?, ?, ?)")
+		args = append(args, value.Metric, value.Dimension, value.Member, value.Value, value.RecordedAt)
+	}
+	_, err := s.db.ExecContext(ctx, query.String(), args...)
+	return s.done("insert dimension values", err)
+}
+
+// DimensionTotals sums metric per member of dimension over [from, to], in no
+// particular order.
+func (s *Store) DimensionTotals(ctx context.Context, metric, dimension string, from, to time.Time) ([]models.Contributor, error) {
+	if s.mem != nil {
+		return s.mem.dimensionTotals(metric, dimension, from, to), nil
+	}
+	const query = `
+		SELECT member, SUM(value)
+		FROM metric_dimensions
+		WHERE metric = ? AND dimension = ? AND recorded_at >= ? AND recorded_at <= ?
+		GROUP BY member
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, metric, dimension, from, to)
+	if err != nil {
+		return nil, s.done("dimension totals", err)
+	}
+	defer rows.Close()
+
+	var totals []models.Contributor
+	for rows.Next() {
+		var total models.Contributor
+		if err := rows.Scan(&total.Member, &total.Value); err != nil {
+			return nil, s.done("dimension totals", err)
+		}
+		totals = append(totals, total)
+	}
+	return totals, s.done("dimension totals", rows.Err())
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const embedTokenColumns = "id, name, token_hash, metrics, created_by, created_at, expires_at, revoked_at"
+
+func scanEmbedToken(row rowScanner) (models.EmbedToken, error) {
+	var token models.EmbedToken
+	var metrics string
+	var createdBy sql.NullInt64
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&token.ID,
+		&token.Name,
+		&token.TokenHash,
+		&metrics,
+		&createdBy,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&revokedAt,
+	)
+	token.Metrics = strings.Split(metrics, ",")
+	if createdBy.Valid {
+		token.CreatedBy = &createdBy.Int64
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, err
+}
+
+func (s *Store) InsertEmbedToken(ctx context.Context, token models.EmbedToken) (models.EmbedToken, error) {
+	if s.mem != nil {
+		return s.mem.insertEmbedToken(token), nil
+	}
+	const query = `
+		INSERT INTO embed_tokens (name, token_hash, metrics, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.EmbedToken{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query,
+		token.Name,
+		token.TokenHash,
+		strings.Join(token.Metrics, ","),
+		token.CreatedBy,
+		token.CreatedAt,
+		token.ExpiresAt,
+	)
+	if err := s.done("insert embed token", err); err != nil {
+		return models.EmbedToken{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.EmbedToken{}, err
+	}
+	token.ID = id
+	return token, nil
+}
+
+// ActiveEmbedToken finds a token by hash if it is neither revoked nor
+// expired.
+func (s *Store) ActiveEmbedToken(ctx context.Context, hash string, now time.Time) (models.EmbedToken, error) {
+	if s.mem != nil {
+		return s.mem.activeEmbedToken(hash, now)
+	}
+	const query = `
+		SELECT ` + embedTokenColumns + `
+		FROM embed_tokens
+		WHERE token_hash = ? AND revoked_at IS NULL AND expires_at > ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.EmbedToken{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	token, err := scanEmbedToken(s.db.QueryRowContext(ctx, query, hash, now))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.EmbedToken{}, ErrNotFound
+	}
+	return token, s.done("active embed token", err)
+}
+
+func (s *Store) ListEmbedTokens(ctx context.Context) ([]models.EmbedToken, error) {
+	if s.mem != nil {
+		return s.mem.listEmbedTokens(), nil
+	}
+	const query = `
+		SELECT ` + embedTokenColumns + `
+		FROM embed_tokens
+		ORDER BY created_at DESC
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list embed tokens", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.EmbedToken{}
+	for rows.Next() {
+		token, err := scanEmbedToken(rows)
+		if err != nil {
+			return nil, s.done("list embed tokens", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list embed tokens", err)
+	}
+	s.breaker.Record(nil)
+	return tokens, nil
+}
+
+func (s *Store) RevokeEmbedToken(ctx context.Context, id int64, at time.Time) error {
+	if s.mem != nil {
+		return s.mem.revokeEmbedToken(id, at)
+	}
+	const query = `
+		UPDATE embed_tokens
+		SET revoked_at = ?
+		WHERE id = ? AND revoked_at IS NULL
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, at, id)
+	if err := s.done("revoke embed token", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
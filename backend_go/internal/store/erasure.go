@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+
+	"mydashboard-backend/internal/models"
+)
+
+// EraseUserData removes a user's personal data in one transaction and
+// reports what it touched. Deleted outright are their sessions (IP addresses
+// and user agents), notification preferences (contact addresses), the
+// insights they authored with their links, tags and assignments, the
+// assignments given to them, the invitations they accepted (their email
+// address) and every audit entry about them or by them. Records that merely
+// name them keep their content but lose the name: embed tokens, silences
+// (whose comment goes too), alert acknowledgements, assignments and
+// invitations they made. The profile fields are cleared; the account itself
+// stays.
+func (s *Store) EraseUserData(ctx context.Context, user models.User) (models.ErasureReport, error) {
+	if s.mem != nil {
+		return s.mem.eraseUserData(user), nil
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return models.ErasureReport{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ErasureReport{}, s.done("erase user data", err)
+	}
+	defer tx.Rollback()
+
+	var report models.ErasureReport
+	statements := []struct {
+		count *int64
+		query string
+		args  []any
+	}{
+		{&report.SessionsDeleted, `DELETE FROM sessions WHERE user_id = ?`, []any{user.ID}},
+		{&report.EmbedTokensUnlinked, `UPDATE embed_tokens SET created_by = NULL WHERE created_by = ?`, []any{user.ID}},
+		{nil, `DELETE FROM notification_preferences WHERE user_id = ?`, []any{user.ID}},
+		{&report.InsightsDeleted, `DELETE FROM insights WHERE author = ?`, []any{user.Username}},
+		{&report.AssignmentsDeleted, `DELETE FROM insight_assignments WHERE user_id = ?`, []any{user.ID}},
+		{&report.AuditEntriesDeleted, `DELETE FROM audit_log WHERE user_id = ? OR actor = ?`, []any{user.ID, user.Username}},
+		{&report.InvitationsDeleted, `DELETE FROM invitations WHERE user_id = ?`, []any{user.ID}},
+		{&report.SilencesUnlinked, `UPDATE alert_silences SET created_by = '', comment = '' WHERE created_by = ?`, []any{user.Username}},
+		{&report.RecordsUnlinked, `UPDATE alerts SET acknowledged_by = '' WHERE acknowledged_by = ?`, []any{user.Username}},
+		{&report.RecordsUnlinked, `UPDATE insight_assignments SET assigned_by = '' WHERE assigned_by = ?`, []any{user.Username}},
+		{&report.RecordsUnlinked, `UPDATE invitations SET invited_by = '' WHERE invited_by = ?`, []any{user.Username}},
+		{nil, `UPDATE users SET display_name = '', locale = '', timezone = '', avatar_url = '' WHERE id = ?`, []any{user.ID}},
+	}
+	for _, statement := range statements {
+		result, err := tx.ExecContext(ctx, statement.query, statement.args...)
+		if err != nil {
+			return models.ErasureReport{}, s.done("erase user data", err)
+		}
+		if statement.count != nil {
+			affected, _ := result.RowsAffected()
+			*statement.count += affected
+		}
+	}
+	if err := s.done("erase user data", tx.Commit()); err != nil {
+		return models.ErasureReport{}, err
+	}
+	return report, nil
+}
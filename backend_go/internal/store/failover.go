@@ -0,0 +1,219 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Failover is a driver.Connector over several MySQL hosts. New connections
+// go to the active host; when it stops accepting connections or turns
+// read-only, the next writable host takes over and connections to the old
+// one are dropped as they return to the pool.
+type Failover struct {
+	hosts      []failoverHost
+	mu         sync.Mutex
+	active     int
+	generation atomic.Uint64
+}
+
+type failoverHost struct {
+	addr      string
+	connector driver.Connector
+}
+
+// NewFailover builds a connector for each DSN, in order of preference.
+func NewFailover(dsns []string) (*Failover, error) {
+	if len(dsns) == 0 {
+		return nil, errors.New("no database hosts configured")
+	}
+	f := &Failover{}
+	for _, dsn := range dsns {
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		connector, err := mysql.NewConnector(cfg)
+		if err != nil {
+			return nil, err
+		}
+		f.hosts = append(f.hosts, failoverHost{addr: cfg.Addr, connector: connector})
+	}
+	return f, nil
+}
+
+// WithFailover reports the active host of a multi-host pool in Stats.
+func (s *Store) WithFailover(failover *Failover) *Store {
+	s.failover = failover
+	return s
+}
+
+// ActiveHost returns the address new connections are made to.
+func (f *Failover) ActiveHost() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hosts[f.active].addr
+}
+
+func (f *Failover) Driver() driver.Driver {
+	return f.hosts[0].connector.Driver()
+}
+
+// Connect dials the active host, failing over once if it is unreachable.
+func (f *Failover) Connect(ctx context.Context) (driver.Conn, error) {
+	f.mu.Lock()
+	active := f.active
+	f.mu.Unlock()
+	conn, err := f.dial(ctx, active)
+	if err == nil || len(f.hosts) == 1 {
+		return conn, err
+	}
+	if !f.failover(ctx, active) {
+		return nil, err
+	}
+	f.mu.Lock()
+	active = f.active
+	f.mu.Unlock()
+	return f.dial(ctx, active)
+}
+
+func (f *Failover) dial(ctx context.Context, host int) (driver.Conn, error) {
+	generation := f.generation.Load()
+	conn, err := f.hosts[host].connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mc, ok := conn.(mysqlConn)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("unsupported connection type %T", conn)
+	}
+	return &failoverConn{mysqlConn: mc, failover: f, generation: generation}, nil
+}
+
+// Monitor checks the active host every interval and fails over when it is
+// unreachable or read-only, until ctx is done. A healthy host is kept even
+// when an earlier one in the list recovers, so a demoted primary does not
+// win writes back.
+func (f *Failover) Monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		f.mu.Lock()
+		active := f.active
+		f.mu.Unlock()
+		probeCtx, cancel := context.WithTimeout(ctx, interval)
+		err := f.probe(probeCtx, active)
+		cancel()
+		if err != nil {
+			log.Printf("database host %s unhealthy: %v", f.hosts[active].addr, err)
+			f.failover(ctx, active)
+		}
+	}
+}
+
+// failover switches away from host to the first other host that passes a
+// probe. It reports whether the active host changed.
+func (f *Failover) failover(ctx context.Context, from int) bool {
+	for i := 1; i < len(f.hosts); i++ {
+		next := (from + i) % len(f.hosts)
+		probeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := f.probe(probeCtx, next)
+		cancel()
+		if err != nil {
+			log.Printf("database host %s unavailable: %v", f.hosts[next].addr, err)
+			continue
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.active != from {
+			return true
+		}
+		f.active = next
+		f.generation.Add(1)
+		log.Printf("database failover: %s -> %s", f.hosts[from].addr, f.hosts[next].addr)
+		return true
+	}
+	return false
+}
+
+// probe connects to a host directly and checks that it accepts writes.
+func (f *Failover) probe(ctx context.Context, host int) error {
+	conn, err := f.hosts[host].connector.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return fmt.Errorf("unsupported connection type %T", conn)
+	}
+	rows, err := queryer.QueryContext(ctx, "SELECT @@global.read_only", nil)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	values := make([]driver.Value, 1)
+	if err := rows.Next(values); err != nil && err != io.EOF {
+		return err
+	}
+	switch value := values[0].(type) {
+	case int64:
+		if value != 0 {
+			return errors.New("host is read-only")
+		}
+	case []byte:
+		if string(value) != "0" {
+			return errors.New("host is read-only")
+		}
+	default:
+		return fmt.Errorf("unexpected read_only value %v", value)
+	}
+	return nil
+}
+
+// mysqlConn is the set of optional interfaces the MySQL driver's connections
+// implement; failoverConn must forward all of them for database/sql to keep
+// using the fast paths.
+type mysqlConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+	driver.NamedValueChecker
+}
+
+// failoverConn retires itself once the failover generation moves on.
+type failoverConn struct {
+	mysqlConn
+	failover   *Failover
+	generation uint64
+}
+
+func (c *failoverConn) IsValid() bool {
+	return c.generation == c.failover.generation.Load() && c.mysqlConn.IsValid()
+}
+
+func (c *failoverConn) ResetSession(ctx context.Context) error {
+	if c.generation != c.failover.generation.Load() {
+		return driver.ErrBadConn
+	}
+	return c.mysqlConn.ResetSession(ctx)
+}
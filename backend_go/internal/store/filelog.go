@@ -0,0 +1,316 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const segmentIndexFile = "index.json"
+
+// MetricSegment describes one append-only JSONL file of a file-backed store.
+// Snapshots land in the segment of the UTC day they were written on. Synced
+// counts the rows, from the start of the file, a central instance has
+// acknowledged; Offset counts acknowledged rows since deleted from the front,
+// so row i is always sent as position Offset+i.
+type MetricSegment struct {
+	Name   string    `json:"name"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Count  int       `json:"count"`
+	Size   int64     `json:"size"`
+	Offset int       `json:"offset"`
+	Synced int       `json:"synced"`
+}
+
+// metricLog persists metrics for STORE=file. The index records each
+// segment's time range and sync position; it is rewritten with the state
+// file and reconciled against the segments on open. A stale sync position
+// only makes the next push resend rows or rewind, which the central instance
+// handles.
+type metricLog struct {
+	dir      string
+	segments []MetricSegment
+}
+
+// NewFile returns a memory store whose metrics are appended to segment files
+// under dir/metrics and whose other tables are snapshotted to dir/state.json.
+// Everything is loaded back when the store is opened again.
+func NewFile(dir string) (*Store, error) {
+	log := &metricLog{dir: filepath.Join(dir, "metrics")}
+	if err := os.MkdirAll(log.dir, 0o755); err != nil {
+		return nil, err
+	}
+	s, err := NewMemory(filepath.Join(dir, "state.json"))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := log.open()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].CreatedAt.Before(rows[j].CreatedAt) })
+	data := s.mem.data
+	data.Metrics = make([]memoryMetric, 0, len(rows))
+	data.NextID["metrics_snapshot"] = 0
+	for _, metrics := range rows {
+		data.Metrics = append(data.Metrics, memoryMetric{ID: s.mem.nextID("metrics_snapshot"), Metrics: metrics})
+	}
+	s.mem.log = log
+	return s, nil
+}
+
+// open loads every segment and rebuilds the index from them, keeping the
+// sync positions recorded for them.
+func (l *metricLog) open() ([]models.Metrics, error) {
+	indexed := map[string]MetricSegment{}
+	raw, err := os.ReadFile(filepath.Join(l.dir, segmentIndexFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		var segments []MetricSegment
+		if err := json.Unmarshal(raw, &segments); err != nil {
+			return nil, fmt.Errorf("%s: %w", segmentIndexFile, err)
+		}
+		for _, segment := range segments {
+			indexed[segment.Name] = segment
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(l.dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var all []models.Metrics
+	for _, file := range files {
+		rows, size, err := readSegment(file)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		segment := MetricSegment{Name: strings.TrimSuffix(filepath.Base(file), ".jsonl"), Size: size}
+		segment.add(rows)
+		if previous, ok := indexed[segment.Name]; ok {
+			segment.Offset, segment.Synced = previous.Offset, min(previous.Synced, segment.Count)
+		}
+		l.segments = append(l.segments, segment)
+		all = append(all, rows...)
+	}
+	return all, nil
+}
+
+// readSegment reads a segment, ignoring a torn last line left by a crash.
+func readSegment(path string) ([]models.Metrics, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	var rows []models.Metrics
+	var size int64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var metrics models.Metrics
+		if err := json.Unmarshal(line, &metrics); err != nil {
+			break
+		}
+		rows = append(rows, metrics)
+		size += int64(len(line)) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return rows, size, nil
+}
+
+func (l *metricLog) path(name string) string {
+	return filepath.Join(l.dir, name+".jsonl")
+}
+
+func (s *MetricSegment) add(rows []models.Metrics) {
+	for _, metrics := range rows {
+		if s.Count == 0 || metrics.CreatedAt.Before(s.From) {
+			s.From = metrics.CreatedAt
+		}
+		if s.Count == 0 || metrics.CreatedAt.After(s.To) {
+			s.To = metrics.CreatedAt
+		}
+		s.Count++
+	}
+}
+
+// append writes rows to today's segment. A torn write from an earlier crash
+// is cut off first so the new rows start on a line of their own.
+func (l *metricLog) append(rows []models.Metrics) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, metrics := range rows {
+		if err := encoder.Encode(metrics); err != nil {
+			return err
+		}
+	}
+	name := time.Now().UTC().Format(time.DateOnly)
+	i := slices.IndexFunc(l.segments, func(segment MetricSegment) bool { return segment.Name == name })
+	if i < 0 {
+		l.segments = append(l.segments, MetricSegment{Name: name})
+		i = len(l.segments) - 1
+	}
+	segment := &l.segments[i]
+	file, err := os.OpenFile(l.path(name), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := file.Truncate(segment.Size); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(buf.Bytes(), segment.Size); err != nil {
+		return err
+	}
+	segment.Size += int64(buf.Len())
+	segment.add(rows)
+	return nil
+}
+
+// remove drops the rows matching match from the segments overlapping
+// [from, to], the range of the rows being deleted. The index is saved right
+// away since the sync positions of rewritten segments have moved.
+func (l *metricLog) remove(from, to time.Time, match func(time.Time) bool) error {
+	kept := make([]MetricSegment, 0, len(l.segments))
+	for i, segment := range l.segments {
+		if segment.To.Before(from) || segment.From.After(to) {
+			kept = append(kept, segment)
+			continue
+		}
+		rewritten, err := l.filter(segment, match)
+		if err != nil {
+			l.segments = append(kept, l.segments[i:]...)
+			return err
+		}
+		if rewritten.Count > 0 {
+			kept = append(kept, rewritten)
+		}
+	}
+	l.segments = kept
+	return l.saveIndex()
+}
+
+func (l *metricLog) filter(segment MetricSegment, match func(time.Time) bool) (MetricSegment, error) {
+	rows, _, err := readSegment(l.path(segment.Name))
+	if err != nil {
+		return segment, err
+	}
+	var removedSynced int
+	kept := rows[:0]
+	for i, metrics := range rows {
+		switch {
+		case !match(metrics.CreatedAt):
+			kept = append(kept, metrics)
+		case i < segment.Synced:
+			removedSynced++
+		}
+	}
+	rows = kept
+	switch {
+	case len(rows) == segment.Count:
+		return segment, nil
+	case len(rows) == 0:
+		if err := os.Remove(l.path(segment.Name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return segment, err
+		}
+		return MetricSegment{}, nil
+	}
+	rewritten := MetricSegment{
+		Name:   segment.Name,
+		Offset: segment.Offset + removedSynced,
+		Synced: segment.Synced - removedSynced,
+	}
+	if err := l.rewrite(&rewritten, rows); err != nil {
+		return segment, err
+	}
+	return rewritten, nil
+}
+
+func (l *metricLog) rewrite(segment *MetricSegment, rows []models.Metrics) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, metrics := range rows {
+		if err := encoder.Encode(metrics); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(l.path(segment.Name), buf.Bytes()); err != nil {
+		return err
+	}
+	segment.Size = int64(buf.Len())
+	segment.add(rows)
+	return nil
+}
+
+func (l *metricLog) saveIndex() error {
+	raw, err := json.MarshalIndent(l.segments, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(l.dir, segmentIndexFile), raw)
+}
+
+// MetricSegments lists the segments of a file-backed store, oldest first.
+// It returns nil for other stores.
+func (s *Store) MetricSegments() []MetricSegment {
+	if s.mem == nil || s.mem.log == nil {
+		return nil
+	}
+	defer s.mem.lock()()
+	return slices.Clone(s.mem.log.segments)
+}
+
+// ReadMetricSegment returns the snapshots in a segment in write order.
+func (s *Store) ReadMetricSegment(name string) ([]models.Metrics, error) {
+	if s.mem == nil || s.mem.log == nil {
+		return nil, ErrUnsupported
+	}
+	unlock := s.mem.lock()
+	found := slices.ContainsFunc(s.mem.log.segments, func(segment MetricSegment) bool { return segment.Name == name })
+	path := s.mem.log.path(name)
+	unlock()
+	if !found {
+		return nil, ErrNotFound
+	}
+	rows, _, err := readSegment(path)
+	return rows, err
+}
+
+// AckMetricSegment records that a central instance has received a segment up
+// to position acked, which may also move the sync position back.
+func (s *Store) AckMetricSegment(name string, acked int) error {
+	if s.mem == nil || s.mem.log == nil {
+		return ErrUnsupported
+	}
+	defer s.mem.lock()()
+	for i := range s.mem.log.segments {
+		if segment := &s.mem.log.segments[i]; segment.Name == name {
+			segment.Synced = min(max(acked-segment.Offset, 0), segment.Count)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
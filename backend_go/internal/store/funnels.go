@@ -0,0 +1,203 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+func scanFunnel(row rowScanner) (models.Funnel, error) {
+	var funnel models.Funnel
+	var stages []byte
+	if err := row.Scan(&funnel.Name, &stages, &funnel.CreatedAt); err != nil {
+		return funnel, err
+	}
+	return funnel, json.Unmarshal(stages, &funnel.Stages)
+}
+
+func (s *Store) ListFunnels(ctx context.Context) ([]models.Funnel, error) {
+	if s.mem != nil {
+		return s.mem.listFunnels(), nil
+	}
+	const query = `
+		SELECT name, stages, created_at
+		FROM funnels
+		ORDER BY name
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list funnels", err)
+	}
+	defer rows.Close()
+
+	var funnels []models.Funnel
+	for rows.Next() {
+		funnel, err := scanFunnel(rows)
+		if err != nil {
+			return nil, s.done("list funnels", err)
+		}
+		funnels = append(funnels, funnel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list funnels", err)
+	}
+	s.breaker.Record(nil)
+	return funnels, nil
+}
+
+func (s *Store) FunnelByName(ctx context.Context, name string) (models.Funnel, error) {
+	if s.mem != nil {
+		return s.mem.funnelByName(name)
+	}
+	const query = `
+		SELECT name, stages, created_at
+		FROM funnels
+		WHERE name = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Funnel{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	funnel, err := scanFunnel(s.db.QueryRowContext(ctx, query, name))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Funnel{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Funnel{}, s.done("funnel by name", err)
+	}
+	s.breaker.Record(nil)
+	return funnel, nil
+}
+
+// SaveFunnel creates the funnel or replaces its stages. Events of stages
+// that were removed stay stored but no longer show up in reports.
+func (s *Store) SaveFunnel(ctx context.Context, funnel models.Funnel) error {
+	if s.mem != nil {
+		s.mem.saveFunnel(funnel)
+		return nil
+	}
+	const query = `
+		INSERT INTO funnels (name, stages)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE stages = VALUES(stages)
+	`
+	stages, err := json.Marshal(funnel.Stages)
+	if err != nil {
+		return err
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, query, funnel.Name, stages)
+	return s.done("save funnel", err)
+}
+
+// DeleteFunnel removes a funnel together with its events.
+func (s *Store) DeleteFunnel(ctx context.Context, name string) error {
+	if s.mem != nil {
+		return s.mem.deleteFunnel(name)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return s.done("delete funnel", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM funnels WHERE name = ?`, name)
+	if err != nil {
+		return s.done("delete funnel", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		s.breaker.Record(nil)
+		return ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM funnel_events WHERE funnel = ?`, name); err != nil {
+		return s.done("delete funnel", err)
+	}
+	return s.done("delete funnel", tx.Commit())
+}
+
+func (s *Store) InsertFunnelEvents(ctx context.Context, funnel string, events []models.FunnelEvent) error {
+	if s.mem != nil {
+		s.mem.insertFunnelEvents(funnel, events)
+		return nil
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO funnel_events (funnel, stage, subject, occurred_at) VALUES ")
+	args := make([]any, 0, len(events)*4)
+	for i, event := range events {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?, ?, ?)")
+		args = append(args, funnel, event.Stage, event.Subject, event.OccurredAt)
+	}
+	_, err := s.db.ExecContext(ctx, query.String(), args...)
+	return s.done("insert funnel events", err)
+}
+
+// FunnelEvents returns up to limit events of funnel in [from, to], oldest
+// first.
+func (s *Store) FunnelEvents(ctx context.Context, funnel string, from, to time.Time, limit int) ([]models.FunnelEvent, error) {
+	if s.mem != nil {
+		return s.mem.funnelEvents(funnel, from, to, limit), nil
+	}
+	const query = `
+		SELECT stage, subject, occurred_at
+		FROM funnel_events
+		WHERE funnel = ? AND occurred_at >= ? AND occurred_at <= ?
+		ORDER BY occurred_at, id
+		LIMIT ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, funnel, from, to, limit)
+	if err != nil {
+		return nil, s.done("funnel events", err)
+	}
+	defer rows.Close()
+
+	var events []models.FunnelEvent
+	for rows.Next() {
+		var event models.FunnelEvent
+		if err := rows.Scan(&event.Stage, &event.Subject, &event.OccurredAt); err != nil {
+			return nil, s.done("funnel events", err)
+		}
+		events = append(events, event)
+	}
+	return events, s.done("funnel events", rows.Err())
+}
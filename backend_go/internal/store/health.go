@@ -0,0 +1,14 @@
+package store
+
+import "context"
+
+// Ping checks the database directly. It bypasses the breaker so health checks
+// keep observing the database while the breaker is open.
+func (s *Store) Ping(ctx context.Context) error {
+	if s.mem != nil {
+		return nil
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return wrapErr("ping", s.db.PingContext(ctx))
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+// ReserveIdempotencyKey claims key for a new request. When the key is already
+// taken it returns the existing record and reserved=false.
+func (s *Store) ReserveIdempotencyKey(ctx context.Context, key, requestHash string, ttl time.Duration) (models.IdempotencyRecord, bool, error) {
+	if s.mem != nil {
+		record, reserved := s.mem.reserveIdempotencyKey(key, requestHash, ttl)
+		return record, reserved, nil
+	}
+	const expire = `
+		DELETE FROM idempotency_keys
+		WHERE id_key = ? AND created_at < ?
+	`
+	const insert = `
+		INSERT IGNORE INTO idempotency_keys (id_key, request_hash, created_at)
+		VALUES (?, ?, ?)
+	`
+	const lookup = `
+		SELECT id_key, request_hash, status_code, response_body, created_at
+		FROM idempotency_keys
+		WHERE id_key = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	if _, err := s.db.ExecContext(ctx, expire, key, now.Add(-ttl)); err != nil {
+		return models.IdempotencyRecord{}, false, s.done("reserve idempotency key", err)
+	}
+	result, err := s.db.ExecContext(ctx, insert, key, requestHash, now)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, s.done("reserve idempotency key", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 1 {
+		s.breaker.Record(nil)
+		return models.IdempotencyRecord{Key: key, RequestHash: requestHash, CreatedAt: now}, true, nil
+	}
+
+	var record models.IdempotencyRecord
+	err = s.db.QueryRowContext(ctx, lookup, key).Scan(
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.Body,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, s.done("reserve idempotency key", err)
+	}
+	s.breaker.Record(nil)
+	return record, false, nil
+}
+
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, key string, statusCode int, body []byte) error {
+	if s.mem != nil {
+		s.mem.completeIdempotencyKey(key, statusCode, body)
+		return nil
+	}
+	const query = `
+		UPDATE idempotency_keys
+		SET status_code = ?, response_body = ?
+		WHERE id_key = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, statusCode, body, key)
+	return s.done("complete idempotency key", err)
+}
+
+func (s *Store) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	if s.mem != nil {
+		s.mem.releaseIdempotencyKey(key)
+		return nil
+	}
+	const query = `
+		DELETE FROM idempotency_keys
+		WHERE id_key = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, key)
+	return s.done("release idempotency key", err)
+}
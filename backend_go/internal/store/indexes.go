@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+)
+
+// expectedIndexes are the indexes the hot queries rely on; without them
+// trend and feed reads fall back to filesorts.
+var expectedIndexes = []struct{ table, index string }{
+	{"metrics_snapshot", "idx_metrics_created_desc"},
+	{"insights", "idx_insights_feed"},
+	{"insights", "idx_insights_fingerprint"},
+	{"insights", "idx_insights_deleted_at"},
+	{"insights", "ft_insights_text"},
+	{"insight_metrics", "idx_insight_metrics_key"},
+	{"insight_tags", "idx_insight_tags_tag"},
+	{"notification_outbox", "idx_outbox_status_next"},
+	{"notification_deliveries", "idx_deliveries_status_next"},
+	{"jobs", "idx_jobs_status_created"},
+	{"sessions", "idx_sessions_user"},
+	{"api_usage", "idx_api_usage_tenant"},
+}
+
+// MissingIndexes returns the expected indexes, as table.index, that the
+// current schema lacks.
+func (s *Store) MissingIndexes(ctx context.Context) ([]string, error) {
+	if s.mem != nil {
+		return nil, nil
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT table_name, index_name
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE()
+	`)
+	if err != nil {
+		return nil, s.done("missing indexes", err)
+	}
+	defer rows.Close()
+
+	present := map[string]bool{}
+	for rows.Next() {
+		var table, index string
+		if err := rows.Scan(&table, &index); err != nil {
+			return nil, s.done("missing indexes", err)
+		}
+		present[table+"."+index] = true
+	}
+	if err := s.done("missing indexes", rows.Err()); err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, expected := range expectedIndexes {
+		if name := expected.table + "." + expected.index; !present[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+func insertInsightLinks(ctx context.Context, db execer, insightID int64, links []models.InsightMetricLink) error {
+	query := `
+		INSERT INTO insight_metrics (insight_id, metric_key, window_start, window_end)
+		VALUES (?, ?, ?, ?)
+	`
+	for _, link := range links {
+		if _, err := db.ExecContext(ctx, query, insightID, link.MetricKey, link.WindowStart, link.WindowEnd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadInsightLinks fills in the metric links of items with a single query.
+func (s *Store) loadInsightLinks(ctx context.Context, items []models.Insight) error {
+	if len(items) == 0 {
+		return nil
+	}
+	index := make(map[int64]int, len(items))
+	args := make([]any, len(items))
+	for i, item := range items {
+		index[item.ID] = i
+		args[i] = item.ID
+	}
+	query := `
+		SELECT insight_id, metric_key, window_start, window_end
+		FROM insight_metrics
+		WHERE insight_id IN (?` + strings.Repeat(", ?", len(items)-1) + `)
+		ORDER BY insight_id, metric_key
+	`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var link models.InsightMetricLink
+		var start, end sql.NullTime
+		if err := rows.Scan(&id, &link.MetricKey, &start, &end); err != nil {
+			return err
+		}
+		link.WindowStart, link.WindowEnd = start.Time, end.Time
+		if i, ok := index[id]; ok {
+			items[i].Metrics = append(items[i].Metrics, link)
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) RecentInsightByFingerprint(ctx context.Context, fingerprint string, since time.Time) (models.Insight, error) {
+	if s.mem != nil {
+		return s.mem.recentInsightByFingerprint(fingerprint, since)
+	}
+	query := `
+		SELECT ` + insightColumns + `
+		FROM insights
+		WHERE fingerprint = ? AND created_at >= ? AND deleted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Insight{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	insight, err := scanInsight(s.db.QueryRowContext(ctx, query, fingerprint, since))
+	if errors.Is(err, sql.ErrNoRows) {
+		s.breaker.Record(nil)
+		return models.Insight{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Insight{}, s.done("recent insight by fingerprint", err)
+	}
+	s.breaker.Record(nil)
+	return insight, nil
+}
+
+func (s *Store) BumpInsightRepeat(ctx context.Context, id int64, at time.Time) error {
+	if s.mem != nil {
+		s.mem.bumpInsightRepeat(id)
+		return nil
+	}
+	const query = `
+		UPDATE insights
+		SET repeat_count = repeat_count + 1, last_seen_at = ?
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, at, id)
+	return s.done("bump insight repeat", err)
+}
+
+func (s *Store) InsightByID(ctx context.Context, id int64) (models.Insight, error) {
+	if s.mem != nil {
+		return s.mem.insightByID(id)
+	}
+	const query = `
+		SELECT ` + insightColumns + `
+		FROM insights
+		WHERE id = ?
+	`
+	const links = `
+		SELECT metric_key, window_start, window_end
+		FROM insight_metrics
+		WHERE insight_id = ?
+		ORDER BY metric_key
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Insight{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	insight, err := scanInsight(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		s.breaker.Record(nil)
+		return models.Insight{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Insight{}, s.done("insight by id", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, links, id)
+	if err != nil {
+		return models.Insight{}, s.done("insight by id", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var link models.InsightMetricLink
+		var start, end sql.NullTime
+		if err := rows.Scan(&link.MetricKey, &start, &end); err != nil {
+			return models.Insight{}, s.done("insight by id", err)
+		}
+		link.WindowStart, link.WindowEnd = start.Time, end.Time
+		insight.Metrics = append(insight.Metrics, link)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Insight{}, s.done("insight by id", err)
+	}
+	items := []models.Insight{insight}
+	if err := s.loadInsightTags(ctx, items); err != nil {
+		return models.Insight{}, s.done("insight by id", err)
+	}
+	s.breaker.Record(nil)
+	return items[0], nil
+}
+
+// UpdateInsight saves edited text and severity if the stored version still
+// equals version, bumping it. A stale version reports ErrConflict; a missing
+// or deleted insight ErrNotFound.
+func (s *Store) UpdateInsight(ctx context.Context, insight models.Insight, version int) error {
+	if s.mem != nil {
+		return s.mem.updateInsight(insight, version)
+	}
+	const query = `
+		UPDATE insights
+		SET title = ?, message = ?, severity = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`
+	const exists = `
+		SELECT 1
+		FROM insights
+		WHERE id = ? AND deleted_at IS NULL
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, insight.Title, insight.Message, insight.Severity, insight.ID, version)
+	if err := s.done("update insight", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+	var one int
+	err = s.db.QueryRowContext(ctx, exists, insight.ID).Scan(&one)
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return ErrNotFound
+	}
+	if err := s.done("update insight", err); err != nil {
+		return err
+	}
+	return ErrConflict
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"mydashboard-backend/internal/models"
+)
+
+const insightRuleColumns = "id, name, rule_condition, title, template, severity, locale, enabled, escalation, team_id, created_at, updated_at"
+
+func scanInsightRule(row rowScanner) (models.InsightRule, error) {
+	var rule models.InsightRule
+	var escalation []byte
+	err := row.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Condition,
+		&rule.Title,
+		&rule.Template,
+		&rule.Severity,
+		&rule.Locale,
+		&rule.Enabled,
+		&escalation,
+		&rule.TeamID,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil || len(escalation) == 0 {
+		return rule, err
+	}
+	return rule, json.Unmarshal(escalation, &rule.Escalation)
+}
+
+// escalationColumn stores a rule without escalation steps as NULL.
+func escalationColumn(steps []models.EscalationStep) (any, error) {
+	if len(steps) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(steps)
+}
+
+func (s *Store) ListInsightRules(ctx context.Context, enabledOnly bool) ([]models.InsightRule, error) {
+	if s.mem != nil {
+		return s.mem.listInsightRules(enabledOnly), nil
+	}
+	query := `
+		SELECT ` + insightRuleColumns + `
+		FROM insight_rules
+	`
+	if enabledOnly {
+		query += " WHERE enabled = 1"
+	}
+	query += " ORDER BY id"
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list insight rules", err)
+	}
+	defer rows.Close()
+
+	var rules []models.InsightRule
+	for rows.Next() {
+		rule, err := scanInsightRule(rows)
+		if err != nil {
+			return nil, s.done("list insight rules", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list insight rules", err)
+	}
+	s.breaker.Record(nil)
+	return rules, nil
+}
+
+func (s *Store) InsertInsightRule(ctx context.Context, rule models.InsightRule) (models.InsightRule, error) {
+	if s.mem != nil {
+		return s.mem.saveInsightRule(rule, true)
+	}
+	const query = `
+		INSERT INTO insight_rules (name, rule_condition, title, template, severity, locale, enabled, escalation, team_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	escalation, err := escalationColumn(rule.Escalation)
+	if err != nil {
+		return models.InsightRule{}, err
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return models.InsightRule{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query,
+		rule.Name,
+		rule.Condition,
+		rule.Title,
+		rule.Template,
+		rule.Severity,
+		rule.Locale,
+		rule.Enabled,
+		escalation,
+		rule.TeamID,
+	)
+	if isDuplicate(err) {
+		s.breaker.Record(nil)
+		return models.InsightRule{}, ErrConflict
+	}
+	if err := s.done("insert insight rule", err); err != nil {
+		return models.InsightRule{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.InsightRule{}, err
+	}
+	return s.InsightRuleByID(ctx, id)
+}
+
+func (s *Store) UpdateInsightRule(ctx context.Context, rule models.InsightRule) (models.InsightRule, error) {
+	if s.mem != nil {
+		return s.mem.saveInsightRule(rule, false)
+	}
+	const query = `
+		UPDATE insight_rules
+		SET name = ?, rule_condition = ?, title = ?, template = ?, severity = ?, locale = ?, enabled = ?, escalation = ?, team_id = ?
+		WHERE id = ?
+	`
+	escalation, err := escalationColumn(rule.Escalation)
+	if err != nil {
+		return models.InsightRule{}, err
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return models.InsightRule{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, query,
+		rule.Name,
+		rule.Condition,
+		rule.Title,
+		rule.Template,
+		rule.Severity,
+		rule.Locale,
+		rule.Enabled,
+		escalation,
+		rule.TeamID,
+		rule.ID,
+	)
+	if isDuplicate(err) {
+		s.breaker.Record(nil)
+		return models.InsightRule{}, ErrConflict
+	}
+	if err := s.done("update insight rule", err); err != nil {
+		return models.InsightRule{}, err
+	}
+	return s.InsightRuleByID(ctx, rule.ID)
+}
+
+func (s *Store) InsightRuleByID(ctx context.Context, id int64) (models.InsightRule, error) {
+	if s.mem != nil {
+		return s.mem.insightRuleByID(id)
+	}
+	query := `
+		SELECT ` + insightRuleColumns + `
+		FROM insight_rules
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.InsightRule{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rule, err := scanInsightRule(s.db.QueryRowContext(ctx, query, id))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.InsightRule{}, ErrNotFound
+	}
+	if err != nil {
+		return models.InsightRule{}, s.done("insight rule by id", err)
+	}
+	s.breaker.Record(nil)
+	return rule, nil
+}
+
+func (s *Store) DeleteInsightRule(ctx context.Context, id int64) error {
+	if s.mem != nil {
+		return s.mem.deleteInsightRule(id)
+	}
+	const query = `
+		DELETE FROM insight_rules
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err := s.done("delete insight rule", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
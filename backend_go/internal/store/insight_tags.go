@@ -0,0 +1,265 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"mydashboard-backend/internal/models"
+)
+
+func insertInsightTags(ctx context.Context, db execer, insightID int64, tags []string) error {
+	const query = `
+		INSERT IGNORE INTO insight_tags (insight_id, tag)
+		VALUES (?, ?)
+	`
+	for _, tag := range tags {
+		if _, err := db.ExecContext(ctx, query, insightID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadInsightTags fills in the tags of items with a single query.
+func (s *Store) loadInsightTags(ctx context.Context, items []models.Insight) error {
+	if len(items) == 0 {
+		return nil
+	}
+	index := make(map[int64]int, len(items))
+	args := make([]any, len(items))
+	for i, item := range items {
+		index[item.ID] = i
+		args[i] = item.ID
+	}
+	query := `
+		SELECT insight_id, tag
+		FROM insight_tags
+		WHERE insight_id IN (?` + strings.Repeat(", ?", len(items)-1) + `)
+		ORDER BY insight_id, tag
+	`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+		if i, ok := index[id]; ok {
+			items[i].Tags = append(items[i].Tags, tag)
+		}
+	}
+	return rows.Err()
+}
+
+// TaggedInsights returns the newest insights outside the trash that carry
+// every one of tags.
+func (s *Store) TaggedInsights(ctx context.Context, locale string, tags []string, limit int) ([]models.Insight, error) {
+	if s.mem != nil {
+		return s.mem.taggedInsights(locale, tags, limit), nil
+	}
+	query := `
+		SELECT ` + insightColumns + `
+		FROM insights
+		WHERE locale = ? AND deleted_at IS NULL AND id IN (
+			SELECT insight_id
+			FROM insight_tags
+			WHERE tag IN (?` + strings.Repeat(", ?", len(tags)-1) + `)
+			GROUP BY insight_id
+			HAVING COUNT(*) = ?
+		)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+	args := []any{locale}
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	args = append(args, len(tags), limit)
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, s.done("tagged insights", err)
+	}
+	defer rows.Close()
+
+	var items []models.Insight
+	for rows.Next() {
+		insight, err := scanInsight(rows)
+		if err != nil {
+			return nil, s.done("tagged insights", err)
+		}
+		items = append(items, insight)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("tagged insights", err)
+	}
+	if err := s.loadInsightLinks(ctx, items); err != nil {
+		return nil, s.done("tagged insights", err)
+	}
+	if err := s.loadInsightTags(ctx, items); err != nil {
+		return nil, s.done("tagged insights", err)
+	}
+	s.breaker.Record(nil)
+	return items, nil
+}
+
+// InsightTags lists every tag in use by insights outside the trash, most
+// used first.
+func (s *Store) InsightTags(ctx context.Context) ([]models.InsightTag, error) {
+	if s.mem != nil {
+		return s.mem.insightTags(), nil
+	}
+	const query = `
+		SELECT t.tag, COUNT(*)
+		FROM insight_tags t
+		JOIN insights i ON i.id = t.insight_id
+		WHERE i.deleted_at IS NULL
+		GROUP BY t.tag
+		ORDER BY COUNT(*) DESC, t.tag
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("insight tags", err)
+	}
+	defer rows.Close()
+
+	var tags []models.InsightTag
+	for rows.Next() {
+		var tag models.InsightTag
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, s.done("insight tags", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("insight tags", err)
+	}
+	s.breaker.Record(nil)
+	return tags, nil
+}
+
+// SetInsightTags replaces the tags of an insight outside the trash. A missing
+// or deleted insight reports ErrNotFound.
+func (s *Store) SetInsightTags(ctx context.Context, id int64, tags []string) error {
+	if s.mem != nil {
+		return s.mem.setInsightTags(id, tags)
+	}
+	const exists = `
+		SELECT 1
+		FROM insights
+		WHERE id = ? AND deleted_at IS NULL
+		FOR UPDATE
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return s.done("set insight tags", err)
+	}
+	defer tx.Rollback()
+
+	var one int
+	err = tx.QueryRowContext(ctx, exists, id).Scan(&one)
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return ErrNotFound
+	}
+	if err != nil {
+		return s.done("set insight tags", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM insight_tags WHERE insight_id = ?", id); err != nil {
+		return s.done("set insight tags", err)
+	}
+	if err := insertInsightTags(ctx, tx, id, tags); err != nil {
+		return s.done("set insight tags", err)
+	}
+	return s.done("set insight tags", tx.Commit())
+}
+
+// RenameInsightTag moves every insight tagged from to tag to, merging the
+// two when both exist. It reports how many insights carried from, or
+// ErrNotFound when none did.
+func (s *Store) RenameInsightTag(ctx context.Context, from, to string) (int64, error) {
+	if s.mem != nil {
+		return s.mem.renameInsightTag(from, to)
+	}
+	const copyTags = `
+		INSERT IGNORE INTO insight_tags (insight_id, tag)
+		SELECT insight_id, ?
+		FROM insight_tags
+		WHERE tag = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return 0, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, s.done("rename insight tag", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, copyTags, to, from); err != nil {
+		return 0, s.done("rename insight tag", err)
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM insight_tags WHERE tag = ?", from)
+	if err != nil {
+		return 0, s.done("rename insight tag", err)
+	}
+	renamed, err := result.RowsAffected()
+	if err != nil {
+		return 0, s.done("rename insight tag", err)
+	}
+	if renamed == 0 {
+		s.breaker.Record(nil)
+		return 0, ErrNotFound
+	}
+	return renamed, s.done("rename insight tag", tx.Commit())
+}
+
+// DeleteInsightTag removes tag from every insight, reporting how many
+// carried it, or ErrNotFound when none did.
+func (s *Store) DeleteInsightTag(ctx context.Context, tag string) (int64, error) {
+	if s.mem != nil {
+		return s.mem.deleteInsightTag(tag)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return 0, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM insight_tags WHERE tag = ?", tag)
+	if err := s.done("delete insight tag", err); err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if deleted == 0 {
+		return 0, ErrNotFound
+	}
+	return deleted, nil
+}
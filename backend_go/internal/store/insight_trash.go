@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+// SoftDeleteInsight moves an insight to the trash. Deleting one that is
+// already there reports ErrNotFound.
+func (s *Store) SoftDeleteInsight(ctx context.Context, id int64, at time.Time) error {
+	if s.mem != nil {
+		return s.mem.softDeleteInsight(id, at)
+	}
+	const query = `
+		UPDATE insights
+		SET deleted_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, at, id)
+	if err := s.done("soft delete insight", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RestoreInsight takes an insight out of the trash if it was deleted at or
+// after since, i.e. has not yet expired.
+func (s *Store) RestoreInsight(ctx context.Context, id int64, since time.Time) error {
+	if s.mem != nil {
+		return s.mem.restoreInsight(id, since)
+	}
+	const query = `
+		UPDATE insights
+		SET deleted_at = NULL
+		WHERE id = ? AND deleted_at >= ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, id, since)
+	if err := s.done("restore insight", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeletedInsights lists insights deleted at or after since, most recently
+// deleted first.
+func (s *Store) DeletedInsights(ctx context.Context, since time.Time, limit int) ([]models.Insight, error) {
+	if s.mem != nil {
+		return s.mem.deletedInsights(since, limit), nil
+	}
+	const query = `
+		SELECT ` + insightColumns + `
+		FROM insights
+		WHERE deleted_at >= ?
+		ORDER BY deleted_at DESC
+		LIMIT ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, s.done("deleted insights", err)
+	}
+	defer rows.Close()
+
+	var items []models.Insight
+	for rows.Next() {
+		insight, err := scanInsight(rows)
+		if err != nil {
+			return nil, s.done("deleted insights", err)
+		}
+		items = append(items, insight)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("deleted insights", err)
+	}
+	if err := s.loadInsightLinks(ctx, items); err != nil {
+		return nil, s.done("deleted insights", err)
+	}
+	if err := s.loadInsightTags(ctx, items); err != nil {
+		return nil, s.done("deleted insights", err)
+	}
+	s.breaker.Record(nil)
+	return items, nil
+}
+
+// PurgeDeletedInsights permanently removes insights deleted before cutoff;
+// their metric links go with them.
+func (s *Store) PurgeDeletedInsights(ctx context.Context, cutoff time.Time) (int64, error) {
+	if s.mem != nil {
+		return s.mem.purgeDeletedInsights(cutoff), nil
+	}
+	const query = `
+		DELETE FROM insights
+		WHERE deleted_at < ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return 0, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, cutoff)
+	if err := s.done("purge deleted insights", err); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryStat aggregates timings per statement label such as
+// "SELECT metrics_snapshot".
+type QueryStat struct {
+	Statement string  `json:"statement"`
+	Count     int64   `json:"count"`
+	Errors    int64   `json:"errors"`
+	Slow      int64   `json:"slow"`
+	TotalMs   float64 `json:"total_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+type DBStats struct {
+	Pool       sql.DBStats `json:"pool"`
+	ActiveHost string      `json:"active_host,omitempty"`
+	Queries    []QueryStat `json:"queries"`
+}
+
+type queryStats struct {
+	mu   sync.Mutex
+	byOp map[string]*QueryStat
+}
+
+var statementTable = regexp.MustCompile(`(?is)^\s*(?:(UPDATE)\s+|(SELECT|INSERT|DELETE)\b.*?\b(?:FROM|INTO)\s+)([a-z_]+)`)
+
+// statementLabel keeps stats keyed by verb and table rather than by full text,
+// so batch inserts with varying placeholder counts share one entry.
+func statementLabel(query string) string {
+	if m := statementTable.FindStringSubmatch(query); m != nil {
+		return strings.ToUpper(m[1]+m[2]) + " " + m[3]
+	}
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "?"
+	}
+	return strings.ToUpper(fields[0])
+}
+
+func (q *queryStats) record(label string, elapsed time.Duration, slow bool, err error) {
+	ms := float64(elapsed.Microseconds()) / 1000
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stat, ok := q.byOp[label]
+	if !ok {
+		stat = &QueryStat{Statement: label}
+		q.byOp[label] = stat
+	}
+	stat.Count++
+	stat.TotalMs += ms
+	stat.MaxMs = max(stat.MaxMs, ms)
+	if slow {
+		stat.Slow++
+	}
+	if err != nil && err != sql.ErrNoRows {
+		stat.Errors++
+	}
+}
+
+func (q *queryStats) snapshot() []QueryStat {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := make([]QueryStat, 0, len(q.byOp))
+	for _, stat := range q.byOp {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(a, b int) bool { return stats[a].TotalMs > stats[b].TotalMs })
+	return stats
+}
+
+// instrumentedDB times every statement issued through the store, including
+// those inside transactions. With tx set, as inside Store.WithTx, every
+// statement runs in that transaction.
+type instrumentedDB struct {
+	*sql.DB
+	stats    *queryStats
+	slow     time.Duration
+	tx       *sql.Tx
+	prepared *stmtCache
+}
+
+// instrumentedTx is a transaction begun by a store method. A joined one
+// belongs to an enclosing WithTx, which alone commits or rolls back.
+type instrumentedTx struct {
+	*sql.Tx
+	db     *instrumentedDB
+	joined bool
+}
+
+func (d *instrumentedDB) observe(query string, args []any, started time.Time, err error) {
+	elapsed := time.Since(started)
+	slow := d.slow > 0 && elapsed >= d.slow
+	d.stats.record(statementLabel(query), elapsed, slow, err)
+	if slow {
+		log.Printf("slow query (%s): %s args=%s", elapsed.Round(time.Millisecond), strings.Join(strings.Fields(query), " "), redactArgs(args))
+	}
+}
+
+func (d *instrumentedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if d.tx != nil {
+		return (&instrumentedTx{Tx: d.tx, db: d}).ExecContext(ctx, query, args...)
+	}
+	started := time.Now()
+	result, err := d.DB.ExecContext(ctx, query, args...)
+	d.observe(query, args, started, err)
+	return result, err
+}
+
+func (d *instrumentedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if d.tx != nil {
+		return (&instrumentedTx{Tx: d.tx, db: d}).QueryContext(ctx, query, args...)
+	}
+	started := time.Now()
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	d.observe(query, args, started, err)
+	return rows, err
+}
+
+func (d *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if d.tx != nil {
+		return (&instrumentedTx{Tx: d.tx, db: d}).QueryRowContext(ctx, query, args...)
+	}
+	started := time.Now()
+	row := d.DB.QueryRowContext(ctx, query, args...)
+	d.observe(query, args, started, row.Err())
+	return row
+}
+
+func (d *instrumentedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*instrumentedTx, error) {
+	if d.tx != nil {
+		return &instrumentedTx{Tx: d.tx, db: d, joined: true}, nil
+	}
+	tx, err := d.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedTx{Tx: tx, db: d}, nil
+}
+
+func (t *instrumentedTx) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *instrumentedTx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+func (t *instrumentedTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	started := time.Now()
+	result, err := t.Tx.ExecContext(ctx, query, args...)
+	t.db.observe(query, args, started, err)
+	return result, err
+}
+
+func (t *instrumentedTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	started := time.Now()
+	rows, err := t.Tx.QueryContext(ctx, query, args...)
+	t.db.observe(query, args, started, err)
+	return rows, err
+}
+
+func (t *instrumentedTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	started := time.Now()
+	row := t.Tx.QueryRowContext(ctx, query, args...)
+	t.db.observe(query, args, started, row.Err())
+	return row
+}
+
+// redactArgs keeps numbers, booleans and times but hides text and blobs,
+// which may carry tokens, payloads or personal data.
+func redactArgs(args []any) string {
+	const maxArgs = 20
+	parts := make([]string, min(len(args), maxArgs))
+	for i, arg := range args[:len(parts)] {
+		switch v := arg.(type) {
+		case nil:
+			parts[i] = "NULL"
+		case string:
+			parts[i] = fmt.Sprintf("<string len=%d>", len(v))
+		case []byte:
+			parts[i] = fmt.Sprintf("<bytes len=%d>", len(v))
+		case sql.NullString:
+			parts[i] = fmt.Sprintf("<string len=%d>", len(v.String))
+		case time.Time:
+			parts[i] = v.Format(time.RFC3339)
+		case int, int64, int32, float64, float32, bool:
+			parts[i] = fmt.Sprint(v)
+		default:
+			parts[i] = fmt.Sprintf("<%T>", v)
+		}
+	}
+	if len(args) > maxArgs {
+		parts = append(parts, fmt.Sprintf("... %d more", len(args)-maxArgs))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func (s *Store) WithSlowQueryLog(threshold time.Duration) *Store {
+	s.db.slow = threshold
+	return s
+}
+
+func (s *Store) Stats() DBStats {
+	stats := DBStats{Queries: s.db.stats.snapshot()}
+	if s.db.DB != nil {
+		stats.Pool = s.db.Stats()
+	}
+	if s.failover != nil {
+		stats.ActiveHost = s.failover.ActiveHost()
+	}
+	return stats
+}
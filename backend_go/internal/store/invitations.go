@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const invitationColumns = "id, email, token_hash, role, team_id, team_role, invited_by, created_at, expires_at, accepted_at, user_id, revoked_at"
+
+func scanInvitation(row rowScanner) (models.Invitation, error) {
+	var inv models.Invitation
+	err := row.Scan(
+		&inv.ID,
+		&inv.Email,
+		&inv.TokenHash,
+		&inv.Role,
+		&inv.TeamID,
+		&inv.TeamRole,
+		&inv.InvitedBy,
+		&inv.CreatedAt,
+		&inv.ExpiresAt,
+		&inv.AcceptedAt,
+		&inv.UserID,
+		&inv.RevokedAt,
+	)
+	return inv, err
+}
+
+func (s *Store) InsertInvitation(ctx context.Context, inv models.Invitation) (models.Invitation, error) {
+	if s.mem != nil {
+		return s.mem.insertInvitation(inv), nil
+	}
+	const query = `
+		INSERT INTO invitations (email, token_hash, role, team_id, team_role, invited_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Invitation{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query,
+		inv.Email,
+		inv.TokenHash,
+		inv.Role,
+		inv.TeamID,
+		inv.TeamRole,
+		inv.InvitedBy,
+		inv.CreatedAt,
+		inv.ExpiresAt,
+	)
+	if err := s.done("insert invitation", err); err != nil {
+		return models.Invitation{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Invitation{}, err
+	}
+	inv.ID = id
+	return inv, nil
+}
+
+// ListInvitations lists every invitation, newest first.
+func (s *Store) ListInvitations(ctx context.Context) ([]models.Invitation, error) {
+	if s.mem != nil {
+		return s.mem.listInvitations(), nil
+	}
+	const query = `
+		SELECT ` + invitationColumns + `
+		FROM invitations
+		ORDER BY created_at DESC, id DESC
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list invitations", err)
+	}
+	defer rows.Close()
+
+	var invitations []models.Invitation
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, s.done("list invitations", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list invitations", err)
+	}
+	s.breaker.Record(nil)
+	return invitations, nil
+}
+
+func (s *Store) InvitationByHash(ctx context.Context, hash string) (models.Invitation, error) {
+	if s.mem != nil {
+		return s.mem.invitationByHash(hash)
+	}
+	const query = `
+		SELECT ` + invitationColumns + `
+		FROM invitations
+		WHERE token_hash = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Invitation{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	inv, err := scanInvitation(s.db.QueryRowContext(ctx, query, hash))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Invitation{}, ErrNotFound
+	}
+	return inv, s.done("invitation by hash", err)
+}
+
+// AcceptInvitation marks a pending invitation as accepted by userID. It
+// returns ErrNotFound if the invitation was accepted, revoked or expired in
+// the meantime, so two people cannot accept the same one.
+func (s *Store) AcceptInvitation(ctx context.Context, id, userID int64, at time.Time) error {
+	if s.mem != nil {
+		return s.mem.acceptInvitation(id, userID, at)
+	}
+	const query = `
+		UPDATE invitations
+		SET accepted_at = ?, user_id = ?
+		WHERE id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, at, userID, id, at)
+	if err := s.done("accept invitation", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeInvitation withdraws an invitation that has not been accepted.
+func (s *Store) RevokeInvitation(ctx context.Context, id int64, at time.Time) error {
+	if s.mem != nil {
+		return s.mem.revokeInvitation(id, at)
+	}
+	const query = `
+		UPDATE invitations
+		SET revoked_at = ?
+		WHERE id = ? AND accepted_at IS NULL AND revoked_at IS NULL
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, at, id)
+	if err := s.done("revoke invitation", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
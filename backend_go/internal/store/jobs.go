@@ -0,0 +1,214 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const jobColumns = "id, kind, status, payload, result, error, progress, attempts, created_at, started_at, finished_at"
+
+func scanJob(row rowScanner) (models.Job, error) {
+	var job models.Job
+	var payload, result []byte
+	var jobErr sql.NullString
+	var startedAt, finishedAt sql.NullTime
+	err := row.Scan(
+		&job.ID,
+		&job.Kind,
+		&job.Status,
+		&payload,
+		&result,
+		&jobErr,
+		&job.Progress,
+		&job.Attempts,
+		&job.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	job.Payload = payload
+	job.Result = result
+	job.Error = jobErr.String
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, err
+}
+
+func (s *Store) EnqueueJob(ctx context.Context, kind string, payload any) (models.Job, error) {
+	const query = `
+		INSERT INTO jobs (kind, payload)
+		VALUES (?, ?)
+	`
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return models.Job{}, err
+	}
+	if s.mem != nil {
+		return s.mem.enqueueJob(kind, body), nil
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return models.Job{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, kind, body)
+	if err := s.done("enqueue job", err); err != nil {
+		return models.Job{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Job{}, err
+	}
+	return models.Job{
+		ID:        id,
+		Kind:      kind,
+		Status:    models.JobQueued,
+		Payload:   body,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func (s *Store) JobByID(ctx context.Context, id int64) (models.Job, error) {
+	if s.mem != nil {
+		return s.mem.jobByID(id)
+	}
+	const query = `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Job{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	job, err := scanJob(s.db.QueryRowContext(ctx, query, id))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Job{}, ErrNotFound
+	}
+	return job, s.done("job by id", err)
+}
+
+// ClaimJob marks the oldest runnable job as running and returns it. Jobs left
+// running past staleBefore (a crashed worker) are picked up again until they
+// have used maxAttempts. ErrNotFound means the queue is empty.
+func (s *Store) ClaimJob(ctx context.Context, staleBefore time.Time, maxAttempts int) (models.Job, error) {
+	if s.mem != nil {
+		return s.mem.claimJob(staleBefore, maxAttempts)
+	}
+	const selectQuery = `
+		SELECT id
+		FROM jobs
+		WHERE (status = 'queued' OR (status = 'running' AND started_at < ?))
+			AND attempts < ?
+		ORDER BY id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`
+	const updateQuery = `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, progress = 0, started_at = ?
+		WHERE id = ?
+	`
+	const loadQuery = `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Job{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Job{}, s.done("claim job", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, selectQuery, staleBefore, maxAttempts).Scan(&id)
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Job{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Job{}, s.done("claim job", err)
+	}
+	if _, err := tx.ExecContext(ctx, updateQuery, time.Now(), id); err != nil {
+		return models.Job{}, s.done("claim job", err)
+	}
+	job, err := scanJob(tx.QueryRowContext(ctx, loadQuery, id))
+	if err != nil {
+		return models.Job{}, s.done("claim job", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Job{}, s.done("claim job", err)
+	}
+	s.breaker.Record(nil)
+	return job, nil
+}
+
+func (s *Store) UpdateJobProgress(ctx context.Context, id int64, progress int) error {
+	if s.mem != nil {
+		s.mem.updateJobProgress(id, progress)
+		return nil
+	}
+	const query = `
+		UPDATE jobs
+		SET progress = ?
+		WHERE id = ? AND status = 'running'
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, progress, id)
+	return s.done("update job progress", err)
+}
+
+// FinishJob stores the outcome; a non-nil jobErr marks the job failed.
+func (s *Store) FinishJob(ctx context.Context, id int64, result any, jobErr error) error {
+	const query = `
+		UPDATE jobs
+		SET status = ?, progress = IF(? = 'succeeded', 100, progress), result = ?, error = ?, finished_at = ?
+		WHERE id = ?
+	`
+	status := models.JobSucceeded
+	var body []byte
+	var message sql.NullString
+	if jobErr != nil {
+		status = models.JobFailed
+		message = sql.NullString{String: jobErr.Error(), Valid: true}
+	} else if result != nil {
+		var err error
+		if body, err = json.Marshal(result); err != nil {
+			return err
+		}
+	}
+	if s.mem != nil {
+		s.mem.finishJob(id, status, body, message.String)
+		return nil
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, status, status, body, message, time.Now(), id)
+	return s.done("finish job", err)
+}
@@ -0,0 +1,540 @@
+package store
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+// ErrUnsupported is returned by operations the memory store cannot perform.
+var ErrUnsupported = errors.New("store: not supported by the memory store")
+
+// memory keeps every table in process memory for STORE=memory, following the
+// semantics of the SQL queries closely enough for the services not to notice.
+// Inside WithTx it is a view sharing the same data that records how to undo
+// its inserts. With STORE=file, metrics are also written to log, at commit
+// when inside WithTx.
+type memory struct {
+	mu      *sync.Mutex
+	data    *memoryData
+	undo    *[]func(*memoryData)
+	log     *metricLog
+	pending *[]models.Metrics
+}
+
+// memoryData holds the tables. Exported fields are written to the snapshot,
+// mirroring what a backup covers; users, sessions, tokens and operational
+// queues live only as long as the process.
+type memoryData struct {
+	NextID         map[string]int64            `json:"next_id"`
+	Metrics        []memoryMetric              `json:"metrics"`
+	Insights       []memoryInsight             `json:"insights"`
+	InsightRules   []models.InsightRule        `json:"insight_rules"`
+	Channels       []memoryChannel             `json:"notification_channels"`
+	Silences       []models.Silence            `json:"alert_silences"`
+	DerivedMetrics []models.DerivedMetric      `json:"derived_metrics"`
+	Funnels        []models.Funnel             `json:"funnels"`
+	FunnelEvents   []memoryFunnelEvent         `json:"funnel_events"`
+	Surveys        []models.SurveyResponse     `json:"survey_responses"`
+	Dimensions     []models.DimensionValue     `json:"metric_dimensions"`
+	Targets        []models.Target             `json:"metric_targets"`
+	Widgets        []models.Widget             `json:"widgets"`
+	MetricMeta     []models.MetricMetaOverride `json:"metric_meta"`
+	TenantSettings []models.TenantSettings     `json:"tenant_settings"`
+	Orgs           []models.Org                `json:"orgs"`
+	Teams          []models.Team               `json:"teams"`
+	ScheduledJobs  []models.ScheduledJob       `json:"scheduled_jobs"`
+	BacklogItems   []models.BacklogItem        `json:"backlog_items"`
+
+	users       []models.User
+	sessions    []models.Session
+	embedTokens []models.EmbedToken
+	invitations []models.Invitation
+	audit       []models.AuditEvent
+	assignments []models.InsightAssignment
+	teamMembers []models.TeamMember
+	preferences map[int64]models.NotificationPreferences
+	jobs        []models.Job
+	outbox      []memoryOutboxEvent
+	deliveries  []memoryDelivery
+	alerts      []models.Alert
+	idempotency map[string]models.IdempotencyRecord
+	usage       map[usageKey]models.UsageCounter
+	syncCursors map[[2]string]int
+}
+
+type memoryMetric struct {
+	ID int64 `json:"id"`
+	models.Metrics
+}
+
+type memoryFunnelEvent struct {
+	Funnel string `json:"funnel"`
+	models.FunnelEvent
+}
+
+// memoryInsight and memoryChannel carry the fields the API models hide from
+// JSON so they survive a snapshot.
+type memoryInsight struct {
+	models.Insight
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+type memoryChannel struct {
+	models.NotificationChannel
+	Secret string `json:"secret,omitempty"`
+}
+
+type memoryOutboxEvent struct {
+	models.OutboxEvent
+	nextAttempt time.Time
+}
+
+type memoryDelivery struct {
+	models.OutboxDelivery
+	nextAttempt time.Time
+}
+
+type usageKey struct {
+	bucket      int64
+	tenant, key string
+}
+
+// NewMemory returns a Store that keeps everything in process memory. When
+// snapshotPath names an existing file, its contents are loaded first.
+func NewMemory(snapshotPath string) (*Store, error) {
+	data := &memoryData{NextID: map[string]int64{}}
+	if snapshotPath != "" {
+		raw, err := os.ReadFile(snapshotPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(raw, data); err != nil {
+				return nil, err
+			}
+			if data.NextID == nil {
+				data.NextID = map[string]int64{}
+			}
+		}
+	}
+	data.idempotency = map[string]models.IdempotencyRecord{}
+	data.usage = map[usageKey]models.UsageCounter{}
+	data.preferences = map[int64]models.NotificationPreferences{}
+	data.syncCursors = map[[2]string]int{}
+	return &Store{
+		db: &instrumentedDB{
+			stats:    &queryStats{byOp: map[string]*QueryStat{}},
+			prepared: &stmtCache{stmts: map[string]*sql.Stmt{}},
+		},
+		queryTimeout: defaultQueryTimeout,
+		mem:          &memory{mu: &sync.Mutex{}, data: data},
+		snapshotPath: snapshotPath,
+	}, nil
+}
+
+// SaveSnapshot writes the persistent part of a memory store to its snapshot
+// file. It does nothing for a database-backed store or without a path.
+func (s *Store) SaveSnapshot() error {
+	if s.mem == nil || s.snapshotPath == "" {
+		return nil
+	}
+	defer s.mem.lock()()
+	data := *s.mem.data
+	if s.mem.log != nil {
+		// Metrics are in the segments already.
+		data.Metrics = nil
+		if err := s.mem.log.saveIndex(); err != nil {
+			return err
+		}
+	}
+	raw, err := json.Marshal(&data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.snapshotPath, raw)
+}
+
+// writeFileAtomic replaces path through a temporary file in the same
+// directory, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (m *memory) lock() func() {
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+func (m *memory) nextID(table string) int64 {
+	m.data.NextID[table]++
+	return m.data.NextID[table]
+}
+
+// onRollback registers how to undo a write made inside WithTx.
+func (m *memory) onRollback(fn func(*memoryData)) {
+	if m.undo != nil {
+		*m.undo = append(*m.undo, fn)
+	}
+}
+
+func (m *memory) begin() *memory {
+	return &memory{mu: m.mu, data: m.data, undo: new([]func(*memoryData)), log: m.log, pending: new([]models.Metrics)}
+}
+
+// commit writes the metrics inserted inside WithTx to the log.
+func (m *memory) commit() error {
+	if m.log == nil || len(*m.pending) == 0 {
+		return nil
+	}
+	defer m.lock()()
+	return m.log.append(*m.pending)
+}
+
+func (m *memory) rollback() {
+	defer m.lock()()
+	for i := len(*m.undo) - 1; i >= 0; i-- {
+		(*m.undo)[i](m.data)
+	}
+}
+
+func (m *memory) insertMetrics(batch []models.Metrics) error {
+	defer m.lock()()
+	inserted := make(map[int64]bool, len(batch))
+	for _, metrics := range batch {
+		row := memoryMetric{ID: m.nextID("metrics_snapshot"), Metrics: metrics}
+		i := sort.Search(len(m.data.Metrics), func(i int) bool {
+			return m.data.Metrics[i].CreatedAt.After(metrics.CreatedAt)
+		})
+		m.data.Metrics = slices.Insert(m.data.Metrics, i, row)
+		inserted[row.ID] = true
+	}
+	undo := func(data *memoryData) {
+		data.Metrics = slices.DeleteFunc(data.Metrics, func(row memoryMetric) bool { return inserted[row.ID] })
+	}
+	switch {
+	case m.log == nil:
+	case m.pending != nil:
+		*m.pending = append(*m.pending, batch...)
+	default:
+		if err := m.log.append(batch); err != nil {
+			undo(m.data)
+			return err
+		}
+	}
+	m.onRollback(undo)
+	return nil
+}
+
+func (m *memory) latestMetrics() models.Metrics {
+	defer m.lock()()
+	if len(m.data.Metrics) == 0 {
+		return models.Metrics{}
+	}
+	return m.data.Metrics[len(m.data.Metrics)-1].Metrics
+}
+
+// extendLatestMetrics is not written to the file log; after a restart the
+// snapshot reads as valid only at its own time.
+func (m *memory) extendLatestMetrics(until time.Time) error {
+	defer m.lock()()
+	if len(m.data.Metrics) == 0 {
+		return ErrNotFound
+	}
+	id, previous := m.data.Metrics[len(m.data.Metrics)-1].ID, m.data.Metrics[len(m.data.Metrics)-1].ValidUntil
+	m.data.Metrics[len(m.data.Metrics)-1].ValidUntil = &until
+	m.onRollback(func(data *memoryData) {
+		for i := range data.Metrics {
+			if data.Metrics[i].ID == id {
+				data.Metrics[i].ValidUntil = previous
+			}
+		}
+	})
+	return nil
+}
+
+func (m *memory) trend(limit int) []models.Metrics {
+	defer m.lock()()
+	rows := m.data.Metrics[max(len(m.data.Metrics)-limit, 0):]
+	var points []models.Metrics
+	for _, row := range rows {
+		points = append(points, row.Metrics)
+	}
+	return points
+}
+
+// metricsAfter returns up to limit snapshots after the (at, id) keyset
+// position and no later than to.
+func (m *memory) metricsAfter(at time.Time, id int64, to time.Time, limit int) []models.MetricSnapshot {
+	defer m.lock()()
+	i := sort.Search(len(m.data.Metrics), func(i int) bool {
+		row := m.data.Metrics[i]
+		return row.CreatedAt.After(at) || row.CreatedAt.Equal(at) && row.ID > id
+	})
+	var rows []models.MetricSnapshot
+	for ; i < len(m.data.Metrics) && len(rows) < limit; i++ {
+		row := m.data.Metrics[i]
+		if row.CreatedAt.After(to) {
+			break
+		}
+		rows = append(rows, models.MetricSnapshot{ID: row.ID, Metrics: row.Metrics})
+	}
+	return rows
+}
+
+func (m *memory) metricsBetween(from, to time.Time, limit int) []models.Metrics {
+	rows := m.metricsAfter(from, 0, to, limit)
+	points := make([]models.Metrics, len(rows))
+	for i, row := range rows {
+		points[i] = row.Metrics
+	}
+	return points
+}
+
+func (m *memory) metricsAt(at time.Time) (models.Metrics, error) {
+	defer m.lock()()
+	i := sort.Search(len(m.data.Metrics), func(i int) bool {
+		return m.data.Metrics[i].CreatedAt.After(at)
+	})
+	if i == 0 {
+		return models.Metrics{}, ErrNotFound
+	}
+	return m.data.Metrics[i-1].Metrics, nil
+}
+
+func (m *memory) quarterHourTotals(key string, from, to time.Time) []models.MetricBucket {
+	points := m.metricsBetween(from, to, math.MaxInt)
+	var buckets []models.MetricBucket
+	for _, point := range points {
+		t := point.CreatedAt
+		start := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()/15*15, 0, 0, t.Location())
+		value, _ := point.Value(key)
+		if n := len(buckets); n > 0 && buckets[n-1].Start.Equal(start) {
+			buckets[n-1].Sum += value
+			buckets[n-1].Count++
+			continue
+		}
+		buckets = append(buckets, models.MetricBucket{Start: start, Sum: value, Count: 1})
+	}
+	return buckets
+}
+
+func (m *memory) deleteMetrics(match func(time.Time) bool) (int64, error) {
+	defer m.lock()()
+	var from, to time.Time
+	var deleted int64
+	m.data.Metrics = slices.DeleteFunc(m.data.Metrics, func(row memoryMetric) bool {
+		if !match(row.CreatedAt) {
+			return false
+		}
+		if deleted == 0 {
+			from = row.CreatedAt
+		}
+		to = row.CreatedAt
+		deleted++
+		return true
+	})
+	if m.log != nil && deleted > 0 {
+		return deleted, m.log.remove(from, to, match)
+	}
+	return deleted, nil
+}
+
+// updateMetricsDerived is not written to the file log; segments keep the
+// values the snapshots were ingested with.
+func (m *memory) updateMetricsDerived(rows []models.MetricSnapshot) error {
+	defer m.lock()()
+	derived := make(map[int64]map[string]float64, len(rows))
+	for _, row := range rows {
+		derived[row.ID] = row.Derived
+	}
+	previous := make(map[int64]map[string]float64, len(rows))
+	for i, row := range m.data.Metrics {
+		if values, ok := derived[row.ID]; ok {
+			previous[row.ID] = row.Derived
+			m.data.Metrics[i].Derived = values
+		}
+	}
+	m.onRollback(func(data *memoryData) {
+		for i, row := range data.Metrics {
+			if values, ok := previous[row.ID]; ok {
+				data.Metrics[i].Derived = values
+			}
+		}
+	})
+	return nil
+}
+
+// insight returns the index of a stored insight, or -1.
+func (m *memory) insight(id int64) int {
+	i, found := sort.Find(len(m.data.Insights), func(i int) int {
+		return cmp.Compare(id, m.data.Insights[i].ID)
+	})
+	if !found {
+		return -1
+	}
+	return i
+}
+
+func (m *memory) insertInsight(insight models.Insight) (models.Insight, error) {
+	unlock := m.lock()
+	insight.ID = m.nextID("insights")
+	insight.CreatedAt = time.Now()
+	insight.RepeatCount = 1
+	insight.Version = 1
+	stored := insight
+	stored.Metrics = slices.Clone(insight.Metrics)
+	stored.Tags = slices.Clone(insight.Tags)
+	m.data.Insights = append(m.data.Insights, memoryInsight{Insight: stored, Fingerprint: insight.Fingerprint})
+	id := insight.ID
+	m.onRollback(func(data *memoryData) {
+		data.Insights = slices.DeleteFunc(data.Insights, func(row memoryInsight) bool { return row.ID == id })
+	})
+	unlock()
+	if err := m.enqueueEvent(models.EventInsightCreated, insight); err != nil {
+		return models.Insight{}, err
+	}
+	return insight, nil
+}
+
+// findInsights returns copies of the insights matching keep, newest first
+// when newest is set, up to limit.
+func (m *memory) findInsights(keep func(memoryInsight) bool, newest bool, limit int) []models.Insight {
+	defer m.lock()()
+	var items []models.Insight
+	for n := range m.data.Insights {
+		i := n
+		if newest {
+			i = len(m.data.Insights) - 1 - n
+		}
+		if len(items) >= limit {
+			break
+		}
+		if row := m.data.Insights[i]; keep(row) {
+			insight := row.Insight
+			insight.Metrics = slices.Clone(row.Metrics)
+			insight.Tags = slices.Clone(row.Tags)
+			items = append(items, insight)
+		}
+	}
+	return items
+}
+
+func (m *memory) latestInsights(locale string, limit int) []models.Insight {
+	return m.findInsights(func(row memoryInsight) bool {
+		return row.Locale == locale && row.DeletedAt == nil
+	}, true, limit)
+}
+
+func (m *memory) insightsBetween(locale string, from, to time.Time, severities []string, limit int) []models.Insight {
+	return m.findInsights(func(row memoryInsight) bool {
+		return row.Locale == locale && row.DeletedAt == nil &&
+			!row.CreatedAt.Before(from) && !row.CreatedAt.After(to) &&
+			(len(severities) == 0 || slices.Contains(severities, row.Severity))
+	}, false, limit)
+}
+
+func (m *memory) recentInsightByFingerprint(fingerprint string, since time.Time) (models.Insight, error) {
+	items := m.findInsights(func(row memoryInsight) bool {
+		return row.Fingerprint == fingerprint && !row.CreatedAt.Before(since) && row.DeletedAt == nil
+	}, true, 1)
+	if len(items) == 0 {
+		return models.Insight{}, ErrNotFound
+	}
+	items[0].Metrics = nil
+	return items[0], nil
+}
+
+func (m *memory) bumpInsightRepeat(id int64) {
+	defer m.lock()()
+	if i := m.insight(id); i >= 0 {
+		m.data.Insights[i].RepeatCount++
+	}
+}
+
+func (m *memory) insightByID(id int64) (models.Insight, error) {
+	defer m.lock()()
+	i := m.insight(id)
+	if i < 0 {
+		return models.Insight{}, ErrNotFound
+	}
+	insight := m.data.Insights[i].Insight
+	insight.Metrics = slices.Clone(insight.Metrics)
+	insight.Tags = slices.Clone(insight.Tags)
+	return insight, nil
+}
+
+func (m *memory) updateInsight(insight models.Insight, version int) error {
+	defer m.lock()()
+	i := m.insight(insight.ID)
+	if i < 0 || m.data.Insights[i].DeletedAt != nil {
+		return ErrNotFound
+	}
+	row := &m.data.Insights[i]
+	if row.Version != version {
+		return ErrConflict
+	}
+	row.Title, row.Message, row.Severity = insight.Title, insight.Message, insight.Severity
+	row.Version++
+	return nil
+}
+
+func (m *memory) softDeleteInsight(id int64, at time.Time) error {
+	defer m.lock()()
+	i := m.insight(id)
+	if i < 0 || m.data.Insights[i].DeletedAt != nil {
+		return ErrNotFound
+	}
+	m.data.Insights[i].DeletedAt = &at
+	return nil
+}
+
+func (m *memory) restoreInsight(id int64, since time.Time) error {
+	defer m.lock()()
+	i := m.insight(id)
+	if i < 0 || m.data.Insights[i].DeletedAt == nil || m.data.Insights[i].DeletedAt.Before(since) {
+		return ErrNotFound
+	}
+	m.data.Insights[i].DeletedAt = nil
+	return nil
+}
+
+func (m *memory) deletedInsights(since time.Time, limit int) []models.Insight {
+	items := m.findInsights(func(row memoryInsight) bool {
+		return row.DeletedAt != nil && !row.DeletedAt.Before(since)
+	}, true, math.MaxInt)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.After(*items[j].DeletedAt) })
+	return items[:min(len(items), limit)]
+}
+
+func (m *memory) purgeDeletedInsights(cutoff time.Time) int64 {
+	defer m.lock()()
+	before := len(m.data.Insights)
+	m.data.Insights = slices.DeleteFunc(m.data.Insights, func(row memoryInsight) bool {
+		return row.DeletedAt != nil && row.DeletedAt.Before(cutoff)
+	})
+	return int64(before - len(m.data.Insights))
+}
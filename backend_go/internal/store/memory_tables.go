@@ -0,0 +1,1543 @@
+package store
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+func (m *memory) enqueueEvent(eventType string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	defer m.lock()()
+	now := time.Now()
+	id := m.nextID("notification_outbox")
+	m.data.outbox = append(m.data.outbox, memoryOutboxEvent{
+		OutboxEvent: models.OutboxEvent{ID: id, EventType: eventType, Payload: body, CreatedAt: now},
+		nextAttempt: now,
+	})
+	m.onRollback(func(data *memoryData) {
+		data.outbox = slices.DeleteFunc(data.outbox, func(event memoryOutboxEvent) bool { return event.ID == id })
+	})
+	return nil
+}
+
+func (m *memory) claimOutbox(limit int, lease time.Duration) []models.OutboxEvent {
+	defer m.lock()()
+	now := time.Now()
+	var events []models.OutboxEvent
+	for i := range m.data.outbox {
+		event := &m.data.outbox[i]
+		if len(events) >= limit {
+			break
+		}
+		if !event.nextAttempt.After(now) {
+			event.nextAttempt = now.Add(lease)
+			events = append(events, event.OutboxEvent)
+		}
+	}
+	return events
+}
+
+// fanOutOutbox replaces an event with a delivery per target, each carrying
+// its own copy of the event. Finished events and deliveries are dropped
+// rather than kept around as they are in the database.
+func (m *memory) fanOutOutbox(id int64, targets []string) {
+	defer m.lock()()
+	i := slices.IndexFunc(m.data.outbox, func(event memoryOutboxEvent) bool { return event.ID == id })
+	if i < 0 {
+		return
+	}
+	event := m.data.outbox[i].OutboxEvent
+	m.data.outbox = slices.Delete(m.data.outbox, i, i+1)
+	for _, target := range targets {
+		m.data.deliveries = append(m.data.deliveries, memoryDelivery{
+			OutboxDelivery: models.OutboxDelivery{ID: m.nextID("notification_deliveries"), Target: target, Event: event},
+			nextAttempt:    event.CreatedAt,
+		})
+	}
+}
+
+func (m *memory) claimDeliveries(limit int, lease time.Duration) []models.OutboxDelivery {
+	defer m.lock()()
+	now := time.Now()
+	var deliveries []models.OutboxDelivery
+	for i := range m.data.deliveries {
+		delivery := &m.data.deliveries[i]
+		if len(deliveries) >= limit {
+			break
+		}
+		if !delivery.nextAttempt.After(now) {
+			delivery.nextAttempt = now.Add(lease)
+			deliveries = append(deliveries, delivery.OutboxDelivery)
+		}
+	}
+	return deliveries
+}
+
+// markDelivery records a delivery attempt, dropping finished deliveries.
+func (m *memory) markDelivery(id int64, finished bool, nextAttempt time.Time) {
+	defer m.lock()()
+	for i := range m.data.deliveries {
+		delivery := &m.data.deliveries[i]
+		if delivery.ID != id {
+			continue
+		}
+		if finished {
+			m.data.deliveries = slices.Delete(m.data.deliveries, i, i+1)
+			return
+		}
+		delivery.Attempts++
+		delivery.nextAttempt = nextAttempt
+		return
+	}
+}
+
+func (m *memory) insertBacklogItem(item models.BacklogItem) models.BacklogItem {
+	defer m.lock()()
+	item.ID = m.nextID("backlog_items")
+	item.ResolvedAt = nil
+	m.data.BacklogItems = append(m.data.BacklogItems, item)
+	return item
+}
+
+func (m *memory) resolveBacklogItem(id int64, at time.Time) error {
+	defer m.lock()()
+	for i := range m.data.BacklogItems {
+		if item := &m.data.BacklogItems[i]; item.ID == id && item.ResolvedAt == nil {
+			item.ResolvedAt = &at
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *memory) openBacklogItems() []models.BacklogItem {
+	defer m.lock()()
+	var items []models.BacklogItem
+	for _, item := range m.data.BacklogItems {
+		if item.ResolvedAt == nil {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].OpenedAt.Before(items[j].OpenedAt) })
+	return items
+}
+
+func (m *memory) reserveIdempotencyKey(key, requestHash string, ttl time.Duration) (models.IdempotencyRecord, bool) {
+	defer m.lock()()
+	now := time.Now()
+	if record, ok := m.data.idempotency[key]; ok && !record.CreatedAt.Before(now.Add(-ttl)) {
+		return record, false
+	}
+	record := models.IdempotencyRecord{Key: key, RequestHash: requestHash, CreatedAt: now}
+	m.data.idempotency[key] = record
+	return record, true
+}
+
+func (m *memory) completeIdempotencyKey(key string, statusCode int, body []byte) {
+	defer m.lock()()
+	if record, ok := m.data.idempotency[key]; ok {
+		record.StatusCode, record.Body = statusCode, slices.Clone(body)
+		m.data.idempotency[key] = record
+	}
+}
+
+func (m *memory) releaseIdempotencyKey(key string) {
+	defer m.lock()()
+	delete(m.data.idempotency, key)
+}
+
+func (m *memory) listInsightRules(enabledOnly bool) []models.InsightRule {
+	defer m.lock()()
+	var rules []models.InsightRule
+	for _, rule := range m.data.InsightRules {
+		if !enabledOnly || rule.Enabled {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func (m *memory) saveInsightRule(rule models.InsightRule, insert bool) (models.InsightRule, error) {
+	defer m.lock()()
+	index := -1
+	for i, existing := range m.data.InsightRules {
+		if existing.Name == rule.Name && (insert || existing.ID != rule.ID) {
+			return models.InsightRule{}, ErrConflict
+		}
+		if !insert && existing.ID == rule.ID {
+			index = i
+		}
+	}
+	now := time.Now()
+	rule.UpdatedAt = now
+	switch {
+	case insert:
+		rule.ID = m.nextID("insight_rules")
+		rule.CreatedAt = now
+		m.data.InsightRules = append(m.data.InsightRules, rule)
+	case index < 0:
+		return models.InsightRule{}, ErrNotFound
+	default:
+		rule.CreatedAt = m.data.InsightRules[index].CreatedAt
+		m.data.InsightRules[index] = rule
+	}
+	return rule, nil
+}
+
+func (m *memory) insightRuleByID(id int64) (models.InsightRule, error) {
+	defer m.lock()()
+	for _, rule := range m.data.InsightRules {
+		if rule.ID == id {
+			return rule, nil
+		}
+	}
+	return models.InsightRule{}, ErrNotFound
+}
+
+func (m *memory) deleteInsightRule(id int64) error {
+	defer m.lock()()
+	before := len(m.data.InsightRules)
+	m.data.InsightRules = slices.DeleteFunc(m.data.InsightRules, func(rule models.InsightRule) bool { return rule.ID == id })
+	if len(m.data.InsightRules) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (c memoryChannel) model() models.NotificationChannel {
+	channel := c.NotificationChannel
+	channel.Secret = c.Secret
+	channel.HasSecret = c.Secret != ""
+	channel.EventTypes = slices.Clone(channel.EventTypes)
+	return channel
+}
+
+func (m *memory) listNotificationChannels(enabledOnly bool) []models.NotificationChannel {
+	defer m.lock()()
+	var channels []models.NotificationChannel
+	for _, channel := range m.data.Channels {
+		if !enabledOnly || channel.Enabled {
+			channels = append(channels, channel.model())
+		}
+	}
+	return channels
+}
+
+func (m *memory) saveNotificationChannel(channel models.NotificationChannel, insert bool) (models.NotificationChannel, error) {
+	defer m.lock()()
+	index := -1
+	for i, existing := range m.data.Channels {
+		if existing.Name == channel.Name && (insert || existing.ID != channel.ID) {
+			return models.NotificationChannel{}, ErrConflict
+		}
+		if !insert && existing.ID == channel.ID {
+			index = i
+		}
+	}
+	now := time.Now()
+	row := memoryChannel{NotificationChannel: channel, Secret: channel.Secret}
+	row.EventTypes = slices.Clone(channel.EventTypes)
+	row.UpdatedAt = now
+	switch {
+	case insert:
+		row.ID = m.nextID("notification_channels")
+		row.CreatedAt = now
+		m.data.Channels = append(m.data.Channels, row)
+	case index < 0:
+		return models.NotificationChannel{}, ErrNotFound
+	default:
+		row.CreatedAt = m.data.Channels[index].CreatedAt
+		m.data.Channels[index] = row
+	}
+	return row.model(), nil
+}
+
+func (m *memory) notificationChannelByID(id int64) (models.NotificationChannel, error) {
+	defer m.lock()()
+	for _, channel := range m.data.Channels {
+		if channel.ID == id {
+			return channel.model(), nil
+		}
+	}
+	return models.NotificationChannel{}, ErrNotFound
+}
+
+func (m *memory) deleteNotificationChannel(id int64) error {
+	defer m.lock()()
+	before := len(m.data.Channels)
+	m.data.Channels = slices.DeleteFunc(m.data.Channels, func(channel memoryChannel) bool { return channel.ID == id })
+	if len(m.data.Channels) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) listSilences(includeExpired bool, now time.Time) []models.Silence {
+	defer m.lock()()
+	var silences []models.Silence
+	for i := len(m.data.Silences) - 1; i >= 0; i-- {
+		silence := m.data.Silences[i]
+		if includeExpired || silence.EndsAt == nil || silence.EndsAt.After(now) {
+			silences = append(silences, silence)
+		}
+	}
+	return silences
+}
+
+func (m *memory) insertSilence(silence models.Silence) models.Silence {
+	defer m.lock()()
+	silence.ID = m.nextID("alert_silences")
+	silence.CreatedAt = time.Now()
+	m.data.Silences = append(m.data.Silences, silence)
+	return silence
+}
+
+func (m *memory) silenceByID(id int64) (models.Silence, error) {
+	defer m.lock()()
+	for _, silence := range m.data.Silences {
+		if silence.ID == id {
+			return silence, nil
+		}
+	}
+	return models.Silence{}, ErrNotFound
+}
+
+func (m *memory) deleteSilence(id int64) error {
+	defer m.lock()()
+	before := len(m.data.Silences)
+	m.data.Silences = slices.DeleteFunc(m.data.Silences, func(silence models.Silence) bool { return silence.ID == id })
+	if len(m.data.Silences) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) listDerivedMetrics() []models.DerivedMetric {
+	defer m.lock()()
+	items := slices.Clone(m.data.DerivedMetrics)
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return items
+}
+
+func (m *memory) saveDerivedMetric(item models.DerivedMetric) {
+	defer m.lock()()
+	for i, existing := range m.data.DerivedMetrics {
+		if existing.Name == item.Name {
+			item.CreatedAt = existing.CreatedAt
+			m.data.DerivedMetrics[i] = item
+			return
+		}
+	}
+	item.CreatedAt = time.Now()
+	m.data.DerivedMetrics = append(m.data.DerivedMetrics, item)
+}
+
+func (m *memory) deleteDerivedMetric(name string) error {
+	defer m.lock()()
+	before := len(m.data.DerivedMetrics)
+	m.data.DerivedMetrics = slices.DeleteFunc(m.data.DerivedMetrics, func(item models.DerivedMetric) bool { return item.Name == name })
+	if len(m.data.DerivedMetrics) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) listFunnels() []models.Funnel {
+	defer m.lock()()
+	funnels := slices.Clone(m.data.Funnels)
+	sort.Slice(funnels, func(i, j int) bool { return funnels[i].Name < funnels[j].Name })
+	return funnels
+}
+
+func (m *memory) funnelByName(name string) (models.Funnel, error) {
+	defer m.lock()()
+	for _, funnel := range m.data.Funnels {
+		if funnel.Name == name {
+			return funnel, nil
+		}
+	}
+	return models.Funnel{}, ErrNotFound
+}
+
+func (m *memory) saveFunnel(funnel models.Funnel) {
+	defer m.lock()()
+	for i, existing := range m.data.Funnels {
+		if existing.Name == funnel.Name {
+			funnel.CreatedAt = existing.CreatedAt
+			m.data.Funnels[i] = funnel
+			return
+		}
+	}
+	funnel.CreatedAt = time.Now()
+	m.data.Funnels = append(m.data.Funnels, funnel)
+}
+
+func (m *memory) deleteFunnel(name string) error {
+	defer m.lock()()
+	before := len(m.data.Funnels)
+	m.data.Funnels = slices.DeleteFunc(m.data.Funnels, func(funnel models.Funnel) bool { return funnel.Name == name })
+	if len(m.data.Funnels) == before {
+		return ErrNotFound
+	}
+	m.data.FunnelEvents = slices.DeleteFunc(m.data.FunnelEvents, func(event memoryFunnelEvent) bool { return event.Funnel == name })
+	return nil
+}
+
+func (m *memory) insertFunnelEvents(funnel string, events []models.FunnelEvent) {
+	defer m.lock()()
+	for _, event := range events {
+		m.data.FunnelEvents = append(m.data.FunnelEvents, memoryFunnelEvent{Funnel: funnel, FunnelEvent: event})
+	}
+}
+
+func (m *memory) funnelEvents(funnel string, from, to time.Time, limit int) []models.FunnelEvent {
+	defer m.lock()()
+	var events []models.FunnelEvent
+	for _, event := range m.data.FunnelEvents {
+		if event.Funnel == funnel && !event.OccurredAt.Before(from) && !event.OccurredAt.After(to) {
+			events = append(events, event.FunnelEvent)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].OccurredAt.Before(events[j].OccurredAt) })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
+
+func (m *memory) insertSurveyResponses(responses []models.SurveyResponse) {
+	defer m.lock()()
+	m.data.Surveys = append(m.data.Surveys, responses...)
+}
+
+func (m *memory) surveyResponses(from, to time.Time, limit int) []models.SurveyResponse {
+	defer m.lock()()
+	var responses []models.SurveyResponse
+	for _, response := range m.data.Surveys {
+		if !response.RespondedAt.Before(from) && !response.RespondedAt.After(to) {
+			responses = append(responses, response)
+		}
+	}
+	sort.SliceStable(responses, func(i, j int) bool { return responses[i].RespondedAt.Before(responses[j].RespondedAt) })
+	if len(responses) > limit {
+		responses = responses[:limit]
+	}
+	return responses
+}
+
+func (m *memory) insertDimensionValues(values []models.DimensionValue) {
+	defer m.lock()()
+	m.data.Dimensions = append(m.data.Dimensions, values...)
+}
+
+func (m *memory) dimensionTotals(metric, dimension string, from, to time.Time) []models.Contributor {
+	defer m.lock()()
+	totals := map[string]float64{}
+	for _, value := range m.data.Dimensions {
+		if value.Metric == metric && value.Dimension == dimension &&
+			!value.RecordedAt.Before(from) && !value.RecordedAt.After(to) {
+			totals[value.Member] += value.Value
+		}
+	}
+	out := make([]models.Contributor, 0, len(totals))
+	for member, total := range totals {
+		out = append(out, models.Contributor{Member: member, Value: total})
+	}
+	return out
+}
+
+func (m *memory) listTargets(quarter string) []models.Target {
+	defer m.lock()()
+	var targets []models.Target
+	for _, target := range m.data.Targets {
+		if quarter == "" || target.Quarter == quarter {
+			targets = append(targets, target)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Quarter != targets[j].Quarter {
+			return targets[i].Quarter < targets[j].Quarter
+		}
+		return targets[i].Metric < targets[j].Metric
+	})
+	return targets
+}
+
+func (m *memory) saveTarget(target models.Target) {
+	defer m.lock()()
+	target.UpdatedAt = time.Now()
+	for i, existing := range m.data.Targets {
+		if existing.Metric == target.Metric && existing.Quarter == target.Quarter {
+			m.data.Targets[i] = target
+			return
+		}
+	}
+	m.data.Targets = append(m.data.Targets, target)
+}
+
+func (m *memory) deleteTarget(metric, quarter string) error {
+	defer m.lock()()
+	before := len(m.data.Targets)
+	m.data.Targets = slices.DeleteFunc(m.data.Targets, func(target models.Target) bool {
+		return target.Metric == metric && target.Quarter == quarter
+	})
+	if len(m.data.Targets) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) listAlerts(openOnly bool, limit int) []models.Alert {
+	defer m.lock()()
+	var alerts []models.Alert
+	for i := len(m.data.alerts) - 1; i >= 0 && len(alerts) < limit; i-- {
+		if alert := m.data.alerts[i]; !openOnly || alert.AcknowledgedAt == nil {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+func (m *memory) alertWhere(match func(models.Alert) bool) (models.Alert, error) {
+	defer m.lock()()
+	for i := len(m.data.alerts) - 1; i >= 0; i-- {
+		if match(m.data.alerts[i]) {
+			return m.data.alerts[i], nil
+		}
+	}
+	return models.Alert{}, ErrNotFound
+}
+
+func (m *memory) dueAlerts(now time.Time, limit int) []models.Alert {
+	defer m.lock()()
+	var alerts []models.Alert
+	for _, alert := range m.data.alerts {
+		if alert.AcknowledgedAt == nil && alert.NextStepAt != nil && !alert.NextStepAt.After(now) {
+			alerts = append(alerts, alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].NextStepAt.Before(*alerts[j].NextStepAt) })
+	return alerts[:min(len(alerts), limit)]
+}
+
+func (m *memory) insertAlert(alert models.Alert) models.Alert {
+	defer m.lock()()
+	alert.ID = m.nextID("alerts")
+	alert.CreatedAt = time.Now()
+	m.data.alerts = append(m.data.alerts, alert)
+	id := alert.ID
+	m.onRollback(func(data *memoryData) {
+		data.alerts = slices.DeleteFunc(data.alerts, func(alert models.Alert) bool { return alert.ID == id })
+	})
+	return alert
+}
+
+func (m *memory) advanceAlert(id int64, from, to int, next *time.Time) error {
+	defer m.lock()()
+	for i := range m.data.alerts {
+		alert := &m.data.alerts[i]
+		if alert.ID != id {
+			continue
+		}
+		if alert.Step != from || alert.AcknowledgedAt != nil {
+			return ErrConflict
+		}
+		previous := *alert
+		alert.Step, alert.NextStepAt = to, next
+		m.onRollback(func(data *memoryData) {
+			if i := slices.IndexFunc(data.alerts, func(alert models.Alert) bool { return alert.ID == id }); i >= 0 {
+				data.alerts[i] = previous
+			}
+		})
+		return nil
+	}
+	return ErrConflict
+}
+
+func (m *memory) acknowledgeAlert(id int64, by string, at time.Time) (models.Alert, error) {
+	defer m.lock()()
+	for i := range m.data.alerts {
+		alert := &m.data.alerts[i]
+		if alert.ID != id {
+			continue
+		}
+		if alert.AcknowledgedAt == nil {
+			alert.AcknowledgedAt, alert.AcknowledgedBy, alert.NextStepAt = &at, by, nil
+		}
+		return *alert, nil
+	}
+	return models.Alert{}, ErrNotFound
+}
+
+func (m *memory) ensureScheduledJob(name, schedule string) {
+	defer m.lock()()
+	for _, job := range m.data.ScheduledJobs {
+		if job.Name == name {
+			return
+		}
+	}
+	m.data.ScheduledJobs = append(m.data.ScheduledJobs, models.ScheduledJob{Name: name, Schedule: schedule, Enabled: true})
+	sort.Slice(m.data.ScheduledJobs, func(i, j int) bool { return m.data.ScheduledJobs[i].Name < m.data.ScheduledJobs[j].Name })
+}
+
+func (m *memory) listScheduledJobs() []models.ScheduledJob {
+	defer m.lock()()
+	return slices.Clone(m.data.ScheduledJobs)
+}
+
+func (m *memory) updateScheduledJob(name string, update func(*models.ScheduledJob)) {
+	defer m.lock()()
+	for i := range m.data.ScheduledJobs {
+		if m.data.ScheduledJobs[i].Name == name {
+			update(&m.data.ScheduledJobs[i])
+			return
+		}
+	}
+}
+
+func (m *memory) addUsage(counters []models.UsageCounter) {
+	defer m.lock()()
+	for _, c := range counters {
+		key := usageKey{bucket: c.BucketStart.Unix(), tenant: c.Tenant, key: c.Caller}
+		total, ok := m.data.usage[key]
+		if !ok {
+			m.data.usage[key] = c
+			continue
+		}
+		total.Requests += c.Requests
+		total.Errors += c.Errors
+		total.BytesIn += c.BytesIn
+		total.BytesOut += c.BytesOut
+		m.data.usage[key] = total
+	}
+}
+
+func (m *memory) usageReport(from, to time.Time, byKey bool) []models.UsageRow {
+	defer m.lock()()
+	rows := map[[2]string]*models.UsageRow{}
+	var report []models.UsageRow
+	for _, c := range m.data.usage {
+		if c.BucketStart.Before(from) || !c.BucketStart.Before(to) {
+			continue
+		}
+		group := [2]string{c.Tenant, ""}
+		if byKey {
+			group[1] = c.Caller
+		}
+		row, ok := rows[group]
+		if !ok {
+			row = &models.UsageRow{Tenant: group[0], Caller: group[1]}
+			rows[group] = row
+		}
+		row.Requests += c.Requests
+		row.Errors += c.Errors
+		row.BytesIn += c.BytesIn
+		row.BytesOut += c.BytesOut
+	}
+	for _, row := range rows {
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Requests > report[j].Requests })
+	return report
+}
+
+func (m *memory) enqueueJob(kind string, payload []byte) models.Job {
+	defer m.lock()()
+	job := models.Job{
+		ID:        m.nextID("jobs"),
+		Kind:      kind,
+		Status:    models.JobQueued,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+	m.data.jobs = append(m.data.jobs, job)
+	return job
+}
+
+func (m *memory) job(id int64) *models.Job {
+	for i := range m.data.jobs {
+		if m.data.jobs[i].ID == id {
+			return &m.data.jobs[i]
+		}
+	}
+	return nil
+}
+
+func (m *memory) jobByID(id int64) (models.Job, error) {
+	defer m.lock()()
+	if job := m.job(id); job != nil {
+		return *job, nil
+	}
+	return models.Job{}, ErrNotFound
+}
+
+func (m *memory) claimJob(staleBefore time.Time, maxAttempts int) (models.Job, error) {
+	defer m.lock()()
+	for i := range m.data.jobs {
+		job := &m.data.jobs[i]
+		stale := job.Status == models.JobRunning && job.StartedAt != nil && job.StartedAt.Before(staleBefore)
+		if (job.Status != models.JobQueued && !stale) || job.Attempts >= maxAttempts {
+			continue
+		}
+		now := time.Now()
+		job.Status = models.JobRunning
+		job.Attempts++
+		job.Progress = 0
+		job.StartedAt = &now
+		return *job, nil
+	}
+	return models.Job{}, ErrNotFound
+}
+
+func (m *memory) updateJobProgress(id int64, progress int) {
+	defer m.lock()()
+	if job := m.job(id); job != nil && job.Status == models.JobRunning {
+		job.Progress = progress
+	}
+}
+
+func (m *memory) finishJob(id int64, status string, result []byte, message string) {
+	defer m.lock()()
+	job := m.job(id)
+	if job == nil {
+		return
+	}
+	now := time.Now()
+	job.Status = status
+	if status == models.JobSucceeded {
+		job.Progress = 100
+	}
+	job.Result = result
+	job.Error = message
+	job.FinishedAt = &now
+}
+
+func (m *memory) insertUser(user models.User) (models.User, error) {
+	defer m.lock()()
+	for _, existing := range m.data.users {
+		if existing.Username == user.Username {
+			return models.User{}, ErrConflict
+		}
+	}
+	if user.Role == "" {
+		user.Role = models.RoleViewer
+	}
+	user.ID = m.nextID("users")
+	user.Disabled = false
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.FailedLogins, user.LockedUntil = 0, nil
+	user.CreatedAt = time.Now()
+	m.data.users = append(m.data.users, user)
+	m.onRollback(func(data *memoryData) {
+		data.users = slices.DeleteFunc(data.users, func(u models.User) bool { return u.ID == user.ID })
+	})
+	return user, nil
+}
+
+func (m *memory) user(match func(models.User) bool) (models.User, error) {
+	defer m.lock()()
+	for _, user := range m.data.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (m *memory) listUsers() []models.User {
+	defer m.lock()()
+	return slices.Clone(m.data.users)
+}
+
+// updateUser applies update to a user and reports whether it matched.
+func (m *memory) updateUser(id int64, update func(*models.User) bool) bool {
+	defer m.lock()()
+	for i := range m.data.users {
+		if m.data.users[i].ID == id {
+			return update(&m.data.users[i])
+		}
+	}
+	return false
+}
+
+func (m *memory) insertSession(session models.Session) models.Session {
+	defer m.lock()()
+	session.ID = m.nextID("sessions")
+	session.RevokedAt = nil
+	m.data.sessions = append(m.data.sessions, session)
+	return session
+}
+
+func (m *memory) sessionByRefreshHash(hash string) (models.Session, error) {
+	defer m.lock()()
+	for _, session := range m.data.sessions {
+		if session.RefreshHash == hash {
+			return session, nil
+		}
+	}
+	return models.Session{}, ErrNotFound
+}
+
+func (m *memory) activePrincipal(sessionID int64, now time.Time) (models.Principal, error) {
+	defer m.lock()()
+	for _, session := range m.data.sessions {
+		if session.ID != sessionID || session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+			continue
+		}
+		for _, user := range m.data.users {
+			if user.ID == session.UserID && !user.Disabled {
+				return models.Principal{
+					UserID:      user.ID,
+					Username:    user.Username,
+					Role:        user.Role,
+					SessionID:   session.ID,
+					TOTPEnabled: user.TOTPEnabled,
+					Locale:      user.Locale,
+					Timezone:    user.Timezone,
+				}, nil
+			}
+		}
+	}
+	return models.Principal{}, ErrNotFound
+}
+
+// updateSession applies update to the sessions matching match and reports
+// whether any did.
+func (m *memory) updateSession(match func(models.Session) bool, update func(*models.Session)) bool {
+	defer m.lock()()
+	found := false
+	for i := range m.data.sessions {
+		if match(m.data.sessions[i]) {
+			update(&m.data.sessions[i])
+			found = true
+		}
+	}
+	return found
+}
+
+func (m *memory) listUserSessions(userID int64, now time.Time) []models.Session {
+	defer m.lock()()
+	var sessions []models.Session
+	for _, session := range m.data.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt) })
+	return sessions
+}
+
+func (m *memory) eraseUserData(user models.User) models.ErasureReport {
+	defer m.lock()()
+	var report models.ErasureReport
+	deleted := func(before, after int) int64 { return int64(before - after) }
+
+	before := len(m.data.sessions)
+	m.data.sessions = slices.DeleteFunc(m.data.sessions, func(session models.Session) bool { return session.UserID == user.ID })
+	report.SessionsDeleted = deleted(before, len(m.data.sessions))
+	for i := range m.data.embedTokens {
+		if createdBy := m.data.embedTokens[i].CreatedBy; createdBy != nil && *createdBy == user.ID {
+			m.data.embedTokens[i].CreatedBy = nil
+			report.EmbedTokensUnlinked++
+		}
+	}
+	delete(m.data.preferences, user.ID)
+
+	var authored []int64
+	before = len(m.data.Insights)
+	m.data.Insights = slices.DeleteFunc(m.data.Insights, func(row memoryInsight) bool {
+		if row.Author != user.Username {
+			return false
+		}
+		authored = append(authored, row.ID)
+		return true
+	})
+	report.InsightsDeleted = deleted(before, len(m.data.Insights))
+	m.data.assignments = slices.DeleteFunc(m.data.assignments, func(a models.InsightAssignment) bool {
+		return slices.Contains(authored, a.InsightID)
+	})
+	before = len(m.data.assignments)
+	m.data.assignments = slices.DeleteFunc(m.data.assignments, func(a models.InsightAssignment) bool { return a.UserID == user.ID })
+	report.AssignmentsDeleted = deleted(before, len(m.data.assignments))
+
+	before = len(m.data.audit)
+	m.data.audit = slices.DeleteFunc(m.data.audit, func(event models.AuditEvent) bool {
+		return (event.UserID != nil && *event.UserID == user.ID) || event.Actor == user.Username
+	})
+	report.AuditEntriesDeleted = deleted(before, len(m.data.audit))
+	before = len(m.data.invitations)
+	m.data.invitations = slices.DeleteFunc(m.data.invitations, func(inv models.Invitation) bool {
+		return inv.UserID != nil && *inv.UserID == user.ID
+	})
+	report.InvitationsDeleted = deleted(before, len(m.data.invitations))
+
+	for i := range m.data.Silences {
+		if silence := &m.data.Silences[i]; silence.CreatedBy == user.Username {
+			silence.CreatedBy, silence.Comment = "", ""
+			report.SilencesUnlinked++
+		}
+	}
+	for i := range m.data.alerts {
+		if alert := &m.data.alerts[i]; alert.AcknowledgedBy == user.Username {
+			alert.AcknowledgedBy = ""
+			report.RecordsUnlinked++
+		}
+	}
+	for i := range m.data.assignments {
+		if a := &m.data.assignments[i]; a.AssignedBy == user.Username {
+			a.AssignedBy = ""
+			report.RecordsUnlinked++
+		}
+	}
+	for i := range m.data.invitations {
+		if inv := &m.data.invitations[i]; inv.InvitedBy == user.Username {
+			inv.InvitedBy = ""
+			report.RecordsUnlinked++
+		}
+	}
+	for i := range m.data.users {
+		if u := &m.data.users[i]; u.ID == user.ID {
+			u.DisplayName, u.Locale, u.Timezone, u.AvatarURL = "", "", "", ""
+		}
+	}
+	return report
+}
+
+func (m *memory) insertEmbedToken(token models.EmbedToken) models.EmbedToken {
+	defer m.lock()()
+	token.ID = m.nextID("embed_tokens")
+	token.Metrics = slices.Clone(token.Metrics)
+	token.RevokedAt = nil
+	m.data.embedTokens = append(m.data.embedTokens, token)
+	return token
+}
+
+func (m *memory) activeEmbedToken(hash string, now time.Time) (models.EmbedToken, error) {
+	defer m.lock()()
+	for _, token := range m.data.embedTokens {
+		if token.TokenHash == hash && token.RevokedAt == nil && token.ExpiresAt.After(now) {
+			return token, nil
+		}
+	}
+	return models.EmbedToken{}, ErrNotFound
+}
+
+func (m *memory) listEmbedTokens() []models.EmbedToken {
+	defer m.lock()()
+	tokens := slices.Clone(m.data.embedTokens)
+	slices.Reverse(tokens)
+	if tokens == nil {
+		tokens = []models.EmbedToken{}
+	}
+	return tokens
+}
+
+func (m *memory) revokeEmbedToken(id int64, at time.Time) error {
+	defer m.lock()()
+	for i := range m.data.embedTokens {
+		if token := &m.data.embedTokens[i]; token.ID == id && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *memory) insertInvitation(inv models.Invitation) models.Invitation {
+	defer m.lock()()
+	inv.ID = m.nextID("invitations")
+	m.data.invitations = append(m.data.invitations, inv)
+	return inv
+}
+
+func (m *memory) listInvitations() []models.Invitation {
+	defer m.lock()()
+	invitations := slices.Clone(m.data.invitations)
+	slices.Reverse(invitations)
+	return invitations
+}
+
+func (m *memory) invitationByHash(hash string) (models.Invitation, error) {
+	defer m.lock()()
+	for _, inv := range m.data.invitations {
+		if inv.TokenHash == hash {
+			return inv, nil
+		}
+	}
+	return models.Invitation{}, ErrNotFound
+}
+
+func (m *memory) acceptInvitation(id, userID int64, at time.Time) error {
+	defer m.lock()()
+	for i := range m.data.invitations {
+		inv := &m.data.invitations[i]
+		if inv.ID != id || inv.AcceptedAt != nil || inv.RevokedAt != nil || !inv.ExpiresAt.After(at) {
+			continue
+		}
+		inv.AcceptedAt, inv.UserID = &at, &userID
+		m.onRollback(func(data *memoryData) {
+			for i := range data.invitations {
+				if data.invitations[i].ID == id {
+					data.invitations[i].AcceptedAt, data.invitations[i].UserID = nil, nil
+				}
+			}
+		})
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (m *memory) revokeInvitation(id int64, at time.Time) error {
+	defer m.lock()()
+	for i := range m.data.invitations {
+		if inv := &m.data.invitations[i]; inv.ID == id && inv.AcceptedAt == nil && inv.RevokedAt == nil {
+			inv.RevokedAt = &at
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *memory) syncCursor(node, segment string) int {
+	defer m.lock()()
+	return m.data.syncCursors[[2]string{node, segment}]
+}
+
+func (m *memory) setSyncCursor(node, segment string, acked int) {
+	defer m.lock()()
+	m.data.syncCursors[[2]string{node, segment}] = acked
+}
+
+func (m *memory) listWidgets() []models.Widget {
+	defer m.lock()()
+	widgets := slices.Clone(m.data.Widgets)
+	sort.Slice(widgets, func(i, j int) bool { return widgets[i].Name < widgets[j].Name })
+	return widgets
+}
+
+func (m *memory) widgetByName(name string) (models.Widget, error) {
+	defer m.lock()()
+	for _, widget := range m.data.Widgets {
+		if widget.Name == name {
+			return widget, nil
+		}
+	}
+	return models.Widget{}, ErrNotFound
+}
+
+func (m *memory) saveWidget(widget models.Widget) {
+	defer m.lock()()
+	widget.UpdatedAt = time.Now()
+	for i, existing := range m.data.Widgets {
+		if existing.Name == widget.Name {
+			widget.CreatedAt = existing.CreatedAt
+			m.data.Widgets[i] = widget
+			return
+		}
+	}
+	widget.CreatedAt = widget.UpdatedAt
+	m.data.Widgets = append(m.data.Widgets, widget)
+}
+
+func (m *memory) deleteWidget(name string) error {
+	defer m.lock()()
+	before := len(m.data.Widgets)
+	m.data.Widgets = slices.DeleteFunc(m.data.Widgets, func(widget models.Widget) bool { return widget.Name == name })
+	if len(m.data.Widgets) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) listMetricMeta() []models.MetricMetaOverride {
+	defer m.lock()()
+	items := slices.Clone(m.data.MetricMeta)
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Tenant != items[j].Tenant {
+			return items[i].Tenant < items[j].Tenant
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+func (m *memory) saveMetricMeta(item models.MetricMetaOverride) {
+	defer m.lock()()
+	item.UpdatedAt = time.Now()
+	for i, existing := range m.data.MetricMeta {
+		if existing.Tenant == item.Tenant && existing.Key == item.Key {
+			m.data.MetricMeta[i] = item
+			return
+		}
+	}
+	m.data.MetricMeta = append(m.data.MetricMeta, item)
+}
+
+func (m *memory) deleteMetricMeta(tenant, key string) error {
+	defer m.lock()()
+	before := len(m.data.MetricMeta)
+	m.data.MetricMeta = slices.DeleteFunc(m.data.MetricMeta, func(item models.MetricMetaOverride) bool {
+		return item.Tenant == tenant && item.Key == key
+	})
+	if len(m.data.MetricMeta) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) tenantSettings(tenant string) (models.TenantSettings, error) {
+	defer m.lock()()
+	for _, settings := range m.data.TenantSettings {
+		if settings.Tenant == tenant {
+			return settings, nil
+		}
+	}
+	return models.TenantSettings{}, ErrNotFound
+}
+
+func (m *memory) saveTenantSettings(settings models.TenantSettings) {
+	defer m.lock()()
+	now := time.Now()
+	settings.UpdatedAt = &now
+	for i, existing := range m.data.TenantSettings {
+		if existing.Tenant == settings.Tenant {
+			m.data.TenantSettings[i] = settings
+			return
+		}
+	}
+	m.data.TenantSettings = append(m.data.TenantSettings, settings)
+}
+
+func (m *memory) deleteTenantSettings(tenant string) error {
+	defer m.lock()()
+	before := len(m.data.TenantSettings)
+	m.data.TenantSettings = slices.DeleteFunc(m.data.TenantSettings, func(settings models.TenantSettings) bool {
+		return settings.Tenant == tenant
+	})
+	if len(m.data.TenantSettings) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) taggedInsights(locale string, tags []string, limit int) []models.Insight {
+	return m.findInsights(func(row memoryInsight) bool {
+		if row.Locale != locale || row.DeletedAt != nil {
+			return false
+		}
+		for _, tag := range tags {
+			if !slices.Contains(row.Tags, tag) {
+				return false
+			}
+		}
+		return true
+	}, true, limit)
+}
+
+func (m *memory) insightTags() []models.InsightTag {
+	defer m.lock()()
+	counts := map[string]int{}
+	for _, row := range m.data.Insights {
+		if row.DeletedAt != nil {
+			continue
+		}
+		for _, tag := range row.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]models.InsightTag, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, models.InsightTag{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags
+}
+
+func (m *memory) setInsightTags(id int64, tags []string) error {
+	defer m.lock()()
+	i := m.insight(id)
+	if i < 0 || m.data.Insights[i].DeletedAt != nil {
+		return ErrNotFound
+	}
+	m.data.Insights[i].Tags = slices.Clone(tags)
+	return nil
+}
+
+func (m *memory) renameInsightTag(from, to string) (int64, error) {
+	defer m.lock()()
+	var renamed int64
+	for i := range m.data.Insights {
+		row := &m.data.Insights[i]
+		at := slices.Index(row.Tags, from)
+		if at < 0 {
+			continue
+		}
+		renamed++
+		if slices.Contains(row.Tags, to) {
+			row.Tags = slices.Delete(row.Tags, at, at+1)
+		} else {
+			row.Tags[at] = to
+			sort.Strings(row.Tags)
+		}
+	}
+	if renamed == 0 {
+		return 0, ErrNotFound
+	}
+	return renamed, nil
+}
+
+func (m *memory) deleteInsightTag(tag string) (int64, error) {
+	defer m.lock()()
+	var deleted int64
+	for i := range m.data.Insights {
+		row := &m.data.Insights[i]
+		if at := slices.Index(row.Tags, tag); at >= 0 {
+			row.Tags = slices.Delete(row.Tags, at, at+1)
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return 0, ErrNotFound
+	}
+	return deleted, nil
+}
+
+func (m *memory) saveAssignment(a models.InsightAssignment) {
+	defer m.lock()()
+	now := time.Now()
+	a.CreatedAt, a.UpdatedAt = now, now
+	previous := slices.Clone(m.data.assignments)
+	m.onRollback(func(data *memoryData) { data.assignments = previous })
+	for i := range m.data.assignments {
+		if m.data.assignments[i].InsightID == a.InsightID {
+			m.data.assignments[i] = a
+			return
+		}
+	}
+	m.data.assignments = append(m.data.assignments, a)
+}
+
+// withUsername fills in the assignee's username; the caller holds the lock.
+func (m *memory) withUsername(a models.InsightAssignment) models.InsightAssignment {
+	for _, user := range m.data.users {
+		if user.ID == a.UserID {
+			a.Username = user.Username
+		}
+	}
+	return a
+}
+
+func (m *memory) assignmentByInsight(insightID int64) (models.InsightAssignment, error) {
+	defer m.lock()()
+	for _, a := range m.data.assignments {
+		if a.InsightID == insightID {
+			return m.withUsername(a), nil
+		}
+	}
+	return models.InsightAssignment{}, ErrNotFound
+}
+
+func (m *memory) setAssignmentStatus(insightID int64, status string, doneAt *time.Time) error {
+	defer m.lock()()
+	for i := range m.data.assignments {
+		if a := &m.data.assignments[i]; a.InsightID == insightID {
+			a.Status, a.DoneAt, a.UpdatedAt = status, doneAt, time.Now()
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *memory) deleteAssignment(insightID int64) error {
+	defer m.lock()()
+	before := len(m.data.assignments)
+	m.data.assignments = slices.DeleteFunc(m.data.assignments, func(a models.InsightAssignment) bool { return a.InsightID == insightID })
+	if len(m.data.assignments) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) listAssignments(match func(models.InsightAssignment) bool, statuses []string, limit int) []models.InsightAssignment {
+	defer m.lock()()
+	var out []models.InsightAssignment
+	for _, a := range m.data.assignments {
+		if !match(a) || (len(statuses) > 0 && !slices.Contains(statuses, a.Status)) {
+			continue
+		}
+		i := m.insight(a.InsightID)
+		if i < 0 || m.data.Insights[i].DeletedAt != nil {
+			continue
+		}
+		insight := m.data.Insights[i].Insight
+		insight.Metrics = slices.Clone(insight.Metrics)
+		insight.Tags = slices.Clone(insight.Tags)
+		a = m.withUsername(a)
+		a.Insight = &insight
+		out = append(out, a)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].DueAt, out[j].DueAt
+		switch {
+		case a == nil || b == nil:
+			return a != nil && b == nil
+		case !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out[:min(len(out), limit)]
+}
+
+func (m *memory) notificationPreferences(userID int64) (models.NotificationPreferences, error) {
+	defer m.lock()()
+	prefs, ok := m.data.preferences[userID]
+	if !ok {
+		return models.NotificationPreferences{}, ErrNotFound
+	}
+	prefs.Rules = slices.Clone(prefs.Rules)
+	return prefs, nil
+}
+
+func (m *memory) activeNotificationPreferences() []models.NotificationPreferences {
+	defer m.lock()()
+	var out []models.NotificationPreferences
+	for _, user := range m.data.users {
+		if prefs, ok := m.data.preferences[user.ID]; ok && !user.Disabled {
+			prefs.Rules = slices.Clone(prefs.Rules)
+			out = append(out, prefs)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+	return out
+}
+
+func (m *memory) saveNotificationPreferences(prefs models.NotificationPreferences) {
+	defer m.lock()()
+	prefs.Rules = slices.Clone(prefs.Rules)
+	prefs.UpdatedAt = time.Now()
+	m.data.preferences[prefs.UserID] = prefs
+}
+
+func (m *memory) listOrgs() []models.Org {
+	defer m.lock()()
+	orgs := slices.Clone(m.data.Orgs)
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].Name < orgs[j].Name })
+	return orgs
+}
+
+func (m *memory) orgByID(id int64) (models.Org, error) {
+	defer m.lock()()
+	for _, org := range m.data.Orgs {
+		if org.ID == id {
+			return org, nil
+		}
+	}
+	return models.Org{}, ErrNotFound
+}
+
+func (m *memory) saveOrg(org models.Org) (models.Org, error) {
+	defer m.lock()()
+	index := -1
+	for i, existing := range m.data.Orgs {
+		if existing.Name == org.Name && existing.ID != org.ID {
+			return models.Org{}, ErrConflict
+		}
+		if existing.ID == org.ID {
+			index = i
+		}
+	}
+	now := time.Now()
+	org.UpdatedAt = now
+	switch {
+	case org.ID == 0:
+		org.ID = m.nextID("orgs")
+		org.CreatedAt = now
+		m.data.Orgs = append(m.data.Orgs, org)
+	case index < 0:
+		return models.Org{}, ErrNotFound
+	default:
+		org.CreatedAt = m.data.Orgs[index].CreatedAt
+		m.data.Orgs[index] = org
+	}
+	return org, nil
+}
+
+func (m *memory) deleteOrg(id int64) error {
+	defer m.lock()()
+	before := len(m.data.Orgs)
+	m.data.Orgs = slices.DeleteFunc(m.data.Orgs, func(org models.Org) bool { return org.ID == id })
+	if len(m.data.Orgs) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) listTeams(orgID int64) []models.Team {
+	defer m.lock()()
+	var teams []models.Team
+	for _, team := range m.data.Teams {
+		if orgID == 0 || team.OrgID == orgID {
+			teams = append(teams, team)
+		}
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		if teams[i].OrgID != teams[j].OrgID {
+			return teams[i].OrgID < teams[j].OrgID
+		}
+		return teams[i].Name < teams[j].Name
+	})
+	return teams
+}
+
+func (m *memory) teamByID(id int64) (models.Team, error) {
+	defer m.lock()()
+	for _, team := range m.data.Teams {
+		if team.ID == id {
+			return team, nil
+		}
+	}
+	return models.Team{}, ErrNotFound
+}
+
+func (m *memory) saveTeam(team models.Team) (models.Team, error) {
+	defer m.lock()()
+	index := -1
+	for i, existing := range m.data.Teams {
+		if existing.ID == team.ID {
+			index = i
+			// The org of a team does not change, as in the UPDATE query.
+			team.OrgID = existing.OrgID
+		}
+	}
+	for _, existing := range m.data.Teams {
+		if existing.OrgID == team.OrgID && existing.Name == team.Name && existing.ID != team.ID {
+			return models.Team{}, ErrConflict
+		}
+	}
+	now := time.Now()
+	team.UpdatedAt = now
+	switch {
+	case team.ID == 0:
+		team.ID = m.nextID("teams")
+		team.CreatedAt = now
+		m.data.Teams = append(m.data.Teams, team)
+	case index < 0:
+		return models.Team{}, ErrNotFound
+	default:
+		team.CreatedAt = m.data.Teams[index].CreatedAt
+		m.data.Teams[index] = team
+	}
+	return team, nil
+}
+
+// deleteTeam follows the foreign keys: memberships go with the team and
+// whatever was scoped to it loses its team_id.
+func (m *memory) deleteTeam(id int64) error {
+	defer m.lock()()
+	before := len(m.data.Teams)
+	m.data.Teams = slices.DeleteFunc(m.data.Teams, func(team models.Team) bool { return team.ID == id })
+	if len(m.data.Teams) == before {
+		return ErrNotFound
+	}
+	m.data.teamMembers = slices.DeleteFunc(m.data.teamMembers, func(member models.TeamMember) bool { return member.TeamID == id })
+	unscope := func(teamID **int64) {
+		if *teamID != nil && **teamID == id {
+			*teamID = nil
+		}
+	}
+	for i := range m.data.Widgets {
+		unscope(&m.data.Widgets[i].TeamID)
+	}
+	for i := range m.data.InsightRules {
+		unscope(&m.data.InsightRules[i].TeamID)
+	}
+	for i := range m.data.assignments {
+		unscope(&m.data.assignments[i].TeamID)
+	}
+	for i := range m.data.invitations {
+		unscope(&m.data.invitations[i].TeamID)
+	}
+	return nil
+}
+
+func (m *memory) teamMembers(teamID, userID int64) []models.TeamMember {
+	defer m.lock()()
+	var members []models.TeamMember
+	for _, member := range m.data.teamMembers {
+		if (teamID != 0 && member.TeamID != teamID) || (userID != 0 && member.UserID != userID) {
+			continue
+		}
+		for _, user := range m.data.users {
+			if user.ID == member.UserID {
+				member.Username, member.DisplayName = user.Username, user.DisplayName
+			}
+		}
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].TeamID != members[j].TeamID {
+			return members[i].TeamID < members[j].TeamID
+		}
+		return members[i].Username < members[j].Username
+	})
+	return members
+}
+
+func (m *memory) saveTeamMember(member models.TeamMember) {
+	defer m.lock()()
+	for i, existing := range m.data.teamMembers {
+		if existing.TeamID == member.TeamID && existing.UserID == member.UserID {
+			m.data.teamMembers[i].Role = member.Role
+			return
+		}
+	}
+	member.CreatedAt = time.Now()
+	m.data.teamMembers = append(m.data.teamMembers, member)
+}
+
+func (m *memory) deleteTeamMember(teamID, userID int64) error {
+	defer m.lock()()
+	before := len(m.data.teamMembers)
+	m.data.teamMembers = slices.DeleteFunc(m.data.teamMembers, func(member models.TeamMember) bool {
+		return member.TeamID == teamID && member.UserID == userID
+	})
+	if len(m.data.teamMembers) == before {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memory) insertAuditEvent(event models.AuditEvent) {
+	defer m.lock()()
+	event.ID = m.nextID("audit_log")
+	m.data.audit = append(m.data.audit, event)
+}
+
+func (m *memory) listAuditEvents(q models.AuditQuery) []models.AuditEvent {
+	defer m.lock()()
+	var events []models.AuditEvent
+	for i := len(m.data.audit) - 1; i >= 0 && len(events) < q.Limit; i-- {
+		e := m.data.audit[i]
+		if (q.Action == "" || e.Action == q.Action) &&
+			(q.UserID == 0 || (e.UserID != nil && *e.UserID == q.UserID)) &&
+			!e.CreatedAt.Before(q.Since) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"mydashboard-backend/internal/models"
+)
+
+// metricMetaColumn is the part of an override kept in the meta column.
+type metricMetaColumn struct {
+	Labels       map[string]string        `json:"labels,omitempty"`
+	Descriptions map[string]string        `json:"descriptions,omitempty"`
+	Unit         string                   `json:"unit,omitempty"`
+	Color        string                   `json:"color,omitempty"`
+	Thresholds   []models.MetricThreshold `json:"thresholds,omitempty"`
+}
+
+// ListMetricMeta returns the overrides of every tenant.
+func (s *Store) ListMetricMeta(ctx context.Context) ([]models.MetricMetaOverride, error) {
+	if s.mem != nil {
+		return s.mem.listMetricMeta(), nil
+	}
+	const query = `
+		SELECT tenant, metric_key, meta, updated_at
+		FROM metric_meta
+		ORDER BY tenant, metric_key
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list metric meta", err)
+	}
+	defer rows.Close()
+
+	var items []models.MetricMetaOverride
+	for rows.Next() {
+		var item models.MetricMetaOverride
+		var raw []byte
+		if err := rows.Scan(&item.Tenant, &item.Key, &raw, &item.UpdatedAt); err != nil {
+			return nil, s.done("list metric meta", err)
+		}
+		var column metricMetaColumn
+		if err := json.Unmarshal(raw, &column); err != nil {
+			return nil, s.done("list metric meta", err)
+		}
+		item.Labels, item.Descriptions, item.Unit = column.Labels, column.Descriptions, column.Unit
+		item.Color, item.Thresholds = column.Color, column.Thresholds
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list metric meta", err)
+	}
+	s.breaker.Record(nil)
+	return items, nil
+}
+
+// SaveMetricMeta creates or replaces the override of item.Key for
+// item.Tenant.
+func (s *Store) SaveMetricMeta(ctx context.Context, item models.MetricMetaOverride) error {
+	if s.mem != nil {
+		s.mem.saveMetricMeta(item)
+		return nil
+	}
+	const query = `
+		INSERT INTO metric_meta (tenant, metric_key, meta)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE meta = VALUES(meta)
+	`
+	raw, err := json.Marshal(metricMetaColumn{
+		Labels:       item.Labels,
+		Descriptions: item.Descriptions,
+		Unit:         item.Unit,
+		Color:        item.Color,
+		Thresholds:   item.Thresholds,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, query, item.Tenant, item.Key, raw)
+	return s.done("save metric meta", err)
+}
+
+func (s *Store) DeleteMetricMeta(ctx context.Context, tenant, key string) error {
+	if s.mem != nil {
+		return s.mem.deleteMetricMeta(tenant, key)
+	}
+	const query = `
+		DELETE FROM metric_meta
+		WHERE tenant = ? AND metric_key = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, tenant, key)
+	if err := s.done("delete metric meta", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+// MetricsIterator walks snapshots in a time range in created_at order, one
+// keyset page at a time, so long ranges never sit in memory at once.
+type MetricsIterator struct {
+	store    *Store
+	to       time.Time
+	pageSize int
+
+	page    []models.MetricSnapshot
+	pos     int
+	afterAt time.Time
+	afterID int64
+	done    bool
+	current models.Metrics
+	err     error
+}
+
+// IterateMetrics returns an iterator over snapshots with
+// from <= created_at <= to. Each page is a separate query bounded by the
+// query timeout.
+func (s *Store) IterateMetrics(from, to time.Time, pageSize int) *MetricsIterator {
+	if pageSize <= 0 {
+		pageSize = 1000
+	}
+	// A zero id sorts before every row, so the first page starts at from.
+	return &MetricsIterator{store: s, to: to, pageSize: pageSize, afterAt: from}
+}
+
+// Next advances to the next snapshot, fetching a new page when needed. It
+// returns false at the end of the range or on error.
+func (it *MetricsIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.pos >= len(it.page) {
+		if it.done {
+			return false
+		}
+		if it.err = it.fetch(ctx); it.err != nil || len(it.page) == 0 {
+			return false
+		}
+	}
+	row := it.page[it.pos]
+	it.current, it.afterAt, it.afterID = row.Metrics, row.CreatedAt, row.ID
+	it.pos++
+	return true
+}
+
+func (it *MetricsIterator) Metrics() models.Metrics {
+	return it.current
+}
+
+// ID is the row id of the current snapshot.
+func (it *MetricsIterator) ID() int64 {
+	return it.afterID
+}
+
+func (it *MetricsIterator) Err() error {
+	return it.err
+}
+
+func (it *MetricsIterator) fetch(ctx context.Context) error {
+	page, err := it.store.MetricsAfter(ctx, it.afterAt, it.afterID, it.to, it.pageSize)
+	if err != nil {
+		return err
+	}
+	it.page, it.pos, it.done = page, 0, len(page) < it.pageSize
+	return nil
+}
+
+// MetricsAfter returns up to limit snapshots ordered by (created_at, id) that
+// come after the keyset position (afterAt, afterID) and no later than to. A
+// zero afterID includes the rows created exactly at afterAt.
+func (s *Store) MetricsAfter(ctx context.Context, afterAt time.Time, afterID int64, to time.Time, limit int) ([]models.MetricSnapshot, error) {
+	const query = `
+		SELECT id, revenue, growth, sentiment, backlog, created_at, derived, valid_until
+		FROM metrics_snapshot
+		WHERE (created_at > ? OR (created_at = ? AND id > ?)) AND created_at <= ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT ?
+	`
+	if s.mem != nil {
+		return s.mem.metricsAfter(afterAt, afterID, to, limit), nil
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, afterAt, afterAt, afterID, to, limit)
+	if err != nil {
+		return nil, s.done("metrics after", err)
+	}
+	defer rows.Close()
+
+	var page []models.MetricSnapshot
+	for rows.Next() {
+		var row models.MetricSnapshot
+		var derived []byte
+		if err := rows.Scan(
+			&row.ID,
+			&row.Revenue,
+			&row.Growth,
+			&row.Sentiment,
+			&row.Backlog,
+			&row.CreatedAt,
+			&derived,
+			&row.ValidUntil,
+		); err != nil {
+			return nil, s.done("metrics after", err)
+		}
+		if err := decodeDerived(derived, &row.Metrics); err != nil {
+			return nil, s.done("metrics after", err)
+		}
+		page = append(page, row)
+	}
+	return page, s.done("metrics after", rows.Err())
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"mydashboard-backend/internal/models"
+)
+
+const notificationChannelColumns = "id, name, kind, webhook_url, secret, event_types, min_severity, enabled, created_at, updated_at"
+
+func scanNotificationChannel(row rowScanner) (models.NotificationChannel, error) {
+	var channel models.NotificationChannel
+	var eventTypes string
+	err := row.Scan(
+		&channel.ID,
+		&channel.Name,
+		&channel.Kind,
+		&channel.WebhookURL,
+		&channel.Secret,
+		&eventTypes,
+		&channel.MinSeverity,
+		&channel.Enabled,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+	channel.EventTypes = strings.Split(eventTypes, ",")
+	channel.HasSecret = channel.Secret != ""
+	return channel, err
+}
+
+func (s *Store) ListNotificationChannels(ctx context.Context, enabledOnly bool) ([]models.NotificationChannel, error) {
+	if s.mem != nil {
+		return s.mem.listNotificationChannels(enabledOnly), nil
+	}
+	query := `
+		SELECT ` + notificationChannelColumns + `
+		FROM notification_channels
+	`
+	if enabledOnly {
+		query += " WHERE enabled = 1"
+	}
+	query += " ORDER BY id"
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list notification channels", err)
+	}
+	defer rows.Close()
+
+	var channels []models.NotificationChannel
+	for rows.Next() {
+		channel, err := scanNotificationChannel(rows)
+		if err != nil {
+			return nil, s.done("list notification channels", err)
+		}
+		channels = append(channels, channel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list notification channels", err)
+	}
+	s.breaker.Record(nil)
+	return channels, nil
+}
+
+func (s *Store) InsertNotificationChannel(ctx context.Context, channel models.NotificationChannel) (models.NotificationChannel, error) {
+	if s.mem != nil {
+		return s.mem.saveNotificationChannel(channel, true)
+	}
+	const query = `
+		INSERT INTO notification_channels (name, kind, webhook_url, secret, event_types, min_severity, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.NotificationChannel{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query,
+		channel.Name,
+		channel.Kind,
+		channel.WebhookURL,
+		channel.Secret,
+		strings.Join(channel.EventTypes, ","),
+		channel.MinSeverity,
+		channel.Enabled,
+	)
+	if isDuplicate(err) {
+		s.breaker.Record(nil)
+		return models.NotificationChannel{}, ErrConflict
+	}
+	if err := s.done("insert notification channel", err); err != nil {
+		return models.NotificationChannel{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.NotificationChannel{}, err
+	}
+	return s.NotificationChannelByID(ctx, id)
+}
+
+func (s *Store) UpdateNotificationChannel(ctx context.Context, channel models.NotificationChannel) (models.NotificationChannel, error) {
+	if s.mem != nil {
+		return s.mem.saveNotificationChannel(channel, false)
+	}
+	const query = `
+		UPDATE notification_channels
+		SET name = ?, kind = ?, webhook_url = ?, secret = ?, event_types = ?, min_severity = ?, enabled = ?
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.NotificationChannel{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query,
+		channel.Name,
+		channel.Kind,
+		channel.WebhookURL,
+		channel.Secret,
+		strings.Join(channel.EventTypes, ","),
+		channel.MinSeverity,
+		channel.Enabled,
+		channel.ID,
+	)
+	if isDuplicate(err) {
+		s.breaker.Record(nil)
+		return models.NotificationChannel{}, ErrConflict
+	}
+	if err := s.done("update notification channel", err); err != nil {
+		return models.NotificationChannel{}, err
+	}
+	return s.NotificationChannelByID(ctx, channel.ID)
+}
+
+func (s *Store) NotificationChannelByID(ctx context.Context, id int64) (models.NotificationChannel, error) {
+	if s.mem != nil {
+		return s.mem.notificationChannelByID(id)
+	}
+	query := `
+		SELECT ` + notificationChannelColumns + `
+		FROM notification_channels
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.NotificationChannel{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	channel, err := scanNotificationChannel(s.db.QueryRowContext(ctx, query, id))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.NotificationChannel{}, ErrNotFound
+	}
+	if err != nil {
+		return models.NotificationChannel{}, s.done("notification channel by id", err)
+	}
+	s.breaker.Record(nil)
+	return channel, nil
+}
+
+func (s *Store) DeleteNotificationChannel(ctx context.Context, id int64) error {
+	if s.mem != nil {
+		return s.mem.deleteNotificationChannel(id)
+	}
+	const query = `
+		DELETE FROM notification_channels
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err := s.done("delete notification channel", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"mydashboard-backend/internal/models"
+)
+
+func scanNotificationPreferences(row rowScanner) (models.NotificationPreferences, error) {
+	var prefs models.NotificationPreferences
+	var raw []byte
+	if err := row.Scan(&prefs.UserID, &prefs.Email, &prefs.SlackUserID, &raw, &prefs.UpdatedAt); err != nil {
+		return prefs, err
+	}
+	return prefs, json.Unmarshal(raw, &prefs.Rules)
+}
+
+// NotificationPreferences returns a user's settings, or ErrNotFound if they
+// never saved any.
+func (s *Store) NotificationPreferences(ctx context.Context, userID int64) (models.NotificationPreferences, error) {
+	if s.mem != nil {
+		return s.mem.notificationPreferences(userID)
+	}
+	const query = `
+		SELECT user_id, email, slack_user_id, rules, updated_at
+		FROM notification_preferences
+		WHERE user_id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.NotificationPreferences{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	prefs, err := scanNotificationPreferences(s.db.QueryRowContext(ctx, query, userID))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.NotificationPreferences{}, ErrNotFound
+	}
+	return prefs, s.done("notification preferences", err)
+}
+
+// ActiveNotificationPreferences lists the settings of every enabled user
+// that saved some.
+func (s *Store) ActiveNotificationPreferences(ctx context.Context) ([]models.NotificationPreferences, error) {
+	if s.mem != nil {
+		return s.mem.activeNotificationPreferences(), nil
+	}
+	const query = `
+		SELECT p.user_id, p.email, p.slack_user_id, p.rules, p.updated_at
+		FROM notification_preferences p
+		JOIN users u ON u.id = p.user_id
+		WHERE u.disabled = 0
+		ORDER BY p.user_id
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("active notification preferences", err)
+	}
+	defer rows.Close()
+
+	var out []models.NotificationPreferences
+	for rows.Next() {
+		prefs, err := scanNotificationPreferences(rows)
+		if err != nil {
+			return nil, s.done("active notification preferences", err)
+		}
+		out = append(out, prefs)
+	}
+	return out, s.done("active notification preferences", rows.Err())
+}
+
+func (s *Store) SaveNotificationPreferences(ctx context.Context, prefs models.NotificationPreferences) error {
+	if s.mem != nil {
+		s.mem.saveNotificationPreferences(prefs)
+		return nil
+	}
+	const query = `
+		INSERT INTO notification_preferences (user_id, email, slack_user_id, rules)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			email = VALUES(email),
+			slack_user_id = VALUES(slack_user_id),
+			rules = VALUES(rules)
+	`
+	rules, err := json.Marshal(prefs.Rules)
+	if err != nil {
+		return err
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, query, prefs.UserID, prefs.Email, prefs.SlackUserID, rules)
+	return s.done("save notification preferences", err)
+}
@@ -0,0 +1,279 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func enqueueOutbox(ctx context.Context, db execer, eventType string, payload any) error {
+	const query = `
+		INSERT INTO notification_outbox (event_type, payload)
+		VALUES (?, ?)
+	`
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, query, eventType, body)
+	return err
+}
+
+// EnqueueEvent adds an event that is not tied to another write.
+func (s *Store) EnqueueEvent(ctx context.Context, eventType string, payload any) error {
+	if s.mem != nil {
+		return s.mem.enqueueEvent(eventType, payload)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return s.done("enqueue event", enqueueOutbox(ctx, s.db, eventType, payload))
+}
+
+// ClaimOutbox marks up to limit new events as being fanned out by this
+// instance until lease runs out and returns them. Rows another replica holds
+// are skipped; claims left behind by a crashed instance lapse with the lease.
+func (s *Store) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	if s.mem != nil {
+		return s.mem.claimOutbox(limit, lease), nil
+	}
+	const selectQuery = `
+		SELECT id, event_type, payload, attempts, created_at
+		FROM notification_outbox
+		WHERE status IN ('pending', 'sending') AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+	const claimQuery = `
+		UPDATE notification_outbox
+		SET status = 'sending', next_attempt_at = ?
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, s.done("claim outbox", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	rows, err := tx.QueryContext(ctx, selectQuery, now, limit)
+	if err != nil {
+		return nil, s.done("claim outbox", err)
+	}
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.EventType, &payload, &event.Attempts, &event.CreatedAt); err != nil {
+			rows.Close()
+			return nil, s.done("claim outbox", err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, s.done("claim outbox", err)
+	}
+	for _, event := range events {
+		if _, err := tx.ExecContext(ctx, claimQuery, now.Add(lease), event.ID); err != nil {
+			return nil, s.done("claim outbox", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, s.done("claim outbox", err)
+	}
+	s.breaker.Record(nil)
+	return events, nil
+}
+
+// FanOutOutbox records one pending delivery per target and marks the event
+// dispatched. Targets the event already has are left as they are, so a
+// repeated fan-out after a lapsed claim adds nothing twice.
+func (s *Store) FanOutOutbox(ctx context.Context, id int64, targets []string) error {
+	if s.mem != nil {
+		s.mem.fanOutOutbox(id, targets)
+		return nil
+	}
+	const insertQuery = `
+		INSERT IGNORE INTO notification_deliveries (event_id, target)
+		VALUES (?, ?)
+	`
+	const updateQuery = `
+		UPDATE notification_outbox
+		SET status = 'dispatched', attempts = attempts + 1, last_error = NULL
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return s.done("fan out outbox", err)
+	}
+	defer tx.Rollback()
+
+	for _, target := range targets {
+		if _, err := tx.ExecContext(ctx, insertQuery, id, target); err != nil {
+			return s.done("fan out outbox", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, updateQuery, id); err != nil {
+		return s.done("fan out outbox", err)
+	}
+	return s.done("fan out outbox", tx.Commit())
+}
+
+// MarkOutboxSilenced finishes an event without delivering it, recording the
+// silence that suppressed it.
+func (s *Store) MarkOutboxSilenced(ctx context.Context, id, silenceID int64) error {
+	if s.mem != nil {
+		s.mem.fanOutOutbox(id, nil)
+		return nil
+	}
+	const query = `
+		UPDATE notification_outbox
+		SET status = 'silenced', silenced_by = ?, last_error = NULL
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, silenceID, id)
+	return s.done("mark outbox silenced", err)
+}
+
+// ClaimDeliveries marks up to limit due deliveries as being sent by this
+// instance until lease runs out and returns them with their events. Like
+// ClaimOutbox it skips rows held elsewhere and picks up lapsed claims.
+func (s *Store) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxDelivery, error) {
+	if s.mem != nil {
+		return s.mem.claimDeliveries(limit, lease), nil
+	}
+	const selectQuery = `
+		SELECT d.id, d.target, d.attempts, o.id, o.event_type, o.payload, o.created_at
+		FROM notification_deliveries d
+		JOIN notification_outbox o ON o.id = d.event_id
+		WHERE d.status IN ('pending', 'sending') AND d.next_attempt_at <= ?
+		ORDER BY d.id
+		LIMIT ?
+		FOR UPDATE OF d SKIP LOCKED
+	`
+	const claimQuery = `
+		UPDATE notification_deliveries
+		SET status = 'sending', next_attempt_at = ?
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, s.done("claim deliveries", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	rows, err := tx.QueryContext(ctx, selectQuery, now, limit)
+	if err != nil {
+		return nil, s.done("claim deliveries", err)
+	}
+	var deliveries []models.OutboxDelivery
+	for rows.Next() {
+		var delivery models.OutboxDelivery
+		var payload []byte
+		event := &delivery.Event
+		if err := rows.Scan(&delivery.ID, &delivery.Target, &delivery.Attempts, &event.ID, &event.EventType, &payload, &event.CreatedAt); err != nil {
+			rows.Close()
+			return nil, s.done("claim deliveries", err)
+		}
+		event.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, s.done("claim deliveries", err)
+	}
+	for _, delivery := range deliveries {
+		if _, err := tx.ExecContext(ctx, claimQuery, now.Add(lease), delivery.ID); err != nil {
+			return nil, s.done("claim deliveries", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, s.done("claim deliveries", err)
+	}
+	s.breaker.Record(nil)
+	return deliveries, nil
+}
+
+func (s *Store) MarkDeliveryDelivered(ctx context.Context, id int64) error {
+	if s.mem != nil {
+		s.mem.markDelivery(id, true, time.Time{})
+		return nil
+	}
+	const query = `
+		UPDATE notification_deliveries
+		SET status = 'delivered', attempts = attempts + 1, delivered_at = ?, last_error = NULL
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, time.Now(), id)
+	return s.done("mark delivery delivered", err)
+}
+
+// MarkDeliveryFailed schedules another attempt at nextAttempt, or gives up on
+// the delivery for good when giveUp is set.
+func (s *Store) MarkDeliveryFailed(ctx context.Context, id int64, cause error, nextAttempt time.Time, giveUp bool) error {
+	if s.mem != nil {
+		s.mem.markDelivery(id, giveUp, nextAttempt)
+		return nil
+	}
+	const query = `
+		UPDATE notification_deliveries
+		SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	status := "pending"
+	if giveUp {
+		status = "failed"
+	}
+	_, err := s.db.ExecContext(ctx, query, status, cause.Error(), nextAttempt, id)
+	return s.done("mark delivery failed", err)
+}
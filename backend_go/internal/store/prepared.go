@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// stmtCache keeps prepared statements for the hottest queries so polling and
+// per-second inserts skip the prepare round trip the MySQL driver otherwise
+// makes for every parameterised statement. database/sql re-prepares a
+// statement on whichever pooled connection runs it.
+type stmtCache struct {
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func (d *instrumentedDB) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	d.prepared.mu.Lock()
+	defer d.prepared.mu.Unlock()
+	if stmt, ok := d.prepared.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := d.DB.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	d.prepared.stmts[query] = stmt
+	return stmt, nil
+}
+
+// bind returns the cached statement for query, bound to the enclosing
+// transaction if there is one.
+func (d *instrumentedDB) bind(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := d.stmt(ctx, query)
+	if err != nil || d.tx == nil {
+		return stmt, err
+	}
+	return d.tx.StmtContext(ctx, stmt), nil
+}
+
+func (d *instrumentedDB) execPrepared(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	started := time.Now()
+	stmt, err := d.bind(ctx, query)
+	var result sql.Result
+	if err == nil {
+		result, err = stmt.ExecContext(ctx, args...)
+	}
+	d.observe(query, args, started, err)
+	return result, err
+}
+
+func (d *instrumentedDB) queryPrepared(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	started := time.Now()
+	stmt, err := d.bind(ctx, query)
+	var rows *sql.Rows
+	if err == nil {
+		rows, err = stmt.QueryContext(ctx, args...)
+	}
+	d.observe(query, args, started, err)
+	return rows, err
+}
+
+// queryRowPrepared reports a prepare failure through Scan, like
+// QueryRowContext does for query errors.
+func (d *instrumentedDB) queryRowPrepared(ctx context.Context, query string, args ...any) rowScanner {
+	started := time.Now()
+	stmt, err := d.bind(ctx, query)
+	if err != nil {
+		d.observe(query, args, started, err)
+		return errRow{err}
+	}
+	row := stmt.QueryRowContext(ctx, args...)
+	d.observe(query, args, started, row.Err())
+	return row
+}
+
+type errRow struct{ err error }
+
+func (r errRow) Scan(...any) error { return r.err }
+
+// Close releases the cached prepared statements.
+func (s *Store) Close() error {
+	s.db.prepared.mu.Lock()
+	defer s.db.prepared.mu.Unlock()
+	for query, stmt := range s.db.prepared.stmts {
+		_ = stmt.Close()
+		delete(s.db.prepared.stmts, query)
+	}
+	return nil
+}
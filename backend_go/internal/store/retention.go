@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+func (s *Store) DeleteMetricsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	if s.mem != nil {
+		return s.mem.deleteMetrics(func(at time.Time) bool { return at.Before(cutoff) })
+	}
+	const query = `
+		DELETE FROM metrics_snapshot
+		WHERE created_at < ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return 0, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, cutoff)
+	if err := s.done("delete metrics", err); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteMetricsBetween deletes snapshots with from <= created_at <= to.
+func (s *Store) DeleteMetricsBetween(ctx context.Context, from, to time.Time) (int64, error) {
+	if s.mem != nil {
+		return s.mem.deleteMetrics(func(at time.Time) bool { return !at.Before(from) && !at.After(to) })
+	}
+	const query = `
+		DELETE FROM metrics_snapshot
+		WHERE created_at >= ? AND created_at <= ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return 0, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, from, to)
+	if err := s.done("delete metrics", err); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
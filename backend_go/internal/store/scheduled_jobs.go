@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+
+	"mydashboard-backend/internal/models"
+)
+
+// EnsureScheduledJob inserts the default definition for a job and leaves any
+// persisted schedule or enabled flag untouched.
+func (s *Store) EnsureScheduledJob(ctx context.Context, name, schedule string) error {
+	if s.mem != nil {
+		s.mem.ensureScheduledJob(name, schedule)
+		return nil
+	}
+	const query = `
+		INSERT IGNORE INTO scheduled_jobs (name, schedule)
+		VALUES (?, ?)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, name, schedule)
+	return s.done("ensure scheduled job", err)
+}
+
+func (s *Store) ListScheduledJobs(ctx context.Context) ([]models.ScheduledJob, error) {
+	if s.mem != nil {
+		return s.mem.listScheduledJobs(), nil
+	}
+	const query = `
+		SELECT name, schedule, enabled, last_run_at, last_status, last_error, last_duration_ms
+		FROM scheduled_jobs
+		ORDER BY name
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list scheduled jobs", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.ScheduledJob
+	for rows.Next() {
+		var job models.ScheduledJob
+		var lastRun sql.NullTime
+		if err := rows.Scan(
+			&job.Name,
+			&job.Schedule,
+			&job.Enabled,
+			&lastRun,
+			&job.LastStatus,
+			&job.LastError,
+			&job.LastDurationMs,
+		); err != nil {
+			return nil, s.done("list scheduled jobs", err)
+		}
+		if lastRun.Valid {
+			job.LastRunAt = &lastRun.Time
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list scheduled jobs", err)
+	}
+	s.breaker.Record(nil)
+	return jobs, nil
+}
+
+func (s *Store) UpdateScheduledJob(ctx context.Context, name, schedule string, enabled bool) error {
+	if s.mem != nil {
+		s.mem.updateScheduledJob(name, func(job *models.ScheduledJob) {
+			job.Schedule, job.Enabled = schedule, enabled
+		})
+		return nil
+	}
+	const query = `
+		UPDATE scheduled_jobs
+		SET schedule = ?, enabled = ?
+		WHERE name = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, schedule, enabled, name)
+	return s.done("update scheduled job", err)
+}
+
+func (s *Store) RecordJobRun(ctx context.Context, job models.ScheduledJob) error {
+	if s.mem != nil {
+		s.mem.updateScheduledJob(job.Name, func(stored *models.ScheduledJob) {
+			stored.LastRunAt, stored.LastStatus = job.LastRunAt, job.LastStatus
+			stored.LastError, stored.LastDurationMs = job.LastError, job.LastDurationMs
+		})
+		return nil
+	}
+	const query = `
+		UPDATE scheduled_jobs
+		SET last_run_at = ?, last_status = ?, last_error = ?, last_duration_ms = ?
+		WHERE name = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, job.LastRunAt, job.LastStatus, job.LastError, job.LastDurationMs, job.Name)
+	return s.done("record job run", err)
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const sessionColumns = "id, user_id, refresh_hash, user_agent, ip, created_at, last_used_at, expires_at, revoked_at"
+
+func scanSession(row rowScanner) (models.Session, error) {
+	var session models.Session
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.RefreshHash,
+		&session.UserAgent,
+		&session.IP,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&revokedAt,
+	)
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, err
+}
+
+func (s *Store) InsertSession(ctx context.Context, session models.Session) (models.Session, error) {
+	if s.mem != nil {
+		return s.mem.insertSession(session), nil
+	}
+	const query = `
+		INSERT INTO sessions (user_id, refresh_hash, user_agent, ip, created_at, last_used_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Session{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query,
+		session.UserID,
+		session.RefreshHash,
+		session.UserAgent,
+		session.IP,
+		session.CreatedAt,
+		session.LastUsedAt,
+		session.ExpiresAt,
+	)
+	if err := s.done("insert session", err); err != nil {
+		return models.Session{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Session{}, err
+	}
+	session.ID = id
+	return session, nil
+}
+
+func (s *Store) SessionByRefreshHash(ctx context.Context, hash string) (models.Session, error) {
+	if s.mem != nil {
+		return s.mem.sessionByRefreshHash(hash)
+	}
+	const query = `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE refresh_hash = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Session{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	session, err := scanSession(s.db.QueryRowContext(ctx, query, hash))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Session{}, ErrNotFound
+	}
+	return session, s.done("session by refresh", err)
+}
+
+// ActivePrincipal resolves a session id to its user, provided the session is
+// neither revoked nor expired and the user is not disabled.
+func (s *Store) ActivePrincipal(ctx context.Context, sessionID int64, now time.Time) (models.Principal, error) {
+	if s.mem != nil {
+		return s.mem.activePrincipal(sessionID, now)
+	}
+	const query = `
+		SELECT u.id, u.username, u.role, s.id, u.totp_enabled, u.locale, u.timezone
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = ? AND s.revoked_at IS NULL AND s.expires_at > ? AND u.disabled = 0
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Principal{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var principal models.Principal
+	err := s.db.QueryRowContext(ctx, query, sessionID, now).Scan(
+		&principal.UserID,
+		&principal.Username,
+		&principal.Role,
+		&principal.SessionID,
+		&principal.TOTPEnabled,
+		&principal.Locale,
+		&principal.Timezone,
+	)
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Principal{}, ErrNotFound
+	}
+	return principal, s.done("active principal", err)
+}
+
+// RotateRefresh swaps the refresh token hash only if oldHash is still current,
+// so a refresh token can be redeemed once.
+func (s *Store) RotateRefresh(ctx context.Context, id int64, oldHash, newHash string, now, expiresAt time.Time) error {
+	if s.mem != nil {
+		matched := s.mem.updateSession(func(session models.Session) bool {
+			return session.ID == id && session.RefreshHash == oldHash && session.RevokedAt == nil
+		}, func(session *models.Session) {
+			session.RefreshHash, session.LastUsedAt, session.ExpiresAt = newHash, now, expiresAt
+		})
+		if !matched {
+			return ErrNotFound
+		}
+		return nil
+	}
+	const query = `
+		UPDATE sessions
+		SET refresh_hash = ?, last_used_at = ?, expires_at = ?
+		WHERE id = ? AND refresh_hash = ? AND revoked_at IS NULL
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, newHash, now, expiresAt, id, oldHash)
+	if err := s.done("rotate refresh", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) ListUserSessions(ctx context.Context, userID int64, now time.Time) ([]models.Session, error) {
+	if s.mem != nil {
+		return s.mem.listUserSessions(userID, now), nil
+	}
+	const query = `
+		SELECT ` + sessionColumns + `
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_used_at DESC
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, now)
+	if err != nil {
+		return nil, s.done("list sessions", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, s.done("list sessions", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list sessions", err)
+	}
+	s.breaker.Record(nil)
+	return sessions, nil
+}
+
+// RevokeSession only matches sessions owned by userID so users cannot revoke
+// each other's sessions by guessing ids.
+func (s *Store) RevokeSession(ctx context.Context, userID, id int64, at time.Time) error {
+	if s.mem != nil {
+		matched := s.mem.updateSession(func(session models.Session) bool {
+			return session.ID == id && session.UserID == userID && session.RevokedAt == nil
+		}, func(session *models.Session) {
+			session.RevokedAt = &at
+		})
+		if !matched {
+			return ErrNotFound
+		}
+		return nil
+	}
+	const query = `
+		UPDATE sessions
+		SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, at, id, userID)
+	if err := s.done("revoke session", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
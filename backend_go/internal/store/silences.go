@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const silenceColumns = "id, kind, matchers, starts_at, ends_at, schedule, duration, comment, created_by, created_at"
+
+func scanSilence(row rowScanner) (models.Silence, error) {
+	var silence models.Silence
+	var matchers []byte
+	var endsAt sql.NullTime
+	err := row.Scan(
+		&silence.ID,
+		&silence.Kind,
+		&matchers,
+		&silence.StartsAt,
+		&endsAt,
+		&silence.Schedule,
+		&silence.Duration,
+		&silence.Comment,
+		&silence.CreatedBy,
+		&silence.CreatedAt,
+	)
+	if err != nil {
+		return silence, err
+	}
+	if endsAt.Valid {
+		silence.EndsAt = &endsAt.Time
+	}
+	return silence, json.Unmarshal(matchers, &silence.Matchers)
+}
+
+// ListSilences returns the silences, newest first. Unless includeExpired is
+// set, only those that have not ended by now are returned.
+func (s *Store) ListSilences(ctx context.Context, includeExpired bool) ([]models.Silence, error) {
+	now := time.Now()
+	if s.mem != nil {
+		return s.mem.listSilences(includeExpired, now), nil
+	}
+	query := `
+		SELECT ` + silenceColumns + `
+		FROM alert_silences
+	`
+	if !includeExpired {
+		query += " WHERE ends_at IS NULL OR ends_at > ?"
+	}
+	query += " ORDER BY id DESC"
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var args []any
+	if !includeExpired {
+		args = append(args, now)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, s.done("list silences", err)
+	}
+	defer rows.Close()
+
+	var silences []models.Silence
+	for rows.Next() {
+		silence, err := scanSilence(rows)
+		if err != nil {
+			return nil, s.done("list silences", err)
+		}
+		silences = append(silences, silence)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list silences", err)
+	}
+	s.breaker.Record(nil)
+	return silences, nil
+}
+
+func (s *Store) InsertSilence(ctx context.Context, silence models.Silence) (models.Silence, error) {
+	if s.mem != nil {
+		return s.mem.insertSilence(silence), nil
+	}
+	const query = `
+		INSERT INTO alert_silences (kind, matchers, starts_at, ends_at, schedule, duration, comment, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	matchers, err := json.Marshal(silence.Matchers)
+	if err != nil {
+		return models.Silence{}, err
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return models.Silence{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query,
+		silence.Kind,
+		matchers,
+		silence.StartsAt,
+		silence.EndsAt,
+		silence.Schedule,
+		silence.Duration,
+		silence.Comment,
+		silence.CreatedBy,
+	)
+	if err := s.done("insert silence", err); err != nil {
+		return models.Silence{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Silence{}, err
+	}
+	return s.SilenceByID(ctx, id)
+}
+
+func (s *Store) SilenceByID(ctx context.Context, id int64) (models.Silence, error) {
+	if s.mem != nil {
+		return s.mem.silenceByID(id)
+	}
+	query := `
+		SELECT ` + silenceColumns + `
+		FROM alert_silences
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Silence{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	silence, err := scanSilence(s.db.QueryRowContext(ctx, query, id))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Silence{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Silence{}, s.done("silence by id", err)
+	}
+	s.breaker.Record(nil)
+	return silence, nil
+}
+
+func (s *Store) DeleteSilence(ctx context.Context, id int64) error {
+	if s.mem != nil {
+		return s.mem.deleteSilence(id)
+	}
+	const query = `
+		DELETE FROM alert_silences
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err := s.done("delete silence", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
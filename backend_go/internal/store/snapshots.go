@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+// MetricsAt returns the last snapshot recorded at or before at.
+func (s *Store) MetricsAt(ctx context.Context, at time.Time) (models.Metrics, error) {
+	if s.mem != nil {
+		return s.mem.metricsAt(at)
+	}
+	const query = `
+		SELECT revenue, growth, sentiment, backlog, created_at, derived, valid_until
+		FROM metrics_snapshot
+		WHERE created_at <= ?
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Metrics{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var metrics models.Metrics
+	var derived []byte
+	err := s.db.QueryRowContext(ctx, query, at).Scan(
+		&metrics.Revenue,
+		&metrics.Growth,
+		&metrics.Sentiment,
+		&metrics.Backlog,
+		&metrics.CreatedAt,
+		&derived,
+		&metrics.ValidUntil,
+	)
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Metrics{}, ErrNotFound
+	}
+	if err == nil {
+		err = decodeDerived(derived, &metrics)
+	}
+	return metrics, s.done("metrics at", err)
+}
@@ -4,38 +4,91 @@ import (
   "context"
   "database/sql"
   "errors"
+  "strings"
   "time"
   
   "mydashboard-backend/internal/models"
 )
 
+const defaultQueryTimeout = 3 * time.Second
+
 type Store struct {
-  db *sql.DB
+  db           *instrumentedDB
+  queryTimeout time.Duration
+  breaker      *Breaker
+  failover     *Failover
+  mem          *memory
+  snapshotPath string
 }
 
 func New(db *sql.DB) *Store {
-  return &Store{db: db}
+  return &Store{
+    db: &instrumentedDB{
+      DB:       db,
+      stats:    &queryStats{byOp: map[string]*QueryStat{}},
+      prepared: &stmtCache{stmts: map[string]*sql.Stmt{}},
+    },
+    queryTimeout: defaultQueryTimeout,
+  }
+}
+
+func (s *Store) WithQueryTimeout(timeout time.Duration) *Store {
+  if timeout <= 0 {
+    return s
+  }
+  s.queryTimeout = timeout
+  return s
+}
+
+func (s *Store) WithBreaker(breaker *Breaker) *Store {
+  s.breaker = breaker
+  return s
+}
+
+func (s *Store) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+  return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+func (s *Store) done(op string, err error) error {
+  s.breaker.Record(err)
+  return wrapErr(op, err)
 }
 
 func (s *Store) LatestMetrics(ctx context.Context) (models.Metrics, error) {
+  if s.mem != nil {
+    return s.mem.latestMetrics(), nil
+  }
   const query = `
-    SELECT revenue, growth, sentiment, backlog, created_at
+    SELECT revenue, growth, sentiment, backlog, created_at, derived, valid_until
     FROM metrics_snapshot
     ORDER BY created_at DESC
     LIMIT 1
   `
+  if err := s.breaker.Allow(); err != nil {
+    return models.Metrics{}, err
+  }
+  ctx, cancel := s.withTimeout(ctx)
+  defer cancel()
+
   var metrics models.Metrics
-  err := s.db.QueryRowContext(ctx, query).Scan(
+  var derived []byte
+  err := s.db.queryRowPrepared(ctx, query).Scan(
     &metrics.Revenue,
     &metrics.Growth,
     &metrics.Sentiment,
     &metrics.Backlog,
     &metrics.CreatedAt,
+    &derived,
+    &metrics.ValidUntil,
   )
   if errors.Is(err, sql.ErrNoRows) {
+    s.breaker.Record(nil)
     return models.Metrics{}, nil
   }
-  return metrics, err
+  if err == nil {
+    err = decodeDerived(derived, &metrics)
+  }
+  return metrics, s.done("latest metrics", err)
 }
 
 func (s *Store) InsertMetrics(ctx context.Context, metrics models.Metrics) error {
@@ -43,50 +96,142 @@ func (s *Store) InsertMetrics(ctx context.Context, metrics models.Metrics) error
 }
 
 func (s *Store) InsertMetricsAt(ctx context.Context, metrics models.Metrics) error {
+  if s.mem != nil {
+    return s.mem.insertMetrics([]models.Metrics{metrics})
+  }
   const query = `
-    INSERT INTO metrics_snapshot (revenue, growth, sentiment, backlog, created_at)
-    VALUES (?, ?, ?, ?, ?)
+    INSERT INTO metrics_snapshot (revenue, growth, sentiment, backlog, created_at, derived, valid_until)
+    VALUES (?, ?, ?, ?, ?, ?, ?)
   `
-  _, err := s.db.ExecContext(ctx, query,
+  derived, err := derivedColumn(metrics.Derived)
+  if err != nil {
+    return err
+  }
+  if err := s.breaker.Allow(); err != nil {
+    return err
+  }
+  ctx, cancel := s.withTimeout(ctx)
+  defer cancel()
+
+  _, err = s.db.execPrepared(ctx, query,
     metrics.Revenue,
     metrics.Growth,
     metrics.Sentiment,
     metrics.Backlog,
     metrics.CreatedAt,
+    derived,
+    metrics.ValidUntil,
   )
-  return err
+  return s.done("insert metrics", err)
+}
+
+func (s *Store) InsertMetricsBatch(ctx context.Context, batch []models.Metrics) error {
+  if s.mem != nil {
+    return s.mem.insertMetrics(batch)
+  }
+  if len(batch) == 0 {
+    return nil
+  }
+  if err := s.breaker.Allow(); err != nil {
+    return err
+  }
+  ctx, cancel := s.withTimeout(ctx)
+  defer cancel()
+
+  var query strings.Builder
+  query.WriteString("INSERT INTO metrics_snapshot (revenue, growth, sentiment, backlog, created_at, derived, valid_until) VALUES ")
+  args := make([]any, 0, len(batch)*7)
+  for i, metrics := range batch {
+    if i > 0 {
+      query.WriteString(", ")
+    }
+    derived, err := derivedColumn(metrics.Derived)
+    if err != nil {
+      return err
+    }
+    query.WriteString("(?, ?, ?, ?, ?, ?, ?)")
+    args = append(args,
+      metrics.Revenue,
+      metrics.Growth,
+      metrics.Sentiment,
+      metrics.Backlog,
+      metrics.CreatedAt,
+      derived,
+      metrics.ValidUntil,
+    )
+  }
+  _, err := s.db.ExecContext(ctx, query.String(), args...)
+  return s.done("insert metrics batch", err)
+}
+
+// ExtendLatestMetrics marks the newest snapshot as still valid at until,
+// in place of inserting an identical one.
+func (s *Store) ExtendLatestMetrics(ctx context.Context, until time.Time) error {
+  if s.mem != nil {
+    return s.mem.extendLatestMetrics(until)
+  }
+  const query = `
+    UPDATE metrics_snapshot
+    SET valid_until = ?
+    ORDER BY created_at DESC
+    LIMIT 1
+  `
+  if err := s.breaker.Allow(); err != nil {
+    return err
+  }
+  ctx, cancel := s.withTimeout(ctx)
+  defer cancel()
+
+  _, err := s.db.ExecContext(ctx, query, until)
+  return s.done("extend latest metrics", err)
 }
 
 func (s *Store) Trend(ctx context.Context, limit int) ([]models.Metrics, error) {
+  if s.mem != nil {
+    return s.mem.trend(limit), nil
+  }
   const query = `
-    SELECT revenue, growth, sentiment, backlog, created_at
+    SELECT revenue, growth, sentiment, backlog, created_at, derived, valid_until
     FROM metrics_snapshot
     ORDER BY created_at DESC
     LIMIT ?
   `
+  if err := s.breaker.Allow(); err != nil {
+    return nil, err
+  }
+  ctx, cancel := s.withTimeout(ctx)
+  defer cancel()
+
   rows, err := s.db.QueryContext(ctx, query, limit)
   if err != nil {
-    return nil, err
+    return nil, s.done("trend", err)
   }
   defer rows.Close()
 
   var points []models.Metrics
   for rows.Next() {
     var metrics models.Metrics
+    var derived []byte
     if err := rows.Scan(
       &metrics.Revenue,
       &metrics.Growth,
       &metrics.Sentiment,
       &metrics.Backlog,
       &metrics.CreatedAt,
+      &derived,
+      &metrics.ValidUntil,
     ); err != nil {
-      return nil, err
+      return nil, s.done("trend", err)
+    }
+    if err := decodeDerived(derived, &metrics); err != nil {
+      return nil, s.done("trend", err)
     }
     points = append(points, metrics)
   }
   if err := rows.Err(); err != nil {
-    return nil, err
+    return nil, s.done("trend", err)
   }
+  s.breaker.Record(nil)
 
   for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
     points[i], points[j] = points[j], points[i]
@@ -95,51 +240,214 @@ func (s *Store) Trend(ctx context.Context, limit int) ([]models.Metrics, error)
   return points, nil
 }
 
-func (s *Store) LatestInsights(ctx context.Context, limit int) ([]models.Insight, error) {
+func (s *Store) MetricsBetween(ctx context.Context, from, to time.Time, limit int) ([]models.Metrics, error) {
+  if s.mem != nil {
+    return s.mem.metricsBetween(from, to, limit), nil
+  }
   const query = `
-    SELECT id, title, message, source, created_at
+    SELECT revenue, growth, sentiment, backlog, created_at, derived, valid_until
+    FROM metrics_snapshot
+    WHERE created_at >= ? AND created_at <= ?
+    ORDER BY created_at ASC
+    LIMIT ?
+  `
+  if err := s.breaker.Allow(); err != nil {
+    return nil, err
+  }
+  ctx, cancel := s.withTimeout(ctx)
+  defer cancel()
+
+  rows, err := s.db.QueryContext(ctx, query, from, to, limit)
+  if err != nil {
+    return nil, s.done("metrics between", err)
+  }
+  defer rows.Close()
+
+  var points []models.Metrics
+  for rows.Next() {
+    var metrics models.Metrics
+    var derived []byte
+    if err := rows.Scan(
+      &metrics.Revenue,
+      &metrics.Growth,
+      &metrics.Sentiment,
+      &metrics.Backlog,
+      &metrics.CreatedAt,
+      &derived,
+      &metrics.ValidUntil,
+    ); err != nil {
+      return nil, s.done("metrics between", err)
+    }
+    if err := decodeDerived(derived, &metrics); err != nil {
+      return nil, s.done("metrics between", err)
+    }
+    points = append(points, metrics)
+  }
+  if err := rows.Err(); err != nil {
+    return nil, s.done("metrics between", err)
+  }
+  s.breaker.Record(nil)
+  return points, nil
+}
+
+const insightColumns = "id, title, message, source, created_at, repeat_count, locale, severity, deleted_at, version, author"
+
+type rowScanner interface {
+  Scan(dest ...any) error
+}
+
+func scanInsight(row rowScanner) (models.Insight, error) {
+  var insight models.Insight
+  var deleted sql.NullTime
+  var author sql.NullString
+  err := row.Scan(
+    &insight.ID,
+    &insight.Title,
+    &insight.Message,
+    &insight.Source,
+    &insight.CreatedAt,
+    &insight.RepeatCount,
+    &insight.Locale,
+    &insight.Severity,
+    &deleted,
+    &insight.Version,
+    &author,
+  )
+  if deleted.Valid {
+    insight.DeletedAt = &deleted.Time
+  }
+  insight.Author = author.String
+  return insight, err
+}
+
+func (s *Store) LatestInsights(ctx context.Context, locale string, limit int) ([]models.Insight, error) {
+  if s.mem != nil {
+    return s.mem.latestInsights(locale, limit), nil
+  }
+  const query = `
+    SELECT ` + insightColumns + `
     FROM insights
+    WHERE locale = ? AND deleted_at IS NULL
     ORDER BY created_at DESC
     LIMIT ?
   `
-  rows, err := s.db.QueryContext(ctx, query, limit)
-  if err != nil {
+  if err := s.breaker.Allow(); err != nil {
     return nil, err
   }
+  ctx, cancel := s.withTimeout(ctx)
+  defer cancel()
+
+  rows, err := s.db.queryPrepared(ctx, query, locale, limit)
+  if err != nil {
+    return nil, s.done("latest insights", err)
+  }
   defer rows.Close()
 
   var items []models.Insight
   for rows.Next() {
-    var insight models.Insight
-    if err := rows.Scan(
-      &insight.ID,
-      &insight.Title,
-      &insight.Message,
-      &insight.Source,
-      &insight.CreatedAt,
-    ); err != nil {
-      return nil, err
+    insight, err := scanInsight(rows)
+    if err != nil {
+      return nil, s.done("latest insights", err)
     }
     items = append(items, insight)
   }
   if err := rows.Err(); err != nil {
+    return nil, s.done("latest insights", err)
+  }
+  if err := s.loadInsightLinks(ctx, items); err != nil {
+    return nil, s.done("latest insights", err)
+  }
+  if err := s.loadInsightTags(ctx, items); err != nil {
+    return nil, s.done("latest insights", err)
+  }
+  s.breaker.Record(nil)
+
+  return items, nil
+}
+
+// InsightsBetween returns insights created in [from, to], oldest first,
+// optionally only those of the given severities.
+func (s *Store) InsightsBetween(ctx context.Context, locale string, from, to time.Time, severities []string, limit int) ([]models.Insight, error) {
+  if s.mem != nil {
+    return s.mem.insightsBetween(locale, from, to, severities, limit), nil
+  }
+  query := `
+    SELECT ` + insightColumns + `
+    FROM insights
+    WHERE locale = ? AND deleted_at IS NULL AND created_at >= ? AND created_at <= ?
+  `
+  args := []any{locale, from, to}
+  if len(severities) > 0 {
+    query += " AND severity IN (?" + strings.Repeat(", ?", len(severities)-1) + ")"
+    for _, severity := range severities {
+      args = append(args, severity)
+    }
+  }
+  query += " ORDER BY created_at ASC LIMIT ?"
+  args = append(args, limit)
+  if err := s.breaker.Allow(); err != nil {
     return nil, err
   }
+  ctx, cancel := s.withTimeout(ctx)
+  defer cancel()
+
+  rows, err := s.db.QueryContext(ctx, query, args...)
+  if err != nil {
+    return nil, s.done("insights between", err)
+  }
+  defer rows.Close()
 
+  var items []models.Insight
+  for rows.Next() {
+    insight, err := scanInsight(rows)
+    if err != nil {
+      return nil, s.done("insights between", err)
+    }
+    items = append(items, insight)
+  }
+  if err := rows.Err(); err != nil {
+    return nil, s.done("insights between", err)
+  }
+  if err := s.loadInsightLinks(ctx, items); err != nil {
+    return nil, s.done("insights between", err)
+  }
+  if err := s.loadInsightTags(ctx, items); err != nil {
+    return nil, s.done("insights between", err)
+  }
+  s.breaker.Record(nil)
   return items, nil
 }
 
 func (s *Store) InsertInsight(ctx context.Context, insight models.Insight) (models.Insight, error) {
+  if s.mem != nil {
+    return s.mem.insertInsight(insight)
+  }
   const query = `
-    INSERT INTO insights (title, message, source)
-    VALUES (?, ?, ?)
+    INSERT INTO insights (title, message, source, fingerprint, locale, severity, author)
+    VALUES (?, ?, ?, ?, ?, ?, ?)
   `
-  result, err := s.db.ExecContext(ctx, query,
+  if err := s.breaker.Allow(); err != nil {
+    return models.Insight{}, err
+  }
+  ctx, cancel := s.withTimeout(ctx)
+  defer cancel()
+
+  tx, err := s.db.BeginTx(ctx, nil)
+  if err != nil {
+    return models.Insight{}, s.done("insert insight", err)
+  }
+  defer tx.Rollback()
+
+  result, err := tx.ExecContext(ctx, query,
     insight.Title,
     insight.Message,
     insight.Source,
+    sql.NullString{String: insight.Fingerprint, Valid: insight.Fingerprint != ""},
+    insight.Locale,
+    insight.Severity,
+    sql.NullString{String: insight.Author, Valid: insight.Author != ""},
   )
-  if err != nil {
+  if err := s.done("insert insight", err); err != nil {
     return models.Insight{}, err
   }
   id, err := result.LastInsertId()
@@ -148,6 +456,21 @@ func (s *Store) InsertInsight(ctx context.Context, insight models.Insight) (mode
   }
   insight.ID = id
   insight.CreatedAt = time.Now()
+  insight.RepeatCount = 1
+  insight.Version = 1
+
+  if err := insertInsightLinks(ctx, tx, id, insight.Metrics); err != nil {
+    return models.Insight{}, s.done("insert insight", err)
+  }
+  if err := insertInsightTags(ctx, tx, id, insight.Tags); err != nil {
+    return models.Insight{}, s.done("insert insight", err)
+  }
+  if err := enqueueOutbox(ctx, tx, models.EventInsightCreated, insight); err != nil {
+    return models.Insight{}, s.done("insert insight", err)
+  }
+  if err := tx.Commit(); err != nil {
+    return models.Insight{}, s.done("insert insight", err)
+  }
   return insight, nil
 }
 
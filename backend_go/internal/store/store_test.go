@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"mydashboard-backend/internal/models"
+)
+
+func newMock(t *testing.T) (*Store, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	return New(db), mock
+}
+
+func TestLatestMetrics(t *testing.T) {
+	st, mock := newMock(t)
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectPrepare(regexp.QuoteMeta("FROM metrics_snapshot")).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"revenue", "growth", "sentiment", "backlog", "created_at", "derived", "valid_until"}).
+			AddRow(1200.0, 3.5, 0.8, 4, created, []byte(`{"arpu":12}`), nil))
+
+	got, err := st.LatestMetrics(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Revenue != 1200 || got.Backlog != 4 || !got.CreatedAt.Equal(created) {
+		t.Errorf("got %+v", got)
+	}
+	if got.Derived["arpu"] != 12 {
+		t.Errorf("derived = %v, want arpu 12", got.Derived)
+	}
+}
+
+func TestLatestMetricsEmpty(t *testing.T) {
+	st, mock := newMock(t)
+	mock.ExpectPrepare(regexp.QuoteMeta("FROM metrics_snapshot")).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"revenue", "growth", "sentiment", "backlog", "created_at", "derived", "valid_until"}))
+
+	got, err := st.LatestMetrics(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.IsZero() {
+		t.Errorf("got %+v, want the zero snapshot", got)
+	}
+}
+
+func TestTrendIsOldestFirst(t *testing.T) {
+	st, mock := newMock(t)
+	newer := time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC)
+	older := newer.Add(-time.Minute)
+	mock.ExpectQuery(regexp.QuoteMeta("ORDER BY created_at DESC")).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"revenue", "growth", "sentiment", "backlog", "created_at", "derived", "valid_until"}).
+			AddRow(20.0, 0.0, 0.0, 0, newer, nil, nil).
+			AddRow(10.0, 0.0, 0.0, 0, older, nil, nil))
+
+	got, err := st.Trend(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[0].CreatedAt.Equal(older) || !got[1].CreatedAt.Equal(newer) {
+		t.Errorf("got %+v, want oldest first", got)
+	}
+}
+
+func TestClaimOutboxLeasesClaimedEvents(t *testing.T) {
+	st, mock := newMock(t)
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE SKIP LOCKED")).
+		WithArgs(sqlmock.AnyArg(), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "payload", "attempts", "created_at"}).
+			AddRow(7, "alert", []byte(`{}`), 1, created))
+	mock.ExpectExec(regexp.QuoteMeta("SET status = 'sending'")).
+		WithArgs(sqlmock.AnyArg(), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	events, err := st.ClaimOutbox(context.Background(), 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ID != 7 || events[0].EventType != "alert" {
+		t.Errorf("got %+v", events)
+	}
+}
+
+func TestClaimOutboxRollsBackOnError(t *testing.T) {
+	st, mock := newMock(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM notification_outbox")).
+		WillReturnError(errors.New("deadlock"))
+	mock.ExpectRollback()
+
+	if _, err := st.ClaimOutbox(context.Background(), 10, time.Minute); err == nil {
+		t.Fatal("want an error")
+	}
+}
+
+func TestEraseUserDataCountsRows(t *testing.T) {
+	st, mock := newMock(t)
+	user := models.User{ID: 3, Username: "ana"}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sessions")).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE embed_tokens")).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM notification_preferences")).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM insights")).WithArgs(user.Username).WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM insight_assignments")).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM audit_log")).WithArgs(user.ID, user.Username).WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM invitations")).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE alert_silences")).WithArgs(user.Username).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE alerts")).WithArgs(user.Username).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE insight_assignments")).WithArgs(user.Username).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE invitations")).WithArgs(user.Username).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users")).WithArgs(user.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	report, err := st.EraseUserData(context.Background(), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.ErasureReport{
+		SessionsDeleted:     2,
+		EmbedTokensUnlinked: 1,
+		InsightsDeleted:     4,
+		AssignmentsDeleted:  1,
+		AuditEntriesDeleted: 5,
+		InvitationsDeleted:  1,
+		SilencesUnlinked:    2,
+		RecordsUnlinked:     3,
+	}
+	if report != want {
+		t.Errorf("got %+v, want %+v", report, want)
+	}
+}
+
+func TestEraseUserDataRollsBackOnError(t *testing.T) {
+	st, mock := newMock(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sessions")).WillReturnError(errors.New("lock wait timeout"))
+	mock.ExpectRollback()
+
+	if _, err := st.EraseUserData(context.Background(), models.User{ID: 3, Username: "ana"}); err == nil {
+		t.Fatal("want an error")
+	}
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+func (s *Store) InsertSurveyResponses(ctx context.Context, responses []models.SurveyResponse) error {
+	if s.mem != nil {
+		s.mem.insertSurveyResponses(responses)
+		return nil
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO survey_responses (score, segment, responded_at) VALUES ")
+	args := make([]any, 0, len(responses)*3)
+	for i, response := range responses {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?, ?)")
+		args = append(args, response.Score, response.Segment, response.RespondedAt)
+	}
+	_, err := s.db.ExecContext(ctx, query.String(), args...)
+	return s.done("insert survey responses", err)
+}
+
+// SurveyResponses returns up to limit responses in [from, to], oldest first.
+func (s *Store) SurveyResponses(ctx context.Context, from, to time.Time, limit int) ([]models.SurveyResponse, error) {
+	if s.mem != nil {
+		return s.mem.surveyResponses(from, to, limit), nil
+	}
+	const query = `
+		SELECT score, segment, responded_at
+		FROM survey_responses
+		WHERE responded_at >= ? AND responded_at <= ?
+		ORDER BY responded_at, id
+		LIMIT ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, from, to, limit)
+	if err != nil {
+		return nil, s.done("survey responses", err)
+	}
+	defer rows.Close()
+
+	var responses []models.SurveyResponse
+	for rows.Next() {
+		var response models.SurveyResponse
+		if err := rows.Scan(&response.Score, &response.Segment, &response.RespondedAt); err != nil {
+			return nil, s.done("survey responses", err)
+		}
+		responses = append(responses, response)
+	}
+	return responses, s.done("survey responses", rows.Err())
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+)
+
+// SyncCursor returns how many rows of an edge segment have been received,
+// locking the cursor until the end of the surrounding transaction.
+func (s *Store) SyncCursor(ctx context.Context, node, segment string) (int, error) {
+	if s.mem != nil {
+		return s.mem.syncCursor(node, segment), nil
+	}
+	const query = `
+		SELECT acked
+		FROM sync_cursors
+		WHERE node = ? AND segment = ?
+		FOR UPDATE
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return 0, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var acked int
+	err := s.db.QueryRowContext(ctx, query, node, segment).Scan(&acked)
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return 0, nil
+	}
+	return acked, s.done("sync cursor", err)
+}
+
+func (s *Store) SetSyncCursor(ctx context.Context, node, segment string, acked int) error {
+	if s.mem != nil {
+		s.mem.setSyncCursor(node, segment, acked)
+		return nil
+	}
+	const query = `
+		INSERT INTO sync_cursors (node, segment, acked)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE acked = VALUES(acked)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, node, segment, acked)
+	return s.done("set sync cursor", err)
+}
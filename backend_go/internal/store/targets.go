@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+
+	"mydashboard-backend/internal/models"
+)
+
+// ListTargets returns the targets of quarter, or of all quarters when it is
+// empty, ordered by quarter and metric.
+func (s *Store) ListTargets(ctx context.Context, quarter string) ([]models.Target, error) {
+	if s.mem != nil {
+		return s.mem.listTargets(quarter), nil
+	}
+	const query = `
+		SELECT metric, quarter, value, direction, updated_at
+		FROM metric_targets
+		WHERE ? = '' OR quarter = ?
+		ORDER BY quarter, metric
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, quarter, quarter)
+	if err != nil {
+		return nil, s.done("list targets", err)
+	}
+	defer rows.Close()
+
+	var targets []models.Target
+	for rows.Next() {
+		var target models.Target
+		if err := rows.Scan(&target.Metric, &target.Quarter, &target.Value, &target.Direction, &target.UpdatedAt); err != nil {
+			return nil, s.done("list targets", err)
+		}
+		targets = append(targets, target)
+	}
+	return targets, s.done("list targets", rows.Err())
+}
+
+func (s *Store) SaveTarget(ctx context.Context, target models.Target) error {
+	if s.mem != nil {
+		s.mem.saveTarget(target)
+		return nil
+	}
+	const query = `
+		INSERT INTO metric_targets (metric, quarter, value, direction)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE value = VALUES(value), direction = VALUES(direction)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, target.Metric, target.Quarter, target.Value, target.Direction)
+	return s.done("save target", err)
+}
+
+func (s *Store) DeleteTarget(ctx context.Context, metric, quarter string) error {
+	if s.mem != nil {
+		return s.mem.deleteTarget(metric, quarter)
+	}
+	const query = `
+		DELETE FROM metric_targets
+		WHERE metric = ? AND quarter = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, metric, quarter)
+	if err := s.done("delete target", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
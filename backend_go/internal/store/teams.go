@@ -0,0 +1,362 @@
+package store
+
+import (
+	"context"
+
+	"mydashboard-backend/internal/models"
+)
+
+const teamColumns = "id, org_id, parent_id, name, created_at, updated_at"
+
+func scanTeam(row rowScanner) (models.Team, error) {
+	var team models.Team
+	err := row.Scan(&team.ID, &team.OrgID, &team.ParentID, &team.Name, &team.CreatedAt, &team.UpdatedAt)
+	return team, err
+}
+
+func (s *Store) ListOrgs(ctx context.Context) ([]models.Org, error) {
+	if s.mem != nil {
+		return s.mem.listOrgs(), nil
+	}
+	const query = `
+		SELECT id, name, created_at, updated_at
+		FROM orgs
+		ORDER BY name
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list orgs", err)
+	}
+	defer rows.Close()
+
+	var orgs []models.Org
+	for rows.Next() {
+		var org models.Org
+		if err := rows.Scan(&org.ID, &org.Name, &org.CreatedAt, &org.UpdatedAt); err != nil {
+			return nil, s.done("list orgs", err)
+		}
+		orgs = append(orgs, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list orgs", err)
+	}
+	s.breaker.Record(nil)
+	return orgs, nil
+}
+
+func (s *Store) OrgByID(ctx context.Context, id int64) (models.Org, error) {
+	if s.mem != nil {
+		return s.mem.orgByID(id)
+	}
+	const query = `
+		SELECT id, name, created_at, updated_at
+		FROM orgs
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Org{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var org models.Org
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&org.ID, &org.Name, &org.CreatedAt, &org.UpdatedAt)
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Org{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Org{}, s.done("org by id", err)
+	}
+	s.breaker.Record(nil)
+	return org, nil
+}
+
+// SaveOrg inserts org when its ID is zero and renames it otherwise.
+func (s *Store) SaveOrg(ctx context.Context, org models.Org) (models.Org, error) {
+	if s.mem != nil {
+		return s.mem.saveOrg(org)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return models.Org{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if org.ID == 0 {
+		result, err := s.db.ExecContext(ctx, "INSERT INTO orgs (name) VALUES (?)", org.Name)
+		if isDuplicate(err) {
+			s.breaker.Record(nil)
+			return models.Org{}, ErrConflict
+		}
+		if err := s.done("insert org", err); err != nil {
+			return models.Org{}, err
+		}
+		if org.ID, err = result.LastInsertId(); err != nil {
+			return models.Org{}, err
+		}
+		return s.OrgByID(ctx, org.ID)
+	}
+	_, err := s.db.ExecContext(ctx, "UPDATE orgs SET name = ? WHERE id = ?", org.Name, org.ID)
+	if isDuplicate(err) {
+		s.breaker.Record(nil)
+		return models.Org{}, ErrConflict
+	}
+	if err := s.done("update org", err); err != nil {
+		return models.Org{}, err
+	}
+	return s.OrgByID(ctx, org.ID)
+}
+
+func (s *Store) DeleteOrg(ctx context.Context, id int64) error {
+	if s.mem != nil {
+		return s.mem.deleteOrg(id)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM orgs WHERE id = ?", id)
+	if err := s.done("delete org", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListTeams lists the teams of an org, or of every org when orgID is zero.
+func (s *Store) ListTeams(ctx context.Context, orgID int64) ([]models.Team, error) {
+	if s.mem != nil {
+		return s.mem.listTeams(orgID), nil
+	}
+	query := `
+		SELECT ` + teamColumns + `
+		FROM teams`
+	var args []any
+	if orgID != 0 {
+		query += `
+		WHERE org_id = ?`
+		args = append(args, orgID)
+	}
+	query += `
+		ORDER BY org_id, name`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, s.done("list teams", err)
+	}
+	defer rows.Close()
+
+	var teams []models.Team
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			return nil, s.done("list teams", err)
+		}
+		teams = append(teams, team)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list teams", err)
+	}
+	s.breaker.Record(nil)
+	return teams, nil
+}
+
+func (s *Store) TeamByID(ctx context.Context, id int64) (models.Team, error) {
+	if s.mem != nil {
+		return s.mem.teamByID(id)
+	}
+	query := `
+		SELECT ` + teamColumns + `
+		FROM teams
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Team{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	team, err := scanTeam(s.db.QueryRowContext(ctx, query, id))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Team{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Team{}, s.done("team by id", err)
+	}
+	s.breaker.Record(nil)
+	return team, nil
+}
+
+// SaveTeam inserts team when its ID is zero and updates it otherwise.
+func (s *Store) SaveTeam(ctx context.Context, team models.Team) (models.Team, error) {
+	if s.mem != nil {
+		return s.mem.saveTeam(team)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return models.Team{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if team.ID == 0 {
+		const query = `
+			INSERT INTO teams (org_id, parent_id, name)
+			VALUES (?, ?, ?)
+		`
+		result, err := s.db.ExecContext(ctx, query, team.OrgID, team.ParentID, team.Name)
+		if isDuplicate(err) {
+			s.breaker.Record(nil)
+			return models.Team{}, ErrConflict
+		}
+		if err := s.done("insert team", err); err != nil {
+			return models.Team{}, err
+		}
+		if team.ID, err = result.LastInsertId(); err != nil {
+			return models.Team{}, err
+		}
+		return s.TeamByID(ctx, team.ID)
+	}
+	const query = `
+		UPDATE teams
+		SET parent_id = ?, name = ?
+		WHERE id = ?
+	`
+	_, err := s.db.ExecContext(ctx, query, team.ParentID, team.Name, team.ID)
+	if isDuplicate(err) {
+		s.breaker.Record(nil)
+		return models.Team{}, ErrConflict
+	}
+	if err := s.done("update team", err); err != nil {
+		return models.Team{}, err
+	}
+	return s.TeamByID(ctx, team.ID)
+}
+
+// DeleteTeam removes a team and its memberships. Widgets, rules and
+// assignments scoped to it are left unscoped.
+func (s *Store) DeleteTeam(ctx context.Context, id int64) error {
+	if s.mem != nil {
+		return s.mem.deleteTeam(id)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM teams WHERE id = ?", id)
+	if err := s.done("delete team", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TeamMembers lists the members of a team, or the teams of a user, with the
+// user's name attached. Pass zero for the side not filtered on.
+func (s *Store) TeamMembers(ctx context.Context, teamID, userID int64) ([]models.TeamMember, error) {
+	if s.mem != nil {
+		return s.mem.teamMembers(teamID, userID), nil
+	}
+	query := `
+		SELECT m.team_id, m.user_id, u.username, u.display_name, m.role, m.created_at
+		FROM team_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE 1 = 1`
+	var args []any
+	if teamID != 0 {
+		query += " AND m.team_id = ?"
+		args = append(args, teamID)
+	}
+	if userID != 0 {
+		query += " AND m.user_id = ?"
+		args = append(args, userID)
+	}
+	query += `
+		ORDER BY m.team_id, u.username`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, s.done("team members", err)
+	}
+	defer rows.Close()
+
+	var members []models.TeamMember
+	for rows.Next() {
+		var m models.TeamMember
+		if err := rows.Scan(&m.TeamID, &m.UserID, &m.Username, &m.DisplayName, &m.Role, &m.CreatedAt); err != nil {
+			return nil, s.done("team members", err)
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("team members", err)
+	}
+	s.breaker.Record(nil)
+	return members, nil
+}
+
+// SaveTeamMember adds a user to a team or changes their role in it.
+func (s *Store) SaveTeamMember(ctx context.Context, member models.TeamMember) error {
+	if s.mem != nil {
+		s.mem.saveTeamMember(member)
+		return nil
+	}
+	const query = `
+		INSERT INTO team_members (team_id, user_id, role)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE role = VALUES(role)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, member.TeamID, member.UserID, member.Role)
+	return s.done("save team member", err)
+}
+
+func (s *Store) DeleteTeamMember(ctx context.Context, teamID, userID int64) error {
+	if s.mem != nil {
+		return s.mem.deleteTeamMember(teamID, userID)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM team_members WHERE team_id = ? AND user_id = ?", teamID, userID)
+	if err := s.done("delete team member", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"mydashboard-backend/internal/models"
+)
+
+// tenantSettingsColumn is the part of the settings kept in the settings
+// column.
+type tenantSettingsColumn struct {
+	Name     string   `json:"name,omitempty"`
+	LogoURL  string   `json:"logo_url,omitempty"`
+	Palette  []string `json:"palette,omitempty"`
+	Locale   string   `json:"locale,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+	Currency string   `json:"currency,omitempty"`
+}
+
+func (s *Store) TenantSettings(ctx context.Context, tenant string) (models.TenantSettings, error) {
+	if s.mem != nil {
+		return s.mem.tenantSettings(tenant)
+	}
+	const query = `
+		SELECT settings, updated_at
+		FROM tenant_settings
+		WHERE tenant = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.TenantSettings{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	settings := models.TenantSettings{Tenant: tenant}
+	var raw []byte
+	err := s.db.QueryRowContext(ctx, query, tenant).Scan(&raw, &settings.UpdatedAt)
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.TenantSettings{}, ErrNotFound
+	}
+	if err != nil {
+		return models.TenantSettings{}, s.done("tenant settings", err)
+	}
+	var column tenantSettingsColumn
+	if err := json.Unmarshal(raw, &column); err != nil {
+		return models.TenantSettings{}, s.done("tenant settings", err)
+	}
+	settings.Name, settings.LogoURL, settings.Palette = column.Name, column.LogoURL, column.Palette
+	settings.Locale, settings.Timezone, settings.Currency = column.Locale, column.Timezone, column.Currency
+	s.breaker.Record(nil)
+	return settings, nil
+}
+
+// SaveTenantSettings creates or replaces the settings of settings.Tenant.
+func (s *Store) SaveTenantSettings(ctx context.Context, settings models.TenantSettings) error {
+	if s.mem != nil {
+		s.mem.saveTenantSettings(settings)
+		return nil
+	}
+	const query = `
+		INSERT INTO tenant_settings (tenant, settings)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE settings = VALUES(settings)
+	`
+	raw, err := json.Marshal(tenantSettingsColumn{
+		Name:     settings.Name,
+		LogoURL:  settings.LogoURL,
+		Palette:  settings.Palette,
+		Locale:   settings.Locale,
+		Timezone: settings.Timezone,
+		Currency: settings.Currency,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, query, settings.Tenant, raw)
+	return s.done("save tenant settings", err)
+}
+
+func (s *Store) DeleteTenantSettings(ctx context.Context, tenant string) error {
+	if s.mem != nil {
+		return s.mem.deleteTenantSettings(tenant)
+	}
+	const query = `
+		DELETE FROM tenant_settings
+		WHERE tenant = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, tenant)
+	if err := s.done("delete tenant settings", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
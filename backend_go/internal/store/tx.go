@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithTx runs fn against a Store whose statements all belong to one
+// transaction, committing if fn returns nil and rolling back otherwise.
+// Methods that open their own transaction, such as InsertInsight, join it,
+// and so does a nested WithTx. The transaction is bounded by ctx rather than
+// the per-query timeout.
+func (s *Store) WithTx(ctx context.Context, fn func(tx *Store) error) error {
+	if s.db.tx != nil || (s.mem != nil && s.mem.undo != nil) {
+		return fn(s)
+	}
+	if s.mem != nil {
+		return s.memoryTx(fn)
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	sqlTx, err := s.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return s.done("begin transaction", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	scoped := *s
+	db := *s.db
+	db.tx = sqlTx
+	scoped.db = &db
+	if err := fn(&scoped); err != nil {
+		if rbErr := sqlTx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback: %v)", err, rbErr)
+		}
+		return err
+	}
+	return s.done("commit transaction", sqlTx.Commit())
+}
+
+// memoryTx undoes the inserts fn made when it fails. Updates and deletes are
+// not undone; the services only roll back batches of inserts.
+func (s *Store) memoryTx(fn func(tx *Store) error) (err error) {
+	scoped := *s
+	scoped.mem = s.mem.begin()
+	defer func() {
+		if p := recover(); p != nil {
+			scoped.mem.rollback()
+			panic(p)
+		}
+		if err != nil {
+			scoped.mem.rollback()
+		}
+	}()
+	if err := fn(&scoped); err != nil {
+		return err
+	}
+	return scoped.mem.commit()
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+func (s *Store) AddUsage(ctx context.Context, counters []models.UsageCounter) error {
+	if s.mem != nil {
+		s.mem.addUsage(counters)
+		return nil
+	}
+	if len(counters) == 0 {
+		return nil
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO api_usage (bucket_start, tenant, api_key, requests, errors, bytes_in, bytes_out) VALUES ")
+	args := make([]any, 0, len(counters)*7)
+	for i, c := range counters {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, c.BucketStart, c.Tenant, c.Caller, c.Requests, c.Errors, c.BytesIn, c.BytesOut)
+	}
+	query.WriteString(`
+		ON DUPLICATE KEY UPDATE
+			requests = requests + VALUES(requests),
+			errors = errors + VALUES(errors),
+			bytes_in = bytes_in + VALUES(bytes_in),
+			bytes_out = bytes_out + VALUES(bytes_out)
+	`)
+	_, err := s.db.ExecContext(ctx, query.String(), args...)
+	return s.done("add usage", err)
+}
+
+// UsageReport sums buckets in [from, to) per tenant, or per tenant and caller
+// when byKey is set, busiest first.
+func (s *Store) UsageReport(ctx context.Context, from, to time.Time, byKey bool) ([]models.UsageRow, error) {
+	if s.mem != nil {
+		return s.mem.usageReport(from, to, byKey), nil
+	}
+	groupBy := "tenant"
+	if byKey {
+		groupBy = "tenant, api_key"
+	}
+	query := `
+		SELECT ` + groupBy + `, SUM(requests), SUM(errors), SUM(bytes_in), SUM(bytes_out)
+		FROM api_usage
+		WHERE bucket_start >= ? AND bucket_start < ?
+		GROUP BY ` + groupBy + `
+		ORDER BY SUM(requests) DESC
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, s.done("usage report", err)
+	}
+	defer rows.Close()
+
+	var report []models.UsageRow
+	for rows.Next() {
+		var row models.UsageRow
+		dest := []any{&row.Tenant}
+		if byKey {
+			dest = append(dest, &row.Caller)
+		}
+		dest = append(dest, &row.Requests, &row.Errors, &row.BytesIn, &row.BytesOut)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, s.done("usage report", err)
+		}
+		report = append(report, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("usage report", err)
+	}
+	s.breaker.Record(nil)
+	return report, nil
+}
@@ -0,0 +1,303 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"mydashboard-backend/internal/models"
+)
+
+const userColumns = "id, username, password_hash, role, display_name, locale, timezone, avatar_url, disabled, totp_enabled, totp_secret, totp_last_step, failed_logins, locked_until, created_at"
+
+func scanUser(row rowScanner) (models.User, error) {
+	var user models.User
+	var secret sql.NullString
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Role,
+		&user.DisplayName,
+		&user.Locale,
+		&user.Timezone,
+		&user.AvatarURL,
+		&user.Disabled,
+		&user.TOTPEnabled,
+		&secret,
+		&user.TOTPLastStep,
+		&user.FailedLogins,
+		&user.LockedUntil,
+		&user.CreatedAt,
+	)
+	user.TOTPSecret = secret.String
+	return user, err
+}
+
+func (s *Store) InsertUser(ctx context.Context, user models.User) (models.User, error) {
+	if s.mem != nil {
+		return s.mem.insertUser(user)
+	}
+	const query = `
+		INSERT INTO users (username, password_hash, role, display_name)
+		VALUES (?, ?, ?, ?)
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.User{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, user.Username, user.PasswordHash, user.Role, user.DisplayName)
+	if isDuplicate(err) {
+		s.breaker.Record(nil)
+		return models.User{}, ErrConflict
+	}
+	if err := s.done("insert user", err); err != nil {
+		return models.User{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.User{}, err
+	}
+	return s.UserByID(ctx, id)
+}
+
+func (s *Store) UserByID(ctx context.Context, id int64) (models.User, error) {
+	if s.mem != nil {
+		return s.mem.user(func(user models.User) bool { return user.ID == id })
+	}
+	return s.userWhere(ctx, "id = ?", id)
+}
+
+func (s *Store) UserByUsername(ctx context.Context, username string) (models.User, error) {
+	if s.mem != nil {
+		return s.mem.user(func(user models.User) bool { return user.Username == username })
+	}
+	return s.userWhere(ctx, "username = ?", username)
+}
+
+func (s *Store) userWhere(ctx context.Context, cond string, arg any) (models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE ` + cond
+	if err := s.breaker.Allow(); err != nil {
+		return models.User{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, arg))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.User{}, ErrNotFound
+	}
+	return user, s.done("user lookup", err)
+}
+
+func (s *Store) ListUsers(ctx context.Context) ([]models.User, error) {
+	if s.mem != nil {
+		return s.mem.listUsers(), nil
+	}
+	const query = `
+		SELECT ` + userColumns + `
+		FROM users
+		ORDER BY id
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list users", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, s.done("list users", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list users", err)
+	}
+	s.breaker.Record(nil)
+	return users, nil
+}
+
+// SetTOTPSecret stores a new, not yet confirmed secret; it never replaces the
+// secret of a user who already has two-factor enabled.
+func (s *Store) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	if s.mem != nil {
+		matched := s.mem.updateUser(userID, func(user *models.User) bool {
+			if user.TOTPEnabled {
+				return false
+			}
+			user.TOTPSecret, user.TOTPLastStep = secret, 0
+			return true
+		})
+		if !matched {
+			return ErrConflict
+		}
+		return nil
+	}
+	const query = `
+		UPDATE users
+		SET totp_secret = ?, totp_last_step = 0
+		WHERE id = ? AND totp_enabled = 0
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, secret, userID)
+	if err := s.done("set totp secret", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// UseTOTPStep records the step of an accepted code and enables two-factor if
+// it was pending. ErrConflict means the code was already used.
+func (s *Store) UseTOTPStep(ctx context.Context, userID, step int64) error {
+	if s.mem != nil {
+		matched := s.mem.updateUser(userID, func(user *models.User) bool {
+			if user.TOTPSecret == "" || user.TOTPLastStep >= step {
+				return false
+			}
+			user.TOTPLastStep, user.TOTPEnabled = step, true
+			return true
+		})
+		if !matched {
+			return ErrConflict
+		}
+		return nil
+	}
+	const query = `
+		UPDATE users
+		SET totp_last_step = ?, totp_enabled = 1
+		WHERE id = ? AND totp_secret IS NOT NULL AND totp_last_step < ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, step, userID, step)
+	if err := s.done("use totp step", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// RecordLoginFailure counts a failed login against a user. The failure that
+// reaches maxFailures locks the account until lockUntil and starts the count
+// again; maxFailures of zero never locks. It returns the updated user.
+func (s *Store) RecordLoginFailure(ctx context.Context, userID int64, maxFailures int, lockUntil time.Time) (models.User, error) {
+	if s.mem != nil {
+		matched := s.mem.updateUser(userID, func(user *models.User) bool {
+			user.FailedLogins++
+			if maxFailures > 0 && user.FailedLogins >= maxFailures {
+				user.FailedLogins, user.LockedUntil = 0, &lockUntil
+			}
+			return true
+		})
+		if !matched {
+			return models.User{}, ErrNotFound
+		}
+		return s.UserByID(ctx, userID)
+	}
+	// MySQL applies the assignments left to right, so locked_until still
+	// sees the old count.
+	const query = `
+		UPDATE users
+		SET locked_until = IF(? > 0 AND failed_logins + 1 >= ?, ?, locked_until),
+			failed_logins = IF(? > 0 AND failed_logins + 1 >= ?, 0, failed_logins + 1)
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.User{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, maxFailures, maxFailures, lockUntil, maxFailures, maxFailures, userID)
+	if err := s.done("record login failure", err); err != nil {
+		return models.User{}, err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return models.User{}, ErrNotFound
+	}
+	return s.UserByID(ctx, userID)
+}
+
+// ClearLoginFailures resets a user's failure count and lifts any lockout.
+func (s *Store) ClearLoginFailures(ctx context.Context, userID int64) error {
+	if s.mem != nil {
+		s.mem.updateUser(userID, func(user *models.User) bool {
+			user.FailedLogins, user.LockedUntil = 0, nil
+			return true
+		})
+		return nil
+	}
+	const query = `
+		UPDATE users
+		SET failed_logins = 0, locked_until = NULL
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return s.done("clear login failures", err)
+}
+
+// UpdateProfile saves the fields users edit about themselves: display name,
+// locale, timezone and avatar.
+func (s *Store) UpdateProfile(ctx context.Context, user models.User) (models.User, error) {
+	if s.mem != nil {
+		matched := s.mem.updateUser(user.ID, func(u *models.User) bool {
+			u.DisplayName, u.Locale, u.Timezone, u.AvatarURL = user.DisplayName, user.Locale, user.Timezone, user.AvatarURL
+			return true
+		})
+		if !matched {
+			return models.User{}, ErrNotFound
+		}
+		return s.UserByID(ctx, user.ID)
+	}
+	const query = `
+		UPDATE users
+		SET display_name = ?, locale = ?, timezone = ?, avatar_url = ?
+		WHERE id = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.User{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, user.DisplayName, user.Locale, user.Timezone, user.AvatarURL, user.ID)
+	if err := s.done("update profile", err); err != nil {
+		return models.User{}, err
+	}
+	return s.UserByID(ctx, user.ID)
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+
+	"mydashboard-backend/internal/models"
+)
+
+// widgetDefinition is the part of a widget kept in the definition column.
+type widgetDefinition struct {
+	Title       string                `json:"title"`
+	Description string                `json:"description,omitempty"`
+	Kind        string                `json:"kind"`
+	Series      []models.WidgetSeries `json:"series"`
+	Params      []models.WidgetParam  `json:"params"`
+	Window      int                   `json:"window,omitempty"`
+}
+
+func scanWidget(row rowScanner) (models.Widget, error) {
+	var widget models.Widget
+	var raw []byte
+	if err := row.Scan(&widget.Name, &raw, &widget.TeamID, &widget.CreatedAt, &widget.UpdatedAt); err != nil {
+		return widget, err
+	}
+	var def widgetDefinition
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return widget, err
+	}
+	widget.Title, widget.Description, widget.Kind = def.Title, def.Description, def.Kind
+	widget.Series, widget.Params, widget.Window = def.Series, def.Params, def.Window
+	return widget, nil
+}
+
+func (s *Store) ListWidgets(ctx context.Context) ([]models.Widget, error) {
+	if s.mem != nil {
+		return s.mem.listWidgets(), nil
+	}
+	const query = `
+		SELECT name, definition, team_id, created_at, updated_at
+		FROM widgets
+		ORDER BY name
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, s.done("list widgets", err)
+	}
+	defer rows.Close()
+
+	var widgets []models.Widget
+	for rows.Next() {
+		widget, err := scanWidget(rows)
+		if err != nil {
+			return nil, s.done("list widgets", err)
+		}
+		widgets = append(widgets, widget)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, s.done("list widgets", err)
+	}
+	s.breaker.Record(nil)
+	return widgets, nil
+}
+
+func (s *Store) WidgetByName(ctx context.Context, name string) (models.Widget, error) {
+	if s.mem != nil {
+		return s.mem.widgetByName(name)
+	}
+	const query = `
+		SELECT name, definition, team_id, created_at, updated_at
+		FROM widgets
+		WHERE name = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return models.Widget{}, err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	widget, err := scanWidget(s.db.QueryRowContext(ctx, query, name))
+	if isNoRows(err) {
+		s.breaker.Record(nil)
+		return models.Widget{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Widget{}, s.done("widget by name", err)
+	}
+	s.breaker.Record(nil)
+	return widget, nil
+}
+
+// SaveWidget creates the widget or replaces its definition.
+func (s *Store) SaveWidget(ctx context.Context, widget models.Widget) error {
+	if s.mem != nil {
+		s.mem.saveWidget(widget)
+		return nil
+	}
+	const query = `
+		INSERT INTO widgets (name, definition, team_id)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE definition = VALUES(definition), team_id = VALUES(team_id)
+	`
+	def, err := json.Marshal(widgetDefinition{
+		Title:       widget.Title,
+		Description: widget.Description,
+		Kind:        widget.Kind,
+		Series:      widget.Series,
+		Params:      widget.Params,
+		Window:      widget.Window,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err = s.db.ExecContext(ctx, query, widget.Name, def, widget.TeamID)
+	return s.done("save widget", err)
+}
+
+func (s *Store) DeleteWidget(ctx context.Context, name string) error {
+	if s.mem != nil {
+		return s.mem.deleteWidget(name)
+	}
+	const query = `
+		DELETE FROM widgets
+		WHERE name = ?
+	`
+	if err := s.breaker.Allow(); err != nil {
+		return err
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, name)
+	if err := s.done("delete widget", err); err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}